@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/spf13/cobra"
 	vfs "github.com/twpayne/go-vfs"
+	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
@@ -27,6 +29,7 @@ var addCmd = &cobra.Command{
 type addCmdConfig struct {
 	force   bool
 	prompt  bool
+	rename  bool
 	options chezmoi.AddOptions
 }
 
@@ -34,12 +37,15 @@ func init() {
 	rootCmd.AddCommand(addCmd)
 
 	persistentFlags := addCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.add.options.Blob, "blob", false, "store file contents in the blob store")
+	persistentFlags.BoolVar(&config.add.options.Compress, "compress", false, "compress files")
 	persistentFlags.BoolVarP(&config.add.options.Empty, "empty", "e", false, "add empty files")
 	persistentFlags.BoolVar(&config.add.options.Encrypt, "encrypt", false, "encrypt files")
 	persistentFlags.BoolVarP(&config.add.force, "force", "f", false, "overwrite source state, even if template would be lost")
 	persistentFlags.BoolVarP(&config.add.options.Exact, "exact", "x", false, "add directories exactly")
 	persistentFlags.BoolVarP(&config.add.prompt, "prompt", "p", false, "prompt before adding")
 	persistentFlags.BoolVarP(&config.add.options.Recursive, "recursive", "r", false, "recurse in to subdirectories")
+	persistentFlags.BoolVar(&config.add.rename, "rename", false, "record moved files as renames without prompting")
 	persistentFlags.BoolVarP(&config.add.options.Template, "template", "T", false, "add files as templates")
 	persistentFlags.BoolVarP(&config.add.options.AutoTemplate, "autotemplate", "a", false, "auto generate the template when adding files as templates")
 
@@ -51,6 +57,7 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 	if c.add.options.AutoTemplate {
 		c.add.options.Template = true
 	}
+	c.add.options.Rename = c.getRenameFunc(c.add.rename)
 
 	ts, err := c.getTargetState(nil)
 	if err != nil {
@@ -109,7 +116,16 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 						c.add.prompt = false
 					}
 				}
-				return ts.Add(c.fs, c.add.options, path, info, c.Follow, c.mutator)
+				addOptions, ok, err := c.checkAddSecrets(cmd, path)
+				switch {
+				case errors.Is(err, errConfirmQuit):
+					panic(&quit) // abort vfs.Walk by panicking
+				case err != nil:
+					return err
+				case !ok:
+					return nil
+				}
+				return ts.Add(c.fs, addOptions, path, info, c.Follow, c.mutator)
 			}); err != nil {
 				return err
 			}
@@ -143,10 +159,66 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 					c.add.prompt = false
 				}
 			}
-			if err := ts.Add(c.fs, c.add.options, path, nil, c.Follow, c.mutator); err != nil {
+			addOptions, ok, err := c.checkAddSecrets(cmd, path)
+			switch {
+			case errors.Is(err, errConfirmQuit):
+				return nil
+			case err != nil:
+				return err
+			case !ok:
+				continue
+			}
+			if err := ts.Add(c.fs, addOptions, path, nil, c.Follow, c.mutator); err != nil {
 				return err
 			}
 		}
 	}
 	return nil
 }
+
+// checkAddSecrets warns if the file at path may contain secrets and, if stdin
+// is a terminal, prompts the user to add it anyway, skip it, or encrypt it. It
+// returns the options to add path with, whether to add it, and errConfirmQuit
+// if the user chooses to quit.
+func (c *Config) checkAddSecrets(cmd *cobra.Command, path string) (chezmoi.AddOptions, bool, error) {
+	addOptions := c.add.options
+	if addOptions.Encrypt {
+		return addOptions, true, nil
+	}
+	var info os.FileInfo
+	var err error
+	if c.Follow {
+		info, err = c.fs.Stat(path)
+	} else {
+		info, err = c.fs.Lstat(path)
+	}
+	if err != nil || !info.Mode().IsRegular() {
+		// Leave reporting errors and adding other types to TargetState.Add.
+		return addOptions, true, nil
+	}
+	contents, err := c.fs.ReadFile(path)
+	if err != nil {
+		return addOptions, false, err
+	}
+	findings := scanForSecrets(contents)
+	if len(findings) == 0 {
+		return addOptions, true, nil
+	}
+	cmd.Println(formatSecretFindings(path, findings))
+	if stdin, ok := c.Stdin.(*os.File); !ok || !terminal.IsTerminal(int(stdin.Fd())) {
+		return addOptions, true, nil
+	}
+	choice, err := c.prompt(fmt.Sprintf("Add %s anyway (y), skip it (n), or encrypt it (e)", path), "yneq")
+	if err != nil {
+		return addOptions, false, err
+	}
+	switch choice {
+	case 'n':
+		return addOptions, false, nil
+	case 'e':
+		addOptions.Encrypt = true
+	case 'q':
+		return addOptions, false, errConfirmQuit
+	}
+	return addOptions, true, nil
+}
@@ -521,6 +521,108 @@ func TestAddCommand(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "rename",
+			args: []string{"/home/user/.bar"},
+			add: addCmdConfig{
+				rename: true,
+			},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bar": "contents",
+					".local/share/chezmoi": map[string]interface{}{
+						"dot_foo": "contents",
+					},
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_foo",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_bar",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("contents"),
+				),
+			},
+		},
+		{
+			name: "rename_old_target_exists",
+			args: []string{"/home/user/.bar"},
+			add: addCmdConfig{
+				rename: true,
+			},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bar": "contents",
+					".foo": "contents",
+					".local/share/chezmoi": map[string]interface{}{
+						"dot_foo": "contents",
+					},
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("contents"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_bar",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("contents"),
+				),
+			},
+		},
+		{
+			name: "rename_without_prompt",
+			args: []string{"/home/user/.bar"},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bar": "contents",
+					".local/share/chezmoi": map[string]interface{}{
+						"dot_foo": "contents",
+					},
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("contents"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_bar",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("contents"),
+				),
+			},
+		},
+		{
+			name: "blob",
+			args: []string{"/home/user/.bar", "/home/user/.foo"},
+			add: addCmdConfig{
+				options: chezmoi.AddOptions{
+					Blob: true,
+				},
+			},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bar":                 "contents",
+					".foo":                 "contents",
+					".local/share/chezmoi": &vfst.Dir{Perm: 0700},
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_bar",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("chezmoi-blob sha256:d1b2a59fbea7e20077af9f91b27e95e865061b270be03ff539ab3b73587882e8\n"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("chezmoi-blob sha256:d1b2a59fbea7e20077af9f91b27e95e865061b270be03ff539ab3b73587882e8\n"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/.chezmoiblobs/d1/d1b2a59fbea7e20077af9f91b27e95e865061b270be03ff539ab3b73587882e8",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("contents"),
+				),
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			fs, cleanup, err := vfst.NewTestFS(tc.root)
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var affectedCmd = &cobra.Command{
+	Use:     "affected",
+	Args:    cobra.NoArgs,
+	Short:   "List the targets affected by changes to templates or data",
+	Long:    mustGetLongHelp("affected"),
+	Example: getExample("affected"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runAffectedCmd,
+}
+
+type affectedCmdConfig struct {
+	data      []string
+	templates []string
+}
+
+func init() {
+	rootCmd.AddCommand(affectedCmd)
+
+	persistentFlags := affectedCmd.PersistentFlags()
+	persistentFlags.StringSliceVar(&config.affected.data, "data", nil, "data key")
+	persistentFlags.StringSliceVar(&config.affected.templates, "template", nil, "template name")
+
+	panicOnError(affectedCmd.MarkPersistentFlagFilename("template"))
+}
+
+func (c *Config) runAffectedCmd(cmd *cobra.Command, args []string) error {
+	if len(c.affected.data) == 0 && len(c.affected.templates) == 0 {
+		return errors.New("at least one of --data or --template must be specified")
+	}
+
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+
+	templateNames := make(map[string]struct{})
+	for _, template := range c.affected.templates {
+		templateName, err := c.getTemplateName(ts, template)
+		if err != nil {
+			return err
+		}
+		templateNames[templateName] = struct{}{}
+	}
+
+	var targetPaths []string
+	for _, entry := range ts.AllEntries() {
+		refs, err := c.getTemplateReferences(ts, entry)
+		if err != nil {
+			return err
+		}
+		if refs == nil || !refs.affectedBy(templateNames, c.affected.data) {
+			continue
+		}
+		targetPaths = append(targetPaths, chezmoi.TargetPath(ts.DestDir, entry.TargetName()))
+	}
+	sort.Strings(targetPaths)
+
+	for _, targetPath := range targetPaths {
+		if _, err := fmt.Fprintln(c.Stdout, targetPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getTemplateName returns the name of template in ts. template may be the name
+// of the template or the path of its file in the .chezmoitemplates directory.
+func (c *Config) getTemplateName(ts *chezmoi.TargetState, template string) (string, error) {
+	if _, ok := ts.Templates[template]; ok {
+		return template, nil
+	}
+	templatePath, err := filepath.Abs(template)
+	if err != nil {
+		return "", err
+	}
+	templateName, err := filepath.Rel(filepath.Join(ts.SourceDir, ".chezmoitemplates"), templatePath)
+	if err == nil {
+		templateName = filepath.ToSlash(templateName)
+		if _, ok := ts.Templates[templateName]; ok {
+			return templateName, nil
+		}
+	}
+	return "", fmt.Errorf("%s: template not found", template)
+}
+
+// affectedBy returns true if refs references any of templateNames or any of
+// dataKeys. A data key is referenced if it, any value inside it, or any value
+// containing it is referenced.
+func (refs *templateReferences) affectedBy(templateNames map[string]struct{}, dataKeys []string) bool {
+	for templateName := range templateNames {
+		if _, ok := refs.templates[templateName]; ok {
+			return true
+		}
+	}
+	for _, dataKey := range dataKeys {
+		dataKey = "." + strings.TrimPrefix(dataKey, ".")
+		for ref := range refs.data {
+			if ref == dataKey || strings.HasPrefix(ref, dataKey+".") || strings.HasPrefix(dataKey, ref+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
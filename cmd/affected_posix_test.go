@@ -0,0 +1,107 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestAffectedCmd(t *testing.T) {
+	root := map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoitemplates": map[string]interface{}{
+				"user":  "{{ .name }} {{ template \"email\" . }}",
+				"email": "{{ .email.work }}",
+				"proxy": "{{ .proxy.host }}",
+			},
+			"dot_bashrc":             "# contents of .bashrc\n",
+			"dot_curlrc.tmpl":        "proxy = {{ includeTemplate \"proxy\" . | trim }}\n",
+			"dot_gitconfig.tmpl":     "{{ template \"user\" . }}\n",
+			"dot_hgrc.tmpl":          "{{ template \"email\" . }}\n",
+			"dot_profile.tmpl":       "{{ .chezmoi.hostname }}\n",
+			"symlink_dot_vimrc.tmpl": "{{ .email | toString }}\n",
+		},
+	}
+	for _, tc := range []struct {
+		name      string
+		data      []string
+		templates []string
+		expected  string
+		wantErr   bool
+	}{
+		{
+			name:      "template",
+			templates: []string{"user"},
+			expected:  "/home/user/.gitconfig\n",
+		},
+		{
+			name:      "nested_template",
+			templates: []string{"/home/user/.local/share/chezmoi/.chezmoitemplates/email"},
+			expected:  "/home/user/.gitconfig\n/home/user/.hgrc\n",
+		},
+		{
+			name:      "include_template",
+			templates: []string{"proxy"},
+			expected:  "/home/user/.curlrc\n",
+		},
+		{
+			name:     "include_template_data",
+			data:     []string{"proxy.host"},
+			expected: "/home/user/.curlrc\n",
+		},
+		{
+			name:     "data",
+			data:     []string{"name"},
+			expected: "/home/user/.gitconfig\n",
+		},
+		{
+			name:     "data_inside",
+			data:     []string{"email"},
+			expected: "/home/user/.gitconfig\n/home/user/.hgrc\n/home/user/.vimrc\n",
+		},
+		{
+			name:     "data_containing",
+			data:     []string{"chezmoi.hostname", "email.home"},
+			expected: "/home/user/.profile\n/home/user/.vimrc\n",
+		},
+		{
+			name:     "none",
+			data:     []string{"unknown"},
+			expected: "",
+		},
+		{
+			name:      "unknown_template",
+			templates: []string{"unknown"},
+			wantErr:   true,
+		},
+		{
+			name:    "no_flags",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(root)
+			require.NoError(t, err)
+			defer cleanup()
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			c.addTemplateFunc("includeTemplate", c.includeTemplateFunc)
+			c.affected = affectedCmdConfig{
+				data:      tc.data,
+				templates: tc.templates,
+			}
+			err = c.runAffectedCmd(nil, nil)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, stdout.String())
+		})
+	}
+}
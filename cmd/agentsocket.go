@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var agentSocketCache = make(map[string]string)
+
+func init() {
+	config.addTemplateFunc("gpgAgentSocket", config.gpgAgentSocketFunc)
+	config.addTemplateFunc("gpgAgentSSHSocket", config.gpgAgentSSHSocketFunc)
+	config.addTemplateFunc("sshAgentSocket", config.sshAgentSocketFunc)
+}
+
+func (c *Config) gpgAgentSocketFunc() string {
+	return c.getGPGAgentSocket("agent-socket", "S.gpg-agent")
+}
+
+func (c *Config) gpgAgentSSHSocketFunc() string {
+	return c.getGPGAgentSocket("agent-ssh-socket", "S.gpg-agent.ssh")
+}
+
+// sshAgentSocketFunc returns the path of the platform's SSH agent socket. It
+// does not use $SSH_AUTH_SOCK, as that is typically a temporary path or the
+// path of a forwarded agent.
+func (c *Config) sshAgentSocketFunc() string {
+	if socket, ok := agentSocketCache["ssh"]; ok {
+		return socket
+	}
+	socket, err := c.getSSHAgentSocket()
+	if err != nil {
+		panic(fmt.Errorf("sshAgentSocket: %w", err))
+	}
+	agentSocketCache["ssh"] = socket
+	return socket
+}
+
+func (c *Config) getSSHAgentSocket() (string, error) {
+	switch {
+	case runtime.GOOS == "darwin":
+		// launchd creates the agent socket on demand in a per-session
+		// directory.
+		name := "launchctl"
+		args := []string{"getenv", "SSH_AUTH_SOCK"}
+		output, err := c.templateFuncCmdOutput(name, args, nil, nil)
+		if err != nil {
+			return "", fmt.Errorf("%s %s: %w", name, chezmoi.ShellQuoteArgs(args), err)
+		}
+		return string(bytes.TrimSpace(output)), nil
+	case c.isWSL():
+		// Relays from WSL to the Windows agent (e.g. npiperelay with socat,
+		// or wsl2-ssh-pageant) conventionally listen on ~/.ssh/agent.sock.
+		return filepath.Join(c.homeDir, ".ssh", "agent.sock"), nil
+	case runtime.GOOS == "windows":
+		return `\\.\pipe\openssh-ssh-agent`, nil
+	default:
+		// ssh-agent.service, shipped by many distributions, listens in the
+		// user's runtime directory.
+		if c.bds.RuntimeDir == "" {
+			return "", fmt.Errorf("runtime directory not set")
+		}
+		return filepath.Join(c.bds.RuntimeDir, "ssh-agent.socket"), nil
+	}
+}
+
+// getGPGAgentSocket returns the gpg-agent socket reported by gpgconf as dir,
+// falling back to filename in the GnuPG home directory if gpgconf is not
+// available.
+func (c *Config) getGPGAgentSocket(dir, filename string) string {
+	if socket, ok := agentSocketCache[dir]; ok {
+		return socket
+	}
+	var socket string
+	output, err := c.templateFuncCmdOutput("gpgconf", []string{"--list-dirs", dir}, nil, nil)
+	if err == nil {
+		socket = string(bytes.TrimSpace(output))
+	} else {
+		gnupgHome := os.Getenv("GNUPGHOME")
+		if gnupgHome == "" {
+			gnupgHome = filepath.Join(c.homeDir, ".gnupg")
+		}
+		socket = filepath.Join(gnupgHome, filename)
+	}
+	agentSocketCache[dir] = socket
+	return socket
+}
+
+// isWSL returns true if chezmoi is running in the Windows Subsystem for Linux.
+func (c *Config) isWSL() bool {
+	kernelInfo, err := getKernelInfo(c.fs)
+	if err != nil {
+		return false
+	}
+	return kernelInfo["wsl"] != ""
+}
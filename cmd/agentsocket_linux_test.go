@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestSSHAgentSocketFunc(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		root          interface{}
+		noRuntimeDir  bool
+		expected      string
+		expectedPanic bool
+	}{
+		{
+			name: "linux",
+			root: map[string]interface{}{
+				"/proc/sys/kernel/osrelease": "5.6.15-300.fc32.x86_64\n",
+			},
+			expected: "/home/user/.run/ssh-agent.socket",
+		},
+		{
+			name: "wsl",
+			root: map[string]interface{}{
+				"/proc/sys/kernel/osrelease": "4.19.104-microsoft-standard\n",
+			},
+			expected: "/home/user/.ssh/agent.sock",
+		},
+		{
+			name: "linux_no_runtime_dir",
+			root: map[string]interface{}{
+				"/proc/sys/kernel/osrelease": "5.6.15-300.fc32.x86_64\n",
+			},
+			noRuntimeDir:  true,
+			expectedPanic: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			agentSocketCache = make(map[string]string)
+			defer func() {
+				agentSocketCache = make(map[string]string)
+			}()
+			c := newTestConfig(fs)
+			if tc.noRuntimeDir {
+				c.bds.RuntimeDir = ""
+			}
+			if tc.expectedPanic {
+				assert.Panics(t, func() {
+					c.sshAgentSocketFunc()
+				})
+				return
+			}
+			assert.Equal(t, tc.expected, c.sshAgentSocketFunc())
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// readAliases returns the aliases defined in the system config file and
+// configFile in fs. Errors are ignored, as they are reported when the config
+// is read.
+func (c *Config) readAliases(fs vfs.FS, configFile string) map[string]string {
+	var configFiles []string
+	if systemConfigFile := c.getSystemConfigFile(fs); systemConfigFile != "" {
+		configFiles = append(configFiles, systemConfigFile)
+	}
+	if _, err := fs.Stat(configFile); err == nil {
+		configFiles = append(configFiles, configFile)
+	}
+	v := viper.New()
+	if len(configFiles) == 0 || readConfigFiles(v, fs, configFiles) != nil {
+		return nil
+	}
+	return v.GetStringMapString("aliases")
+}
+
+// expandAliases returns args with the command, if it is an alias, replaced by
+// the alias's expansion. Aliases may expand to other aliases, but not to
+// themselves. Built-in commands cannot be aliased.
+func expandAliases(cmd *cobra.Command, args []string, aliases map[string]string) ([]string, error) {
+	if len(aliases) == 0 {
+		return args, nil
+	}
+	expanded := make(map[string]bool)
+	for {
+		i := commandIndex(cmd.PersistentFlags(), args)
+		if i == -1 {
+			return args, nil
+		}
+		name := args[i]
+		alias, ok := aliases[strings.ToLower(name)]
+		if !ok || isBuiltinCommand(cmd, name) {
+			return args, nil
+		}
+		if expanded[name] {
+			return nil, fmt.Errorf("%s: recursive alias", name)
+		}
+		expanded[name] = true
+		aliasArgs, err := splitAliasArgs(alias)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(aliasArgs) == 0 {
+			return nil, fmt.Errorf("%s: empty alias", name)
+		}
+		newArgs := make([]string, 0, len(args)-1+len(aliasArgs))
+		newArgs = append(newArgs, args[:i]...)
+		newArgs = append(newArgs, aliasArgs...)
+		newArgs = append(newArgs, args[i+1:]...)
+		args = newArgs
+	}
+}
+
+// commandIndex returns the index of the command in args, skipping the global
+// flags in flags and their values, or -1 if there is no command.
+func commandIndex(flags *pflag.FlagSet, args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var flag *pflag.Flag
+		switch {
+		case arg == "--":
+			return -1
+		case strings.HasPrefix(arg, "--"):
+			if strings.Contains(arg, "=") {
+				continue
+			}
+			flag = flags.Lookup(arg[2:])
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			if len(arg) > 2 {
+				continue
+			}
+			flag = flags.ShorthandLookup(arg[1:])
+		default:
+			return i
+		}
+		if flag != nil && flag.NoOptDefVal == "" {
+			i++
+		}
+	}
+	return -1
+}
+
+// findConfigFileArg returns the value of the --config flag in args, or
+// defaultConfigFile if it is not set.
+func findConfigFileArg(args []string, defaultConfigFile string) string {
+	configFile := defaultConfigFile
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--":
+			return configFile
+		case (arg == "-c" || arg == "--config") && i+1 < len(args):
+			configFile = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--config="):
+			configFile = strings.TrimPrefix(arg, "--config=")
+		case strings.HasPrefix(arg, "-c") && !strings.HasPrefix(arg, "--"):
+			configFile = strings.TrimPrefix(arg, "-c")
+		}
+	}
+	return configFile
+}
+
+// isBuiltinCommand returns true if name is the name or an alias of a
+// subcommand of cmd.
+func isBuiltinCommand(cmd *cobra.Command, name string) bool {
+	for _, subcommand := range cmd.Commands() {
+		if subcommand.Name() == name || subcommand.HasAlias(name) {
+			return true
+		}
+	}
+	return name == "help"
+}
+
+// splitAliasArgs splits s into arguments like a POSIX shell, without any
+// expansions. Arguments are separated by whitespace, and single quotes,
+// double quotes, and backslashes quote characters.
+func splitAliasArgs(s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("%q: unterminated quote or escape", s)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// expandOSArgsAliases sets the arguments of rootCmd to os.Args with any alias
+// expanded.
+func expandOSArgsAliases() error {
+	args := os.Args[1:]
+	defaultConfigFile := config.configFile
+	if configFile := os.Getenv(globalFlagEnvVars["config"]); configFile != "" {
+		defaultConfigFile = configFile
+	}
+	aliases := config.readAliases(vfs.OSFS, findConfigFileArg(args, defaultConfigFile))
+	expandedArgs, err := expandAliases(rootCmd, args, aliases)
+	if err != nil {
+		return err
+	}
+	rootCmd.SetArgs(expandedArgs)
+	return nil
+}
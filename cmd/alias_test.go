@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestExpandAliases(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "chezmoi"}
+	rootCmd.PersistentFlags().StringP("source", "S", "", "")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "")
+	rootCmd.AddCommand(&cobra.Command{Use: "apply"})
+	rootCmd.AddCommand(&cobra.Command{Use: "git"})
+	rootCmd.AddCommand(&cobra.Command{Use: "update"})
+	aliases := map[string]string{
+		"apply": "diff",
+		"loop":  "loop",
+		"ping":  "pong",
+		"pong":  "ping",
+		"quote": `git -- commit -m "two words"`,
+		"sync":  "update --apply",
+		"up":    "sync",
+		"wip":   "git -- add -A",
+	}
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		wantArgs []string
+		wantErr  bool
+	}{
+		{
+			name:     "no_args",
+			args:     []string{},
+			wantArgs: []string{},
+		},
+		{
+			name:     "not_alias",
+			args:     []string{"update", "--apply"},
+			wantArgs: []string{"update", "--apply"},
+		},
+		{
+			name:     "builtin",
+			args:     []string{"apply"},
+			wantArgs: []string{"apply"},
+		},
+		{
+			name:     "alias",
+			args:     []string{"sync", "--verbose"},
+			wantArgs: []string{"update", "--apply", "--verbose"},
+		},
+		{
+			name:     "alias_after_flags",
+			args:     []string{"-v", "--source", "/src", "wip"},
+			wantArgs: []string{"-v", "--source", "/src", "git", "--", "add", "-A"},
+		},
+		{
+			name:     "nested_alias",
+			args:     []string{"up"},
+			wantArgs: []string{"update", "--apply"},
+		},
+		{
+			name:     "quoted_alias",
+			args:     []string{"quote"},
+			wantArgs: []string{"git", "--", "commit", "-m", "two words"},
+		},
+		{
+			name:     "after_double_dash",
+			args:     []string{"--", "sync"},
+			wantArgs: []string{"--", "sync"},
+		},
+		{
+			name:    "recursive_alias",
+			args:    []string{"loop"},
+			wantErr: true,
+		},
+		{
+			name:    "mutually_recursive_aliases",
+			args:    []string{"ping"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			args, err := expandAliases(rootCmd, tc.args, aliases)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantArgs, args)
+		})
+	}
+}
+
+func TestSplitAliasArgs(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		wantArgs []string
+		wantErr  bool
+	}{
+		{s: "", wantArgs: nil},
+		{s: "update --apply", wantArgs: []string{"update", "--apply"}},
+		{s: "  a\tb\n", wantArgs: []string{"a", "b"}},
+		{s: `a "b c" 'd e'`, wantArgs: []string{"a", "b c", "d e"}},
+		{s: `a b\ c`, wantArgs: []string{"a", "b c"}},
+		{s: `a ""`, wantArgs: []string{"a", ""}},
+		{s: `'a\b'`, wantArgs: []string{`a\b`}},
+		{s: `"a`, wantErr: true},
+		{s: `a\`, wantErr: true},
+	} {
+		t.Run(tc.s, func(t *testing.T) {
+			args, err := splitAliasArgs(tc.s)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantArgs, args)
+		})
+	}
+}
+
+func TestReadAliases(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/etc/chezmoi/chezmoi.toml":               "[aliases]\n  sync = \"update\"\n  wip = \"git -- add -A\"\n",
+		"/home/user/.config/chezmoi/chezmoi.toml": "[aliases]\n  sync = \"update --apply\"\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	c := newConfig()
+	c.systemConfigDir = "/etc/chezmoi"
+	assert.Equal(t, map[string]string{
+		"sync": "update --apply",
+		"wip":  "git -- add -A",
+	}, c.readAliases(fs, "/home/user/.config/chezmoi/chezmoi.toml"))
+}
@@ -1,7 +1,16 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
 	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var applyCmd = &cobra.Command{
@@ -13,9 +22,39 @@ var applyCmd = &cobra.Command{
 	RunE:    config.runApplyCmd,
 }
 
+// An entryState records the state of an entry in the destination directory.
+type entryState struct {
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+type applyCmdConfig struct {
+	force       bool
+	interactive bool
+	report      string
+}
+
+// An applyReport summarizes the result of applying the target state.
+type applyReport struct {
+	Time       time.Time `json:"time"`
+	Hostname   string    `json:"hostname"`
+	DryRun     bool      `json:"dryRun"`
+	Written    []string  `json:"written"`
+	Removed    []string  `json:"removed"`
+	Unchanged  []string  `json:"unchanged"`
+	ScriptsRun []string  `json:"scriptsRun"`
+	Errors     []string  `json:"errors"`
+	targets    map[string]struct{}
+}
+
 func init() {
 	rootCmd.AddCommand(applyCmd)
 
+	persistentFlags := applyCmd.PersistentFlags()
+	persistentFlags.BoolVarP(&config.apply.force, "force", "f", false, "overwrite modified files without prompting")
+	persistentFlags.BoolVarP(&config.apply.interactive, "interactive", "i", false, "prompt before applying each changed target")
+	persistentFlags.StringVar(&config.apply.report, "report", "", "write a JSON report to file")
+	panicOnError(applyCmd.MarkPersistentFlagFilename("report"))
+
 	markRemainingZshCompPositionalArgumentsAsFiles(applyCmd, 1)
 }
 
@@ -26,5 +65,195 @@ func (c *Config) runApplyCmd(cmd *cobra.Command, args []string) error {
 	}
 	defer persistentState.Close()
 
-	return c.applyArgs(args, persistentState)
+	if c.apply.interactive {
+		c.confirmApply = c.getConfirmApplyFunc()
+		defer func() {
+			c.confirmApply = nil
+		}()
+	}
+
+	return c.applyArgsAndReport(args, persistentState, c.apply.report, &c.apply.force)
+}
+
+// applyArgsAndReport applies args, records the state of all changed entries,
+// and prints a summary of what was changed. If reportPath is not empty then a
+// JSON report is also written to reportPath. Overwriting files that were
+// modified since they were last applied is confirmed, and applying as root to
+// another user's home directory is refused, unless *force is true.
+func (c *Config) applyArgsAndReport(args []string, persistentState chezmoi.PersistentState, reportPath string, force *bool) error {
+	if !*force && !c.DryRun && c.user == "" && c.remoteDestination == nil {
+		if err := checkDestDirOwner(c.fs, c.DestDir, os.Geteuid(), os.Getenv); err != nil {
+			return err
+		}
+	}
+
+	mutator := chezmoi.NewRecordingMutator(c.mutator)
+	c.mutator = mutator
+	recordingMutators := []*chezmoi.RecordingMutator{mutator}
+	if c.privilegedMutator != nil {
+		privilegedMutator := chezmoi.NewRecordingMutator(c.privilegedMutator)
+		c.privilegedMutator = privilegedMutator
+		recordingMutators = append(recordingMutators, privilegedMutator)
+	}
+	report := newApplyReport(c.DryRun)
+	if c.remoteDestination != nil {
+		report.Hostname = c.remoteDestination.hostname
+	}
+	c.applyReport = report
+	// Files on remote systems are always overwritten, as the state of the
+	// entries applied to them is not recorded.
+	if c.remoteDestination == nil {
+		c.confirmOverwrite = c.getConfirmOverwriteFunc(persistentState, force)
+	}
+	applyErr := c.applyArgs(args, persistentState)
+	c.applyReport = nil
+	c.confirmOverwrite = nil
+	c.lastApplyReport = report
+
+	// If the user chose to quit when confirming an overwrite, stop without an
+	// error.
+	quit := errors.Is(applyErr, errConfirmQuit)
+	if quit {
+		applyErr = nil
+	}
+
+	var appliedTargetPaths []string
+	for _, m := range recordingMutators {
+		for name := range m.Names() {
+			appliedTargetPaths = append(appliedTargetPaths, name)
+		}
+	}
+	sort.Strings(appliedTargetPaths)
+
+	var interruptedErr *chezmoi.InterruptedError
+	interrupted := errors.As(applyErr, &interruptedErr)
+	if !c.DryRun {
+		// Record the state of all changed entries, even if applying failed
+		// part way through. Entries on remote systems are not recorded as their
+		// target paths are not local paths.
+		if c.remoteDestination == nil {
+			value, err := json.Marshal(&entryState{
+				AppliedAt: time.Now().UTC(),
+			})
+			if err != nil {
+				return err
+			}
+			for _, targetPath := range appliedTargetPaths {
+				if err := persistentState.Set(c.entryStateBucket, []byte(targetPath), value); err != nil {
+					return err
+				}
+			}
+		}
+
+		// If applying was interrupted, report what was and was not applied.
+		if interrupted {
+			for _, targetPath := range appliedTargetPaths {
+				fmt.Fprintf(c.Stderr, "applied: %s\n", targetPath)
+			}
+			for _, targetPath := range interruptedErr.TargetPaths {
+				fmt.Fprintf(c.Stderr, "not applied: %s\n", targetPath)
+			}
+		}
+	}
+
+	switch {
+	case quit:
+		report.finish(appliedTargetPaths, nil, false)
+	case applyErr == nil:
+		report.finish(appliedTargetPaths, nil, true)
+	case interrupted:
+		report.finish(appliedTargetPaths, applyErr, true, interruptedErr.TargetPaths...)
+	default:
+		report.finish(appliedTargetPaths, applyErr, false)
+	}
+	fmt.Fprintln(c.Stdout, report.summary())
+	if reportPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := c.fs.WriteFile(reportPath, append(data, '\n'), 0666); err != nil {
+			return err
+		}
+	}
+	return applyErr
+}
+
+// getEntryState returns the state of the entry at targetPath, or nil if it has
+// no state.
+func (c *Config) getEntryState(persistentState chezmoi.PersistentState, targetPath string) (*entryState, error) {
+	value, err := persistentState.Get(c.entryStateBucket, []byte(targetPath))
+	if err != nil || value == nil {
+		return nil, err
+	}
+	var es entryState
+	if err := json.Unmarshal(value, &es); err != nil {
+		return nil, err
+	}
+	return &es, nil
+}
+
+func newApplyReport(dryRun bool) *applyReport {
+	hostname, _ := os.Hostname()
+	return &applyReport{
+		Time:       time.Now().UTC(),
+		Hostname:   hostname,
+		DryRun:     dryRun,
+		Written:    []string{},
+		Removed:    []string{},
+		Unchanged:  []string{},
+		ScriptsRun: []string{},
+		Errors:     []string{},
+		targets:    make(map[string]struct{}),
+	}
+}
+
+// addScriptRun records that the script at targetPath was run.
+func (r *applyReport) addScriptRun(targetPath string) {
+	r.ScriptsRun = append(r.ScriptsRun, targetPath)
+}
+
+// addTargets records the targets of entries, excluding scripts and ignored
+// targets, as targets that are being applied.
+func (r *applyReport) addTargets(ts *chezmoi.TargetState, entries []chezmoi.Entry) {
+	for _, entry := range entries {
+		if _, ok := entry.(*chezmoi.Script); ok || ts.TargetIgnore.Match(entry.TargetName()) {
+			continue
+		}
+		r.targets[chezmoi.TargetPath(ts.DestDir, entry.TargetName())] = struct{}{}
+	}
+}
+
+// finish completes r from the sorted changedTargetPaths and applyErr. If
+// computeUnchanged is true then all targets that were not changed and are not
+// in notAppliedTargetPaths are recorded as unchanged.
+func (r *applyReport) finish(changedTargetPaths []string, applyErr error, computeUnchanged bool, notAppliedTargetPaths ...string) {
+	changed := make(map[string]struct{}, len(changedTargetPaths))
+	for _, targetPath := range changedTargetPaths {
+		changed[targetPath] = struct{}{}
+		if _, ok := r.targets[targetPath]; ok {
+			r.Written = append(r.Written, targetPath)
+		} else {
+			r.Removed = append(r.Removed, targetPath)
+		}
+	}
+	if computeUnchanged {
+		for _, targetPath := range notAppliedTargetPaths {
+			changed[targetPath] = struct{}{}
+		}
+		for targetPath := range r.targets {
+			if _, ok := changed[targetPath]; !ok {
+				r.Unchanged = append(r.Unchanged, targetPath)
+			}
+		}
+		sort.Strings(r.Unchanged)
+	}
+	if applyErr != nil {
+		r.Errors = append(r.Errors, applyErr.Error())
+	}
+}
+
+// summary returns a one line summary of r.
+func (r *applyReport) summary() string {
+	return fmt.Sprintf("written: %d, removed: %d, unchanged: %d, scripts run: %d, errors: %d", len(r.Written), len(r.Removed), len(r.Unchanged), len(r.ScriptsRun), len(r.Errors))
 }
@@ -35,6 +35,35 @@ func getApplyScriptTestCases(tempDir string) []scriptTestCase {
 				),
 			},
 		},
+		{
+			name: "simple_onchange",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/run_onchange_true": "#!/bin/sh\necho foo >>" + filepath.Join(tempDir, "evidence") + "\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("foo\n"),
+				),
+			},
+		},
+		{
+			name: "before_after",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"foo":               "a\n",
+					"run_after_a":       "#!/bin/sh\ncat " + filepath.Join(tempDir, "foo") + " >>" + filepath.Join(tempDir, "evidence") + "\n",
+					"run_b":             "#!/bin/sh\necho b >>" + filepath.Join(tempDir, "evidence") + "\n",
+					"run_once_before_c": "#!/bin/sh\necho c >>" + filepath.Join(tempDir, "evidence") + "\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("c\nb\na\nb\na\nb\na\n"),
+				),
+			},
+		},
 		{
 			name: "template",
 			root: map[string]interface{}{
@@ -82,3 +111,9 @@ func getRunOnceFiles() map[string]interface{} {
 		"/home/user/.local/share/chezmoi/run_once_foo.tmpl": "#!/bin/sh\necho bar >> {{ .TempFile }}\n",
 	}
 }
+
+func getRunOnChangeFiles() map[string]interface{} {
+	return map[string]interface{}{
+		"/home/user/.local/share/chezmoi/run_onchange_foo.tmpl": "#!/bin/sh\necho {{ .Value }} >> {{ .TempFile }}\n",
+	}
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -338,6 +340,26 @@ func TestApplyRemove(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "dont_remove_managed",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiremove": "f*",
+				"/home/user/.local/share/chezmoi/foo/bar":        "# contents of bar\n",
+				"/home/user/fizz":    "# contents of fizz\n",
+				"/home/user/foo/baz": "# contents of baz\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/fizz",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/foo/bar",
+					vfst.TestContentsString("# contents of bar\n"),
+				),
+				vfst.TestPath("/home/user/foo/baz",
+					vfst.TestContentsString("# contents of baz\n"),
+				),
+			},
+		},
 		{
 			name: "remove_subdirectory_first",
 			root: map[string]interface{}{
@@ -439,6 +461,52 @@ func TestApplyRunOnce(t *testing.T) {
 	assert.Equal(t, []byte("bar\n"), actualData)
 }
 
+func TestApplyRunOnChange(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	tempFile := filepath.Join(tempDir, "foo")
+
+	fs, cleanup, err := vfst.NewTestFS(
+		[]interface{}{
+			getRunOnChangeFiles(),
+		},
+	)
+	require.NoError(t, err)
+	defer cleanup()
+
+	// Unlike run once scripts, run on change scripts run again when their
+	// contents change back to a previous value.
+	var want []byte
+	for _, tc := range []struct {
+		value   string
+		wantRun bool
+	}{
+		{value: "foo", wantRun: true},
+		{value: "foo", wantRun: false},
+		{value: "bar", wantRun: true},
+		{value: "foo", wantRun: true},
+	} {
+		c := newTestConfig(
+			fs,
+			withDestDir("/"),
+			withData(map[string]interface{}{
+				"TempFile": tempFile,
+				"Value":    tc.value,
+			}),
+		)
+		require.NoError(t, c.runApplyCmd(nil, nil))
+		if tc.wantRun {
+			want = append(want, []byte(tc.value+"\n")...)
+		}
+		actualData, err := ioutil.ReadFile(tempFile)
+		require.NoError(t, err)
+		assert.Equal(t, want, actualData)
+	}
+}
+
 func TestApplyRemoveEmptySymlink(t *testing.T) {
 	for _, tc := range []struct {
 		name  string
@@ -485,3 +553,101 @@ func TestApplyRemoveEmptySymlink(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyReport(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# contents of .bashrc\n",
+			".old":    "# contents of .old\n",
+			".local/share/chezmoi": map[string]interface{}{
+				".chezmoiremove": ".old\n",
+				"dot_bashrc":     "# contents of .bashrc\n",
+				"dot_vimrc":      "# contents of .vimrc\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withRemove(true),
+		withStdout(stdout),
+	)
+	c.apply.report = "/home/user/report.json"
+	assert.NoError(t, c.runApplyCmd(nil, nil))
+	assert.Equal(t, "written: 1, removed: 1, unchanged: 1, scripts run: 0, errors: 0\n", stdout.String())
+
+	data, err := fs.ReadFile("/home/user/report.json")
+	require.NoError(t, err)
+	var report applyReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.False(t, report.DryRun)
+	assert.Equal(t, []string{filepath.Join("/", "home", "user", ".vimrc")}, report.Written)
+	assert.Equal(t, []string{filepath.Join("/", "home", "user", ".old")}, report.Removed)
+	assert.Equal(t, []string{filepath.Join("/", "home", "user", ".bashrc")}, report.Unchanged)
+	assert.Equal(t, []string{}, report.ScriptsRun)
+	assert.Equal(t, []string{}, report.Errors)
+}
+
+func TestApplyInteractive(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		stdin       string
+		wantWritten []string
+	}{
+		{
+			name:        "yes_no_yes",
+			stdin:       "y\nn\ny\n",
+			wantWritten: []string{".gitconfig", ".zshrc"},
+		},
+		{
+			name:        "diff_then_yes",
+			stdin:       "d\ny\nn\nn\n",
+			wantWritten: []string{".gitconfig"},
+		},
+		{
+			name:        "all",
+			stdin:       "n\na\n",
+			wantWritten: []string{".vimrc", ".zshrc"},
+		},
+		{
+			name:  "quit",
+			stdin: "q\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc": "# contents of .bashrc\n",
+					".local/share/chezmoi": map[string]interface{}{
+						"dot_bashrc":    "# contents of .bashrc\n",
+						"dot_gitconfig": "# contents of .gitconfig\n",
+						"dot_vimrc":     "# contents of .vimrc\n",
+						"dot_zshrc":     "# contents of .zshrc\n",
+					},
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			c := newTestConfig(
+				fs,
+				withStdin(bytes.NewBufferString(tc.stdin)),
+				withStdout(&bytes.Buffer{}),
+			)
+			c.apply.interactive = true
+			require.NoError(t, c.runApplyCmd(nil, nil))
+
+			written := make(map[string]bool)
+			for _, name := range tc.wantWritten {
+				written[name] = true
+			}
+			for _, name := range []string{".gitconfig", ".vimrc", ".zshrc"} {
+				_, err := fs.Stat(filepath.Join("/home/user", name))
+				assert.Equal(t, written[name], err == nil, name)
+			}
+		})
+	}
+}
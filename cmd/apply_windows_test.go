@@ -35,6 +35,34 @@ func getApplyScriptTestCases(tempDir string) []scriptTestCase {
 				),
 			},
 		},
+		{
+			name: "simple_onchange",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/run_onchange_true.bat": "@echo foo>>" + filepath.Join(tempDir, "evidence") + "\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("foo\r\n"),
+				),
+			},
+		},
+		{
+			name: "before_after",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"run_after_a.bat":       "@echo a>>" + filepath.Join(tempDir, "evidence") + "\n",
+					"run_b.bat":             "@echo b>>" + filepath.Join(tempDir, "evidence") + "\n",
+					"run_once_before_c.bat": "@echo c>>" + filepath.Join(tempDir, "evidence") + "\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("c\r\nb\r\na\r\nb\r\na\r\nb\r\na\r\n"),
+				),
+			},
+		},
 		{
 			name: "template",
 			root: map[string]interface{}{
@@ -86,3 +114,9 @@ func getRunOnceFiles() map[string]interface{} {
 		"/home/user/.local/share/chezmoi/run_once_foo.bat.tmpl": "@powershell.exe -NoProfile -NonInteractive -c \"Write-Host -NoNewLine ('bar{0}' -f (0x0A -as [char]))\">> {{ .TempFile }}\n",
 	}
 }
+
+func getRunOnChangeFiles() map[string]interface{} {
+	return map[string]interface{}{
+		"/home/user/.local/share/chezmoi/run_onchange_foo.bat.tmpl": "@powershell.exe -NoProfile -NonInteractive -c \"Write-Host -NoNewLine ('{{ .Value }}{0}' -f (0x0A -as [char]))\">> {{ .TempFile }}\n",
+	}
+}
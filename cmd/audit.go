@@ -0,0 +1,15 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var auditCmd = &cobra.Command{
+	Use:     "audit",
+	Args:    cobra.NoArgs,
+	Short:   "Audit the source state",
+	Long:    mustGetLongHelp("audit"),
+	Example: getExample("audit"),
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var auditExternalsCmd = &cobra.Command{
+	Use:     "externals",
+	Args:    cobra.NoArgs,
+	Short:   "List externals and check them against policy",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runAuditExternalsCmd,
+}
+
+type auditExternalsCmdConfig struct {
+	format  string
+	maxSize int64
+}
+
+// An auditExternal is an external in an audit listing. Size and LastRefreshed
+// are only set for externals that have been downloaded.
+type auditExternal struct {
+	TargetPath    string     `json:"targetPath"`
+	Type          string     `json:"type"`
+	URL           string     `json:"url"`
+	Size          *int64     `json:"size,omitempty"`
+	Checksum      string     `json:"checksum"`
+	LastRefreshed *time.Time `json:"lastRefreshed,omitempty"`
+	Problems      []string   `json:"problems"`
+}
+
+// Checksum statuses.
+const (
+	auditChecksumMismatch    = "mismatch"
+	auditChecksumMissing     = "missing"
+	auditChecksumOK          = "ok"
+	auditChecksumUnsupported = "unsupported"
+	auditChecksumUnverified  = "unverified"
+)
+
+// unpinnedURLRegexp matches URLs that refer to a branch or release that
+// changes over time, rather than to a fixed version.
+var unpinnedURLRegexp = regexp.MustCompile(`(?i)(?:^|[/=@])(?:develop|head|latest|main|master|trunk)(?:$|[/.?&#])`)
+
+func init() {
+	auditCmd.AddCommand(auditExternalsCmd)
+
+	persistentFlags := auditExternalsCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.auditExternals.format, "format", "f", "text", "format (text or JSON)")
+	persistentFlags.Int64Var(&config.auditExternals.maxSize, "max-size", 0, "maximum size in bytes, zero for no limit")
+}
+
+func (c *Config) runAuditExternalsCmd(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(c.auditExternals.format)
+	switch format {
+	case "json", "text":
+	default:
+		return fmt.Errorf("%s: unknown format", c.auditExternals.format)
+	}
+
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+
+	var auditExternals []*auditExternal
+	problems := 0
+	for _, entry := range ts.AllEntries() {
+		external, ok := entry.(*chezmoi.External)
+		if !ok || ts.TargetIgnore.Match(external.TargetName()) {
+			continue
+		}
+		auditExternal, err := c.auditExternal(ts, external)
+		if err != nil {
+			return err
+		}
+		problems += len(auditExternal.Problems)
+		auditExternals = append(auditExternals, auditExternal)
+	}
+	sort.Slice(auditExternals, func(i, j int) bool {
+		return auditExternals[i].TargetPath < auditExternals[j].TargetPath
+	})
+
+	if format == "json" {
+		if auditExternals == nil {
+			auditExternals = []*auditExternal{}
+		}
+		if err := formatMap["json"](c.Stdout, auditExternals); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(c.Stdout, 0, 8, 1, ' ', 0)
+		for _, auditExternal := range auditExternals {
+			size := "-"
+			if auditExternal.Size != nil {
+				size = strconv.FormatInt(*auditExternal.Size, 10)
+			}
+			lastRefreshed := "-"
+			if auditExternal.LastRefreshed != nil {
+				lastRefreshed = auditExternal.LastRefreshed.Format(time.RFC3339)
+			}
+			problems := strings.Join(auditExternal.Problems, ",")
+			if problems == "" {
+				problems = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", auditExternal.Type, size, auditExternal.Checksum, lastRefreshed, problems, auditExternal.TargetPath, auditExternal.URL)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if problems != 0 {
+		return fmt.Errorf("found %d problem(s) in externals", problems)
+	}
+	return nil
+}
+
+// auditExternal returns the audit listing of external. Files and archives are
+// checked using the on-disk cache and are not downloaded. Git repositories are
+// checked using their clone in the destination directory.
+func (c *Config) auditExternal(ts *chezmoi.TargetState, external *chezmoi.External) (*auditExternal, error) {
+	targetPath := chezmoi.TargetPath(ts.DestDir, external.TargetName())
+	auditExternal := &auditExternal{
+		TargetPath: targetPath,
+		Type:       external.Type,
+		URL:        external.URL,
+		Problems:   []string{},
+	}
+
+	if u, err := url.Parse(external.URL); err == nil && u.Scheme == "http" {
+		auditExternal.Problems = append(auditExternal.Problems, "insecure URL")
+	}
+	// git-repo externals follow the repository's default branch unless they
+	// are locked to a commit.
+	if external.Type == chezmoi.ExternalTypeGitRepo && external.Commit == "" || unpinnedURLRegexp.MatchString(external.URL) {
+		auditExternal.Problems = append(auditExternal.Problems, "unpinned URL")
+	}
+
+	if external.Type == chezmoi.ExternalTypeGitRepo {
+		auditExternal.Checksum = auditChecksumUnsupported
+		switch info, err := c.fs.Stat(filepath.Join(targetPath, ".git", "FETCH_HEAD")); {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, err
+		default:
+			modTime := info.ModTime()
+			auditExternal.LastRefreshed = &modTime
+		}
+		return auditExternal, nil
+	}
+
+	cacheFilename := c.externalCacheFilename(external.URL)
+	var data []byte
+	switch info, err := c.fs.Stat(cacheFilename); {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		data, err = c.fs.ReadFile(cacheFilename)
+		if err != nil {
+			return nil, err
+		}
+		size := int64(len(data))
+		modTime := info.ModTime()
+		auditExternal.Size = &size
+		auditExternal.LastRefreshed = &modTime
+		if c.auditExternals.maxSize != 0 && size > c.auditExternals.maxSize {
+			auditExternal.Problems = append(auditExternal.Problems, "too large")
+		}
+	}
+
+	switch {
+	case external.Checksum == "":
+		auditExternal.Checksum = auditChecksumMissing
+		auditExternal.Problems = append(auditExternal.Problems, "missing checksum")
+	case auditExternal.Size == nil:
+		auditExternal.Checksum = auditChecksumUnverified
+	case external.Verify(data) != nil:
+		auditExternal.Checksum = auditChecksumMismatch
+		auditExternal.Problems = append(auditExternal.Problems, "checksum mismatch")
+	default:
+		auditExternal.Checksum = auditChecksumOK
+	}
+
+	return auditExternal, nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestAuditExternalsCmd(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".local/share/chezmoi/.chezmoiexternal.toml": "" +
+				"[\".bin/tool\"]\n" +
+				"    type = \"file\"\n" +
+				"    url = \"http://example.com/tool-1.0\"\n" +
+				"    checksum = \"0000000000000000000000000000000000000000000000000000000000000000\"\n" +
+				"[\".oh-my-zsh\"]\n" +
+				"    type = \"archive\"\n" +
+				"    url = \"https://github.com/ohmyzsh/ohmyzsh/archive/master.tar.gz\"\n" +
+				"[\".vim/autoload/plug.vim\"]\n" +
+				"    type = \"file\"\n" +
+				"    url = \"https://raw.githubusercontent.com/junegunn/vim-plug/0.11.0/plug.vim\"\n" +
+				"    checksum = \"0daf0c9ca37fec6e1d5a340073fb43a19c89c50c02827c9991295f89987c7c90\"\n" +
+				"[\".zsh/plugin\"]\n" +
+				"    type = \"git-repo\"\n" +
+				"    url = \"https://github.com/example/plugin.git\"\n",
+			".zsh/plugin/.git/FETCH_HEAD": "",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs, withStdout(stdout))
+	c.auditExternals = auditExternalsCmdConfig{
+		format:  "json",
+		maxSize: 4,
+	}
+	for url, contents := range map[string]string{
+		"http://example.com/tool-1.0":                                         "tool contents",
+		"https://raw.githubusercontent.com/junegunn/vim-plug/0.11.0/plug.vim": "plug",
+	} {
+		cacheFilename := c.externalCacheFilename(url)
+		require.NoError(t, vfs.MkdirAll(fs, filepath.Dir(cacheFilename), 0700))
+		require.NoError(t, fs.WriteFile(cacheFilename, []byte(contents), 0600))
+	}
+
+	assert.EqualError(t, c.runAuditExternalsCmd(nil, nil), "found 6 problem(s) in externals")
+
+	var actual []*auditExternal
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &actual))
+	for _, auditExternal := range actual {
+		switch auditExternal.TargetPath {
+		case "/home/user/.oh-my-zsh":
+			assert.Nil(t, auditExternal.LastRefreshed)
+		default:
+			assert.NotNil(t, auditExternal.LastRefreshed)
+			auditExternal.LastRefreshed = nil
+		}
+	}
+	assert.Equal(t, []*auditExternal{
+		{
+			TargetPath: "/home/user/.bin/tool",
+			Type:       "file",
+			URL:        "http://example.com/tool-1.0",
+			Size:       int64Ptr(13),
+			Checksum:   auditChecksumMismatch,
+			Problems:   []string{"insecure URL", "too large", "checksum mismatch"},
+		},
+		{
+			TargetPath: "/home/user/.oh-my-zsh",
+			Type:       "archive",
+			URL:        "https://github.com/ohmyzsh/ohmyzsh/archive/master.tar.gz",
+			Checksum:   auditChecksumMissing,
+			Problems:   []string{"unpinned URL", "missing checksum"},
+		},
+		{
+			TargetPath: "/home/user/.vim/autoload/plug.vim",
+			Type:       "file",
+			URL:        "https://raw.githubusercontent.com/junegunn/vim-plug/0.11.0/plug.vim",
+			Size:       int64Ptr(4),
+			Checksum:   auditChecksumOK,
+			Problems:   []string{},
+		},
+		{
+			TargetPath: "/home/user/.zsh/plugin",
+			Type:       "git-repo",
+			URL:        "https://github.com/example/plugin.git",
+			Checksum:   auditChecksumUnsupported,
+			Problems:   []string{"unpinned URL"},
+		},
+	}, actual)
+}
+
+func TestUnpinnedURLRegexp(t *testing.T) {
+	for url, expected := range map[string]bool{
+		"https://github.com/ohmyzsh/ohmyzsh/archive/master.tar.gz":              true,
+		"https://github.com/ohmyzsh/ohmyzsh/archive/refs/heads/main.zip":        true,
+		"https://github.com/cli/cli/releases/latest/download/gh.tar.gz":         true,
+		"https://raw.githubusercontent.com/junegunn/vim-plug/HEAD/plug.vim":     true,
+		"https://example.com/download?version=latest":                           true,
+		"https://github.com/ohmyzsh/ohmyzsh/archive/1a2b3c4d.tar.gz":            false,
+		"https://raw.githubusercontent.com/junegunn/vim-plug/0.11.0/plug.vim":   false,
+		"https://github.com/example/mainframe/releases/download/v1.0/mf.tar.gz": false,
+	} {
+		assert.Equal(t, expected, unpinnedURLRegexp.MatchString(url), url)
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
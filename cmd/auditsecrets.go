@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var auditSecretsCmd = &cobra.Command{
+	Use:     "secrets",
+	Args:    cobra.NoArgs,
+	Short:   "Scan the source state for secrets",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runAuditSecretsCmd,
+}
+
+type auditSecretsCmdConfig struct {
+	format   string
+	rendered bool
+}
+
+// An auditSecretFinding is a likely secret in a source file.
+type auditSecretFinding struct {
+	SourcePath string `json:"sourcePath"`
+	Kind       string `json:"kind"`
+	secretFinding
+}
+
+func init() {
+	auditCmd.AddCommand(auditSecretsCmd)
+
+	persistentFlags := auditSecretsCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.auditSecrets.format, "format", "f", "text", "format (text or JSON)")
+	persistentFlags.BoolVar(&config.auditSecrets.rendered, "rendered", false, "also scan rendered templates")
+}
+
+func (c *Config) runAuditSecretsCmd(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(c.auditSecrets.format)
+	switch format {
+	case "json", "text":
+	default:
+		return fmt.Errorf("%s: unknown format", c.auditSecrets.format)
+	}
+
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+
+	findings, err := c.findSourceSecrets(ts)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		if findings == nil {
+			findings = []auditSecretFinding{}
+		}
+		if err := formatMap["json"](c.Stdout, findings); err != nil {
+			return err
+		}
+	} else {
+		for _, finding := range findings {
+			fmt.Fprintf(c.Stdout, "%s: %s: %s\n", finding.SourcePath, finding.Kind, finding.secretFinding)
+		}
+	}
+
+	if len(findings) != 0 {
+		return fmt.Errorf("found %d likely secret(s) in source state", len(findings))
+	}
+	return nil
+}
+
+// findSourceSecrets returns the likely secrets in ts, sorted by source path. Raw
+// source files are scanned, except for encrypted and compressed files and the
+// SOPS data file. If c.auditSecrets.rendered is set, the contents of templates
+// and compressed files are also scanned. Templates that cannot be rendered are
+// reported as warnings.
+func (c *Config) findSourceSecrets(ts *chezmoi.TargetState) ([]auditSecretFinding, error) {
+	skipSourceNames := map[string]bool{
+		chezmoi.SOPSDataName: true,
+	}
+	var renderedEntries []chezmoi.Entry
+	for _, entry := range ts.AllEntries() {
+		switch entry := entry.(type) {
+		case *chezmoi.File:
+			if entry.Encrypted || entry.Compressed {
+				skipSourceNames[entry.SourceName()] = true
+			}
+			if !entry.Encrypted && (entry.Template || entry.Compressed) {
+				renderedEntries = append(renderedEntries, entry)
+			}
+		case *chezmoi.Script:
+			if entry.Template {
+				renderedEntries = append(renderedEntries, entry)
+			}
+		}
+	}
+
+	var findings []auditSecretFinding
+	if err := vfs.Walk(c.fs, ts.SourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			switch info.Name() {
+			case ".git", ".hg", ".chezmoiblobs":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		sourceName, err := filepath.Rel(ts.SourceDir, path)
+		if err != nil {
+			return err
+		}
+		if skipSourceNames[sourceName] {
+			return nil
+		}
+		contents, _, err := ts.ReadSourceFile(c.fs, sourceName)
+		if err != nil {
+			return err
+		}
+		for _, finding := range scanForSecrets(contents) {
+			findings = append(findings, auditSecretFinding{
+				SourcePath:    filepath.ToSlash(sourceName),
+				Kind:          "raw",
+				secretFinding: finding,
+			})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if c.auditSecrets.rendered {
+		for _, entry := range renderedEntries {
+			var contents []byte
+			var err error
+			switch entry := entry.(type) {
+			case *chezmoi.File:
+				contents, err = entry.Contents()
+			case *chezmoi.Script:
+				contents, err = entry.Contents()
+			}
+			if err != nil {
+				fmt.Fprintf(c.Stderr, "warning: %s: %v\n", entry.SourceName(), err)
+				continue
+			}
+			for _, finding := range scanForSecrets(contents) {
+				findings = append(findings, auditSecretFinding{
+					SourcePath:    filepath.ToSlash(entry.SourceName()),
+					Kind:          "rendered",
+					secretFinding: finding,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].SourcePath < findings[j].SourcePath
+	})
+	return findings, nil
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	vfs "github.com/twpayne/go-vfs"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+type authorizedKeysConfig struct {
+	Fingerprints  map[string][]string
+	RefreshPeriod time.Duration
+	URLs          map[string]string
+}
+
+var (
+	defaultAuthorizedKeysURLs = map[string]string{
+		"github": "https://github.com/%s.keys",
+		"gitlab": "https://gitlab.com/%s.keys",
+	}
+
+	// authorizedKeysNameRegexp matches valid forges and usernames, which are
+	// used as components of cache filenames.
+	authorizedKeysNameRegexp = regexp.MustCompile(`\A[A-Za-z0-9_.-]+\z`)
+
+	authorizedKeysCache = make(map[string][]string)
+)
+
+func init() {
+	config.addTemplateFunc("authorizedKeys", config.authorizedKeysFunc)
+}
+
+// authorizedKeysFunc returns the public keys of the forge users in specs, in
+// authorized_keys format. Each spec has the form forge:username. It panics,
+// causing template execution to fail, if the keys for any user cannot be
+// fetched or verified, so the target file is never replaced with a truncated
+// list of keys.
+func (c *Config) authorizedKeysFunc(specs ...string) string {
+	if len(specs) == 0 {
+		panic(fmt.Errorf("authorizedKeys: no users specified"))
+	}
+	b := &strings.Builder{}
+	for _, spec := range specs {
+		keys, err := c.getAuthorizedKeys(spec)
+		if err != nil {
+			panic(fmt.Errorf("authorizedKeys: %s: %w", spec, err))
+		}
+		fmt.Fprintf(b, "# %s\n", spec)
+		for _, key := range keys {
+			b.WriteString(key)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// getAuthorizedKeys returns the verified keys for spec, using the in-memory
+// cache, the external lock, and the on-disk cache where possible.
+func (c *Config) getAuthorizedKeys(spec string) ([]string, error) {
+	return c.resolveAuthorizedKeys(spec, false)
+}
+
+// resolveAuthorizedKeys returns the verified keys for spec. Keys that are
+// fetched are locked. If refresh is true then the keys are always fetched.
+func (c *Config) resolveAuthorizedKeys(spec string, refresh bool) ([]string, error) {
+	spec = strings.ToLower(spec)
+	if keys, ok := authorizedKeysCache[spec]; ok && !refresh {
+		return keys, nil
+	}
+	components := strings.SplitN(spec, ":", 2)
+	if len(components) != 2 || components[0] == "" || components[1] == "" {
+		return nil, fmt.Errorf("expected forge:username")
+	}
+	forge, username := components[0], components[1]
+	if !isValidAuthorizedKeysName(forge) {
+		return nil, fmt.Errorf("%s: invalid forge", forge)
+	}
+	if !isValidAuthorizedKeysName(username) {
+		return nil, fmt.Errorf("%s: invalid username", username)
+	}
+
+	externalLock, err := c.getExternalLock()
+	if err != nil {
+		return nil, err
+	}
+	lockKey := "authorizedKeys " + spec
+	if value, ok := externalLock.Values[lockKey]; ok && !refresh {
+		keys, err := verifyAuthorizedKeys([]byte(value), c.AuthorizedKeys.Fingerprints[spec])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", chezmoi.ExternalLockName, err)
+		}
+		authorizedKeysCache[spec] = keys
+		return keys, nil
+	}
+
+	cacheFilename := filepath.Join(c.bds.CacheHome, "chezmoi", "authorizedkeys", forge, username)
+	var data []byte
+	if !refresh {
+		if data, err = c.readAuthorizedKeysCache(cacheFilename); err != nil {
+			return nil, err
+		}
+	}
+	fromCache := data != nil
+	if !fromCache {
+		if data, err = c.fetchAuthorizedKeys(forge, username); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := verifyAuthorizedKeys(data, c.AuthorizedKeys.Fingerprints[spec])
+	if err != nil {
+		return nil, err
+	}
+
+	if !fromCache && c.AuthorizedKeys.RefreshPeriod > 0 {
+		if err := vfs.MkdirAll(c.fs, filepath.Dir(cacheFilename), 0700); err != nil {
+			return nil, err
+		}
+		if err := c.fs.WriteFile(cacheFilename, data, 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	externalLock.Values[lockKey] = strings.Join(keys, "\n") + "\n"
+	if err := c.writeExternalLock(); err != nil {
+		return nil, err
+	}
+
+	authorizedKeysCache[spec] = keys
+	return keys, nil
+}
+
+// readAuthorizedKeysCache returns the contents of cacheFilename if it was
+// written within the refresh period, or nil otherwise.
+func (c *Config) readAuthorizedKeysCache(cacheFilename string) ([]byte, error) {
+	if c.AuthorizedKeys.RefreshPeriod <= 0 {
+		return nil, nil
+	}
+	info, err := c.fs.Stat(cacheFilename)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case time.Since(info.ModTime()) > c.AuthorizedKeys.RefreshPeriod:
+		return nil, nil
+	}
+	return c.fs.ReadFile(cacheFilename)
+}
+
+func (c *Config) fetchAuthorizedKeys(forge, username string) ([]byte, error) {
+	urlFormat, ok := c.AuthorizedKeys.URLs[forge]
+	if !ok {
+		if urlFormat, ok = defaultAuthorizedKeysURLs[forge]; !ok {
+			return nil, fmt.Errorf("%s: unknown forge", forge)
+		}
+	}
+	keysURL := fmt.Sprintf(urlFormat, url.PathEscape(username))
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	resp, err := client.Get(keysURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", keysURL, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// verifyAuthorizedKeys parses the authorized keys in data and returns them
+// normalized, one per element. If fingerprints is not empty then every key
+// must have one of the SHA256 fingerprints in fingerprints.
+func verifyAuthorizedKeys(data []byte, fingerprints []string) ([]string, error) {
+	allowedFingerprints := make(map[string]bool, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		allowedFingerprints[fingerprint] = true
+	}
+	var keys []string
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		publicKey, comment, _, _, err := ssh.ParseAuthorizedKey(line)
+		if err != nil {
+			return nil, err
+		}
+		if fingerprint := ssh.FingerprintSHA256(publicKey); len(allowedFingerprints) != 0 && !allowedFingerprints[fingerprint] {
+			return nil, fmt.Errorf("%s: unexpected key fingerprint", fingerprint)
+		}
+		key := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(publicKey)), "\n")
+		if comment != "" {
+			key += " " + comment
+		}
+		keys = append(keys, key)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys found")
+	}
+	return keys, nil
+}
+
+// isValidAuthorizedKeysName returns true if name is a valid forge or username.
+func isValidAuthorizedKeysName(name string) bool {
+	return name != "." && name != ".." && authorizedKeysNameRegexp.MatchString(name)
+}
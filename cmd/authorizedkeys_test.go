@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestAuthorizedKeysFunc(t *testing.T) {
+	publicKey1, fingerprint1 := newTestSSHPublicKey(t)
+	publicKey2, fingerprint2 := newTestSSHPublicKey(t)
+	_, fingerprint3 := newTestSSHPublicKey(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/alice.keys":
+			_, _ = w.Write([]byte(publicKey1 + "\n" + publicKey2 + " alice@example.com\n"))
+		case "/empty.keys":
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name          string
+		specs         []string
+		fingerprints  map[string][]string
+		refreshPeriod time.Duration
+		expected      string
+		wantPanic     bool
+	}{
+		{
+			name:     "fetch",
+			specs:    []string{"test:alice"},
+			expected: "# test:alice\n" + publicKey1 + "\n" + publicKey2 + " alice@example.com\n",
+		},
+		{
+			name:  "fingerprints",
+			specs: []string{"test:alice"},
+			fingerprints: map[string][]string{
+				"test:alice": {fingerprint1, fingerprint2},
+			},
+			expected: "# test:alice\n" + publicKey1 + "\n" + publicKey2 + " alice@example.com\n",
+		},
+		{
+			name:          "cached",
+			specs:         []string{"test:alice"},
+			refreshPeriod: time.Hour,
+			expected:      "# test:alice\n" + publicKey1 + "\n" + publicKey2 + " alice@example.com\n",
+		},
+		{
+			name:  "unexpected_fingerprint",
+			specs: []string{"test:alice"},
+			fingerprints: map[string][]string{
+				"test:alice": {fingerprint1, fingerprint3},
+			},
+			wantPanic: true,
+		},
+		{
+			name:      "not_found",
+			specs:     []string{"test:alice", "test:bob"},
+			wantPanic: true,
+		},
+		{
+			name:      "no_keys",
+			specs:     []string{"test:empty"},
+			wantPanic: true,
+		},
+		{
+			name:      "unknown_forge",
+			specs:     []string{"unknown:alice"},
+			wantPanic: true,
+		},
+		{
+			name:      "invalid_forge",
+			specs:     []string{"..:alice"},
+			wantPanic: true,
+		},
+		{
+			name:      "invalid_username",
+			specs:     []string{"test:.."},
+			wantPanic: true,
+		},
+		{
+			name:      "invalid_username_characters",
+			specs:     []string{"test:alice?bob"},
+			wantPanic: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": &vfst.Dir{Perm: 0755},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(fs)
+			c.AuthorizedKeys = authorizedKeysConfig{
+				Fingerprints:  tc.fingerprints,
+				RefreshPeriod: tc.refreshPeriod,
+				URLs: map[string]string{
+					"test": server.URL + "/%s.keys",
+				},
+			}
+			authorizedKeysCache = make(map[string][]string)
+			defer func() {
+				authorizedKeysCache = make(map[string][]string)
+			}()
+			if tc.wantPanic {
+				assert.Panics(t, func() {
+					c.authorizedKeysFunc(tc.specs...)
+				})
+				return
+			}
+			assert.Equal(t, tc.expected, c.authorizedKeysFunc(tc.specs...))
+			if tc.refreshPeriod == 0 {
+				return
+			}
+			authorizedKeysCache = make(map[string][]string)
+			requestsBefore := requests
+			assert.Equal(t, tc.expected, c.authorizedKeysFunc(tc.specs...))
+			assert.Equal(t, requestsBefore, requests)
+		})
+	}
+}
+
+func newTestSSHPublicKey(t *testing.T) (string, string) {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	require.NoError(t, err)
+	authorizedKey := string(ssh.MarshalAuthorizedKey(sshPublicKey))
+	return authorizedKey[:len(authorizedKey)-1], ssh.FingerprintSHA256(sshPublicKey)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for _, tc := range []struct {
+		s           string
+		expected    int64
+		expectedErr bool
+	}{
+		{s: "", expected: 0},
+		{s: "0", expected: 0},
+		{s: "1024", expected: 1024},
+		{s: "100B", expected: 100},
+		{s: "500kB", expected: 500000},
+		{s: "1.5MB", expected: 1500000},
+		{s: "2 GB", expected: 2000000000},
+		{s: "1KiB", expected: 1024},
+		{s: "1.5gib", expected: 3 << 29},
+		{s: "1TiB", expected: 1 << 40},
+		{s: "MB", expectedErr: true},
+		{s: "1XB", expectedErr: true},
+		{s: "1.2.3MB", expectedErr: true},
+		{s: "-1MB", expectedErr: true},
+	} {
+		t.Run(tc.s, func(t *testing.T) {
+			actual, err := parseByteSize(tc.s)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -49,6 +49,12 @@ func (c *Config) runCatCmd(cmd *cobra.Command, args []string) error {
 				return err
 			}
 			fmt.Println(linkname)
+		case *chezmoi.Hardlink:
+			linkname, err := entry.Linkname()
+			if err != nil {
+				return err
+			}
+			fmt.Println(linkname)
 		default:
 			return fmt.Errorf("%s: not a file or symlink", args[i])
 		}
@@ -23,9 +23,14 @@ var chattrCmd = &cobra.Command{
 	PostRunE: config.autoCommitAndAutoPush,
 }
 
+type chattrCmdConfig struct {
+	recursive bool
+}
+
 type boolModifier int
 
 type attributeModifiers struct {
+	compress   boolModifier
 	empty      boolModifier
 	encrypt    boolModifier
 	exact      boolModifier
@@ -37,7 +42,11 @@ type attributeModifiers struct {
 func init() {
 	rootCmd.AddCommand(chattrCmd)
 
+	persistentFlags := chattrCmd.PersistentFlags()
+	persistentFlags.BoolVarP(&config.chattr.recursive, "recursive", "r", false, "recurse in to subdirectories")
+
 	attributes := []string{
+		"compress",
 		"empty", "e",
 		"encrypt",
 		"exact",
@@ -68,10 +77,22 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if c.chattr.recursive {
+		var allEntries []chezmoi.Entry
+		for _, entry := range entries {
+			allEntries = entry.AppendAllEntries(allEntries)
+		}
+		entries = allEntries
+	}
 
 	updates := make(map[string]func() error)
 	for _, entry := range entries {
 		dir, oldBase := filepath.Split(entry.SourceName())
+		if hasSourceMeta, err := ts.HasSourceMeta(c.fs, dir); err != nil {
+			return err
+		} else if hasSourceMeta {
+			return fmt.Errorf("%s: attributes are set in .chezmoimeta.yaml", entry.TargetName())
+		}
 		oldpath := filepath.Join(ts.SourceDir, dir, oldBase)
 		switch entry := entry.(type) {
 		case *chezmoi.Dir:
@@ -99,25 +120,31 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 				mode &= 0700
 			}
 			fa.Mode = mode
+			fa.Compressed = ams.compress.modify(entry.Compressed)
 			fa.Encrypted = ams.encrypt.modify(entry.Encrypted)
 			fa.Empty = ams.empty.modify(entry.Empty)
 			fa.Template = ams.template.modify(entry.Template)
 			newpath := filepath.Join(ts.SourceDir, dir, fa.SourceName())
-			if fa.Encrypted != entry.Encrypted {
-				oldContents, err := c.fs.ReadFile(filepath.Join(c.SourceDir, entry.SourceName()))
+			if fa.Compressed != entry.Compressed || fa.Encrypted != entry.Encrypted {
+				oldContents, blob, err := ts.ReadSourceFile(c.fs, entry.SourceName())
 				if err != nil {
 					return err
 				}
-				var newContents []byte
-				if fa.Encrypted {
-					newContents, err = ts.GPG.Encrypt(entry.TargetName(), oldContents)
-				} else {
-					newContents, err = ts.GPG.Decrypt(entry.TargetName(), oldContents)
+				contents, err := ts.DecodeContents(entry.TargetName(), entry.Compressed, entry.Encrypted, oldContents)
+				if err != nil {
+					return err
 				}
+				newContents, err := ts.EncodeContents(entry.TargetName(), fa.Compressed, fa.Encrypted, contents)
 				if err != nil {
 					return err
 				}
 				updates[oldpath] = func() error {
+					if blob {
+						var err error
+						if newContents, err = ts.AddBlob(c.fs, newContents, c.mutator); err != nil {
+							return err
+						}
+					}
 					// FIXME replace file and contents atomically, see
 					// https://github.com/google/renameio/issues/16.
 					if err := c.mutator.WriteFile(newpath, newContents, 0644, oldContents); err != nil {
@@ -184,6 +211,8 @@ func parseAttributeModifiers(s string) (*attributeModifiers, error) {
 			attribute = attributeModifier
 		}
 		switch attribute {
+		case "compress":
+			ams.compress = modifier
 		case "empty", "e":
 			ams.empty = modifier
 		case "encrypt":
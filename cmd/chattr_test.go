@@ -10,10 +10,11 @@ import (
 
 func TestChattrCommand(t *testing.T) {
 	for _, tc := range []struct {
-		name  string
-		args  []string
-		root  interface{}
-		tests interface{}
+		name      string
+		args      []string
+		recursive bool
+		root      interface{}
+		tests     interface{}
 	}{
 		{
 			name: "dir_add_exact",
@@ -283,12 +284,51 @@ func TestChattrCommand(t *testing.T) {
 				),
 			},
 		},
+		{
+			name:      "recursive_add_private",
+			args:      []string{"+private", "/home/user/.ssh"},
+			recursive: true,
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_ssh": map[string]interface{}{
+					"config":          "# contents of ~/.ssh/config\n",
+					"private_id_rsa":  "# contents of ~/.ssh/id_rsa\n",
+					"dir/symlink_foo": "bar",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_ssh",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_ssh/private_config",
+					vfst.TestContentsString("# contents of ~/.ssh/config\n"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_ssh/private_id_rsa",
+					vfst.TestContentsString("# contents of ~/.ssh/id_rsa\n"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_ssh/private_dir/symlink_foo",
+					vfst.TestContentsString("bar"),
+				),
+			},
+		},
+		{
+			name: "non_recursive_add_private",
+			args: []string{"+private", "/home/user/.ssh"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_ssh/config": "# contents of ~/.ssh/config\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_ssh/config",
+					vfst.TestContentsString("# contents of ~/.ssh/config\n"),
+				),
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			fs, cleanup, err := vfst.NewTestFS(tc.root)
 			require.NoError(t, err)
 			defer cleanup()
 			c := newTestConfig(fs)
+			c.chattr.recursive = tc.recursive
 			assert.NoError(t, c.runChattrCmd(nil, tc.args))
 			vfst.RunTests(t, fs, "", tc.tests)
 		})
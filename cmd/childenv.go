@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+	"runtime"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// getChildEnv returns the environment variables that describe the running
+// chezmoi command to child processes, for example editors, scripts, diff and
+// merge tools, and the cd shell. If proxy.url is set then the proxy
+// environment variables are also set, so that child processes that access the
+// network, like git, use the same proxy as chezmoi.
+func (c *Config) getChildEnv(cmd *cobra.Command) map[string]string {
+	env := map[string]string{
+		"CHEZMOI":             "1",
+		"CHEZMOI_ARCH":        runtime.GOARCH,
+		"CHEZMOI_COMMAND":     getCommandName(cmd),
+		"CHEZMOI_DESTINATION": c.DestDir,
+		"CHEZMOI_OS":          runtime.GOOS,
+		"CHEZMOI_SOURCE_DIR":  c.SourceDir,
+	}
+	if executable, err := os.Executable(); err == nil {
+		env["CHEZMOI_EXECUTABLE"] = executable
+	}
+	if c.Proxy.URL != "" {
+		for _, key := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+			env[key] = c.Proxy.URL
+		}
+		if c.Proxy.NoProxy != "" {
+			env["NO_PROXY"] = c.Proxy.NoProxy
+			env["no_proxy"] = c.Proxy.NoProxy
+		}
+	}
+	return env
+}
+
+// setChildEnv sets the environment variables returned by getChildEnv in the
+// environment of the current process, so they are inherited by all child
+// processes.
+func (c *Config) setChildEnv(cmd *cobra.Command) error {
+	env := c.getChildEnv(cmd)
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := os.Setenv(key, env[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
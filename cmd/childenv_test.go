@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetChildEnv(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "chezmoi"}
+	fleetCmd := &cobra.Command{Use: "fleet"}
+	fleetApplyCmd := &cobra.Command{Use: "apply"}
+	rootCmd.AddCommand(fleetCmd)
+	fleetCmd.AddCommand(fleetApplyCmd)
+
+	c := newConfig(
+		withTestUser("user"),
+	)
+	env := c.getChildEnv(fleetApplyCmd)
+	assert.Equal(t, "1", env["CHEZMOI"])
+	assert.Equal(t, runtime.GOARCH, env["CHEZMOI_ARCH"])
+	assert.Equal(t, "fleet apply", env["CHEZMOI_COMMAND"])
+	assert.Equal(t, c.DestDir, env["CHEZMOI_DESTINATION"])
+	assert.Equal(t, runtime.GOOS, env["CHEZMOI_OS"])
+	assert.Equal(t, c.SourceDir, env["CHEZMOI_SOURCE_DIR"])
+}
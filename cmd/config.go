@@ -13,8 +13,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 	"unicode"
 
 	"github.com/Masterminds/sprig"
@@ -29,10 +31,19 @@ import (
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
-const commitMessageTemplateAsset = "assets/templates/COMMIT_MESSAGE.tmpl"
+const (
+	commitMessageTemplateAsset = "assets/templates/COMMIT_MESSAGE.tmpl"
+	dataEnvVarPrefix           = "CHEZMOI_DATA_"
+	hostDataDirName            = ".chezmoidata/hosts"
+)
 
 var whitespaceRegexp = regexp.MustCompile(`\s+`)
 
+type destDirOverride struct {
+	From string
+	To   string
+}
+
 type sourceVCSConfig struct {
 	Command    string
 	AutoCommit bool
@@ -42,16 +53,29 @@ type sourceVCSConfig struct {
 	Pull       interface{}
 }
 
+type sudoConfig struct {
+	Command string
+	Args    []string
+}
+
 type templateConfig struct {
-	Options []string
+	Options     []string
+	FuncTimeout time.Duration
+	DisplayData bool
+	NetworkData bool
+	ProxyData   bool
+	State       bool
 }
 
 // A Config represents a configuration.
 type Config struct {
 	configFile        string
+	systemConfigDir   string
+	user              string
 	err               error
 	fs                vfs.FS
 	mutator           chezmoi.Mutator
+	privilegedMutator chezmoi.Mutator
 	SourceDir         string
 	DestDir           string
 	Umask             permValue
@@ -61,27 +85,66 @@ type Config struct {
 	Verbose           bool
 	Color             string
 	Debug             bool
+	Encryption        string
+	Age               chezmoi.Age
 	GPG               chezmoi.GPG
 	GPGRecipient      string
+	LFS               chezmoi.LFS
+	SevenZip          chezmoi.SevenZip
+	SOPS              sopsConfig
+	Xz                chezmoi.Xz
+	Zstd              chezmoi.Zstd
 	SourceVCS         sourceVCSConfig
 	Template          templateConfig
 	Merge             mergeConfig
+	Metrics           metricsConfig
+	NoConfirm         bool
+	FIFOs             bool
+	CrossFileSystems  bool
+	RemoveToTrash     bool
+	AuthorizedKeys    authorizedKeysConfig
+	AWSSecretsManager awsSecretsManagerConfig
+	AzureKeyVault     azureKeyVaultConfig
+	GCPSecretManager  gcpSecretManagerConfig
 	Bitwarden         bitwardenCmdConfig
 	CD                cdCmdConfig
+	DegradedFS        degradedFSConfig
 	Diff              diffCmdConfig
+	Docker            containerConfig
+	Doppler           dopplerCmdConfig
+	Externals         externalsConfig
+	Fleet             fleetCmdConfig
 	GenericSecret     genericSecretCmdConfig
+	Git               gitCmdConfig
+	GitHub            gitHubConfig
 	Gopass            gopassCmdConfig
 	KeePassXC         keePassXCCmdConfig
 	Lastpass          lastpassCmdConfig
+	MachineID         machineIDConfig
 	Onepassword       onepasswordCmdConfig
 	Vault             vaultCmdConfig
 	Pass              passCmdConfig
+	Podman            containerConfig
+	PromptStringOnce  promptStringOnceConfig
+	Proxy             proxyConfig
+	Secret            secretConfig
+	SSH               sshConfig
+	Sudo              sudoConfig
 	Data              map[string]interface{}
+	dataOverrides     map[string]string
+	DestDirOverrides  []destDirOverride
 	colored           bool
 	maxDiffDataSize   int
 	templateFuncs     template.FuncMap
+	includeTemplates  map[string]bool
 	add               addCmdConfig
+	affected          affectedCmdConfig
+	apply             applyCmdConfig
+	auditExternals    auditExternalsCmdConfig
+	auditSecrets      auditSecretsCmdConfig
+	chattr            chattrCmdConfig
 	completion        completionCmdConfig
+	cp                cpCmdConfig
 	data              dataCmdConfig
 	dump              dumpCmdConfig
 	edit              editCmdConfig
@@ -94,11 +157,27 @@ type Config struct {
 	remove            removeCmdConfig
 	update            updateCmdConfig
 	upgrade           upgradeCmdConfig
+	verify            verifyCmdConfig
 	Stdin             io.Reader
 	Stdout            io.Writer
 	Stderr            io.Writer
+	bufferedStdin     *bufio.Reader
 	bds               *xdg.BaseDirectorySpecification
+	homeDir           string
+	entryStateBucket  []byte
 	scriptStateBucket []byte
+	promptOnceBucket  []byte
+	stateFuncsBucket  []byte
+	persistentState   *openPersistentState
+	applyReport       *applyReport
+	confirmApply      func(chezmoi.Entry, chezmoi.PreviewFunc) (bool, error)
+	confirmOverwrite  func(string, os.FileInfo) (bool, error)
+	lastApplyReport   *applyReport
+	externalLock      *chezmoi.ExternalLock
+	rawMachineID      *string
+	proxyCache        proxyCache
+	remoteDestination *remoteDestination
+	Aliases           map[string]string
 }
 
 // A configOption sets an option on a Config.
@@ -140,20 +219,71 @@ func newConfig(options ...configOption) *Config {
 			Command: "git",
 		},
 		Template: templateConfig{
-			Options: chezmoi.DefaultTemplateOptions,
+			Options:     chezmoi.DefaultTemplateOptions,
+			FuncTimeout: time.Minute,
+		},
+		DegradedFS: degradedFSConfig{
+			Auto: true,
 		},
 		Diff: diffCmdConfig{
 			Format: "chezmoi",
 		},
+		Docker: containerConfig{
+			Command: "docker",
+		},
+		Doppler: dopplerCmdConfig{
+			Endpoint: "https://api.doppler.com",
+		},
+		Fleet: fleetCmdConfig{
+			SSHCommand: "ssh",
+		},
+		GCPSecretManager: gcpSecretManagerConfig{
+			Endpoint: "https://secretmanager.googleapis.com",
+		},
+		GitHub: gitHubConfig{
+			APIURL: "https://api.github.com",
+		},
+		Vault: vaultCmdConfig{
+			MinTTL: 5 * time.Minute,
+		},
 		Merge: mergeConfig{
 			Command: "vimdiff",
 		},
+		Podman: containerConfig{
+			Command: "podman",
+		},
+		Encryption: "gpg",
+		Age: chezmoi.Age{
+			Command: "age",
+		},
 		GPG: chezmoi.GPG{
 			Command: "gpg",
 		},
+		LFS: chezmoi.LFS{
+			Command: "git",
+		},
+		SevenZip: chezmoi.SevenZip{
+			Command: "7z",
+		},
+		SOPS: sopsConfig{
+			Command: "sops",
+		},
+		SSH: sshConfig{
+			Command: "ssh",
+		},
+		Xz: chezmoi.Xz{
+			Command: "xz",
+		},
+		Zstd: chezmoi.Zstd{
+			Command: "zstd",
+		},
 		maxDiffDataSize:   1 * 1024 * 1024, // 1MB
 		templateFuncs:     sprig.TxtFuncMap(),
+		entryStateBucket:  []byte("entryState"),
 		scriptStateBucket: []byte("script"),
+		promptOnceBucket:  []byte("promptStringOnce"),
+		stateFuncsBucket:  []byte("templateState"),
+		systemConfigDir:   getSystemConfigDir(),
 		Stdin:             os.Stdin,
 		Stdout:            os.Stdout,
 		Stderr:            os.Stderr,
@@ -176,34 +306,69 @@ func (c *Config) addTemplateFunc(key string, value interface{}) {
 
 func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentState) error {
 	fs := vfs.NewReadOnlyFS(c.fs)
+	if c.remoteDestination != nil {
+		fs = vfs.NewReadOnlyFS(c.remoteDestination.fs)
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
 	}
+	if c.remoteDestination != nil {
+		if err := checkRemoteExternals(ts); err != nil {
+			return err
+		}
+	}
+	// Stop between entries if chezmoi is interrupted.
+	ctx, release := newInterruptContext()
+	defer release()
 	applyOptions := &chezmoi.ApplyOptions{
+		Context:           ctx,
 		DestDir:           ts.DestDir,
 		DryRun:            c.DryRun,
+		FIFOs:             c.FIFOs,
 		Ignore:            ts.TargetIgnore.Match,
 		PersistentState:   persistentState,
+		PrivilegedMutator: c.privilegedMutator,
 		Remove:            c.Remove,
 		ScriptStateBucket: c.scriptStateBucket,
 		Stdout:            c.Stdout,
 		Umask:             ts.Umask,
 		Verbose:           c.Verbose,
 	}
+	if c.applyReport != nil {
+		applyOptions.OnRunScript = c.applyReport.addScriptRun
+	}
+	// Scripts for remote systems are run on the remote system and their state
+	// is recorded separately for each remote system.
+	if c.remoteDestination != nil {
+		applyOptions.NewScriptCmd = c.remoteDestination.mutator.NewScriptCmd
+		applyOptions.ScriptStateBucket = append([]byte(string(c.scriptStateBucket)+":"), c.remoteDestination.name...)
+	}
+	// Confirming an entry interactively also confirms overwriting it.
+	switch {
+	case c.confirmApply != nil:
+		applyOptions.ConfirmApply = c.confirmApply
+	case c.confirmOverwrite != nil && !c.DryRun:
+		applyOptions.ConfirmOverwrite = c.confirmOverwrite
+	}
 	if len(args) == 0 {
+		if c.applyReport != nil {
+			c.applyReport.addTargets(ts, ts.AllEntries())
+		}
 		return ts.Apply(fs, c.mutator, c.Follow, applyOptions)
 	}
 	entries, err := c.getEntries(ts, args)
 	if err != nil {
 		return err
 	}
-	for _, entry := range entries {
-		if err := entry.Apply(fs, c.mutator, c.Follow, applyOptions); err != nil {
-			return err
+	if c.applyReport != nil {
+		var allEntries []chezmoi.Entry
+		for _, entry := range entries {
+			allEntries = entry.AppendAllEntries(allEntries)
 		}
+		c.applyReport.addTargets(ts, allEntries)
 	}
-	return nil
+	return chezmoi.ApplyEntries(fs, c.mutator, c.Follow, applyOptions, entries)
 }
 
 func (c *Config) autoCommit(vcs VCS) error {
@@ -234,10 +399,18 @@ func (c *Config) autoCommit(vcs VCS) error {
 	if err := commitMessageTmpl.Execute(b, status); err != nil {
 		return err
 	}
+	// Nothing changed, so there is nothing to commit.
+	if b.Len() == 0 {
+		return nil
+	}
 	commitArgs := vcs.CommitArgs(b.String())
 	return c.run(c.SourceDir, c.SourceVCS.Command, commitArgs...)
 }
 
+// autoCommitAndAutoPush commits and pushes the changes to the source directory,
+// if git.autoCommit and git.autoPush, or their older equivalents
+// sourceVCS.autoCommit and sourceVCS.autoPush, are set. autoPush implies
+// autoCommit.
 func (c *Config) autoCommitAndAutoPush(cmd *cobra.Command, args []string) error {
 	vcs, err := c.getVCS()
 	if err != nil {
@@ -246,12 +419,14 @@ func (c *Config) autoCommitAndAutoPush(cmd *cobra.Command, args []string) error
 	if c.DryRun {
 		return nil
 	}
-	if c.SourceVCS.AutoCommit || c.SourceVCS.AutoPush {
+	autoPush := c.Git.AutoPush || c.SourceVCS.AutoPush
+	autoCommit := c.Git.AutoCommit || c.SourceVCS.AutoCommit || autoPush
+	if autoCommit {
 		if err := c.autoCommit(vcs); err != nil {
 			return err
 		}
 	}
-	if c.SourceVCS.AutoPush {
+	if autoPush {
 		if err := c.autoPush(vcs); err != nil {
 			return err
 		}
@@ -306,17 +481,61 @@ func (c *Config) getData() (map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	data := map[string]interface{}{
-		"chezmoi": defaultData,
+	data, err := c.getHostData(defaultData)
+	if err != nil {
+		return nil, err
 	}
 	for key, value := range c.Data {
 		data[key] = value
 	}
+	sopsData, err := c.getSOPSData()
+	if err != nil {
+		return nil, err
+	}
+	if sopsData != nil {
+		data["sops"] = sopsData
+	}
+	data["chezmoi"] = defaultData
+	if err := setDataOverrides(data, os.Environ(), c.dataOverrides); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// getHostData returns the data in the host data file for the host described
+// by defaultData. The host data file is the first of
+// .chezmoidata/hosts/<fullHostname>.yaml and
+// .chezmoidata/hosts/<hostname>.yaml in the source directory that exists. If
+// neither exists then an empty map is returned.
+func (c *Config) getHostData(defaultData map[string]interface{}) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	for _, key := range []string{"fullHostname", "hostname"} {
+		hostname, ok := defaultData[key].(string)
+		if !ok || hostname == "" {
+			continue
+		}
+		path := filepath.Join(c.SourceDir, filepath.FromSlash(hostDataDirName), hostname+".yaml")
+		b, err := c.fs.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var value map[string]interface{}
+		if err := yaml.Unmarshal(b, &value); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for k, v := range value {
+			data[k] = normalizeYAMLValue(v)
+		}
+		return data, nil
+	}
 	return data, nil
 }
 
 func (c *Config) getDefaultData() (map[string]interface{}, error) {
 	data := map[string]interface{}{
+		"android":   runtime.GOOS == "android" || getTermuxPrefix(os.Getenv) != "",
 		"arch":      runtime.GOARCH,
 		"os":        runtime.GOOS,
 		"sourceDir": c.SourceDir,
@@ -355,7 +574,12 @@ func (c *Config) getDefaultData() (map[string]interface{}, error) {
 		return nil, err
 	}
 	data["fullHostname"] = hostname
+	data["fqdnHostname"] = getFQDNHostname(c.fs, hostname)
 	data["hostname"] = strings.SplitN(hostname, ".", 2)[0]
+	data["machineID"] = c.getMachineID()
+	chassis, hasBattery := c.getChassis()
+	data["chassis"] = chassis
+	data["hasBattery"] = hasBattery
 
 	osRelease, err := getOSRelease(c.fs)
 	if err == nil {
@@ -373,6 +597,31 @@ func (c *Config) getDefaultData() (map[string]interface{}, error) {
 		return nil, err
 	}
 
+	// Display facts are opt-in as some depend on the session that chezmoi is
+	// run from, which would otherwise change the target state.
+	if c.Template.DisplayData {
+		data["display"] = getDisplayData(c.fs, os.Getenv)
+	}
+	// Proxy facts are opt-in as reading the system proxy settings runs
+	// external commands on some systems.
+	if c.Template.ProxyData {
+		data["proxy"] = c.getProxyData()
+	}
+
+	// Network facts are opt-in as they change as the machine moves between
+	// networks, which would otherwise change the target state.
+	if c.Template.NetworkData {
+		data["network"] = getNetworkData(c.fs)
+	}
+
+	data["xdg"] = c.getXDGDirs()
+
+	if c.remoteDestination != nil {
+		if err := c.remoteDestination.setRemoteData(data); err != nil {
+			return nil, err
+		}
+	}
+
 	return data, nil
 }
 
@@ -391,7 +640,7 @@ func (c *Config) getEditor() (string, []string) {
 func (c *Config) getEntries(ts *chezmoi.TargetState, args []string) ([]chezmoi.Entry, error) {
 	entries := []chezmoi.Entry{}
 	for _, arg := range args {
-		targetPath, err := filepath.Abs(arg)
+		targetPath, err := filepath.Abs(chezmoi.NormalPath(arg))
 		if err != nil {
 			return nil, err
 		}
@@ -415,7 +664,53 @@ func (c *Config) getPersistentState(options *bolt.Options) (chezmoi.PersistentSt
 		}
 		options.ReadOnly = true
 	}
-	return chezmoi.NewBoltPersistentState(c.fs, persistentStateFile, os.FileMode(c.Umask), options)
+	persistentState, err := chezmoi.NewBoltPersistentState(c.fs, persistentStateFile, os.FileMode(c.Umask), options)
+	if err != nil {
+		return nil, err
+	}
+	c.persistentState = &openPersistentState{
+		PersistentState: persistentState,
+		c:               c,
+		readOnly:        options != nil && options.ReadOnly,
+	}
+	return c.persistentState, nil
+}
+
+// getSystemConfigFile returns the path of the system config file in
+// c.systemConfigDir, or the empty string if there is no system config file.
+func (c *Config) getSystemConfigFile(fs vfs.FS) string {
+	if c.systemConfigDir == "" {
+		return ""
+	}
+	for _, extension := range viper.SupportedExts {
+		systemConfigFile := filepath.Join(c.systemConfigDir, "chezmoi."+extension)
+		if _, err := fs.Stat(systemConfigFile); err == nil {
+			return systemConfigFile
+		}
+	}
+	return ""
+}
+
+// readConfigFiles reads configFiles from fs into v in order, so values in
+// later config files override values in earlier ones. Tables, including data,
+// are merged.
+func readConfigFiles(v *viper.Viper, fs vfs.FS, configFiles []string) error {
+	for i, configFile := range configFiles {
+		data, err := fs.ReadFile(configFile)
+		if err != nil {
+			return err
+		}
+		v.SetConfigType(strings.TrimPrefix(filepath.Ext(configFile), "."))
+		if i == 0 {
+			err = v.ReadConfig(bytes.NewReader(data))
+		} else {
+			err = v.MergeConfig(bytes.NewReader(data))
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", configFile, err)
+		}
+	}
+	return nil
 }
 
 func (c *Config) getPersistentStateFile() string {
@@ -432,16 +727,26 @@ func (c *Config) getPersistentStateFile() string {
 }
 
 func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chezmoi.TargetState, error) {
-	fs := vfs.NewReadOnlyFS(c.fs)
+	return c.getTargetStateFromSourceDir(vfs.NewReadOnlyFS(c.fs), c.SourceDir, populateOptions)
+}
 
+// getTargetStateFromSourceDir returns the target state of the source state in
+// sourceDir in fs.
+func (c *Config) getTargetStateFromSourceDir(fs vfs.FS, sourceDir string, populateOptions *chezmoi.PopulateOptions) (*chezmoi.TargetState, error) {
 	data, err := c.getData()
 	if err != nil {
 		return nil, err
 	}
+	return c.getTargetStateWithData(fs, sourceDir, data, populateOptions)
+}
 
+// getTargetStateWithData returns the target state of the source state in
+// sourceDir in fs with template data data.
+func (c *Config) getTargetStateWithData(fs vfs.FS, sourceDir string, data map[string]interface{}, populateOptions *chezmoi.PopulateOptions) (*chezmoi.TargetState, error) {
 	destDir := c.DestDir
 	if destDir != "" {
-		destDir, err = filepath.Abs(c.DestDir)
+		var err error
+		destDir, err = filepath.Abs(chezmoi.NormalPath(c.DestDir))
 		if err != nil {
 			return nil, err
 		}
@@ -452,15 +757,43 @@ func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chez
 		c.GPG.Recipient = c.GPGRecipient
 	}
 
-	ts := chezmoi.NewTargetState(
+	encryption, err := c.getEncryption()
+	if err != nil {
+		return nil, err
+	}
+
+	destDirOverrides := make(map[string]string, len(c.DestDirOverrides))
+	for _, destDirOverride := range c.DestDirOverrides {
+		destDirOverrides[filepath.Clean(destDirOverride.From)] = destDirOverride.To
+	}
+
+	options := []chezmoi.TargetStateOption{
 		chezmoi.WithDestDir(destDir),
-		chezmoi.WithGPG(&c.GPG),
-		chezmoi.WithSourceDir(c.SourceDir),
+		chezmoi.WithDestDirOverrides(destDirOverrides),
+		chezmoi.WithEncryption(encryption),
+		chezmoi.WithFetchURL(c.fetchExternalURL),
+		chezmoi.WithLFS(&c.LFS),
+		chezmoi.WithPathPrefixes(c.getPathPrefixes()),
+		chezmoi.WithResolveRelease(c.resolveExternalRelease),
+		chezmoi.WithSevenZip(&c.SevenZip),
+		chezmoi.WithSourceDir(chezmoi.NormalPath(sourceDir)),
 		chezmoi.WithTemplateData(data),
 		chezmoi.WithTemplateFuncs(c.templateFuncs),
 		chezmoi.WithTemplateOptions(c.Template.Options),
 		chezmoi.WithUmask(os.FileMode(c.Umask)),
-	)
+		chezmoi.WithXz(&c.Xz),
+		chezmoi.WithZstd(&c.Zstd),
+	}
+	// Only the lock of the source directory is written. Other source states,
+	// for example those at other revisions, use their own locks.
+	if sourceDir == c.SourceDir {
+		externalLock, err := c.getExternalLock()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, chezmoi.WithExternalLock(externalLock, c.writeExternalLock))
+	}
+	ts := chezmoi.NewTargetState(options...)
 	if err := ts.Populate(fs, populateOptions); err != nil {
 		return nil, err
 	}
@@ -492,7 +825,7 @@ func (c *Config) output(dir, name string, argv ...string) ([]byte, error) {
 
 //nolint:unparam
 func (c *Config) prompt(s, choices string) (byte, error) {
-	r := bufio.NewReader(c.Stdin)
+	r := c.stdinReader()
 	for {
 		_, err := fmt.Printf("%s [%s]? ", s, strings.Join(strings.Split(choices, ""), ","))
 		if err != nil {
@@ -509,6 +842,15 @@ func (c *Config) prompt(s, choices string) (byte, error) {
 	}
 }
 
+// stdinReader returns a buffered reader of c.Stdin. The same reader is
+// returned each time so that input is not lost between prompts.
+func (c *Config) stdinReader() *bufio.Reader {
+	if c.bufferedStdin == nil {
+		c.bufferedStdin = bufio.NewReader(c.Stdin)
+	}
+	return c.bufferedStdin
+}
+
 // run runs name argv... in dir.
 func (c *Config) run(dir, name string, argv ...string) error {
 	cmd := exec.Command(name, argv...)
@@ -534,6 +876,67 @@ func (c *Config) validateData() error {
 	return validateKeys(config.Data, identifierRegexp)
 }
 
+// setDataOverrides sets the values in data given by the CHEZMOI_DATA_*
+// variables in environ and then by dataOverrides. The key of an environment
+// variable is the rest of its name with "__" separating nested keys, for
+// example CHEZMOI_DATA_git__email sets .git.email. The keys of dataOverrides
+// use "." to separate nested keys.
+func setDataOverrides(data map[string]interface{}, environ []string, dataOverrides map[string]string) error {
+	for _, keyValue := range environ {
+		if !strings.HasPrefix(keyValue, dataEnvVarPrefix) {
+			continue
+		}
+		keyValue = strings.TrimPrefix(keyValue, dataEnvVarPrefix)
+		i := strings.IndexByte(keyValue, '=')
+		if i == -1 {
+			continue
+		}
+		if err := setDataValue(data, strings.Split(keyValue[:i], "__"), keyValue[i+1:]); err != nil {
+			return fmt.Errorf("%s%s: %w", dataEnvVarPrefix, keyValue[:i], err)
+		}
+	}
+	keys := make([]string, 0, len(dataOverrides))
+	for key := range dataOverrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := setDataValue(data, strings.Split(key, "."), dataOverrides[key]); err != nil {
+			return fmt.Errorf("--data %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// setDataValue sets the value at path in data to value. Maps along path are
+// copied, rather than modified, so that values shared with the config are not
+// changed.
+func setDataValue(data map[string]interface{}, path []string, value string) error {
+	for _, key := range path {
+		if !identifierRegexp.MatchString(key) {
+			return fmt.Errorf("invalid key: %q", key)
+		}
+	}
+	for i, key := range path[:len(path)-1] {
+		var m map[string]interface{}
+		switch child := data[key].(type) {
+		case nil:
+			m = make(map[string]interface{})
+		case map[string]interface{}:
+			m = make(map[string]interface{}, len(child))
+			for k, v := range child {
+				m[k] = v
+			}
+		default:
+			return fmt.Errorf("%s: not a map", strings.Join(path[:i+1], "."))
+		}
+		data[key] = m
+		data = m
+	}
+	data[path[len(path)-1]] = value
+	return nil
+}
+
 func getAsset(name string) ([]byte, error) {
 	asset, ok := assets[name]
 	if !ok {
@@ -9,9 +9,11 @@ import (
 	"text/template"
 
 	"github.com/Masterminds/sprig"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
 	xdg "github.com/twpayne/go-xdg/v3"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -69,6 +71,220 @@ func TestAutoCommitCommitMessage(t *testing.T) {
 	}
 }
 
+func TestAutoCommitAndAutoPush(t *testing.T) {
+	defer setTestGitEnv(t, nil)()
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# contents of .bashrc\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	require.NoError(t, newTestConfig(fs).runInitCmd(nil, nil))
+
+	c := newTestConfig(fs)
+	c.Git.AutoCommit = true
+	require.NoError(t, c.runAddCmd(nil, []string{"/home/user/.bashrc"}))
+	require.NoError(t, c.autoCommitAndAutoPush(nil, nil))
+	assert.Equal(t, "Add dot_bashrc\n", testGitOutput(t, fs, "log", "--format=%s"))
+
+	// Nothing is committed if nothing changed.
+	require.NoError(t, c.autoCommitAndAutoPush(nil, nil))
+	assert.Equal(t, "Add dot_bashrc\n", testGitOutput(t, fs, "log", "--format=%s"))
+}
+
+func TestGetHostData(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoidata/hosts": map[string]interface{}{
+			"db1.yaml":              "role: db\n",
+			"web1.example.com.yaml": "role: web\nports:\n  http: 80\n",
+			"web1.yaml":             "role: ignored\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	c := newTestConfig(fs)
+	for _, tc := range []struct {
+		fullHostname string
+		hostname     string
+		expected     map[string]interface{}
+	}{
+		{
+			fullHostname: "web1.example.com",
+			hostname:     "web1",
+			expected: map[string]interface{}{
+				"role": "web",
+				"ports": map[string]interface{}{
+					"http": 80,
+				},
+			},
+		},
+		{
+			fullHostname: "db1.example.com",
+			hostname:     "db1",
+			expected: map[string]interface{}{
+				"role": "db",
+			},
+		},
+		{
+			fullHostname: "mail1.example.com",
+			hostname:     "mail1",
+			expected:     map[string]interface{}{},
+		},
+	} {
+		t.Run(tc.fullHostname, func(t *testing.T) {
+			actual, err := c.getHostData(map[string]interface{}{
+				"fullHostname": tc.fullHostname,
+				"hostname":     tc.hostname,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestReadConfigFiles(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/etc/chezmoi/chezmoi.yaml": "" +
+			"data:\n" +
+			"  email: user@company.com\n" +
+			"  proxy: http://proxy.company.com:3128\n" +
+			"sourceVCS:\n" +
+			"  autoCommit: true\n",
+		"/home/user/.config/chezmoi/chezmoi.toml": "" +
+			"[data]\n" +
+			"  email = \"user@home.org\"\n" +
+			"[sourceVCS]\n" +
+			"  autoPush = true\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	c.systemConfigDir = "/etc/chezmoi"
+	systemConfigFile := c.getSystemConfigFile(fs)
+	assert.Equal(t, "/etc/chezmoi/chezmoi.yaml", systemConfigFile)
+
+	v := viper.New()
+	require.NoError(t, readConfigFiles(v, fs, []string{systemConfigFile, "/home/user/.config/chezmoi/chezmoi.toml"}))
+	require.NoError(t, v.Unmarshal(c))
+	assert.Equal(t, map[string]interface{}{
+		"email": "user@home.org",
+		"proxy": "http://proxy.company.com:3128",
+	}, c.Data)
+	assert.True(t, c.SourceVCS.AutoCommit)
+	assert.True(t, c.SourceVCS.AutoPush)
+	assert.Equal(t, "git", c.SourceVCS.Command)
+
+	c.systemConfigDir = "/etc/nonexistent"
+	assert.Equal(t, "", c.getSystemConfigFile(fs))
+}
+
+func TestSetDataOverrides(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		data          map[string]interface{}
+		environ       []string
+		dataOverrides map[string]string
+		expected      map[string]interface{}
+		expectedErr   bool
+	}{
+		{
+			name: "none",
+			data: map[string]interface{}{
+				"email": "user@home.org",
+			},
+			environ: []string{"HOME=/home/user"},
+			expected: map[string]interface{}{
+				"email": "user@home.org",
+			},
+		},
+		{
+			name: "environ",
+			data: map[string]interface{}{
+				"email": "user@home.org",
+				"git": map[string]interface{}{
+					"name": "User",
+				},
+			},
+			environ: []string{
+				"CHEZMOI_DATA_email=user@work.com",
+				"CHEZMOI_DATA_foo_bar=baz",
+				"CHEZMOI_DATA_git__signingKey=0x1234",
+			},
+			expected: map[string]interface{}{
+				"email":   "user@work.com",
+				"foo_bar": "baz",
+				"git": map[string]interface{}{
+					"name":       "User",
+					"signingKey": "0x1234",
+				},
+			},
+		},
+		{
+			name: "flags_override_environ",
+			data: map[string]interface{}{},
+			environ: []string{
+				"CHEZMOI_DATA_email=user@work.com",
+			},
+			dataOverrides: map[string]string{
+				"email":    "user@ci.org",
+				"git.name": "CI",
+			},
+			expected: map[string]interface{}{
+				"email": "user@ci.org",
+				"git": map[string]interface{}{
+					"name": "CI",
+				},
+			},
+		},
+		{
+			name: "not_a_map",
+			data: map[string]interface{}{
+				"email": "user@home.org",
+			},
+			dataOverrides: map[string]string{
+				"email.work": "user@work.com",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid_key",
+			data: map[string]interface{}{},
+			environ: []string{
+				"CHEZMOI_DATA_git__=User",
+			},
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := setDataOverrides(tc.data, tc.environ, tc.dataOverrides)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, tc.data)
+		})
+	}
+}
+
+func TestSetDataOverridesDoesNotModifyConfig(t *testing.T) {
+	git := map[string]interface{}{
+		"name": "User",
+	}
+	data := map[string]interface{}{
+		"git": git,
+	}
+	require.NoError(t, setDataOverrides(data, nil, map[string]string{
+		"git.name": "CI",
+	}))
+	assert.Equal(t, "CI", data["git"].(map[string]interface{})["name"])
+	assert.Equal(t, "User", git["name"])
+}
+
 func TestUpperSnakeCaseToCamelCase(t *testing.T) {
 	for s, want := range map[string]string{
 		"BUG_REPORT_URL":   "bugReportURL",
@@ -168,6 +384,12 @@ func withDumpCmdConfig(dumpCmdConfig dumpCmdConfig) configOption {
 	}
 }
 
+func withFleetCmdConfig(fleet fleetCmdConfig) configOption {
+	return func(c *Config) {
+		c.Fleet = fleet
+	}
+}
+
 func withFollow(follow bool) configOption {
 	return func(c *Config) {
 		c.Follow = follow
@@ -224,6 +446,7 @@ func withTestUser(username string) configOption {
 		c.SourceDir = filepath.Join(homeDir, ".local", "share", "chezmoi")
 		c.DestDir = homeDir
 		c.Umask = 022
+		c.homeDir = homeDir
 		c.bds = &xdg.BaseDirectorySpecification{
 			ConfigHome: filepath.Join(homeDir, ".config"),
 			DataHome:   filepath.Join(homeDir, ".local"),
@@ -232,3 +455,23 @@ func withTestUser(username string) configOption {
 		}
 	}
 }
+
+// setTestEnv sets the environment variables in env and returns a function that
+// restores their previous values.
+func setTestEnv(t *testing.T, env map[string]string) func() {
+	var restores []func()
+	for key, value := range env {
+		key := key
+		if prevValue, ok := os.LookupEnv(key); ok {
+			restores = append(restores, func() { os.Setenv(key, prevValue) })
+		} else {
+			restores = append(restores, func() { os.Unsetenv(key) })
+		}
+		require.NoError(t, os.Setenv(key, value))
+	}
+	return func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
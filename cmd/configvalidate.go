@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+var configCmd = &cobra.Command{
+	Use:     "config",
+	Args:    cobra.NoArgs,
+	Short:   "Work with the configuration file",
+	Long:    mustGetLongHelp("config"),
+	Example: getExample("config"),
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Args:  cobra.NoArgs,
+	Short: "Check the configuration file and the config file template",
+	RunE:  config.runConfigValidateCmd,
+}
+
+// A configKey is a key in the configuration file schema.
+type configKey struct {
+	name     string
+	freeForm bool
+}
+
+var configSchema = getConfigSchema(reflect.TypeOf(Config{}))
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+}
+
+func (c *Config) runConfigValidateCmd(cmd *cobra.Command, args []string) error {
+	problems := 0
+
+	if systemConfigFile := c.getSystemConfigFile(c.fs); systemConfigFile != "" {
+		data, err := c.fs.ReadFile(systemConfigFile)
+		if err != nil {
+			return err
+		}
+		problems += c.printConfigProblems(systemConfigFile, filepath.Ext(systemConfigFile), data)
+	}
+
+	data, err := c.fs.ReadFile(c.configFile)
+	switch {
+	case err == nil:
+		problems += c.printConfigProblems(c.configFile, filepath.Ext(c.configFile), data)
+	case !os.IsNotExist(err):
+		return err
+	default:
+		fmt.Fprintf(c.Stdout, "%s: does not exist\n", c.configFile)
+	}
+
+	filename, ext, templateData, err := c.findConfigTemplate()
+	if err != nil {
+		return err
+	}
+	if filename != "" {
+		templatePath := filepath.Join(c.SourceDir, "."+filename+".tmpl")
+		contents, err := c.renderConfigTemplate(filename, templateData)
+		if err != nil {
+			fmt.Fprintf(c.Stdout, "%s: %v\n", templatePath, err)
+			problems++
+		} else {
+			problems += c.printConfigProblems(templatePath, ext, contents)
+		}
+	}
+
+	if problems != 0 {
+		return fmt.Errorf("found %d problem(s) in config", problems)
+	}
+	return nil
+}
+
+// warnUnknownConfigKeys prints a warning for every key in the config file at
+// path in fs that is not in the config file schema.
+func (c *Config) warnUnknownConfigKeys(fs vfs.FS, path string) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return
+	}
+	v, err := newConfigFileViper(filepath.Ext(path), data)
+	if err != nil {
+		return
+	}
+	for _, problem := range unknownConfigKeyProblems(v) {
+		rootCmd.Printf("warning: %s: %s\n", path, problem)
+	}
+}
+
+// printConfigProblems prints the problems with the config file at path, with
+// extension ext and contents data, and returns how many were found.
+func (c *Config) printConfigProblems(path, ext string, data []byte) int {
+	problems := validateConfigData(ext, data)
+	for _, problem := range problems {
+		fmt.Fprintf(c.Stdout, "%s: %s\n", path, problem)
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(c.Stdout, "%s: ok\n", path)
+	}
+	return len(problems)
+}
+
+// renderConfigTemplate renders the config file template in data without any
+// side effects. promptString returns its prompt, promptBool returns false, and
+// promptInt returns zero.
+func (c *Config) renderConfigTemplate(filename, data string) ([]byte, error) {
+	funcMap := make(template.FuncMap)
+	for key, value := range c.templateFuncs {
+		funcMap[key] = value
+	}
+	funcMap["promptBool"] = func(prompt string) bool {
+		return false
+	}
+	funcMap["promptInt"] = func(prompt string) int64 {
+		return 0
+	}
+	funcMap["promptString"] = func(prompt string) string {
+		return prompt
+	}
+	t, err := template.New(filename).Funcs(funcMap).Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	defaultData, err := c.getDefaultData()
+	if err != nil {
+		return nil, err
+	}
+	contents := &bytes.Buffer{}
+	if err := t.Execute(contents, map[string]interface{}{
+		"chezmoi": defaultData,
+	}); err != nil {
+		return nil, err
+	}
+	return contents.Bytes(), nil
+}
+
+// validateConfigData returns the problems with the config file with extension
+// ext and contents data.
+func validateConfigData(ext string, data []byte) []string {
+	v, err := newConfigFileViper(ext, data)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	problems := unknownConfigKeyProblems(v)
+	c := newConfig()
+	if decodeProblems := strictDecodeConfig(v, c); len(decodeProblems) != 0 {
+		problems = append(problems, decodeProblems...)
+	} else if err := validateKeys(c.Data, identifierRegexp); err != nil {
+		problems = append(problems, "data: "+err.Error())
+	}
+	return problems
+}
+
+// strictDecodeConfig decodes v into c without converting values between types
+// and returns the problems found. Unknown keys are not returned as they are
+// already reported, with suggestions, by unknownConfigKeyProblems.
+func strictDecodeConfig(v *viper.Viper, c *Config) []string {
+	err := v.UnmarshalExact(c, func(decoderConfig *mapstructure.DecoderConfig) {
+		decoderConfig.WeaklyTypedInput = false
+	})
+	if err == nil {
+		return nil
+	}
+	var mapstructureErr *mapstructure.Error
+	if !errors.As(err, &mapstructureErr) {
+		return []string{err.Error()}
+	}
+	var problems []string
+	for _, problem := range mapstructureErr.Errors {
+		if strings.Contains(problem, "has invalid keys: ") {
+			continue
+		}
+		// Problems start with the quoted name of the field, which is replaced
+		// with the config file key.
+		if strings.HasPrefix(problem, "'") {
+			if i := strings.Index(problem[1:], "' "); i != -1 {
+				if k, ok := configSchema[strings.ToLower(problem[1:i+1])]; ok {
+					problem = k.name + ": " + problem[i+3:]
+				}
+			}
+		}
+		problems = append(problems, problem)
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// newConfigFileViper returns a new viper.Viper containing only the config
+// file with extension ext and contents data.
+func newConfigFileViper(ext string, data []byte) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(ext, "."))
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// unknownConfigKeyProblems returns a problem for every key in v that is not in
+// the config file schema, with a suggestion if there is a similar key.
+func unknownConfigKeyProblems(v *viper.Viper) []string {
+	var problems []string
+	for _, key := range v.AllKeys() {
+		if isKnownConfigKey(key) {
+			continue
+		}
+		problem := fmt.Sprintf("unknown key %s", key)
+		if suggestion := suggestConfigKey(key); suggestion != "" {
+			problem += fmt.Sprintf(" (did you mean %s?)", suggestion)
+		}
+		problems = append(problems, problem)
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// isKnownConfigKey returns true if the lowercase key is in the config file
+// schema, or is inside a key that accepts arbitrary values.
+func isKnownConfigKey(key string) bool {
+	components := strings.Split(key, ".")
+	for i := range components {
+		if k, ok := configSchema[strings.Join(components[:i+1], ".")]; ok && (k.freeForm || i == len(components)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestConfigKey returns the key in the config file schema that is most
+// similar to the lowercase key, or the empty string if there is no similar key.
+func suggestConfigKey(key string) string {
+	suggestion := ""
+	bestDistance := len(key)/3 + 1
+	for lowerKey, k := range configSchema {
+		distance := levenshteinDistance(key, lowerKey)
+		if distance < bestDistance || distance == bestDistance && suggestion != "" && k.name < suggestion {
+			suggestion = k.name
+			bestDistance = distance
+		}
+	}
+	return suggestion
+}
+
+// getConfigSchema returns the config file keys of the exported fields of the
+// struct type t, indexed by their lowercase names.
+func getConfigSchema(t reflect.Type) map[string]configKey {
+	schema := make(map[string]configKey)
+	var addFields func(reflect.Type, string, string)
+	addFields = func(t reflect.Type, lowerPrefix, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := prefix + lowerCamelCase(field.Name)
+			lowerName := lowerPrefix + strings.ToLower(field.Name)
+			switch {
+			case field.Type.Implements(reflect.TypeOf((*io.Reader)(nil)).Elem()) || field.Type.Implements(reflect.TypeOf((*io.Writer)(nil)).Elem()):
+			case field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}):
+				schema[lowerName] = configKey{name: name}
+				addFields(field.Type, lowerName+".", name+".")
+			default:
+				kind := field.Type.Kind()
+				schema[lowerName] = configKey{
+					name:     name,
+					freeForm: kind == reflect.Map || kind == reflect.Interface || kind == reflect.Slice,
+				}
+			}
+		}
+	}
+	addFields(t, "", "")
+	return schema
+}
+
+// lowerCamelCase converts an exported Go identifier to lowerCamelCase, for
+// example GPG to gpg, SourceVCS to sourceVCS, and URLs to urls.
+func lowerCamelCase(s string) string {
+	runes := []rune(s)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) && string(runes[n:]) != "s" {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// levenshteinDistance returns the Levenshtein distance between a and b.
+func levenshteinDistance(a, b string) int {
+	d := make([]int, len(b)+1)
+	for j := range d {
+		d[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := d[0]
+		d[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			next := min3(d[j]+1, d[j-1]+1, prev+cost)
+			prev = d[j]
+			d[j] = next
+		}
+	}
+	return d[len(b)]
+}
+
+func min3(a, b, c int) int {
+	switch {
+	case a <= b && a <= c:
+		return a
+	case b <= c:
+		return b
+	default:
+		return c
+	}
+}
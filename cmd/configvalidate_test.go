@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestValidateConfigData(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		ext      string
+		data     string
+		expected []string
+	}{
+		{
+			name: "valid_toml",
+			ext:  ".toml",
+			data: "" +
+				"[data]\n" +
+				"  email = \"user@home.org\"\n" +
+				"  [data.git]\n" +
+				"    signingKey = \"0x1234\"\n" +
+				"[sourceVCS]\n" +
+				"  autoCommit = true\n" +
+				"[[destDirOverrides]]\n" +
+				"  from = \".config\"\n" +
+				"  to = \"/etc/xdg\"\n",
+		},
+		{
+			name: "valid_yaml",
+			ext:  ".yaml",
+			data: "" +
+				"gpg:\n" +
+				"  recipient: user@home.org\n" +
+				"authorizedKeys:\n" +
+				"  urls:\n" +
+				"    github: https://github.com/%s.keys\n",
+		},
+		{
+			name: "misspelled_keys",
+			ext:  ".toml",
+			data: "" +
+				"sourceDri = \"/home/user/dotfiles\"\n" +
+				"[gpg]\n" +
+				"  recipent = \"user@home.org\"\n" +
+				"[sourceVSC]\n" +
+				"  autoCommit = true\n",
+			expected: []string{
+				"unknown key gpg.recipent (did you mean gpg.recipient?)",
+				"unknown key sourcedri (did you mean sourceDir?)",
+				"unknown key sourcevsc.autocommit (did you mean sourceVCS.autoCommit?)",
+			},
+		},
+		{
+			name: "unknown_key_without_suggestion",
+			ext:  ".json",
+			data: `{"frobnicate":true}`,
+			expected: []string{
+				"unknown key frobnicate",
+			},
+		},
+		{
+			name: "type_mismatches",
+			ext:  ".toml",
+			data: "" +
+				"sourceDir = 1\n" +
+				"[sourceVCS]\n" +
+				"  autoCommit = \"true\"\n",
+			expected: []string{
+				"sourceDir: expected type 'string', got unconvertible type 'int64'",
+				"sourceVCS.autoCommit: expected type 'bool', got unconvertible type 'string'",
+			},
+		},
+		{
+			name: "invalid_data_key",
+			ext:  ".yaml",
+			data: "data:\n  bad-key: 1\n",
+			expected: []string{
+				"data: invalid key: \"bad-key\"",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, validateConfigData(tc.ext, []byte(tc.data)))
+		})
+	}
+}
+
+func TestValidateConfigDataTypeError(t *testing.T) {
+	problems := validateConfigData(".toml", []byte("[diff]\n  noPager = [1, 2]\n"))
+	require.Len(t, problems, 1)
+	assert.Contains(t, problems[0], "diff.noPager")
+}
+
+func TestConfigValidateCmd(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/etc/chezmoi/chezmoi.yaml": "sourceVCS:\n  autoCommit: true\n",
+		"/home/user": map[string]interface{}{
+			".config/chezmoi/chezmoi.toml": "[data]\n  email = \"user@home.org\"\n",
+			".local/share/chezmoi/.chezmoi.toml.tmpl": "" +
+				"[data]\n" +
+				"  email = {{ promptString \"email\" | quote }}\n" +
+				"[merge]\n" +
+				"  comand = \"vimdiff\"\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs, withStdout(stdout))
+	c.configFile = "/home/user/.config/chezmoi/chezmoi.toml"
+	c.systemConfigDir = "/etc/chezmoi"
+	assert.EqualError(t, c.runConfigValidateCmd(nil, nil), "found 1 problem(s) in config")
+	assert.Equal(t, ""+
+		"/etc/chezmoi/chezmoi.yaml: ok\n"+
+		"/home/user/.config/chezmoi/chezmoi.toml: ok\n"+
+		"/home/user/.local/share/chezmoi/.chezmoi.toml.tmpl: unknown key merge.comand (did you mean merge.command?)\n",
+		stdout.String(),
+	)
+}
+
+func TestLowerCamelCase(t *testing.T) {
+	for s, expected := range map[string]string{
+		"Data":         "data",
+		"GPG":          "gpg",
+		"GPGRecipient": "gpgRecipient",
+		"SSHCommand":   "sshCommand",
+		"SourceVCS":    "sourceVCS",
+		"URLs":         "urls",
+	} {
+		assert.Equal(t, expected, lowerCamelCase(s))
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var (
+	// errConfirmQuit is returned by confirm when the user chooses to quit.
+	errConfirmQuit = errors.New("quit")
+
+	// errConfirmNoInput is returned by confirm when there is no input to
+	// answer the prompt.
+	errConfirmNoInput = errors.New("cannot confirm without input, use --force to proceed")
+)
+
+// confirm prompts the user to confirm the destructive action described by
+// prompt, unless *force is true or noConfirm is set in the config file.
+// Answering "a" (all) sets *force so that later actions are not prompted for.
+// It returns errConfirmQuit if the user chooses to quit.
+func (c *Config) confirm(prompt string, force *bool) (bool, error) {
+	if *force || c.NoConfirm {
+		return true, nil
+	}
+	choice, err := c.prompt(prompt, "ynqa")
+	switch {
+	case errors.Is(err, io.EOF):
+		return false, fmt.Errorf("%s: %w", prompt, errConfirmNoInput)
+	case err != nil:
+		return false, err
+	}
+	switch choice {
+	case 'y':
+		return true, nil
+	case 'a':
+		*force = true
+		return true, nil
+	case 'q':
+		return false, errConfirmQuit
+	default:
+		return false, nil
+	}
+}
+
+// getConfirmOverwriteFunc returns a function that confirms overwriting the
+// file at targetPath if it has been modified since chezmoi last wrote it.
+// Files that chezmoi has never written, and all files when there is no input
+// to answer the prompt, for example when chezmoi is run from a script, are
+// overwritten without prompting.
+func (c *Config) getConfirmOverwriteFunc(persistentState chezmoi.PersistentState, force *bool) func(string, os.FileInfo) (bool, error) {
+	return func(targetPath string, info os.FileInfo) (bool, error) {
+		es, err := c.getEntryState(persistentState, targetPath)
+		if err != nil {
+			return false, err
+		}
+		if es == nil || !info.ModTime().After(es.AppliedAt) {
+			return true, nil
+		}
+		ok, err := c.confirm(fmt.Sprintf("%s has changed since chezmoi last wrote it, overwrite", targetPath), force)
+		if errors.Is(err, errConfirmNoInput) {
+			return true, nil
+		}
+		return ok, err
+	}
+}
+
+// getConfirmApplyFunc returns a function that prompts the user before applying
+// each entry that would change the destination directory. Directories are
+// created without prompting, but the entries in them are prompted for.
+// Answering "d" (diff) shows the changes before prompting again, "a" (all)
+// applies all remaining entries, and "q" (quit) returns errConfirmQuit.
+func (c *Config) getConfirmApplyFunc() func(chezmoi.Entry, chezmoi.PreviewFunc) (bool, error) {
+	all := false
+	return func(entry chezmoi.Entry, preview chezmoi.PreviewFunc) (bool, error) {
+		if _, ok := entry.(*chezmoi.Dir); ok || all {
+			return true, nil
+		}
+		b := &bytes.Buffer{}
+		if err := preview(chezmoi.NewVerboseMutator(b, chezmoi.NullMutator{}, false, 0), b); err != nil {
+			return false, err
+		}
+		if b.Len() == 0 {
+			return true, nil
+		}
+		prompt := "Apply " + chezmoi.TargetPath(c.DestDir, entry.TargetName())
+		if _, ok := entry.(*chezmoi.Script); ok {
+			prompt = "Run " + chezmoi.TargetPath(c.DestDir, entry.TargetName())
+		}
+		for {
+			choice, err := c.prompt(prompt, "ynaqd")
+			switch {
+			case errors.Is(err, io.EOF):
+				return false, fmt.Errorf("%s: cannot confirm without input", prompt)
+			case err != nil:
+				return false, err
+			}
+			switch choice {
+			case 'y':
+				return true, nil
+			case 'n':
+				return false, nil
+			case 'a':
+				all = true
+				return true, nil
+			case 'q':
+				return false, errConfirmQuit
+			case 'd':
+				if err := preview(c.newDiffMutator(c.Stdout, chezmoi.NullMutator{}), c.Stdout); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+}
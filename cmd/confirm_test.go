@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestConfirm(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		force     bool
+		noConfirm bool
+		stdin     string
+		want      bool
+		wantForce bool
+		wantErr   error
+	}{
+		{
+			name:      "force",
+			force:     true,
+			want:      true,
+			wantForce: true,
+		},
+		{
+			name:      "no_confirm",
+			noConfirm: true,
+			want:      true,
+		},
+		{
+			name:  "yes",
+			stdin: "y\n",
+			want:  true,
+		},
+		{
+			name:  "no",
+			stdin: "n\n",
+			want:  false,
+		},
+		{
+			name:  "invalid_then_yes",
+			stdin: "x\ny\n",
+			want:  true,
+		},
+		{
+			name:      "all",
+			stdin:     "a\n",
+			want:      true,
+			wantForce: true,
+		},
+		{
+			name:    "quit",
+			stdin:   "q\n",
+			wantErr: errConfirmQuit,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newConfig(
+				withStdin(bytes.NewBufferString(tc.stdin)),
+			)
+			c.NoConfirm = tc.noConfirm
+			force := tc.force
+			got, err := c.confirm("Proceed", &force)
+			if tc.wantErr != nil {
+				assert.Equal(t, tc.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantForce, force)
+		})
+	}
+}
+
+func TestConfirmNoInput(t *testing.T) {
+	c := newConfig(
+		withStdin(&bytes.Buffer{}),
+	)
+	force := false
+	_, err := c.confirm("Proceed", &force)
+	assert.Error(t, err)
+}
+
+func TestApplyConfirmOverwrite(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		stdin        string
+		force        bool
+		wantContents string
+	}{
+		{
+			name:         "overwrite",
+			stdin:        "y\n",
+			wantContents: "# contents of .bashrc\n",
+		},
+		{
+			name:         "keep",
+			stdin:        "n\n",
+			wantContents: "# local edit\n",
+		},
+		{
+			name:         "no_input",
+			wantContents: "# contents of .bashrc\n",
+		},
+		{
+			name:         "force",
+			force:        true,
+			wantContents: "# contents of .bashrc\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc": "# local edit\n",
+					".local/share/chezmoi": map[string]interface{}{
+						"dot_bashrc": "# contents of .bashrc\n",
+					},
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(fs,
+				withStdin(bytes.NewBufferString(tc.stdin)),
+				withStdout(&bytes.Buffer{}),
+			)
+			persistentState, err := c.getPersistentState(nil)
+			require.NoError(t, err)
+			defer persistentState.Close()
+			value, err := json.Marshal(&entryState{
+				AppliedAt: time.Now().Add(-time.Hour).UTC(),
+			})
+			require.NoError(t, err)
+			require.NoError(t, persistentState.Set(c.entryStateBucket, []byte("/home/user/.bashrc"), value))
+			force := tc.force
+			require.NoError(t, c.applyArgsAndReport(nil, persistentState, "", &force))
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString(tc.wantContents),
+				),
+			)
+		})
+	}
+}
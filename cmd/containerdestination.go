@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var (
+	containerNameRegexp = regexp.MustCompile(`\A[A-Za-z0-9][A-Za-z0-9_.-]*\z`)
+	containerUserRegexp = regexp.MustCompile(`\A[A-Za-z0-9_][A-Za-z0-9_.-]*(?::[A-Za-z0-9_][A-Za-z0-9_.-]*)?\z`)
+)
+
+type containerConfig struct {
+	Command string
+	Args    []string
+}
+
+// isContainerDestination returns whether destDir is a docker:// or podman://
+// URL.
+func isContainerDestination(destDir string) bool {
+	return strings.HasPrefix(destDir, "docker://") || strings.HasPrefix(destDir, "podman://")
+}
+
+// newContainerDestination returns a new remoteDestination for rawURL, a
+// docker:// or podman:// URL, and the directory in the container. Commands are
+// run in the container with docker exec or podman exec.
+func (c *Config) newContainerDestination(rawURL string) (*remoteDestination, string, error) {
+	engine, user, container, remoteDir, err := parseContainerDestination(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	containerConfig := c.Docker
+	if engine == "podman" {
+		containerConfig = c.Podman
+	}
+	args := append([]string{}, containerConfig.Args...)
+	args = append(args, "exec", "--interactive")
+	if user != "" {
+		args = append(args, "--user", user)
+	}
+	args = append(args, container, "sh", "-c")
+	return &remoteDestination{
+		url:  rawURL,
+		name: engine + "://" + container,
+		fs:   chezmoi.NewRemoteFS(containerConfig.Command, args),
+	}, remoteDir, nil
+}
+
+// parseContainerDestination parses rawURL, a
+// (docker|podman)://[user@]container[/path] URL, and returns the container
+// engine, user, container, and path. A path of ~, or beginning with ~/, is
+// relative to the user's home directory in the container.
+func parseContainerDestination(rawURL string) (string, string, string, string, error) {
+	i := strings.Index(rawURL, "://")
+	if i == -1 {
+		return "", "", "", "", fmt.Errorf("%s: not a container URL", rawURL)
+	}
+	engine, container := rawURL[:i], rawURL[i+3:]
+	if engine != "docker" && engine != "podman" {
+		return "", "", "", "", fmt.Errorf("%s: unsupported container engine", rawURL)
+	}
+	var remoteDir string
+	if j := strings.IndexByte(container, '/'); j != -1 {
+		container, remoteDir = container[:j], container[j:]
+	}
+	var user string
+	if j := strings.LastIndexByte(container, '@'); j != -1 {
+		user, container = container[:j], container[j+1:]
+		if !containerUserRegexp.MatchString(user) {
+			return "", "", "", "", fmt.Errorf("%s: invalid user", rawURL)
+		}
+	}
+	if !containerNameRegexp.MatchString(container) {
+		return "", "", "", "", fmt.Errorf("%s: invalid container", rawURL)
+	}
+	return engine, user, container, remoteDestinationPath(remoteDir), nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+var cpCmd = &cobra.Command{
+	Use:      "cp source target",
+	Args:     cobra.ExactArgs(2),
+	Short:    "Copy a target in the source state",
+	Long:     mustGetLongHelp("cp"),
+	Example:  getExample("cp"),
+	PreRunE:  config.ensureNoError,
+	RunE:     config.runCpCmd,
+	PostRunE: config.autoCommitAndAutoPush,
+}
+
+type cpCmdConfig struct {
+	template bool
+}
+
+func init() {
+	rootCmd.AddCommand(cpCmd)
+
+	persistentFlags := cpCmd.PersistentFlags()
+	persistentFlags.BoolVarP(&config.cp.template, "template", "T", false, "copy as a template")
+
+	markRemainingZshCompPositionalArgumentsAsFiles(cpCmd, 1)
+}
+
+func (c *Config) runCpCmd(cmd *cobra.Command, args []string) error {
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	entries, err := c.getEntries(ts, args[:1])
+	if err != nil {
+		return err
+	}
+	entry := entries[0]
+
+	newTargetPath, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+	if _, err := ts.Get(c.fs, newTargetPath); err == nil {
+		return fmt.Errorf("%s: already managed", newTargetPath)
+	}
+	oldSourcePath, newSourcePath, err := c.getNewSourcePath(ts, entry, newTargetPath, c.cp.template)
+	if err != nil {
+		return err
+	}
+	if _, err := c.fs.Lstat(newSourcePath); err == nil {
+		return fmt.Errorf("%s: already exists", newSourcePath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	umask := os.FileMode(c.Umask)
+	return vfs.Walk(c.fs, oldSourcePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(oldSourcePath, path)
+		if err != nil {
+			return err
+		}
+		newPath := filepath.Join(newSourcePath, relPath)
+		switch {
+		case info.IsDir():
+			return c.mutator.Mkdir(newPath, 0777&^umask)
+		case info.Mode().IsRegular():
+			data, err := c.fs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return c.mutator.WriteFile(newPath, data, 0666&^umask, nil)
+		default:
+			return fmt.Errorf("%s: unsupported file type", path)
+		}
+	})
+}
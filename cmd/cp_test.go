@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestCpCommand(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		template bool
+		root     interface{}
+		wantErr  bool
+		tests    interface{}
+	}{
+		{
+			name: "file",
+			args: []string{"/home/user/.bashrc", "/home/user/.bashrc.local"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/private_executable_dot_bashrc": "# contents of .bashrc\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_executable_dot_bashrc",
+					vfst.TestContentsString("# contents of .bashrc\n"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_executable_dot_bashrc.local",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0644),
+					vfst.TestContentsString("# contents of .bashrc\n"),
+				),
+				vfst.TestPath("/home/user/.bashrc.local",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name:     "template",
+			args:     []string{"/home/user/.gitconfig", "/home/user/.config/git/config"},
+			template: true,
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_gitconfig":    "[core]\n",
+					"dot_config/git":   &vfst.Dir{Perm: 0755},
+					"symlink_dot_foo":  "bar",
+					"empty_dot_random": "",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_config/git/config.tmpl",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("[core]\n"),
+				),
+			},
+		},
+		{
+			name: "dir",
+			args: []string{"/home/user/.foo", "/home/user/.bar"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/exact_private_dot_foo": map[string]interface{}{
+					"baz":            "baz",
+					"qux/quux":       "quux",
+					".chezmoiignore": "baz\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/exact_private_dot_foo/baz",
+					vfst.TestContentsString("baz"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/exact_private_dot_bar",
+					vfst.TestIsDir,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/exact_private_dot_bar/baz",
+					vfst.TestContentsString("baz"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/exact_private_dot_bar/qux/quux",
+					vfst.TestContentsString("quux"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/exact_private_dot_bar/.chezmoiignore",
+					vfst.TestContentsString("baz\n"),
+				),
+			},
+		},
+		{
+			name:     "dir_template",
+			args:     []string{"/home/user/.foo", "/home/user/.bar"},
+			template: true,
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_foo/baz": "baz",
+			},
+			wantErr: true,
+		},
+		{
+			name: "target_managed",
+			args: []string{"/home/user/foo", "/home/user/bar"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"foo":         "foo",
+					"private_bar": "bar",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "parent_not_managed",
+			args: []string{"/home/user/foo", "/home/user/dir/foo"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/foo": "foo",
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(fs)
+			c.cp.template = tc.template
+			err = c.runCpCmd(nil, tc.args)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			vfst.RunTests(t, fs, "", tc.tests)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// +build dragonfly freebsd netbsd openbsd solaris
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/twpayne/go-vfs"
+	"golang.org/x/sys/unix"
+)
+
+// getKernelInfo returns the same kernel information as is read from
+// /proc/sys/kernel on Linux, using uname(2).
+func getKernelInfo(fs vfs.FS) (map[string]string, error) {
+	var utsname unix.Utsname
+	if err := unix.Uname(&utsname); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"osrelease": utsnameString(utsname.Release[:]),
+		"ostype":    utsnameString(utsname.Sysname[:]),
+		"version":   utsnameString(utsname.Version[:]),
+	}, nil
+}
+
+// getOSRelease returns the operating system identification data as defined by
+// https://www.freedesktop.org/software/systemd/man/os-release.html. If there is
+// no os-release file then it is derived from uname(2) and, on illumos and
+// Solaris, the first line of /etc/release.
+func getOSRelease(fs vfs.FS) (map[string]string, error) {
+	osRelease, err := readOSRelease(fs, []string{"/etc/os-release", "/var/run/os-release", "/usr/lib/os-release"})
+	if !os.IsNotExist(err) {
+		return osRelease, err
+	}
+	var utsname unix.Utsname
+	if err := unix.Uname(&utsname); err != nil {
+		return nil, err
+	}
+	prettyName := ""
+	if data, err := fs.ReadFile("/etc/release"); err == nil {
+		prettyName = string(bytes.TrimSpace(bytes.SplitN(data, []byte("\n"), 2)[0]))
+	}
+	return newOSReleaseFromUname(utsnameString(utsname.Sysname[:]), utsnameString(utsname.Release[:]), prettyName), nil
+}
+
+// utsnameString returns the NUL-terminated string in field.
+func utsnameString(field []byte) string {
+	if i := bytes.IndexByte(field, 0); i >= 0 {
+		field = field[:i]
+	}
+	return string(field)
+}
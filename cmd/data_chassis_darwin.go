@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// getChassis returns the chassis of the machine chezmoi is running on and
+// whether it has a battery. Macs with a battery are laptops and Macs without
+// are desktops.
+func (c *Config) getChassis() (string, bool) {
+	if vmmPresent, err := unix.SysctlUint32("kern.hv_vmm_present"); err == nil && vmmPresent != 0 {
+		return "vm", false
+	}
+	if model, err := unix.Sysctl("hw.model"); err == nil {
+		for _, prefix := range []string{"Parallels", "VMware", "VirtualMac"} {
+			if strings.HasPrefix(model, prefix) {
+				return "vm", false
+			}
+		}
+	}
+
+	cmd := exec.Command("ioreg", "-r", "-c", "AppleSmartBattery")
+	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	if err != nil {
+		return "", false
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return "desktop", false
+	}
+	return "laptop", true
+}
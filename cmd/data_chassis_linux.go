@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// smbiosChassisTypes maps SMBIOS chassis types to chassis, following
+// systemd-hostnamed. Types that do not identify a chassis, like "Other" and
+// "Unknown", are omitted.
+var smbiosChassisTypes = map[int]string{
+	0x03: "desktop",     // Desktop
+	0x04: "desktop",     // Low Profile Desktop
+	0x06: "desktop",     // Mini Tower
+	0x07: "desktop",     // Tower
+	0x08: "laptop",      // Portable
+	0x09: "laptop",      // Laptop
+	0x0a: "laptop",      // Notebook
+	0x0b: "handset",     // Hand Held
+	0x0d: "desktop",     // All In One
+	0x0e: "laptop",      // Sub Notebook
+	0x11: "server",      // Main Server Chassis
+	0x17: "server",      // Rack Mount Chassis
+	0x1c: "server",      // Blade
+	0x1d: "server",      // Blade Enclosure
+	0x1e: "tablet",      // Tablet
+	0x1f: "convertible", // Convertible
+	0x20: "convertible", // Detachable
+	0x21: "embedded",    // IoT Gateway
+	0x22: "embedded",    // Embedded PC
+	0x23: "desktop",     // Mini PC
+	0x24: "desktop",     // Stick PC
+}
+
+// acpiPMProfiles maps ACPI preferred power management profiles to chassis.
+var acpiPMProfiles = map[int]string{
+	1: "desktop", // Desktop
+	2: "laptop",  // Mobile
+	3: "desktop", // Workstation
+	4: "server",  // Enterprise Server
+	5: "server",  // SOHO Server
+	6: "desktop", // Appliance PC
+	7: "server",  // Performance Server
+	8: "tablet",  // Tablet
+}
+
+// hypervisorVendors are the DMI system vendors and product names of common
+// hypervisors.
+var hypervisorVendors = []string{
+	"Bochs",
+	"KVM",
+	"Parallels",
+	"QEMU",
+	"VMware",
+	"VirtualBox",
+	"Xen",
+	"innotek GmbH",
+}
+
+// getChassis returns the chassis of the machine chezmoi is running on and
+// whether it has a battery. The chassis is determined in the same way as
+// hostnamectl: the CHASSIS variable in /etc/machine-info, then whether chezmoi
+// is running in a container or a virtual machine, then the SMBIOS chassis type,
+// and finally the ACPI power management profile. Batteries in peripherals, like
+// wireless mice, are ignored.
+func (c *Config) getChassis() (string, bool) {
+	return getLinuxChassis(c.fs), hasSystemBattery(c.fs)
+}
+
+func getLinuxChassis(fs vfs.FS) string {
+	if data, err := fs.ReadFile("/etc/machine-info"); err == nil {
+		if machineInfo, err := parseOSRelease(bytes.NewBuffer(data)); err == nil && machineInfo["CHASSIS"] != "" {
+			return machineInfo["CHASSIS"]
+		}
+	}
+
+	if getContainer(fs) != "" {
+		return "container"
+	}
+	if isVirtualMachine(fs) {
+		return "vm"
+	}
+
+	if chassisType, ok := readIntFile(fs, "/sys/class/dmi/id/chassis_type"); ok {
+		if chassis, ok := smbiosChassisTypes[chassisType]; ok {
+			return chassis
+		}
+	}
+	if pmProfile, ok := readIntFile(fs, "/sys/firmware/acpi/pm_profile"); ok {
+		if chassis, ok := acpiPMProfiles[pmProfile]; ok {
+			return chassis
+		}
+	}
+	return ""
+}
+
+// isVirtualMachine returns whether chezmoi is running in a virtual machine.
+func isVirtualMachine(fs vfs.FS) bool {
+	if _, err := fs.Stat("/sys/hypervisor/type"); err == nil {
+		return true
+	}
+	if getCloudProvider(fs) != "" {
+		return true
+	}
+	for _, name := range []string{"sys_vendor", "product_name"} {
+		data, err := fs.ReadFile("/sys/class/dmi/id/" + name)
+		if err != nil {
+			continue
+		}
+		value := string(bytes.TrimSpace(data))
+		for _, vendor := range hypervisorVendors {
+			if strings.HasPrefix(value, vendor) {
+				return true
+			}
+		}
+		if value == "Virtual Machine" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSystemBattery returns whether any of the power supplies in
+// /sys/class/power_supply is a battery that powers the system.
+func hasSystemBattery(fs vfs.FS) bool {
+	const powerSupplyDir = "/sys/class/power_supply"
+	infos, err := fs.ReadDir(powerSupplyDir)
+	if err != nil {
+		return false
+	}
+	for _, info := range infos {
+		typ, err := fs.ReadFile(filepath.Join(powerSupplyDir, info.Name(), "type"))
+		if err != nil || string(bytes.TrimSpace(typ)) != "Battery" {
+			continue
+		}
+		if scope, err := fs.ReadFile(filepath.Join(powerSupplyDir, info.Name(), "scope")); err == nil && string(bytes.TrimSpace(scope)) == "Device" {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// readIntFile returns the integer in filename and whether it could be read.
+func readIntFile(fs vfs.FS, filename string) (int, bool) {
+	data, err := fs.ReadFile(filename)
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.Atoi(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestGetLinuxChassis(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		root               interface{}
+		expectedChassis    string
+		expectedHasBattery bool
+	}{
+		{
+			name:            "empty",
+			root:            map[string]interface{}{},
+			expectedChassis: "",
+		},
+		{
+			name: "laptop",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id/chassis_type": "10\n",
+				"/sys/class/power_supply": map[string]interface{}{
+					"AC/type":   "Mains\n",
+					"BAT0/type": "Battery\n",
+				},
+			},
+			expectedChassis:    "laptop",
+			expectedHasBattery: true,
+		},
+		{
+			name: "desktop_with_wireless_mouse",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id/chassis_type": "3\n",
+				"/sys/class/power_supply/hidpp_battery_0": map[string]interface{}{
+					"scope": "Device\n",
+					"type":  "Battery\n",
+				},
+			},
+			expectedChassis: "desktop",
+		},
+		{
+			name: "server",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id/chassis_type": "23\n",
+			},
+			expectedChassis: "server",
+		},
+		{
+			name: "acpi_pm_profile",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id/chassis_type": "2\n",
+				"/sys/firmware/acpi/pm_profile":  "2\n",
+			},
+			expectedChassis: "laptop",
+		},
+		{
+			name: "qemu",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id": map[string]interface{}{
+					"chassis_type": "1\n",
+					"sys_vendor":   "QEMU\n",
+				},
+			},
+			expectedChassis: "vm",
+		},
+		{
+			name: "hyper_v",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id": map[string]interface{}{
+					"chassis_type": "3\n",
+					"product_name": "Virtual Machine\n",
+					"sys_vendor":   "Microsoft Corporation\n",
+				},
+			},
+			expectedChassis: "vm",
+		},
+		{
+			name: "docker",
+			root: map[string]interface{}{
+				"/.dockerenv":                    "",
+				"/sys/class/dmi/id/chassis_type": "10\n",
+			},
+			expectedChassis: "container",
+		},
+		{
+			name: "machine_info",
+			root: map[string]interface{}{
+				"/etc/machine-info":              "PRETTY_HOSTNAME=\"Laptop\"\nCHASSIS=convertible\n",
+				"/sys/class/dmi/id/chassis_type": "10\n",
+			},
+			expectedChassis: "convertible",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			assert.Equal(t, tc.expectedChassis, getLinuxChassis(fs))
+			assert.Equal(t, tc.expectedHasBattery, hasSystemBattery(fs))
+		})
+	}
+}
@@ -0,0 +1,9 @@
+// +build !darwin,!linux,!windows
+
+package cmd
+
+// getChassis returns the chassis of the machine chezmoi is running on and
+// whether it has a battery, which cannot be determined on this platform.
+func (c *Config) getChassis() (string, bool) {
+	return "", false
+}
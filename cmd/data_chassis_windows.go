@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// noSystemBattery is the BatteryFlag reported by GetSystemPowerStatus when the
+// system does not have a battery.
+const noSystemBattery = 128
+
+var procGetSystemPowerStatus = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetSystemPowerStatus")
+
+// systemPowerStatus is a SYSTEM_POWER_STATUS.
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// getChassis returns the chassis of the machine chezmoi is running on and
+// whether it has a battery. Virtual machines are recognized from the system
+// manufacturer and product name reported by the BIOS, machines with a battery
+// are laptops, and all others are desktops.
+func (c *Config) getChassis() (string, bool) {
+	if key, err := registry.OpenKey(registry.LOCAL_MACHINE, `HARDWARE\DESCRIPTION\System\BIOS`, registry.QUERY_VALUE); err == nil {
+		defer key.Close()
+		manufacturer, _, _ := key.GetStringValue("SystemManufacturer")
+		productName, _, _ := key.GetStringValue("SystemProductName")
+		for _, value := range []string{manufacturer, productName} {
+			for _, vendor := range []string{"Parallels", "QEMU", "VMware", "VirtualBox", "Xen", "innotek GmbH"} {
+				if strings.HasPrefix(value, vendor) {
+					return "vm", false
+				}
+			}
+		}
+		if productName == "Virtual Machine" {
+			return "vm", false
+		}
+	}
+
+	var status systemPowerStatus
+	if r, _, _ := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status))); r == 0 {
+		return "", false
+	}
+	if status.BatteryFlag&noSystemBattery != 0 {
+		return "desktop", false
+	}
+	return "laptop", true
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// hiDPIThreshold is the DPI from which displays are scaled by a factor of two,
+// halfway between the traditional 96 DPI and double that.
+const hiDPIThreshold = 144
+
+// getDisplayData returns template data describing the machine's graphics: the
+// display server of the current session, the vendors of its GPUs, the highest
+// DPI of its connected displays, and its scale factor. Facts that cannot be
+// determined are left empty.
+func getDisplayData(fs vfs.FS, getenv func(string) string) map[string]interface{} {
+	gpuVendors, primaryGPUVendor := getGPUVendors(fs)
+	dpi := getDisplayDPI(fs)
+	scale := getDisplayScale(getenv, dpi)
+	return map[string]interface{}{
+		"dpi":        dpi,
+		"gpuVendor":  primaryGPUVendor,
+		"gpuVendors": gpuVendors,
+		"hiDPI":      scale > 1,
+		"scale":      scale,
+		"server":     getDisplayServer(getenv),
+	}
+}
+
+// getDisplayServer returns the display server of the current session, either
+// wayland or x11, or the empty string if there is none, for example in an SSH
+// session.
+func getDisplayServer(getenv func(string) string) string {
+	switch sessionType := getenv("XDG_SESSION_TYPE"); {
+	case sessionType == "wayland" || sessionType == "x11":
+		return sessionType
+	case getenv("WAYLAND_DISPLAY") != "":
+		return "wayland"
+	case getenv("DISPLAY") != "":
+		return "x11"
+	default:
+		return ""
+	}
+}
+
+// getDisplayScale returns the scale factor set by the GDK_SCALE or
+// QT_SCALE_FACTOR environment variables or, if neither is set, the integer
+// scale factor suited to a display with dpi. It is 1 if dpi is unknown.
+func getDisplayScale(getenv func(string) string, dpi int) float64 {
+	for _, key := range []string{"GDK_SCALE", "QT_SCALE_FACTOR"} {
+		if scale, err := strconv.ParseFloat(getenv(key), 64); err == nil && scale > 0 {
+			return scale
+		}
+	}
+	if dpi < hiDPIThreshold {
+		return 1
+	}
+	return math.Round(float64(dpi) / 96)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/twpayne/go-vfs"
+)
+
+const sysClassDRM = "/sys/class/drm"
+
+var (
+	drmCardRegexp      = regexp.MustCompile(`\Acard\d+\z`)
+	drmConnectorRegexp = regexp.MustCompile(`\Acard\d+-`)
+	drmModeRegexp      = regexp.MustCompile(`\A(\d+)x(\d+)`)
+	edidHeader         = []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}
+)
+
+// pciGPUVendors maps PCI vendor IDs to GPU vendors.
+var pciGPUVendors = map[string]string{
+	"0x1002": "amd",
+	"0x10de": "nvidia",
+	"0x15ad": "vmware",
+	"0x1af4": "virtio",
+	"0x8086": "intel",
+}
+
+// getGPUVendors returns the sorted vendors of the GPUs in /sys/class/drm and
+// the vendor of the GPU that the firmware used to boot, or of the only GPU.
+func getGPUVendors(fs vfs.FS) ([]string, string) {
+	gpuVendors := []string{}
+	primaryGPUVendor := ""
+	infos, err := fs.ReadDir(sysClassDRM)
+	if err != nil {
+		return gpuVendors, primaryGPUVendor
+	}
+	vendors := make(map[string]struct{})
+	for _, info := range infos {
+		if !drmCardRegexp.MatchString(info.Name()) {
+			continue
+		}
+		deviceDir := filepath.Join(sysClassDRM, info.Name(), "device")
+		data, err := fs.ReadFile(filepath.Join(deviceDir, "vendor"))
+		if err != nil {
+			continue
+		}
+		vendor, ok := pciGPUVendors[string(bytes.TrimSpace(data))]
+		if !ok {
+			continue
+		}
+		vendors[vendor] = struct{}{}
+		if bootVGA, err := fs.ReadFile(filepath.Join(deviceDir, "boot_vga")); err == nil && string(bytes.TrimSpace(bootVGA)) == "1" {
+			primaryGPUVendor = vendor
+		}
+	}
+	for vendor := range vendors {
+		gpuVendors = append(gpuVendors, vendor)
+	}
+	sort.Strings(gpuVendors)
+	if primaryGPUVendor == "" && len(gpuVendors) == 1 {
+		primaryGPUVendor = gpuVendors[0]
+	}
+	return gpuVendors, primaryGPUVendor
+}
+
+// getDisplayDPI returns the highest DPI of the connected displays in
+// /sys/class/drm, calculated from the width of their preferred modes and the
+// physical width in their EDIDs, or 0 if it cannot be determined.
+func getDisplayDPI(fs vfs.FS) int {
+	infos, err := fs.ReadDir(sysClassDRM)
+	if err != nil {
+		return 0
+	}
+	maxDPI := 0
+	for _, info := range infos {
+		if !drmConnectorRegexp.MatchString(info.Name()) {
+			continue
+		}
+		connectorDir := filepath.Join(sysClassDRM, info.Name())
+		if status, err := fs.ReadFile(filepath.Join(connectorDir, "status")); err != nil || string(bytes.TrimSpace(status)) != "connected" {
+			continue
+		}
+		modes, err := fs.ReadFile(filepath.Join(connectorDir, "modes"))
+		if err != nil {
+			continue
+		}
+		m := drmModeRegexp.FindSubmatch(modes)
+		if m == nil {
+			continue
+		}
+		widthPixels, _ := strconv.Atoi(string(m[1]))
+		edid, err := fs.ReadFile(filepath.Join(connectorDir, "edid"))
+		if err != nil || len(edid) < 23 || !bytes.Equal(edid[:8], edidHeader) {
+			continue
+		}
+		// Byte 21 of the EDID is the maximum horizontal image size in
+		// centimeters, which is zero for projectors.
+		widthCM := int(edid[21])
+		if widthCM == 0 {
+			continue
+		}
+		if dpi := int(math.Round(float64(widthPixels) * 2.54 / float64(widthCM))); dpi > maxDPI {
+			maxDPI = dpi
+		}
+	}
+	return maxDPI
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+// newTestEDID returns an EDID for a display that is widthCM centimeters wide.
+func newTestEDID(widthCM byte) string {
+	edid := make([]byte, 128)
+	copy(edid, []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00})
+	edid[21] = widthCM
+	return string(edid)
+}
+
+func TestGetGPUVendors(t *testing.T) {
+	for _, tc := range []struct {
+		name                     string
+		root                     interface{}
+		expectedGPUVendors       []string
+		expectedPrimaryGPUVendor string
+	}{
+		{
+			name:               "none",
+			root:               map[string]interface{}{},
+			expectedGPUVendors: []string{},
+		},
+		{
+			name: "single",
+			root: map[string]interface{}{
+				"/sys/class/drm": map[string]interface{}{
+					"card0/device/vendor": "0x8086\n",
+					"card0-eDP-1/status":  "connected\n",
+					"renderD128":          &vfst.Dir{Perm: 0755},
+				},
+			},
+			expectedGPUVendors:       []string{"intel"},
+			expectedPrimaryGPUVendor: "intel",
+		},
+		{
+			name: "hybrid",
+			root: map[string]interface{}{
+				"/sys/class/drm": map[string]interface{}{
+					"card0/device/boot_vga": "0\n",
+					"card0/device/vendor":   "0x10de\n",
+					"card1/device/boot_vga": "1\n",
+					"card1/device/vendor":   "0x8086\n",
+				},
+			},
+			expectedGPUVendors:       []string{"intel", "nvidia"},
+			expectedPrimaryGPUVendor: "intel",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			actualGPUVendors, actualPrimaryGPUVendor := getGPUVendors(fs)
+			assert.Equal(t, tc.expectedGPUVendors, actualGPUVendors)
+			assert.Equal(t, tc.expectedPrimaryGPUVendor, actualPrimaryGPUVendor)
+		})
+	}
+}
+
+func TestGetDisplayDPI(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		root     interface{}
+		expected int
+	}{
+		{
+			name:     "none",
+			root:     map[string]interface{}{},
+			expected: 0,
+		},
+		{
+			name: "laptop_with_external_display",
+			root: map[string]interface{}{
+				"/sys/class/drm": map[string]interface{}{
+					"card0-DP-1": map[string]interface{}{
+						"edid":   newTestEDID(60),
+						"modes":  "2560x1440\n1920x1080\n",
+						"status": "connected\n",
+					},
+					"card0-eDP-1": map[string]interface{}{
+						"edid":   newTestEDID(30),
+						"modes":  "2880x1800\n",
+						"status": "connected\n",
+					},
+					"card0-HDMI-A-1": map[string]interface{}{
+						"edid":   "",
+						"modes":  "",
+						"status": "disconnected\n",
+					},
+				},
+			},
+			expected: 244,
+		},
+		{
+			name: "projector",
+			root: map[string]interface{}{
+				"/sys/class/drm/card0-HDMI-A-1": map[string]interface{}{
+					"edid":   newTestEDID(0),
+					"modes":  "1920x1080\n",
+					"status": "connected\n",
+				},
+			},
+			expected: 0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			assert.Equal(t, tc.expected, getDisplayDPI(fs))
+		})
+	}
+}
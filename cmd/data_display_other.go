@@ -0,0 +1,13 @@
+// +build !linux
+
+package cmd
+
+import "github.com/twpayne/go-vfs"
+
+func getGPUVendors(fs vfs.FS) ([]string, string) {
+	return []string{}, ""
+}
+
+func getDisplayDPI(fs vfs.FS) int {
+	return 0
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDisplayServer(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{
+			name:     "none",
+			expected: "",
+		},
+		{
+			name: "session_type",
+			env: map[string]string{
+				"DISPLAY":          ":0",
+				"XDG_SESSION_TYPE": "wayland",
+			},
+			expected: "wayland",
+		},
+		{
+			name: "tty_session",
+			env: map[string]string{
+				"XDG_SESSION_TYPE": "tty",
+			},
+			expected: "",
+		},
+		{
+			name: "wayland_display",
+			env: map[string]string{
+				"DISPLAY":         ":0",
+				"WAYLAND_DISPLAY": "wayland-0",
+			},
+			expected: "wayland",
+		},
+		{
+			name: "display",
+			env: map[string]string{
+				"DISPLAY": ":0",
+			},
+			expected: "x11",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				return tc.env[key]
+			}
+			assert.Equal(t, tc.expected, getDisplayServer(getenv))
+		})
+	}
+}
+
+func TestGetDisplayScale(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		env      map[string]string
+		dpi      int
+		expected float64
+	}{
+		{
+			name:     "unknown",
+			expected: 1,
+		},
+		{
+			name:     "low_dpi",
+			dpi:      96,
+			expected: 1,
+		},
+		{
+			name:     "high_dpi",
+			dpi:      220,
+			expected: 2,
+		},
+		{
+			name: "gdk_scale",
+			env: map[string]string{
+				"GDK_SCALE": "2",
+			},
+			dpi:      96,
+			expected: 2,
+		},
+		{
+			name: "qt_scale_factor",
+			env: map[string]string{
+				"QT_SCALE_FACTOR": "1.5",
+			},
+			dpi:      220,
+			expected: 1.5,
+		},
+		{
+			name: "invalid",
+			env: map[string]string{
+				"GDK_SCALE": "x",
+			},
+			expected: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				return tc.env[key]
+			}
+			assert.Equal(t, tc.expected, getDisplayScale(getenv, tc.dpi))
+		})
+	}
+}
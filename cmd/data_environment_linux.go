@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// Azure and Oracle Cloud do not set a recognizable system vendor, but do set a
+// fixed chassis asset tag.
+const (
+	azureChassisAssetTag  = "7783-7084-3265-9085-8269-3286-77"
+	oracleChassisAssetTag = "OracleCloud.com"
+)
+
+// getEnvironmentInfo returns what can be detected about the environment that
+// chezmoi is running in on Linux: the version of the Windows Subsystem for
+// Linux, the container, and the cloud provider. Every key is always set, to
+// the empty string if nothing was detected, so templates can compare against
+// them without checking that they exist.
+func getEnvironmentInfo(fs vfs.FS, kernelInfo map[string]string) map[string]string {
+	return map[string]string{
+		"wsl":       getWSLVersion(kernelInfo),
+		"container": getContainer(fs),
+		"cloud":     getCloudProvider(fs),
+	}
+}
+
+// getWSLVersion returns the major version of the Windows Subsystem for Linux
+// that chezmoi is running in, determined from the kernel release, or the empty
+// string if chezmoi is not running in WSL. WSL 1 kernel releases end in
+// "-Microsoft" and WSL 2 kernels are "-microsoft-standard" builds.
+func getWSLVersion(kernelInfo map[string]string) string {
+	osRelease := strings.ToLower(kernelInfo["osrelease"])
+	switch {
+	case !strings.Contains(osRelease, "microsoft"):
+		return ""
+	case strings.Contains(osRelease, "microsoft-standard"), strings.Contains(osRelease, "wsl2"):
+		return "2"
+	default:
+		return "1"
+	}
+}
+
+// getContainer returns the name of the container runtime that chezmoi is
+// running in, or the empty string if chezmoi does not appear to be running in
+// a container. ChromeOS's Linux environment, Crostini, runs in an LXC
+// container but is reported as crostini.
+func getContainer(fs vfs.FS) string {
+	for _, marker := range []struct {
+		name      string
+		container string
+	}{
+		{"/dev/.cros_milestone", "crostini"},
+		{"/run/.containerenv", "podman"},
+		{"/.dockerenv", "docker"},
+	} {
+		if _, err := fs.Lstat(marker.name); err == nil {
+			return marker.container
+		}
+	}
+
+	// systemd and most container runtimes set the container environment
+	// variable of PID 1, which systemd also writes to /run/systemd/container.
+	if data, err := fs.ReadFile("/run/systemd/container"); err == nil {
+		if container := string(bytes.TrimSpace(data)); container != "" {
+			return container
+		}
+	}
+	if data, err := fs.ReadFile("/proc/1/environ"); err == nil {
+		for _, env := range bytes.Split(data, []byte{0}) {
+			if bytes.HasPrefix(env, []byte("container=")) {
+				return string(env[len("container="):])
+			}
+		}
+	}
+
+	// Fall back to the control group of PID 1, which is only reliable with
+	// cgroup v1.
+	if data, err := fs.ReadFile("/proc/1/cgroup"); err == nil {
+		for _, container := range []string{"docker", "lxc"} {
+			if bytes.Contains(data, []byte("/"+container+"/")) {
+				return container
+			}
+		}
+	}
+
+	return ""
+}
+
+// getCloudProvider returns the cloud provider whose virtual machine chezmoi is
+// running on, determined from the DMI information in /sys/class/dmi/id, or the
+// empty string if it cannot be determined. No metadata services are queried.
+func getCloudProvider(fs vfs.FS) string {
+	dmi := make(map[string]string)
+	for _, name := range []string{
+		"bios_vendor",
+		"chassis_asset_tag",
+		"product_name",
+		"sys_vendor",
+	} {
+		data, err := fs.ReadFile("/sys/class/dmi/id/" + name)
+		switch {
+		case os.IsNotExist(err), os.IsPermission(err):
+			continue
+		case err != nil:
+			return ""
+		}
+		dmi[name] = string(bytes.TrimSpace(data))
+	}
+
+	switch {
+	case strings.HasPrefix(dmi["sys_vendor"], "Amazon"), strings.HasPrefix(dmi["bios_vendor"], "Amazon"):
+		return "aws"
+	case dmi["chassis_asset_tag"] == azureChassisAssetTag:
+		return "azure"
+	case dmi["sys_vendor"] == "DigitalOcean":
+		return "digitalocean"
+	case dmi["sys_vendor"] == "Google", dmi["product_name"] == "Google Compute Engine":
+		return "gcp"
+	case dmi["sys_vendor"] == "Hetzner":
+		return "hetzner"
+	case dmi["chassis_asset_tag"] == oracleChassisAssetTag:
+		return "oracle"
+	default:
+		return ""
+	}
+}
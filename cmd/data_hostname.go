@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// getFQDNHostname returns the fully-qualified domain name of hostname. If
+// hostname does not already contain a domain then the first name for hostname
+// in /etc/hosts that does is used. No DNS queries are made, so if no such name
+// is found then hostname is returned unchanged.
+func getFQDNHostname(fs vfs.FS, hostname string) string {
+	if strings.Contains(hostname, ".") {
+		return hostname
+	}
+	data, err := fs.ReadFile("/etc/hosts")
+	if err != nil {
+		return hostname
+	}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i != -1 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		names := fields[1:]
+		matched := false
+		for _, name := range names {
+			if name == hostname {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		for _, name := range names {
+			if strings.HasPrefix(name, hostname+".") {
+				return name
+			}
+		}
+	}
+	return hostname
+}
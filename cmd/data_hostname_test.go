@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestGetFQDNHostname(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		root     interface{}
+		hostname string
+		expected string
+	}{
+		{
+			name:     "already_qualified",
+			root:     map[string]interface{}{},
+			hostname: "host.example.com",
+			expected: "host.example.com",
+		},
+		{
+			name:     "no_etc_hosts",
+			root:     map[string]interface{}{},
+			hostname: "host",
+			expected: "host",
+		},
+		{
+			name: "etc_hosts",
+			root: map[string]interface{}{
+				"/etc/hosts": "" +
+					"# comment\n" +
+					"127.0.0.1 localhost\n" +
+					"127.0.1.1\thost.example.com host # comment\n" +
+					"10.0.0.1 host.other.example.com host\n",
+			},
+			hostname: "host",
+			expected: "host.example.com",
+		},
+		{
+			name: "etc_hosts_alias",
+			root: map[string]interface{}{
+				"/etc/hosts": "127.0.1.1 host host.example.com\n",
+			},
+			hostname: "host",
+			expected: "host.example.com",
+		},
+		{
+			name: "etc_hosts_other_host",
+			root: map[string]interface{}{
+				"/etc/hosts": "10.0.0.2 other.example.com other\n",
+			},
+			hostname: "host",
+			expected: "host",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			assert.Equal(t, tc.expected, getFQDNHostname(fs, tc.hostname))
+		})
+	}
+}
@@ -25,9 +25,12 @@ func TestGetKernelInfo(t *testing.T) {
 				},
 			},
 			expectedKernelInfo: map[string]string{
+				"cloud":     "",
+				"container": "",
 				"osrelease": "4.19.81-microsoft-standard",
 				"ostype":    "Linux",
 				"version":   "#1 SMP Debian 5.2.9-2 (2019-08-21)",
+				"wsl":       "2",
 			},
 		},
 		{
@@ -38,7 +41,10 @@ func TestGetKernelInfo(t *testing.T) {
 				},
 			},
 			expectedKernelInfo: map[string]string{
-				"version": "#1 SMP Debian 5.2.9-2 (2019-08-21)",
+				"cloud":     "",
+				"container": "",
+				"version":   "#1 SMP Debian 5.2.9-2 (2019-08-21)",
+				"wsl":       "",
 			},
 		},
 		{
@@ -60,6 +66,158 @@ func TestGetKernelInfo(t *testing.T) {
 	}
 }
 
+func TestGetEnvironmentInfo(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		root       interface{}
+		kernelInfo map[string]string
+		expected   map[string]string
+	}{
+		{
+			name: "none",
+			root: map[string]interface{}{
+				"/proc/1/cgroup": "0::/init.scope\n",
+			},
+			expected: map[string]string{
+				"cloud":     "",
+				"container": "",
+				"wsl":       "",
+			},
+		},
+		{
+			name: "wsl1",
+			root: map[string]interface{}{},
+			kernelInfo: map[string]string{
+				"osrelease": "4.4.0-19041-Microsoft",
+			},
+			expected: map[string]string{
+				"cloud":     "",
+				"container": "",
+				"wsl":       "1",
+			},
+		},
+		{
+			name: "wsl2",
+			root: map[string]interface{}{},
+			kernelInfo: map[string]string{
+				"osrelease": "5.10.16.3-microsoft-standard-WSL2",
+			},
+			expected: map[string]string{
+				"cloud":     "",
+				"container": "",
+				"wsl":       "2",
+			},
+		},
+		{
+			name: "docker",
+			root: map[string]interface{}{
+				"/.dockerenv": "",
+			},
+			expected: map[string]string{
+				"cloud":     "",
+				"container": "docker",
+				"wsl":       "",
+			},
+		},
+		{
+			name: "podman",
+			root: map[string]interface{}{
+				"/run/.containerenv":     "",
+				"/run/systemd/container": "podman\n",
+			},
+			expected: map[string]string{
+				"cloud":     "",
+				"container": "podman",
+				"wsl":       "",
+			},
+		},
+		{
+			name: "lxc_environ",
+			root: map[string]interface{}{
+				"/proc/1/environ": "PATH=/usr/bin\x00container=lxc\x00TERM=linux\x00",
+			},
+			expected: map[string]string{
+				"cloud":     "",
+				"container": "lxc",
+				"wsl":       "",
+			},
+		},
+		{
+			name: "docker_cgroup",
+			root: map[string]interface{}{
+				"/proc/1/cgroup": "12:pids:/docker/0123456789abcdef\n",
+			},
+			expected: map[string]string{
+				"cloud":     "",
+				"container": "docker",
+				"wsl":       "",
+			},
+		},
+		{
+			name: "crostini",
+			root: map[string]interface{}{
+				"/dev/.cros_milestone":   "96\n",
+				"/run/systemd/container": "lxc\n",
+			},
+			expected: map[string]string{
+				"cloud":     "",
+				"container": "crostini",
+				"wsl":       "",
+			},
+		},
+		{
+			name: "aws",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id": map[string]interface{}{
+					"bios_vendor":  "Amazon EC2\n",
+					"product_name": "t3.micro\n",
+					"sys_vendor":   "Amazon EC2\n",
+				},
+			},
+			expected: map[string]string{
+				"cloud":     "aws",
+				"container": "",
+				"wsl":       "",
+			},
+		},
+		{
+			name: "azure",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id": map[string]interface{}{
+					"chassis_asset_tag": "7783-7084-3265-9085-8269-3286-77\n",
+					"sys_vendor":        "Microsoft Corporation\n",
+				},
+			},
+			expected: map[string]string{
+				"cloud":     "azure",
+				"container": "",
+				"wsl":       "",
+			},
+		},
+		{
+			name: "gcp",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id": map[string]interface{}{
+					"product_name": "Google Compute Engine\n",
+					"sys_vendor":   "Google\n",
+				},
+			},
+			expected: map[string]string{
+				"cloud":     "gcp",
+				"container": "",
+				"wsl":       "",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			assert.Equal(t, tc.expected, getEnvironmentInfo(fs, tc.kernelInfo))
+		})
+	}
+}
+
 func TestGetOSRelease(t *testing.T) {
 	for _, tc := range []struct {
 		name string
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"strings"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// getNetworkData returns template data describing the machine's network: its
+// interfaces, the IP addresses used to reach the default routes, and the DNS
+// search domains. Facts that cannot be determined are left empty, so that a
+// machine without a network does not prevent templates from being executed.
+func getNetworkData(fs vfs.FS) map[string]interface{} {
+	domain, searchDomains := "", []string{}
+	if data, err := fs.ReadFile("/etc/resolv.conf"); err == nil {
+		domain, searchDomains = parseResolvConf(data)
+	}
+	return map[string]interface{}{
+		"domain":        domain,
+		"interfaces":    getNetworkInterfaces(),
+		"primaryIP":     getPrimaryIP("udp4", "192.0.2.1:9"),
+		"primaryIPv6":   getPrimaryIP("udp6", "[2001:db8::1]:9"),
+		"searchDomains": searchDomains,
+	}
+}
+
+// getNetworkInterfaces returns the machine's network interfaces, indexed by
+// name.
+func getNetworkInterfaces() map[string]interface{} {
+	result := make(map[string]interface{})
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return result
+	}
+	for _, iface := range interfaces {
+		addresses := []string{}
+		if addrs, err := iface.Addrs(); err == nil {
+			for _, addr := range addrs {
+				addresses = append(addresses, addr.String())
+			}
+		}
+		result[iface.Name] = map[string]interface{}{
+			"addresses":    addresses,
+			"hardwareAddr": iface.HardwareAddr.String(),
+			"loopback":     iface.Flags&net.FlagLoopback != 0,
+			"mtu":          iface.MTU,
+			"up":           iface.Flags&net.FlagUp != 0,
+		}
+	}
+	return result
+}
+
+// getPrimaryIP returns the local IP address that the operating system would
+// use to reach address on network, which is the address of the interface with
+// the default route. Connecting a UDP socket does not send any packets, so
+// address can be a documentation address that is never reached.
+func getPrimaryIP(network, address string) string {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+	udpAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return ""
+	}
+	return udpAddr.IP.String()
+}
+
+// parseResolvConf returns the local domain and the search domains in the
+// resolv.conf(5) data. If there is no domain line then the domain is the first
+// search domain.
+func parseResolvConf(data []byte) (string, []string) {
+	domain := ""
+	searchDomains := []string{}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+		// The last domain or search line takes precedence.
+		switch fields[0] {
+		case "domain":
+			domain = fields[1]
+		case "search":
+			searchDomains = fields[1:]
+		}
+	}
+	if domain == "" && len(searchDomains) > 0 {
+		domain = searchDomains[0]
+	}
+	return domain, searchDomains
+}
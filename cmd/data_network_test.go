@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestParseResolvConf(t *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		data                  string
+		expectedDomain        string
+		expectedSearchDomains []string
+	}{
+		{
+			name:                  "empty",
+			expectedSearchDomains: []string{},
+		},
+		{
+			name: "search",
+			data: "" +
+				"# Generated by NetworkManager\n" +
+				"search office.example.com example.com\n" +
+				"nameserver 192.168.1.1\n",
+			expectedDomain:        "office.example.com",
+			expectedSearchDomains: []string{"office.example.com", "example.com"},
+		},
+		{
+			name: "domain",
+			data: "" +
+				"domain home.example.com\n" +
+				"search example.com\n" +
+				"; search ignored.example.com\n",
+			expectedDomain:        "home.example.com",
+			expectedSearchDomains: []string{"example.com"},
+		},
+		{
+			name: "last_search_wins",
+			data: "" +
+				"search first.example.com\n" +
+				"search second.example.com\n",
+			expectedDomain:        "second.example.com",
+			expectedSearchDomains: []string{"second.example.com"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			domain, searchDomains := parseResolvConf([]byte(tc.data))
+			assert.Equal(t, tc.expectedDomain, domain)
+			assert.Equal(t, tc.expectedSearchDomains, searchDomains)
+		})
+	}
+}
+
+func TestGetNetworkData(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/etc/resolv.conf": "search example.com\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	networkData := getNetworkData(fs)
+	assert.Equal(t, "example.com", networkData["domain"])
+	assert.Equal(t, []string{"example.com"}, networkData["searchDomains"])
+	assert.Contains(t, networkData, "interfaces")
+	assert.Contains(t, networkData, "primaryIP")
+	assert.Contains(t, networkData, "primaryIPv6")
+}
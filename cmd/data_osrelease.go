@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// readOSRelease reads operating system identification data from the first of
+// filenames that exists.
+func readOSRelease(fs vfs.FS, filenames []string) (map[string]string, error) {
+	for _, filename := range filenames {
+		data, err := fs.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		m, err := parseOSRelease(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+// newOSReleaseFromUname returns operating system identification data for
+// systems without an os-release file, for example older BSDs, using the
+// system name and release returned by uname(2). prettyName, if not empty, is
+// used as the pretty name.
+func newOSReleaseFromUname(sysname, release, prettyName string) map[string]string {
+	if prettyName == "" {
+		prettyName = sysname + " " + release
+	}
+	return map[string]string{
+		"ID":          strings.ToLower(sysname),
+		"NAME":        sysname,
+		"PRETTY_NAME": prettyName,
+		"VERSION_ID":  release,
+	}
+}
+
+// maybeUnquote removes quotation marks around s.
+func maybeUnquote(s string) string {
+	// Try to unquote.
+	if s, err := strconv.Unquote(s); err == nil {
+		return s
+	}
+	// Otherwise return s, unchanged.
+	return s
+}
+
+// parseOSRelease parses operating system identification data from r as defined
+// by https://www.freedesktop.org/software/systemd/man/os-release.html.
+func parseOSRelease(r io.Reader) (map[string]string, error) {
+	result := make(map[string]string)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		// trim all leading whitespace, but not necessarily trailing whitespace
+		token := strings.TrimLeftFunc(s.Text(), unicode.IsSpace)
+		// if the line is empty or starts with #, skip
+		if len(token) == 0 || token[0] == '#' {
+			continue
+		}
+		fields := strings.SplitN(token, "=", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("cannot parse %q", token)
+		}
+		key := fields[0]
+		value := maybeUnquote(fields[1])
+		result[key] = value
+	}
+	return result, s.Err()
+}
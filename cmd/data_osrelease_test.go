@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOSReleaseFromUname(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		sysname    string
+		release    string
+		prettyName string
+		expected   map[string]string
+	}{
+		{
+			name:    "openbsd",
+			sysname: "OpenBSD",
+			release: "6.7",
+			expected: map[string]string{
+				"ID":          "openbsd",
+				"NAME":        "OpenBSD",
+				"PRETTY_NAME": "OpenBSD 6.7",
+				"VERSION_ID":  "6.7",
+			},
+		},
+		{
+			name:       "illumos",
+			sysname:    "SunOS",
+			release:    "5.11",
+			prettyName: "OmniOS v11 r151034",
+			expected: map[string]string{
+				"ID":          "sunos",
+				"NAME":        "SunOS",
+				"PRETTY_NAME": "OmniOS v11 r151034",
+				"VERSION_ID":  "5.11",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, newOSReleaseFromUname(tc.sysname, tc.release, tc.prettyName))
+		})
+	}
+}
@@ -1,4 +1,4 @@
-// +build !linux
+// +build !dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
 
 package cmd
 
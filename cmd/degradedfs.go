@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+type degradedFSConfig struct {
+	Auto  bool
+	Paths []string
+}
+
+// isDegradedPath returns true if path is on a filesystem that does not support
+// permissions or symlinks, either because it is in one of the configured
+// degradedFS.paths or because it is automatically detected.
+func (c *Config) isDegradedPath(path string) bool {
+	for _, degradedPath := range c.DegradedFS.Paths {
+		degradedPath = filepath.Clean(c.expandTilde(degradedPath))
+		if path == degradedPath || strings.HasPrefix(path, degradedPath+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return c.DegradedFS.Auto && chezmoi.IsDegradedFS(path)
+}
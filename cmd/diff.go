@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"archive/tar"
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -21,6 +26,8 @@ type diffCmdConfig struct {
 	Format  string
 	NoPager bool
 	Pager   string
+	fromRef string
+	toRef   string
 }
 
 var diffCmd = &cobra.Command{
@@ -38,6 +45,8 @@ func init() {
 	persistentFlags := diffCmd.PersistentFlags()
 	persistentFlags.StringVarP(&config.Diff.Format, "format", "f", config.Diff.Format, "format, \"chezmoi\" or \"git\"")
 	persistentFlags.BoolVar(&config.Diff.NoPager, "no-pager", false, "disable pager")
+	persistentFlags.StringVar(&config.Diff.fromRef, "from-ref", "", "source revision to diff from")
+	persistentFlags.StringVar(&config.Diff.toRef, "to-ref", "", "source revision to diff to")
 
 	markRemainingZshCompPositionalArgumentsAsFiles(diffCmd, 1)
 }
@@ -66,17 +75,7 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 	defer persistentState.Close()
 
 	if c.Diff.NoPager || c.Diff.Pager == "" {
-		switch c.Diff.Format {
-		case "chezmoi":
-			c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
-		case "git":
-			unifiedEncoder := diff.NewUnifiedEncoder(c.Stdout, diff.DefaultContextLines)
-			if c.colored {
-				unifiedEncoder.SetColor(diff.NewColorConfig())
-			}
-			c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
-		}
-		return c.applyArgs(args, persistentState)
+		return c.diffArgs(c.Stdout, args, persistentState)
 	}
 
 	var pagerCmd *exec.Cmd
@@ -103,24 +102,228 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	switch c.Diff.Format {
-	case "chezmoi":
-		c.mutator = chezmoi.NewVerboseMutator(pagerStdinPipe, c.mutator, c.colored, c.maxDiffDataSize)
-	case "git":
-		unifiedEncoder := diff.NewUnifiedEncoder(pagerStdinPipe, diff.DefaultContextLines)
-		if c.colored {
-			unifiedEncoder.SetColor(diff.NewColorConfig())
+	if err := c.diffArgs(pagerStdinPipe, args, persistentState); err != nil {
+		return err
+	}
+
+	if err := pagerStdinPipe.Close(); err != nil {
+		return err
+	}
+
+	return pagerCmd.Wait()
+}
+
+// diffArgs writes the diff for args to w.
+func (c *Config) diffArgs(w io.Writer, args []string, persistentState chezmoi.PersistentState) error {
+	if c.Diff.fromRef != "" || c.Diff.toRef != "" {
+		return c.diffRefs(w, args, persistentState)
+	}
+	c.mutator = c.newDiffMutator(w, c.mutator)
+	return c.applyArgs(args, persistentState)
+}
+
+// newDiffMutator returns a Mutator that wraps mutator and writes a diff of its
+// actions to w in c.Diff.Format.
+func (c *Config) newDiffMutator(w io.Writer, mutator chezmoi.Mutator) chezmoi.Mutator {
+	if c.Diff.Format == "git" {
+		return c.newGitDiffMutator(w, mutator, c.DestDir)
+	}
+	return chezmoi.NewVerboseMutator(w, mutator, c.colored, c.maxDiffDataSize)
+}
+
+// diffRefs writes the git format diff between the target states of two
+// revisions of the source state to w. The target states are rendered with the
+// current template data. If c.Diff.fromRef is not set then the diff is from
+// HEAD, and if c.Diff.toRef is not set then the diff is to the working tree.
+func (c *Config) diffRefs(w io.Writer, args []string, persistentState chezmoi.PersistentState) error {
+	if filepath.Base(c.SourceVCS.Command) != "git" {
+		return fmt.Errorf("%s: source revisions not supported", c.SourceVCS.Command)
+	}
+	fromRef := c.Diff.fromRef
+	if fromRef == "" {
+		fromRef = "HEAD"
+	}
+
+	// The rendered target states may contain decrypted secrets.
+	tempDir, removeTempDir, err := c.makeSecretTempDir()
+	if err != nil {
+		return err
+	}
+	defer removeTempDir()
+
+	fromTargetState, err := c.getTargetStateAtRef(fromRef, filepath.Join(tempDir, "from"))
+	if err != nil {
+		return err
+	}
+	var toTargetState *chezmoi.TargetState
+	if c.Diff.toRef == "" {
+		toTargetState, err = c.getTargetState(nil)
+	} else {
+		toTargetState, err = c.getTargetStateAtRef(c.Diff.toRef, filepath.Join(tempDir, "to"))
+	}
+	if err != nil {
+		return err
+	}
+
+	targetNames := make([]string, 0, len(args))
+	for _, arg := range args {
+		targetPath, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+		targetName, err := chezmoi.RelTargetName(c.fs, c.DestDir, targetPath)
+		if err != nil {
+			return err
+		}
+		targetNames = append(targetNames, targetName)
+	}
+	// Targets outside the destination directory cannot be rendered in the
+	// temporary destination directory, so they are always ignored.
+	newIgnore := func(ts *chezmoi.TargetState) func(string) bool {
+		return func(targetName string) bool {
+			return filepath.IsAbs(targetName) || !targetNamesInclude(targetNames, targetName) || ts.TargetIgnore.Match(targetName)
 		}
-		c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
 	}
 
-	if err := c.applyArgs(args, persistentState); err != nil {
+	// Apply the from target state to a temporary destination directory and
+	// then diff the to target state against it.
+	destDir := filepath.Join(tempDir, "dest")
+	if err := os.Mkdir(destDir, 0700); err != nil {
+		return err
+	}
+	umask := os.FileMode(c.Umask)
+	fromIgnore := newIgnore(fromTargetState)
+	if err := fromTargetState.Apply(vfs.OSFS, chezmoi.NewFSMutator(vfs.OSFS), false, &chezmoi.ApplyOptions{
+		DestDir:           destDir,
+		DryRun:            true,
+		FIFOs:             c.FIFOs,
+		Ignore:            fromIgnore,
+		PersistentState:   persistentState,
+		ScriptStateBucket: c.scriptStateBucket,
+		Stdout:            ioutil.Discard,
+		Umask:             umask,
+	}); err != nil {
+		return err
+	}
+	mutator := c.newGitDiffMutator(w, chezmoi.NewFSMutator(vfs.NewReadOnlyFS(vfs.OSFS)), destDir)
+	toIgnore := newIgnore(toTargetState)
+	if err := toTargetState.Apply(vfs.OSFS, mutator, false, &chezmoi.ApplyOptions{
+		DestDir:           destDir,
+		DryRun:            true,
+		FIFOs:             c.FIFOs,
+		Ignore:            toIgnore,
+		PersistentState:   persistentState,
+		ScriptStateBucket: c.scriptStateBucket,
+		Stdout:            ioutil.Discard,
+		Umask:             umask,
+	}); err != nil {
 		return err
 	}
 
-	if err := pagerStdinPipe.Close(); err != nil {
+	// Remove targets that are only in the from target state, unless they
+	// have already been removed as the contents of an exact directory.
+	toEntries := make(map[string]chezmoi.Entry)
+	for _, entry := range toTargetState.AllEntries() {
+		if !toIgnore(entry.TargetName()) {
+			toEntries[entry.TargetName()] = entry
+		}
+	}
+	removedTargetNames := make(map[string]struct{})
+	fromEntries := fromTargetState.AllEntries()
+	sort.Slice(fromEntries, func(i, j int) bool {
+		return fromEntries[i].TargetName() < fromEntries[j].TargetName()
+	})
+	for _, entry := range fromEntries {
+		targetName := entry.TargetName()
+		if _, ok := toEntries[targetName]; ok || fromIgnore(targetName) {
+			continue
+		}
+		parentDirTargetName := filepath.Dir(targetName)
+		if _, ok := removedTargetNames[parentDirTargetName]; ok {
+			removedTargetNames[targetName] = struct{}{}
+			continue
+		}
+		if dir, ok := toEntries[parentDirTargetName].(*chezmoi.Dir); ok && dir.Exact {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(destDir, targetName)); os.IsNotExist(err) {
+			continue
+		}
+		if err := mutator.RemoveAll(filepath.Join(destDir, targetName)); err != nil {
+			return err
+		}
+		removedTargetNames[targetName] = struct{}{}
+	}
+	return nil
+}
+
+// getTargetStateAtRef returns the target state of the source state at ref,
+// which is exported to dir.
+func (c *Config) getTargetStateAtRef(ref, dir string) (*chezmoi.TargetState, error) {
+	output, err := c.output(c.SourceDir, c.SourceVCS.Command, "archive", "--format=tar", ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	if err := extractTAR(tar.NewReader(bytes.NewReader(output)), dir); err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	return c.getTargetStateFromSourceDir(vfs.NewReadOnlyFS(vfs.OSFS), dir, nil)
+}
+
+func (c *Config) newGitDiffMutator(w io.Writer, mutator chezmoi.Mutator, destDir string) chezmoi.Mutator {
+	unifiedEncoder := diff.NewUnifiedEncoder(w, diff.DefaultContextLines)
+	if c.colored {
+		unifiedEncoder.SetColor(diff.NewColorConfig())
+	}
+	return chezmoi.NewGitDiffMutator(unifiedEncoder, mutator, destDir+string(filepath.Separator))
+}
+
+// extractTAR extracts the directories, regular files, and symlinks in r to dir.
+func extractTAR(r *tar.Reader, dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		return err
 	}
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		name := filepath.Join(dir, filepath.FromSlash(header.Name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(name, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(name, data, 0600); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, name); err != nil {
+				return err
+			}
+		}
+	}
+}
 
-	return pagerCmd.Wait()
+// targetNamesInclude returns true if targetNames is empty or if targetName is
+// equal to, inside, or a parent of any of targetNames.
+func targetNamesInclude(targetNames []string, targetName string) bool {
+	if len(targetNames) == 0 {
+		return true
+	}
+	for _, name := range targetNames {
+		if name == targetName ||
+			strings.HasPrefix(targetName, name+string(filepath.Separator)) ||
+			strings.HasPrefix(name, targetName+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
 }
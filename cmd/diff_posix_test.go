@@ -3,8 +3,10 @@
 package cmd
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
@@ -35,3 +37,105 @@ func TestDiffDoesNotRunScript(t *testing.T) {
 		),
 	)
 }
+
+func TestDiffRefs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in $PATH")
+	}
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"dot_bashrc":      "# contents of .bashrc\n",
+			"dot_gitconfig":   "[user]\n\temail = {{ .email }}\n",
+			"dot_profile":     "# contents of .profile\n",
+			"dot_vimrc":       "set nocompatible\n",
+			".chezmoiignore":  ".vimrc\n",
+			"run_echo_foo.sh": "#!/bin/sh\necho foo\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	sourceDir, err := fs.RawPath("/home/user/.local/share/chezmoi")
+	require.NoError(t, err)
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=User", "-c", "user.email=user@example.com"}, args...)...)
+		cmd.Dir = sourceDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "--message", "Initial commit")
+	git("tag", "v1")
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_gitconfig.tmpl", []byte("[user]\n\temail = {{ .email }}\n"), 0666))
+	require.NoError(t, fs.Remove("/home/user/.local/share/chezmoi/dot_gitconfig"))
+	require.NoError(t, fs.Remove("/home/user/.local/share/chezmoi/dot_profile"))
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_vimrc", []byte("set compatible\n"), 0666))
+	git("add", "--all", ".")
+	git("commit", "--quiet", "--message", "Make .gitconfig a template")
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_bashrc", []byte("# new contents of .bashrc\n"), 0666))
+
+	for _, tc := range []struct {
+		name     string
+		fromRef  string
+		toRef    string
+		args     []string
+		expected string
+	}{
+		{
+			name:    "refs",
+			fromRef: "v1",
+			toRef:   "HEAD",
+			expected: "" +
+				"diff --git a/.gitconfig b/.gitconfig\n" +
+				"index 14809b0281894706cdf7981aa22c4533f7edc0a1..80519bacf00b2f9779771fd3c75583bd52ed7708 100644\n" +
+				"--- a/.gitconfig\n" +
+				"+++ b/.gitconfig\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				" [user]\n" +
+				"-\temail = {{ .email }}\n" +
+				"+\temail = user@home.org\n" +
+				"diff --git a/.profile b/.profile\n" +
+				"deleted file mode 100644\n" +
+				"index 0000000000000000000000000000000000000000..0000000000000000000000000000000000000000\n" +
+				"--- a/.profile\n" +
+				"+++ /dev/null\n",
+		},
+		{
+			name:    "working_tree",
+			fromRef: "HEAD",
+			expected: "" +
+				"diff --git a/.bashrc b/.bashrc\n" +
+				"index 13faef3591002a9d38fe869ca0e205ca472fac73..8f18f3682d10acf42d28fd33606b6e047558d466 100644\n" +
+				"--- a/.bashrc\n" +
+				"+++ b/.bashrc\n" +
+				"@@ -1 +1 @@\n" +
+				"-# contents of .bashrc\n" +
+				"+# new contents of .bashrc\n",
+		},
+		{
+			name:    "args",
+			fromRef: "v1",
+			args:    []string{"/home/user/.bashrc"},
+			expected: "" +
+				"diff --git a/.bashrc b/.bashrc\n" +
+				"index 13faef3591002a9d38fe869ca0e205ca472fac73..8f18f3682d10acf42d28fd33606b6e047558d466 100644\n" +
+				"--- a/.bashrc\n" +
+				"+++ b/.bashrc\n" +
+				"@@ -1 +1 @@\n" +
+				"-# contents of .bashrc\n" +
+				"+# new contents of .bashrc\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withData(map[string]interface{}{
+				"email": "user@home.org",
+			}), withStdout(stdout))
+			c.Diff.NoPager = true
+			c.Diff.fromRef = tc.fromRef
+			c.Diff.toRef = tc.toRef
+			require.NoError(t, c.runDiffCmd(nil, tc.args))
+			assert.Equal(t, tc.expected, stdout.String())
+		})
+	}
+}
@@ -504,7 +504,11 @@ func init() {
 		"* [Include a subdirectory from another repository, like Oh My Zsh](#include-a-subdirectory-from-another-repository-like-oh-my-zsh)\n" +
 		"* [Handle configuration files which are externally modified](#handle-configuration-files-which-are-externally-modified)\n" +
 		"* [Keep data private](#keep-data-private)\n" +
+		"  * [Use AWS Secrets Manager to keep your secrets](#use-aws-secrets-manager-to-keep-your-secrets)\n" +
+		"  * [Use Azure Key Vault to keep your secrets](#use-azure-key-vault-to-keep-your-secrets)\n" +
 		"  * [Use Bitwarden to keep your secrets](#use-bitwarden-to-keep-your-secrets)\n" +
+		"  * [Use Doppler to keep your secrets](#use-doppler-to-keep-your-secrets)\n" +
+		"  * [Use Google Cloud Secret Manager to keep your secrets](#use-google-cloud-secret-manager-to-keep-your-secrets)\n" +
 		"  * [Use gopass to keep your secrets](#use-gopass-to-keep-your-secrets)\n" +
 		"  * [Use gpg to keep your secrets](#use-gpg-to-keep-your-secrets)\n" +
 		"  * [Use KeePassXC to keep your secrets](#use-keepassxc-to-keep-your-secrets)\n" +
@@ -512,6 +516,7 @@ func init() {
 		"  * [Use LastPass to keep your secrets](#use-lastpass-to-keep-your-secrets)\n" +
 		"  * [Use 1Password to keep your secrets](#use-1password-to-keep-your-secrets)\n" +
 		"  * [Use pass to keep your secrets](#use-pass-to-keep-your-secrets)\n" +
+		"  * [Use SOPS to keep your secrets](#use-sops-to-keep-your-secrets)\n" +
 		"  * [Use Vault to keep your secrets](#use-vault-to-keep-your-secrets)\n" +
 		"  * [Use a generic tool to keep your secrets](#use-a-generic-tool-to-keep-your-secrets)\n" +
 		"  * [Use templates variables to keep your secrets](#use-templates-variables-to-keep-your-secrets)\n" +
@@ -585,16 +590,18 @@ func init() {
 		"your repo. This feature is disabled by default. To enable it, add the following\n" +
 		"to your config file:\n" +
 		"\n" +
-		"    [sourceVCS]\n" +
+		"    [git]\n" +
 		"        autoCommit = true\n" +
 		"        autoPush = true\n" +
 		"\n" +
-		"Whenever a change is made to your source directory, chezmoi will commit the\n" +
+		"Whenever a command changes your source directory, for example `add`, `chattr`,\n" +
+		"`edit`, `forget`, `import`, `merge`, `mv`, or `remove`, chezmoi will commit the\n" +
 		"changes with an automatically-generated commit message (if `autoCommit` is true)\n" +
 		"and push them to your repo (if `autoPush` is true). `autoPush` implies\n" +
 		"`autoCommit`, i.e. if `autoPush` is true then chezmoi will auto-commit your\n" +
 		"changes. If you only set `autoCommit` to true then changes will be committed but\n" +
-		"not pushed.\n" +
+		"not pushed. The older `sourceVCS.autoCommit` and `sourceVCS.autoPush`\n" +
+		"configuration variables are equivalent.\n" +
 		"\n" +
 		"Be careful when using `autoPush`. If your dotfiles repo is public and you\n" +
 		"accidentally add a secret in plain text, that secret will be pushed to your\n" +
@@ -834,8 +841,23 @@ func init() {
 		"To include a subdirectory from another repository, e.g. [Oh My\n" +
 		"Zsh](https://github.com/robbyrussell/oh-my-zsh), you cannot use git submodules\n" +
 		"because chezmoi uses its own format for the source state and Oh My Zsh is not\n" +
-		"distributed in this format. Instead, you can use the `import` command to import\n" +
-		"a snapshot from a tarball:\n" +
+		"distributed in this format. Instead, you can list it in a\n" +
+		"`.chezmoiexternal.toml` file in the root of your source directory:\n" +
+		"\n" +
+		"```toml\n" +
+		"[\".oh-my-zsh\"]\n" +
+		"    type = \"archive\"\n" +
+		"    url = \"https://github.com/ohmyzsh/ohmyzsh/archive/master.tar.gz\"\n" +
+		"    exact = true\n" +
+		"    stripComponents = 1\n" +
+		"    refreshPeriod = \"168h\"\n" +
+		"```\n" +
+		"\n" +
+		"chezmoi will download the archive, cache it, and download it again at most once\n" +
+		"a week. Plugins and themes can be added as further externals in the same file.\n" +
+		"\n" +
+		"Alternatively, you can use the `import` command to import a snapshot from a\n" +
+		"tarball:\n" +
 		"\n" +
 		"    curl -s -L -o oh-my-zsh-master.tar.gz https://github.com/robbyrussell/oh-my-zsh/archive/master.tar.gz\n" +
 		"    chezmoi import --strip-components 1 --destination ${HOME}/.oh-my-zsh oh-my-zsh-master.tar.gz\n" +
@@ -845,8 +867,8 @@ func init() {
 		"\n" +
 		"Disable Oh My Zsh auto-updates by setting `DISABLE_AUTO_UPDATE=\"true\"` in\n" +
 		"`~/.zshrc`. Auto updates will cause the `~/.oh-my-zsh` directory to drift out of\n" +
-		"sync with chezmoi's source state. To update Oh My Zsh, re-run the `curl` and\n" +
-		"`chezmoi import` commands above.\n" +
+		"sync with chezmoi's source state. To update an imported Oh My Zsh, re-run the\n" +
+		"`curl` and `chezmoi import` commands above.\n" +
 		"\n" +
 		"## Handle configuration files which are externally modified\n" +
 		"\n" +
@@ -902,6 +924,58 @@ func init() {
 		"There are several ways to keep these tokens secure, and to prevent them leaving\n" +
 		"your machine.\n" +
 		"\n" +
+		"### Use AWS Secrets Manager to keep your secrets\n" +
+		"\n" +
+		"chezmoi includes support for [AWS Secrets\n" +
+		"Manager](https://aws.amazon.com/secrets-manager/) to expose secrets as template\n" +
+		"functions.\n" +
+		"\n" +
+		"chezmoi uses the same credentials as the [AWS\n" +
+		"CLI](https://aws.amazon.com/cli/), so if the AWS CLI is configured you can\n" +
+		"verify that chezmoi can read a secret by running:\n" +
+		"\n" +
+		"    aws secretsmanager get-secret-value --secret-id <arn>\n" +
+		"\n" +
+		"Secrets that are stored as JSON key/value pairs are available as structured data\n" +
+		"from the `awsSecretsManager` template function. For example:\n" +
+		"\n" +
+		"    {{ (awsSecretsManager \"<arn>\").password }}\n" +
+		"\n" +
+		"Other secrets are available unmodified from the `awsSecretsManagerRaw` template\n" +
+		"function. For example:\n" +
+		"\n" +
+		"    {{ awsSecretsManagerRaw \"<arn>\" }}\n" +
+		"\n" +
+		"To use a profile or region other than your default, set them in your config\n" +
+		"file, for example:\n" +
+		"\n" +
+		"    [awsSecretsManager]\n" +
+		"      profile = \"personal\"\n" +
+		"      region = \"eu-west-1\"\n" +
+		"\n" +
+		"### Use Azure Key Vault to keep your secrets\n" +
+		"\n" +
+		"chezmoi includes support for [Azure Key\n" +
+		"Vault](https://azure.microsoft.com/services/key-vault/) to expose secrets as a\n" +
+		"template function.\n" +
+		"\n" +
+		"On your own machine, log in with the [Azure\n" +
+		"CLI](https://docs.microsoft.com/cli/azure/) and verify that you can read a\n" +
+		"secret by running:\n" +
+		"\n" +
+		"    az keyvault secret show --vault-name <vault> --name <secret>\n" +
+		"\n" +
+		"On Azure hosts with a managed identity, no login is needed. Set your default\n" +
+		"vault in your config file:\n" +
+		"\n" +
+		"    [azureKeyVault]\n" +
+		"      defaultVault = \"<vault>\"\n" +
+		"\n" +
+		"Secrets are then available from the `azureKeyVault` template function, for\n" +
+		"example:\n" +
+		"\n" +
+		"    {{ azureKeyVault \"<secret>\" }}\n" +
+		"\n" +
 		"### Use Bitwarden to keep your secrets\n" +
 		"\n" +
 		"chezmoi includes support for [Bitwarden](https://bitwarden.com/) using the\n" +
@@ -924,6 +998,67 @@ func init() {
 		"    username = {{ (bitwarden \"item\" \"example.com\").login.username }}\n" +
 		"    password = {{ (bitwarden \"item\" \"example.com\").login.password }}\n" +
 		"\n" +
+		"Each call to `bw` starts a new Node.js process, which can make templates that\n" +
+		"use many secrets slow. If you use [`rbw`](https://github.com/doy/rbw) then you\n" +
+		"can configure chezmoi to use it instead, and the same templates will work:\n" +
+		"\n" +
+		"```toml\n" +
+		"[bitwarden]\n" +
+		"    command = \"rbw\"\n" +
+		"```\n" +
+		"\n" +
+		"### Use Doppler to keep your secrets\n" +
+		"\n" +
+		"chezmoi includes support for [Doppler](https://www.doppler.com/) to expose the\n" +
+		"secrets of a Doppler config as a template function.\n" +
+		"\n" +
+		"Log in with the [Doppler CLI](https://docs.doppler.com/docs/cli):\n" +
+		"\n" +
+		"    doppler login\n" +
+		"\n" +
+		"Set your default project and config in your config file:\n" +
+		"\n" +
+		"    [doppler]\n" +
+		"      project = \"<project>\"\n" +
+		"      config = \"<config>\"\n" +
+		"\n" +
+		"The secrets are then available as a map from the `doppler` template function,\n" +
+		"for example:\n" +
+		"\n" +
+		"    {{ (doppler).GITHUB_TOKEN }}\n" +
+		"    {{ (doppler \"<project>\" \"<config>\").DATABASE_URL }}\n" +
+		"\n" +
+		"On machines without the Doppler CLI, such as servers, create a service token\n" +
+		"for the machine's config and set `doppler.token` instead, for example by\n" +
+		"reading it from the environment in your config file template:\n" +
+		"\n" +
+		"    [doppler]\n" +
+		"      token = \"{{ env \"DOPPLER_TOKEN\" }}\"\n" +
+		"\n" +
+		"chezmoi then uses the Doppler API directly.\n" +
+		"\n" +
+		"### Use Google Cloud Secret Manager to keep your secrets\n" +
+		"\n" +
+		"chezmoi includes support for [Google Cloud Secret\n" +
+		"Manager](https://cloud.google.com/secret-manager) to expose secrets as a\n" +
+		"template function.\n" +
+		"\n" +
+		"On your own machine, create Application Default Credentials with the [Google\n" +
+		"Cloud SDK](https://cloud.google.com/sdk):\n" +
+		"\n" +
+		"    gcloud auth application-default login\n" +
+		"\n" +
+		"On Google Cloud hosts, the host's service account is used and no login is\n" +
+		"needed. Set your default project in your config file:\n" +
+		"\n" +
+		"    [gcpSecretManager]\n" +
+		"      project = \"<project>\"\n" +
+		"\n" +
+		"Secrets are then available from the `gcpSecretManager` template function, for\n" +
+		"example:\n" +
+		"\n" +
+		"    {{ gcpSecretManager \"<secret>\" }}\n" +
+		"\n" +
 		"### Use gopass to keep your secrets\n" +
 		"\n" +
 		"chezmoi includes support for [gopass](https://gopass.pw/) using the gopass CLI.\n" +
@@ -933,6 +1068,23 @@ func init() {
 		"\n" +
 		"    {{ gopass \"<pass-name>\" }}\n" +
 		"\n" +
+		"The rest of the secret can hold extra fields, either as `key: value` lines or\n" +
+		"as a YAML document after a `---` line, for example:\n" +
+		"\n" +
+		"    correct-horse-battery-staple\n" +
+		"    ---\n" +
+		"    login: alice\n" +
+		"    url: https://example.com\n" +
+		"\n" +
+		"These fields are available as a map with the `gopassFields` template function,\n" +
+		"and the full output of `gopass show` is available with the `gopassRaw` template\n" +
+		"function, for example:\n" +
+		"\n" +
+		"    {{ (gopassFields \"<pass-name>\").login }}\n" +
+		"    {{ gopassRaw \"<pass-name>\" }}\n" +
+		"\n" +
+		"Each secret is only fetched once, however many times it is used.\n" +
+		"\n" +
 		"### Use gpg to keep your secrets\n" +
 		"\n" +
 		"chezmoi supports encrypting files with [gpg](https://www.gnupg.org/). Encrypted\n" +
@@ -997,15 +1149,36 @@ func init() {
 		"\n" +
 		"    {{ keepassxcAttribute \"SSH Key\" \"private-key\" }}\n" +
 		"\n" +
+		"By default, chezmoi prompts for your database password. To avoid the prompt on a\n" +
+		"trusted machine, store the password in your keyring:\n" +
+		"\n" +
+		"    $ chezmoi secret keyring set --service=keepassxc --user=<user>\n" +
+		"\n" +
+		"and configure chezmoi to read it from there:\n" +
+		"\n" +
+		"    [keepassxc]\n" +
+		"      database = \"/home/user/Passwords.kdbx\"\n" +
+		"    [keepassxc.keyring]\n" +
+		"      service = \"keepassxc\"\n" +
+		"      user = \"<user>\"\n" +
+		"\n" +
+		"Alternatively, chezmoi can retrieve entries by URL from a running KeePassXC\n" +
+		"using its browser integration. Enable browser integration in KeePassXC, run:\n" +
+		"\n" +
+		"    $ chezmoi secret keepassxc-browser associate\n" +
+		"\n" +
+		"and follow the instructions. See the [`keepassxc`\n" +
+		"reference](REFERENCE.md#keepassxc-entry) for details.\n" +
+		"\n" +
 		"### Use a keyring to keep your secrets\n" +
 		"\n" +
-		"chezmoi includes support for Keychain (on macOS), GNOME Keyring (on Linux), and\n" +
-		"Windows Credentials Manager (on Windows) via the\n" +
+		"chezmoi includes support for Keychain (on macOS), the Secret Service API, e.g.\n" +
+		"GNOME Keyring or KWallet (on Linux), and Credential Manager (on Windows) via the\n" +
 		"[`zalando/go-keyring`](https://github.com/zalando/go-keyring) library.\n" +
 		"\n" +
 		"Set passwords with:\n" +
 		"\n" +
-		"    $ chezmoi keyring set --service=<service> --user=<user>\n" +
+		"    $ chezmoi secret keyring set --service=<service> --user=<user>\n" +
 		"    Password: xxxxxxxx\n" +
 		"\n" +
 		"The password can then be used in templates using the `keyring` function which\n" +
@@ -1013,7 +1186,7 @@ func init() {
 		"\n" +
 		"For example, save a GitHub access token in keyring with:\n" +
 		"\n" +
-		"    $ chezmoi keyring set --service=github --user=<github-username>\n" +
+		"    $ chezmoi secret keyring set --service=github --user=<github-username>\n" +
 		"    Password: xxxxxxxx\n" +
 		"\n" +
 		"and then include it in your `~/.gitconfig` file with:\n" +
@@ -1024,7 +1197,11 @@ func init() {
 		"\n" +
 		"You can query the keyring from the command line:\n" +
 		"\n" +
-		"    chezmoi keyring get --service=github --user=<github-username>\n" +
+		"    chezmoi secret keyring get --service=github --user=<github-username>\n" +
+		"\n" +
+		"and remove it with:\n" +
+		"\n" +
+		"    chezmoi secret keyring delete --service=github --user=<github-username>\n" +
 		"\n" +
 		"### Use LastPass to keep your secrets\n" +
 		"\n" +
@@ -1099,6 +1276,23 @@ func init() {
 		"substitution. This removes any trailing newline added by your editor when saving\n" +
 		"the template.\n" +
 		"\n" +
+		"On machines without the 1Password app, such as CI machines and servers, chezmoi\n" +
+		"can read items from a [1Password\n" +
+		"Connect](https://support.1password.com/secrets-automation/) server instead of\n" +
+		"running `op`. Set the `OP_CONNECT_HOST` and `OP_CONNECT_TOKEN` environment\n" +
+		"variables, or configure the server in your config file:\n" +
+		"\n" +
+		"```toml\n" +
+		"[onepassword]\n" +
+		"    connectHost = \"https://op-connect.example.com\"\n" +
+		"    connectToken = \"<token>\"\n" +
+		"```\n" +
+		"\n" +
+		"The `onepassword` and `onepasswordDocument` template functions then work\n" +
+		"unchanged. Alternatively, the `op` CLI itself runs without an interactive\n" +
+		"session when the `OP_SERVICE_ACCOUNT_TOKEN` environment variable is set to a\n" +
+		"service account token.\n" +
+		"\n" +
 		"### Use pass to keep your secrets\n" +
 		"\n" +
 		"chezmoi includes support for [pass](https://www.passwordstore.org/) using the\n" +
@@ -1109,6 +1303,25 @@ func init() {
 		"\n" +
 		"    {{ pass \"<pass-name>\" }}\n" +
 		"\n" +
+		"### Use SOPS to keep your secrets\n" +
+		"\n" +
+		"chezmoi can read structured secret data from a file encrypted with\n" +
+		"[SOPS](https://github.com/mozilla/sops), which encrypts the values in a YAML\n" +
+		"file but leaves its keys readable. Create the file `secrets.sops.yaml` in your\n" +
+		"source directory, encrypting it with, for example, an age key:\n" +
+		"\n" +
+		"    sops --age <recipient> ~/.local/share/chezmoi/secrets.sops.yaml\n" +
+		"\n" +
+		"chezmoi decrypts the file with `sops --decrypt` whenever it needs the template\n" +
+		"data, and its contents are available under `.sops`, for example:\n" +
+		"\n" +
+		"    [github]\n" +
+		"        token = {{ .sops.github.token | quote }}\n" +
+		"\n" +
+		"SOPS must be able to find the decryption key, for example in\n" +
+		"`~/.config/sops/age/keys.txt` or `$SOPS_AGE_KEY_FILE` for age, or from your\n" +
+		"cloud credentials for a KMS.\n" +
+		"\n" +
 		"### Use Vault to keep your secrets\n" +
 		"\n" +
 		"chezmoi includes support for [Vault](https://www.vaultproject.io/) using the\n" +
@@ -1127,6 +1340,22 @@ func init() {
 		"\n" +
 		"    {{ (vault \"<key>\").data.data.password }}\n" +
 		"\n" +
+		"Alternatively, chezmoi can talk to the Vault server directly, without the vault\n" +
+		"CLI. This is faster and supports logging in with AppRole or AWS IAM\n" +
+		"credentials. Set `vault.address`, or the `VAULT_ADDR` environment variable, and\n" +
+		"configure authentication in your config file, for example:\n" +
+		"\n" +
+		"    [vault]\n" +
+		"      address = \"https://vault.example.com:8200\"\n" +
+		"      authMethod = \"approle\"\n" +
+		"      roleID = \"<role-id>\"\n" +
+		"      secretID = \"<secret-id>\"\n" +
+		"\n" +
+		"Then read secrets from a KV version 2 secrets engine with the `vaultKVv2`\n" +
+		"template function:\n" +
+		"\n" +
+		"    {{ (vaultKVv2 \"secret\" \"github\").token }}\n" +
+		"\n" +
 		"### Use a generic tool to keep your secrets\n" +
 		"\n" +
 		"You can use any command line tool that outputs secrets either as a string or in\n" +
@@ -1179,8 +1408,20 @@ func init() {
 		"dry-run mode, the script is not executed.\n" +
 		"\n" +
 		"Scripts are any file in the source directory with the prefix `run_`, and are\n" +
-		"executed in alphabetical order. Scripts that should only be run when their\n" +
-		"contents change have the prefix `run_once_`.\n" +
+		"executed in alphabetical order. Scripts that should only be run once for each\n" +
+		"distinct contents have the prefix `run_once_`. Scripts that should be run\n" +
+		"whenever their contents differ from the last time they were run have the prefix\n" +
+		"`run_onchange_`. The difference is that a `run_once_` script is not run again if\n" +
+		"its contents change back to something that has already been run, whereas a\n" +
+		"`run_onchange_` script is.\n" +
+		"\n" +
+		"By default, scripts are run in alphabetical order along with all other targets.\n" +
+		"Scripts with the prefix `run_before_` are run before any other targets are\n" +
+		"updated, and scripts with the prefix `run_after_` are run after all other\n" +
+		"targets have been updated, in alphabetical order of their target paths within\n" +
+		"each phase. These can be combined with `once_` and `onchange_`, for example\n" +
+		"`run_once_before_install-password-manager.sh` or\n" +
+		"`run_onchange_after_reload-config.sh`.\n" +
 		"\n" +
 		"Scripts break chezmoi's declarative approach, and as such should be used\n" +
 		"sparingly. Any script should be idempotent, even `run_once_` scripts.\n" +
@@ -1225,6 +1466,20 @@ func init() {
 		"\n" +
 		"This will install `ripgrep` on both Debian/Ubuntu Linux systems and macOS.\n" +
 		"\n" +
+		"To re-run a script whenever a list of packages changes, for example a Homebrew\n" +
+		"`Brewfile` generated from a template, use the `run_onchange_` prefix. For\n" +
+		"example, `run_onchange_brew-bundle.sh.tmpl`:\n" +
+		"\n" +
+		"    #!/bin/sh\n" +
+		"    brew bundle --no-lock --file=/dev/stdin <<EOF\n" +
+		"    {{ range .packages -}}\n" +
+		"    brew {{ . | quote }}\n" +
+		"    {{ end -}}\n" +
+		"    EOF\n" +
+		"\n" +
+		"The script is run whenever the rendered list of packages changes, including\n" +
+		"when it changes back to an earlier list.\n" +
+		"\n" +
 		"## Import archives\n" +
 		"\n" +
 		"It is occasionally useful to import entire archives of configuration into your\n" +
@@ -1502,6 +1757,7 @@ func init() {
 		"* [Global command line flags](#global-command-line-flags)\n" +
 		"  * [`--color` *value*](#--color-value)\n" +
 		"  * [`-c`, `--config` *filename*](#-c---config-filename)\n" +
+		"  * [`--data` *pairs*](#--data-pairs)\n" +
 		"  * [`--debug`](#--debug)\n" +
 		"  * [`-D`, `--destination` *directory*](#-d---destination-directory)\n" +
 		"  * [`-f`, `--follow`](#-f---follow)\n" +
@@ -1509,25 +1765,49 @@ func init() {
 		"  * [`-h`, `--help`](#-h---help)\n" +
 		"  * [`-r`. `--remove`](#-r---remove)\n" +
 		"  * [`-S`, `--source` *directory*](#-s---source-directory)\n" +
+		"  * [`--user` *username*](#--user-username)\n" +
 		"  * [`-v`, `--verbose`](#-v---verbose)\n" +
 		"  * [`--version`](#--version)\n" +
 		"* [Configuration file](#configuration-file)\n" +
 		"  * [Configuration variables](#configuration-variables)\n" +
+		"  * [Destination directory overrides](#destination-directory-overrides)\n" +
+		"  * [Degraded filesystems](#degraded-filesystems)\n" +
+		"  * [System configuration file](#system-configuration-file)\n" +
+		"  * [Command aliases](#command-aliases)\n" +
+		"  * [Command metrics](#command-metrics)\n" +
+		"  * [Proxies](#proxies)\n" +
 		"* [Source state attributes](#source-state-attributes)\n" +
+		"  * [Hard links and FIFOs](#hard-links-and-fifos)\n" +
+		"  * [Mount points](#mount-points)\n" +
+		"  * [Trash](#trash)\n" +
+		"  * [Encryption](#encryption)\n" +
+		"  * [Binary files](#binary-files)\n" +
+		"  * [Path prefixes](#path-prefixes)\n" +
 		"* [Special files and directories](#special-files-and-directories)\n" +
 		"  * [`.chezmoi.<format>.tmpl`](#chezmoiformattmpl)\n" +
+		"  * [`.chezmoiblobs`](#chezmoiblobs)\n" +
+		"  * [`.chezmoidata/hosts`](#chezmoidatahosts)\n" +
+		"  * [`.chezmoiexternal.lock`](#chezmoiexternallock)\n" +
+		"  * [`.chezmoiexternal.toml`](#chezmoiexternaltoml)\n" +
 		"  * [`.chezmoiignore`](#chezmoiignore)\n" +
+		"  * [`.chezmoimeta.yaml`](#chezmoimetayaml)\n" +
 		"  * [`.chezmoiremove`](#chezmoiremove)\n" +
+		"  * [`.chezmoisshconfig`](#chezmoisshconfig)\n" +
 		"  * [`.chezmoitemplates`](#chezmoitemplates)\n" +
 		"  * [`.chezmoiversion`](#chezmoiversion)\n" +
+		"  * [`secrets.sops.yaml`](#secretssopsyaml)\n" +
 		"* [Commands](#commands)\n" +
 		"  * [`add` *targets*](#add-targets)\n" +
+		"  * [`affected`](#affected)\n" +
 		"  * [`apply` [*targets*]](#apply-targets)\n" +
 		"  * [`archive`](#archive)\n" +
+		"  * [`audit` *subcommand*](#audit-subcommand)\n" +
 		"  * [`cat` targets](#cat-targets)\n" +
 		"  * [`cd`](#cd)\n" +
 		"  * [`chattr` *attributes* *targets*](#chattr-attributes-targets)\n" +
 		"  * [`completion` *shell*](#completion-shell)\n" +
+		"  * [`config` validate](#config-validate)\n" +
+		"  * [`cp` *source* *target*](#cp-source-target)\n" +
 		"  * [`data`](#data)\n" +
 		"  * [`diff` [*targets*]](#diff-targets)\n" +
 		"  * [`docs` [*regexp*]](#docs-regexp)\n" +
@@ -1536,7 +1816,11 @@ func init() {
 		"  * [`edit` [*targets*]](#edit-targets)\n" +
 		"  * [`edit-config`](#edit-config)\n" +
 		"  * [`execute-template` [*templates*]](#execute-template-templates)\n" +
+		"  * [`explain` *target*](#explain-target)\n" +
+		"  * [`externals` update [*targets*]](#externals-update-targets)\n" +
+		"  * [`fleet` apply](#fleet-apply)\n" +
 		"  * [`forget` *targets*](#forget-targets)\n" +
+		"  * [`gc`](#gc)\n" +
 		"  * [`git` [*arguments*]](#git-arguments)\n" +
 		"  * [`help` *command*](#help-command)\n" +
 		"  * [`hg` [*arguments]](#hg-arguments)\n" +
@@ -1545,36 +1829,65 @@ func init() {
 		"  * [`manage` *targets*](#manage-targets)\n" +
 		"  * [`managed`](#managed)\n" +
 		"  * [`merge` *targets*](#merge-targets)\n" +
+		"  * [`mv` *source* *target*](#mv-source-target)\n" +
+		"  * [`parse-source-name` *source-name*](#parse-source-name-source-name)\n" +
 		"  * [`purge`](#purge)\n" +
 		"  * [`remove` *targets*](#remove-targets)\n" +
 		"  * [`rm` *targets*](#rm-targets)\n" +
 		"  * [`secret`](#secret)\n" +
 		"  * [`source` [*args*]](#source-args)\n" +
 		"  * [`source-path` [*targets*]](#source-path-targets)\n" +
+		"  * [`test` [*names*]](#test-names)\n" +
 		"  * [`unmanage` *targets*](#unmanage-targets)\n" +
 		"  * [`unmanaged`](#unmanaged)\n" +
 		"  * [`update`](#update)\n" +
 		"  * [`upgrade`](#upgrade)\n" +
+		"  * [`upgrade-externals` [*targets*]](#upgrade-externals-targets)\n" +
 		"  * [`verify` [*targets*]](#verify-targets)\n" +
 		"* [Editor configuration](#editor-configuration)\n" +
+		"* [Child process environment](#child-process-environment)\n" +
 		"* [Umask configuration](#umask-configuration)\n" +
 		"* [Template execution](#template-execution)\n" +
 		"* [Template variables](#template-variables)\n" +
 		"* [Template functions](#template-functions)\n" +
+		"  * [`authorizedKeys` *specs*](#authorizedkeys-specs)\n" +
+		"  * [`awsSecretsManager` *arn*](#awssecretsmanager-arn)\n" +
+		"  * [`awsSecretsManagerRaw` *arn*](#awssecretsmanagerraw-arn)\n" +
+		"  * [`azureKeyVault` *secret* [*vault*]](#azurekeyvault-secret-vault)\n" +
 		"  * [`bitwarden` [*args*]](#bitwarden-args)\n" +
+		"  * [`doppler` [*project* [*config*]]](#doppler-project-config)\n" +
+		"  * [`gcpSecretManager` *name*](#gcpsecretmanager-name)\n" +
+		"  * [`gcpSecretManagerVersion` *name* *version*](#gcpsecretmanagerversion-name-version)\n" +
 		"  * [`gopass` *gopass-name*](#gopass-gopass-name)\n" +
+		"  * [`gopassFields` *gopass-name*](#gopassfields-gopass-name)\n" +
+		"  * [`gopassRaw` *gopass-name*](#gopassraw-gopass-name)\n" +
+		"  * [`gpgAgentSocket`](#gpgagentsocket)\n" +
+		"  * [`gpgAgentSSHSocket`](#gpgagentsshsocket)\n" +
+		"  * [`include` *filename*](#include-filename)\n" +
+		"  * [`includeTemplate` *name* [*data*]](#includetemplate-name-data)\n" +
 		"  * [`keepassxc` *entry*](#keepassxc-entry)\n" +
 		"  * [`keepassxcAttribute` *entry* *attribute*](#keepassxcattribute-entry-attribute)\n" +
 		"  * [`keyring` *service* *user*](#keyring-service-user)\n" +
 		"  * [`lastpass` *id*](#lastpass-id)\n" +
 		"  * [`lastpassRaw` *id*](#lastpassraw-id)\n" +
+		"  * [`machineID` *salt*](#machineid-salt)\n" +
 		"  * [`onepassword` *uuid*](#onepassword-uuid)\n" +
 		"  * [`onepasswordDocument` *uuid*](#onepassworddocument-uuid)\n" +
+		"  * [`output` *name* [*args*]](#output-name-args)\n" +
 		"  * [`pass` *pass-name*](#pass-pass-name)\n" +
+		"  * [`promptBool` *prompt*](#promptbool-prompt)\n" +
+		"  * [`promptInt` *prompt*](#promptint-prompt)\n" +
 		"  * [`promptString` *prompt*](#promptstring-prompt)\n" +
+		"  * [`promptStringOnce` *key* *prompt*](#promptstringonce-key-prompt)\n" +
 		"  * [`secret` [*args*]](#secret-args)\n" +
 		"  * [`secretJSON` [*args*]](#secretjson-args)\n" +
+		"  * [`sshAgentSocket`](#sshagentsocket)\n" +
+		"  * [`stateGet` *namespace* *key*](#stateget-namespace-key)\n" +
+		"  * [`stateSet` *namespace* *key* *value*](#stateset-namespace-key-value)\n" +
+		"  * [`targetContents` *target*](#targetcontents-target)\n" +
+		"  * [`totp` *secret*](#totp-secret)\n" +
 		"  * [`vault` *key*](#vault-key)\n" +
+		"  * [`vaultKVv2` *mount* *key*](#vaultkvv2-mount-key)\n" +
 		"\n" +
 		"## Concepts\n" +
 		"\n" +
@@ -1604,6 +1917,24 @@ func init() {
 		"\n" +
 		"Command line flags override any values set in the configuration file.\n" +
 		"\n" +
+		"Global flags can also be set with environment variables, which is useful in\n" +
+		"wrapper scripts and containers. Command line flags take precedence over\n" +
+		"environment variables, which take precedence over the configuration file.\n" +
+		"When chezmoi is run by chezmoi, for example from a script, `CHEZMOI_DESTINATION`\n" +
+		"and `CHEZMOI_SOURCE_DIR` are ignored, as they are set by the parent chezmoi.\n" +
+		"\n" +
+		"| Flag            | Environment variable  |\n" +
+		"| --------------- | --------------------- |\n" +
+		"| `--color`       | `CHEZMOI_COLOR`       |\n" +
+		"| `--config`      | `CHEZMOI_CONFIG`      |\n" +
+		"| `--debug`       | `CHEZMOI_DEBUG`       |\n" +
+		"| `--destination` | `CHEZMOI_DESTINATION` |\n" +
+		"| `--dry-run`     | `CHEZMOI_DRY_RUN`     |\n" +
+		"| `--follow`      | `CHEZMOI_FOLLOW`      |\n" +
+		"| `--remove`      | `CHEZMOI_REMOVE`      |\n" +
+		"| `--source`      | `CHEZMOI_SOURCE_DIR`  |\n" +
+		"| `--verbose`     | `CHEZMOI_VERBOSE`     |\n" +
+		"\n" +
 		"### `--color` *value*\n" +
 		"\n" +
 		"Colorize diffs, *value* can be `on`, `off`, `auto`, or any boolean-like value\n" +
@@ -1616,13 +1947,31 @@ func init() {
 		"\n" +
 		"Read the configuration from *filename*.\n" +
 		"\n" +
+		"### `--data` *pairs*\n" +
+		"\n" +
+		"Override template data for this run only. *pairs* is a comma-separated list of\n" +
+		"*key*`=`*value* pairs, where *key* may use `.` to set a nested value, for example\n" +
+		"`--data email=me@ci.example.com,git.name=CI`. Values are always strings. This\n" +
+		"flag may be specified multiple times. The `affected` command has its own\n" +
+		"`--data` flag, so template data cannot be overridden with `--data` there.\n" +
+		"\n" +
+		"Template data can also be overridden with environment variables called\n" +
+		"`CHEZMOI_DATA_`*key*, where `__` separates nested keys, for example\n" +
+		"`CHEZMOI_DATA_git__name=CI`. Values from `--data` take precedence over values\n" +
+		"from environment variables, which take precedence over all other template data,\n" +
+		"including the automatically populated `.chezmoi` variables.\n" +
+		"\n" +
 		"### `--debug`\n" +
 		"\n" +
 		"Log information helpful for debugging.\n" +
 		"\n" +
 		"### `-D`, `--destination` *directory*\n" +
 		"\n" +
-		"Use *directory* as the destination directory.\n" +
+		"Use *directory* as the destination directory. *directory* may also be an\n" +
+		"`ssh://[user@]host[:port][/path]` URL, in which case `apply` updates *path* on\n" +
+		"the remote host over `ssh`, or a `docker://[user@]container[/path]` or\n" +
+		"`podman://[user@]container[/path]` URL, in which case `apply` updates *path* in\n" +
+		"the running container. See [`apply`](#apply-targets).\n" +
 		"\n" +
 		"### `-f`, `--follow`\n" +
 		"\n" +
@@ -1647,6 +1996,18 @@ func init() {
 		"\n" +
 		"Use *directory* as the source directory.\n" +
 		"\n" +
+		"### `--user` *username*\n" +
+		"\n" +
+		"Use the home directory of *username* as the destination directory. If chezmoi\n" +
+		"is running as root, for example with `sudo`, then *username* owns every file,\n" +
+		"directory, and symlink that chezmoi creates in their home directory.\n" +
+		"\n" +
+		"When running as root without `--user`, `apply`, `update`, and `init --apply`\n" +
+		"refuse to update a destination directory that is owned by another user, as\n" +
+		"this usually means that `sudo` kept `$HOME` pointing at the invoking user's home\n" +
+		"directory and the files written would be owned by root. Pass `--force` to apply\n" +
+		"anyway.\n" +
+		"\n" +
 		"### `-v`, `--verbose`\n" +
 		"\n" +
 		"Set verbose mode. In verbose mode, chezmoi prints the changes that it is making\n" +
@@ -1673,39 +2034,282 @@ func init() {
 		"\n" +
 		"The following configuration variables are available:\n" +
 		"\n" +
-		"| Variable                | Type     | Default value             | Description                                         |\n" +
-		"| ----------------------- | -------- | ------------------------- | --------------------------------------------------- |\n" +
-		"| `bitwarden.command`     | string   | `bw`                      | Bitwarden CLI command                               |\n" +
-		"| `cd.command`            | string   | *none*                    | Shell to run in `cd` command                        |\n" +
-		"| `color`                 | string   | `auto`                    | Colorize diffs                                      |\n" +
-		"| `data`                  | any      | *none*                    | Template data                                       |\n" +
-		"| `destDir`               | string   | `~`                       | Destination directory                               |\n" +
-		"| `diff.format`           | string   | `chezmoi`                 | Diff format, either `chezmoi` or `git`              |\n" +
-		"| `diff.pager`            | string   | *none*                    | Pager                                               |\n" +
-		"| `dryRun`                | bool     | `false`                   | Dry run mode                                        |\n" +
-		"| `follow`                | bool     | `false`                   | Follow symlinks                                     |\n" +
-		"| `genericSecret.command` | string   | *none*                    | Generic secret command                              |\n" +
-		"| `gopass.command`        | string   | `gopass`                  | gopass CLI command                                  |\n" +
-		"| `gpg.command`           | string   | `gpg`                     | GPG CLI command                                     |\n" +
-		"| `gpg.recipient`         | string   | *none*                    | GPG recipient                                       |\n" +
-		"| `gpg.symmetric`         | bool     | `false`                   | Use symmetric GPG encryption                        |\n" +
-		"| `keepassxc.args`        | []string | *none*                    | Extra args to KeePassXC CLI command                 |\n" +
-		"| `keepassxc.command`     | string   | `keepassxc-cli`           | KeePassXC CLI command                               |\n" +
-		"| `keepassxc.database`    | string   | *none*                    | KeePassXC database                                  |\n" +
-		"| `lastpass.command`      | string   | `lpass`                   | Lastpass CLI command                                |\n" +
-		"| `merge.args`            | []string | *none*                    | Extra args to 3-way merge command                   |\n" +
-		"| `merge.command`         | string   | `vimdiff`                 | 3-way merge command                                 |\n" +
-		"| `onepassword.command`   | string   | `op`                      | 1Password CLI command                               |\n" +
-		"| `pass.command`          | string   | `pass`                    | Pass CLI command                                    |\n" +
-		"| `remove`                | bool     | `false`                   | Remove targets                                      |\n" +
-		"| `sourceDir`             | string   | `~/.local/share/chezmoi`  | Source directory                                    |\n" +
-		"| `sourceVCS.autoCommit`  | bool     | `false`                   | Commit changes to the source state after any change |\n" +
-		"| `sourceVCS.autoPush`    | bool     | `false`                   | Push changes to the source state after any change   |\n" +
-		"| `sourceVCS.command`     | string   | `git`                     | Source version control system                       |\n" +
-		"| `template.options`      | []string | `[\"missingkey=error\"]`    | Template options                                    |\n" +
-		"| `umask`                 | int      | *from system*             | Umask                                               |\n" +
-		"| `vault.command`         | string   | `vault`                   | Vault CLI command                                   |\n" +
-		"| `verbose`               | bool     | `false`                   | Verbose mode                                        |\n" +
+		"| Variable                       | Type     | Default value            | Description                                         |\n" +
+		"| ------------------------------ | -------- | ------------------------ | --------------------------------------------------- |\n" +
+		"| `age.command`                  | string   | `age`                    | age CLI command, used with age plugins              |\n" +
+		"| `age.identities`               | []string | *none*                   | Additional age identity files                       |\n" +
+		"| `age.identity`                 | string   | *none*                   | age identity file                                   |\n" +
+		"| `age.keyring.service`          | string   | *none*                   | Keyring service of age identity or passphrase       |\n" +
+		"| `age.keyring.user`             | string   | *none*                   | Keyring user of age identity or passphrase          |\n" +
+		"| `age.recipient`                | string   | *none*                   | age recipient                                       |\n" +
+		"| `age.recipients`               | []string | *none*                   | Additional age recipients or forge users            |\n" +
+		"| `age.recipientsFile`           | string   | *none*                   | File containing age recipients, one per line        |\n" +
+		"| `aliases`                      | map      | *none*                   | Command aliases                                     |\n" +
+		"| `authorizedKeys.fingerprints`  | map      | *none*                   | Allowed SSH key fingerprints for each forge user    |\n" +
+		"| `authorizedKeys.refreshPeriod` | duration | `0`                      | Maximum age of cached SSH keys                      |\n" +
+		"| `authorizedKeys.urls`          | map      | *none*                   | URL formats for fetching SSH keys                   |\n" +
+		"| `awsSecretsManager.endpoint`   | string   | *none*                   | AWS Secrets Manager endpoint URL                    |\n" +
+		"| `awsSecretsManager.profile`    | string   | *none*                   | AWS shared config profile                           |\n" +
+		"| `awsSecretsManager.region`     | string   | *none*                   | AWS region                                          |\n" +
+		"| `azureKeyVault.defaultVault`   | string   | *none*                   | Default Azure Key Vault name                        |\n" +
+		"| `azureKeyVault.vaults`         | map      | *none*                   | Per-vault `url`, `tenantID`, and `clientID`         |\n" +
+		"| `bitwarden.command`            | string   | `bw`                     | Bitwarden CLI command, `bw` or `rbw`                |\n" +
+		"| `cd.command`                   | string   | *none*                   | Shell to run in `cd` command                        |\n" +
+		"| `color`                        | string   | `auto`                   | Colorize diffs                                      |\n" +
+		"| `crossFileSystems`             | bool     | `false`                  | Allow removals to cross filesystem boundaries       |\n" +
+		"| `data`                         | any      | *none*                   | Template data                                       |\n" +
+		"| `degradedFS.auto`              | bool     | `true`                   | Detect filesystems without permissions or symlinks  |\n" +
+		"| `degradedFS.paths`             | []string | *none*                   | Paths without support for permissions or symlinks   |\n" +
+		"| `destDir`                      | string   | `~`                      | Destination directory                               |\n" +
+		"| `destDirOverrides`             | []object | *none*                   | Destination directory overrides                     |\n" +
+		"| `diff.format`                  | string   | `chezmoi`                | Diff format, either `chezmoi` or `git`              |\n" +
+		"| `diff.pager`                   | string   | *none*                   | Pager                                               |\n" +
+		"| `docker.args`                  | []string | *none*                   | Extra args to `docker` for `docker://` destinations |\n" +
+		"| `docker.command`               | string   | `docker`                 | `docker` command for `docker://` destinations       |\n" +
+		"| `doppler.command`              | string   | `doppler`                | Doppler CLI command                                 |\n" +
+		"| `doppler.config`               | string   | *none*                   | Default Doppler config                              |\n" +
+		"| `doppler.endpoint`             | string   | *see below*              | Doppler API endpoint URL                            |\n" +
+		"| `doppler.project`              | string   | *none*                   | Default Doppler project                             |\n" +
+		"| `doppler.token`                | string   | *none*                   | Doppler API token, uses the CLI if not set          |\n" +
+		"| `dryRun`                       | bool     | `false`                  | Dry run mode                                        |\n" +
+		"| `encryption`                   | string   | `gpg`                    | Encryption, either `gpg`, `age`, or `dpapi`         |\n" +
+		"| `externals.maxBandwidth`       | string   | *none*                   | Maximum download speed per second, e.g. `1MB`       |\n" +
+		"| `externals.maxCacheSize`       | string   | *none*                   | Maximum size of the externals cache, e.g. `1GB`     |\n" +
+		"| `fifos`                        | bool     | `false`                  | Create FIFOs from `fifo_` source files              |\n" +
+		"| `fleet.sshCommand`             | string   | `ssh`                    | ssh CLI used by `fleet apply`                       |\n" +
+		"| `follow`                       | bool     | `false`                  | Follow symlinks                                     |\n" +
+		"| `gcpSecretManager.endpoint`    | string   | *see below*              | Secret Manager API endpoint URL                     |\n" +
+		"| `gcpSecretManager.project`     | string   | *none*                   | Default Google Cloud project                        |\n" +
+		"| `genericSecret.command`        | string   | *none*                   | Generic secret command                              |\n" +
+		"| `git.autoCommit`               | bool     | `false`                  | Commit changes to the source state after any change |\n" +
+		"| `git.autoPush`                 | bool     | `false`                  | Push changes to the source state after any change   |\n" +
+		"| `gitHub.apiURL`                | string   | `https://api.github.com` | GitHub API URL for `github-release` externals       |\n" +
+		"| `gitHub.token`                 | string   | *none*                   | GitHub API token, `$GITHUB_TOKEN` if not set        |\n" +
+		"| `gopass.command`               | string   | `gopass`                 | gopass CLI command                                  |\n" +
+		"| `gpg.command`                  | string   | `gpg`                    | GPG CLI command                                     |\n" +
+		"| `gpg.recipient`                | string   | *none*                   | GPG recipient                                       |\n" +
+		"| `gpg.symmetric`                | bool     | `false`                  | Use symmetric GPG encryption                        |\n" +
+		"| `keepassxc.args`               | []string | *none*                   | Extra args to KeePassXC CLI command                 |\n" +
+		"| `keepassxc.browser.id`         | string   | *none*                   | KeePassXC browser integration association name      |\n" +
+		"| `keepassxc.browser.key`        | string   | *none*                   | KeePassXC browser integration association key       |\n" +
+		"| `keepassxc.browser.socket`     | string   | *see below*              | KeePassXC browser integration socket                |\n" +
+		"| `keepassxc.command`            | string   | `keepassxc-cli`          | KeePassXC CLI command                               |\n" +
+		"| `keepassxc.database`           | string   | *none*                   | KeePassXC database                                  |\n" +
+		"| `keepassxc.keyFile`            | string   | *none*                   | KeePassXC database key file                         |\n" +
+		"| `keepassxc.keyring.service`    | string   | *none*                   | Keyring service of KeePassXC database password      |\n" +
+		"| `keepassxc.keyring.user`       | string   | *none*                   | Keyring user of KeePassXC database password         |\n" +
+		"| `keepassxc.mode`               | string   | `cli`                    | KeePassXC access mode, `cli` or `browser`           |\n" +
+		"| `keepassxc.noPassword`         | bool     | `false`                  | KeePassXC database has no password                  |\n" +
+		"| `lastpass.command`             | string   | `lpass`                  | Lastpass CLI command                                |\n" +
+		"| `lfs.command`                  | string   | `git`                    | git command used to fetch git-lfs objects           |\n" +
+		"| `machineID.salt`               | string   | *none*                   | Salt for `.chezmoi.machineID`                       |\n" +
+		"| `merge.args`                   | []string | *none*                   | Extra args to 3-way merge command                   |\n" +
+		"| `merge.command`                | string   | `vimdiff`                | 3-way merge command                                 |\n" +
+		"| `metrics.file`                 | string   | *none*                   | File to append command metrics to                   |\n" +
+		"| `metrics.prefix`               | string   | `chezmoi`                | Prefix for statsd metric names                      |\n" +
+		"| `metrics.statsd`               | string   | *none*                   | statsd address to send command metrics to          |\n" +
+		"| `noConfirm`                    | bool     | `false`                  | Never prompt before destructive operations          |\n" +
+		"| `onepassword.command`          | string   | `op`                     | 1Password CLI command                               |\n" +
+		"| `onepassword.connectHost`      | string   | `$OP_CONNECT_HOST`       | 1Password Connect server URL                        |\n" +
+		"| `onepassword.connectToken`     | string   | `$OP_CONNECT_TOKEN`      | 1Password Connect access token                      |\n" +
+		"| `onepassword.connectVault`     | string   | *none*                   | 1Password Connect vault UUID to search              |\n" +
+		"| `pass.command`                 | string   | `pass`                   | Pass CLI command                                    |\n" +
+		"| `podman.args`                  | []string | *none*                   | Extra args to `podman` for `podman://` destinations |\n" +
+		"| `podman.command`               | string   | `podman`                 | `podman` command for `podman://` destinations       |\n" +
+		"| `promptStringOnce.encrypt`     | bool     | `false`                  | Encrypt `promptStringOnce` values                   |\n" +
+		"| `proxy.noProxy`                | string   | *none*                   | Hosts not to use `proxy.url` for                    |\n" +
+		"| `proxy.pac`                    | string   | *none*                   | PAC file URL or path                                |\n" +
+		"| `proxy.pacArgs`                | []string | *none*                   | Extra args to the PAC command                       |\n" +
+		"| `proxy.pacCommand`             | string   | *see below*              | Command to evaluate PAC files                       |\n" +
+		"| `proxy.url`                    | string   | *none*                   | HTTP and HTTPS proxy                                |\n" +
+		"| `remove`                       | bool     | `false`                  | Remove targets                                      |\n" +
+		"| `removeToTrash`                | bool     | `false`                  | Move removed targets to the trash                   |\n" +
+		"| `secret.tempDir`               | string   | *none*                   | Directory for temporary decrypted files             |\n" +
+		"| `sevenZip.command`             | string   | `7z`                     | 7z CLI command                                      |\n" +
+		"| `sops.command`                 | string   | `sops`                   | SOPS CLI command                                    |\n" +
+		"| `sourceDir`                    | string   | `~/.local/share/chezmoi` | Source directory                                    |\n" +
+		"| `sourceVCS.autoCommit`         | bool     | `false`                  | Equivalent to `git.autoCommit`                      |\n" +
+		"| `sourceVCS.autoPush`           | bool     | `false`                  | Equivalent to `git.autoPush`                        |\n" +
+		"| `sourceVCS.command`            | string   | `git`                    | Source version control system                       |\n" +
+		"| `sourceVCS.pull`               | string   | *none*                   | Arguments to the pull command run by `update`       |\n" +
+		"| `ssh.args`                     | []string | *none*                   | Extra args to `ssh` for `ssh://` destinations       |\n" +
+		"| `ssh.command`                  | string   | `ssh`                    | `ssh` command for `ssh://` destinations             |\n" +
+		"| `sudo.args`                    | []string | *none*                   | Extra args to privilege escalation command          |\n" +
+		"| `sudo.command`                 | string   | *none*                   | Privilege escalation command                        |\n" +
+		"| `template.displayData`         | bool     | `false`                  | Include `.chezmoi.display` in template data         |\n" +
+		"| `template.funcTimeout`         | duration | `1m`                     | Timeout for commands run by template functions      |\n" +
+		"| `template.networkData`         | bool     | `false`                  | Include `.chezmoi.network` in template data         |\n" +
+		"| `template.state`               | bool     | `false`                  | Enable `stateGet` and `stateSet`                    |\n" +
+		"| `template.options`             | []string | `[\"missingkey=error\"]`   | Template options                                    |\n" +
+		"| `template.proxyData`           | bool     | `false`                  | Include `.chezmoi.proxy` in template data           |\n" +
+		"| `umask`                        | int      | *from system*            | Umask                                               |\n" +
+		"| `vault.address`                | string   | *none*                   | Vault server URL, use the Vault CLI if not set      |\n" +
+		"| `vault.authMethod`             | string   | `token`                  | Authentication method, `token`, `approle`, or `aws` |\n" +
+		"| `vault.authMount`              | string   | *auth method*            | Path where the authentication method is mounted     |\n" +
+		"| `vault.awsServerID`            | string   | *none*                   | Value of `X-Vault-AWS-IAM-Server-ID` for `aws` auth |\n" +
+		"| `vault.command`                | string   | `vault`                  | Vault CLI command                                   |\n" +
+		"| `vault.minTTL`                 | duration | `5m`                     | Renew tokens that expire sooner than this           |\n" +
+		"| `vault.namespace`              | string   | `$VAULT_NAMESPACE`       | Vault namespace                                     |\n" +
+		"| `vault.role`                   | string   | *none*                   | Role for `aws` auth                                 |\n" +
+		"| `vault.roleID`                 | string   | *none*                   | Role ID for `approle` auth                          |\n" +
+		"| `vault.secretID`               | string   | *none*                   | Secret ID for `approle` auth                        |\n" +
+		"| `vault.token`                  | string   | `$VAULT_TOKEN`           | Token for `token` auth, or `~/.vault-token`         |\n" +
+		"| `verbose`                      | bool     | `false`                  | Verbose mode                                        |\n" +
+		"| `xz.command`                   | string   | `xz`                     | xz CLI command                                      |\n" +
+		"| `zstd.command`                 | string   | `zstd`                   | zstd CLI command                                    |\n" +
+		"\n" +
+		"### Destination directory overrides\n" +
+		"\n" +
+		"`destDirOverrides` applies a subtree of the target state to a different\n" +
+		"location, which is useful when an application stores its configuration in\n" +
+		"different places on different platforms. Each override has a `from` target\n" +
+		"directory and a `to` directory, both relative to the destination directory,\n" +
+		"unless `to` is absolute. The longest matching `from` is used. For example, to\n" +
+		"install `~/.local/share/chezmoi/dot_config/Code` as `~/Library/Application\n" +
+		"Support/Code` on macOS, use:\n" +
+		"\n" +
+		"    [[destDirOverrides]]\n" +
+		"      from = \".config/Code\"\n" +
+		"      to = \"Library/Application Support/Code\"\n" +
+		"\n" +
+		"If you generate your config file with\n" +
+		"[`.chezmoi.<format>.tmpl`](#chezmoiformattmpl) then you can set the override\n" +
+		"only on the machines that need it:\n" +
+		"\n" +
+		"    {{- if eq .chezmoi.os \"darwin\" }}\n" +
+		"    [[destDirOverrides]]\n" +
+		"      from = \".config/Code\"\n" +
+		"      to = \"Library/Application Support/Code\"\n" +
+		"    {{- end }}\n" +
+		"\n" +
+		"### Degraded filesystems\n" +
+		"\n" +
+		"Some filesystems, for example Samba shares, some NFS mounts, FAT-formatted\n" +
+		"drives, and Android shared storage, do not support permissions or symlinks. On these filesystems chezmoi\n" +
+		"prints a warning instead of failing when it is not permitted to set\n" +
+		"permissions, and writes a copy of a symlink's target when it cannot create the\n" +
+		"symlink. Each kind of warning is printed at most once per command.\n" +
+		"\n" +
+		"By default, chezmoi detects these filesystems automatically on Linux and macOS.\n" +
+		"To treat other paths the same way, list them in `degradedFS.paths`, for\n" +
+		"example:\n" +
+		"\n" +
+		"    [degradedFS]\n" +
+		"      paths = [\"~/share\"]\n" +
+		"\n" +
+		"To disable automatic detection, set `degradedFS.auto` to `false`.\n" +
+		"\n" +
+		"### System configuration file\n" +
+		"\n" +
+		"An optional system configuration file, for example one installed by your\n" +
+		"organization's configuration management, is read before your config file. It\n" +
+		"is called `chezmoi` with any of the supported extensions and is in\n" +
+		"`/etc/chezmoi` (`$PREFIX/etc/chezmoi` in Termux, `%ProgramData%\\chezmoi` on\n" +
+		"Windows). Values in your config file override values in the system\n" +
+		"configuration file, and tables, including `data`, are merged, so the system\n" +
+		"configuration file can provide defaults like proxy settings while you keep your\n" +
+		"own values. `chezmoi config validate` checks the system configuration file too.\n" +
+		"\n" +
+		"#### System configuration file examples\n" +
+		"\n" +
+		"`/etc/chezmoi/chezmoi.yaml`:\n" +
+		"\n" +
+		"```yaml\n" +
+		"data:\n" +
+		"  proxy: http://proxy.example.com:3128\n" +
+		"git:\n" +
+		"  autoCommit: true\n" +
+		"```\n" +
+		"\n" +
+		"### Command aliases\n" +
+		"\n" +
+		"`aliases` defines abbreviations for commands that you run often. When the\n" +
+		"command is an alias, chezmoi replaces it with the alias's value, split into\n" +
+		"arguments like a shell without any expansions, and appends any remaining\n" +
+		"arguments. Aliases may refer to other aliases, but built-in commands cannot be\n" +
+		"aliased.\n" +
+		"\n" +
+		"#### Command aliases examples\n" +
+		"\n" +
+		"    [aliases]\n" +
+		"      sync = \"update --apply\"\n" +
+		"      wip = \"git -- add -A\"\n" +
+		"\n" +
+		"With these aliases, `chezmoi sync` runs `chezmoi update --apply` and `chezmoi\n" +
+		"wip` runs `chezmoi git -- add -A`.\n" +
+		"\n" +
+		"### Command metrics\n" +
+		"\n" +
+		"chezmoi can record how long each command took and whether it succeeded, so you\n" +
+		"can monitor drift and apply duration across your machines. Metrics are never\n" +
+		"collected unless you enable them, and are only written to places that you\n" +
+		"choose.\n" +
+		"\n" +
+		"If `metrics.file` is set then chezmoi appends a line of JSON to it after every\n" +
+		"command, containing the time, hostname, command, duration in seconds, success,\n" +
+		"and any error. `apply` and `update` also record the number of targets written\n" +
+		"and removed.\n" +
+		"\n" +
+		"If `metrics.statsd` is set to a `host:port` address then chezmoi sends the same\n" +
+		"metrics over UDP in the statsd format, for example\n" +
+		"`chezmoi.apply.duration:1250|ms`, `chezmoi.apply.success:1|c`, and\n" +
+		"`chezmoi.apply.written:3|g`. Metric names start with `metrics.prefix`.\n" +
+		"\n" +
+		"Failing to write metrics prints a warning but does not change the result of the\n" +
+		"command.\n" +
+		"\n" +
+		"#### Command metrics examples\n" +
+		"\n" +
+		"    [metrics]\n" +
+		"      file = \"~/.local/state/chezmoi/metrics.jsonl\"\n" +
+		"      statsd = \"127.0.0.1:8125\"\n" +
+		"\n" +
+		"### Proxies\n" +
+		"\n" +
+		"chezmoi uses a proxy for all of its HTTP requests, including downloading\n" +
+		"externals, fetching `authorizedKeys`, and `upgrade`. The proxy is, in order of\n" +
+		"precedence:\n" +
+		"\n" +
+		"1. `proxy.url`, except for the hosts in `proxy.noProxy`.\n" +
+		"2. The result of the PAC file `proxy.pac`.\n" +
+		"3. The `HTTPS_PROXY`, `HTTP_PROXY`, and `NO_PROXY` environment variables, or\n" +
+		"   their lowercase equivalents.\n" +
+		"4. The system proxy settings: on macOS, the settings from `scutil --proxy`, and\n" +
+		"   on Windows, the current user's Internet Options, including PAC files and\n" +
+		"   automatic detection with WPAD. On systems other than Windows, the system PAC\n" +
+		"   file is ignored.\n" +
+		"\n" +
+		"Requests to loopback addresses are never proxied. `proxy.url` may omit the\n" +
+		"`http://` scheme. `proxy.noProxy` and the system proxy exceptions are lists of\n" +
+		"host names, which also match their subdomains, IP addresses, CIDR ranges, `*`,\n" +
+		"which matches every host, and `<local>`, which matches host names without a\n" +
+		"domain.\n" +
+		"\n" +
+		"When `proxy.url` is set, chezmoi also sets `HTTP_PROXY`, `HTTPS_PROXY`, and\n" +
+		"`NO_PROXY`, and their lowercase equivalents, in the [environment of the\n" +
+		"processes that it runs](#child-process-environment), so `git`, `curl`, and\n" +
+		"scripts use the same proxy.\n" +
+		"\n" +
+		"`proxy.pac` is an `https` URL or a path to a PAC file. On Windows, PAC files\n" +
+		"are evaluated by WinHTTP. On other systems, chezmoi evaluates them with\n" +
+		"`proxy.pacCommand`, which defaults to `osascript -l JavaScript` on macOS and\n" +
+		"`node` elsewhere, with `proxy.pacArgs` as extra arguments. The script is passed\n" +
+		"on the standard input and the result is read from the standard output. As the\n" +
+		"PAC file is run as your user, only set `proxy.pac` to a PAC file that you trust.\n" +
+		"PAC files are fetched and evaluated once per host per run. The PAC helper\n" +
+		"functions are implemented, except that `dnsResolve` and `isResolvable` only\n" +
+		"resolve the requested host and `dateRange` always returns `false`. If the PAC\n" +
+		"file cannot be fetched or evaluated then chezmoi prints a warning and connects\n" +
+		"directly.\n" +
+		"\n" +
+		"#### Proxies examples\n" +
+		"\n" +
+		"    [proxy]\n" +
+		"      url = \"proxy.example.com:3128\"\n" +
+		"      noProxy = \"localhost,.example.com,10.0.0.0/8\"\n" +
+		"\n" +
+		"    [proxy]\n" +
+		"      pac = \"https://wpad.example.com/proxy.pac\"\n" +
 		"\n" +
 		"## Source state attributes\n" +
 		"\n" +
@@ -1720,30 +2324,240 @@ func init() {
 		"| Prefix       | Effect                                                                         |\n" +
 		"| ------------ | ------------------------------------------------------------------------------ |\n" +
 		"| `encrypted_` | Encrypt the file in the source state.                                          |\n" +
+		"| `compressed_`| Compress the file in the source state with zstd.                               |\n" +
 		"| `once_`      | Only run script once.                                                          |\n" +
+		"| `onchange_`  | Only run script when its contents have changed since it last ran.              |\n" +
+		"| `before_`    | Run script before updating any other targets.                                  |\n" +
+		"| `after_`     | Run script after updating all other targets.                                   |\n" +
 		"| `private_`   | Remove all group and world permissions from the target file or directory.      |\n" +
 		"| `empty_`     | Ensure the file exists, even if is empty. By default, empty files are removed. |\n" +
 		"| `exact_`     | Remove anything not managed by chezmoi.                                        |\n" +
 		"| `executable_`| Add executable permissions to the target file.                                 |\n" +
+		"| `fifo_`      | Create a FIFO (named pipe) instead of a regular file.                          |\n" +
+		"| `hardlink_`  | Create a hard link to another target instead of a regular file.                |\n" +
 		"| `run_`       | Treat the contents as a script to run.                                         |\n" +
 		"| `symlink_`   | Create a symlink instead of a regular file.                                    |\n" +
 		"| `dot_`       | Rename to use a leading dot, e.g. `dot_foo` becomes `.foo`.                    |\n" +
-		"\n" +
-		"| Suffix  | Effect                                               |\n" +
-		"| ------- | ---------------------------------------------------- |\n" +
-		"| `.tmpl` | Treat the contents of the source file as a template. |\n" +
-		"\n" +
-		"Order of prefixes is important, the order is `run_`, `exact_`, `private_`,\n" +
-		"`empty_`, `executable_`, `symlink_`, `once_`, `dot_`.\n" +
-		"\n" +
-		"Different target types allow different prefixes and suffixes:\n" +
-		"\n" +
-		"| Target type   | Allowed prefixes                                          | Allowed suffixes |\n" +
-		"| ------------- | --------------------------------------------------------- | ---------------- |\n" +
-		"| Directory     | `exact_`, `private_`, `dot_`                              | *none*           |\n" +
-		"| Regular file  | `encrypted_`, `private_`, `empty_`, `executable_`, `dot_` | `.tmpl`          |\n" +
-		"| Script        | `run_`, `once_`                                           | `.tmpl`          |\n" +
-		"| Symbolic link | `symlink_`, `dot_`,                                       | `.tmpl`          |\n" +
+		"| `literal_`   | Stop parsing prefixes, e.g. `literal_dot_foo` becomes `dot_foo`.               |\n" +
+		"\n" +
+		"| Suffix     | Effect                                                              |\n" +
+		"| ---------- | ------------------------------------------------------------------- |\n" +
+		"| `.tmpl`    | Treat the contents of the source file as a template.                |\n" +
+		"| `.literal` | Stop parsing suffixes, e.g. `foo.tmpl.literal` becomes `foo.tmpl`. |\n" +
+		"\n" +
+		"Different target types allow different prefixes and suffixes. Order of\n" +
+		"prefixes is important: each prefix may appear at most once, and only in the\n" +
+		"order given here:\n" +
+		"\n" +
+		"| Target type   | Allowed prefixes                                                                       | Allowed suffixes    |\n" +
+		"| ------------- | -------------------------------------------------------------------------------------- | ------------------- |\n" +
+		"| Directory     | `exact_`, `private_`, `literal_` or `dot_`                                             | *none*              |\n" +
+		"| Regular file  | `encrypted_`, `compressed_`, `private_`, `empty_`, `executable_`, `literal_` or `dot_` | `.literal`, `.tmpl` |\n" +
+		"| Script        | `run_`, `once_` or `onchange_`, `before_` or `after_`, `literal_`                      | `.literal`, `.tmpl` |\n" +
+		"| Symbolic link | `symlink_`, `literal_` or `dot_`                                                       | `.literal`, `.tmpl` |\n" +
+		"| Hard link     | `hardlink_`, `literal_` or `dot_`                                                      | `.literal`, `.tmpl` |\n" +
+		"| FIFO          | `fifo_`, `private_`, `literal_` or `dot_`                                              | *none*              |\n" +
+		"\n" +
+		"Prefixes that are out of order or repeated are part of the target name, as is\n" +
+		"any prefix or suffix that would otherwise leave an empty target name, `.`, or\n" +
+		"`..`. `literal_` may appear before or after any prefix and ends the prefixes, so\n" +
+		"everything after it is part of the target name. For example `literal_run_foo` is\n" +
+		"a regular file called `run_foo` and `private_literal_dot_foo` is a private file\n" +
+		"called `dot_foo`. Similarly, `.literal` ends the suffixes, so\n" +
+		"`foo.tmpl.literal.tmpl` is a template for a file called `foo.tmpl`. chezmoi adds\n" +
+		"`literal_` and `.literal` automatically when adding targets whose names would\n" +
+		"otherwise be parsed as attributes. Use `chezmoi parse-source-name` to check how\n" +
+		"a source name is parsed.\n" +
+		"\n" +
+		"On macOS, target names are normalized to Unicode Normalization Form C (NFC), so\n" +
+		"that a name containing accented characters is the same target whether the\n" +
+		"source directory's names were decomposed by the macOS filesystem or not. Names\n" +
+		"in the destination directory are normalized in the same way when looking for\n" +
+		"unmanaged entries in `exact_` directories. On other systems, whose filesystems\n" +
+		"distinguish names that differ only in their normalization, names are not\n" +
+		"normalized.\n" +
+		"\n" +
+		"### Hard links and FIFOs\n" +
+		"\n" +
+		"The contents of a `hardlink_` source file are the target name of the file that\n" +
+		"the hard link shares an inode with, relative to the destination directory, for\n" +
+		"example `.bashrc`. chezmoi applies hard links after everything else, replacing\n" +
+		"the target if it is not already the same file. The file that it links to must\n" +
+		"be in the destination directory, and must also be managed by chezmoi or already\n" +
+		"exist.\n" +
+		"\n" +
+		"FIFOs are only created if `fifos` is set to `true` in the configuration file,\n" +
+		"otherwise applying a `fifo_` source file is an error. The contents of a `fifo_`\n" +
+		"source file are ignored. FIFOs are not supported on Windows.\n" +
+		"\n" +
+		"### Mount points\n" +
+		"\n" +
+		"chezmoi never removes a target that is on, or contains, a different filesystem\n" +
+		"to its parent directory. For example, if a NAS is mounted on `~/media/nas` and\n" +
+		"`~/media` is an `exact_` directory, then chezmoi reports an error instead of\n" +
+		"removing `~/media/nas` and everything on the NAS. The same applies to targets\n" +
+		"listed in `.chezmoiremove` and to targets that are replaced by a different type\n" +
+		"of target. To allow removals to cross filesystem boundaries, set\n" +
+		"`crossFileSystems` to `true` in the configuration file. Mount points are\n" +
+		"detected by their device numbers, so bind mounts of the same filesystem are not\n" +
+		"detected, and mount points are not detected on Windows.\n" +
+		"\n" +
+		"### Trash\n" +
+		"\n" +
+		"If `removeToTrash` is set to `true` in the configuration file then chezmoi\n" +
+		"moves targets to the trash instead of removing them, so that they can be\n" +
+		"recovered. This applies to targets removed from `exact_` directories, targets\n" +
+		"listed in `.chezmoiremove`, targets removed by `chezmoi remove`, and targets\n" +
+		"that are replaced by a different type of target, including by hard links and\n" +
+		"FIFOs. Files in the source directory are still removed. The trash is\n" +
+		"`$XDG_DATA_HOME/Trash` on Linux and the BSDs, `~/.Trash` on macOS, and the\n" +
+		"recycle bin on Windows. Targets on a different filesystem to the trash are\n" +
+		"copied to the trash and then removed.\n" +
+		"\n" +
+		"### Encryption\n" +
+		"\n" +
+		"Files with the `encrypted_` attribute are encrypted in the source state with\n" +
+		"the encryption set by the `encryption` configuration variable:\n" +
+		"\n" +
+		"| Encryption | Description                                                            |\n" +
+		"| ---------- | ---------------------------------------------------------------------- |\n" +
+		"| `gpg`      | Encrypt with `gpg`, configured by the `gpg.*` configuration variables. |\n" +
+		"| `age`      | Encrypt with age, configured by the `age.*` configuration variables.   |\n" +
+		"| `dpapi`    | Encrypt with the Windows Data Protection API.                          |\n" +
+		"\n" +
+		"`age` encryption is built in to chezmoi, so `age` does not need to be\n" +
+		"installed. Files are decrypted with the identities in `age.identity` and\n" +
+		"`age.identities`, and encrypted to the recipients in `age.recipient`,\n" +
+		"`age.recipients`, and `age.recipientsFile`. If no recipients are set then files\n" +
+		"are encrypted to the recipients of the identities. Encrypted files are stored as\n" +
+		"armored text.\n" +
+		"\n" +
+		"    encryption = \"age\"\n" +
+		"    [age]\n" +
+		"      identity = \"~/.config/chezmoi/key.txt\"\n" +
+		"      recipient = \"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p\"\n" +
+		"\n" +
+		"Recipients can also be SSH public keys (`ssh-ed25519` or `ssh-rsa`), and\n" +
+		"identities can be unencrypted SSH private keys. A recipient of the form\n" +
+		"*forge*`:`*username*, for example `github:username`, is replaced with the\n" +
+		"user's `ssh-ed25519` and `ssh-rsa` keys on that forge. The keys are fetched,\n" +
+		"cached, and verified exactly as for the [`authorizedKeys`](#authorizedkeys-specs)\n" +
+		"template function, so they can be pinned with `authorizedKeys.fingerprints` and\n" +
+		"cached with `authorizedKeys.refreshPeriod`. This makes sharing an encrypted\n" +
+		"source directory with a teammate a one line change:\n" +
+		"\n" +
+		"    [age]\n" +
+		"      identity = \"~/.ssh/id_ed25519\"\n" +
+		"      recipients = [\"github:alice\", \"github:bob\"]\n" +
+		"\n" +
+		"To keep the identity off disk, store it in the system keyring (see the\n" +
+		"[`keyring`](#keyring-service-user) template function) and set `age.keyring.service` and `age.keyring.user`:\n" +
+		"\n" +
+		"    $ chezmoi secret keyring set --service=chezmoi-age --user=$USER --password=\"$(grep ^AGE-SECRET-KEY- key.txt)\"\n" +
+		"\n" +
+		"    encryption = \"age\"\n" +
+		"    [age.keyring]\n" +
+		"      service = \"chezmoi-age\"\n" +
+		"      user = \"me\"\n" +
+		"\n" +
+		"If the keyring entry is not an age identity then it is used as a passphrase and\n" +
+		"files are encrypted with the passphrase instead of to recipients. Passphrase\n" +
+		"encryption is deliberately slow, so it is best suited to a small number of\n" +
+		"encrypted files. The keyring is queried at most once per command.\n" +
+		"\n" +
+		"Hardware-backed keys are supported through age plugins, for example\n" +
+		"[`age-plugin-yubikey`](https://github.com/str4d/age-plugin-yubikey) for\n" +
+		"YubiKeys and other PIV tokens and\n" +
+		"[`age-plugin-tpm`](https://github.com/Foxboron/age-plugin-tpm) for TPMs. If\n" +
+		"any identity in `age.identity` or `age.identities` is a plugin identity\n" +
+		"(`AGE-PLUGIN-NAME-1...`) or any recipient is a plugin recipient\n" +
+		"(`age1name1...`) then chezmoi runs `age.command`, which must be version 1.0.0\n" +
+		"or later, and the plugin `age-plugin-name` must be in your `$PATH`. The\n" +
+		"plugin's PIN and touch prompts are shown on the terminal while chezmoi runs.\n" +
+		"`chezmoi doctor` checks that `age` and all needed plugins are installed. For\n" +
+		"example, after generating an identity with `age-plugin-yubikey`:\n" +
+		"\n" +
+		"    encryption = \"age\"\n" +
+		"    [age]\n" +
+		"      identity = \"~/.config/chezmoi/yubikey-identity.txt\"\n" +
+		"\n" +
+		"`dpapi` encryption is only available on Windows and needs no extra tools or\n" +
+		"keys. Files are encrypted for the current Windows user and can only be decrypted\n" +
+		"by the same user, normally on the same machine, so it is best suited to source\n" +
+		"directories that are only used on one Windows machine. Encrypted files are\n" +
+		"stored as PEM-encoded text.\n" +
+		"\n" +
+		"    encryption = \"dpapi\"\n" +
+		"\n" +
+		"### Binary files\n" +
+		"\n" +
+		"Large or binary files can be kept small in the source state by adding them\n" +
+		"with `chezmoi add --compress`, which stores them compressed with\n" +
+		"[zstd](https://facebook.github.io/zstd/) and sets the `compressed_` attribute.\n" +
+		"chezmoi runs `zstd` when it needs a file's contents, so compressed files are\n" +
+		"only decompressed when they are applied, diffed, or otherwise used. Compressed\n" +
+		"files that are also encrypted are compressed before they are encrypted.\n" +
+		"\n" +
+		"Alternatively, files can be stored with [git-lfs](https://git-lfs.github.com/).\n" +
+		"Track them in the source directory with `git lfs track`. If the source\n" +
+		"directory was cloned without fetching the git-lfs objects, for example with\n" +
+		"`GIT_LFS_SKIP_SMUDGE=1`, then source files contain git-lfs pointers. chezmoi\n" +
+		"resolves each pointer when the file's contents are needed, reading the object\n" +
+		"from the source directory's local git-lfs store if it is there, and running\n" +
+		"`git lfs smudge` to fetch it otherwise.\n" +
+		"\n" +
+		"### Path prefixes\n" +
+		"\n" +
+		"A top-level source directory whose name is a path prefix in braces refers to\n" +
+		"the corresponding directory on the current machine, so the same source state\n" +
+		"can be used on machines with different directory layouts. The available path\n" +
+		"prefixes are:\n" +
+		"\n" +
+		"| Path prefix     | Linux and other Unixes | macOS                           | Windows            |\n" +
+		"| --------------- | ---------------------- | ------------------------------- | ------------------ |\n" +
+		"| `{root}`        | `/`                    | `/`                             | `C:\\`              |\n" +
+		"| `{xdg-cache}`   | `~/.cache`             | `~/Library/Caches`              | `%LOCALAPPDATA%`   |\n" +
+		"| `{xdg-config}`  | `~/.config`            | `~/Library/Application Support` | `%APPDATA%`        |\n" +
+		"| `{xdg-data}`    | `~/.local/share`       | `~/Library/Application Support` | `%LOCALAPPDATA%`   |\n" +
+		"| `{xdg-runtime}` | `$XDG_RUNTIME_DIR`     | `$XDG_RUNTIME_DIR`              | `$XDG_RUNTIME_DIR` |\n" +
+		"| `{xdg-state}`   | `~/.local/state`       | `~/Library/Application Support` | `%LOCALAPPDATA%`   |\n" +
+		"\n" +
+		"On all platforms, the `XDG_CACHE_HOME`, `XDG_CONFIG_HOME`, `XDG_DATA_HOME`, and\n" +
+		"`XDG_STATE_HOME` environment variables override the defaults. The resolved\n" +
+		"directories are available in templates as `.chezmoi.xdg.cacheHome`,\n" +
+		"`.chezmoi.xdg.configHome`, `.chezmoi.xdg.dataHome`, `.chezmoi.xdg.runtimeDir`,\n" +
+		"and `.chezmoi.xdg.stateHome`.\n" +
+		"\n" +
+		"A directory with a path prefix can have the `exact_` and `private_` attributes.\n" +
+		"Unless it is `private_`, chezmoi does not change the permissions of the\n" +
+		"directory that it refers to.\n" +
+		"\n" +
+		"The `{root}` path prefix refers to the root directory, so\n" +
+		"`~/.local/share/chezmoi/{root}/etc/wsl.conf` is installed as `/etc/wsl.conf`.\n" +
+		"In [Termux](https://termux.com/) on Android, `{root}` is the Termux prefix\n" +
+		"directory, `$PREFIX`, and chezmoi uses `$PREFIX/tmp` for temporary files if\n" +
+		"`$TMPDIR` is not set.\n" +
+		"\n" +
+		"On Windows, `{root}` is the root of the drive containing your home directory,\n" +
+		"or of the system drive if your home directory is on a network share. Paths may\n" +
+		"be given in extended-length form, for example `\\\\?\\C:\\Users\\user` or\n" +
+		"`\\\\?\\UNC\\server\\share\\user`.\n" +
+		"\n" +
+		"Targets outside your home directory, including `{xdg-runtime}` on most systems,\n" +
+		"are always installed at their absolute paths, regardless of the destination\n" +
+		"directory. If `sudo.command` is set in the config file then chezmoi uses it to\n" +
+		"make changes outside your home directory, for example:\n" +
+		"\n" +
+		"    [sudo]\n" +
+		"      command = \"sudo\"\n" +
+		"\n" +
+		"chezmoi then runs commands like `sudo install -m 644 ... /etc/wsl.conf`, and\n" +
+		"`sudo` prompts for your password if needed. Otherwise, chezmoi makes the changes\n" +
+		"directly, which requires that you have permission to do so.\n" +
+		"\n" +
+		"For example, `~/.local/share/chezmoi/{xdg-config}/Code/User/settings.json` is\n" +
+		"installed as `~/.config/Code/User/settings.json` on Linux and as\n" +
+		"`~/Library/Application Support/Code/User/settings.json` on macOS.\n" +
 		"\n" +
 		"## Special files and directories\n" +
 		"\n" +
@@ -1754,7 +2568,8 @@ func init() {
 		"\n" +
 		"If a file called `.chezmoi.<format>.tmpl` exists then `chezmoi init` will use it\n" +
 		"to create an initial config file. *format* must be one of the the supported\n" +
-		"config file formats.\n" +
+		"config file formats. The template can prompt the user for values with the\n" +
+		"`promptString`, `promptBool`, and `promptInt` template functions.\n" +
 		"\n" +
 		"#### `.chezmoi.<format>.tmpl` examples\n" +
 		"\n" +
@@ -1762,6 +2577,168 @@ func init() {
 		"    data:\n" +
 		"        email: \"{{ $email }}\"\n" +
 		"\n" +
+		"### `.chezmoiblobs`\n" +
+		"\n" +
+		"If a file in the source state contains only a line of the form\n" +
+		"\n" +
+		"    chezmoi-blob sha256:<sha256>\n" +
+		"\n" +
+		"then its contents are read from the file `.chezmoiblobs/<xx>/<sha256>` in the\n" +
+		"source directory, where `<xx>` is the first two characters of `<sha256>`. This\n" +
+		"content-addressable blob store lets several source files, for example variants\n" +
+		"of the same large file for different operating systems, share a single copy of\n" +
+		"their contents. Each blob is read and checked against its SHA256 once, however\n" +
+		"many files refer to it, and its SHA256 is used as the SHA256 of the contents of\n" +
+		"files that are not compressed, encrypted, or templates. Add files to the blob\n" +
+		"store with `chezmoi add --blob`. `chezmoi edit` and `chezmoi chattr` keep\n" +
+		"edited files in the blob store. Blobs that are no longer referred to are\n" +
+		"removed by `chezmoi gc`.\n" +
+		"\n" +
+		"### `.chezmoidata/hosts`\n" +
+		"\n" +
+		"If the source directory contains a file called\n" +
+		"`.chezmoidata/hosts/`*hostname*`.yaml`, where *hostname* is either\n" +
+		"`.chezmoi.fullHostname` or `.chezmoi.hostname`, then its contents are merged\n" +
+		"into the template data. The file for the full hostname is used if it exists,\n" +
+		"otherwise the file for the short hostname is used. This gives host-specific\n" +
+		"values a place in the source state without `if`/`else` chains in your config\n" +
+		"file template. Variables in the `data` section of the config file take\n" +
+		"precedence over variables in the host data file. When using `fleet apply`, the\n" +
+		"host data file is selected by each host's name.\n" +
+		"\n" +
+		"#### `.chezmoidata/hosts` examples\n" +
+		"\n" +
+		"The file `.chezmoidata/hosts/work-laptop.yaml` in the source directory:\n" +
+		"\n" +
+		"```yaml\n" +
+		"email: me@work.example.com\n" +
+		"gitSigningKey: 0x1234567890ABCDEF\n" +
+		"```\n" +
+		"\n" +
+		"### `.chezmoiexternal.lock`\n" +
+		"\n" +
+		"chezmoi records what externals and network-derived template values resolve to\n" +
+		"in `.chezmoiexternal.lock` in the root of the source directory, so that applies\n" +
+		"are reproducible across machines and over time. This file should be committed.\n" +
+		"It records:\n" +
+		"\n" +
+		"| Entry                     | Locked to                                                     |\n" +
+		"| ------------------------- | ------------------------------------------------------------- |\n" +
+		"| `file` and `archive`      | The SHA256 of the downloaded contents                         |\n" +
+		"| `git-repo`                | The commit that was checked out                               |\n" +
+		"| `github-release`          | The release, and the URL and SHA256 of each asset used        |\n" +
+		"| `authorizedKeys` *spec*   | The keys returned                                             |\n" +
+		"\n" +
+		"Entries are added the first time that an external is downloaded or a value is\n" +
+		"fetched, and are otherwise only changed by `chezmoi externals update`, or its\n" +
+		"alias `chezmoi upgrade-externals`. Locked externals are\n" +
+		"not refreshed, so `refreshPeriod` has no effect on them, and a locked `file` or\n" +
+		"`archive` whose URL no longer returns the locked contents is an error. An entry\n" +
+		"for an external is ignored if the external's URL, or its repo for\n" +
+		"`github-release` externals, changes. `file` and `archive` externals with a\n" +
+		"`checksum` in `.chezmoiexternal.toml` and externals declared in archives are not\n" +
+		"locked.\n" +
+		"\n" +
+		"### `.chezmoiexternal.toml`\n" +
+		"\n" +
+		"If a directory in the source state contains a file called\n" +
+		"`.chezmoiexternal.toml` then chezmoi includes the files, archives, and git\n" +
+		"repositories listed in it in the target state, downloading them as needed. This\n" +
+		"keeps third-party files, for example shell plugins, out of your source\n" +
+		"directory. Each table in the file describes one external, and its name is the\n" +
+		"target path relative to the directory containing `.chezmoiexternal.toml`, which\n" +
+		"can be any directory in the source state. Names can contain slashes, and any\n" +
+		"parent directories that are not in the source state are created.\n" +
+		"`.chezmoiexternal.toml` is interpreted as a template.\n" +
+		"\n" +
+		"An `archive` external can itself contain `.chezmoiexternal.toml` files, for\n" +
+		"example a shell framework that lists the plugins it needs. If the external sets\n" +
+		"`nestedExternals = true` then these are not written to the target state.\n" +
+		"Instead, the externals that they declare are added relative to the file's\n" +
+		"directory in the archive, after any `stripComponents`. They are not interpreted\n" +
+		"as templates, and only one level of nesting is supported: `.chezmoiexternal.toml`\n" +
+		"files in archives that were themselves declared in an archive are treated as\n" +
+		"ordinary files. By default, `.chezmoiexternal.toml` files in archives are\n" +
+		"treated as ordinary files, so an archive cannot add downloads that you did not\n" +
+		"declare yourself.\n" +
+		"\n" +
+		"| Variable          | Type     | Default value | Description                                            |\n" +
+		"| ----------------- | -------- | ------------- | ------------------------------------------------------ |\n" +
+		"| `type`            | string   | *none*        | `file`, `archive`, `git-repo`, or `github-release`     |\n" +
+		"| `url`             | string   | *none*        | URL to download                                        |\n" +
+		"| `repo`            | string   | *none*        | `github-release` repository, as *owner*/*name*         |\n" +
+		"| `version`         | string   | *none*        | `github-release` version constraint, e.g. `^2.0.0`     |\n" +
+		"| `asset`           | string   | *none*        | Pattern matching the `github-release` asset name       |\n" +
+		"| `checksum`        | string   | *none*        | Expected SHA256 of the download, not for `git-repo`    |\n" +
+		"| `executable`      | bool     | `false`       | Make a `file` external executable                      |\n" +
+		"| `exact`           | bool     | `false`       | Remove files not in an `archive` external              |\n" +
+		"| `stripComponents` | int      | `0`           | Number of leading directories to strip from an archive |\n" +
+		"| `nestedExternals` | bool     | `false`       | Add externals declared in an archive's externals files |\n" +
+		"| `refreshPeriod`   | duration | `0`           | How often to re-download, `0` means never              |\n" +
+		"\n" +
+		"Downloads are cached in `$XDG_CACHE_HOME/chezmoi/external`. An external is\n" +
+		"downloaded again only if it is not in the cache, its cached copy is older than\n" +
+		"`refreshPeriod`, or its cached copy does not match `checksum`. Externals are\n" +
+		"locked in [`.chezmoiexternal.lock`](#chezmoiexternallock) when they are first\n" +
+		"downloaded.\n" +
+		"\n" +
+		"Re-downloads transfer as little as possible. If the server sent an `ETag` or\n" +
+		"`Last-Modified` header, a cached copy that is older than `refreshPeriod` is\n" +
+		"revalidated with a conditional request and is only downloaded again if it has\n" +
+		"changed on the server. If a download is interrupted, the partial download is\n" +
+		"kept in the cache and the next download resumes it with a range request, as\n" +
+		"long as the server supports range requests and the file has not changed. If\n" +
+		"the partial download turns out to be complete, it is verified and used without\n" +
+		"downloading it again.\n" +
+		"\n" +
+		"On metered connections, set `externals.maxBandwidth` to limit the download\n" +
+		"speed, in bytes per second. On small disks, set `externals.maxCacheSize` and\n" +
+		"run [`chezmoi gc`](#gc) to remove the least recently used downloads. Sizes are\n" +
+		"numbers of bytes with an optional unit, for example `500kB`, `10MB`, or `1GiB`.\n" +
+		"\n" +
+		"`archive` externals can be `.tar`, `.tar.gz`, `.tgz`, `.tar.bz2`, `.tbz2`,\n" +
+		"`.tar.xz`, `.txz`, `.tar.zst`, `.tzst`, `.zip`, `.7z`, or `.dmg` files,\n" +
+		"determined from the suffix of the URL. xz, zstd, and 7z archives are extracted\n" +
+		"with the commands set by `xz.command`, `zstd.command`, and `sevenZip.command`,\n" +
+		"which must be installed. `.dmg` disk images are only supported on macOS, where\n" +
+		"they are mounted read-only with `hdiutil` while their contents are read. `git-repo` externals are\n" +
+		"cloned with `git clone` if they do not exist, and their locked commit is checked\n" +
+		"out. Only `git-repo` externals that cannot be locked are updated with `git pull\n" +
+		"--ff-only` if they have not been fetched within `refreshPeriod`. It is an error\n" +
+		"for an external to have the same target as an entry in the source state.\n" +
+		"\n" +
+		"`github-release` externals download an asset of a release of a GitHub\n" +
+		"repository. The asset is the one whose name matches `asset`, which can contain\n" +
+		"shell-style wildcards and, since `.chezmoiexternal.toml` is a template,\n" +
+		"`.chezmoi.os` and `.chezmoi.arch`. It is treated as an `archive` if its name has\n" +
+		"an archive suffix, otherwise as a `file`. The first time that the external is\n" +
+		"downloaded, chezmoi finds the release with the highest version that satisfies\n" +
+		"`version`, or the latest release if `version` is not set, ignoring drafts and\n" +
+		"prereleases, and locks the external to it in `.chezmoiexternal.lock`. From then\n" +
+		"on the locked release is used, and its asset checksum is verified, until\n" +
+		"`chezmoi externals update` is run. `github-release` externals cannot be\n" +
+		"declared in archives.\n" +
+		"\n" +
+		"#### `.chezmoiexternal.toml` examples\n" +
+		"\n" +
+		"```toml\n" +
+		"[\".oh-my-zsh\"]\n" +
+		"    type = \"archive\"\n" +
+		"    url = \"https://github.com/ohmyzsh/ohmyzsh/archive/master.tar.gz\"\n" +
+		"    exact = true\n" +
+		"    stripComponents = 1\n" +
+		"    refreshPeriod = \"168h\"\n" +
+		"[\".vim/autoload/plug.vim\"]\n" +
+		"    type = \"file\"\n" +
+		"    url = \"https://raw.githubusercontent.com/junegunn/vim-plug/master/plug.vim\"\n" +
+		"[\".local/bin/ripgrep\"]\n" +
+		"    type = \"github-release\"\n" +
+		"    repo = \"BurntSushi/ripgrep\"\n" +
+		"    version = \"^13.0.0\"\n" +
+		"    asset = \"ripgrep-*-x86_64-unknown-{{ .chezmoi.os }}-*.tar.gz\"\n" +
+		"    stripComponents = 1\n" +
+		"```\n" +
+		"\n" +
 		"### `.chezmoiignore`\n" +
 		"\n" +
 		"If a file called `.chezmoiignore` exists in the source state then it is\n" +
@@ -1796,17 +2773,125 @@ func init() {
 		"    .personal-file\n" +
 		"    {{- end }}\n" +
 		"\n" +
+		"### `.chezmoimeta.yaml`\n" +
+		"\n" +
+		"If a directory in the source state contains a file called `.chezmoimeta.yaml`\n" +
+		"then the attributes of the entries in that directory are read from it instead\n" +
+		"of from their source names. `.chezmoimeta.yaml` maps source names to\n" +
+		"attributes. In such a directory, only the `dot_` and `literal_` prefixes are\n" +
+		"significant in source names and all other prefixes and suffixes are part of the\n" +
+		"target name. Entries that are not listed have no attributes. Subdirectories use\n" +
+		"source name attributes unless they have their own `.chezmoimeta.yaml`.\n" +
+		"\n" +
+		"| Key          | Type    | Allowed for           | Effect                                             |\n" +
+		"| ------------ | ------- | --------------------- | -------------------------------------------------- |\n" +
+		"| `type`       | string  | files                 | `file` (default), `script`, or `symlink`           |\n" +
+		"| `compressed` | bool    | regular files         | As the `compressed_` prefix                        |\n" +
+		"| `empty`      | bool    | regular files         | As the `empty_` prefix                             |\n" +
+		"| `encrypted`  | bool    | regular files         | As the `encrypted_` prefix                         |\n" +
+		"| `exact`      | bool    | directories           | As the `exact_` prefix                             |\n" +
+		"| `executable` | bool    | regular files         | As the `executable_` prefix                        |\n" +
+		"| `once`       | bool    | scripts               | As the `once_` prefix                              |\n" +
+		"| `onchange`   | bool    | scripts               | As the `onchange_` prefix                          |\n" +
+		"| `before`     | bool    | scripts               | As the `before_` prefix                            |\n" +
+		"| `after`      | bool    | scripts               | As the `after_` prefix                             |\n" +
+		"| `private`    | bool    | directories and files | As the `private_` prefix                           |\n" +
+		"| `template`   | bool    | files                 | As the `.tmpl` suffix                              |\n" +
+		"| `recursive`  | bool    | templates             | Execute the template's output as a template        |\n" +
+		"| `owner`      | string  | directories and files | Owner of the target                                |\n" +
+		"| `group`      | string  | directories and files | Group of the target                                |\n" +
+		"| `xattrs`     | object  | directories and files | Extended attributes of the target                  |\n" +
+		"\n" +
+		"`owner`, `group`, and `xattrs` are recorded in the target state and shown by\n" +
+		"`chezmoi dump` and `chezmoi explain`, but cannot be applied yet, so\n" +
+		"`chezmoi apply` fails if they are set on a target that it would apply.\n" +
+		"\n" +
+		"`recursive` requires `template`. The output of a recursive template is executed\n" +
+		"as a template again until it no longer changes, which is useful when template\n" +
+		"data itself contains templates. It is an error if the output is still changing\n" +
+		"after 10 passes. As every pass is executed, a recursive template cannot produce\n" +
+		"a literal `{{`.\n" +
+		"\n" +
+		"`chezmoi add` and `chezmoi import` update `.chezmoimeta.yaml` when adding\n" +
+		"entries to a directory that has one, so comments in it are not preserved.\n" +
+		"`chezmoi chattr`, `chezmoi cp`, and `chezmoi mv` do not support entries in\n" +
+		"such directories; edit `.chezmoimeta.yaml` instead.\n" +
+		"\n" +
+		"#### `.chezmoimeta.yaml` examples\n" +
+		"\n" +
+		"    dot_bashrc.d:\n" +
+		"      exact: true\n" +
+		"    dot_netrc:\n" +
+		"      encrypted: true\n" +
+		"      private: true\n" +
+		"      template: true\n" +
+		"    install-packages.sh:\n" +
+		"      type: script\n" +
+		"      once: true\n" +
+		"    dot_gitconfig:\n" +
+		"      template: true\n" +
+		"      recursive: true\n" +
+		"\n" +
 		"### `.chezmoiremove`\n" +
 		"\n" +
 		"If a file called `.chezmoiremove` exists in the source state then it is\n" +
-		"interpreted as a list of targets to remove. `.chezmoiremove` is interpreted as a\n" +
-		"template.\n" +
+		"interpreted as a list of targets to remove when `chezmoi apply` is run with\n" +
+		"`--remove`. Patterns are matched in the same way as in\n" +
+		"[`.chezmoiignore`](#chezmoiignore), so they can contain globs, patterns prefixed\n" +
+		"with `!` are excluded, and comments are introduced with the `#` character.\n" +
+		"\n" +
+		"`.chezmoiremove` is interpreted as a template. This allows obsolete files to be\n" +
+		"removed declaratively, and different files to be removed on different machines.\n" +
+		"\n" +
+		"`.chezmoiremove` files in subdirectories apply only to that subdirectory.\n" +
+		"Targets that are ignored by `.chezmoiignore` or that are in the target state are\n" +
+		"never removed.\n" +
+		"\n" +
+		"#### `.chezmoiremove` examples\n" +
+		"\n" +
+		"    .oldrc\n" +
+		"    .config/oldtool/**\n" +
+		"\n" +
+		"    {{- if ne .chezmoi.os \"darwin\" }}\n" +
+		"    .hammerspoon\n" +
+		"    {{- end }}\n" +
+		"\n" +
+		"### `.chezmoisshconfig`\n" +
+		"\n" +
+		"If a directory called `.chezmoisshconfig` exists at the root of the source\n" +
+		"state, then the files in it are treated as fragments of `~/.ssh/config`. The\n" +
+		"fragments are interpreted as templates and concatenated in alphabetical order of\n" +
+		"their names, so prefixing them with numbers (e.g. `10-defaults`, `20-work`)\n" +
+		"controls their order. Fragments whose result is empty are skipped, allowing\n" +
+		"hosts to be included conditionally based on template data.\n" +
+		"\n" +
+		"The assembled `~/.ssh/config` always has permissions `0600` and `~/.ssh` is\n" +
+		"always made private. Each line of each fragment is verified to be a comment, a\n" +
+		"blank line, or an `ssh_config` keyword followed by its arguments, and chezmoi\n" +
+		"will refuse to write `~/.ssh/config` if verification fails. It is an error to\n" +
+		"also manage `~/.ssh/config` as a regular file in the source state.\n" +
+		"\n" +
+		"#### `.chezmoisshconfig` examples\n" +
+		"\n" +
+		"    .chezmoisshconfig/10-work\n" +
+		"    {{- if eq .chezmoi.hostname \"work-laptop\" }}\n" +
+		"    Host *.corp.example.com\n" +
+		"        User jsmith\n" +
+		"        ProxyJump bastion.corp.example.com\n" +
+		"    {{- end }}\n" +
+		"\n" +
+		"    .chezmoisshconfig/99-defaults\n" +
+		"    Host *\n" +
+		"        ServerAliveInterval 60\n" +
 		"\n" +
 		"### `.chezmoitemplates`\n" +
 		"\n" +
 		"If a directory called `.chezmoitemplates` exists, then all files in this\n" +
 		"directory are parsed as templates are available as templates with a name equal\n" +
-		"to the relative path of the file.\n" +
+		"to the relative path of the file. Templates in `.chezmoitemplates` can use all\n" +
+		"template functions. Use the `template` action to include a template in place,\n" +
+		"or the [`includeTemplate`](#includetemplate-name-data) function to use its\n" +
+		"output in a pipeline, for example to indent it.\n" +
 		"\n" +
 		"#### `.chezmoitemplates` examples\n" +
 		"\n" +
@@ -1834,13 +2919,44 @@ func init() {
 		"\n" +
 		"    1.5.0\n" +
 		"\n" +
+		"### `secrets.sops.yaml`\n" +
+		"\n" +
+		"If the source directory contains a file called `secrets.sops.yaml` then chezmoi\n" +
+		"decrypts it with [SOPS](https://github.com/mozilla/sops), by running `sops\n" +
+		"--decrypt`, and merges its contents into the template data under the `sops`\n" +
+		"key. SOPS finds the keys using the metadata in the file, so any of its\n" +
+		"backends, for example age, PGP, or a cloud KMS, can be used. This keeps\n" +
+		"structured secret data in the source state without a secret manager call for\n" +
+		"every value. `secrets.sops.yaml` is not part of the target state, and\n" +
+		"`sops.command` sets the SOPS command.\n" +
+		"\n" +
+		"#### `secrets.sops.yaml` examples\n" +
+		"\n" +
+		"Create the file with:\n" +
+		"\n" +
+		"    sops --age <recipient> ~/.local/share/chezmoi/secrets.sops.yaml\n" +
+		"\n" +
+		"and refer to its values in templates:\n" +
+		"\n" +
+		"    [github]\n" +
+		"        token = {{ .sops.github.token | quote }}\n" +
+		"\n" +
 		"## Commands\n" +
 		"\n" +
 		"### `add` *targets*\n" +
 		"\n" +
 		"Add *targets* to the source state. If any target is already in the source state,\n" +
 		"then its source state is replaced with its current state in the destination\n" +
-		"directory. The `add` command accepts additional flags:\n" +
+		"directory.\n" +
+		"\n" +
+		"Unless `--encrypt` is given, files are scanned for likely secrets, such as\n" +
+		"private keys, well-known kinds of API token, and high-entropy strings, before\n" +
+		"they are added. If any are found then chezmoi prints a warning and, if stdin is a\n" +
+		"terminal, prompts you to add the file anyway, skip it, or encrypt it. Consider\n" +
+		"encrypting such files or replacing the secrets with template functions that read\n" +
+		"them from a password manager.\n" +
+		"\n" +
+		"The `add` command accepts additional flags:\n" +
 		"\n" +
 		"#### `--autotemplate`\n" +
 		"\n" +
@@ -1848,6 +2964,15 @@ func init() {
 		"the `data` section of the config file. Longer subsitutions occur before shorter\n" +
 		"ones. This implies the `--template` option.\n" +
 		"\n" +
+		"#### `--blob`\n" +
+		"\n" +
+		"Store the contents of added files in the blob store in `.chezmoiblobs`, and\n" +
+		"make their source files pointers to it.\n" +
+		"\n" +
+		"#### `--compress`\n" +
+		"\n" +
+		"Compress added files with zstd and set the `compressed` attribute on them.\n" +
+		"\n" +
 		"#### `-e`, `--empty`\n" +
 		"\n" +
 		"Set the `empty` attribute on added files.\n" +
@@ -1868,6 +2993,15 @@ func init() {
 		"\n" +
 		"Recursively add all files, directories, and symlinks.\n" +
 		"\n" +
+		"#### `--rename`\n" +
+		"\n" +
+		"If an added file has the same contents as a file in the source state whose\n" +
+		"target no longer exists, record it as a rename without prompting. Without\n" +
+		"`--rename`, `add` prompts before recording a rename if stdin is a terminal, and\n" +
+		"adds the file as a new file otherwise. Renames use `git mv` if the source\n" +
+		"directory is a git repository, so the file's history is kept. Compressed,\n" +
+		"encrypted, template, and empty files are never considered renames.\n" +
+		"\n" +
 		"#### `-T`, `--template`\n" +
 		"\n" +
 		"Set the `template` attribute on added files and symlinks.\n" +
@@ -1879,16 +3013,122 @@ func init() {
 		"    chezmoi add ~/.vim --recursive\n" +
 		"    chezmoi add ~/.oh-my-zsh --exact --recursive\n" +
 		"\n" +
+		"### `affected`\n" +
+		"\n" +
+		"List the targets whose target state depends on the given templates or template\n" +
+		"data, for example after editing a shared template in `.chezmoitemplates`. A\n" +
+		"target depends on a template if its source is a template that uses it, either\n" +
+		"directly or through other templates, with the `template` action or with\n" +
+		"`includeTemplate` and a literal name. A target depends on a data key if the\n" +
+		"template uses the key, a value inside the key, or a value containing the key.\n" +
+		"\n" +
+		"#### `--data` *key*\n" +
+		"\n" +
+		"List targets that use the template data *key*, for example `email` or\n" +
+		"`chezmoi.hostname`. This option may be specified multiple times.\n" +
+		"\n" +
+		"#### `--template` *template*\n" +
+		"\n" +
+		"List targets that use *template*, which may be the name of the template or the\n" +
+		"path of its file in the `.chezmoitemplates` directory. This option may be\n" +
+		"specified multiple times.\n" +
+		"\n" +
+		"#### `affected` examples\n" +
+		"\n" +
+		"    chezmoi affected --template gitconfig\n" +
+		"    chezmoi affected --data email\n" +
+		"    chezmoi apply $(chezmoi affected --template ~/.local/share/chezmoi/.chezmoitemplates/gitconfig)\n" +
+		"\n" +
 		"### `apply` [*targets*]\n" +
 		"\n" +
 		"Ensure that *targets* are in the target state, updating them if necessary. If no\n" +
 		"targets are specified, the state of all targets are ensured.\n" +
 		"\n" +
+		"If chezmoi is interrupted, for example by pressing Ctrl-C, then it finishes\n" +
+		"updating the current target, kills any running script, and stops. It then\n" +
+		"prints the targets that were applied and the targets that were not applied. A\n" +
+		"second interrupt exits immediately.\n" +
+		"\n" +
+		"On macOS and BSDs, chezmoi preserves file flags set with `chflags` when it\n" +
+		"updates a file, and does not update files that are immutable or append-only.\n" +
+		"\n" +
+		"If a directory in the target state is a symlink to a directory in the\n" +
+		"destination directory, then chezmoi updates the directory that the symlink\n" +
+		"points to rather than replacing the symlink. *targets* may be given through\n" +
+		"symlinks to the destination directory or its ancestors, for example\n" +
+		"`/usr/home/user/.bashrc` when `/home` is a symlink to `/usr/home`.\n" +
+		"\n" +
+		"When it finishes, `apply` prints a one line summary of the number of targets\n" +
+		"written, removed, and unchanged, the number of scripts run, and the number of\n" +
+		"errors. With `--dry-run`, the summary counts the changes that would be made.\n" +
+		"\n" +
+		"If a file has been modified since chezmoi last wrote it, then chezmoi prompts\n" +
+		"before overwriting it. Files that chezmoi has never written are overwritten\n" +
+		"without prompting, as are all files if there is no input to answer the prompt,\n" +
+		"for example when chezmoi is run from a script.\n" +
+		"\n" +
+		"If the destination directory is an `ssh://[user@]host[:port][/path]` URL, then\n" +
+		"chezmoi applies the target state to *path* on the remote host, which does not\n" +
+		"need chezmoi installed, only `ssh` access and a POSIX shell. *path* defaults to\n" +
+		"the remote user's home directory and may start with `~`. chezmoi renders the\n" +
+		"target state locally, with `.chezmoi.os`, `.chezmoi.arch`, `.chezmoi.hostname`,\n" +
+		"`.chezmoi.fullHostname`, `.chezmoi.username`, `.chezmoi.homedir`, and\n" +
+		"`.chezmoi.osRelease` describing the remote host, and reads and updates the\n" +
+		"destination directory by running commands on the remote host. Scripts run on\n" +
+		"the remote host, and the state of `run_once_` and `run_onchange_` scripts is\n" +
+		"recorded separately for each remote host. Facts that describe only the local\n" +
+		"machine, like `.chezmoi.machineID` and `.chezmoi.kernel`, are not set. The\n" +
+		"`ssh` command and any extra arguments are set with the `ssh.command` and\n" +
+		"`ssh.args` configuration variables, and a single connection is shared by all the\n" +
+		"commands that chezmoi runs. The source directory, including\n" +
+		"`.chezmoiexternal.lock`, is always read and written locally. Remote\n" +
+		"destinations cannot be used with `--remove` or `--user`, and `git-repo`\n" +
+		"externals cannot be applied to them. chezmoi does not record the state of the\n" +
+		"files that it writes on the remote host, so it does not prompt before\n" +
+		"overwriting files there that have been modified.\n" +
+		"\n" +
+		"Similarly, if the destination directory is a `docker://[user@]container[/path]`\n" +
+		"or `podman://[user@]container[/path]` URL, then chezmoi applies the target state\n" +
+		"to *path* in the running container *container* by running commands in it with\n" +
+		"`docker exec` or `podman exec`, optionally as *user*. The container needs only a\n" +
+		"POSIX shell. The state of scripts is recorded separately for each container, and\n" +
+		"the commands and any extra arguments, which are given before `exec`, are set\n" +
+		"with the `docker.command`, `docker.args`, `podman.command`, and `podman.args`\n" +
+		"configuration variables.\n" +
+		"\n" +
+		"#### `-f`, `--force`\n" +
+		"\n" +
+		"Overwrite modified files without prompting.\n" +
+		"\n" +
+		"#### `-i`, `--interactive`\n" +
+		"\n" +
+		"Prompt before applying each target that would change. Answer `y` to apply the\n" +
+		"target, `n` to skip it, `a` to apply it and all remaining targets, `q` to stop,\n" +
+		"or `d` to show the diff before answering. Directories are created without\n" +
+		"prompting, but their contents are prompted for. The contents of an external\n" +
+		"archive are prompted for as a whole. Confirming a target also confirms\n" +
+		"overwriting it if it has been modified since chezmoi last wrote it.\n" +
+		"\n" +
+		"#### `--report` *filename*\n" +
+		"\n" +
+		"Also write a JSON report to *filename*, for example for collection by fleet\n" +
+		"management tools. The report contains the time, the hostname, whether\n" +
+		"`--dry-run` was given, and lists of the written, removed, and unchanged targets,\n" +
+		"the scripts run, and the errors. If applying fails with an error, other than\n" +
+		"being interrupted, then the list of unchanged targets is empty, as chezmoi\n" +
+		"does not know which targets were reached.\n" +
+		"\n" +
 		"#### `apply` examples\n" +
 		"\n" +
 		"    chezmoi apply\n" +
 		"    chezmoi apply --dry-run --verbose\n" +
 		"    chezmoi apply ~/.bashrc\n" +
+		"    chezmoi apply --interactive\n" +
+		"    chezmoi apply --report /var/tmp/chezmoi-report.json\n" +
+		"    chezmoi apply --destination ssh://user@host.example.com\n" +
+		"    chezmoi apply --destination ssh://user@host.example.com:2222/~/dotfiles --dry-run --verbose\n" +
+		"    chezmoi apply --destination docker://debug\n" +
+		"    chezmoi apply --destination podman://root@debug/root\n" +
 		"\n" +
 		"### `archive`\n" +
 		"\n" +
@@ -1899,6 +3139,68 @@ func init() {
 		"\n" +
 		"    chezmoi archive | tar tvf -\n" +
 		"\n" +
+		"### `audit` *subcommand*\n" +
+		"\n" +
+		"Audit the source state. *subcommand* is `externals` or `secrets`. Both fail if\n" +
+		"any problems are found, so they can be used to check shared dotfile repos in\n" +
+		"CI.\n" +
+		"\n" +
+		"`audit externals` lists every external with its type, the size of its\n" +
+		"downloaded contents, its checksum status, when it was last refreshed, any\n" +
+		"problems, its target, and its URL. Files and archives are checked against\n" +
+		"chezmoi's download cache and are never downloaded, so the size and last\n" +
+		"refreshed time are only known for externals that have been applied. The\n" +
+		"checksum status is one of:\n" +
+		"\n" +
+		"| Status        | Meaning                                                     |\n" +
+		"| ------------- | ----------------------------------------------------------- |\n" +
+		"| `ok`          | The downloaded contents match the checksum                  |\n" +
+		"| `mismatch`    | The downloaded contents do not match the checksum           |\n" +
+		"| `missing`     | The external does not have a checksum                       |\n" +
+		"| `unverified`  | The external has a checksum but has not been downloaded yet |\n" +
+		"| `unsupported` | The external is a `git-repo`, which cannot have a checksum  |\n" +
+		"\n" +
+		"Externals are flagged for using `http` instead of `https`, for unpinned URLs,\n" +
+		"which refer to a branch or release that changes over time such as `master`,\n" +
+		"`main`, `HEAD`, or `latest`, for missing or mismatched checksums, and for being\n" +
+		"larger than `--max-size`. `git-repo` externals that are not locked to a commit\n" +
+		"follow the repository's default branch, so they are flagged as unpinned.\n" +
+		"\n" +
+		"`audit secrets` scans the source state for likely secrets, such as private\n" +
+		"keys, access tokens, and high-entropy strings, that are not encrypted. Every\n" +
+		"file in the source directory is scanned as it is stored, except for encrypted\n" +
+		"files, compressed files, and `secrets.sops.yaml`. If `--rendered` is given,\n" +
+		"templates and compressed files are also scanned after they are rendered, so\n" +
+		"secrets that templates read from the config file data are found too. Templates\n" +
+		"that cannot be rendered are reported as warnings.\n" +
+		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Print the results in *format*, which can be `text` (the default) or `json`. With\n" +
+		"`json`, `audit externals` prints an array of objects with the keys\n" +
+		"`targetPath`, `type`, `url`, `size`, `checksum`, `lastRefreshed`, and\n" +
+		"`problems`, and `audit secrets` prints an array of objects with the keys\n" +
+		"`sourcePath`, `kind` (`raw` or `rendered`), `line`, and `description`.\n" +
+		"\n" +
+		"#### `--max-size` *bytes*\n" +
+		"\n" +
+		"`audit externals` only. Flag downloaded externals that are larger than *bytes*.\n" +
+		"The default is `0`, meaning no limit.\n" +
+		"\n" +
+		"#### `--rendered` *bool*\n" +
+		"\n" +
+		"`audit secrets` only. Scan templates and compressed files after rendering them.\n" +
+		"The default is `false`, as templates that read secrets from a password manager\n" +
+		"are expected to contain secrets once they are rendered.\n" +
+		"\n" +
+		"#### `audit` examples\n" +
+		"\n" +
+		"    chezmoi audit externals\n" +
+		"    chezmoi audit externals --max-size=10000000 --format=json\n" +
+		"    chezmoi audit secrets\n" +
+		"    chezmoi audit secrets --rendered\n" +
+		"    chezmoi audit secrets --format=json\n" +
+		"\n" +
 		"### `cat` targets\n" +
 		"\n" +
 		"Write the target state of *targets*  to stdout. *targets* must be files or\n" +
@@ -1929,6 +3231,7 @@ func init() {
 		"\n" +
 		"| Attribute    | Abbreviation |\n" +
 		"| ------------ | ------------ |\n" +
+		"| `compress`   | *none*       |\n" +
 		"| `empty`      | `e`          |\n" +
 		"| `encrypted`  | *none*       |\n" +
 		"| `exact`      | *none*       |\n" +
@@ -1939,11 +3242,19 @@ func init() {
 		"Multiple attributes modifications may be specified by separating them with a\n" +
 		"comma (`,`).\n" +
 		"\n" +
+		"#### `-r`, `--recursive`\n" +
+		"\n" +
+		"Recursively change the attributes of all entries in any target directories.\n" +
+		"Combine with `--dry-run` and `--verbose` to preview all the changes that will\n" +
+		"be made to the source state without making them.\n" +
+		"\n" +
 		"#### `chattr` examples\n" +
 		"\n" +
 		"    chezmoi chattr template ~/.bashrc\n" +
 		"    chezmoi chattr noempty ~/.profile\n" +
 		"    chezmoi chattr private,template ~/.netrc\n" +
+		"    chezmoi chattr --recursive --dry-run --verbose private ~/.ssh\n" +
+		"    chezmoi chattr --recursive exact ~/.config/nvim\n" +
 		"\n" +
 		"### `completion` *shell*\n" +
 		"\n" +
@@ -1959,6 +3270,44 @@ func init() {
 		"    chezmoi completion bash\n" +
 		"    chezmoi completion fish --output ~/.config/fish/completions/chezmoi.fish\n" +
 		"\n" +
+		"### `config` validate\n" +
+		"\n" +
+		"Check the config file and the config file template without changing anything.\n" +
+		"Every key in the config file is checked against the configuration variables\n" +
+		"that chezmoi understands, and unknown keys are reported along with the most\n" +
+		"similar known key, if there is one. The config file is then decoded strictly,\n" +
+		"so values whose type does not match the variable's type, for example `\"true\"`\n" +
+		"for a boolean, and invalid template data keys are also reported. If the source\n" +
+		"directory contains a config file template then it is rendered, with\n" +
+		"`promptString` returning its prompt, `promptBool` returning false, and\n" +
+		"`promptInt` returning zero, and the result is checked in the same way.\n" +
+		"`config validate` fails if any problems are found.\n" +
+		"\n" +
+		"chezmoi also prints a warning for every unknown key in the config file whenever\n" +
+		"it reads the config file.\n" +
+		"\n" +
+		"#### `config` examples\n" +
+		"\n" +
+		"    chezmoi config validate\n" +
+		"\n" +
+		"### `cp` *source* *target*\n" +
+		"\n" +
+		"Copy *source* to *target* in the source state. The new source state entry has\n" +
+		"the same contents and attributes as *source*, for example `private_` and\n" +
+		"`executable_`, and the parent directory of *target* must either be the\n" +
+		"destination directory or be managed by chezmoi. The destination directory is\n" +
+		"not changed; run `chezmoi apply` to create *target*. This is useful for\n" +
+		"splitting a single configuration file into per-host variants.\n" +
+		"\n" +
+		"#### `-T`, `--template`\n" +
+		"\n" +
+		"Make the copy of *source* a template. *source* must be a file or a symlink.\n" +
+		"\n" +
+		"#### `cp` examples\n" +
+		"\n" +
+		"    chezmoi cp ~/.bashrc ~/.bashrc.local\n" +
+		"    chezmoi cp --template ~/.gitconfig ~/.config/git/config\n" +
+		"\n" +
 		"### `data`\n" +
 		"\n" +
 		"Write the computed template data in JSON format to stdout. The `data` command\n" +
@@ -1998,15 +3347,30 @@ func init() {
 		"version 2.0.0 of chezmoi, `git` format diffs will become the default and include\n" +
 		"scripts and the `chezmoi` format will be removed.\n" +
 		"\n" +
+		"#### `--from-ref` *ref*\n" +
+		"\n" +
+		"Print the git format diff between the target states rendered from two\n" +
+		"revisions of the source state, using the current template data, instead of the\n" +
+		"diff between the target state and the destination state. *ref* is the git\n" +
+		"revision to diff from, and defaults to `HEAD` if only `--to-ref` is given.\n" +
+		"Targets outside the destination directory are not included.\n" +
+		"\n" +
 		"#### `--no-pager`\n" +
 		"\n" +
 		"Do not use the pager.\n" +
 		"\n" +
+		"#### `--to-ref` *ref*\n" +
+		"\n" +
+		"Set the git revision of the source state to diff to when printing the diff\n" +
+		"between revisions of the source state. The default is the working tree.\n" +
+		"\n" +
 		"#### `diff` examples\n" +
 		"\n" +
 		"    chezmoi diff\n" +
 		"    chezmoi diff ~/.bashrc\n" +
 		"    chezmoi diff --format=git\n" +
+		"    chezmoi diff --from-ref origin/master\n" +
+		"    chezmoi diff --from-ref v1 --to-ref v2 ~/.gitconfig\n" +
 		"\n" +
 		"### `docs` [*regexp*]\n" +
 		"\n" +
@@ -2045,8 +3409,15 @@ func init() {
 		"### `edit` [*targets*]\n" +
 		"\n" +
 		"Edit the source state of *targets*, which must be files or symlinks. If no\n" +
-		"targets are given the the source directory itself is opened with `$EDITOR`. The\n" +
-		"`edit` command accepts additional arguments:\n" +
+		"targets are given the the source directory itself is opened with `$EDITOR`.\n" +
+		"\n" +
+		"Encrypted targets are decrypted into a private temporary directory which is\n" +
+		"removed when the editor exits or chezmoi is interrupted. This directory is\n" +
+		"created in `secret.tempDir` if set, otherwise in `$XDG_RUNTIME_DIR` if it\n" +
+		"exists, otherwise in the system temporary directory. The same directory is used\n" +
+		"for the target states written by `merge` and `diff --from-ref`.\n" +
+		"\n" +
+		"The `edit` command accepts additional arguments:\n" +
 		"\n" +
 		"#### `-a`, `--apply`\n" +
 		"\n" +
@@ -2086,6 +3457,20 @@ func init() {
 		"\n" +
 		"Include simulated functions only available during `chezmoi init`.\n" +
 		"\n" +
+		"#### `--promptBool` *pairs*\n" +
+		"\n" +
+		"Simulate the `promptBool` function with a function that returns values from\n" +
+		"*pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+		"`promptBool` is called with a *prompt* that does not match any of *pairs*, then\n" +
+		"it returns false.\n" +
+		"\n" +
+		"#### `--promptInt` *pairs*\n" +
+		"\n" +
+		"Simulate the `promptInt` function with a function that returns values from\n" +
+		"*pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+		"`promptInt` is called with a *prompt* that does not match any of *pairs*, then\n" +
+		"it returns zero.\n" +
+		"\n" +
 		"#### `--promptString`, `-p` *pairs*\n" +
 		"\n" +
 		"Simulate the `promptString` function with a function that returns values from\n" +
@@ -2099,6 +3484,112 @@ func init() {
 		"    chezmoi execute-template '{{ .chezmoi.os }}' / '{{ .chezmoi.arch }}'\n" +
 		"    echo '{{ .chezmoi | toJson }}' | chezmoi execute-template\n" +
 		"    chezmoi execute-template --init --promptString email=john@home.org < ~/.local/share/chezmoi/.chezmoi.toml.tmpl\n" +
+		"    chezmoi execute-template --init --promptBool personal=true --promptInt width=80 < ~/.local/share/chezmoi/.chezmoi.toml.tmpl\n" +
+		"\n" +
+		"### `explain` *target*\n" +
+		"\n" +
+		"Print how the target state of *target* is derived: its source path, the\n" +
+		"attributes parsed from its source name, whether it is encrypted, the templates\n" +
+		"it includes and the template data it references (if it is a template), whether\n" +
+		"it is ignored and which ignore patterns match it, and its final permissions and\n" +
+		"the SHA256 hash of its contents.\n" +
+		"\n" +
+		"#### `explain` examples\n" +
+		"\n" +
+		"    chezmoi explain ~/.bashrc\n" +
+		"\n" +
+		"### `fleet` apply\n" +
+		"\n" +
+		"Apply the same source state to multiple remote hosts. `fleet apply` reads the\n" +
+		"hosts listed in the file given by `--hosts`, connects to each host with `ssh`,\n" +
+		"renders the target state of each host locally with that host's template data,\n" +
+		"and then applies it to each host's destination directory by running commands on\n" +
+		"the host, in the same way as [`apply`](#apply-targets) does for `ssh://`\n" +
+		"destinations. The `ssh` command can be set with the `fleet.sshCommand`\n" +
+		"configuration variable. Every host's target state is rendered before any host is\n" +
+		"changed, so a template error for any host stops `fleet apply` before anything is\n" +
+		"written.\n" +
+		"\n" +
+		"The hosts file is a YAML file with a list of hosts, for example:\n" +
+		"\n" +
+		"```yaml\n" +
+		"hosts:\n" +
+		"- name: web1.example.com\n" +
+		"  address: admin@web1.example.com\n" +
+		"  data:\n" +
+		"    role: web\n" +
+		"- name: db1.example.com\n" +
+		"  destDir: /home/postgres\n" +
+		"  data:\n" +
+		"    role: db\n" +
+		"```\n" +
+		"\n" +
+		"Each host has a `name`, an optional `address` to pass to `ssh` (the default is\n" +
+		"the name), an optional `destDir` on the remote host (the default is the remote\n" +
+		"user's home directory, and it may start with `~`), and optional template\n" +
+		"`data`, which is merged over the `data` section of your config file.\n" +
+		"`.chezmoi.hostname` and `.chezmoi.fullHostname` are set from the host's name,\n" +
+		"the other `.chezmoi` variables describe the remote host as for `ssh://`\n" +
+		"destinations, and any of them can be overridden with a `chezmoi` key in the\n" +
+		"host's `data`.\n" +
+		"\n" +
+		"Files are removed and `exact_` directories are made exact as with `apply`.\n" +
+		"Scripts run on the remote host with no standard input, their output is written\n" +
+		"to the host's log, and the state of `run_once_` and `run_onchange_` scripts is\n" +
+		"recorded separately for each host's address. `git-repo` externals cannot be\n" +
+		"applied to fleet hosts. `fleet apply` does not prompt before overwriting files\n" +
+		"on the remote hosts that have been modified.\n" +
+		"\n" +
+		"After each host is applied, its output and the number of targets written and\n" +
+		"removed and scripts run are printed, followed by a summary of how many hosts\n" +
+		"succeeded and failed. With `--dry-run`, the counts are of the changes that would\n" +
+		"be made. With `--verbose`, the output includes the changes made to each host.\n" +
+		"`fleet apply` fails if any host failed.\n" +
+		"\n" +
+		"#### `--hosts` *filename*\n" +
+		"\n" +
+		"Read the list of hosts from *filename*. This flag is required.\n" +
+		"\n" +
+		"#### `--log-dir` *directory*\n" +
+		"\n" +
+		"Write the output of each host to *directory*`/`*name*`.log` instead of printing\n" +
+		"it.\n" +
+		"\n" +
+		"#### `--parallel` *n*\n" +
+		"\n" +
+		"Apply to at most *n* hosts concurrently. The default is 4.\n" +
+		"\n" +
+		"#### `--report` *filename*\n" +
+		"\n" +
+		"Also write a JSON report to *filename* listing, for each host, the targets\n" +
+		"written and removed, the scripts run, and any error.\n" +
+		"\n" +
+		"#### `fleet` examples\n" +
+		"\n" +
+		"    chezmoi fleet apply --hosts hosts.yaml\n" +
+		"    chezmoi fleet apply --hosts hosts.yaml --dry-run --verbose\n" +
+		"    chezmoi fleet apply --hosts hosts.yaml --parallel 16 --log-dir logs --report report.json\n" +
+		"\n" +
+		"### `externals` update [*targets*]\n" +
+		"\n" +
+		"Update the entries for *targets* in [`.chezmoiexternal.lock`](#chezmoiexternallock)\n" +
+		"to what they currently resolve to. If no *targets* are specified then all\n" +
+		"externals and all locked template values are updated. `file` and `archive`\n" +
+		"externals are downloaded again, `git-repo` externals are locked to the commit\n" +
+		"of the remote repository's `HEAD`, `github-release` externals are upgraded\n" +
+		"to the release with the highest version that satisfies their `version`\n" +
+		"constraint, and locked `authorizedKeys` are fetched again. Each entry that\n" +
+		"changes is reported on its own line. The target state is not changed until\n" +
+		"`chezmoi apply` is run.\n" +
+		"\n" +
+		"The GitHub API is called with the token in the `gitHub.token` configuration\n" +
+		"variable or the `GITHUB_TOKEN` environment variable, if set.\n" +
+		"\n" +
+		"#### `externals` examples\n" +
+		"\n" +
+		"    chezmoi externals update\n" +
+		"    chezmoi externals update ~/.oh-my-zsh\n" +
+		"    chezmoi externals update && chezmoi diff\n" +
 		"\n" +
 		"### `forget` *targets*\n" +
 		"\n" +
@@ -2108,16 +3599,41 @@ func init() {
 		"\n" +
 		"    chezmoi forget ~/.bashrc\n" +
 		"\n" +
+		"### `gc`\n" +
+		"\n" +
+		"Remove stale entries from chezmoi's caches and state that is no longer needed,\n" +
+		"and print the size of everything removed. Currently this removes cached\n" +
+		"`authorizedKeys` keys that are older than `authorizedKeys.refreshPeriod`, the\n" +
+		"least recently used downloads in the externals cache until the cache is no\n" +
+		"larger than `externals.maxCacheSize`, blobs in the blob store that no source\n" +
+		"file refers to, and the state of `run_once_` and `run_onchange_` scripts that\n" +
+		"are no longer in the source state. Combine with\n" +
+		"`--dry-run` to print what would be removed without removing it.\n" +
+		"\n" +
+		"`gc` does not remove source directories that `chezmoi init` moved aside, which\n" +
+		"may contain local changes, or targets in the trash. Templates are executed\n" +
+		"every time they are needed, so there is no template cache to remove.\n" +
+		"\n" +
+		"#### `gc` examples\n" +
+		"\n" +
+		"    chezmoi gc\n" +
+		"    chezmoi gc --dry-run\n" +
+		"\n" +
 		"### `git` [*arguments*]\n" +
 		"\n" +
 		"Run `git` *arguments* in the source directory. Note that flags in *arguments*\n" +
-		"must occur after `--` to prevent chezmoi from interpreting them.\n" +
+		"must occur after `--` to prevent chezmoi from interpreting them. *arguments* are\n" +
+		"passed to `git` unchanged, without being interpreted by a shell, and `git` is\n" +
+		"run with the same [environment](#child-process-environment) as other commands\n" +
+		"that chezmoi runs. chezmoi exits with `git`'s exit code, so `chezmoi git` can be\n" +
+		"used in conditionals.\n" +
 		"\n" +
 		"#### `git` examples\n" +
 		"\n" +
 		"    chezmoi git add .\n" +
 		"    chezmoi git add dot_gitconfig\n" +
 		"    chezmoi git -- commit -m \"Add .gitconfig\"\n" +
+		"    chezmoi git -- diff --quiet || echo \"uncommitted changes\"\n" +
 		"\n" +
 		"### `help` *command*\n" +
 		"\n" +
@@ -2132,7 +3648,7 @@ func init() {
 		"\n" +
 		"    chezmoi hg -- pull --rebase --update\n" +
 		"\n" +
-		"### `init` [*repo*]\n" +
+		"### `init` [*repo* | `--template` *repo* | `--wizard`]\n" +
 		"\n" +
 		"Setup the source directory and update the destination directory to match the\n" +
 		"target state. If *repo* is given then it is checked out into the source\n" +
@@ -2142,10 +3658,69 @@ func init() {
 		"file is created using that file as a template. Finally, if the `--apply` flag is\n" +
 		"passed, `chezmoi apply` is run.\n" +
 		"\n" +
+		"If *repo* is given and the source directory already exists and is not empty,\n" +
+		"then chezmoi prompts before moving it aside to *source-dir*`.`*timestamp*, so\n" +
+		"that any local changes or unpushed commits in it are kept, and cloning *repo* in\n" +
+		"its place. *repo* may be abbreviated as\n" +
+		"`gh:user/repo`, which is expanded to `https://github.com/user/repo.git`.\n" +
+		"\n" +
+		"#### `-f`, `--force`\n" +
+		"\n" +
+		"Move an existing source directory aside without prompting.\n" +
+		"\n" +
+		"#### `--one-shot`\n" +
+		"\n" +
+		"Clone *repo* into a private temporary directory, create the config file there,\n" +
+		"run `chezmoi apply` without prompting, and then remove the temporary directory,\n" +
+		"including the source directory, the config file, and the persistent state. Only\n" +
+		"the applied targets remain. This is useful for ephemeral machines like\n" +
+		"containers and CI runners. Any existing source directory and config file are\n" +
+		"left untouched.\n" +
+		"\n" +
+		"#### `--template` *repo*\n" +
+		"\n" +
+		"Initialize the source directory from the template repository *repo*, for\n" +
+		"example a starter set of dotfiles. The template is cloned into the source\n" +
+		"directory and its history is discarded. If the template contains a\n" +
+		"`.chezmoiplaceholders.yaml` manifest, chezmoi prompts for a value for each\n" +
+		"placeholder in it, replaces every occurrence of the placeholder in the source\n" +
+		"files with the value, and removes the manifest. Finally, the result is committed\n" +
+		"to a new git repository. For example:\n" +
+		"\n" +
+		"    placeholders:\n" +
+		"    - placeholder: __EMAIL__\n" +
+		"      prompt: Email address\n" +
+		"    - placeholder: __NAME__\n" +
+		"      prompt: Full name\n" +
+		"      default: Your Name\n" +
+		"\n" +
+		"Each placeholder has the following fields:\n" +
+		"\n" +
+		"| Field         | Type   | Description                                      |\n" +
+		"| ------------- | ------ | ------------------------------------------------ |\n" +
+		"| `placeholder` | string | Text to replace, required                        |\n" +
+		"| `prompt`      | string | Prompt shown to the user                         |\n" +
+		"| `default`     | string | Value used if the user enters nothing            |\n" +
+		"\n" +
+		"`--template` is only supported when the source VCS is git.\n" +
+		"\n" +
+		"#### `--wizard`\n" +
+		"\n" +
+		"After initializing a new repository in the source directory, look for common\n" +
+		"dotfiles in the destination directory, for example shell, git, editor, and ssh\n" +
+		"configuration files, and offer to add each one that is not already managed.\n" +
+		"Files that contain your home directory or hostname are added as templates, and\n" +
+		"files that are only readable by you are added as private. The added files are\n" +
+		"then committed.\n" +
+		"\n" +
 		"#### `init` examples\n" +
 		"\n" +
 		"    chezmoi init https://github.com/user/dotfiles.git\n" +
 		"    chezmoi init https://github.com/user/dotfiles.git --apply\n" +
+		"    chezmoi init --one-shot https://github.com/user/dotfiles.git\n" +
+		"    chezmoi init gh:user/dotfiles\n" +
+		"    chezmoi init --template gh:someone/dotfiles-starter\n" +
+		"    chezmoi init --wizard\n" +
 		"\n" +
 		"### `import` *filename*\n" +
 		"\n" +
@@ -2168,6 +3743,11 @@ func init() {
 		"\n" +
 		"Remove destination (in the source state) before importing.\n" +
 		"\n" +
+		"#### `--rename`\n" +
+		"\n" +
+		"Record imported files that were moved as renames without prompting, as for\n" +
+		"`add --rename`.\n" +
+		"\n" +
 		"#### `--strip-components` *n*\n" +
 		"\n" +
 		"Strip *n* leading components from paths.\n" +
@@ -2192,6 +3772,17 @@ func init() {
 		"abbreviated to `d`, `f`, and `s` respectively. By default, `manage` will list\n" +
 		"entries of all types.\n" +
 		"\n" +
+		"#### `-l`, `--long`\n" +
+		"\n" +
+		"Print a long listing of each entry with its type, permissions, size in bytes,\n" +
+		"the attributes from its source name, the time that `chezmoi apply` last changed\n" +
+		"it, and its path. The size of a directory is the total size of its entries.\n" +
+		"\n" +
+		"#### `--sort` *order*\n" +
+		"\n" +
+		"Sort entries by *order*, which is one of `name` (the default), `size` (largest\n" +
+		"first), or `time` (most recently applied first).\n" +
+		"\n" +
 		"#### `managed` examples\n" +
 		"\n" +
 		"    chezmoi managed\n" +
@@ -2199,6 +3790,7 @@ func init() {
 		"    chezmoi managed --include=files,symlinks\n" +
 		"    chezmoi managed -i d\n" +
 		"    chezmoi managed -i d,f\n" +
+		"    chezmoi managed --long --sort=size\n" +
 		"\n" +
 		"### `merge` *targets*\n" +
 		"\n" +
@@ -2213,6 +3805,19 @@ func init() {
 		"\n" +
 		"    chezmoi merge ~/.bashrc\n" +
 		"\n" +
+		"### `mv` *source* *target*\n" +
+		"\n" +
+		"Move *source* to *target* in both the source state and the destination\n" +
+		"directory. The source state entry keeps all of its attributes, for example\n" +
+		"`private_` and `.tmpl`, and the parent directory of *target* must either be the\n" +
+		"destination directory or be managed by chezmoi. If *source* does not exist in\n" +
+		"the destination directory then only the source state is changed.\n" +
+		"\n" +
+		"#### `mv` examples\n" +
+		"\n" +
+		"    chezmoi mv ~/.bashrc ~/.bashrc.local\n" +
+		"    chezmoi mv ~/.config/foo ~/.config/bar\n" +
+		"\n" +
 		"### `purge`\n" +
 		"\n" +
 		"Remove chezmoi's configuration, state, and source directory, but leave the\n" +
@@ -2227,6 +3832,23 @@ func init() {
 		"    chezmoi purge\n" +
 		"    chezmoi purge --force\n" +
 		"\n" +
+		"### `parse-source-name` *source-name*\n" +
+		"\n" +
+		"Print how *source-name*, a path relative to the source directory, is parsed.\n" +
+		"For each component, print its type, the attributes parsed from it, and its\n" +
+		"target name, then print the resulting target name. All components except the\n" +
+		"last are parsed as directories. The last component is parsed as a directory if\n" +
+		"*source-name* ends with a slash.\n" +
+		"\n" +
+		"Prefixes that are out of order, repeated, or follow `dot_` are part of the\n" +
+		"target name and are listed as misplaced. See [Source state\n" +
+		"attributes](#source-state-attributes) for the order of prefixes.\n" +
+		"\n" +
+		"#### `parse-source-name` examples\n" +
+		"\n" +
+		"    chezmoi parse-source-name private_dot_ssh/encrypted_private_id_rsa\n" +
+		"    chezmoi parse-source-name exact_dot_config/\n" +
+		"\n" +
 		"### `remove` *targets*\n" +
 		"\n" +
 		"Remove *targets* from both the source state and the destination directory.\n" +
@@ -2254,8 +3876,11 @@ func init() {
 		"#### `secret` examples\n" +
 		"\n" +
 		"    chezmoi secret bitwarden list items\n" +
+		"    chezmoi secret doppler secrets\n" +
 		"    chezmoi secret keyring set --service service --user user\n" +
 		"    chezmoi secret keyring get --service service --user user\n" +
+		"    chezmoi secret keepassxc-browser associate\n" +
+		"    chezmoi secret keyring delete --service service --user user\n" +
 		"    chezmoi secret lastpass ls\n" +
 		"    chezmoi secret lastpass -- show --format=json id\n" +
 		"    chezmoi secret onepassword list items\n" +
@@ -2285,6 +3910,27 @@ func init() {
 		"    chezmoi source-path\n" +
 		"    chezmoi source-path ~/.bashrc\n" +
 		"\n" +
+		"### `test` [*names*]\n" +
+		"\n" +
+		"Check that the templates in the source state render as expected with fixture\n" +
+		"data. Each subdirectory of `.chezmoitests` in the source directory is a test.\n" +
+		"A test contains the template data in one of `data.json`, `data.toml`, or\n" +
+		"`data.yaml`, and the expected contents of targets in an `expected` directory,\n" +
+		"laid out as they would be in the destination directory. Values under the\n" +
+		"`chezmoi` key of the data override the automatically populated `.chezmoi`\n" +
+		"variables, so tests can check templates for other operating systems and\n" +
+		"machines. The expected contents of a symlink are its target.\n" +
+		"\n" +
+		"Only targets in the `expected` directory are checked. For each test, `test`\n" +
+		"prints the differences between the expected and actual contents followed by\n" +
+		"`ok` or `FAIL` and the test's name. If any tests fail then `test` exits with a\n" +
+		"non-zero status. If no *names* are specified then all tests are run.\n" +
+		"\n" +
+		"#### `test` examples\n" +
+		"\n" +
+		"    chezmoi test\n" +
+		"    chezmoi test windows-laptop\n" +
+		"\n" +
 		"### `unmanage` *targets*\n" +
 		"\n" +
 		"`unmanage` is an alias for `forget` for symmetry with `manage`.\n" +
@@ -2299,11 +3945,28 @@ func init() {
 		"\n" +
 		"### `update`\n" +
 		"\n" +
-		"Pull changes from the source VCS and apply any changes.\n" +
+		"Pull changes from the source VCS and apply any changes. Like `apply`, a\n" +
+		"summary is printed after applying. The changes are pulled with `git pull\n" +
+		"--rebase`, or `hg pull --rebase --update` if the source VCS is Mercurial, or the\n" +
+		"command set in `sourceVCS.pull`.\n" +
+		"\n" +
+		"#### `-a`, `--apply`\n" +
+		"\n" +
+		"Apply the changes after pulling them, the default. Pass `--apply=false` to only\n" +
+		"pull.\n" +
+		"\n" +
+		"#### `-f`, `--force`\n" +
+		"\n" +
+		"Overwrite modified files without prompting, as `chezmoi apply --force`.\n" +
+		"\n" +
+		"#### `--report` *filename*\n" +
+		"\n" +
+		"Write a JSON report of applying to *filename*, as `chezmoi apply --report`.\n" +
 		"\n" +
 		"#### `update` examples\n" +
 		"\n" +
 		"    chezmoi update\n" +
+		"    chezmoi update --apply=false\n" +
 		"\n" +
 		"### `upgrade`\n" +
 		"\n" +
@@ -2327,16 +3990,47 @@ func init() {
 		"\n" +
 		"    chezmoi upgrade\n" +
 		"\n" +
+		"### `upgrade-externals` [*targets*]\n" +
+		"\n" +
+		"An alias for [`externals update`](#externals-update-targets), which upgrades\n" +
+		"`github-release` externals to the release with the highest version that\n" +
+		"satisfies their `version` constraint along with updating all other externals.\n" +
+		"\n" +
+		"#### `upgrade-externals` examples\n" +
+		"\n" +
+		"    chezmoi upgrade-externals\n" +
+		"    chezmoi upgrade-externals ~/.local/bin/ripgrep\n" +
+		"\n" +
 		"### `verify` [*targets*]\n" +
 		"\n" +
 		"Verify that all *targets* match their target state. chezmoi exits with code 0\n" +
 		"(success) if all targets match their target state, or 1 (failure) otherwise. If\n" +
 		"no targets are specified then all targets are checked.\n" +
 		"\n" +
+		"If no targets are specified then chezmoi also checks that every `run_once_` and\n" +
+		"`run_onchange_` script that has been run is still in the source state, and that\n" +
+		"the cached contents of every file and archive external match its checksum,\n" +
+		"either declared in `.chezmoiexternal.toml` or recorded in\n" +
+		"`.chezmoiexternal.lock`, and every git repository external that is locked to a\n" +
+		"commit has that commit checked out. Externals are not downloaded. Each category\n" +
+		"of failure, `targets`, `scripts`, or `externals`, is reported on its own line.\n" +
+		"\n" +
+		"Scripts are never run.\n" +
+		"\n" +
+		"#### `--fix`\n" +
+		"\n" +
+		"Fix the permissions of files and directories and the targets of existing\n" +
+		"symlinks, but never change contents. Each target that is fixed and each target\n" +
+		"that still does not match its target state is reported on its own line, and\n" +
+		"chezmoi exits with code 1 (failure) only if any targets still do not match.\n" +
+		"This is useful as a cron job that keeps, for example, `~/.ssh` private without\n" +
+		"risking overwriting local edits.\n" +
+		"\n" +
 		"#### `verify` examples\n" +
 		"\n" +
 		"    chezmoi verify\n" +
 		"    chezmoi verify ~/.bashrc\n" +
+		"    chezmoi verify --fix ~/.ssh\n" +
 		"\n" +
 		"## Editor configuration\n" +
 		"\n" +
@@ -2344,6 +4038,29 @@ func init() {
 		"environment variable, the `EDITOR` environment variable, or `vi`, whichever is\n" +
 		"specified first.\n" +
 		"\n" +
+		"## Child process environment\n" +
+		"\n" +
+		"chezmoi sets the following environment variables for every process that it\n" +
+		"runs, including editors, scripts, diff pagers, merge tools, version control\n" +
+		"commands, and the `cd` shell, so they can detect that they are being run by\n" +
+		"chezmoi:\n" +
+		"\n" +
+		"| Variable              | Value                                                   |\n" +
+		"| --------------------- | ------------------------------------------------------- |\n" +
+		"| `CHEZMOI`             | `1`                                                     |\n" +
+		"| `CHEZMOI_ARCH`        | The architecture, as in `.chezmoi.arch`                 |\n" +
+		"| `CHEZMOI_COMMAND`     | The command being run, for example `apply`              |\n" +
+		"| `CHEZMOI_DESTINATION` | The destination directory                               |\n" +
+		"| `CHEZMOI_EXECUTABLE`  | The path to chezmoi                                     |\n" +
+		"| `CHEZMOI_OS`          | The operating system, as in `.chezmoi.os`               |\n" +
+		"| `CHEZMOI_SOURCE_DIR`  | The source directory                                    |\n" +
+		"| `CHEZMOI_SOURCE_FILE` | Scripts only, the script's path in the source directory |\n" +
+		"\n" +
+		"Although `CHEZMOI_DESTINATION` and `CHEZMOI_SOURCE_DIR` also [set global\n" +
+		"flags](#global-command-line-flags), they are ignored by a chezmoi command run by\n" +
+		"a child process, which uses its own config file's source and destination\n" +
+		"directories. Pass `--source` or `--destination` explicitly to use the parent's.\n" +
+		"\n" +
 		"## Umask configuration\n" +
 		"\n" +
 		"By default, chezmoi uses your current umask as set by your operating system and\n" +
@@ -2383,24 +4100,162 @@ func init() {
 		"For a full list of options, see\n" +
 		"[`Template.Option`](https://pkg.go.dev/text/template?tab=doc#Template.Option).\n" +
 		"\n" +
+		"If a template fails to parse or execute, then chezmoi reports the source file,\n" +
+		"line, and column of the error, and the surrounding lines of the template. If\n" +
+		"the error is a missing key then chezmoi suggests similarly spelled keys, or\n" +
+		"lists the available keys if there are none. For example:\n" +
+		"\n" +
+		"    dot_gitconfig.tmpl:2:16: at <.emial>: map has no entry for key \"emial\"\n" +
+		"    1 | [user]\n" +
+		"    2 |     email = {{ .emial }}\n" +
+		"      |                ^\n" +
+		"    3 |     name = {{ .name }}\n" +
+		"    did you mean .email?\n" +
+		"\n" +
 		"## Template variables\n" +
 		"\n" +
 		"chezmoi provides the following automatically populated variables:\n" +
 		"\n" +
 		"| Variable                | Value                                                                                                                           |\n" +
 		"| ----------------------- | ------------------------------------------------------------------------------------------------------------------------------- |\n" +
+		"| `.chezmoi.android`      | Whether chezmoi is running on Android, including in [Termux](https://termux.com/).                                              |\n" +
 		"| `.chezmoi.arch`         | Architecture, e.g. `amd64`, `arm`, etc. as returned by [runtime.GOARCH](https://pkg.go.dev/runtime?tab=doc#pkg-constants).      |\n" +
+		"| `.chezmoi.chassis`      | The chassis of the machine chezmoi is running on, e.g. `laptop`, `desktop`, or `vm`, see below.                                 |\n" +
+		"| `.chezmoi.display`      | Display facts, if `template.displayData` is `true`, see below.                                                                  |\n" +
+		"| `.chezmoi.fqdnHostname` | The fully-qualified domain name of the machine chezmoi is running on, see below.                                                |\n" +
 		"| `.chezmoi.fullHostname` | The full hostname of the machine chezmoi is running on.                                                                         |\n" +
 		"| `.chezmoi.group`        | The group of the user running chezmoi.                                                                                          |\n" +
+		"| `.chezmoi.hasBattery`   | Whether the machine chezmoi is running on has a battery.                                                                        |\n" +
 		"| `.chezmoi.homedir`      | The home directory of the user running chezmoi.                                                                                 |\n" +
 		"| `.chezmoi.hostname`     | The hostname of the machine chezmoi is running on, up to the first `.`.                                                         |\n" +
-		"| `.chezmoi.kernel`       | Contains information from `/proc/sys/kernel`. Linux only, useful for detecting specific kernels (i.e. Microsoft's WSL kernel).  |\n" +
+		"| `.chezmoi.kernel`       | Kernel information from `/proc/sys/kernel` on Linux, or from `uname` on BSDs and illumos. On Linux, also the environment, see below. |\n" +
+		"| `.chezmoi.machineID`    | A stable identifier for the machine chezmoi is running on, see [`machineID`](#machineid-salt).                                  |\n" +
+		"| `.chezmoi.network`      | Network facts, if `template.networkData` is `true`, see below.                                                                  |\n" +
 		"| `.chezmoi.os`           | Operating system, e.g. `darwin`, `linux`, etc. as returned by [runtime.GOOS](https://pkg.go.dev/runtime?tab=doc#pkg-constants). |\n" +
-		"| `.chezmoi.osRelease`    | The information from `/etc/os-release`, Linux only, run `chezmoi data` to see its output.                                       |\n" +
+		"| `.chezmoi.osRelease`    | The information from `/etc/os-release`, Linux, BSDs, and illumos only, run `chezmoi data` to see its output.                    |\n" +
+		"| `.chezmoi.proxy`        | Proxy facts, if `template.proxyData` is `true`, see below.                                                                      |\n" +
 		"| `.chezmoi.sourceDir`    | The source directory.                                                                                                           |\n" +
 		"| `.chezmoi.username`     | The username of the user running chezmoi.                                                                                       |\n" +
-		"\n" +
-		"Additional variables can be defined in the config file in the `data` section.\n" +
+		"| `.chezmoi.xdg`          | The user's XDG base directories, `cacheHome`, `configHome`, `dataHome`, `runtimeDir`, and `stateHome`, see [path prefixes](#path-prefixes). |\n" +
+		"\n" +
+		"`.chezmoi.fqdnHostname` is `.chezmoi.fullHostname` if it already contains a\n" +
+		"domain, otherwise the first name for the host in `/etc/hosts` that does. No DNS\n" +
+		"queries are made, so if there is no such name it is the same as\n" +
+		"`.chezmoi.fullHostname`.\n" +
+		"\n" +
+		"`.chezmoi.chassis` is one of `desktop`, `laptop`, `convertible`, `server`,\n" +
+		"`tablet`, `handset`, `embedded`, `vm`, or `container`, or empty if it cannot be\n" +
+		"determined. On Linux it is determined in the same way as `hostnamectl chassis`:\n" +
+		"the `CHASSIS` variable in `/etc/machine-info`, then whether chezmoi is running in\n" +
+		"a container or a virtual machine, then the SMBIOS chassis type, and finally the\n" +
+		"ACPI power management profile. On macOS and Windows, virtual machines are `vm`,\n" +
+		"machines with a battery are `laptop`, and all other machines are `desktop`.\n" +
+		"Batteries in peripherals, like wireless mice, do not count towards\n" +
+		"`.chezmoi.hasBattery`.\n" +
+		"\n" +
+		"For example:\n" +
+		"\n" +
+		"    {{ if .chezmoi.hasBattery }}\n" +
+		"    # enable tlp\n" +
+		"    {{ end }}\n" +
+		"    {{ if not (eq .chezmoi.chassis \"vm\" \"container\") }}\n" +
+		"    # enable the compositor\n" +
+		"    {{ end }}\n" +
+		"\n" +
+		"If `template.displayData` is `true` then `.chezmoi.display` contains the\n" +
+		"following facts about the machine's graphics. They are opt-in because some of\n" +
+		"them depend on the session that chezmoi is run from, and so change the target\n" +
+		"state.\n" +
+		"\n" +
+		"| Key          | Value                                                                                                               |\n" +
+		"| ------------ | ------------------------------------------------------------------------------------------------------------------- |\n" +
+		"| `dpi`        | The highest DPI of the connected displays, calculated from their preferred modes and EDIDs, or `0` if unknown.      |\n" +
+		"| `gpuVendor`  | The vendor of the primary GPU, one of `amd`, `intel`, `nvidia`, `virtio`, or `vmware`.                              |\n" +
+		"| `gpuVendors` | The vendors of all GPUs, sorted.                                                                                    |\n" +
+		"| `hiDPI`      | Whether `scale` is greater than one.                                                                                |\n" +
+		"| `scale`      | The scale factor from `$GDK_SCALE` or `$QT_SCALE_FACTOR`, otherwise `2` or more if `dpi` is at least 144, else `1`. |\n" +
+		"| `server`     | The display server of the current session, `wayland` or `x11`, or empty if there is none.                           |\n" +
+		"\n" +
+		"GPUs and displays are read from `/sys/class/drm` and so are only detected on\n" +
+		"Linux. `server` is detected from `$XDG_SESSION_TYPE`, `$WAYLAND_DISPLAY`, and\n" +
+		"`$DISPLAY`, so it depends on the session that chezmoi is run from and is empty,\n" +
+		"for example, when chezmoi is run over SSH.\n" +
+		"\n" +
+		"For example:\n" +
+		"\n" +
+		"    {{ if eq .chezmoi.display.server \"wayland\" }}\n" +
+		"    exec sway\n" +
+		"    {{ end }}\n" +
+		"    font_size {{ if .chezmoi.display.hiDPI }}14{{ else }}11{{ end }}\n" +
+		"\n" +
+		"On Linux, `.chezmoi.kernel` also contains the following keys describing the\n" +
+		"environment that chezmoi is running in. Each key is always set, to the empty\n" +
+		"string if nothing was detected, so templates can compare against them directly.\n" +
+		"\n" +
+		"| Key         | Value                                                                                                                        |\n" +
+		"| ----------- | ---------------------------------------------------------------------------------------------------------------------------- |\n" +
+		"| `cloud`     | The cloud provider, one of `aws`, `azure`, `digitalocean`, `gcp`, `hetzner`, or `oracle`, detected from `/sys/class/dmi/id`. |\n" +
+		"| `container` | The container runtime, e.g. `docker`, `podman`, `lxc`, or `crostini` for Linux on ChromeOS.                                  |\n" +
+		"| `wsl`       | The major version of the Windows Subsystem for Linux, `1` or `2`.                                                            |\n" +
+		"\n" +
+		"No network requests are made, so cloud providers that cannot be identified from\n" +
+		"DMI information are not detected.\n" +
+		"\n" +
+		"For example:\n" +
+		"\n" +
+		"    {{ if eq .chezmoi.kernel.wsl \"2\" }}\n" +
+		"    # WSL 2\n" +
+		"    {{ else if eq .chezmoi.kernel.container \"crostini\" }}\n" +
+		"    # ChromeOS\n" +
+		"    {{ else if ne .chezmoi.kernel.container \"\" }}\n" +
+		"    # some other container\n" +
+		"    {{ end }}\n" +
+		"\n" +
+		"If `template.networkData` is `true` then `.chezmoi.network` contains the\n" +
+		"following facts about the machine's network. They are opt-in because they change\n" +
+		"as the machine moves between networks, and so change the target state.\n" +
+		"\n" +
+		"| Key             | Value                                                                                                |\n" +
+		"| --------------- | ---------------------------------------------------------------------------------------------------- |\n" +
+		"| `domain`        | The DNS domain, from the `domain` or first `search` line of `/etc/resolv.conf`.                      |\n" +
+		"| `interfaces`    | The network interfaces, by name, each with `addresses`, `hardwareAddr`, `loopback`, `mtu`, and `up`. |\n" +
+		"| `primaryIP`     | The IPv4 address of the interface with the default route.                                            |\n" +
+		"| `primaryIPv6`   | The IPv6 address of the interface with the default route.                                            |\n" +
+		"| `searchDomains` | The DNS search domains, from the `search` line of `/etc/resolv.conf`.                                |\n" +
+		"\n" +
+		"Facts that cannot be determined, for example the primary IP address of a machine\n" +
+		"without a default route, are empty. No network traffic is sent to determine\n" +
+		"them. `/etc/resolv.conf` does not exist on Windows, so `domain` and\n" +
+		"`searchDomains` are always empty there.\n" +
+		"\n" +
+		"For example:\n" +
+		"\n" +
+		"    {{ if eq .chezmoi.network.domain \"office.example.com\" }}\n" +
+		"    export http_proxy=http://proxy.office.example.com:3128\n" +
+		"    {{ end }}\n" +
+		"\n" +
+		"If `template.proxyData` is `true` then `.chezmoi.proxy` contains the proxy\n" +
+		"settings that chezmoi uses for its own HTTP requests, from the config file, the\n" +
+		"environment, or the system proxy settings, see [proxies](#proxies):\n" +
+		"\n" +
+		"| Key       | Value                                                |\n" +
+		"| --------- | ---------------------------------------------------- |\n" +
+		"| `http`    | The HTTP proxy, or empty if there is none.           |\n" +
+		"| `https`   | The HTTPS proxy, or empty if there is none.          |\n" +
+		"| `noProxy` | The hosts that are not proxied.                      |\n" +
+		"| `pac`     | The URL or path of the PAC file, or empty if unused. |\n" +
+		"\n" +
+		"For example:\n" +
+		"\n" +
+		"    {{ with .chezmoi.proxy.https }}\n" +
+		"    [http]\n" +
+		"        proxy = {{ . }}\n" +
+		"    {{ end }}\n" +
+		"\n" +
+		"Additional variables can be defined in the config file in the `data` section,\n" +
+		"and per host in [`.chezmoidata/hosts`](#chezmoidatahosts). Any variable can be\n" +
+		"overridden for a single run with the [`--data`](#--data-pairs) flag or\n" +
+		"`CHEZMOI_DATA_`*key* environment variables.\n" +
 		"Variable names must consist of a letter and be followed by zero or more letters\n" +
 		"and/or digits.\n" +
 		"\n" +
@@ -2410,6 +4265,112 @@ func init() {
 		"template functions from `sprig`](http://masterminds.github.io/sprig/) are\n" +
 		"included. chezmoi provides some additional functions.\n" +
 		"\n" +
+		"Template functions that run commands, for example the password manager\n" +
+		"functions, kill the command if it does not exit within `template.funcTimeout`,\n" +
+		"so that a hung command does not hang chezmoi. The error names the template, the\n" +
+		"function, and the command. Set `template.funcTimeout` to `0` to disable the\n" +
+		"timeout.\n" +
+		"\n" +
+		"### `authorizedKeys` *specs*\n" +
+		"\n" +
+		"`authorizedKeys` returns the public SSH keys of the forge users in *specs*, in\n" +
+		"`authorized_keys` format, with each user's keys preceded by a comment line.\n" +
+		"Each spec has the form *forge*`:`*username*, where *forge* is `github`,\n" +
+		"`gitlab`, or a forge configured in `authorizedKeys.urls`. Keys are fetched from\n" +
+		"`https://github.com/`*username*`.keys` and `https://gitlab.com/`*username*`.keys`\n" +
+		"respectively. Forges and usernames may only contain ASCII letters, digits, `_`,\n" +
+		"`.`, and `-`.\n" +
+		"\n" +
+		"Every fetched key is parsed and, if any fingerprints are configured for the\n" +
+		"user in `authorizedKeys.fingerprints`, must match one of them. If the keys for\n" +
+		"any user cannot be fetched, are invalid, have an unexpected fingerprint, or if\n" +
+		"no keys are returned, then template execution fails and the target file is left\n" +
+		"unchanged.\n" +
+		"\n" +
+		"Keys are cached in memory, so each user's keys are only fetched once. If\n" +
+		"`authorizedKeys.refreshPeriod` is greater than zero, keys are also cached in\n" +
+		"`$XDG_CACHE_HOME/chezmoi/authorizedkeys` and only fetched again when the cache\n" +
+		"is older than the refresh period. Fetched keys are locked in\n" +
+		"[`.chezmoiexternal.lock`](#chezmoiexternallock), and locked keys are used, and\n" +
+		"checked against `authorizedKeys.fingerprints`, until `chezmoi externals update`\n" +
+		"is run.\n" +
+		"\n" +
+		"#### `authorizedKeys` examples\n" +
+		"\n" +
+		"In `~/.local/share/chezmoi/private_dot_ssh/private_authorized_keys.tmpl`:\n" +
+		"\n" +
+		"    {{ authorizedKeys \"github:alice\" \"gitlab:alice\" }}\n" +
+		"\n" +
+		"In `~/.config/chezmoi/chezmoi.toml`:\n" +
+		"\n" +
+		"    [authorizedKeys]\n" +
+		"      refreshPeriod = \"24h\"\n" +
+		"    [authorizedKeys.fingerprints]\n" +
+		"      \"github:alice\" = [\"SHA256:0EN7UDs0awddYqnuJ1WThBSsIRe5xGTdoljq0Qz5lFw\"]\n" +
+		"    [authorizedKeys.urls]\n" +
+		"      gitea = \"https://gitea.example.com/%s.keys\"\n" +
+		"\n" +
+		"### `awsSecretsManager` *arn*\n" +
+		"\n" +
+		"`awsSecretsManager` returns structured data retrieved from [AWS Secrets\n" +
+		"Manager](https://aws.amazon.com/secrets-manager/). *arn* is the ARN or name of\n" +
+		"a secret, and the secret's current string value is parsed as JSON.\n" +
+		"Credentials, and the region if `awsSecretsManager.region` is not set, are found\n" +
+		"using the standard AWS credential chain, e.g. the `AWS_ACCESS_KEY_ID` and\n" +
+		"`AWS_SECRET_ACCESS_KEY` environment variables or `~/.aws/credentials`. The\n" +
+		"profile is `awsSecretsManager.profile` if set, or `$AWS_PROFILE` otherwise.\n" +
+		"Secrets are cached so calling `awsSecretsManager` multiple times with the same\n" +
+		"*arn* will only retrieve the secret once.\n" +
+		"\n" +
+		"#### `awsSecretsManager` examples\n" +
+		"\n" +
+		"    {{ (awsSecretsManager \"arn:aws:secretsmanager:us-east-1:123456789012:secret:github-AbCdEf\").token }}\n" +
+		"\n" +
+		"### `awsSecretsManagerRaw` *arn*\n" +
+		"\n" +
+		"`awsSecretsManagerRaw` returns the current string value of the secret *arn*\n" +
+		"from AWS Secrets Manager without parsing it. It is otherwise identical to\n" +
+		"`awsSecretsManager`.\n" +
+		"\n" +
+		"#### `awsSecretsManagerRaw` examples\n" +
+		"\n" +
+		"    {{ awsSecretsManagerRaw \"github-token\" }}\n" +
+		"\n" +
+		"### `azureKeyVault` *secret* [*vault*]\n" +
+		"\n" +
+		"`azureKeyVault` returns the current value of the secret *secret* in the [Azure\n" +
+		"Key Vault](https://azure.microsoft.com/services/key-vault/) *vault*, or in\n" +
+		"`azureKeyVault.defaultVault` if *vault* is not given. The vault's URL is\n" +
+		"`https://`*vault*`.vault.azure.net` unless `azureKeyVault.vaults.`*vault*`.url`\n" +
+		"is set.\n" +
+		"\n" +
+		"chezmoi authenticates with the first of the following that succeeds, like the\n" +
+		"Azure SDKs' `DefaultAzureCredential`:\n" +
+		"\n" +
+		"1. A service principal with a client secret from the `AZURE_TENANT_ID`,\n" +
+		"   `AZURE_CLIENT_ID`, and `AZURE_CLIENT_SECRET` environment variables.\n" +
+		"2. The managed identity of the Azure host, if any.\n" +
+		"3. The account that is logged in to the [Azure\n" +
+		"   CLI](https://docs.microsoft.com/cli/azure/) (`az`).\n" +
+		"\n" +
+		"`azureKeyVault.vaults.`*vault*`.tenantID` and\n" +
+		"`azureKeyVault.vaults.`*vault*`.clientID` override the tenant and the client or\n" +
+		"managed identity used for *vault*. Secrets are cached so calling\n" +
+		"`azureKeyVault` multiple times with the same arguments will only retrieve the\n" +
+		"secret once.\n" +
+		"\n" +
+		"#### `azureKeyVault` examples\n" +
+		"\n" +
+		"    {{ azureKeyVault \"github-token\" }}\n" +
+		"    {{ azureKeyVault \"db-password\" \"work-vault\" }}\n" +
+		"\n" +
+		"In `~/.config/chezmoi/chezmoi.toml`:\n" +
+		"\n" +
+		"    [azureKeyVault]\n" +
+		"      defaultVault = \"personal-vault\"\n" +
+		"    [azureKeyVault.vaults.work-vault]\n" +
+		"      tenantID = \"00000000-0000-0000-0000-000000000000\"\n" +
+		"\n" +
 		"### `bitwarden` [*args*]\n" +
 		"\n" +
 		"`bitwarden` returns structured data retrieved from\n" +
@@ -2419,23 +4380,157 @@ func init() {
 		"cached so calling `bitwarden` multiple times with the same arguments will only\n" +
 		"invoke `bw` once.\n" +
 		"\n" +
+		"If `bitwarden.command` is `rbw` then [`rbw`](https://github.com/doy/rbw), an\n" +
+		"unofficial Bitwarden CLI that keeps the vault unlocked in a background agent,\n" +
+		"is used instead. This is much faster than `bw` when templates use many secrets.\n" +
+		"Only `bitwarden \"item\" name [username]` is supported with `rbw`, and the item\n" +
+		"is returned in the same shape as the output of `bw get item`, with `id`,\n" +
+		"`name`, `notes`, `fields`, and, for logins, `login.username`,\n" +
+		"`login.password`, `login.totp`, and `login.uris`.\n" +
+		"\n" +
 		"#### `bitwarden` examples\n" +
 		"\n" +
 		"    username = {{ (bitwarden \"item\" \"example.com\").login.username }}\n" +
 		"    password = {{ (bitwarden \"item\" \"example.com\").login.password }}\n" +
 		"\n" +
+		"### `doppler` [*project* [*config*]]\n" +
+		"\n" +
+		"`doppler` returns the secrets of a [Doppler](https://www.doppler.com) config as\n" +
+		"a map of secret names to values. *project* and *config* default to\n" +
+		"`doppler.project` and `doppler.config`.\n" +
+		"\n" +
+		"If `doppler.token` is set then the secrets are downloaded with the Doppler API\n" +
+		"using it as the token, otherwise they are downloaded with the Doppler CLI\n" +
+		"(`doppler secrets download`). A service token can only access a single config,\n" +
+		"so the project and config can be omitted when using one. With the CLI, an\n" +
+		"omitted project or config is taken from the directory's `doppler setup`, and\n" +
+		"the CLI's own login is used. `doppler.endpoint` overrides the API endpoint,\n" +
+		"which is `https://api.doppler.com` by default. Secrets are cached so calling\n" +
+		"`doppler` multiple times with the same project and config will only download\n" +
+		"them once.\n" +
+		"\n" +
+		"#### `doppler` examples\n" +
+		"\n" +
+		"    {{ (doppler).GITHUB_TOKEN }}\n" +
+		"    {{ (doppler \"backend\" \"prd\").DATABASE_URL }}\n" +
+		"\n" +
+		"### `gcpSecretManager` *name*\n" +
+		"\n" +
+		"`gcpSecretManager` returns the latest version of the secret *name* from [Google\n" +
+		"Cloud Secret Manager](https://cloud.google.com/secret-manager). *name* is either\n" +
+		"a secret ID in the project `gcpSecretManager.project` or a full resource name of\n" +
+		"the form `projects/`*project*`/secrets/`*secret*. If `gcpSecretManager.project`\n" +
+		"is not set then the project of the credentials, or `$GOOGLE_CLOUD_PROJECT`, is\n" +
+		"used.\n" +
+		"\n" +
+		"Credentials are found using [Application Default\n" +
+		"Credentials](https://cloud.google.com/docs/authentication/production), for\n" +
+		"example a service account key file named by `$GOOGLE_APPLICATION_CREDENTIALS`,\n" +
+		"the credentials created by `gcloud auth application-default login`, or the\n" +
+		"service account of the Google Cloud host. `gcpSecretManager.endpoint` overrides\n" +
+		"the API endpoint, which is `https://secretmanager.googleapis.com` by default.\n" +
+		"Secrets are cached so calling `gcpSecretManager` multiple times with the same\n" +
+		"*name* will only retrieve the secret once.\n" +
+		"\n" +
+		"#### `gcpSecretManager` examples\n" +
+		"\n" +
+		"    {{ gcpSecretManager \"github-token\" }}\n" +
+		"    {{ gcpSecretManager \"projects/my-project/secrets/github-token\" }}\n" +
+		"\n" +
+		"### `gcpSecretManagerVersion` *name* *version*\n" +
+		"\n" +
+		"`gcpSecretManagerVersion` returns the version *version* of the secret *name*\n" +
+		"from Google Cloud Secret Manager, as `gcpSecretManager`. *version* is a version\n" +
+		"number or an alias such as `latest`.\n" +
+		"\n" +
+		"#### `gcpSecretManagerVersion` examples\n" +
+		"\n" +
+		"    {{ gcpSecretManagerVersion \"github-token\" \"3\" }}\n" +
+		"\n" +
 		"### `gopass` *gopass-name*\n" +
 		"\n" +
 		"`gopass` returns passwords stored in [gopass](https://www.gopass.pw/) using the\n" +
 		"gopass CLI (`gopass`). *gopass-name* is passed to `gopass show <gopass-name>`\n" +
 		"and first line of the output of `gopass` is returned with the trailing newline\n" +
-		"stripped. The output from `gopass` is cached so calling `gopass` multiple times\n" +
-		"with the same *gopass-name* will only invoke `gopass` once.\n" +
+		"stripped. If gopass is version 1.12.0 or later then `--noparsing` is also\n" +
+		"passed so that the secret is returned exactly as it is stored. The output from\n" +
+		"`gopass` is cached and shared with `gopassFields` and `gopassRaw`, so using any\n" +
+		"of them multiple times with the same *gopass-name* will only invoke `gopass`\n" +
+		"once.\n" +
 		"\n" +
 		"#### `gopass` examples\n" +
 		"\n" +
 		"    {{ gopass \"<pass-name>\" }}\n" +
 		"\n" +
+		"### `gopassFields` *gopass-name*\n" +
+		"\n" +
+		"`gopassFields` returns the fields in the body of the gopass secret\n" +
+		"*gopass-name*, that is everything after the password on the first line, as a\n" +
+		"map. If the body starts with a `---` line then it is parsed as YAML, otherwise\n" +
+		"every line of the form `key: value` is a field and other lines are ignored.\n" +
+		"\n" +
+		"#### `gopassFields` examples\n" +
+		"\n" +
+		"    {{ (gopassFields \"<pass-name>\").login }}\n" +
+		"    {{ index (gopassFields \"<pass-name>\") \"api-key\" }}\n" +
+		"\n" +
+		"### `gopassRaw` *gopass-name*\n" +
+		"\n" +
+		"`gopassRaw` returns the full output of `gopass show <gopass-name>`, including\n" +
+		"the password and the body, without any processing.\n" +
+		"\n" +
+		"#### `gopassRaw` examples\n" +
+		"\n" +
+		"    {{ gopassRaw \"<pass-name>\" }}\n" +
+		"\n" +
+		"### `gpgAgentSocket`\n" +
+		"\n" +
+		"`gpgAgentSocket` returns the path of the GnuPG agent socket, as reported by\n" +
+		"`gpgconf --list-dirs agent-socket`. If `gpgconf` is not available then it\n" +
+		"returns `S.gpg-agent` in `$GNUPGHOME`, or `~/.gnupg` if `$GNUPGHOME` is not\n" +
+		"set.\n" +
+		"\n" +
+		"#### `gpgAgentSocket` examples\n" +
+		"\n" +
+		"    export GPG_AGENT_SOCK={{ gpgAgentSocket | quote }}\n" +
+		"\n" +
+		"### `gpgAgentSSHSocket`\n" +
+		"\n" +
+		"`gpgAgentSSHSocket` returns the path of the GnuPG agent's SSH agent socket, as\n" +
+		"reported by `gpgconf --list-dirs agent-ssh-socket`, with the same fallback as\n" +
+		"`gpgAgentSocket`. Use this if you use `gpg-agent` as your SSH agent.\n" +
+		"\n" +
+		"#### `gpgAgentSSHSocket` examples\n" +
+		"\n" +
+		"    export SSH_AUTH_SOCK={{ gpgAgentSSHSocket | quote }}\n" +
+		"\n" +
+		"### `include` *filename*\n" +
+		"\n" +
+		"`include` returns the literal contents of the file named *filename*. Relative\n" +
+		"paths are relative to the source directory. The contents are not executed as a\n" +
+		"template.\n" +
+		"\n" +
+		"#### `include` examples\n" +
+		"\n" +
+		"    {{ include \".shared/aliases\" }}\n" +
+		"\n" +
+		"### `includeTemplate` *name* [*data*]\n" +
+		"\n" +
+		"`includeTemplate` returns the result of executing the template *name* in the\n" +
+		"[`.chezmoitemplates`](#chezmoitemplates) directory with *data*. If *data* is\n" +
+		"not given then the template is executed with the template data. Unlike the\n" +
+		"`template` action, the result can be passed to other functions, so shared\n" +
+		"blocks can be indented or otherwise transformed to fit the file that includes\n" +
+		"them. It is an error for a template to include itself, directly or through\n" +
+		"other templates.\n" +
+		"\n" +
+		"#### `includeTemplate` examples\n" +
+		"\n" +
+		"    {{ includeTemplate \"proxy\" . }}\n" +
+		"\n" +
+		"    proxy:\n" +
+		"      {{- includeTemplate \"proxy\" | trim | nindent 2 }}\n" +
+		"\n" +
 		"### `keepassxc` *entry*\n" +
 		"\n" +
 		"`keepassxc` returns structured data retrieved from a\n" +
@@ -2453,6 +4548,30 @@ func init() {
 		"    username = {{ (keepassxc \"example.com\").UserName }}\n" +
 		"    password = {{ (keepassxc \"example.com\").Password }}\n" +
 		"\n" +
+		"To use `keepassxc` non-interactively, for example on a trusted machine, either\n" +
+		"store the database password in the system keyring and set\n" +
+		"`keepassxc.keyring.service` and `keepassxc.keyring.user`, or, for a database\n" +
+		"that is unlocked with a key file only, set `keepassxc.keyFile` and\n" +
+		"`keepassxc.noPassword`. `keepassxc.keyFile` is passed to `keepassxc-cli` as\n" +
+		"`--key-file` and can be combined with a password.\n" +
+		"\n" +
+		"Alternatively, if `keepassxc.mode` is `browser` then chezmoi retrieves entries\n" +
+		"from a running, unlocked KeePassXC using its browser integration, which must be\n" +
+		"enabled in KeePassXC's settings, instead of `keepassxc-cli`. *entry* is then a\n" +
+		"URL, and `keepassxc` returns the first matching entry's `Title`, `UserName`,\n" +
+		"`Password`, and `UUID`, and its additional attributes whose names start with\n" +
+		"`KPH: `, without the prefix, if KeePassXC is configured to return them. Run\n" +
+		"`chezmoi secret keepassxc-browser associate` once, and confirm the association\n" +
+		"in KeePassXC, to get the values of `keepassxc.browser.id` and\n" +
+		"`keepassxc.browser.key`. chezmoi finds KeePassXC's socket automatically unless\n" +
+		"`keepassxc.browser.socket` is set.\n" +
+		"\n" +
+		"    [keepassxc]\n" +
+		"      mode = \"browser\"\n" +
+		"    [keepassxc.browser]\n" +
+		"      id = \"chezmoi\"\n" +
+		"      key = \"...\"\n" +
+		"\n" +
 		"### `keepassxcAttribute` *entry* *attribute*\n" +
 		"\n" +
 		"`keepassxcAttribute` returns the attribute *attribute* of *entry* using\n" +
@@ -2467,12 +4586,18 @@ func init() {
 		"### `keyring` *service* *user*\n" +
 		"\n" +
 		"`keyring` retrieves the password associated with *service* and *user* from the\n" +
-		"user's keyring.\n" +
+		"user's keyring. The same *service* and *user* identify the password on every\n" +
+		"OS, so the same template works everywhere once the password has been set with\n" +
+		"`chezmoi secret keyring set`.\n" +
 		"\n" +
-		"| OS    | Keyring       |\n" +
-		"| ----- | ------------- |\n" +
-		"| macOS | Keychain      |\n" +
-		"| Linux | GNOME Keyring |\n" +
+		"| OS      | Keyring                                                                |\n" +
+		"| ------- | ---------------------------------------------------------------------- |\n" +
+		"| macOS   | Keychain, as a generic password with *service* and account *user*      |\n" +
+		"| Linux   | Secret Service API, e.g. GNOME Keyring or KWallet                      |\n" +
+		"| Windows | Credential Manager, as a generic credential named *service*`:`*user*   |\n" +
+		"\n" +
+		"Passwords are cached so calling `keyring` multiple times with the same *service*\n" +
+		"and *user* will only query the keyring once.\n" +
 		"\n" +
 		"#### `keyring` examples\n" +
 		"\n" +
@@ -2507,6 +4632,28 @@ func init() {
 		"\n" +
 		"    {{ (index (lastpassRaw \"SSH Private Key\") 0).note }}\n" +
 		"\n" +
+		"### `machineID` *salt*\n" +
+		"\n" +
+		"`machineID` returns a stable identifier for the machine chezmoi is running on,\n" +
+		"formatted as a UUID, derived from the operating system's machine ID and *salt*.\n" +
+		"The operating system's machine ID is `/etc/machine-id` or D-Bus's machine ID on\n" +
+		"Linux, `/etc/hostid` on BSDs, `IOPlatformUUID` on macOS, and `MachineGuid` on\n" +
+		"Windows. It is hashed so that it cannot be recovered from the result, and\n" +
+		"different salts give unrelated identifiers, so identifiers can be shared without\n" +
+		"allowing machines to be tracked across uses. Unlike the hostname, the machine ID\n" +
+		"does not change when the machine is renamed.\n" +
+		"\n" +
+		"`.chezmoi.machineID` is the machine ID salted with `machineID.salt`, or the\n" +
+		"empty string if the operating system's machine ID is not available.\n" +
+		"\n" +
+		"#### `machineID` examples\n" +
+		"\n" +
+		"    {{ if eq .chezmoi.machineID \"1d1d6a0c-3c0c-8a4e-9b2f-6f2a4f3d2e1c\" }}\n" +
+		"    # work laptop\n" +
+		"    {{ end }}\n" +
+		"\n" +
+		"    backup-id = {{ machineID \"backup\" | quote }}\n" +
+		"\n" +
 		"### `onepassword` *uuid*\n" +
 		"\n" +
 		"`onepassword` returns structured data from [1Password](https://1password.com/)\n" +
@@ -2516,6 +4663,16 @@ func init() {
 		"The output from `op` is cached so calling `onepassword` multiple times with the\n" +
 		"same *uuid* will only invoke `op` once.\n" +
 		"\n" +
+		"If `onepassword.connectHost` is set then the item is read from that [1Password\n" +
+		"Connect](https://support.1password.com/secrets-automation/) server using\n" +
+		"`onepassword.connectToken` instead, and `op` is not needed. *uuid* may be an\n" +
+		"item UUID or title. The vault `onepassword.connectVault` is searched, or all\n" +
+		"vaults that the token can access if it is not set. The item is returned in the\n" +
+		"same shape as the output of `op get item`, with `uuid`, `vaultUuid`,\n" +
+		"`details.password`, `details.notesPlain`, `details.fields`, `overview.title`,\n" +
+		"and `overview.url`, so that the same templates work with both. The original\n" +
+		"item from the Connect server is available as `connectItem`.\n" +
+		"\n" +
 		"#### `onepassword` examples\n" +
 		"\n" +
 		"    {{ (onepassword \"<uuid>\").details.password }}\n" +
@@ -2529,10 +4686,27 @@ func init() {
 		"The output from `op` is cached so calling `onepasswordDocument` multiple times with the\n" +
 		"same *uuid* will only invoke `op` once.\n" +
 		"\n" +
+		"If `onepassword.connectHost` is set then the contents of the document's file\n" +
+		"are read from the 1Password Connect server instead.\n" +
+		"\n" +
 		"#### `onepasswordDocument` examples\n" +
 		"\n" +
 		"    {{- onepasswordDocument \"<uuid>\" -}}\n" +
 		"\n" +
+		"### `output` *name* [*args*]\n" +
+		"\n" +
+		"`output` returns the standard output of running the command *name* with *args*.\n" +
+		"If the command exits with a non-zero status then template execution fails. The\n" +
+		"command's standard error is passed through to chezmoi's standard error. The\n" +
+		"output is not cached, so the command is run every time `output` is called.\n" +
+		"\n" +
+		"`output` is useful for incorporating facts about the machine that are not\n" +
+		"included in the [template variables](#template-variables).\n" +
+		"\n" +
+		"#### `output` examples\n" +
+		"\n" +
+		"    arch = {{ output \"uname\" \"-m\" | trim | quote }}\n" +
+		"\n" +
 		"### `pass` *pass-name*\n" +
 		"\n" +
 		"`pass` returns passwords stored in [pass](https://www.passwordstore.org/) using\n" +
@@ -2545,6 +4719,32 @@ func init() {
 		"\n" +
 		"    {{ pass \"<pass-name>\" }}\n" +
 		"\n" +
+		"### `promptBool` *prompt*\n" +
+		"\n" +
+		"`promptBool` prompts the user with *prompt* and returns the user's response\n" +
+		"interpreted as a boolean. Any value accepted by\n" +
+		"[`strconv.ParseBool`](https://pkg.go.dev/strconv?tab=doc#ParseBool) is\n" +
+		"accepted, as are `y`, `yes`, `on`, `n`, `no`, and `off`, in any case. It is only\n" +
+		"available when generating the initial config file.\n" +
+		"\n" +
+		"#### `promptBool` examples\n" +
+		"\n" +
+		"    {{ $personal := promptBool \"personal computer\" -}}\n" +
+		"    [data]\n" +
+		"        personal = {{ $personal }}\n" +
+		"\n" +
+		"### `promptInt` *prompt*\n" +
+		"\n" +
+		"`promptInt` prompts the user with *prompt* and returns the user's response\n" +
+		"interpreted as an integer. It is only available when generating the initial\n" +
+		"config file.\n" +
+		"\n" +
+		"#### `promptInt` examples\n" +
+		"\n" +
+		"    {{ $width := promptInt \"terminal width\" -}}\n" +
+		"    [data]\n" +
+		"        width = {{ $width }}\n" +
+		"\n" +
 		"### `promptString` *prompt*\n" +
 		"\n" +
 		"`promptString` takes a single argument is a string prompted to the user, and the\n" +
@@ -2557,6 +4757,25 @@ func init() {
 		"    [data]\n" +
 		"        email = \"{{ $email }}\"\n" +
 		"\n" +
+		"### `promptStringOnce` *key* *prompt*\n" +
+		"\n" +
+		"`promptStringOnce` returns the value stored for *key* in chezmoi's persistent\n" +
+		"state. If there is no value stored for *key*, then the user is prompted with\n" +
+		"*prompt* and their response, with all leading and trailing space stripped, is\n" +
+		"stored for later runs. This is useful for values that should not be committed\n" +
+		"to your source directory and that you do not want to enter every time, like a\n" +
+		"corporate proxy URL. If the `promptStringOnce.encrypt` configuration variable is\n" +
+		"`true` then values are encrypted with the configured encryption before they\n" +
+		"are stored. In dry run\n" +
+		"mode, and in commands that only read the persistent state such as `diff` and\n" +
+		"`verify`, the user is still prompted but the response is not stored.\n" +
+		"\n" +
+		"#### `promptStringOnce` examples\n" +
+		"\n" +
+		"    {{ $proxy := promptStringOnce \"proxy\" \"Corporate proxy URL\" -}}\n" +
+		"    export http_proxy={{ $proxy }}\n" +
+		"    export https_proxy={{ $proxy }}\n" +
+		"\n" +
 		"### `secret` [*args*]\n" +
 		"\n" +
 		"`secret` returns the output of the generic secret command defined by the\n" +
@@ -2571,15 +4790,125 @@ func init() {
 		"parsed as JSON. The output is cached so multiple calls to `secret` with the same\n" +
 		"*args* will only invoke the generic secret command once.\n" +
 		"\n" +
+		"### `sshAgentSocket`\n" +
+		"\n" +
+		"`sshAgentSocket` returns the path of the platform's SSH agent socket:\n" +
+		"\n" +
+		"| Platform | Socket                                                  |\n" +
+		"| -------- | ------------------------------------------------------- |\n" +
+		"| macOS    | The output of `launchctl getenv SSH_AUTH_SOCK`          |\n" +
+		"| WSL      | `~/.ssh/agent.sock`, where relays to Windows listen     |\n" +
+		"| Windows  | `\\\\.\\pipe\\openssh-ssh-agent`                            |\n" +
+		"| Other    | `$XDG_RUNTIME_DIR/ssh-agent.socket`                     |\n" +
+		"\n" +
+		"Unlike `$SSH_AUTH_SOCK` at the time `chezmoi` runs, the result does not depend\n" +
+		"on the current session or on agent forwarding. On other platforms, template\n" +
+		"execution fails if `$XDG_RUNTIME_DIR` is not set.\n" +
+		"\n" +
+		"#### `sshAgentSocket` examples\n" +
+		"\n" +
+		"    {{- if ne .chezmoi.os \"windows\" }}\n" +
+		"    export SSH_AUTH_SOCK={{ sshAgentSocket | quote }}\n" +
+		"    {{- end }}\n" +
+		"\n" +
+		"### `stateGet` *namespace* *key*\n" +
+		"\n" +
+		"`stateGet` returns the value stored for *key* in *namespace* in chezmoi's\n" +
+		"persistent state by [`stateSet`](#stateset-namespace-key-value), or nothing if\n" +
+		"there is no stored value. Values keep their type, except that all numbers are\n" +
+		"returned as floating point numbers. `stateGet` and `stateSet` are only available\n" +
+		"if the `template.state` configuration variable is `true`, as they make the\n" +
+		"target state depend on previous runs.\n" +
+		"\n" +
+		"#### `stateGet` examples\n" +
+		"\n" +
+		"    {{ $port := stateGet \"ports\" \"web\" -}}\n" +
+		"    {{ if not $port -}}\n" +
+		"    {{   $port = add 49152 (mod (now | unixEpoch) 16384) -}}\n" +
+		"    {{   stateSet \"ports\" \"web\" $port -}}\n" +
+		"    {{ end -}}\n" +
+		"    listen = {{ $port }}\n" +
+		"\n" +
+		"### `stateSet` *namespace* *key* *value*\n" +
+		"\n" +
+		"`stateSet` stores *value*, which must be representable as JSON, for *key* in\n" +
+		"*namespace* in chezmoi's persistent state, so that later runs can read it with\n" +
+		"[`stateGet`](#stateget-namespace-key), and returns nothing. *namespace* must not\n" +
+		"be empty or contain a `/`. In dry run mode, and in commands that only read the\n" +
+		"persistent state such as `diff` and `verify`, the value is visible to later\n" +
+		"templates in the same run but is not stored.\n" +
+		"\n" +
+		"### `targetContents` *target*\n" +
+		"\n" +
+		"`targetContents` returns the contents of the file *target* in the target state,\n" +
+		"after any template in its source state has been executed, so that one file can\n" +
+		"embed or derive values from another. *target* is either absolute, relative to\n" +
+		"the destination directory, or begins with `~/`, which is also relative to the\n" +
+		"destination directory. It is an error if *target* does not exist in the target\n" +
+		"state, is ignored, or is not a file, or if its contents depend on the contents\n" +
+		"of the file being rendered, directly or through other targets.\n" +
+		"`targetContents` is only available in templates in the source state, and\n" +
+		"templates in `.chezmoitemplates` that they include with the `template` action.\n" +
+		"\n" +
+		"#### `targetContents` examples\n" +
+		"\n" +
+		"    {{- range list \".bashrc\" \".gitconfig\" \".vimrc\" }}\n" +
+		"    {{ targetContents (print \"~/\" .) | sha256sum }}  {{ . }}\n" +
+		"    {{- end }}\n" +
+		"\n" +
+		"### `totp` *secret*\n" +
+		"\n" +
+		"`totp` returns the current six digit [TOTP](https://tools.ietf.org/html/rfc6238)\n" +
+		"code for the base32-encoded *secret*, as generated by authenticator apps with a\n" +
+		"30 second period. Whitespace, padding, and case in *secret* are ignored. *secret*\n" +
+		"is typically itself fetched from a password manager. Codes are not cached, and\n" +
+		"are only valid for a short time after the template is executed, so `totp` is\n" +
+		"best suited to scripts and to files that are regenerated when they are used.\n" +
+		"\n" +
+		"#### `totp` examples\n" +
+		"\n" +
+		"    {{ totp (keyring \"github-otp\" \"alice\") }}\n" +
+		"    {{ totp (index (keepassxc \"example.com\") \"otp-secret\") }}\n" +
+		"\n" +
 		"### `vault` *key*\n" +
 		"\n" +
-		"`vault` returns structured data from [Vault](https://www.vaultproject.io/) using\n" +
-		"the [Vault CLI](https://www.vaultproject.io/docs/commands/) (`vault`). *key* is\n" +
-		"passed to `vault kv get -format=json <key>` and the output from `vault` is\n" +
-		"parsed as JSON. The output from `vault` is cached so calling `vault` multiple\n" +
-		"times with the same *key* will only invoke `vault` once.\n" +
+		"`vault` returns structured data from [Vault](https://www.vaultproject.io/). If\n" +
+		"`vault.address` is not set, then `vault` uses the [Vault\n" +
+		"CLI](https://www.vaultproject.io/docs/commands/) (`vault`): *key* is passed to\n" +
+		"`vault kv get -format=json <key>` and the output from `vault` is parsed as JSON.\n" +
+		"\n" +
+		"If `vault.address` is set in the config file, then chezmoi reads the path *key*\n" +
+		"from the Vault server directly and returns the response, as\n" +
+		"`vault read -format=json <key>` would. `$VAULT_ADDR` does not set\n" +
+		"`vault.address`, so setting it only affects the Vault CLI. For secrets in a KV\n" +
+		"version 2 secrets engine, *key* includes `data/` or `metadata/` after the mount\n" +
+		"path, for example `secret/data/github`. chezmoi authenticates using\n" +
+		"`vault.authMethod`:\n" +
+		"\n" +
+		"| Method    | Credentials                                                          |\n" +
+		"| --------- | -------------------------------------------------------------------- |\n" +
+		"| `token`   | `vault.token`, or the contents of `~/.vault-token`                   |\n" +
+		"| `approle` | `vault.roleID` and `vault.secretID`                                  |\n" +
+		"| `aws`     | `vault.role` and AWS credentials from the standard credential chain |\n" +
+		"\n" +
+		"Tokens that are renewable and that expire within `vault.minTTL` are renewed\n" +
+		"automatically. Tokens from `approle` and `aws` authentication that cannot be\n" +
+		"renewed are replaced by logging in again.\n" +
+		"\n" +
+		"The result of `vault` is cached so calling `vault` multiple times with the same\n" +
+		"*key* will only read it once.\n" +
 		"\n" +
 		"#### `vault` examples\n" +
 		"\n" +
-		"    {{ (vault \"<key>\").data.data.password }}\n")
+		"    {{ (vault \"<key>\").data.data.password }}\n" +
+		"\n" +
+		"### `vaultKVv2` *mount* *key*\n" +
+		"\n" +
+		"`vaultKVv2` returns the data of the latest version of the secret *key* in the KV\n" +
+		"version 2 secrets engine mounted at *mount*, read from the Vault server at\n" +
+		"`vault.address`. It is equivalent to `(vault \"<mount>/data/<key>\").data.data`.\n" +
+		"\n" +
+		"#### `vaultKVv2` examples\n" +
+		"\n" +
+		"    {{ (vaultKVv2 \"secret\" \"github\").token }}\n")
 }
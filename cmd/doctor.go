@@ -12,6 +12,8 @@ import (
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
 	shell "github.com/twpayne/go-shell"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var doctorCmd = &cobra.Command{
@@ -116,8 +118,31 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 		mustSucceed: true,
 	}
 
+	var agePluginChecks []doctorCheck
+	if c.Encryption == "age" {
+		if encryption, err := c.getEncryption(); err == nil {
+			pluginNames, _ := encryption.(*chezmoi.Age).PluginNames()
+			if len(pluginNames) != 0 {
+				agePluginChecks = append(agePluginChecks, &doctorBinaryCheck{
+					name:          "age CLI",
+					binaryName:    c.Age.Command,
+					mustSucceed:   true,
+					versionArgs:   []string{"--version"},
+					versionRegexp: regexp.MustCompile(`^v?(\d+\.\d+\.\d+)`),
+				})
+			}
+			for _, pluginName := range pluginNames {
+				agePluginChecks = append(agePluginChecks, &doctorBinaryCheck{
+					name:        "age plugin",
+					binaryName:  "age-plugin-" + pluginName,
+					mustSucceed: true,
+				})
+			}
+		}
+	}
+
 	allOK := true
-	for _, dc := range []doctorCheck{
+	for _, dc := range append([]doctorCheck{
 		&doctorVersionCheck{},
 		&doctorRuntimeCheck{},
 		&doctorDirectoryCheck{
@@ -203,7 +228,7 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 			name:       "generic secret CLI",
 			binaryName: c.GenericSecret.Command,
 		},
-	} {
+	}, agePluginChecks...) {
 		if dc.Skip() {
 			continue
 		}
@@ -42,6 +42,7 @@ func TestDumpCmd(t *testing.T) {
 					"type":       "file",
 					"sourcePath": filepath.Join("/", "home", "user", ".local", "share", "chezmoi", "dir", "file"),
 					"targetPath": filepath.Join("dir", "file"),
+					"compressed": false,
 					"empty":      false,
 					"encrypted":  false,
 					"perm":       float64(0644),
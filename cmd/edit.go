@@ -40,11 +40,11 @@ func init() {
 	markRemainingZshCompPositionalArgumentsAsFiles(editCmd, 1)
 }
 
-type encryptedFile struct {
-	index          int
-	file           *chezmoi.File
-	ciphertextPath string
-	plaintextPath  string
+type encodedFile struct {
+	index         int
+	file          *chezmoi.File
+	sourcePath    string
+	plaintextPath string
 }
 
 func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
@@ -78,37 +78,40 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build a list of source file names to pass to the editor. Check that each
-	// is either a file or a symlink. If the entry is an encrypted file then
-	// remember it.
+	// is either a file or a symlink. If the entry is a compressed or encrypted
+	// file, or is stored in the blob store, then remember it.
 	argv := make([]string, len(entries))
-	var encryptedFiles []encryptedFile
+	var encodedFiles []encodedFile
 	for i, entry := range entries {
 		argv[i] = filepath.Join(c.SourceDir, entry.SourceName())
 		if file, ok := entry.(*chezmoi.File); ok {
-			if file.Encrypted {
-				ef := encryptedFile{
-					index:          i,
-					file:           file,
-					ciphertextPath: argv[i],
+			if _, err := file.Contents(); err != nil {
+				return err
+			}
+			if file.Compressed || file.Encrypted || file.Blob() {
+				ef := encodedFile{
+					index:      i,
+					file:       file,
+					sourcePath: argv[i],
 				}
-				encryptedFiles = append(encryptedFiles, ef)
+				encodedFiles = append(encodedFiles, ef)
 			}
 		} else if _, ok := entry.(*chezmoi.Symlink); !ok {
 			return fmt.Errorf("%s: not a file or symlink", args[i])
 		}
 	}
 
-	// If any of the files are encrypted, create a temporary directory to store
-	// the plaintext contents, decrypt each of them, and update argv to point to
+	// If any of the files are encoded, create a temporary directory to store
+	// the plaintext contents, decode each of them, and update argv to point to
 	// the plaintext file.
-	if len(encryptedFiles) != 0 {
-		tempDir, err := ioutil.TempDir("", "chezmoi")
+	if len(encodedFiles) != 0 {
+		tempDir, removeTempDir, err := c.makeSecretTempDir()
 		if err != nil {
 			return err
 		}
-		defer os.RemoveAll(tempDir)
-		for i := range encryptedFiles {
-			ef := &encryptedFiles[i]
+		defer removeTempDir()
+		for i := range encodedFiles {
+			ef := &encodedFiles[i]
 			plaintext, err := ef.file.Contents()
 			if err != nil {
 				return err
@@ -128,17 +131,22 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Re-encrypt any encrypted files.
-	for _, ef := range encryptedFiles {
+	// Re-encode any encoded files.
+	for _, ef := range encodedFiles {
 		plaintext, err := ioutil.ReadFile(ef.plaintextPath)
 		if err != nil {
 			return err
 		}
-		ciphertext, err := ts.GPG.Encrypt(ef.plaintextPath, plaintext)
+		contents, err := ts.EncodeContents(ef.plaintextPath, ef.file.Compressed, ef.file.Encrypted, plaintext)
 		if err != nil {
 			return err
 		}
-		if err := renameio.WriteFile(ef.ciphertextPath, ciphertext, 0644); err != nil {
+		if ef.file.Blob() {
+			if contents, err = ts.AddBlob(c.fs, contents, c.mutator); err != nil {
+				return err
+			}
+		}
+		if err := renameio.WriteFile(ef.sourcePath, contents, 0644); err != nil {
 			return err
 		}
 	}
@@ -158,6 +166,7 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 	applyOptions := chezmoi.ApplyOptions{
 		DestDir:           ts.DestDir,
 		DryRun:            c.DryRun,
+		FIFOs:             c.FIFOs,
 		Ignore:            ts.TargetIgnore.Match,
 		ScriptStateBucket: c.scriptStateBucket,
 		Stdout:            c.Stdout,
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// getEncryption returns the encryption configured by the encryption config
+// variable.
+func (c *Config) getEncryption() (chezmoi.Encryption, error) {
+	switch c.Encryption {
+	case "age":
+		age := c.Age
+		age.Identity = c.expandTilde(age.Identity)
+		age.Identities = nil
+		for _, identity := range c.Age.Identities {
+			age.Identities = append(age.Identities, c.expandTilde(identity))
+		}
+		age.RecipientsFile = c.expandTilde(age.RecipientsFile)
+		age.ResolveRecipient = c.getAuthorizedKeys
+		return &age, nil
+	case "", "gpg":
+		return &c.GPG, nil
+	case "dpapi":
+		return &chezmoi.DPAPI{}, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown encryption", c.Encryption)
+	}
+}
+
+// expandTilde returns path with a leading ~/ replaced by the user's home
+// directory.
+func (c *Config) expandTilde(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	return filepath.Join(c.homeDir, path[2:])
+}
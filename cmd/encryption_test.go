@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestGetEncryption(t *testing.T) {
+	for _, tc := range []struct {
+		encryption string
+		want       chezmoi.Encryption
+		wantErr    bool
+	}{
+		{
+			encryption: "",
+			want:       &chezmoi.GPG{Command: "gpg"},
+		},
+		{
+			encryption: "gpg",
+			want:       &chezmoi.GPG{Command: "gpg"},
+		},
+		{
+			encryption: "age",
+			want:       &chezmoi.Age{Command: "age"},
+		},
+		{
+			encryption: "dpapi",
+			want:       &chezmoi.DPAPI{},
+		},
+		{
+			encryption: "rot13",
+			wantErr:    true,
+		},
+	} {
+		t.Run(tc.encryption, func(t *testing.T) {
+			c := newConfig()
+			c.Encryption = tc.encryption
+			got, err := c.getEncryption()
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				if age, ok := got.(*chezmoi.Age); ok {
+					assert.NotNil(t, age.ResolveRecipient)
+					age.ResolveRecipient = nil
+				}
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
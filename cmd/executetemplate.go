@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"io/ioutil"
 	"strconv"
 
@@ -9,6 +10,8 @@ import (
 
 type executeTemplateCmdConfig struct {
 	init         bool
+	promptBool   map[string]string
+	promptInt    map[string]int
 	promptString map[string]string
 }
 
@@ -26,11 +29,29 @@ func init() {
 
 	persistentFlags := executeTemplateCmd.PersistentFlags()
 	persistentFlags.BoolVarP(&config.executeTemplate.init, "init", "i", false, "simulate chezmoi init")
+	persistentFlags.StringToStringVar(&config.executeTemplate.promptBool, "promptBool", nil, "simulate promptBool")
+	persistentFlags.StringToIntVar(&config.executeTemplate.promptInt, "promptInt", nil, "simulate promptInt")
 	persistentFlags.StringToStringVarP(&config.executeTemplate.promptString, "promptString", "p", nil, "simulate promptString")
 }
 
 func (c *Config) runExecuteTemplateCmd(cmd *cobra.Command, args []string) error {
 	if c.executeTemplate.init {
+		c.templateFuncs["promptBool"] = func(prompt string) bool {
+			if value, ok := c.executeTemplate.promptBool[prompt]; ok {
+				boolValue, err := parseBool(value)
+				if err != nil {
+					panic(fmt.Errorf("promptBool: %s: %w", prompt, err))
+				}
+				return boolValue
+			}
+			return false
+		}
+		c.templateFuncs["promptInt"] = func(prompt string) int64 {
+			if value, ok := c.executeTemplate.promptInt[prompt]; ok {
+				return int64(value)
+			}
+			return 0
+		}
 		c.templateFuncs["promptString"] = func(prompt string) string {
 			if value, ok := c.executeTemplate.promptString[prompt]; ok {
 				return value
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var explainCmd = &cobra.Command{
+	Use:     "explain target",
+	Args:    cobra.ExactArgs(1),
+	Short:   "Explain how the target state of a target is derived",
+	Long:    mustGetLongHelp("explain"),
+	Example: getExample("explain"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runExplainCmd,
+}
+
+// A templateReferences records the names referenced by a template.
+type templateReferences struct {
+	data      map[string]struct{}
+	templates map[string]struct{}
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	markRemainingZshCompPositionalArgumentsAsFiles(explainCmd, 1)
+}
+
+func (c *Config) runExplainCmd(cmd *cobra.Command, args []string) error {
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	entries, err := c.getEntries(ts, args)
+	if err != nil {
+		return err
+	}
+	entry := entries[0]
+	sourcePath := filepath.Join(ts.SourceDir, entry.SourceName())
+	umask := os.FileMode(c.Umask)
+
+	entryType, attributes := getEntryTypeAndAttributes(entry)
+	var (
+		encrypted      bool
+		perm           os.FileMode
+		contentsSHA256 [sha256.Size]byte
+	)
+	switch entry := entry.(type) {
+	case *chezmoi.Dir:
+		perm = entry.Perm &^ umask
+	case *chezmoi.File:
+		encrypted = entry.Encrypted
+		perm = entry.Perm &^ umask
+		if contentsSHA256, err = entry.ContentsSHA256(); err != nil {
+			return err
+		}
+	case *chezmoi.Script:
+		contents, err := entry.Contents()
+		if err != nil {
+			return err
+		}
+		contentsSHA256 = sha256.Sum256(contents)
+	case *chezmoi.Symlink:
+		linkname, err := entry.Linkname()
+		if err != nil {
+			return err
+		}
+		contentsSHA256 = sha256.Sum256([]byte(linkname))
+	case *chezmoi.Hardlink:
+		linkname, err := entry.Linkname()
+		if err != nil {
+			return err
+		}
+		contentsSHA256 = sha256.Sum256([]byte(linkname))
+	case *chezmoi.FIFO:
+		perm = entry.Perm &^ umask
+	}
+
+	refs, err := c.getTemplateReferences(ts, entry)
+	if err != nil {
+		return err
+	}
+
+	w := c.Stdout
+	printExplainField(w, "target", chezmoi.TargetPath(ts.DestDir, entry.TargetName()))
+	printExplainField(w, "source", sourcePath)
+	printExplainField(w, "type", entryType)
+	printExplainField(w, "attributes", strings.Join(attributes, ", "))
+	printExplainField(w, "encrypted", fmt.Sprintf("%t", encrypted))
+	if refs != nil {
+		printExplainField(w, "templates", strings.Join(sortedKeys(refs.templates), ", "))
+		printExplainField(w, "data", strings.Join(sortedKeys(refs.data), ", "))
+	}
+	printExplainField(w, "ignored", fmt.Sprintf("%t", ts.TargetIgnore.Match(entry.TargetName())))
+	printExplainField(w, "ignore", strings.Join(ts.TargetIgnore.MatchingPatterns(entry.TargetName()), ", "))
+	if entryType == "dir" || entryType == "fifo" || entryType == "file" {
+		printExplainField(w, "perm", fmt.Sprintf("%03o", perm))
+	}
+	if entryType != "dir" && entryType != "external" && entryType != "fifo" {
+		printExplainField(w, "sha256", fmt.Sprintf("%x", contentsSHA256))
+	}
+	if external, ok := entry.(*chezmoi.External); ok {
+		printExplainField(w, "url", external.URL)
+	}
+	return nil
+}
+
+// getEntryTypeAndAttributes returns the type of entry and the attributes
+// parsed from its source name.
+func getEntryTypeAndAttributes(entry chezmoi.Entry) (string, []string) {
+	var attributes []string
+	switch entry := entry.(type) {
+	case *chezmoi.Dir:
+		if entry.Exact {
+			attributes = append(attributes, "exact")
+		}
+		if entry.Private() {
+			attributes = append(attributes, "private")
+		}
+		return "dir", attributes
+	case *chezmoi.File:
+		if entry.Compressed {
+			attributes = append(attributes, "compressed")
+		}
+		if entry.Empty {
+			attributes = append(attributes, "empty")
+		}
+		if entry.Encrypted {
+			attributes = append(attributes, "encrypted")
+		}
+		if entry.Executable() {
+			attributes = append(attributes, "executable")
+		}
+		if entry.Private() {
+			attributes = append(attributes, "private")
+		}
+		if entry.Template {
+			attributes = append(attributes, "template")
+		}
+		return "file", attributes
+	case *chezmoi.Script:
+		if entry.Once {
+			attributes = append(attributes, "once")
+		}
+		if entry.OnChange {
+			attributes = append(attributes, "onchange")
+		}
+		if entry.Before {
+			attributes = append(attributes, "before")
+		}
+		if entry.After {
+			attributes = append(attributes, "after")
+		}
+		if entry.Template {
+			attributes = append(attributes, "template")
+		}
+		return "script", attributes
+	case *chezmoi.Symlink:
+		if entry.Template {
+			attributes = append(attributes, "template")
+		}
+		return "symlink", attributes
+	case *chezmoi.Hardlink:
+		if entry.Template {
+			attributes = append(attributes, "template")
+		}
+		return "hardlink", attributes
+	case *chezmoi.FIFO:
+		if entry.Private() {
+			attributes = append(attributes, "private")
+		}
+		return "fifo", attributes
+	case *chezmoi.External:
+		attributes = append(attributes, entry.Type)
+		if entry.Exact {
+			attributes = append(attributes, "exact")
+		}
+		if entry.Executable {
+			attributes = append(attributes, "executable")
+		}
+		return "external", attributes
+	default:
+		return "", nil
+	}
+}
+
+// getTemplateReferences returns the references made by entry's source, or nil
+// if entry is not a template.
+func (c *Config) getTemplateReferences(ts *chezmoi.TargetState, entry chezmoi.Entry) (*templateReferences, error) {
+	compressed, encrypted := false, false
+	switch entry := entry.(type) {
+	case *chezmoi.File:
+		if !entry.Template {
+			return nil, nil
+		}
+		compressed, encrypted = entry.Compressed, entry.Encrypted
+	case *chezmoi.Script:
+		if !entry.Template {
+			return nil, nil
+		}
+	case *chezmoi.Symlink:
+		if !entry.Template {
+			return nil, nil
+		}
+	case *chezmoi.Hardlink:
+		if !entry.Template {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+	sourcePath := filepath.Join(ts.SourceDir, entry.SourceName())
+	data, _, err := ts.ReadSourceFile(c.fs, entry.SourceName())
+	if err != nil {
+		return nil, err
+	}
+	if data, err = ts.DecodeContents(sourcePath, compressed, encrypted, data); err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(sourcePath).Option(ts.TemplateOptions...).Funcs(ts.TemplateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	refs := newTemplateReferences()
+	refs.addNode(ts.Templates, tmpl.Tree.Root)
+	return refs, nil
+}
+
+func newTemplateReferences() *templateReferences {
+	return &templateReferences{
+		data:      make(map[string]struct{}),
+		templates: make(map[string]struct{}),
+	}
+}
+
+// addNode adds the references in node to refs, following references to
+// templates in templates.
+func (refs *templateReferences) addNode(templates map[string]*template.Template, node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ActionNode:
+		refs.addNode(templates, node.Pipe)
+	case *parse.BranchNode:
+		refs.addNode(templates, node.Pipe)
+		refs.addNode(templates, node.List)
+		refs.addNode(templates, node.ElseList)
+	case *parse.ChainNode:
+		refs.addNode(templates, node.Node)
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			refs.addNode(templates, arg)
+		}
+		// includeTemplate references the template named by its first
+		// argument, if it is a string literal.
+		if len(node.Args) > 1 {
+			if ident, ok := node.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "includeTemplate" {
+				if name, ok := node.Args[1].(*parse.StringNode); ok {
+					refs.addTemplate(templates, name.Text)
+				}
+			}
+		}
+	case *parse.FieldNode:
+		refs.data["."+strings.Join(node.Ident, ".")] = struct{}{}
+	case *parse.IfNode:
+		refs.addNode(templates, &node.BranchNode)
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, n := range node.Nodes {
+			refs.addNode(templates, n)
+		}
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, cmd := range node.Cmds {
+			refs.addNode(templates, cmd)
+		}
+	case *parse.RangeNode:
+		refs.addNode(templates, &node.BranchNode)
+	case *parse.TemplateNode:
+		refs.addNode(templates, node.Pipe)
+		refs.addTemplate(templates, node.Name)
+	case *parse.VariableNode:
+		if len(node.Ident) > 1 && node.Ident[0] == "$" {
+			refs.data["."+strings.Join(node.Ident[1:], ".")] = struct{}{}
+		}
+	case *parse.WithNode:
+		refs.addNode(templates, &node.BranchNode)
+	}
+}
+
+// addTemplate adds a reference to the template name to refs and the references
+// made by the template itself, if it is in templates.
+func (refs *templateReferences) addTemplate(templates map[string]*template.Template, name string) {
+	if _, ok := refs.templates[name]; ok {
+		return
+	}
+	refs.templates[name] = struct{}{}
+	if tmpl, ok := templates[name]; ok && tmpl.Tree != nil {
+		refs.addNode(templates, tmpl.Tree.Root)
+	}
+}
+
+func printExplainField(w io.Writer, name, value string) {
+	if value == "" {
+		value = "-"
+	}
+	_, _ = fmt.Fprintf(w, "%s: %s\n", name, value)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestExplainCmd(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		root     interface{}
+		data     map[string]interface{}
+		expected string
+	}{
+		{
+			name: "file",
+			args: []string{"/home/user/.bashrc"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/private_dot_bashrc": "# contents of .bashrc\n",
+			},
+			expected: "" +
+				"target: /home/user/.bashrc\n" +
+				"source: /home/user/.local/share/chezmoi/private_dot_bashrc\n" +
+				"type: file\n" +
+				"attributes: private\n" +
+				"encrypted: false\n" +
+				"ignored: false\n" +
+				"ignore: -\n" +
+				"perm: 600\n" +
+				"sha256: " + sha256Hex("# contents of .bashrc\n") + "\n",
+		},
+		{
+			name: "template",
+			args: []string{"/home/user/.gitconfig"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiignore":         "**/.gitconfig\n!.git*\n",
+					".chezmoitemplates/user": "{{ .name }}",
+					"dot_gitconfig.tmpl": "" +
+						"{{ if .name }}{{ template \"user\" . }}{{ end }}\n" +
+						"{{ range $email := .emails }}{{ $.suffix }}{{ end }}\n",
+				},
+			},
+			data: map[string]interface{}{
+				"emails": []interface{}{"john@example.com"},
+				"name":   "John",
+				"suffix": "!",
+			},
+			expected: "" +
+				"target: /home/user/.gitconfig\n" +
+				"source: /home/user/.local/share/chezmoi/dot_gitconfig.tmpl\n" +
+				"type: file\n" +
+				"attributes: template\n" +
+				"encrypted: false\n" +
+				"templates: user\n" +
+				"data: .emails, .name, .suffix\n" +
+				"ignored: false\n" +
+				"ignore: !.git*, **/.gitconfig\n" +
+				"perm: 644\n" +
+				"sha256: " + sha256Hex("John\n!\n") + "\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withData(tc.data), withStdout(stdout))
+			require.NoError(t, c.runExplainCmd(nil, tc.args))
+			assert.Equal(t, tc.expected, stdout.String())
+		})
+	}
+}
+
+func sha256Hex(s string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+}
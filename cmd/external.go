@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+type externalsConfig struct {
+	MaxBandwidth string
+	MaxCacheSize string
+}
+
+// fetchExternalURL returns the contents of url, using the on-disk cache if it
+// was written within refreshPeriod, or at any time if refreshPeriod is zero.
+// Only contents that pass verify are cached.
+func (c *Config) fetchExternalURL(url string, refreshPeriod time.Duration, verify func([]byte) error) ([]byte, error) {
+	cacheFilename := c.externalCacheFilename(url)
+	switch info, err := c.fs.Stat(cacheFilename); {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	case refreshPeriod == 0 || time.Since(info.ModTime()) < refreshPeriod:
+		data, err := c.fs.ReadFile(cacheFilename)
+		if err != nil {
+			return nil, err
+		}
+		if verify(data) == nil {
+			if err := c.touchExternalCacheEntry(cacheFilename); err != nil {
+				return nil, err
+			}
+			return data, nil
+		}
+	}
+	return c.downloadExternalURL(url, verify)
+}
+
+// An externalCacheMetadata records the validators of a cached or partially
+// downloaded external, so that it can be revalidated or resumed without
+// downloading it again, and when it was last used, so that gc can evict the
+// least recently used entries.
+type externalCacheMetadata struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	LastUsed     time.Time `json:"lastUsed"`
+}
+
+// validator returns the strongest validator in m, or the empty string if m has
+// none.
+func (m *externalCacheMetadata) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+// downloadExternalURL downloads url, bypassing the on-disk cache, and caches its
+// contents if they pass verify.
+//
+// Downloads are made with as little transfer as possible. If url is already
+// cached and the cached contents pass verify, then the request is conditional
+// on the server's ETag or Last-Modified header and a 304 Not Modified response
+// reuses the cached contents. If a previous download was interrupted, then it
+// is resumed with a range request if the server supports it. Downloads are
+// limited to externals.maxBandwidth.
+func (c *Config) downloadExternalURL(url string, verify func([]byte) error) ([]byte, error) {
+	maxBandwidth, err := parseByteSize(c.Externals.MaxBandwidth)
+	if err != nil {
+		return nil, fmt.Errorf("externals.maxBandwidth: %w", err)
+	}
+
+	cacheFilename := c.externalCacheFilename(url)
+	partialFilename := cacheFilename + ".partial"
+	if err := vfs.MkdirAll(c.fs, filepath.Dir(cacheFilename), 0700); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var cachedData []byte
+	var offset int64
+	if partialMetadata := c.readExternalCacheMetadata(partialFilename); partialMetadata.validator() != "" {
+		if info, err := c.fs.Stat(partialFilename); err == nil && info.Size() > 0 {
+			offset = info.Size()
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			req.Header.Set("If-Range", partialMetadata.validator())
+		}
+	}
+	if offset == 0 {
+		if data, err := c.fs.ReadFile(cacheFilename); err == nil && verify(data) == nil {
+			cachedData = data
+			metadata := c.readExternalCacheMetadata(cacheFilename)
+			if metadata.ETag != "" {
+				req.Header.Set("If-None-Match", metadata.ETag)
+			}
+			if metadata.LastModified != "" {
+				req.Header.Set("If-Modified-Since", metadata.LastModified)
+			}
+		}
+	}
+
+	client := &http.Client{
+		Timeout: 5 * time.Minute,
+	}
+	if maxBandwidth > 0 {
+		// A rate limited download may take arbitrarily long.
+		client.Timeout = 0
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	flag := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cachedData != nil:
+		now := time.Now()
+		if err := c.fs.Chtimes(cacheFilename, now, now); err != nil {
+			return nil, err
+		}
+		if err := c.touchExternalCacheEntry(cacheFilename); err != nil {
+			return nil, err
+		}
+		return cachedData, nil
+	case resp.StatusCode == http.StatusPartialContent && offset != 0:
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			c.removeExternalCacheEntry(partialFilename)
+			return nil, fmt.Errorf("%s: invalid Content-Range %q", url, resp.Header.Get("Content-Range"))
+		}
+		flag |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset != 0:
+		// The partial download may already be complete if chezmoi was
+		// interrupted before it could be verified. Otherwise, it is not valid
+		// for the current contents so it is discarded and downloaded again.
+		if resp.Header.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset) {
+			if data, err := c.completeExternalDownload(partialFilename, cacheFilename, verify); err == nil {
+				return data, nil
+			}
+		}
+		c.removeExternalCacheEntry(partialFilename)
+		return c.downloadExternalURL(url, verify)
+	case resp.StatusCode == http.StatusOK:
+		flag |= os.O_TRUNC
+	default:
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	// Record the validators of the response before downloading its body so
+	// that the download can be resumed if it is interrupted.
+	if resp.StatusCode == http.StatusOK {
+		metadata := &externalCacheMetadata{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			LastUsed:     time.Now(),
+		}
+		if err := c.writeExternalCacheMetadata(partialFilename, metadata); err != nil {
+			return nil, err
+		}
+	}
+	f, err := c.fs.OpenFile(partialFilename, flag, 0600)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader = resp.Body
+	if maxBandwidth > 0 {
+		body = newRateLimitedReader(body, maxBandwidth)
+	}
+	_, err = io.Copy(f, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return c.completeExternalDownload(partialFilename, cacheFilename, verify)
+}
+
+// completeExternalDownload verifies the complete download in partialFilename
+// and moves it to cacheFilename. If verification fails then the partial
+// download is removed.
+func (c *Config) completeExternalDownload(partialFilename, cacheFilename string, verify func([]byte) error) ([]byte, error) {
+	data, err := c.fs.ReadFile(partialFilename)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(data); err != nil {
+		c.removeExternalCacheEntry(partialFilename)
+		return nil, err
+	}
+	if err := c.fs.Rename(partialFilename, cacheFilename); err != nil {
+		return nil, err
+	}
+	if err := c.fs.Rename(partialFilename+".json", cacheFilename+".json"); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readExternalCacheMetadata returns the metadata of the cache entry filename.
+// Missing or invalid metadata is treated as empty.
+func (c *Config) readExternalCacheMetadata(filename string) *externalCacheMetadata {
+	metadata := &externalCacheMetadata{}
+	if data, err := c.fs.ReadFile(filename + ".json"); err == nil {
+		_ = json.Unmarshal(data, metadata)
+	}
+	return metadata
+}
+
+// writeExternalCacheMetadata writes the metadata of the cache entry filename.
+func (c *Config) writeExternalCacheMetadata(filename string, metadata *externalCacheMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return c.fs.WriteFile(filename+".json", data, 0600)
+}
+
+// touchExternalCacheEntry records that the cache entry filename was used.
+func (c *Config) touchExternalCacheEntry(filename string) error {
+	metadata := c.readExternalCacheMetadata(filename)
+	metadata.LastUsed = time.Now()
+	return c.writeExternalCacheMetadata(filename, metadata)
+}
+
+// removeExternalCacheEntry removes the cache entry filename and its metadata,
+// ignoring any errors.
+func (c *Config) removeExternalCacheEntry(filename string) {
+	_ = c.fs.Remove(filename)
+	_ = c.fs.Remove(filename + ".json")
+}
+
+// externalCacheFilename returns the filename of the on-disk cache of url.
+func (c *Config) externalCacheFilename(url string) string {
+	urlSHA256 := sha256.Sum256([]byte(url))
+	return filepath.Join(c.bds.CacheHome, "chezmoi", "external", hex.EncodeToString(urlSHA256[:]))
+}
+
+// getExternalLock returns the external lock of the source directory, reading
+// it if needed.
+func (c *Config) getExternalLock() (*chezmoi.ExternalLock, error) {
+	if c.externalLock == nil {
+		externalLock, err := chezmoi.ReadExternalLock(c.fs, c.SourceDir)
+		if err != nil {
+			return nil, err
+		}
+		c.externalLock = externalLock
+	}
+	return c.externalLock, nil
+}
+
+// writeExternalLock writes the external lock of the source directory, if the
+// source directory exists.
+func (c *Config) writeExternalLock() error {
+	switch _, err := c.fs.Stat(c.SourceDir); {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	mutator := c.mutator
+	if c.remoteDestination != nil {
+		// c.mutator writes to the remote destination, but the source directory
+		// is local.
+		if c.DryRun {
+			return nil
+		}
+		mutator = chezmoi.NewFSMutator(c.fs)
+	}
+	return c.externalLock.Write(mutator, c.SourceDir, os.FileMode(c.Umask))
+}
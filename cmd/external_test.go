@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestDownloadExternalURL(t *testing.T) {
+	contents := []byte("version 1\n")
+	etag := `"1"`
+	var requests []*http.Request
+	var statusCodes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		recorder := httptest.NewRecorder()
+		recorder.Header().Set("ETag", etag)
+		http.ServeContent(recorder, r, "file", time.Time{}, bytes.NewReader(contents))
+		statusCodes = append(statusCodes, recorder.Code)
+		for key, values := range recorder.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(recorder.Code)
+		_, _ = w.Write(recorder.Body.Bytes())
+	}))
+	defer server.Close()
+	url := server.URL + "/file"
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	verify := func([]byte) error { return nil }
+	download := func() []byte {
+		requests, statusCodes = nil, nil
+		data, err := c.downloadExternalURL(url, verify)
+		require.NoError(t, err)
+		return data
+	}
+
+	// The first download is unconditional.
+	assert.Equal(t, []byte("version 1\n"), download())
+	assert.Equal(t, "", requests[0].Header.Get("If-None-Match"))
+	assert.Equal(t, []int{http.StatusOK}, statusCodes)
+
+	// Unchanged contents are revalidated, not downloaded.
+	assert.Equal(t, []byte("version 1\n"), download())
+	assert.Equal(t, `"1"`, requests[0].Header.Get("If-None-Match"))
+	assert.Equal(t, []int{http.StatusNotModified}, statusCodes)
+
+	// Changed contents are downloaded.
+	contents, etag = []byte("version 2\n"), `"2"`
+	assert.Equal(t, []byte("version 2\n"), download())
+	assert.Equal(t, []int{http.StatusOK}, statusCodes)
+
+	// Interrupted downloads are resumed.
+	contents, etag = []byte("version 3\n"), `"3"`
+	cacheFilename := c.externalCacheFilename(url)
+	require.NoError(t, fs.WriteFile(cacheFilename+".partial", []byte("vers"), 0600))
+	require.NoError(t, fs.WriteFile(cacheFilename+".partial.json", []byte(`{"etag":"\"3\""}`), 0600))
+	assert.Equal(t, []byte("version 3\n"), download())
+	assert.Equal(t, "bytes=4-", requests[0].Header.Get("Range"))
+	assert.Equal(t, []int{http.StatusPartialContent}, statusCodes)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath(cacheFilename,
+			vfst.TestContentsString("version 3\n"),
+		),
+		vfst.TestPath(cacheFilename+".partial",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	// Interrupted downloads of contents that have since changed are restarted.
+	contents, etag = []byte("version 4\n"), `"4"`
+	require.NoError(t, fs.WriteFile(cacheFilename+".partial", []byte("vers"), 0600))
+	require.NoError(t, fs.WriteFile(cacheFilename+".partial.json", []byte(`{"etag":"\"3\""}`), 0600))
+	assert.Equal(t, []byte("version 4\n"), download())
+	assert.Equal(t, []int{http.StatusOK}, statusCodes)
+
+	// Cached contents that fail verification are downloaded again.
+	verify = func(data []byte) error {
+		if bytes.Equal(data, []byte("version 4\n")) {
+			return errors.New("verification failed")
+		}
+		return nil
+	}
+	contents = []byte("version 5\n")
+	assert.Equal(t, []byte("version 5\n"), download())
+	assert.Equal(t, "", requests[0].Header.Get("If-None-Match"))
+	assert.Equal(t, []int{http.StatusOK}, statusCodes)
+
+	// Interrupted downloads that are already complete are not downloaded
+	// again.
+	contents, etag = []byte("version 6\n"), `"6"`
+	require.NoError(t, fs.WriteFile(cacheFilename+".partial", []byte("version 6\n"), 0600))
+	require.NoError(t, fs.WriteFile(cacheFilename+".partial.json", []byte(`{"etag":"\"6\""}`), 0600))
+	assert.Equal(t, []byte("version 6\n"), download())
+	assert.Equal(t, []int{http.StatusRequestedRangeNotSatisfiable}, statusCodes)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath(cacheFilename,
+			vfst.TestContentsString("version 6\n"),
+		),
+		vfst.TestPath(cacheFilename+".partial",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	// Interrupted downloads that are longer than the contents are restarted.
+	contents, etag = []byte("version 7\n"), `"7"`
+	require.NoError(t, fs.WriteFile(cacheFilename+".partial", []byte("version 7\ngarbage\n"), 0600))
+	require.NoError(t, fs.WriteFile(cacheFilename+".partial.json", []byte(`{"etag":"\"7\""}`), 0600))
+	assert.Equal(t, []byte("version 7\n"), download())
+	assert.Equal(t, []int{http.StatusRequestedRangeNotSatisfiable, http.StatusOK}, statusCodes)
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var externalsCmd = &cobra.Command{
+	Use:     "externals",
+	Args:    cobra.NoArgs,
+	Short:   "Manage externals",
+	Long:    mustGetLongHelp("externals"),
+	Example: getExample("externals"),
+}
+
+func init() {
+	rootCmd.AddCommand(externalsCmd)
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var externalsUpdateCmd = &cobra.Command{
+	Use:      "update [targets...]",
+	Short:    "Update the external lock",
+	PreRunE:  config.ensureNoError,
+	RunE:     config.runExternalsUpdateCmd,
+	PostRunE: config.autoCommitAndAutoPush,
+}
+
+// upgradeExternalsCmd is an alias for externals update at the top level, as
+// cobra aliases cannot move a command to a different parent.
+var upgradeExternalsCmd = &cobra.Command{
+	Use:      "upgrade-externals [targets...]",
+	Short:    "Update the external lock, an alias for externals update",
+	Long:     mustGetLongHelp("upgrade-externals"),
+	Example:  getExample("upgrade-externals"),
+	PreRunE:  config.ensureNoError,
+	RunE:     config.runExternalsUpdateCmd,
+	PostRunE: config.autoCommitAndAutoPush,
+}
+
+func init() {
+	externalsCmd.AddCommand(externalsUpdateCmd)
+	rootCmd.AddCommand(upgradeExternalsCmd)
+}
+
+func (c *Config) runExternalsUpdateCmd(cmd *cobra.Command, args []string) error {
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+
+	var externals []*chezmoi.External
+	if len(args) == 0 {
+		for _, entry := range ts.AllEntries() {
+			if external, ok := entry.(*chezmoi.External); ok {
+				externals = append(externals, external)
+			}
+		}
+		sort.Slice(externals, func(i, j int) bool {
+			return externals[i].TargetName() < externals[j].TargetName()
+		})
+	} else {
+		entries, err := c.getEntries(ts, args)
+		if err != nil {
+			return err
+		}
+		for i, entry := range entries {
+			external, ok := entry.(*chezmoi.External)
+			if !ok {
+				return fmt.Errorf("%s: not an external", args[i])
+			}
+			externals = append(externals, external)
+		}
+	}
+
+	for _, external := range externals {
+		oldLock := externalLockDescription(external.Lock)
+		if err := c.updateExternalLock(external); err != nil {
+			return err
+		}
+		if newLock := externalLockDescription(external.Lock); newLock != oldLock {
+			fmt.Fprintf(c.Stdout, "%s: %s -> %s\n", external.TargetName(), oldLock, newLock)
+		}
+	}
+
+	// Locked template values are only updated when no targets are given.
+	if len(args) != 0 {
+		return nil
+	}
+	externalLock, err := c.getExternalLock()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(externalLock.Values))
+	for key := range externalLock.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		oldValue := externalLock.Values[key]
+		components := strings.SplitN(key, " ", 2)
+		switch {
+		case len(components) == 2 && components[0] == "authorizedKeys":
+			if _, err := c.resolveAuthorizedKeys(components[1], true); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		default:
+			return fmt.Errorf("%s: %s: unknown locked value", chezmoi.ExternalLockName, key)
+		}
+		if externalLock.Values[key] != oldValue {
+			fmt.Fprintf(c.Stdout, "%s: updated\n", key)
+		}
+	}
+	return nil
+}
+
+// updateExternalLock locks external to what it currently resolves to. Files
+// and archives are downloaded, bypassing the cache, and git repositories are
+// locked to the commit of the remote HEAD. Files and archives with a checksum
+// in .chezmoiexternal.toml are not locked.
+func (c *Config) updateExternalLock(external *chezmoi.External) error {
+	switch external.Type {
+	case chezmoi.ExternalTypeArchive, chezmoi.ExternalTypeFile:
+		if external.Lock == nil && external.Checksum != "" {
+			return nil
+		}
+		data, err := c.downloadExternalURL(external.URL, func([]byte) error { return nil })
+		if err != nil {
+			return fmt.Errorf("%s: %w", external.TargetName(), err)
+		}
+		dataSHA256 := sha256.Sum256(data)
+		return external.SetLock(&chezmoi.ExternalLockEntry{
+			Type:     external.Type,
+			URL:      external.URL,
+			Checksum: hex.EncodeToString(dataSHA256[:]),
+		})
+	case chezmoi.ExternalTypeGitHubRelease:
+		return c.lockExternalRelease(external, true)
+	case chezmoi.ExternalTypeGitRepo:
+		output, err := c.output("", "git", "ls-remote", external.URL, "HEAD")
+		if err != nil {
+			return fmt.Errorf("%s: %w", external.TargetName(), err)
+		}
+		fields := strings.Fields(string(output))
+		if len(fields) == 0 {
+			return fmt.Errorf("%s: %s: no HEAD", external.TargetName(), external.URL)
+		}
+		return external.SetLock(&chezmoi.ExternalLockEntry{
+			Type:   external.Type,
+			URL:    external.URL,
+			Commit: fields[0],
+		})
+	default:
+		return nil
+	}
+}
+
+// externalLockDescription returns a short description of what entry locks an
+// external to.
+func externalLockDescription(entry *chezmoi.ExternalLockEntry) string {
+	switch {
+	case entry == nil:
+		return "unlocked"
+	case entry.Version != "":
+		return entry.Version
+	case entry.Commit != "":
+		return shortenHash(entry.Commit)
+	default:
+		return "sha256:" + shortenHash(entry.Checksum)
+	}
+}
+
+// shortenHash returns the first twelve characters of hash.
+func shortenHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestExternalsUpdateCmd(t *testing.T) {
+	publicKey1, _ := newTestSSHPublicKey(t)
+	publicKey2, _ := newTestSSHPublicKey(t)
+
+	fileContents := "version 1\n"
+	publicKey := publicKey1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/file":
+			_, _ = w.Write([]byte(fileContents))
+		case "/alice.keys":
+			_, _ = w.Write([]byte(publicKey + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoiexternal.toml": "" +
+				"[\".file\"]\n" +
+				"    type = \"file\"\n" +
+				"    url = \"" + server.URL + "/file\"\n" +
+				"    refreshPeriod = \"1ns\"\n",
+			"dot_keys.tmpl": "{{ authorizedKeys \"test:alice\" }}",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	newTestExternalsUpdateConfig := func() *Config {
+		authorizedKeysCache = make(map[string][]string)
+		stdout.Reset()
+		c := newTestConfig(fs, withStdout(stdout))
+		c.AuthorizedKeys.URLs = map[string]string{
+			"test": server.URL + "/%s.keys",
+		}
+		c.addTemplateFunc("authorizedKeys", c.authorizedKeysFunc)
+		return c
+	}
+	defer func() {
+		authorizedKeysCache = make(map[string][]string)
+	}()
+	checksum := func(contents string) string {
+		contentsSHA256 := sha256.Sum256([]byte(contents))
+		return hex.EncodeToString(contentsSHA256[:])
+	}
+	testTargets := func(fileContents, publicKey string) {
+		vfst.RunTests(t, fs, "",
+			vfst.TestPath("/home/user/.file",
+				vfst.TestContentsString(fileContents),
+			),
+			vfst.TestPath("/home/user/.keys",
+				vfst.TestContentsString("# test:alice\n"+publicKey+"\n"),
+			),
+		)
+	}
+
+	// The first apply locks the external and the keys.
+	require.NoError(t, newTestExternalsUpdateConfig().runApplyCmd(nil, nil))
+	testTargets("version 1\n", publicKey1)
+	externalLock, err := chezmoi.ReadExternalLock(fs, "/home/user/.local/share/chezmoi")
+	require.NoError(t, err)
+	assert.Equal(t, &chezmoi.ExternalLock{
+		Externals: map[string]*chezmoi.ExternalLockEntry{
+			".file": {
+				Type:     "file",
+				URL:      server.URL + "/file",
+				Checksum: checksum("version 1\n"),
+			},
+		},
+		Values: map[string]string{
+			"authorizedKeys test:alice": publicKey1 + "\n",
+		},
+	}, externalLock)
+
+	// Locked externals are not refreshed and locked keys are not fetched.
+	fileContents = "version 2\n"
+	publicKey = publicKey2
+	require.NoError(t, newTestExternalsUpdateConfig().runApplyCmd(nil, nil))
+	testTargets("version 1\n", publicKey1)
+
+	// externals update updates the lock, but not the targets.
+	c := newTestExternalsUpdateConfig()
+	require.NoError(t, c.runExternalsUpdateCmd(nil, nil))
+	assert.Equal(t, ""+
+		".file: sha256:"+checksum("version 1\n")[:12]+" -> sha256:"+checksum("version 2\n")[:12]+"\n"+
+		"authorizedKeys test:alice: updated\n",
+		stdout.String())
+	testTargets("version 1\n", publicKey1)
+
+	require.NoError(t, newTestExternalsUpdateConfig().runApplyCmd(nil, nil))
+	testTargets("version 2\n", publicKey2)
+
+	// Updating again changes nothing.
+	c = newTestExternalsUpdateConfig()
+	require.NoError(t, c.runExternalsUpdateCmd(nil, []string{"/home/user/.file"}))
+	assert.Equal(t, "", stdout.String())
+
+	c = newTestExternalsUpdateConfig()
+	assert.Error(t, c.runExternalsUpdateCmd(nil, []string{"/home/user/.keys"}))
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// globalFlagEnvVars maps global flags to the environment variables that set
+// them.
+var globalFlagEnvVars = map[string]string{
+	"color":       "CHEZMOI_COLOR",
+	"config":      "CHEZMOI_CONFIG",
+	"debug":       "CHEZMOI_DEBUG",
+	"destination": "CHEZMOI_DESTINATION",
+	"dry-run":     "CHEZMOI_DRY_RUN",
+	"follow":      "CHEZMOI_FOLLOW",
+	"remove":      "CHEZMOI_REMOVE",
+	"source":      "CHEZMOI_SOURCE_DIR",
+	"verbose":     "CHEZMOI_VERBOSE",
+}
+
+// childEnvFlags are the global flags whose environment variables chezmoi also
+// sets for its child processes, see getChildEnv.
+var childEnvFlags = map[string]bool{
+	"destination": true,
+	"source":      true,
+}
+
+// setFlagsFromEnv sets every flag in flags that was not set on the command
+// line and has a non-empty environment variable in globalFlagEnvVars. Flags
+// set this way are marked as changed, so they override the config file just
+// like command line flags. If chezmoi is run by chezmoi, as indicated by
+// $CHEZMOI, then the flags in childEnvFlags are not set, as their environment
+// variables describe the parent chezmoi.
+func setFlagsFromEnv(flags *pflag.FlagSet, getenv func(string) string) error {
+	nested := getenv("CHEZMOI") == "1"
+	for name, key := range globalFlagEnvVars {
+		flag := flags.Lookup(name)
+		if flag == nil || flag.Changed || nested && childEnvFlags[name] {
+			continue
+		}
+		value := getenv(key)
+		if value == "" {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
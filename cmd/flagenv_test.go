@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFlagsFromEnv(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		wantSource  string
+		wantVerbose bool
+		wantChanged bool
+		wantErr     bool
+	}{
+		{
+			name:       "default",
+			wantSource: "/default",
+		},
+		{
+			name: "env",
+			env: map[string]string{
+				"CHEZMOI_SOURCE_DIR": "/env",
+				"CHEZMOI_VERBOSE":    "1",
+			},
+			wantSource:  "/env",
+			wantVerbose: true,
+			wantChanged: true,
+		},
+		{
+			name: "flag_overrides_env",
+			args: []string{"--source", "/flag"},
+			env: map[string]string{
+				"CHEZMOI_SOURCE_DIR": "/env",
+			},
+			wantSource:  "/flag",
+			wantChanged: true,
+		},
+		{
+			name: "nested",
+			env: map[string]string{
+				"CHEZMOI":            "1",
+				"CHEZMOI_SOURCE_DIR": "/parent",
+				"CHEZMOI_VERBOSE":    "1",
+			},
+			wantSource:  "/default",
+			wantVerbose: true,
+		},
+		{
+			name: "invalid_bool",
+			env: map[string]string{
+				"CHEZMOI_VERBOSE": "maybe",
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("chezmoi", pflag.ContinueOnError)
+			source := flags.StringP("source", "S", "/default", "")
+			verbose := flags.BoolP("verbose", "v", false, "")
+			require.NoError(t, flags.Parse(tc.args))
+			getenv := func(key string) string {
+				return tc.env[key]
+			}
+			err := setFlagsFromEnv(flags, getenv)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantSource, *source)
+			assert.Equal(t, tc.wantVerbose, *verbose)
+			assert.Equal(t, tc.wantChanged, flags.Lookup("source").Changed)
+		})
+	}
+}
@@ -0,0 +1,485 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+	"gopkg.in/yaml.v2"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var fleetCmd = &cobra.Command{
+	Use:     "fleet",
+	Args:    cobra.NoArgs,
+	Short:   "Apply the target state to multiple remote hosts",
+	Long:    mustGetLongHelp("fleet"),
+	Example: getExample("fleet"),
+}
+
+var fleetApplyCmd = &cobra.Command{
+	Use:     "apply",
+	Args:    cobra.NoArgs,
+	Short:   "Apply the target state to the hosts in a hosts file over ssh",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runFleetApplyCmd,
+}
+
+type fleetCmdConfig struct {
+	SSHCommand string
+	hosts      string
+	logDir     string
+	parallel   int
+	report     string
+}
+
+// A fleetHostsFile is the contents of a fleet hosts file.
+type fleetHostsFile struct {
+	Hosts []fleetHost `yaml:"hosts"`
+}
+
+// A fleetHost is a single remote host.
+type fleetHost struct {
+	Name    string                 `yaml:"name"`
+	Address string                 `yaml:"address"`
+	DestDir string                 `yaml:"destDir"`
+	Data    map[string]interface{} `yaml:"data"`
+}
+
+// A fleetReport summarizes the result of applying the target state to hosts.
+type fleetReport struct {
+	Time   time.Time          `json:"time"`
+	DryRun bool               `json:"dryRun"`
+	Hosts  []*fleetHostReport `json:"hosts"`
+}
+
+// A fleetHostReport summarizes the result of applying the target state to a
+// single host.
+type fleetHostReport struct {
+	Name       string   `json:"name"`
+	Address    string   `json:"address"`
+	Written    []string `json:"written"`
+	Removed    []string `json:"removed"`
+	ScriptsRun []string `json:"scriptsRun"`
+	Error      string   `json:"error,omitempty"`
+	Log        string   `json:"log,omitempty"`
+}
+
+func init() {
+	rootCmd.AddCommand(fleetCmd)
+	fleetCmd.AddCommand(fleetApplyCmd)
+
+	persistentFlags := fleetApplyCmd.PersistentFlags()
+
+	persistentFlags.StringVar(&config.Fleet.hosts, "hosts", "", "hosts file")
+	panicOnError(fleetApplyCmd.MarkPersistentFlagRequired("hosts"))
+	panicOnError(fleetApplyCmd.MarkPersistentFlagFilename("hosts", "yaml", "yml"))
+
+	persistentFlags.StringVar(&config.Fleet.logDir, "log-dir", "", "write per-host logs to dir")
+	panicOnError(fleetApplyCmd.MarkPersistentFlagDirname("log-dir"))
+
+	persistentFlags.IntVar(&config.Fleet.parallel, "parallel", 4, "number of hosts to apply to concurrently")
+
+	persistentFlags.StringVar(&config.Fleet.report, "report", "", "write a JSON report to file")
+	panicOnError(fleetApplyCmd.MarkPersistentFlagFilename("report"))
+}
+
+func (c *Config) runFleetApplyCmd(cmd *cobra.Command, args []string) error {
+	if runtime.GOOS == "windows" {
+		return errors.New("fleet apply is not supported on windows")
+	}
+	hosts, err := c.getFleetHosts(c.Fleet.hosts)
+	if err != nil {
+		return err
+	}
+	if c.Fleet.parallel < 1 {
+		return fmt.Errorf("%d: invalid --parallel", c.Fleet.parallel)
+	}
+
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	// Connect to every host and render its target state before changing any
+	// of them, so that template errors are reported before anything is
+	// changed and template functions are never called concurrently. Hosts
+	// that cannot be connected to fail without stopping the others.
+	remoteDestinations := make([]*remoteDestination, len(hosts))
+	defer func() {
+		for _, d := range remoteDestinations {
+			if d != nil {
+				d.close()
+			}
+		}
+	}()
+	targetStates := make([]*chezmoi.TargetState, len(hosts))
+	hostReports := make([]*fleetHostReport, len(hosts))
+	for i, host := range hosts {
+		hostReports[i] = &fleetHostReport{
+			Name:       host.Name,
+			Address:    host.Address,
+			Written:    []string{},
+			Removed:    []string{},
+			ScriptsRun: []string{},
+		}
+		d, destDir, err := c.connectFleetHost(host)
+		if err != nil {
+			hostReports[i].Error = err.Error()
+			continue
+		}
+		remoteDestinations[i] = d
+		targetStates[i], err = c.getFleetTargetState(host, d, destDir)
+		if err != nil {
+			return fmt.Errorf("%s: %w", host.Name, err)
+		}
+	}
+
+	if c.Fleet.logDir != "" && !c.DryRun {
+		if err := vfs.MkdirAll(c.fs, c.Fleet.logDir, 0777); err != nil {
+			return err
+		}
+	}
+
+	// Stop between entries if chezmoi is interrupted.
+	ctx, release := newInterruptContext()
+	defer release()
+
+	lockedPersistentState := &lockedPersistentState{
+		PersistentState: persistentState,
+	}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, c.Fleet.parallel)
+	for i := range hosts {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hostReport := hostReports[i]
+			var output []byte
+			if hostReport.Error == "" {
+				semaphore <- struct{}{}
+				var err error
+				output, err = c.applyFleetHost(ctx, remoteDestinations[i], targetStates[i], lockedPersistentState, hostReport)
+				<-semaphore
+				if err != nil {
+					hostReport.Error = err.Error()
+				}
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			_, _ = c.Stdout.Write(output)
+			if hostReport.Error != "" {
+				fmt.Fprintf(c.Stdout, "%s: error: %s\n", hosts[i].Name, hostReport.Error)
+			} else {
+				fmt.Fprintf(c.Stdout, "%s: ok, written: %d, removed: %d, scripts run: %d\n", hosts[i].Name, len(hostReport.Written), len(hostReport.Removed), len(hostReport.ScriptsRun))
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, hostReport := range hostReports {
+		if hostReport.Error != "" {
+			failed++
+		}
+	}
+	fmt.Fprintf(c.Stdout, "hosts: %d, ok: %d, failed: %d\n", len(hosts), len(hosts)-failed, failed)
+
+	if c.Fleet.report != "" {
+		data, err := json.MarshalIndent(&fleetReport{
+			Time:   time.Now().UTC(),
+			DryRun: c.DryRun,
+			Hosts:  hostReports,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := c.fs.WriteFile(c.Fleet.report, append(data, '\n'), 0666); err != nil {
+			return err
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d of %d hosts failed", failed, len(hosts))
+	}
+	return nil
+}
+
+// getFleetHosts returns the hosts in the hosts file at path.
+func (c *Config) getFleetHosts(path string) ([]fleetHost, error) {
+	data, err := c.fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var hostsFile fleetHostsFile
+	if err := yaml.UnmarshalStrict(data, &hostsFile); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	names := make(map[string]bool, len(hostsFile.Hosts))
+	for i := range hostsFile.Hosts {
+		host := &hostsFile.Hosts[i]
+		switch {
+		case host.Name == "":
+			return nil, fmt.Errorf("%s: host %d: missing name", path, i+1)
+		case names[host.Name]:
+			return nil, fmt.Errorf("%s: %s: duplicate host", path, host.Name)
+		}
+		names[host.Name] = true
+		if host.Address == "" {
+			host.Address = host.Name
+		}
+		// Names and addresses are passed to ssh, which would interpret a
+		// leading - as an option.
+		switch {
+		case strings.HasPrefix(host.Name, "-"):
+			return nil, fmt.Errorf("%s: %s: invalid name", path, host.Name)
+		case strings.HasPrefix(host.Address, "-"):
+			return nil, fmt.Errorf("%s: %s: %s: invalid address", path, host.Name, host.Address)
+		}
+		for key, value := range host.Data {
+			host.Data[key] = normalizeYAMLValue(value)
+		}
+	}
+	if len(hostsFile.Hosts) == 0 {
+		return nil, fmt.Errorf("%s: no hosts", path)
+	}
+	return hostsFile.Hosts, nil
+}
+
+// connectFleetHost connects to host and returns the remote destination and the
+// absolute path of the host's destination directory.
+func (c *Config) connectFleetHost(host fleetHost) (*remoteDestination, string, error) {
+	d, err := newSSHRemoteDestination(c.Fleet.SSHCommand, nil, host.Address)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := d.getFacts(); err != nil {
+		d.close()
+		return nil, "", err
+	}
+	destDir, err := d.absDir(host.DestDir)
+	if err != nil {
+		d.close()
+		return nil, "", err
+	}
+	d.mutator = chezmoi.NewRemoteMutator(d.fs)
+	return d, destDir, nil
+}
+
+// getFleetData returns the template data for host, connected to with d. The
+// .chezmoi variables describe the remote host, except that the
+// .chezmoi.fullHostname, .chezmoi.fqdnHostname, and .chezmoi.hostname
+// variables are set from the host's name, which also selects the host data
+// file, and values in the host data's chezmoi key override the automatically
+// populated .chezmoi variables.
+func (c *Config) getFleetData(host fleetHost, d *remoteDestination) (map[string]interface{}, error) {
+	defaultData, err := c.getDefaultData()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.setRemoteData(defaultData); err != nil {
+		return nil, err
+	}
+	defaultData["fullHostname"] = host.Name
+	defaultData["fqdnHostname"] = host.Name
+	defaultData["hostname"] = strings.SplitN(host.Name, ".", 2)[0]
+	data, err := c.getHostData(defaultData)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range c.Data {
+		data[key] = value
+	}
+	sopsData, err := c.getSOPSData()
+	if err != nil {
+		return nil, err
+	}
+	if sopsData != nil {
+		data["sops"] = sopsData
+	}
+	for key, value := range host.Data {
+		data[key] = value
+	}
+	if chezmoiData, ok := host.Data["chezmoi"].(map[string]interface{}); ok {
+		for key, value := range chezmoiData {
+			defaultData[key] = value
+		}
+	}
+	data["chezmoi"] = defaultData
+	if err := setDataOverrides(data, os.Environ(), c.dataOverrides); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// getFleetTargetState returns host's target state in destDir on the remote
+// host, with all templates executed and all externals downloaded.
+func (c *Config) getFleetTargetState(host fleetHost, d *remoteDestination, destDir string) (*chezmoi.TargetState, error) {
+	oldDestDir := c.DestDir
+	c.DestDir = destDir
+	defer func() {
+		c.DestDir = oldDestDir
+	}()
+
+	data, err := c.getFleetData(host, d)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := c.getTargetStateWithData(vfs.NewReadOnlyFS(c.fs), c.SourceDir, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.Evaluate(); err != nil {
+		return nil, err
+	}
+	if err := checkRemoteExternals(ts); err != nil {
+		return nil, err
+	}
+	for _, entry := range ts.AllEntries() {
+		external, ok := entry.(*chezmoi.External)
+		if !ok || ts.TargetIgnore.Match(external.TargetName()) {
+			continue
+		}
+		externalEntry, err := external.Entry()
+		if err != nil {
+			return nil, err
+		}
+		if err := externalEntry.Evaluate(ts.TargetIgnore.Match); err != nil {
+			return nil, err
+		}
+	}
+	return ts, nil
+}
+
+// applyFleetHost applies ts to the remote host d, recording what changed in
+// hostReport, and returns the output of applying it. Scripts are run on the
+// remote host. The output is written to a per-host log instead if a log
+// directory is set.
+func (c *Config) applyFleetHost(ctx context.Context, d *remoteDestination, ts *chezmoi.TargetState, persistentState chezmoi.PersistentState, hostReport *fleetHostReport) ([]byte, error) {
+	output := &bytes.Buffer{}
+	var mutator chezmoi.Mutator = d.mutator
+	if c.DryRun {
+		mutator = chezmoi.NullMutator{}
+	}
+	if c.Verbose {
+		mutator = chezmoi.NewVerboseMutator(output, mutator, false, c.maxDiffDataSize)
+	}
+	recordingMutator := chezmoi.NewRecordingMutator(mutator)
+
+	// As with ssh, the destination directory is created if needed.
+	if !c.DryRun {
+		if _, err := d.fs.Output(nil, `mkdir -p -- "$1"`, ts.DestDir); err != nil {
+			return nil, err
+		}
+	}
+
+	report := newApplyReport(c.DryRun)
+	report.addTargets(ts, ts.AllEntries())
+	applyErr := ts.Apply(vfs.NewReadOnlyFS(d.fs), recordingMutator, c.Follow, &chezmoi.ApplyOptions{
+		Context: ctx,
+		DestDir: ts.DestDir,
+		DryRun:  c.DryRun,
+		FIFOs:   c.FIFOs,
+		Ignore:  ts.TargetIgnore.Match,
+		// Scripts run concurrently on many hosts, so their output goes to the
+		// host's output and they cannot read from chezmoi's standard input.
+		NewScriptCmd: func(name string, contents []byte, dir string, env []string) (*exec.Cmd, func(), error) {
+			cmd, cleanup, err := d.mutator.NewScriptCmd(name, contents, dir, env)
+			if err != nil {
+				return nil, nil, err
+			}
+			cmd.Stdin = bytes.NewReader(nil)
+			cmd.Stdout = output
+			cmd.Stderr = output
+			return cmd, cleanup, nil
+		},
+		OnRunScript:       report.addScriptRun,
+		PersistentState:   persistentState,
+		ScriptStateBucket: append([]byte(string(c.scriptStateBucket)+":"), d.name...),
+		Stdout:            output,
+		Umask:             ts.Umask,
+		Verbose:           c.Verbose,
+	})
+
+	changedTargetPaths := make([]string, 0, len(recordingMutator.Names()))
+	for name := range recordingMutator.Names() {
+		changedTargetPaths = append(changedTargetPaths, name)
+	}
+	sort.Strings(changedTargetPaths)
+	report.finish(changedTargetPaths, nil, false)
+	hostReport.Written = fleetTargetNames(ts.DestDir, report.Written)
+	hostReport.Removed = fleetTargetNames(ts.DestDir, report.Removed)
+	hostReport.ScriptsRun = fleetTargetNames(ts.DestDir, report.ScriptsRun)
+
+	if c.Fleet.logDir != "" && !c.DryRun {
+		hostReport.Log = filepath.Join(c.Fleet.logDir, hostReport.Name+".log")
+		if err := c.fs.WriteFile(hostReport.Log, output.Bytes(), 0666); err != nil {
+			return nil, err
+		}
+		return nil, applyErr
+	}
+	return output.Bytes(), applyErr
+}
+
+// fleetTargetNames returns the names of targetPaths relative to destDir.
+func fleetTargetNames(destDir string, targetPaths []string) []string {
+	targetNames := make([]string, 0, len(targetPaths))
+	for _, targetPath := range targetPaths {
+		targetName, err := filepath.Rel(destDir, targetPath)
+		if err != nil {
+			targetName = targetPath
+		}
+		targetNames = append(targetNames, filepath.ToSlash(targetName))
+	}
+	return targetNames
+}
+
+// A lockedPersistentState is a PersistentState that is safe for concurrent use.
+type lockedPersistentState struct {
+	sync.Mutex
+	chezmoi.PersistentState
+}
+
+// Delete implements PersistentState.Delete.
+func (s *lockedPersistentState) Delete(bucket, key []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.PersistentState.Delete(bucket, key)
+}
+
+// ForEach implements PersistentState.ForEach.
+func (s *lockedPersistentState) ForEach(bucket []byte, fn func(k, v []byte) error) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.PersistentState.ForEach(bucket, fn)
+}
+
+// Get implements PersistentState.Get.
+func (s *lockedPersistentState) Get(bucket, key []byte) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.PersistentState.Get(bucket, key)
+}
+
+// Set implements PersistentState.Set.
+func (s *lockedPersistentState) Set(bucket, key, value []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.PersistentState.Set(bucket, key, value)
+}
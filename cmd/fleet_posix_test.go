@@ -0,0 +1,180 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestFleetApply(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/bin/ssh": &vfst.File{
+			Perm:     0755,
+			Contents: []byte("#!/bin/sh\nwhile [ $# -gt 1 ]; do shift; done\nexec sh -c \"$1\"\n"),
+		},
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"dot_bashrc":                               "bashrc",
+			".chezmoidata/hosts/db1.yaml":              "port: 5432\nrole: ignored\n",
+			".chezmoidata/hosts/web1.example.com.yaml": "port: 80\n",
+			"dot_gitconfig.tmpl":                       "{{ .chezmoi.hostname }} {{ .role }} {{ .chezmoi.os }} {{ .port }}\n",
+			"private_dot_ssh/keys":                     "keys",
+			"exact_dot_config/app":                     "app",
+			"run_install.sh":                           "#!/bin/sh\ntouch installed\n",
+		},
+		"/hosts/web1/.config/stale": "stale",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	hostsDir, err := fs.RawPath("/hosts")
+	require.NoError(t, err)
+	ssh, err := fs.RawPath("/bin/ssh")
+	require.NoError(t, err)
+	require.NoError(t, fs.WriteFile("/home/user/hosts.yaml", []byte(""+
+		"hosts:\n"+
+		"- name: web1.example.com\n"+
+		"  destDir: "+filepath.Join(hostsDir, "web1")+"\n"+
+		"  data:\n"+
+		"    role: web\n"+
+		"- name: db1\n"+
+		"  destDir: "+filepath.Join(hostsDir, "db1")+"\n"+
+		"  data:\n"+
+		"    role: db\n"+
+		"    chezmoi:\n"+
+		"      os: plan9\n",
+	), 0666))
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs,
+		withFleetCmdConfig(fleetCmdConfig{
+			SSHCommand: ssh,
+			hosts:      "/home/user/hosts.yaml",
+			logDir:     "/home/user/logs",
+			parallel:   2,
+			report:     "/home/user/report.json",
+		}),
+		withStdout(stdout),
+	)
+	c.Verbose = false
+
+	// A dry run reports what would change without changing anything.
+	c.DryRun = true
+	require.NoError(t, c.runFleetApplyCmd(nil, nil), stdout.String())
+	assert.Contains(t, stdout.String(), "web1.example.com: ok, written: 5, removed: 1, scripts run: 0\n")
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/hosts/web1/.bashrc",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/hosts/db1",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	stdout.Reset()
+	c.DryRun = false
+	require.NoError(t, c.runFleetApplyCmd(nil, nil), stdout.String())
+	assert.Contains(t, stdout.String(), "web1.example.com: ok, written: 5, removed: 1, scripts run: 1\n")
+	assert.Contains(t, stdout.String(), "db1: ok, written: 6, removed: 0, scripts run: 1\n")
+	assert.Contains(t, stdout.String(), "hosts: 2, ok: 2, failed: 0\n")
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/hosts/web1/.bashrc",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0644),
+			vfst.TestContentsString("bashrc"),
+		),
+		vfst.TestPath("/hosts/web1/.gitconfig",
+			vfst.TestContentsString("web1 web "+runtime.GOOS+" 80\n"),
+		),
+		vfst.TestPath("/hosts/web1/.ssh",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0700),
+		),
+		vfst.TestPath("/hosts/web1/.config/app",
+			vfst.TestContentsString("app"),
+		),
+		vfst.TestPath("/hosts/web1/.config/stale",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/hosts/web1/install.sh",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/hosts/web1/installed",
+			vfst.TestModeIsRegular,
+		),
+		vfst.TestPath("/hosts/db1/.gitconfig",
+			vfst.TestContentsString("db1 db plan9 5432\n"),
+		),
+		vfst.TestPath("/home/user/logs/db1.log",
+			vfst.TestModeIsRegular,
+		),
+	)
+
+	data, err := fs.ReadFile("/home/user/report.json")
+	require.NoError(t, err)
+	var report fleetReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Hosts, 2)
+	assert.Equal(t, "db1", report.Hosts[1].Name)
+	assert.Equal(t, "db1", report.Hosts[1].Address)
+	assert.Equal(t, []string{".bashrc", ".config", ".config/app", ".gitconfig", ".ssh", ".ssh/keys"}, report.Hosts[1].Written)
+	assert.Equal(t, []string{"install.sh"}, report.Hosts[1].ScriptsRun)
+	assert.Equal(t, []string{".config/stale"}, report.Hosts[0].Removed)
+	assert.Equal(t, "", report.Hosts[1].Error)
+
+	// Applying again only reports what changed.
+	stdout.Reset()
+	require.NoError(t, c.runFleetApplyCmd(nil, nil), stdout.String())
+	assert.Contains(t, stdout.String(), "web1.example.com: ok, written: 0, removed: 0, scripts run: 1\n")
+	assert.Contains(t, stdout.String(), "db1: ok, written: 0, removed: 0, scripts run: 1\n")
+}
+
+func TestFleetApplyFailure(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/dot_bashrc": "bashrc",
+		"/home/user/hosts.yaml":                      "hosts:\n- name: web1\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs,
+		withFleetCmdConfig(fleetCmdConfig{
+			SSHCommand: "false",
+			hosts:      "/home/user/hosts.yaml",
+			parallel:   1,
+		}),
+		withStdout(stdout),
+	)
+	assert.EqualError(t, c.runFleetApplyCmd(nil, nil), "1 of 1 hosts failed")
+	assert.Contains(t, stdout.String(), "hosts: 1, ok: 0, failed: 1\n")
+}
+
+func TestFleetApplyHostsErrors(t *testing.T) {
+	for name, hosts := range map[string]string{
+		"duplicate_host":  "hosts:\n- name: web1\n- name: web1\n",
+		"invalid_address": "hosts:\n- name: web1\n  address: -oProxyCommand=touch /tmp/x\n",
+		"invalid_name":    "hosts:\n- name: -oProxyCommand=touch /tmp/x\n",
+		"missing_name":    "hosts:\n- address: web1\n",
+		"no_hosts":        "hosts: []\n",
+		"unknown_key":     "hosts:\n- name: web1\n  port: 22\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/hosts.yaml": hosts,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(fs)
+			_, err = c.getFleetHosts("/home/user/hosts.yaml")
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+	bolt "go.etcd.io/bbolt"
+)
+
+var gcCmd = &cobra.Command{
+	Use:     "gc",
+	Args:    cobra.NoArgs,
+	Short:   "Remove stale cache entries and orphaned state",
+	Long:    mustGetLongHelp("gc"),
+	Example: getExample("gc"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runGCCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+}
+
+func (c *Config) runGCCmd(cmd *cobra.Command, args []string) error {
+	var total int64
+
+	// Remove stale authorized keys cache entries and the least recently used
+	// externals cache entries.
+	staleCacheFilenames, err := c.getStaleAuthorizedKeysCacheFilenames()
+	if err != nil {
+		return err
+	}
+	evictedCacheFilenames, err := c.getEvictedExternalCacheFilenames()
+	if err != nil {
+		return err
+	}
+	for _, filename := range append(staleCacheFilenames, evictedCacheFilenames...) {
+		info, err := c.fs.Lstat(filename)
+		if err != nil {
+			return err
+		}
+		if err := c.mutator.RemoveAll(filename); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Stdout, "cache: %s: %d bytes\n", filename, info.Size())
+		total += info.Size()
+	}
+
+	var options *bolt.Options
+	if c.DryRun {
+		options = &bolt.Options{
+			ReadOnly: true,
+		}
+	}
+	persistentState, err := c.getPersistentState(options)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+
+	// Remove blobs that no source file points to, and any blob store
+	// directories that are left empty.
+	unreferencedBlobPaths, err := ts.UnreferencedBlobs(c.fs)
+	if err != nil {
+		return err
+	}
+	blobDirs := make(map[string]struct{})
+	for _, path := range unreferencedBlobPaths {
+		info, err := c.fs.Lstat(path)
+		if err != nil {
+			return err
+		}
+		if err := c.mutator.RemoveAll(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Stdout, "blobs: %s: %d bytes\n", path, info.Size())
+		total += info.Size()
+		blobDirs[filepath.Dir(path)] = struct{}{}
+	}
+	for _, dir := range sortedKeys(blobDirs) {
+		if infos, err := c.fs.ReadDir(dir); err == nil && len(infos) == 0 {
+			if err := c.mutator.RemoveAll(dir); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Remove orphaned run once script state.
+	orphanedScriptStateKeys, err := c.getOrphanedScriptStateKeys(ts, persistentState)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(orphanedScriptStateKeys))
+	for name := range orphanedScriptStateKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var size int64
+		for _, key := range orphanedScriptStateKeys[name] {
+			value, err := persistentState.Get(c.scriptStateBucket, key)
+			if err != nil {
+				return err
+			}
+			if !c.DryRun {
+				if err := persistentState.Delete(c.scriptStateBucket, key); err != nil {
+					return err
+				}
+			}
+			size += int64(len(key) + len(value))
+		}
+		fmt.Fprintf(c.Stdout, "scripts: %s: %d bytes\n", name, size)
+		total += size
+	}
+
+	fmt.Fprintf(c.Stdout, "total: %d bytes\n", total)
+	return nil
+}
+
+// getStaleAuthorizedKeysCacheFilenames returns the sorted filenames of all
+// authorized keys cache entries that are older than the refresh period.
+func (c *Config) getStaleAuthorizedKeysCacheFilenames() ([]string, error) {
+	cacheDir := filepath.Join(c.bds.CacheHome, "chezmoi", "authorizedkeys")
+	var filenames []string
+	if err := vfs.Walk(c.fs, cacheDir, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case os.IsNotExist(err):
+			return nil
+		case err != nil:
+			return err
+		case info.IsDir():
+			return nil
+		case c.AuthorizedKeys.RefreshPeriod > 0 && time.Since(info.ModTime()) <= c.AuthorizedKeys.RefreshPeriod:
+			return nil
+		}
+		filenames = append(filenames, path)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return filenames, nil
+}
+
+// getEvictedExternalCacheFilenames returns the filenames of the least recently
+// used externals cache entries that must be removed to reduce the size of the
+// externals cache to externals.maxCacheSize, least recently used first.
+func (c *Config) getEvictedExternalCacheFilenames() ([]string, error) {
+	maxCacheSize, err := parseByteSize(c.Externals.MaxCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("externals.maxCacheSize: %w", err)
+	}
+	if maxCacheSize <= 0 {
+		return nil, nil
+	}
+
+	// Group the data and metadata files of each cache entry.
+	type cacheEntry struct {
+		filenames []string
+		size      int64
+		lastUsed  time.Time
+	}
+	entriesByName := make(map[string]*cacheEntry)
+	cacheDir := filepath.Join(c.bds.CacheHome, "chezmoi", "external")
+	if err := vfs.Walk(c.fs, cacheDir, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case os.IsNotExist(err):
+			return nil
+		case err != nil:
+			return err
+		case info.IsDir():
+			return nil
+		}
+		name := strings.TrimSuffix(path, ".json")
+		entry, ok := entriesByName[name]
+		if !ok {
+			entry = &cacheEntry{}
+			entriesByName[name] = entry
+		}
+		entry.filenames = append(entry.filenames, path)
+		entry.size += info.Size()
+		if path == name {
+			entry.lastUsed = info.ModTime()
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	entries := make([]*cacheEntry, 0, len(entriesByName))
+	for name, entry := range entriesByName {
+		if lastUsed := c.readExternalCacheMetadata(name).LastUsed; !lastUsed.IsZero() {
+			entry.lastUsed = lastUsed
+		}
+		sort.Strings(entry.filenames)
+		entries = append(entries, entry)
+	}
+
+	// Keep the most recently used entries that fit.
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastUsed.After(entries[j].lastUsed)
+	})
+	var size int64
+	var filenames []string
+	for i, entry := range entries {
+		size += entry.size
+		if size <= maxCacheSize {
+			continue
+		}
+		for j := len(entries) - 1; j >= i; j-- {
+			filenames = append(filenames, entries[j].filenames...)
+		}
+		break
+	}
+	return filenames, nil
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestGCCmd(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		dryRun         bool
+		expectedStdout string
+		tests          interface{}
+	}{
+		{
+			name: "gc",
+			expectedStdout: "" +
+				"cache: /home/user/.cache/chezmoi/authorizedkeys/github/bob: 3 bytes\n" +
+				"scripts: bar.sh: 25 bytes\n" +
+				"total: 28 bytes\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.cache/chezmoi/authorizedkeys/github/alice",
+					vfst.TestModeIsRegular,
+				),
+				vfst.TestPath("/home/user/.cache/chezmoi/authorizedkeys/github/bob",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name:   "dry_run",
+			dryRun: true,
+			expectedStdout: "" +
+				"cache: /home/user/.cache/chezmoi/authorizedkeys/github/bob: 3 bytes\n" +
+				"scripts: bar.sh: 25 bytes\n" +
+				"total: 28 bytes\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.cache/chezmoi/authorizedkeys/github/bob",
+					vfst.TestModeIsRegular,
+				),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".cache/chezmoi/authorizedkeys/github": map[string]interface{}{
+						"alice": "key",
+						"bob":   "key",
+					},
+					".local/share/chezmoi/run_once_foo.sh": "#!/bin/sh\n",
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			old := time.Now().Add(-2 * time.Hour)
+			require.NoError(t, fs.Chtimes("/home/user/.cache/chezmoi/authorizedkeys/github/bob", old, old))
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			c.AuthorizedKeys.RefreshPeriod = time.Hour
+			persistentState, err := c.getPersistentState(nil)
+			require.NoError(t, err)
+			require.NoError(t, persistentState.Set(c.scriptStateBucket, []byte("foo.sh:0123456789abcdef"), []byte("{}")))
+			require.NoError(t, persistentState.Set(c.scriptStateBucket, []byte("bar.sh:0123456789abcdef"), []byte("{}")))
+			require.NoError(t, persistentState.Close())
+
+			if tc.dryRun {
+				c.mutator = chezmoi.NullMutator{}
+				c.DryRun = true
+			}
+			require.NoError(t, c.runGCCmd(nil, nil))
+			assert.Equal(t, tc.expectedStdout, stdout.String())
+			vfst.RunTests(t, fs, "", tc.tests)
+
+			persistentState, err = c.getPersistentState(nil)
+			require.NoError(t, err)
+			defer persistentState.Close()
+			value, err := persistentState.Get(c.scriptStateBucket, []byte("bar.sh:0123456789abcdef"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.dryRun, value != nil)
+		})
+	}
+}
+
+func TestGCCmdExternalsCache(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	lastUsedJSON := `{"lastUsed":"` + now.Format(time.RFC3339) + `"}`
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".cache/chezmoi/external": map[string]interface{}{
+				"a":      "0123456789",
+				"b":      "0123456789",
+				"c":      "0123456789",
+				"d":      "0123456789",
+				"d.json": lastUsedJSON,
+			},
+			".local/share/chezmoi": &vfst.Dir{Perm: 0755},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	for name, age := range map[string]time.Duration{
+		"a": 1 * time.Hour,
+		"b": 2 * time.Hour,
+		"c": 3 * time.Hour,
+		"d": 4 * time.Hour,
+	} {
+		modTime := now.Add(-age)
+		require.NoError(t, fs.Chtimes("/home/user/.cache/chezmoi/external/"+name, modTime, modTime))
+	}
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs, withStdout(stdout))
+	c.Externals.MaxCacheSize = strconv.Itoa(10 + len(lastUsedJSON) + 15)
+	require.NoError(t, c.runGCCmd(nil, nil))
+	assert.Equal(t, ""+
+		"cache: /home/user/.cache/chezmoi/external/c: 10 bytes\n"+
+		"cache: /home/user/.cache/chezmoi/external/b: 10 bytes\n"+
+		"total: 20 bytes\n",
+		stdout.String())
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.cache/chezmoi/external/a",
+			vfst.TestModeIsRegular,
+		),
+		vfst.TestPath("/home/user/.cache/chezmoi/external/b",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.cache/chezmoi/external/c",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.cache/chezmoi/external/d",
+			vfst.TestModeIsRegular,
+		),
+	)
+}
+
+func TestGCCmdBlobs(t *testing.T) {
+	keptSHA256 := sha256.Sum256([]byte("kept"))
+	kept := hex.EncodeToString(keptSHA256[:])
+	removedSHA256 := sha256.Sum256([]byte("removed"))
+	removed := hex.EncodeToString(removedSHA256[:])
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoiblobs": map[string]interface{}{
+				kept[:2] + "/" + kept:       "kept",
+				removed[:2] + "/" + removed: "removed",
+			},
+			"dot_file": "chezmoi-blob sha256:" + kept + "\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs, withStdout(stdout))
+	require.NoError(t, c.runGCCmd(nil, nil))
+	assert.Equal(t, ""+
+		"blobs: /home/user/.local/share/chezmoi/.chezmoiblobs/"+removed[:2]+"/"+removed+": 7 bytes\n"+
+		"total: 7 bytes\n",
+		stdout.String())
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/.chezmoiblobs/"+kept[:2]+"/"+kept,
+			vfst.TestContentsString("kept"),
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/.chezmoiblobs/"+removed[:2],
+			vfst.TestDoesNotExist,
+		),
+	)
+}
@@ -1,11 +1,19 @@
 package cmd
 
 import (
+	"errors"
+	"os"
+	"os/exec"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
 )
 
+type gitCmdConfig struct {
+	AutoCommit bool
+	AutoPush   bool
+}
+
 var gitCmd = &cobra.Command{
 	Use:     "git [args...]",
 	Short:   "Run git in the source directory",
@@ -19,9 +27,33 @@ func init() {
 }
 
 func (c *Config) runGitCmd(cmd *cobra.Command, args []string) error {
+	err := c.runGit(args...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// Exit with git's exit code so that, for example, chezmoi git -- diff
+		// --quiet can be used in conditionals. git has already reported the
+		// error.
+		os.Exit(exitErr.ExitCode())
+	}
+	return err
+}
+
+// runGit runs git with args in the source directory. Unlike c.run, git's
+// standard error is not redirected to c.Stdout.
+func (c *Config) runGit(args ...string) error {
 	name := "git"
 	if trimExecutableSuffix(filepath.Base(c.SourceVCS.Command)) == "git" {
 		name = c.SourceVCS.Command
 	}
-	return c.run(c.SourceDir, name, args...)
+	//nolint:gosec
+	cmd := exec.Command(name, args...)
+	var err error
+	cmd.Dir, err = c.fs.RawPath(c.SourceDir)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+	return c.mutator.RunCmd(cmd)
 }
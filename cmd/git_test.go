@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestGitCmd(t *testing.T) {
+	defer setTestGitEnv(t, nil)()
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/dot_bashrc": "# contents of .bashrc\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	newTestGitConfig := func() *Config {
+		stdout.Reset()
+		stderr.Reset()
+		c := newTestConfig(fs, withStdout(stdout))
+		c.Stderr = stderr
+		return c
+	}
+
+	require.NoError(t, newTestGitConfig().runGit("init", "--quiet"))
+	require.NoError(t, newTestGitConfig().runGit("add", "."))
+	require.NoError(t, newTestGitConfig().runGit("commit", "--quiet", "--message", "Add dot_bashrc"))
+	assert.Equal(t, "Add dot_bashrc\n", testGitOutput(t, fs, "log", "--format=%s"))
+
+	// git is run in the source directory and its output is passed through.
+	rawSourceDir, err := fs.RawPath("/home/user/.local/share/chezmoi")
+	require.NoError(t, err)
+	require.NoError(t, newTestGitConfig().runGit("rev-parse", "--show-toplevel"))
+	actualSourceDir, err := filepath.EvalSymlinks(string(bytes.TrimSpace(stdout.Bytes())))
+	require.NoError(t, err)
+	expectedSourceDir, err := filepath.EvalSymlinks(rawSourceDir)
+	require.NoError(t, err)
+	assert.Equal(t, expectedSourceDir, actualSourceDir)
+
+	// Arguments are passed without any quoting or splitting.
+	require.NoError(t, newTestGitConfig().runGit("log", "--format=%s with spaces"))
+	assert.Equal(t, "Add dot_bashrc with spaces\n", stdout.String())
+
+	// Errors are written to standard error.
+	assert.Error(t, newTestGitConfig().runGit("rev-parse", "--verify", "--quiet", "nonexistent"))
+	assert.Error(t, newTestGitConfig().runGit("nonexistent-command"))
+	assert.Equal(t, "", stdout.String())
+	assert.NotEqual(t, "", stderr.String())
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/google/go-github/v26/github"
+	"golang.org/x/oauth2"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+type gitHubConfig struct {
+	APIURL string
+	Token  string
+}
+
+// newGitHubClient returns a new GitHub API client. The token is read from
+// the config file, or from the GITHUB_TOKEN environment variable.
+func (c *Config) newGitHubClient(ctx context.Context) (*github.Client, error) {
+	var httpClient *http.Client
+	token := c.GitHub.Token
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token != "" {
+		httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{
+			AccessToken: token,
+		}))
+	}
+	client := github.NewClient(httpClient)
+	if c.GitHub.APIURL != "" {
+		baseURL, err := url.Parse(strings.TrimSuffix(c.GitHub.APIURL, "/") + "/")
+		if err != nil {
+			return nil, err
+		}
+		client.BaseURL = baseURL
+	}
+	return client, nil
+}
+
+// resolveExternalRelease resolves and locks the release of the github-release
+// external external, keeping the release that it is already locked to.
+func (c *Config) resolveExternalRelease(external *chezmoi.External) error {
+	return c.lockExternalRelease(external, false)
+}
+
+// lockExternalRelease resolves the release of the github-release external
+// external and locks external to it. Unless upgrade is true, the release that
+// external is already locked to is kept.
+func (c *Config) lockExternalRelease(external *chezmoi.External, upgrade bool) error {
+	tag := ""
+	if !upgrade {
+		tag = external.Release()
+	}
+	entry, err := c.resolveGitHubRelease(external, tag)
+	if err != nil {
+		return err
+	}
+	// Keep the assets used by other machines if the release is unchanged.
+	if lock := external.Lock; lock != nil && lock.Repo == entry.Repo && lock.Version == entry.Version {
+		for name, asset := range lock.Assets {
+			if _, ok := entry.Assets[name]; !ok {
+				entry.Assets[name] = asset
+			}
+		}
+	}
+	return external.SetLock(entry)
+}
+
+// resolveGitHubRelease returns the lock entry for the asset of external's
+// repository that matches external's asset pattern. If tag is not empty then
+// the release with tag is used, otherwise the release with the highest version
+// that satisfies external's version constraint. The asset is downloaded to
+// determine its checksum.
+func (c *Config) resolveGitHubRelease(external *chezmoi.External, tag string) (*chezmoi.ExternalLockEntry, error) {
+	ctx := context.Background()
+	client, err := c.newGitHubClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ownerRepo := strings.SplitN(external.Repo, "/", 2)
+	owner, repo := ownerRepo[0], ownerRepo[1]
+
+	var release *github.RepositoryRelease
+	if tag != "" {
+		release, _, err = client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+	} else {
+		release, err = findLatestGitHubRelease(ctx, client, owner, repo, external.Version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", external.Repo, err)
+	}
+
+	var assetName, assetURL string
+	for _, asset := range release.Assets {
+		if ok, _ := path.Match(external.Asset, asset.GetName()); !ok {
+			continue
+		}
+		if assetName != "" {
+			return nil, fmt.Errorf("%s %s: %s: matches multiple assets (%s, %s)", external.Repo, release.GetTagName(), external.Asset, assetName, asset.GetName())
+		}
+		assetName, assetURL = asset.GetName(), asset.GetBrowserDownloadURL()
+	}
+	if assetName == "" {
+		return nil, fmt.Errorf("%s %s: no asset matches %s", external.Repo, release.GetTagName(), external.Asset)
+	}
+
+	data, err := c.fetchExternalURL(assetURL, 0, func([]byte) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+	dataSHA256 := sha256.Sum256(data)
+	return &chezmoi.ExternalLockEntry{
+		Type:    chezmoi.ExternalTypeGitHubRelease,
+		Repo:    external.Repo,
+		Version: release.GetTagName(),
+		Assets: map[string]*chezmoi.ExternalLockAsset{
+			assetName: {
+				URL:      assetURL,
+				Checksum: hex.EncodeToString(dataSHA256[:]),
+			},
+		},
+	}, nil
+}
+
+// findLatestGitHubRelease returns the release of owner/repo with the highest
+// version that satisfies constraint, or the highest version if constraint is
+// empty. Drafts, prereleases, and releases whose tags are not semantic
+// versions are ignored.
+func findLatestGitHubRelease(ctx context.Context, client *github.Client, owner, repo, constraint string) (*github.RepositoryRelease, error) {
+	var constraints *semver.Constraints
+	if constraint != "" {
+		var err error
+		constraints, err = semver.NewConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", constraint, err)
+		}
+	}
+
+	var latestRelease *github.RepositoryRelease
+	var latestVersion *semver.Version
+	listOptions := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		releases, resp, err := client.Repositories.ListReleases(ctx, owner, repo, listOptions)
+		if err != nil {
+			return nil, err
+		}
+		for _, release := range releases {
+			if release.GetDraft() || release.GetPrerelease() {
+				continue
+			}
+			version, err := semver.NewVersion(release.GetTagName())
+			if err != nil {
+				continue
+			}
+			if constraints != nil && !constraints.Check(version) {
+				continue
+			}
+			if latestVersion == nil || version.GreaterThan(latestVersion) {
+				latestRelease = release
+				latestVersion = version
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOptions.Page = resp.NextPage
+	}
+
+	if latestRelease == nil {
+		if constraint != "" {
+			return nil, fmt.Errorf("no release satisfies %s", constraint)
+		}
+		return nil, fmt.Errorf("no releases")
+	}
+	return latestRelease, nil
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestGitHubReleaseExternal(t *testing.T) {
+	type testRelease struct {
+		TagName    string                   `json:"tag_name"`
+		Draft      bool                     `json:"draft"`
+		Prerelease bool                     `json:"prerelease"`
+		Assets     []map[string]interface{} `json:"assets"`
+	}
+	var server *httptest.Server
+	newTestRelease := func(tag string) *testRelease {
+		return &testRelease{
+			TagName: tag,
+			Assets: []map[string]interface{}{
+				{"name": "tool_darwin_amd64", "browser_download_url": server.URL + "/download/" + tag + "/tool_darwin_amd64"},
+				{"name": "tool_linux_amd64", "browser_download_url": server.URL + "/download/" + tag + "/tool_linux_amd64"},
+			},
+		}
+	}
+	releases := []*testRelease{}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/owner/tool/releases":
+			assert.NoError(t, json.NewEncoder(w).Encode(releases))
+		case strings.HasPrefix(r.URL.Path, "/repos/owner/tool/releases/tags/"):
+			tag := strings.TrimPrefix(r.URL.Path, "/repos/owner/tool/releases/tags/")
+			for _, release := range releases {
+				if release.TagName == tag {
+					assert.NoError(t, json.NewEncoder(w).Encode(release))
+					return
+				}
+			}
+			http.NotFound(w, r)
+		case strings.HasPrefix(r.URL.Path, "/download/"):
+			_, _ = w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/download/") + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": "" +
+			"[\".local/bin/tool\"]\n" +
+			"    type = \"github-release\"\n" +
+			"    repo = \"owner/tool\"\n" +
+			"    version = \"^1.0.0\"\n" +
+			"    asset = \"tool_linux_*\"\n" +
+			"    executable = true\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	newTestGitHubReleaseConfig := func() *Config {
+		stdout.Reset()
+		c := newTestConfig(fs, withStdout(stdout))
+		c.GitHub.APIURL = server.URL
+		return c
+	}
+	readLock := func() map[string]*chezmoi.ExternalLockEntry {
+		lock, err := chezmoi.ReadExternalLock(fs, "/home/user/.local/share/chezmoi")
+		require.NoError(t, err)
+		return lock.Externals
+	}
+	checksum := func(contents string) string {
+		contentsSHA256 := sha256.Sum256([]byte(contents))
+		return hex.EncodeToString(contentsSHA256[:])
+	}
+
+	// The first apply locks the latest release that satisfies the version
+	// constraint, ignoring drafts, prereleases, and other major versions.
+	releases = []*testRelease{
+		newTestRelease("v1.0.0"),
+		newTestRelease("v1.1.0"),
+		newTestRelease("v2.0.0"),
+		newTestRelease("v1.2.0-rc1"),
+		newTestRelease("v1.3.0"),
+	}
+	releases[3].Prerelease = true
+	releases[4].Draft = true
+	require.NoError(t, newTestGitHubReleaseConfig().runApplyCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/bin/tool",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0755),
+			vfst.TestContentsString("v1.1.0/tool_linux_amd64\n"),
+		),
+	)
+	assert.Equal(t, map[string]*chezmoi.ExternalLockEntry{
+		".local/bin/tool": {
+			Type:    "github-release",
+			Repo:    "owner/tool",
+			Version: "v1.1.0",
+			Assets: map[string]*chezmoi.ExternalLockAsset{
+				"tool_linux_amd64": {
+					URL:      server.URL + "/download/v1.1.0/tool_linux_amd64",
+					Checksum: checksum("v1.1.0/tool_linux_amd64\n"),
+				},
+			},
+		},
+	}, readLock())
+
+	// Subsequent applies use the locked release.
+	releases = append(releases, newTestRelease("v1.4.0"))
+	require.NoError(t, newTestGitHubReleaseConfig().runApplyCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/bin/tool",
+			vfst.TestContentsString("v1.1.0/tool_linux_amd64\n"),
+		),
+	)
+
+	// externals update locks the new release, but does not apply it.
+	c := newTestGitHubReleaseConfig()
+	require.NoError(t, c.runExternalsUpdateCmd(nil, nil))
+	assert.Equal(t, ".local/bin/tool: v1.1.0 -> v1.4.0\n", stdout.String())
+	assert.Equal(t, "v1.4.0", readLock()[".local/bin/tool"].Version)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/bin/tool",
+			vfst.TestContentsString("v1.1.0/tool_linux_amd64\n"),
+		),
+	)
+
+	// Upgrading again changes nothing.
+	c = newTestGitHubReleaseConfig()
+	require.NoError(t, c.runExternalsUpdateCmd(nil, nil))
+	assert.Equal(t, "", stdout.String())
+
+	require.NoError(t, newTestGitHubReleaseConfig().runApplyCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/bin/tool",
+			vfst.TestContentsString("v1.4.0/tool_linux_amd64\n"),
+		),
+	)
+
+	c = newTestGitHubReleaseConfig()
+	assert.Error(t, c.runExternalsUpdateCmd(nil, []string{"/home/user/.local/share/chezmoi"}))
+}
@@ -26,6 +26,10 @@ func (gitVCS) InitArgs() []string {
 	return []string{"init"}
 }
 
+func (gitVCS) MoveArgs(oldpath, newpath string) []string {
+	return []string{"mv", "-k", oldpath, newpath}
+}
+
 func (gitVCS) ParseStatusOutput(output []byte) (interface{}, error) {
 	return git.ParseStatusPorcelainV2(output)
 }
@@ -13,7 +13,16 @@ var helps = map[string]help{
 			"Description:\n" +
 			"  Add *targets* to the source state. If any target is already in the source\n" +
 			"  state, then its source state is replaced with its current state in the\n" +
-			"  destination directory. The `add` command accepts additional flags:\n" +
+			"  destination directory.\n" +
+			"\n" +
+			"  Unless `--encrypt` is given, files are scanned for likely secrets, such as\n" +
+			"  private keys, well-known kinds of API token, and high-entropy strings, before\n" +
+			"  they are added. If any are found then chezmoi prints a warning and, if stdin\n" +
+			"  is a terminal, prompts you to add the file anyway, skip it, or encrypt it.\n" +
+			"  Consider encrypting such files or replacing the secrets with template\n" +
+			"  functions that read them from a password manager.\n" +
+			"\n" +
+			"  The `add` command accepts additional flags:\n" +
 			"\n" +
 			"  `--autotemplate`\n" +
 			"\n" +
@@ -21,6 +30,15 @@ var helps = map[string]help{
 			"  from the `data` section of the config file. Longer subsitutions occur before\n" +
 			"  shorter ones. This implies the `--template` option.\n" +
 			"\n" +
+			"  `--blob`\n" +
+			"\n" +
+			"  Store the contents of added files in the blob store in `.chezmoiblobs`, and\n" +
+			"  make their source files pointers to it.\n" +
+			"\n" +
+			"  `--compress`\n" +
+			"\n" +
+			"  Compress added files with zstd and set the `compressed` attribute on them.\n" +
+			"\n" +
 			"  `-e`, `--empty`\n" +
 			"\n" +
 			"  Set the `empty` attribute on added files.\n" +
@@ -42,6 +60,15 @@ var helps = map[string]help{
 			"\n" +
 			"  Recursively add all files, directories, and symlinks.\n" +
 			"\n" +
+			"  `--rename`\n" +
+			"\n" +
+			"  If an added file has the same contents as a file in the source state whose\n" +
+			"  target no longer exists, record it as a rename without prompting. Without `--\n" +
+			"  rename`, `add` prompts before recording a rename if stdin is a terminal, and\n" +
+			"  adds the file as a new file otherwise. Renames use `git mv` if the source\n" +
+			"  directory is a git repository, so the file's history is kept. Compressed,\n" +
+			"  encrypted, template, and empty files are never considered renames.\n" +
+			"\n" +
 			"  `-T`, `--template`\n" +
 			"\n" +
 			"  Set the `template` attribute on added files and symlinks.",
@@ -51,15 +78,123 @@ var helps = map[string]help{
 			"  chezmoi add ~/.vim --recursive\n" +
 			"  chezmoi add ~/.oh-my-zsh --exact --recursive",
 	},
+	"affected": {
+		long: "" +
+			"Description:\n" +
+			"  List the targets whose target state depends on the given templates or template\n" +
+			"  data, for example after editing a shared template in `.chezmoitemplates`. A\n" +
+			"  target depends on a template if its source is a template that uses it, either\n" +
+			"  directly or through other templates, with the `template` action or with\n" +
+			"  `includeTemplate` and a literal name. A target depends on a data key if the\n" +
+			"  template uses the key, a value inside the key, or a value containing the key.\n" +
+			"\n" +
+			"  `--data` *key*\n" +
+			"\n" +
+			"  List targets that use the template data *key*, for example `email` or\n" +
+			"  `chezmoi.hostname`. This option may be specified multiple times.\n" +
+			"\n" +
+			"  `--template` *template*\n" +
+			"\n" +
+			"  List targets that use *template*, which may be the name of the template or the\n" +
+			"  path of its file in the `.chezmoitemplates` directory. This option may be\n" +
+			"  specified multiple times.",
+		example: "" +
+			"  chezmoi affected --template gitconfig\n" +
+			"  chezmoi affected --data email\n" +
+			"  chezmoi apply $(chezmoi affected --template\n" +
+			"~/.local/share/chezmoi/.chezmoitemplates/gitconfig)",
+	},
 	"apply": {
 		long: "" +
 			"Description:\n" +
 			"  Ensure that *targets* are in the target state, updating them if necessary. If\n" +
-			"  no targets are specified, the state of all targets are ensured.",
+			"  no targets are specified, the state of all targets are ensured.\n" +
+			"\n" +
+			"  If chezmoi is interrupted, for example by pressing Ctrl-C, then it finishes\n" +
+			"  updating the current target, kills any running script, and stops. It then\n" +
+			"  prints the targets that were applied and the targets that were not applied. A\n" +
+			"  second interrupt exits immediately.\n" +
+			"\n" +
+			"  On macOS and BSDs, chezmoi preserves file flags set with `chflags` when it\n" +
+			"  updates a file, and does not update files that are immutable or append-only.\n" +
+			"\n" +
+			"  If a directory in the target state is a symlink to a directory in the\n" +
+			"  destination directory, then chezmoi updates the directory that the symlink\n" +
+			"  points to rather than replacing the symlink. *targets* may be given through\n" +
+			"  symlinks to the destination directory or its ancestors, for example\n" +
+			"  `/usr/home/user/.bashrc` when `/home` is a symlink to `/usr/home`.\n" +
+			"\n" +
+			"  When it finishes, `apply` prints a one line summary of the number of targets\n" +
+			"  written, removed, and unchanged, the number of scripts run, and the number of\n" +
+			"  errors. With `--dry-run`, the summary counts the changes that would be made.\n" +
+			"\n" +
+			"  If a file has been modified since chezmoi last wrote it, then chezmoi prompts\n" +
+			"  before overwriting it. Files that chezmoi has never written are overwritten\n" +
+			"  without prompting, as are all files if there is no input to answer the prompt,\n" +
+			"  for example when chezmoi is run from a script.\n" +
+			"\n" +
+			"  If the destination directory is an `ssh://[user@]host[:port][/path]` URL, then\n" +
+			"  chezmoi applies the target state to *path* on the remote host, which does not\n" +
+			"  need chezmoi installed, only `ssh` access and a POSIX shell. *path* defaults\n" +
+			"  to the remote user's home directory and may start with `~`. chezmoi renders\n" +
+			"  the target state locally, with `.chezmoi.os`, `.chezmoi.arch`,\n" +
+			"  `.chezmoi.hostname`, `.chezmoi.fullHostname`, `.chezmoi.username`,\n" +
+			"  `.chezmoi.homedir`, and `.chezmoi.osRelease` describing the remote host, and\n" +
+			"  reads and updates the destination directory by running commands on the remote\n" +
+			"  host. Scripts run on the remote host, and the state of `run_once_` and\n" +
+			"  `run_onchange_` scripts is recorded separately for each remote host. Facts\n" +
+			"  that describe only the local machine, like `.chezmoi.machineID` and\n" +
+			"  `.chezmoi.kernel`, are not set. The `ssh` command and any extra arguments are\n" +
+			"  set with the `ssh.command` and `ssh.args` configuration variables, and a\n" +
+			"  single connection is shared by all the commands that chezmoi runs. The source\n" +
+			"  directory, including `.chezmoiexternal.lock`, is always read and written\n" +
+			"  locally. Remote destinations cannot be used with `--remove` or `--user`, and `git-\n" +
+			"  repo` externals cannot be applied to them. chezmoi does not record the state\n" +
+			"  of the files that it writes on the remote host, so it does not prompt before\n" +
+			"  overwriting files there that have been modified.\n" +
+			"\n" +
+			"  Similarly, if the destination directory is a\n" +
+			"  `docker://[user@]container[/path]` or `podman://[user@]container[/path]` URL,\n" +
+			"  then chezmoi applies the target state to *path* in the running container\n" +
+			"  *container* by running commands in it with `docker exec` or `podman exec`,\n" +
+			"  optionally as *user*. The container needs only a POSIX shell. The state of\n" +
+			"  scripts is recorded separately for each container, and the commands and any\n" +
+			"  extra arguments, which are given before `exec`, are set with the\n" +
+			"  `docker.command`, `docker.args`, `podman.command`, and `podman.args`\n" +
+			"  configuration variables.\n" +
+			"\n" +
+			"  `-f`, `--force`\n" +
+			"\n" +
+			"  Overwrite modified files without prompting.\n" +
+			"\n" +
+			"  `-i`, `--interactive`\n" +
+			"\n" +
+			"  Prompt before applying each target that would change. Answer `y` to apply the\n" +
+			"  target, `n` to skip it, `a` to apply it and all remaining targets, `q` to\n" +
+			"  stop, or `d` to show the diff before answering. Directories are created\n" +
+			"  without prompting, but their contents are prompted for. The contents of an\n" +
+			"  external archive are prompted for as a whole. Confirming a target also\n" +
+			"  confirms overwriting it if it has been modified since chezmoi last wrote it.\n" +
+			"\n" +
+			"  `--report` *filename*\n" +
+			"\n" +
+			"  Also write a JSON report to *filename*, for example for collection by fleet\n" +
+			"  management tools. The report contains the time, the hostname, whether `--dry-run`\n" +
+			"  was given, and lists of the written, removed, and unchanged targets, the\n" +
+			"  scripts run, and the errors. If applying fails with an error, other than being\n" +
+			"  interrupted, then the list of unchanged targets is empty, as chezmoi does not\n" +
+			"  know which targets were reached.",
 		example: "" +
 			"  chezmoi apply\n" +
 			"  chezmoi apply --dry-run --verbose\n" +
-			"  chezmoi apply ~/.bashrc",
+			"  chezmoi apply ~/.bashrc\n" +
+			"  chezmoi apply --interactive\n" +
+			"  chezmoi apply --report /var/tmp/chezmoi-report.json\n" +
+			"  chezmoi apply --destination ssh://user@host.example.com\n" +
+			"  chezmoi apply --destination ssh://user@host.example.com:2222/~/dotfiles --dry-run --\n" +
+			"verbose\n" +
+			"  chezmoi apply --destination docker://debug\n" +
+			"  chezmoi apply --destination podman://root@debug/root",
 	},
 	"archive": {
 		long: "" +
@@ -69,6 +204,74 @@ var helps = map[string]help{
 		example: "" +
 			"  chezmoi archive | tar tvf -",
 	},
+	"audit": {
+		long: "" +
+			"Description:\n" +
+			"  Audit the source state. *subcommand* is `externals` or `secrets`. Both fail if\n" +
+			"  any problems are found, so they can be used to check shared dotfile repos in\n" +
+			"  CI.\n" +
+			"\n" +
+			"  `audit externals` lists every external with its type, the size of its\n" +
+			"  downloaded contents, its checksum status, when it was last refreshed, any\n" +
+			"  problems, its target, and its URL. Files and archives are checked against\n" +
+			"  chezmoi's download cache and are never downloaded, so the size and last\n" +
+			"  refreshed time are only known for externals that have been applied. The\n" +
+			"  checksum status is one of:\n" +
+			"\n" +
+			"      STATUS    |            MEANING\n" +
+			"  --------------+---------------------------------\n" +
+			"    ok          | The downloaded contents match\n" +
+			"                | the checksum\n" +
+			"    mismatch    | The downloaded contents do not\n" +
+			"                | match the checksum\n" +
+			"    missing     | The external does not have a\n" +
+			"                | checksum\n" +
+			"    unverified  | The external has a checksum\n" +
+			"                | but has not been downloaded\n" +
+			"                | yet\n" +
+			"    unsupported | The external is a git-repo,\n" +
+			"                | which cannot have a checksum\n" +
+			"\n" +
+			"  Externals are flagged for using `http` instead of `https`, for unpinned URLs,\n" +
+			"  which refer to a branch or release that changes over time such as `master`,\n" +
+			"  `main`, `HEAD`, or `latest`, for missing or mismatched checksums, and for\n" +
+			"  being larger than `--max-size`. `git-repo` externals that are not locked to a\n" +
+			"  commit follow the repository's default branch, so they are flagged as\n" +
+			"  unpinned.\n" +
+			"\n" +
+			"  `audit secrets` scans the source state for likely secrets, such as private\n" +
+			"  keys, access tokens, and high-entropy strings, that are not encrypted. Every\n" +
+			"  file in the source directory is scanned as it is stored, except for encrypted\n" +
+			"  files, compressed files, and `secrets.sops.yaml`. If `--rendered` is given,\n" +
+			"  templates and compressed files are also scanned after they are rendered, so\n" +
+			"  secrets that templates read from the config file data are found too. Templates\n" +
+			"  that cannot be rendered are reported as warnings.\n" +
+			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Print the results in *format*, which can be `text` (the default) or `json`.\n" +
+			"  With `json`, `audit externals` prints an array of objects with the keys\n" +
+			"  `targetPath`, `type`, `url`, `size`, `checksum`, `lastRefreshed`, and\n" +
+			"  `problems`, and `audit secrets` prints an array of objects with the keys\n" +
+			"  `sourcePath`, `kind` (`raw` or `rendered`), `line`, and `description`.\n" +
+			"\n" +
+			"  `--max-size` *bytes*\n" +
+			"\n" +
+			"  `audit externals` only. Flag downloaded externals that are larger than\n" +
+			"  *bytes*. The default is `0`, meaning no limit.\n" +
+			"\n" +
+			"  `--rendered` *bool*\n" +
+			"\n" +
+			"  `audit secrets` only. Scan templates and compressed files after rendering\n" +
+			"  them. The default is `false`, as templates that read secrets from a password\n" +
+			"  manager are expected to contain secrets once they are rendered.",
+		example: "" +
+			"  chezmoi audit externals\n" +
+			"  chezmoi audit externals --max-size=10000000 --format=json\n" +
+			"  chezmoi audit secrets\n" +
+			"  chezmoi audit secrets --rendered\n" +
+			"  chezmoi audit secrets --format=json",
+	},
 	"cat": {
 		long: "" +
 			"Description:\n" +
@@ -99,6 +302,7 @@ var helps = map[string]help{
 			"\n" +
 			"    ATTRIBUTE  | ABBREVIATION\n" +
 			"  -------------+---------------\n" +
+			"    compress   | none\n" +
 			"    empty      | e\n" +
 			"    encrypted  | none\n" +
 			"    exact      | none\n" +
@@ -107,11 +311,19 @@ var helps = map[string]help{
 			"    template   | t\n" +
 			"\n" +
 			"  Multiple attributes modifications may be specified by separating them with a\n" +
-			"  comma (`,`).",
+			"  comma (`,`).\n" +
+			"\n" +
+			"  `-r`, `--recursive`\n" +
+			"\n" +
+			"  Recursively change the attributes of all entries in any target directories.\n" +
+			"  Combine with `--dry-run` and `--verbose` to preview all the changes that will be\n" +
+			"  made to the source state without making them.",
 		example: "" +
 			"  chezmoi chattr template ~/.bashrc\n" +
 			"  chezmoi chattr noempty ~/.profile\n" +
-			"  chezmoi chattr private,template ~/.netrc",
+			"  chezmoi chattr private,template ~/.netrc\n" +
+			"  chezmoi chattr --recursive --dry-run --verbose private ~/.ssh\n" +
+			"  chezmoi chattr --recursive exact ~/.config/nvim",
 	},
 	"completion": {
 		long: "" +
@@ -126,6 +338,42 @@ var helps = map[string]help{
 			"  chezmoi completion bash\n" +
 			"  chezmoi completion fish --output ~/.config/fish/completions/chezmoi.fish",
 	},
+	"config": {
+		long: "" +
+			"Description:\n" +
+			"  Check the config file and the config file template without changing anything.\n" +
+			"  Every key in the config file is checked against the configuration variables\n" +
+			"  that chezmoi understands, and unknown keys are reported along with the most\n" +
+			"  similar known key, if there is one. The config file is then decoded strictly,\n" +
+			"  so values whose type does not match the variable's type, for example `\"true\"`\n" +
+			"  for a boolean, and invalid template data keys are also reported. If the source\n" +
+			"  directory contains a config file template then it is rendered, with\n" +
+			"  `promptString` returning its prompt, `promptBool` returning false, and\n" +
+			"  `promptInt` returning zero, and the result is checked in the same way. `config\n" +
+			"  validate` fails if any problems are found.\n" +
+			"\n" +
+			"  chezmoi also prints a warning for every unknown key in the config file\n" +
+			"  whenever it reads the config file.",
+		example: "" +
+			"  chezmoi config validate",
+	},
+	"cp": {
+		long: "" +
+			"Description:\n" +
+			"  Copy *source* to *target* in the source state. The new source state entry has\n" +
+			"  the same contents and attributes as *source*, for example `private_` and\n" +
+			"  `executable_`, and the parent directory of *target* must either be the\n" +
+			"  destination directory or be managed by chezmoi. The destination directory is\n" +
+			"  not changed; run `chezmoi apply` to create *target*. This is useful for\n" +
+			"  splitting a single configuration file into per-host variants.\n" +
+			"\n" +
+			"  `-T`, `--template`\n" +
+			"\n" +
+			"  Make the copy of *source* a template. *source* must be a file or a symlink.",
+		example: "" +
+			"  chezmoi cp ~/.bashrc ~/.bashrc.local\n" +
+			"  chezmoi cp --template ~/.gitconfig ~/.config/git/config",
+	},
 	"data": {
 		long: "" +
 			"Description:\n" +
@@ -165,13 +413,28 @@ var helps = map[string]help{
 			"  version 2.0.0 of chezmoi, `git` format diffs will become the default and\n" +
 			"  include scripts and the `chezmoi` format will be removed.\n" +
 			"\n" +
+			"  `--from-ref` *ref*\n" +
+			"\n" +
+			"  Print the git format diff between the target states rendered from two\n" +
+			"  revisions of the source state, using the current template data, instead of the\n" +
+			"  diff between the target state and the destination state. *ref* is the git\n" +
+			"  revision to diff from, and defaults to `HEAD` if only `--to-ref` is given.\n" +
+			"  Targets outside the destination directory are not included.\n" +
+			"\n" +
 			"  `--no-pager`\n" +
 			"\n" +
-			"  Do not use the pager.",
+			"  Do not use the pager.\n" +
+			"\n" +
+			"  `--to-ref` *ref*\n" +
+			"\n" +
+			"  Set the git revision of the source state to diff to when printing the diff\n" +
+			"  between revisions of the source state. The default is the working tree.",
 		example: "" +
 			"  chezmoi diff\n" +
 			"  chezmoi diff ~/.bashrc\n" +
-			"  chezmoi diff --format=git",
+			"  chezmoi diff --format=git\n" +
+			"  chezmoi diff --from-ref origin/master\n" +
+			"  chezmoi diff --from-ref v1 --to-ref v2 ~/.gitconfig",
 	},
 	"docs": {
 		long: "" +
@@ -209,6 +472,13 @@ var helps = map[string]help{
 			"Description:\n" +
 			"  Edit the source state of *targets*, which must be files or symlinks. If no\n" +
 			"  targets are given the the source directory itself is opened with `$EDITOR`.\n" +
+			"\n" +
+			"  Encrypted targets are decrypted into a private temporary directory which is\n" +
+			"  removed when the editor exits or chezmoi is interrupted. This directory is\n" +
+			"  created in `secret.tempDir` if set, otherwise in `$XDG_RUNTIME_DIR` if it\n" +
+			"  exists, otherwise in the system temporary directory. The same directory is\n" +
+			"  used for the target states written by `merge` and `diff --from-ref`.\n" +
+			"\n" +
 			"  The `edit` command accepts additional arguments:\n" +
 			"\n" +
 			"  `-a`, `--apply`\n" +
@@ -250,6 +520,20 @@ var helps = map[string]help{
 			"\n" +
 			"  Include simulated functions only available during `chezmoi init`.\n" +
 			"\n" +
+			"  `--promptBool` *pairs*\n" +
+			"\n" +
+			"  Simulate the `promptBool` function with a function that returns values from\n" +
+			"  *pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+			"  `promptBool` is called with a *prompt* that does not match any of *pairs*,\n" +
+			"  then it returns false.\n" +
+			"\n" +
+			"  `--promptInt` *pairs*\n" +
+			"\n" +
+			"  Simulate the `promptInt` function with a function that returns values from\n" +
+			"  *pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+			"  `promptInt` is called with a *prompt* that does not match any of *pairs*, then\n" +
+			"  it returns zero.\n" +
+			"\n" +
 			"  `--promptString`, `-p` *pairs*\n" +
 			"\n" +
 			"  Simulate the `promptString` function with a function that returns values from\n" +
@@ -263,8 +547,109 @@ var helps = map[string]help{
 			"    chezmoi execute-template '{{ .chezmoi.os }}' / '{{ .chezmoi.arch }}'\n" +
 			"    echo '{{ .chezmoi | toJson }}' | chezmoi execute-template\n" +
 			"    chezmoi execute-template --init --promptString email=john@home.org <\n" +
+			"  ~/.local/share/chezmoi/.chezmoi.toml.tmpl\n" +
+			"    chezmoi execute-template --init --promptBool personal=true --promptInt width=80 <\n" +
 			"  ~/.local/share/chezmoi/.chezmoi.toml.tmpl",
 	},
+	"explain": {
+		long: "" +
+			"Description:\n" +
+			"  Print how the target state of *target* is derived: its source path, the\n" +
+			"  attributes parsed from its source name, whether it is encrypted, the templates\n" +
+			"  it includes and the template data it references (if it is a template), whether\n" +
+			"  it is ignored and which ignore patterns match it, and its final permissions\n" +
+			"  and the SHA256 hash of its contents.",
+		example: "" +
+			"  chezmoi explain ~/.bashrc",
+	},
+	"externals": {
+		long: "" +
+			"Description:\n" +
+			"  Update the entries for *targets* in .chezmoiexternal.lock to what they\n" +
+			"  currently resolve to. If no *targets* are specified then all externals and all\n" +
+			"  locked template values are updated. `file` and `archive` externals are\n" +
+			"  downloaded again, `git-repo` externals are locked to the commit of the remote\n" +
+			"  repository's `HEAD`, `github-release` externals are upgraded to the release\n" +
+			"  with the highest version that satisfies their `version` constraint, and locked\n" +
+			"  `authorizedKeys` are fetched again. Each entry that changes is reported on its\n" +
+			"  own line. The target state is not changed until `chezmoi apply` is run.\n" +
+			"\n" +
+			"  The GitHub API is called with the token in the `gitHub.token` configuration\n" +
+			"  variable or the `GITHUB_TOKEN` environment variable, if set.",
+		example: "" +
+			"  chezmoi externals update\n" +
+			"  chezmoi externals update ~/.oh-my-zsh\n" +
+			"  chezmoi externals update && chezmoi diff",
+	},
+	"fleet": {
+		long: "" +
+			"Description:\n" +
+			"  Apply the same source state to multiple remote hosts. `fleet apply` reads the\n" +
+			"  hosts listed in the file given by `--hosts`, connects to each host with `ssh`,\n" +
+			"  renders the target state of each host locally with that host's template data,\n" +
+			"  and then applies it to each host's destination directory by running commands\n" +
+			"  on the host, in the same way as apply does for `ssh://` destinations. The\n" +
+			"  `ssh` command can be set with the `fleet.sshCommand` configuration variable.\n" +
+			"  Every host's target state is rendered before any host is changed, so a\n" +
+			"  template error for any host stops `fleet apply` before anything is written.\n" +
+			"\n" +
+			"  The hosts file is a YAML file with a list of hosts, for example:\n" +
+			"\n" +
+			"    hosts:\n" +
+			"    - name: web1.example.com\n" +
+			"      address: admin@web1.example.com\n" +
+			"      data:\n" +
+			"        role: web\n" +
+			"    - name: db1.example.com\n" +
+			"      destDir: /home/postgres\n" +
+			"      data:\n" +
+			"        role: db\n" +
+			"\n" +
+			"  Each host has a `name`, an optional `address` to pass to `ssh` (the default is\n" +
+			"  the name), an optional `destDir` on the remote host (the default is the remote\n" +
+			"  user's home directory, and it may start with `~`), and optional template\n" +
+			"  `data`, which is merged over the `data` section of your config file.\n" +
+			"  `.chezmoi.hostname` and `.chezmoi.fullHostname` are set from the host's name,\n" +
+			"  the other `.chezmoi` variables describe the remote host as for `ssh://`\n" +
+			"  destinations, and any of them can be overridden with a `chezmoi` key in the\n" +
+			"  host's `data`.\n" +
+			"\n" +
+			"  Files are removed and `exact_` directories are made exact as with `apply`.\n" +
+			"  Scripts run on the remote host with no standard input, their output is written\n" +
+			"  to the host's log, and the state of `run_once_` and `run_onchange_` scripts is\n" +
+			"  recorded separately for each host's address. `git-repo` externals cannot be\n" +
+			"  applied to fleet hosts. `fleet apply` does not prompt before overwriting files\n" +
+			"  on the remote hosts that have been modified.\n" +
+			"\n" +
+			"  After each host is applied, its output and the number of targets written and\n" +
+			"  removed and scripts run are printed, followed by a summary of how many hosts\n" +
+			"  succeeded and failed. With `--dry-run`, the counts are of the changes that would\n" +
+			"  be made. With `--verbose`, the output includes the changes made to each host.\n" +
+			"  `fleet apply` fails if any host failed.\n" +
+			"\n" +
+			"  `--hosts` *filename*\n" +
+			"\n" +
+			"  Read the list of hosts from *filename*. This flag is required.\n" +
+			"\n" +
+			"  `--log-dir` *directory*\n" +
+			"\n" +
+			"  Write the output of each host to *directory*`/`*name*`.log` instead of\n" +
+			"  printing it.\n" +
+			"\n" +
+			"  `--parallel` *n*\n" +
+			"\n" +
+			"  Apply to at most *n* hosts concurrently. The default is 4.\n" +
+			"\n" +
+			"  `--report` *filename*\n" +
+			"\n" +
+			"  Also write a JSON report to *filename* listing, for each host, the targets\n" +
+			"  written and removed, the scripts run, and any error.",
+		example: "" +
+			"  chezmoi fleet apply --hosts hosts.yaml\n" +
+			"  chezmoi fleet apply --hosts hosts.yaml --dry-run --verbose\n" +
+			"  chezmoi fleet apply --hosts hosts.yaml --parallel 16 --log-dir logs --report\n" +
+			"report.json",
+	},
 	"forget": {
 		long: "" +
 			"Description:\n" +
@@ -272,15 +657,38 @@ var helps = map[string]help{
 		example: "" +
 			"  chezmoi forget ~/.bashrc",
 	},
+	"gc": {
+		long: "" +
+			"Description:\n" +
+			"  Remove stale entries from chezmoi's caches and state that is no longer needed,\n" +
+			"  and print the size of everything removed. Currently this removes cached\n" +
+			"  `authorizedKeys` keys that are older than `authorizedKeys.refreshPeriod`, the\n" +
+			"  least recently used downloads in the externals cache until the cache is no\n" +
+			"  larger than `externals.maxCacheSize`, blobs in the blob store that no source\n" +
+			"  file refers to, and the state of `run_once_` and `run_onchange_` scripts that\n" +
+			"  are no longer in the source state. Combine with `--dry-run` to print what would\n" +
+			"  be removed without removing it.\n" +
+			"\n" +
+			"  `gc` does not remove source directories that `chezmoi init` moved aside, which\n" +
+			"  may contain local changes, or targets in the trash. Templates are executed\n" +
+			"  every time they are needed, so there is no template cache to remove.",
+		example: "" +
+			"  chezmoi gc\n" +
+			"  chezmoi gc --dry-run",
+	},
 	"git": {
 		long: "" +
 			"Description:\n" +
 			"  Run `git` *arguments* in the source directory. Note that flags in *arguments*\n" +
-			"  must occur after `--` to prevent chezmoi from interpreting them.",
+			"  must occur after `--` to prevent chezmoi from interpreting them. *arguments* are\n" +
+			"  passed to `git` unchanged, without being interpreted by a shell, and `git` is\n" +
+			"  run with the same environment as other commands that chezmoi runs. chezmoi\n" +
+			"  exits with `git`'s exit code, so `chezmoi git` can be used in conditionals.",
 		example: "" +
 			"  chezmoi git add .\n" +
 			"  chezmoi git add dot_gitconfig\n" +
-			"  chezmoi git -- commit -m \"Add .gitconfig\"",
+			"  chezmoi git -- commit -m \"Add .gitconfig\"\n" +
+			"  chezmoi git -- diff --quiet || echo \"uncommitted changes\"",
 	},
 	"help": {
 		long: "" +
@@ -317,6 +725,11 @@ var helps = map[string]help{
 			"\n" +
 			"  Remove destination (in the source state) before importing.\n" +
 			"\n" +
+			"  `--rename`\n" +
+			"\n" +
+			"  Record imported files that were moved as renames without prompting, as for\n" +
+			"  `add --rename`.\n" +
+			"\n" +
 			"  `--strip-components` *n*\n" +
 			"\n" +
 			"  Strip *n* leading components from paths.",
@@ -334,10 +747,70 @@ var helps = map[string]help{
 			"  If a file called `.chezmoi.format.tmpl` exists, where `format` is one of the\n" +
 			"  supported file formats (e.g. `json`, `toml`, or `yaml`) then a new\n" +
 			"  configuration file is created using that file as a template. Finally, if the `--\n" +
-			"  apply` flag is passed, `chezmoi apply` is run.",
+			"  apply` flag is passed, `chezmoi apply` is run.\n" +
+			"\n" +
+			"  If *repo* is given and the source directory already exists and is not empty,\n" +
+			"  then chezmoi prompts before moving it aside to *source-dir*`.`*timestamp*, so\n" +
+			"  that any local changes or unpushed commits in it are kept, and cloning *repo*\n" +
+			"  in its place. *repo* may be abbreviated as `gh:user/repo`, which is expanded\n" +
+			"  to `https://github.com/user/repo.git`.\n" +
+			"\n" +
+			"  `-f`, `--force`\n" +
+			"\n" +
+			"  Move an existing source directory aside without prompting.\n" +
+			"\n" +
+			"  `--one-shot`\n" +
+			"\n" +
+			"  Clone *repo* into a private temporary directory, create the config file there,\n" +
+			"  run `chezmoi apply` without prompting, and then remove the temporary\n" +
+			"  directory, including the source directory, the config file, and the persistent\n" +
+			"  state. Only the applied targets remain. This is useful for ephemeral machines\n" +
+			"  like containers and CI runners. Any existing source directory and config file\n" +
+			"  are left untouched.\n" +
+			"\n" +
+			"  `--template` *repo*\n" +
+			"\n" +
+			"  Initialize the source directory from the template repository *repo*, for\n" +
+			"  example a starter set of dotfiles. The template is cloned into the source\n" +
+			"  directory and its history is discarded. If the template contains a\n" +
+			"  `.chezmoiplaceholders.yaml` manifest, chezmoi prompts for a value for each\n" +
+			"  placeholder in it, replaces every occurrence of the placeholder in the source\n" +
+			"  files with the value, and removes the manifest. Finally, the result is\n" +
+			"  committed to a new git repository. For example:\n" +
+			"\n" +
+			"    placeholders:\n" +
+			"    - placeholder: __EMAIL__\n" +
+			"      prompt: Email address\n" +
+			"    - placeholder: __NAME__\n" +
+			"      prompt: Full name\n" +
+			"      default: Your Name\n" +
+			"\n" +
+			"  Each placeholder has the following fields:\n" +
+			"\n" +
+			"       FIELD    |  TYPE  |          DESCRIPTION\n" +
+			"  --------------+--------+---------------------------------\n" +
+			"    placeholder | string | Text to replace, required\n" +
+			"    prompt      | string | Prompt shown to the user\n" +
+			"    default     | string | Value used if the user enters\n" +
+			"                |        | nothing\n" +
+			"\n" +
+			"  `--template` is only supported when the source VCS is git.\n" +
+			"\n" +
+			"  `--wizard`\n" +
+			"\n" +
+			"  After initializing a new repository in the source directory, look for common\n" +
+			"  dotfiles in the destination directory, for example shell, git, editor, and ssh\n" +
+			"  configuration files, and offer to add each one that is not already managed.\n" +
+			"  Files that contain your home directory or hostname are added as templates, and\n" +
+			"  files that are only readable by you are added as private. The added files are\n" +
+			"  then committed.",
 		example: "" +
 			"  chezmoi init https://github.com/user/dotfiles.git\n" +
-			"  chezmoi init https://github.com/user/dotfiles.git --apply",
+			"  chezmoi init https://github.com/user/dotfiles.git --apply\n" +
+			"  chezmoi init --one-shot https://github.com/user/dotfiles.git\n" +
+			"  chezmoi init gh:user/dotfiles\n" +
+			"  chezmoi init --template gh:someone/dotfiles-starter\n" +
+			"  chezmoi init --wizard",
 	},
 	"manage": {
 		long: "" +
@@ -354,13 +827,26 @@ var helps = map[string]help{
 			"  Only list entries of type *types*. *types* is a comma-separated list of types\n" +
 			"  of entry to include. Valid types are `dirs`, `files`, and `symlinks` which can\n" +
 			"  be abbreviated to `d`, `f`, and `s` respectively. By default, `manage` will\n" +
-			"  list entries of all types.",
+			"  list entries of all types.\n" +
+			"\n" +
+			"  `-l`, `--long`\n" +
+			"\n" +
+			"  Print a long listing of each entry with its type, permissions, size in bytes,\n" +
+			"  the attributes from its source name, the time that `chezmoi apply` last\n" +
+			"  changed it, and its path. The size of a directory is the total size of its\n" +
+			"  entries.\n" +
+			"\n" +
+			"  `--sort` *order*\n" +
+			"\n" +
+			"  Sort entries by *order*, which is one of `name` (the default), `size` (largest\n" +
+			"  first), or `time` (most recently applied first).",
 		example: "" +
 			"  chezmoi managed\n" +
 			"  chezmoi managed --include=files\n" +
 			"  chezmoi managed --include=files,symlinks\n" +
 			"  chezmoi managed -i d\n" +
-			"  chezmoi managed -i d,f",
+			"  chezmoi managed -i d,f\n" +
+			"  chezmoi managed --long --sort=size",
 	},
 	"merge": {
 		long: "" +
@@ -375,6 +861,36 @@ var helps = map[string]help{
 		example: "" +
 			"  chezmoi merge ~/.bashrc",
 	},
+	"mv": {
+		long: "" +
+			"Description:\n" +
+			"  Move *source* to *target* in both the source state and the destination\n" +
+			"  directory. The source state entry keeps all of its attributes, for example\n" +
+			"  `private_` and `.tmpl`, and the parent directory of *target* must either be\n" +
+			"  the destination directory or be managed by chezmoi. If *source* does not exist\n" +
+			"  in the destination directory then only the source state is changed.",
+		example: "" +
+			"  chezmoi mv ~/.bashrc ~/.bashrc.local\n" +
+			"  chezmoi mv ~/.config/foo ~/.config/bar",
+	},
+	"parse-source-name": {
+		long: "" +
+			"Description:\n" +
+			"  Print how *source-name*, a path relative to the source directory, is parsed.\n" +
+			"  For each component, print its type, the attributes parsed from it, and its\n" +
+			"  target name, then print the resulting target name. All components except the\n" +
+			"  last are parsed as directories. The last component is parsed as a directory if\n" +
+			"  *source-name* ends with a slash.\n" +
+			"\n" +
+			"  Prefixes that are out of order, repeated, or follow `dot_` are part of the\n" +
+			"  target name and are listed as misplaced. See Source state attributes for the\n" +
+			"  order of prefixes.\n" +
+			"\n" +
+			"  `parse-source-name` examples\n" +
+			"\n" +
+			"    chezmoi parse-source-name private_dot_ssh/encrypted_private_id_rsa\n" +
+			"    chezmoi parse-source-name exact_dot_config/",
+	},
 	"purge": {
 		long: "" +
 			"Description:\n" +
@@ -416,8 +932,11 @@ var helps = map[string]help{
 			"    chezmoi secret help",
 		example: "" +
 			"  chezmoi secret bitwarden list items\n" +
+			"  chezmoi secret doppler secrets\n" +
 			"  chezmoi secret keyring set --service service --user user\n" +
 			"  chezmoi secret keyring get --service service --user user\n" +
+			"  chezmoi secret keepassxc-browser associate\n" +
+			"  chezmoi secret keyring delete --service service --user user\n" +
 			"  chezmoi secret lastpass ls\n" +
 			"  chezmoi secret lastpass -- show --format=json id\n" +
 			"  chezmoi secret onepassword list items\n" +
@@ -447,6 +966,26 @@ var helps = map[string]help{
 			"    chezmoi source-path\n" +
 			"    chezmoi source-path ~/.bashrc",
 	},
+	"test": {
+		long: "" +
+			"Description:\n" +
+			"  Check that the templates in the source state render as expected with fixture\n" +
+			"  data. Each subdirectory of `.chezmoitests` in the source directory is a test.\n" +
+			"  A test contains the template data in one of `data.json`, `data.toml`, or\n" +
+			"  `data.yaml`, and the expected contents of targets in an `expected` directory,\n" +
+			"  laid out as they would be in the destination directory. Values under the\n" +
+			"  `chezmoi` key of the data override the automatically populated `.chezmoi`\n" +
+			"  variables, so tests can check templates for other operating systems and\n" +
+			"  machines. The expected contents of a symlink are its target.\n" +
+			"\n" +
+			"  Only targets in the `expected` directory are checked. For each test, `test`\n" +
+			"  prints the differences between the expected and actual contents followed by\n" +
+			"  `ok` or `FAIL` and the test's name. If any tests fail then `test` exits with a\n" +
+			"  non-zero status. If no *names* are specified then all tests are run.",
+		example: "" +
+			"  chezmoi test\n" +
+			"  chezmoi test windows-laptop",
+	},
 	"unmanage": {
 		long: "" +
 			"Description:\n" +
@@ -462,9 +1001,26 @@ var helps = map[string]help{
 	"update": {
 		long: "" +
 			"Description:\n" +
-			"  Pull changes from the source VCS and apply any changes.",
+			"  Pull changes from the source VCS and apply any changes. Like `apply`, a\n" +
+			"  summary is printed after applying. The changes are pulled with `git pull --\n" +
+			"  rebase`, or `hg pull --rebase --update` if the source VCS is Mercurial, or the\n" +
+			"  command set in `sourceVCS.pull`.\n" +
+			"\n" +
+			"  `-a`, `--apply`\n" +
+			"\n" +
+			"  Apply the changes after pulling them, the default. Pass `--apply=false` to only\n" +
+			"  pull.\n" +
+			"\n" +
+			"  `-f`, `--force`\n" +
+			"\n" +
+			"  Overwrite modified files without prompting, as `chezmoi apply --force`.\n" +
+			"\n" +
+			"  `--report` *filename*\n" +
+			"\n" +
+			"  Write a JSON report of applying to *filename*, as `chezmoi apply --report`.",
 		example: "" +
-			"  chezmoi update",
+			"  chezmoi update\n" +
+			"  chezmoi update --apply=false",
 	},
 	"upgrade": {
 		long: "" +
@@ -487,14 +1043,47 @@ var helps = map[string]help{
 		example: "" +
 			"  chezmoi upgrade",
 	},
+	"upgrade-externals": {
+		long: "" +
+			"Description:\n" +
+			"  An alias for externals update, which upgrades `github-release` externals to the\n" +
+			"  release with the highest version that satisfies their `version` constraint\n" +
+			"  along with updating all other externals.\n" +
+			"\n" +
+			"  `upgrade-externals` examples\n" +
+			"\n" +
+			"    chezmoi upgrade-externals\n" +
+			"    chezmoi upgrade-externals ~/.local/bin/ripgrep",
+	},
 	"verify": {
 		long: "" +
 			"Description:\n" +
 			"  Verify that all *targets* match their target state. chezmoi exits with code 0\n" +
 			"  (success) if all targets match their target state, or 1 (failure) otherwise.\n" +
-			"  If no targets are specified then all targets are checked.",
+			"  If no targets are specified then all targets are checked.\n" +
+			"\n" +
+			"  If no targets are specified then chezmoi also checks that every `run_once_`\n" +
+			"  and `run_onchange_` script that has been run is still in the source state, and\n" +
+			"  that the cached contents of every file and archive external match its\n" +
+			"  checksum, either declared in `.chezmoiexternal.toml` or recorded in\n" +
+			"  `.chezmoiexternal.lock`, and every git repository external that is locked to a\n" +
+			"  commit has that commit checked out. Externals are not downloaded. Each\n" +
+			"  category of failure, `targets`, `scripts`, or `externals`, is reported on its\n" +
+			"  own line.\n" +
+			"\n" +
+			"  Scripts are never run.\n" +
+			"\n" +
+			"  `--fix`\n" +
+			"\n" +
+			"  Fix the permissions of files and directories and the targets of existing\n" +
+			"  symlinks, but never change contents. Each target that is fixed and each target\n" +
+			"  that still does not match its target state is reported on its own line, and\n" +
+			"  chezmoi exits with code 1 (failure) only if any targets still do not match.\n" +
+			"  This is useful as a cron job that keeps, for example, `~/.ssh` private without\n" +
+			"  risking overwriting local edits.",
 		example: "" +
 			"  chezmoi verify\n" +
-			"  chezmoi verify ~/.bashrc",
+			"  chezmoi verify ~/.bashrc\n" +
+			"  chezmoi verify --fix ~/.ssh",
 	},
 }
@@ -22,6 +22,10 @@ func (hgVCS) InitArgs() []string {
 	return []string{"init"}
 }
 
+func (hgVCS) MoveArgs(oldpath, newpath string) []string {
+	return []string{"mv", oldpath, newpath}
+}
+
 func (hgVCS) ParseStatusOutput(output []byte) (interface{}, error) {
 	return nil, nil
 }
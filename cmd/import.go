@@ -16,17 +16,19 @@ import (
 )
 
 var _importCmd = &cobra.Command{
-	Use:     "import [filename]",
-	Args:    cobra.MaximumNArgs(1),
-	Short:   "Import a tar archive into the source state",
-	Long:    mustGetLongHelp("import"),
-	Example: getExample("import"),
-	PreRunE: config.ensureNoError,
-	RunE:    config.runImportCmd,
+	Use:      "import [filename]",
+	Args:     cobra.MaximumNArgs(1),
+	Short:    "Import a tar archive into the source state",
+	Long:     mustGetLongHelp("import"),
+	Example:  getExample("import"),
+	PreRunE:  config.ensureNoError,
+	RunE:     config.runImportCmd,
+	PostRunE: config.autoCommitAndAutoPush,
 }
 
 type importCmdConfig struct {
 	removeDestination bool
+	rename            bool
 	importTAROptions  chezmoi.ImportTAROptions
 }
 
@@ -38,6 +40,7 @@ func init() {
 	persistentFlags.BoolVarP(&config._import.importTAROptions.Exact, "exact", "x", false, "import directories exactly")
 	persistentFlags.IntVar(&config._import.importTAROptions.StripComponents, "strip-components", 0, "strip components")
 	persistentFlags.BoolVarP(&config._import.removeDestination, "remove-destination", "r", false, "remove destination before import")
+	persistentFlags.BoolVar(&config._import.rename, "rename", false, "record moved files as renames without prompting")
 
 	panicOnError(_importCmd.MarkZshCompPositionalArgumentFile(1, "*.tar", "*.tar.bz2", "*.tar.gz", "*.tgz"))
 }
@@ -84,5 +87,6 @@ func (c *Config) runImportCmd(cmd *cobra.Command, args []string) error {
 			return err
 		}
 	}
-	return ts.ImportTAR(tar.NewReader(r), c._import.importTAROptions, c.mutator)
+	c._import.importTAROptions.Rename = c.getRenameFunc(c._import.rename)
+	return ts.ImportTAR(c.fs, tar.NewReader(r), c._import.importTAROptions, c.mutator)
 }
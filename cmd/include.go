@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"text/template"
+)
+
+func init() {
+	config.addTemplateFunc("include", config.includeFunc)
+	config.addTemplateFunc("includeTemplate", config.includeTemplateFunc)
+}
+
+// includeFunc returns the literal contents of filename, which is relative to
+// the source directory.
+func (c *Config) includeFunc(filename string) string {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(c.SourceDir, filename)
+	}
+	contents, err := c.fs.ReadFile(filename)
+	if err != nil {
+		panic(fmt.Errorf("include: %w", err))
+	}
+	return string(contents)
+}
+
+// includeTemplateFunc returns the result of executing the template name in the
+// .chezmoitemplates directory with data, or with the template data if data is
+// not given. Unlike the template action, the result can be used in pipelines.
+// It panics if name includes itself, directly or through other templates.
+func (c *Config) includeTemplateFunc(name string, args ...interface{}) string {
+	var data interface{}
+	switch len(args) {
+	case 0:
+		var err error
+		data, err = c.getData()
+		if err != nil {
+			panic(fmt.Errorf("includeTemplate: %w", err))
+		}
+	case 1:
+		data = args[0]
+	default:
+		panic(fmt.Errorf("includeTemplate: expected 1 or 2 arguments, got %d", len(args)+1))
+	}
+	filename := filepath.Join(c.SourceDir, ".chezmoitemplates", filepath.FromSlash(name))
+	if c.includeTemplates[filename] {
+		panic(fmt.Errorf("includeTemplate: %s: includes itself", name))
+	}
+	if c.includeTemplates == nil {
+		c.includeTemplates = make(map[string]bool)
+	}
+	c.includeTemplates[filename] = true
+	defer delete(c.includeTemplates, filename)
+	contents, err := c.fs.ReadFile(filename)
+	if err != nil {
+		panic(fmt.Errorf("includeTemplate: %w", err))
+	}
+	tmpl, err := template.New(name).Option(c.Template.Options...).Funcs(c.templateFuncs).Parse(string(contents))
+	if err != nil {
+		panic(fmt.Errorf("includeTemplate: %w", err))
+	}
+	output := &bytes.Buffer{}
+	if err := tmpl.Execute(output, data); err != nil {
+		panic(fmt.Errorf("includeTemplate: %w", err))
+	}
+	return output.String()
+}
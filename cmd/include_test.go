@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestIncludeFuncs(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".local/share/chezmoi": map[string]interface{}{
+				".chezmoitemplates": map[string]interface{}{
+					"aliases": "{{ include \".shared/aliases\" }}",
+					"cycle1":  "{{ includeTemplate \"cycle2\" . }}",
+					"cycle2":  "{{ includeTemplate \"cycle1\" . }}",
+					"proxy":   "export http_proxy={{ .proxy }}\n",
+				},
+				".shared/aliases":  "alias ll='ls -l'\n",
+				"dot_bashrc.tmpl":  "{{ includeTemplate \"proxy\" . }}{{ include \".shared/aliases\" }}",
+				"dot_profile.tmpl": "{{ template \"aliases\" }}",
+				"dot_zshrc.tmpl":   "if true; then\n  {{- includeTemplate \"proxy\" | trim | nindent 2 }}\nfi\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	c.addTemplateFunc("include", c.includeFunc)
+	c.addTemplateFunc("includeTemplate", c.includeTemplateFunc)
+	c.Data = map[string]interface{}{
+		"proxy": "http://proxy.example.com:3128",
+	}
+	require.NoError(t, c.runApplyCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("export http_proxy=http://proxy.example.com:3128\nalias ll='ls -l'\n"),
+		),
+		vfst.TestPath("/home/user/.profile",
+			vfst.TestContentsString("alias ll='ls -l'\n"),
+		),
+		vfst.TestPath("/home/user/.zshrc",
+			vfst.TestContentsString("if true; then\n  export http_proxy=http://proxy.example.com:3128\nfi\n"),
+		),
+	)
+
+	c = newTestConfig(fs, withMutator(chezmoi.NullMutator{}))
+	assert.Panics(t, func() {
+		c.includeTemplateFunc("missing")
+	})
+
+	c.addTemplateFunc("includeTemplate", c.includeTemplateFunc)
+	func() {
+		defer func() {
+			err, ok := recover().(error)
+			require.True(t, ok)
+			assert.Contains(t, err.Error(), "includeTemplate: cycle1: includes itself")
+		}()
+		c.includeTemplateFunc("cycle1", nil)
+	}()
+	assert.Empty(t, c.includeTemplates)
+}
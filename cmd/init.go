@@ -1,24 +1,28 @@
 package cmd
 
 import (
-	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	vfs "github.com/twpayne/go-vfs"
+	"gopkg.in/yaml.v2"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var initCmd = &cobra.Command{
 	Args:    cobra.MaximumNArgs(1),
-	Use:     "init [repo]",
+	Use:     "init [repo | --template repo | --wizard]",
 	Short:   "Setup the source directory and update the destination directory to match the target state",
 	Long:    mustGetLongHelp("init"),
 	Example: getExample("init"),
@@ -27,7 +31,33 @@ var initCmd = &cobra.Command{
 }
 
 type initCmdConfig struct {
-	apply bool
+	apply    bool
+	force    bool
+	oneShot  bool
+	template string
+	wizard   bool
+}
+
+// initPlaceholdersName is the name of the manifest of placeholders in a
+// template repository.
+const initPlaceholdersName = ".chezmoiplaceholders.yaml"
+
+// An initPlaceholder is a placeholder in a template repository.
+type initPlaceholder struct {
+	Placeholder string `yaml:"placeholder"`
+	Prompt      string `yaml:"prompt"`
+	Default     string `yaml:"default"`
+}
+
+// initPlaceholdersFile is the manifest of placeholders in a template
+// repository.
+type initPlaceholdersFile struct {
+	Placeholders []initPlaceholder `yaml:"placeholders"`
+}
+
+// initRepoShorthands maps repo shorthand prefixes to URL formats.
+var initRepoShorthands = map[string]string{
+	"gh:": "https://github.com/%s.git",
 }
 
 func init() {
@@ -35,6 +65,10 @@ func init() {
 
 	persistentFlags := initCmd.PersistentFlags()
 	persistentFlags.BoolVar(&config.init.apply, "apply", false, "update destination directory")
+	persistentFlags.BoolVarP(&config.init.force, "force", "f", false, "move an existing source directory aside without prompting")
+	persistentFlags.BoolVar(&config.init.oneShot, "one-shot", false, "apply, then remove the source directory, config, and state")
+	persistentFlags.StringVar(&config.init.template, "template", "", "initialize from a template repo")
+	persistentFlags.BoolVar(&config.init.wizard, "wizard", false, "interactively add common dotfiles")
 }
 
 func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
@@ -43,6 +77,67 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	var repo string
+	switch {
+	case c.init.oneShot && (c.init.template != "" || c.init.wizard):
+		return errors.New("cannot specify --template or --wizard with --one-shot")
+	case c.init.oneShot && len(args) == 0:
+		return errors.New("--one-shot requires a repo")
+	case c.init.wizard && (c.init.template != "" || len(args) == 1):
+		return errors.New("cannot specify repo or --template with --wizard")
+	case c.init.template != "" && len(args) == 1:
+		return errors.New("cannot specify both repo and --template")
+	case c.init.template != "":
+		if filepath.Base(c.SourceVCS.Command) != "git" {
+			return fmt.Errorf("%s: --template not supported", c.SourceVCS.Command)
+		}
+		repo = expandRepoShorthand(c.init.template)
+	case len(args) == 1:
+		repo = expandRepoShorthand(args[0])
+	}
+
+	// In one-shot mode, the source directory, config file, and persistent
+	// state are kept in a private temporary directory that is removed
+	// afterwards, leaving only the applied targets behind.
+	configDir := filepath.Join(c.bds.ConfigHome, "chezmoi")
+	if c.init.oneShot {
+		tempDir, removeTempDir, err := c.makeSecretTempDir()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = c.fs.RemoveAll(tempDir)
+			removeTempDir()
+		}()
+		c.SourceDir = filepath.Join(tempDir, "source")
+		c.configFile = filepath.Join(tempDir, "chezmoi.toml")
+		configDir = tempDir
+		c.init.apply = true
+		c.init.force = true
+	}
+
+	// Cloning into an existing source directory replaces it. The existing
+	// source directory is moved aside rather than removed, so that any local
+	// changes or unpushed commits in it are not lost.
+	if repo != "" {
+		if infos, err := c.fs.ReadDir(c.SourceDir); err == nil && len(infos) != 0 {
+			backupDir := c.SourceDir + "." + time.Now().UTC().Format("20060102T150405Z")
+			ok, err := c.confirm(fmt.Sprintf("Move existing source directory %s to %s", c.SourceDir, backupDir), &c.init.force)
+			switch {
+			case errors.Is(err, errConfirmQuit):
+				return nil
+			case err != nil:
+				return err
+			case !ok:
+				return fmt.Errorf("%s: source directory already exists", c.SourceDir)
+			}
+			if err := c.mutator.Rename(c.SourceDir, backupDir); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.Stdout, "moved %s to %s\n", c.SourceDir, backupDir)
+		}
+	}
+
 	if err := c.ensureSourceDirectory(); err != nil {
 		return err
 	}
@@ -52,26 +147,22 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	switch len(args) {
-	case 0: // init
-		var initArgs []string
-		if c.SourceVCS.Init != nil {
-			switch v := c.SourceVCS.Init.(type) {
-			case string:
-				initArgs = strings.Split(v, " ")
-			case []string:
-				initArgs = v
-			default:
-				return fmt.Errorf("sourceVCS.init: cannot parse value")
+	switch {
+	case repo == "": // init
+		if err := c.initSourceVCS(vcs); err != nil {
+			return err
+		}
+		if c.init.wizard {
+			if err := c.runInitWizard(vcs); err != nil {
+				return err
 			}
-		} else {
-			initArgs = vcs.InitArgs()
 		}
-		if err := c.run(c.SourceDir, c.SourceVCS.Command, initArgs...); err != nil {
+	case c.init.template != "": // clone template
+		if err := c.initFromTemplate(vcs, repo, rawSourceDir); err != nil {
 			return err
 		}
-	case 1: // clone
-		cloneArgs := vcs.CloneArgs(args[0], rawSourceDir)
+	default: // clone
+		cloneArgs := vcs.CloneArgs(repo, rawSourceDir)
 		if cloneArgs == nil {
 			return fmt.Errorf("%s: cloning not supported", c.SourceVCS.Command)
 		}
@@ -93,15 +184,21 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := c.createConfigFile(); err != nil {
+	if err := c.createConfigFile(configDir); err != nil {
 		return err
 	}
 
 	if c.init.apply {
+		if !c.init.force && !c.DryRun && c.user == "" {
+			if err := checkDestDirOwner(c.fs, c.DestDir, os.Geteuid(), os.Getenv); err != nil {
+				return err
+			}
+		}
 		persistentState, err := c.getPersistentState(nil)
 		if err != nil {
 			return err
 		}
+		defer persistentState.Close()
 		if err := c.applyArgs(nil, persistentState); err != nil {
 			return err
 		}
@@ -110,7 +207,101 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func (c *Config) createConfigFile() error {
+// initSourceVCS initializes a new repository in the source directory.
+func (c *Config) initSourceVCS(vcs VCS) error {
+	var initArgs []string
+	if c.SourceVCS.Init != nil {
+		switch v := c.SourceVCS.Init.(type) {
+		case string:
+			initArgs = strings.Split(v, " ")
+		case []string:
+			initArgs = v
+		default:
+			return fmt.Errorf("sourceVCS.init: cannot parse value")
+		}
+	} else {
+		initArgs = vcs.InitArgs()
+	}
+	return c.run(c.SourceDir, c.SourceVCS.Command, initArgs...)
+}
+
+// initFromTemplate clones the template repo into the source directory,
+// replaces the placeholders listed in its manifest with values entered by the
+// user, and commits the result to a new repository.
+func (c *Config) initFromTemplate(vcs VCS, repo, rawSourceDir string) error {
+	if err := c.run("", c.SourceVCS.Command, vcs.CloneArgs(repo, rawSourceDir)...); err != nil {
+		return err
+	}
+
+	// The template's history belongs to the template, so start afresh.
+	if err := c.mutator.RemoveAll(filepath.Join(c.SourceDir, ".git")); err != nil {
+		return err
+	}
+	if err := c.initSourceVCS(vcs); err != nil {
+		return err
+	}
+
+	manifestPath := filepath.Join(c.SourceDir, initPlaceholdersName)
+	var placeholdersFile initPlaceholdersFile
+	switch data, err := c.fs.ReadFile(manifestPath); {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	default:
+		if err := yaml.UnmarshalStrict(data, &placeholdersFile); err != nil {
+			return fmt.Errorf("%s: %w", manifestPath, err)
+		}
+		if err := c.mutator.RemoveAll(manifestPath); err != nil {
+			return err
+		}
+	}
+
+	var oldnew []string
+	for _, placeholder := range placeholdersFile.Placeholders {
+		if placeholder.Placeholder == "" {
+			return fmt.Errorf("%s: empty placeholder", manifestPath)
+		}
+		value, err := c.promptDefault(placeholder.Prompt, placeholder.Default)
+		if err != nil {
+			return err
+		}
+		oldnew = append(oldnew, placeholder.Placeholder, value)
+	}
+
+	if len(oldnew) != 0 {
+		replacer := strings.NewReplacer(oldnew...)
+		if err := vfs.Walk(c.fs, c.SourceDir, func(path string, info os.FileInfo, err error) error {
+			switch {
+			case err != nil:
+				return err
+			case info.IsDir() && info.Name() == ".git":
+				return filepath.SkipDir
+			case !info.Mode().IsRegular():
+				return nil
+			}
+			contents, err := c.fs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			newContents := replacer.Replace(string(contents))
+			if newContents == string(contents) {
+				return nil
+			}
+			return c.mutator.WriteFile(path, []byte(newContents), info.Mode().Perm(), contents)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := c.run(c.SourceDir, c.SourceVCS.Command, vcs.AddArgs(".")...); err != nil {
+		return err
+	}
+	return c.run(c.SourceDir, c.SourceVCS.Command, vcs.CommitArgs("Initialize from template "+c.init.template)...)
+}
+
+// createConfigFile creates a config file in configDir from the config file
+// template in the source directory, if there is one.
+func (c *Config) createConfigFile(configDir string) error {
 	filename, ext, data, err := c.findConfigTemplate()
 	if err != nil {
 		return err
@@ -125,6 +316,8 @@ func (c *Config) createConfigFile() error {
 	for key, value := range c.templateFuncs {
 		funcMap[key] = value
 	}
+	funcMap["promptBool"] = c.promptBool
+	funcMap["promptInt"] = c.promptInt
 	funcMap["promptString"] = c.promptString
 	t, err := template.New(filename).Funcs(funcMap).Parse(data)
 	if err != nil {
@@ -143,7 +336,6 @@ func (c *Config) createConfigFile() error {
 		return err
 	}
 
-	configDir := filepath.Join(c.bds.ConfigHome, "chezmoi")
 	if err := vfs.MkdirAll(c.mutator, configDir, 0777&^os.FileMode(c.Umask)); err != nil {
 		return err
 	}
@@ -174,9 +366,66 @@ func (c *Config) findConfigTemplate() (string, string, string, error) {
 	return "", "", "", nil
 }
 
+// promptDefault prompts for a value with prompt, returning defaultValue if the
+// user enters nothing.
+func (c *Config) promptDefault(prompt, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		prompt += " [" + defaultValue + "]"
+	}
+	fmt.Fprintf(c.Stdout, "%s? ", prompt)
+	value, err := c.stdinReader().ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && value != "") {
+		return "", err
+	}
+	if value = strings.TrimSpace(value); value == "" {
+		return defaultValue, nil
+	}
+	return value, nil
+}
+
+func (c *Config) promptBool(field string) bool {
+	value, err := parseBool(c.promptString(field))
+	if err != nil {
+		panic(fmt.Errorf("promptBool: %s: %w", field, err))
+	}
+	return value
+}
+
+func (c *Config) promptInt(field string) int64 {
+	value, err := strconv.ParseInt(c.promptString(field), 10, 64)
+	if err != nil {
+		panic(fmt.Errorf("promptInt: %s: %w", field, err))
+	}
+	return value
+}
+
 func (c *Config) promptString(field string) string {
 	fmt.Fprintf(c.Stdout, "%s? ", field)
-	value, err := bufio.NewReader(c.Stdin).ReadString('\n')
+	value, err := c.stdinReader().ReadString('\n')
 	panicOnError(err)
 	return strings.TrimSpace(value)
 }
+
+// parseBool is like strconv.ParseBool but also accepts "y", "yes", "on", "n",
+// "no", and "off", in any case.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "on", "t", "true", "y", "yes":
+		return true, nil
+	case "0", "f", "false", "n", "no", "off":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s: invalid boolean", s)
+	}
+}
+
+// expandRepoShorthand expands repo if it starts with one of
+// initRepoShorthands' prefixes.
+func expandRepoShorthand(repo string) string {
+	for prefix, format := range initRepoShorthands {
+		if strings.HasPrefix(repo, prefix) {
+			return fmt.Sprintf(format, strings.TrimPrefix(repo, prefix))
+		}
+	}
+	return repo
+}
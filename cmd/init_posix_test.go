@@ -0,0 +1,56 @@
+// +build !windows
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestInitWizard(t *testing.T) {
+	defer setTestGitEnv(t, map[string]string{
+		"HOME": "/home/user",
+	})()
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc":    "export PATH=/home/user/bin:$PATH\n",
+			".gitconfig": "[user]\n\temail = john.smith@company.com\n",
+			".vimrc":     "set nocompatible\n",
+			".zshrc":     &vfst.Symlink{Target: ".bashrc"},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdin := newTestStdinPipe(t, "y\nn\ny\n")
+	defer stdin.Close()
+
+	c := newTestConfig(
+		fs,
+		withStdin(stdin),
+	)
+	c.init.wizard = true
+	require.NoError(t, c.runInitCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc.tmpl",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("export PATH={{ .chezmoi.homedir }}/bin:$PATH\n"),
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_gitconfig",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_vimrc",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("set nocompatible\n"),
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/symlink_dot_zshrc",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	assert.Equal(t, "Add dotfiles\n", testGitOutput(t, fs, "log", "--format=%s"))
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"bytes"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -29,7 +30,7 @@ func TestPromptStringIgnoreWindowsNewline(t *testing.T) {
 		withStdin(bytes.NewBufferString("home\r\n")),
 	)
 
-	require.NoError(t, c.createConfigFile())
+	require.NoError(t, c.createConfigFile("/home/user/.config/chezmoi"))
 
 	vfst.RunTests(t, fs, "",
 		vfst.TestPath("/home/user/.config/chezmoi/chezmoi.toml",
@@ -67,7 +68,7 @@ func TestCreateConfigFile(t *testing.T) {
 		withStdin(bytes.NewBufferString("john.smith@company.com \n")),
 	)
 
-	require.NoError(t, c.createConfigFile())
+	require.NoError(t, c.createConfigFile("/home/user/.config/chezmoi"))
 
 	vfst.RunTests(t, fs, "",
 		vfst.TestPath("/home/user/.config/chezmoi/chezmoi.yaml",
@@ -89,6 +90,61 @@ func TestCreateConfigFile(t *testing.T) {
 	}, c.Data)
 }
 
+func TestCreateConfigFilePromptBoolInt(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoi.toml.tmpl": strings.Join([]string{
+			`{{ $personal := promptBool "personal" -}}`,
+			`{{ $width := promptInt "width" -}}`,
+			`[data]`,
+			`  personal = {{ $personal }}`,
+			`  width = {{ $width }}`,
+		}, "\n"),
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(
+		fs,
+		withStdin(bytes.NewBufferString("Yes\n80\n")),
+	)
+
+	require.NoError(t, c.createConfigFile("/home/user/.config/chezmoi"))
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.config/chezmoi/chezmoi.toml",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString(strings.Join([]string{
+				`[data]`,
+				`  personal = true`,
+				`  width = 80`,
+			}, "\n")),
+		),
+	)
+
+	c = newTestConfig(
+		fs,
+		withStdin(bytes.NewBufferString("maybe\n80\n")),
+	)
+	assert.Error(t, c.createConfigFile("/home/user/.config/chezmoi"))
+}
+
+func TestParseBool(t *testing.T) {
+	for _, s := range []string{"1", "on", "t", "true", "True", "y", "YES"} {
+		actual, err := parseBool(s)
+		assert.NoError(t, err)
+		assert.True(t, actual, s)
+	}
+	for _, s := range []string{"0", "off", "f", "false", "FALSE", "n", "no"} {
+		actual, err := parseBool(s)
+		assert.NoError(t, err)
+		assert.False(t, actual, s)
+	}
+	for _, s := range []string{"", "maybe", "2"} {
+		_, err := parseBool(s)
+		assert.Error(t, err, s)
+	}
+}
+
 func TestInit(t *testing.T) {
 	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
 		"/home/user": &vfst.Dir{Perm: 0755},
@@ -142,3 +198,166 @@ func TestInitRepo(t *testing.T) {
 		),
 	)
 }
+
+func TestInitRepoExistingSourceDir(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/dot_local": "# local changes\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs,
+		withStdout(&bytes.Buffer{}),
+	)
+	c.init.force = true
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, c.runInitCmd(nil, []string{filepath.Join(wd, "testdata/gitrepo")}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+			vfst.TestModeIsRegular,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_local",
+			vfst.TestDoesNotExist,
+		),
+	)
+	backupDirs, err := fs.Glob("/home/user/.local/share/chezmoi.*")
+	require.NoError(t, err)
+	require.Len(t, backupDirs, 1)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath(filepath.Join(backupDirs[0], "dot_local"),
+			vfst.TestContentsString("# local changes\n"),
+		),
+	)
+}
+
+func TestInitOneShot(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	c.init.oneShot = true
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, c.runInitCmd(nil, []string{filepath.Join(wd, "testdata/gitrepo")}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString(lines("# contents of .bashrc\n")),
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.config/chezmoi",
+			vfst.TestDoesNotExist,
+		),
+	)
+	_, err = fs.Stat(filepath.Dir(c.SourceDir))
+	assert.True(t, os.IsNotExist(err))
+
+	c = newTestConfig(fs)
+	c.init.oneShot = true
+	assert.Error(t, c.runInitCmd(nil, nil))
+}
+
+func TestInitTemplate(t *testing.T) {
+	defer setTestGitEnv(t, nil)()
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdin := newTestStdinPipe(t, "john.smith@company.com\n\n")
+	defer stdin.Close()
+
+	c := newTestConfig(
+		fs,
+		withStdin(stdin),
+	)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	c.init.template = filepath.Join(wd, "testdata/templaterepo")
+	require.NoError(t, c.runInitCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/.git/HEAD",
+			vfst.TestModeIsRegular,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/.chezmoiplaceholders.yaml",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_gitconfig",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString(lines(
+				"[user]\n"+
+					"\temail = john.smith@company.com\n"+
+					"\tname = Your Name\n",
+			)),
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/README.md",
+			vfst.TestContentsString(lines("# Your Name's dotfiles\n")),
+		),
+	)
+
+	assert.Equal(t, "Initialize from template "+c.init.template+"\n", testGitOutput(t, fs, "log", "--format=%s"))
+	assert.Empty(t, testGitOutput(t, fs, "status", "--porcelain"))
+}
+
+// newTestStdinPipe returns a pipe containing input for use as stdin, like a
+// terminal, so that input is not consumed by commands run before prompting.
+func newTestStdinPipe(t *testing.T, input string) *os.File {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.WriteString(input)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return r
+}
+
+// setTestGitEnv sets the environment variables for a git identity, and those
+// in env, and returns a function that restores the environment.
+func setTestGitEnv(t *testing.T, env map[string]string) func() {
+	gitEnv := map[string]string{
+		"GIT_AUTHOR_NAME":     "chezmoi",
+		"GIT_AUTHOR_EMAIL":    "chezmoi@example.com",
+		"GIT_COMMITTER_NAME":  "chezmoi",
+		"GIT_COMMITTER_EMAIL": "chezmoi@example.com",
+	}
+	for key, value := range env {
+		gitEnv[key] = value
+	}
+	return setTestEnv(t, gitEnv)
+}
+
+// testGitOutput returns the output of git args in the source directory in fs.
+func testGitOutput(t *testing.T, fs *vfst.TestFS, args ...string) string {
+	rawSourceDir, err := fs.RawPath("/home/user/.local/share/chezmoi")
+	require.NoError(t, err)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = rawSourceDir
+	output, err := cmd.Output()
+	require.NoError(t, err)
+	return string(output)
+}
+
+func TestExpandRepoShorthand(t *testing.T) {
+	for _, tc := range []struct {
+		repo     string
+		expected string
+	}{
+		{
+			repo:     "gh:user/dotfiles",
+			expected: "https://github.com/user/dotfiles.git",
+		},
+		{
+			repo:     "https://github.com/user/dotfiles.git",
+			expected: "https://github.com/user/dotfiles.git",
+		},
+	} {
+		assert.Equal(t, tc.expected, expandRepoShorthand(tc.repo))
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// initWizardTargets are the dotfiles that the init wizard offers to add, if
+// they exist.
+var initWizardTargets = []string{
+	".bash_profile",
+	".bashrc",
+	".profile",
+	".zprofile",
+	".zshrc",
+	".config/fish/config.fish",
+	".gitconfig",
+	".config/git/config",
+	".inputrc",
+	".tmux.conf",
+	".vimrc",
+	".config/nvim/init.vim",
+	".config/nvim/init.lua",
+	".emacs",
+	".emacs.d/init.el",
+	".config/helix/config.toml",
+	".config/Code/User/settings.json",
+	".config/alacritty/alacritty.yml",
+	".ssh/config",
+}
+
+// runInitWizard offers to add each of initWizardTargets that exists in the
+// destination directory to the source state, suggesting the template attribute
+// for files that contain machine-specific values and noting the private
+// attribute for files that are only readable by the user, and commits the
+// added files.
+func (c *Config) runInitWizard(vcs VCS) error {
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	defaultData, err := c.getDefaultData()
+	if err != nil {
+		return err
+	}
+	var machineValues []string
+	for _, key := range []string{"homedir", "hostname"} {
+		if value, ok := defaultData[key].(string); ok && value != "" {
+			machineValues = append(machineValues, value)
+		}
+	}
+
+	all := false
+	added := 0
+TARGET:
+	for _, target := range initWizardTargets {
+		targetPath := filepath.Join(c.DestDir, filepath.FromSlash(target))
+		info, err := c.fs.Lstat(targetPath)
+		switch {
+		case os.IsNotExist(err):
+			continue
+		case err != nil:
+			return err
+		case !info.Mode().IsRegular():
+			continue
+		}
+		if entry, err := ts.Get(c.fs, targetPath); err != nil && !os.IsNotExist(err) {
+			return err
+		} else if entry != nil {
+			continue
+		}
+
+		contents, err := c.fs.ReadFile(targetPath)
+		if err != nil {
+			return err
+		}
+		var attributes []string
+		template := false
+		for _, value := range machineValues {
+			if strings.Contains(string(contents), value) {
+				template = true
+				attributes = append(attributes, "template")
+				break
+			}
+		}
+		private, err := chezmoi.IsPrivate(c.fs, targetPath, info.Mode().Perm()&077 == 0)
+		if err != nil {
+			return err
+		}
+		if private {
+			attributes = append(attributes, "private")
+		}
+
+		if !all {
+			prompt := "Add " + targetPath
+			if len(attributes) != 0 {
+				prompt += " (" + strings.Join(attributes, ", ") + ")"
+			}
+			choice, err := c.prompt(prompt, "ynaq")
+			switch {
+			case errors.Is(err, io.EOF):
+				return fmt.Errorf("%s: cannot confirm without input", prompt)
+			case err != nil:
+				return err
+			}
+			switch choice {
+			case 'n':
+				continue TARGET
+			case 'a':
+				all = true
+			case 'q':
+				break TARGET
+			}
+		}
+
+		addOptions := chezmoi.AddOptions{
+			Template:     template,
+			AutoTemplate: template,
+		}
+		if err := ts.Add(c.fs, addOptions, targetPath, nil, c.Follow, c.mutator); err != nil {
+			return err
+		}
+		added++
+	}
+
+	if added == 0 {
+		fmt.Fprintln(c.Stdout, "No dotfiles added, add them later with chezmoi add")
+		return nil
+	}
+	if err := c.run(c.SourceDir, c.SourceVCS.Command, vcs.AddArgs(".")...); err != nil {
+		return err
+	}
+	return c.run(c.SourceDir, c.SourceVCS.Command, vcs.CommitArgs("Add dotfiles")...)
+}
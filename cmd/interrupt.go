@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var (
+	interruptMutex  sync.Mutex
+	interruptCancel context.CancelFunc
+	interruptOnce   sync.Once
+)
+
+// handleInterrupts installs a handler for interrupt signals. If an
+// interruptible operation is running then the first signal cancels it,
+// otherwise chezmoi removes any temporary directories containing secrets and
+// exits.
+func handleInterrupts() {
+	interruptOnce.Do(func() {
+		signalCh := make(chan os.Signal, 1)
+		signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			for range signalCh {
+				interruptMutex.Lock()
+				cancel := interruptCancel
+				interruptCancel = nil
+				interruptMutex.Unlock()
+				if cancel != nil {
+					cancel()
+					continue
+				}
+				removeSecretTempDirs()
+				os.Exit(1)
+			}
+		}()
+	})
+}
+
+// newInterruptContext returns a new context that is cancelled when chezmoi
+// receives an interrupt signal, and a function that releases it.
+func newInterruptContext() (context.Context, func()) {
+	handleInterrupts()
+	ctx, cancel := context.WithCancel(context.Background())
+	interruptMutex.Lock()
+	interruptCancel = cancel
+	interruptMutex.Unlock()
+	return ctx, func() {
+		interruptMutex.Lock()
+		interruptCancel = nil
+		interruptMutex.Unlock()
+		cancel()
+	}
+}
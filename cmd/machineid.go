@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+)
+
+type machineIDConfig struct {
+	Salt string
+}
+
+func init() {
+	config.addTemplateFunc("machineID", config.machineIDFunc)
+}
+
+// machineIDFunc returns the machine ID salted with salt.
+func (c *Config) machineIDFunc(salt string) string {
+	rawMachineID, err := c.getRawMachineID()
+	if err != nil {
+		panic(fmt.Errorf("machineID: %w", err))
+	}
+	if rawMachineID == "" {
+		panic(fmt.Errorf("machineID: machine ID not available"))
+	}
+	return deriveMachineID(rawMachineID, salt)
+}
+
+// getMachineID returns the machine ID salted with machineID.salt, or the empty
+// string if the machine ID is not available.
+func (c *Config) getMachineID() string {
+	rawMachineID, err := c.getRawMachineID()
+	if err != nil || rawMachineID == "" {
+		return ""
+	}
+	return deriveMachineID(rawMachineID, c.MachineID.Salt)
+}
+
+// getRawMachineID returns the operating system's identifier for the machine,
+// or the empty string if there is none. The result is cached.
+func (c *Config) getRawMachineID() (string, error) {
+	if c.rawMachineID == nil {
+		rawMachineID, err := c.readRawMachineID()
+		if err != nil {
+			return "", err
+		}
+		c.rawMachineID = &rawMachineID
+	}
+	return *c.rawMachineID, nil
+}
+
+// deriveMachineID returns a UUID derived from rawMachineID and salt. The raw
+// machine ID is only used as a key to an HMAC, so it cannot be recovered from
+// the result, and different salts give unrelated UUIDs. The UUID has version
+// 8, as defined by RFC 9562 for custom UUIDs.
+func deriveMachineID(rawMachineID, salt string) string {
+	mac := hmac.New(sha256.New, []byte(rawMachineID))
+	_, _ = mac.Write([]byte("chezmoi machine ID\x00" + salt))
+	sum := mac.Sum(nil)
+	sum[6] = sum[6]&0x0f | 0x80
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"os/exec"
+	"regexp"
+)
+
+var ioPlatformUUIDRegexp = regexp.MustCompile(`"IOPlatformUUID" = "([^"]+)"`)
+
+// readRawMachineID returns the IOPlatformUUID of the machine, as reported by
+// ioreg.
+func (c *Config) readRawMachineID() (string, error) {
+	cmd := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice")
+	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	if err != nil {
+		return "", err
+	}
+	m := ioPlatformUUIDRegexp.FindSubmatch(output)
+	if m == nil {
+		return "", nil
+	}
+	return string(m[1]), nil
+}
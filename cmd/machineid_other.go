@@ -0,0 +1,35 @@
+// +build !darwin,!windows
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+)
+
+// machineIDFilenames are the files that may contain the machine ID, in order
+// of preference: systemd's and D-Bus's machine IDs, and the BSDs' host ID.
+var machineIDFilenames = []string{
+	"/etc/machine-id",
+	"/var/lib/dbus/machine-id",
+	"/var/db/dbus/machine-id",
+	"/etc/hostid",
+}
+
+// readRawMachineID returns the contents of the first non-empty machine ID
+// file.
+func (c *Config) readRawMachineID() (string, error) {
+	for _, filename := range machineIDFilenames {
+		data, err := c.fs.ReadFile(filename)
+		switch {
+		case os.IsNotExist(err), os.IsPermission(err):
+			continue
+		case err != nil:
+			return "", err
+		}
+		if rawMachineID := string(bytes.TrimSpace(data)); rawMachineID != "" {
+			return rawMachineID, nil
+		}
+	}
+	return "", nil
+}
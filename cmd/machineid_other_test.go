@@ -0,0 +1,69 @@
+// +build !darwin,!windows
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestMachineID(t *testing.T) {
+	for _, tc := range []struct {
+		name                 string
+		root                 interface{}
+		expectedRawMachineID string
+	}{
+		{
+			name: "etc_machine_id",
+			root: map[string]interface{}{
+				"/etc/machine-id":          "0123456789abcdef0123456789abcdef\n",
+				"/var/lib/dbus/machine-id": "fedcba9876543210fedcba9876543210\n",
+			},
+			expectedRawMachineID: "0123456789abcdef0123456789abcdef",
+		},
+		{
+			name: "empty_etc_machine_id",
+			root: map[string]interface{}{
+				"/etc/machine-id":          "",
+				"/var/lib/dbus/machine-id": "fedcba9876543210fedcba9876543210\n",
+			},
+			expectedRawMachineID: "fedcba9876543210fedcba9876543210",
+		},
+		{
+			name: "etc_hostid",
+			root: map[string]interface{}{
+				"/etc/hostid": "00000000-0000-0000-0000-000000000000\n",
+			},
+			expectedRawMachineID: "00000000-0000-0000-0000-000000000000",
+		},
+		{
+			name: "none",
+			root: map[string]interface{}{
+				"/etc": &vfst.Dir{Perm: 0755},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(fs)
+			c.MachineID.Salt = "salt"
+			rawMachineID, err := c.getRawMachineID()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedRawMachineID, rawMachineID)
+			if tc.expectedRawMachineID == "" {
+				assert.Equal(t, "", c.getMachineID())
+				assert.Panics(t, func() {
+					c.machineIDFunc("")
+				})
+				return
+			}
+			assert.Equal(t, deriveMachineID(tc.expectedRawMachineID, "salt"), c.getMachineID())
+			assert.Equal(t, deriveMachineID(tc.expectedRawMachineID, "other"), c.machineIDFunc("other"))
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveMachineID(t *testing.T) {
+	uuidRegexp := regexp.MustCompile(`\A[0-9a-f]{8}-[0-9a-f]{4}-8[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\z`)
+	machineID := deriveMachineID("0123456789abcdef0123456789abcdef", "")
+	assert.Regexp(t, uuidRegexp, machineID)
+	assert.Equal(t, machineID, deriveMachineID("0123456789abcdef0123456789abcdef", ""))
+	assert.NotContains(t, machineID, "0123456789abcdef")
+
+	saltedMachineID := deriveMachineID("0123456789abcdef0123456789abcdef", "salt")
+	assert.Regexp(t, uuidRegexp, saltedMachineID)
+	assert.NotEqual(t, machineID, saltedMachineID)
+
+	assert.NotEqual(t, machineID, deriveMachineID("fedcba9876543210fedcba9876543210", ""))
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"golang.org/x/sys/windows/registry"
+)
+
+// readRawMachineID returns the MachineGuid that Windows generates when it is
+// installed.
+func (c *Config) readRawMachineID() (string, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Cryptography`, registry.QUERY_VALUE|registry.WOW64_64KEY)
+	if err != nil {
+		return "", err
+	}
+	defer key.Close()
+	machineGUID, _, err := key.GetStringValue("MachineGuid")
+	if err != nil {
+		return "", err
+	}
+	return machineGUID, nil
+}
@@ -2,10 +2,14 @@ package cmd
 
 import (
 	"fmt"
-	"path/filepath"
+	"os"
 	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
@@ -22,6 +26,18 @@ var managedCmd = &cobra.Command{
 
 type managedCmdConfig struct {
 	include []string
+	long    bool
+	sort    string
+}
+
+// A managedEntry is a managed entry in a long listing.
+type managedEntry struct {
+	targetPath string
+	entryType  string
+	perm       string
+	size       int64
+	attributes []string
+	appliedAt  time.Time
 }
 
 func init() {
@@ -29,6 +45,8 @@ func init() {
 
 	persistentFlags := managedCmd.PersistentFlags()
 	persistentFlags.StringSliceVarP(&config.managed.include, "include", "i", []string{"dirs", "files", "symlinks"}, "include")
+	persistentFlags.BoolVarP(&config.managed.long, "long", "l", false, "long listing")
+	persistentFlags.StringVar(&config.managed.sort, "sort", "name", "sort order, \"name\", \"size\", or \"time\"")
 }
 
 func (c *Config) runManagedCmd(cmd *cobra.Command, args []string) error {
@@ -55,9 +73,15 @@ func (c *Config) runManagedCmd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	switch c.managed.sort {
+	case "", "name", "size", "time":
+	default:
+		return fmt.Errorf("unknown sort order: %q", c.managed.sort)
+	}
+
 	allEntries := ts.AllEntries()
 
-	targetNames := make([]string, 0, len(allEntries))
+	entries := make([]chezmoi.Entry, 0, len(allEntries))
 	for _, entry := range allEntries {
 		if _, ok := entry.(*chezmoi.Dir); ok && !includeDirs {
 			continue
@@ -68,16 +92,133 @@ func (c *Config) runManagedCmd(cmd *cobra.Command, args []string) error {
 		if _, ok := entry.(*chezmoi.Symlink); ok && !includeSymlinks {
 			continue
 		}
-		targetNames = append(targetNames, entry.TargetName())
+		if ts.TargetIgnore.Match(entry.TargetName()) {
+			continue
+		}
+		entries = append(entries, entry)
 	}
 
-	sort.Strings(targetNames)
-	for _, targetName := range targetNames {
-		if ts.TargetIgnore.Match(targetName) {
-			continue
+	if !c.managed.long && (c.managed.sort == "" || c.managed.sort == "name") {
+		targetNames := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			targetNames = append(targetNames, entry.TargetName())
+		}
+		sort.Strings(targetNames)
+		for _, targetName := range targetNames {
+			fmt.Fprintln(c.Stdout, chezmoi.TargetPath(ts.DestDir, targetName))
+		}
+		return nil
+	}
+
+	managedEntries, err := c.getManagedEntries(ts, entries)
+	if err != nil {
+		return err
+	}
+	sort.Slice(managedEntries, func(i, j int) bool {
+		switch c.managed.sort {
+		case "size":
+			if managedEntries[i].size != managedEntries[j].size {
+				return managedEntries[i].size > managedEntries[j].size
+			}
+		case "time":
+			if !managedEntries[i].appliedAt.Equal(managedEntries[j].appliedAt) {
+				return managedEntries[i].appliedAt.After(managedEntries[j].appliedAt)
+			}
+		}
+		return managedEntries[i].targetPath < managedEntries[j].targetPath
+	})
+
+	if !c.managed.long {
+		for _, managedEntry := range managedEntries {
+			fmt.Fprintln(c.Stdout, managedEntry.targetPath)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.Stdout, 0, 8, 1, ' ', 0)
+	for _, managedEntry := range managedEntries {
+		attributes := strings.Join(managedEntry.attributes, ",")
+		if attributes == "" {
+			attributes = "-"
+		}
+		appliedAt := "-"
+		if !managedEntry.appliedAt.IsZero() {
+			appliedAt = managedEntry.appliedAt.Format(time.RFC3339)
 		}
-		fmt.Fprintln(c.Stdout, filepath.Join(ts.DestDir, targetName))
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", managedEntry.entryType, managedEntry.perm, managedEntry.size, attributes, appliedAt, managedEntry.targetPath)
 	}
+	return w.Flush()
+}
 
-	return nil
+// getManagedEntries returns the long listing of entries.
+func (c *Config) getManagedEntries(ts *chezmoi.TargetState, entries []chezmoi.Entry) ([]*managedEntry, error) {
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer persistentState.Close()
+
+	umask := os.FileMode(c.Umask)
+	managedEntries := make([]*managedEntry, 0, len(entries))
+	for _, entry := range entries {
+		targetPath := chezmoi.TargetPath(ts.DestDir, entry.TargetName())
+		entryType, attributes := getEntryTypeAndAttributes(entry)
+		size, err := getEntrySize(ts, entry)
+		if err != nil {
+			return nil, err
+		}
+		perm := "-"
+		switch entry := entry.(type) {
+		case *chezmoi.Dir:
+			perm = fmt.Sprintf("%03o", entry.Perm&^umask)
+		case *chezmoi.File:
+			perm = fmt.Sprintf("%03o", entry.Perm&^umask)
+		}
+		managedEntry := &managedEntry{
+			targetPath: targetPath,
+			entryType:  entryType,
+			perm:       perm,
+			size:       size,
+			attributes: attributes,
+		}
+		es, err := c.getEntryState(persistentState, targetPath)
+		if err != nil {
+			return nil, err
+		}
+		if es != nil {
+			managedEntry.appliedAt = es.AppliedAt
+		}
+		managedEntries = append(managedEntries, managedEntry)
+	}
+	return managedEntries, nil
+}
+
+// getEntrySize returns the size of entry's contents. The size of a directory
+// is the total size of the entries that it contains.
+func getEntrySize(ts *chezmoi.TargetState, entry chezmoi.Entry) (int64, error) {
+	switch entry := entry.(type) {
+	case *chezmoi.Dir:
+		var size int64
+		for _, e := range entry.Entries {
+			if ts.TargetIgnore.Match(e.TargetName()) {
+				continue
+			}
+			s, err := getEntrySize(ts, e)
+			if err != nil {
+				return 0, err
+			}
+			size += s
+		}
+		return size, nil
+	case *chezmoi.File:
+		contents, err := entry.Contents()
+		return int64(len(contents)), err
+	case *chezmoi.Symlink:
+		linkname, err := entry.Linkname()
+		return int64(len(linkname)), err
+	default:
+		return 0, nil
+	}
 }
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -92,6 +93,51 @@ func TestManagedCmd(t *testing.T) {
 	}
 }
 
+func TestManagedCmdLong(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"dir/file1":              "contents",
+			"private_executable_big": "0123456789abcdef",
+			"symlink_symlink":        "target",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	require.NoError(t, newTestConfig(fs).runApplyCmd(nil, []string{"/home/user/big"}))
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withStdout(stdout),
+		withManaged(managedCmdConfig{
+			include: []string{"dirs", "files", "symlinks"},
+			long:    true,
+			sort:    "size",
+		}),
+	)
+	require.NoError(t, c.runManagedCmd(nil, nil))
+	var actual [][]string
+	s := bufio.NewScanner(stdout)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		require.Len(t, fields, 6)
+		if fields[4] != "-" {
+			_, err := time.Parse(time.RFC3339, fields[4])
+			assert.NoError(t, err)
+			fields[4] = "time"
+		}
+		fields[5] = posixify(fields[5])
+		actual = append(actual, fields)
+	}
+	require.NoError(t, s.Err())
+	assert.Equal(t, [][]string{
+		{"file", "700", "16", "executable,private", "time", "/home/user/big"},
+		{"dir", "755", "8", "-", "-", "/home/user/dir"},
+		{"file", "644", "8", "-", "-", "/home/user/dir/file1"},
+		{"symlink", "-", "6", "-", "-", "/home/user/symlink"},
+	}, actual)
+}
+
 // extractPOSIXTargetNames extracts all target names from b and coverts them to
 // POSIX-like names.
 func extractPOSIXTargetNames(b []byte) ([]string, error) {
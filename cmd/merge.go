@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"io/ioutil"
-	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
@@ -12,13 +11,14 @@ import (
 )
 
 var mergeCmd = &cobra.Command{
-	Use:     "merge targets...",
-	Args:    cobra.MinimumNArgs(1),
-	Short:   "Perform a three-way merge between the destination state, the source state, and the target state",
-	Long:    mustGetLongHelp("merge"),
-	Example: getExample("merge"),
-	PreRunE: config.ensureNoError,
-	RunE:    config.runMergeCmd,
+	Use:      "merge targets...",
+	Args:     cobra.MinimumNArgs(1),
+	Short:    "Perform a three-way merge between the destination state, the source state, and the target state",
+	Long:     mustGetLongHelp("merge"),
+	Example:  getExample("merge"),
+	PreRunE:  config.ensureNoError,
+	RunE:     config.runMergeCmd,
+	PostRunE: config.autoCommitAndAutoPush,
 }
 
 type mergeConfig struct {
@@ -45,12 +45,12 @@ func (c *Config) runMergeCmd(cmd *cobra.Command, args []string) error {
 
 	// Create a temporary directory to store the target state and ensure that it
 	// is removed afterwards. We cannot use fs as it lacks TempDir
-	// functionality.
-	tempDir, err := ioutil.TempDir("", "chezmoi")
+	// functionality. The target state may contain decrypted secrets.
+	tempDir, removeTempDir, err := c.makeSecretTempDir()
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(tempDir)
+	defer removeTempDir()
 
 	for i, entry := range entries {
 		if err := c.runMergeCommand(cmd, args[i], entry, tempDir); err != nil {
@@ -71,7 +71,7 @@ func (c *Config) runMergeCommand(cmd *cobra.Command, arg string, entry chezmoi.E
 	// source state.
 	args := append(
 		append([]string{}, c.Merge.Args...),
-		filepath.Join(c.DestDir, file.TargetName()),
+		chezmoi.TargetPath(c.DestDir, file.TargetName()),
 		filepath.Join(c.SourceDir, file.SourceName()),
 	)
 
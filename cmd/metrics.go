@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+const metricsStatsdTimeout = time.Second
+
+type metricsConfig struct {
+	File   string
+	Statsd string
+	Prefix string
+}
+
+// A commandMetrics records the timing and result of running a command.
+type commandMetrics struct {
+	Time     time.Time `json:"time"`
+	Hostname string    `json:"hostname"`
+	Command  string    `json:"command"`
+	Duration float64   `json:"duration"`
+	Success  bool      `json:"success"`
+	Error    string    `json:"error,omitempty"`
+	Written  *int      `json:"written,omitempty"`
+	Removed  *int      `json:"removed,omitempty"`
+}
+
+// newCommandMetrics returns the metrics for running cmd, which started at
+// start and returned err.
+func (c *Config) newCommandMetrics(cmd *cobra.Command, start time.Time, err error) *commandMetrics {
+	hostname, _ := os.Hostname()
+	m := &commandMetrics{
+		Time:     start.UTC(),
+		Hostname: hostname,
+		Command:  getCommandName(cmd),
+		Duration: time.Since(start).Seconds(),
+		Success:  err == nil,
+	}
+	if err != nil {
+		m.Error = err.Error()
+	}
+	if r := c.lastApplyReport; r != nil {
+		written, removed := len(r.Written), len(r.Removed)
+		m.Written = &written
+		m.Removed = &removed
+	}
+	return m
+}
+
+// writeMetrics writes m to the metrics file in fs and sends it to the statsd
+// server, if they are configured. Metrics are opt-in, so nothing is written
+// unless metrics.file or metrics.statsd is set.
+func (c *Config) writeMetrics(fs vfs.FS, m *commandMetrics) error {
+	if path := c.Metrics.File; path != "" {
+		if err := appendMetricsFile(fs, c.expandTilde(path), m); err != nil {
+			return err
+		}
+	}
+	if c.Metrics.Statsd != "" {
+		if err := sendStatsdMetrics(c.Metrics.Statsd, c.Metrics.Prefix, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendMetricsFile appends m to the file at path in fs as a single line of
+// JSON.
+func appendMetricsFile(fs vfs.FS, path string, m *commandMetrics) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := vfs.MkdirAll(fs, filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	f, err := fs.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sendStatsdMetrics sends m to the statsd server at addr over UDP.
+func sendStatsdMetrics(addr, prefix string, m *commandMetrics) error {
+	conn, err := net.DialTimeout("udp", addr, metricsStatsdTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetWriteDeadline(time.Now().Add(metricsStatsdTimeout)); err != nil {
+		return err
+	}
+	_, err = conn.Write(formatStatsdMetrics(prefix, m))
+	return err
+}
+
+// formatStatsdMetrics returns m in the statsd line protocol, with each metric
+// name prefixed by prefix.
+func formatStatsdMetrics(prefix string, m *commandMetrics) []byte {
+	if prefix == "" {
+		prefix = "chezmoi"
+	}
+	name := prefix + "." + strings.ReplaceAll(m.Command, " ", "_")
+	if m.Command == "" {
+		name = prefix
+	}
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "%s.duration:%d|ms\n", name, int64(m.Duration*1000))
+	if m.Success {
+		fmt.Fprintf(b, "%s.success:1|c\n", name)
+	} else {
+		fmt.Fprintf(b, "%s.failure:1|c\n", name)
+	}
+	if m.Written != nil {
+		fmt.Fprintf(b, "%s.written:%d|g\n", name, *m.Written)
+	}
+	if m.Removed != nil {
+		fmt.Fprintf(b, "%s.removed:%d|g\n", name, *m.Removed)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestWriteMetricsFile(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	c := newConfig(
+		withTestFS(fs),
+		withTestUser("user"),
+	)
+	c.Metrics.File = "~/.local/state/chezmoi/metrics.jsonl"
+	written := 2
+	removed := 0
+	for _, m := range []*commandMetrics{
+		{
+			Command:  "apply",
+			Duration: 1.5,
+			Success:  true,
+			Written:  &written,
+			Removed:  &removed,
+		},
+		{
+			Command:  "update",
+			Duration: 0.5,
+			Error:    "exit status 1",
+		},
+	} {
+		require.NoError(t, c.writeMetrics(fs, m))
+	}
+	data, err := fs.ReadFile("/home/user/.local/state/chezmoi/metrics.jsonl")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var m commandMetrics
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &m))
+	assert.Equal(t, "apply", m.Command)
+	assert.True(t, m.Success)
+	require.NotNil(t, m.Written)
+	assert.Equal(t, 2, *m.Written)
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &m))
+	assert.Equal(t, "update", m.Command)
+	assert.False(t, m.Success)
+	assert.Equal(t, "exit status 1", m.Error)
+}
+
+func TestFormatStatsdMetrics(t *testing.T) {
+	written := 3
+	removed := 1
+	for _, tc := range []struct {
+		name   string
+		prefix string
+		m      *commandMetrics
+		want   string
+	}{
+		{
+			name: "apply",
+			m: &commandMetrics{
+				Command:  "apply",
+				Duration: 1.25,
+				Success:  true,
+				Written:  &written,
+				Removed:  &removed,
+			},
+			want: "" +
+				"chezmoi.apply.duration:1250|ms\n" +
+				"chezmoi.apply.success:1|c\n" +
+				"chezmoi.apply.written:3|g\n" +
+				"chezmoi.apply.removed:1|g\n",
+		},
+		{
+			name:   "subcommand_failure",
+			prefix: "dotfiles",
+			m: &commandMetrics{
+				Command:  "fleet apply",
+				Duration: 0.1,
+			},
+			want: "" +
+				"dotfiles.fleet_apply.duration:100|ms\n" +
+				"dotfiles.fleet_apply.failure:1|c\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, string(formatStatsdMetrics(tc.prefix, tc.m)))
+		})
+	}
+}
+
+func TestSendStatsdMetrics(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	c := newConfig()
+	c.Metrics.Statsd = conn.LocalAddr().String()
+	require.NoError(t, c.writeMetrics(nil, &commandMetrics{
+		Command:  "diff",
+		Duration: 0.002,
+		Success:  true,
+	}))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "chezmoi.diff.duration:2|ms\nchezmoi.diff.success:1|c\n", string(buf[:n]))
+}
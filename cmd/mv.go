@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var mvCmd = &cobra.Command{
+	Use:      "mv source target",
+	Args:     cobra.ExactArgs(2),
+	Short:    "Move a target in the source state and the destination directory",
+	Long:     mustGetLongHelp("mv"),
+	Example:  getExample("mv"),
+	PreRunE:  config.ensureNoError,
+	RunE:     config.runMvCmd,
+	PostRunE: config.autoCommitAndAutoPush,
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+
+	markRemainingZshCompPositionalArgumentsAsFiles(mvCmd, 1)
+}
+
+func (c *Config) runMvCmd(cmd *cobra.Command, args []string) error {
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	entries, err := c.getEntries(ts, args[:1])
+	if err != nil {
+		return err
+	}
+	entry := entries[0]
+
+	oldTargetPath := chezmoi.TargetPath(ts.DestDir, entry.TargetName())
+	newTargetPath, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+	oldSourcePath, newSourcePath, err := c.getNewSourcePath(ts, entry, newTargetPath, false)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.fs.Lstat(newTargetPath); err == nil {
+		return fmt.Errorf("%s: already exists", newTargetPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if _, err := c.fs.Lstat(newSourcePath); err == nil {
+		return fmt.Errorf("%s: already exists", newSourcePath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := c.mutator.Rename(oldSourcePath, newSourcePath); err != nil {
+		return err
+	}
+
+	switch _, err := c.fs.Lstat(oldTargetPath); {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	if err := vfs.MkdirAll(c.mutator, filepath.Dir(newTargetPath), 0777&^os.FileMode(c.Umask)); err != nil {
+		return err
+	}
+	return c.mutator.Rename(oldTargetPath, newTargetPath)
+}
+
+// getNewSourcePath returns the current source path of entry and the source
+// path for entry if it were moved or copied to newTargetPath. The new source
+// path has the same attributes as the current source path, and is also a
+// template if template is true.
+func (c *Config) getNewSourcePath(ts *chezmoi.TargetState, entry chezmoi.Entry, newTargetPath string, template bool) (string, string, error) {
+	newParentDirSourceName := ""
+	if newParentDir := filepath.Dir(newTargetPath); newParentDir != ts.DestDir {
+		parentEntry, err := ts.Get(c.fs, newParentDir)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: not managed: %w", newParentDir, err)
+		}
+		if _, ok := parentEntry.(*chezmoi.Dir); !ok {
+			return "", "", fmt.Errorf("%s: not a directory", newParentDir)
+		}
+		newParentDirSourceName = parentEntry.SourceName()
+		// The parent directory might be implied by a path prefix or a
+		// destination directory override rather than existing in the source
+		// state.
+		if info, err := c.fs.Stat(filepath.Join(ts.SourceDir, newParentDirSourceName)); err != nil || !info.IsDir() {
+			return "", "", fmt.Errorf("%s: not in the source state", newParentDir)
+		}
+	}
+
+	oldSourceName := entry.SourceName()
+	for _, sourceDirName := range []string{filepath.Dir(oldSourceName), newParentDirSourceName} {
+		if hasSourceMeta, err := ts.HasSourceMeta(c.fs, sourceDirName); err != nil {
+			return "", "", err
+		} else if hasSourceMeta {
+			return "", "", fmt.Errorf("%s: attributes are set in .chezmoimeta.yaml", entry.TargetName())
+		}
+	}
+	oldBase := filepath.Base(oldSourceName)
+	newName := filepath.Base(newTargetPath)
+	// Entries generated from special files, like .chezmoisshconfig, cannot be
+	// moved.
+	if strings.HasPrefix(oldBase, ".") {
+		return "", "", fmt.Errorf("%s: cannot be moved", entry.TargetName())
+	}
+	var newBase string
+	switch entry.(type) {
+	case *chezmoi.Dir:
+		if template {
+			return "", "", fmt.Errorf("%s: cannot be a template", entry.TargetName())
+		}
+		da := chezmoi.ParseDirAttributes(oldBase)
+		da.Name = newName
+		newBase = da.SourceName()
+	case *chezmoi.FIFO:
+		if template {
+			return "", "", fmt.Errorf("%s: cannot be a template", entry.TargetName())
+		}
+		fa := chezmoi.ParseFileAttributes(oldBase)
+		fa.Name = newName
+		newBase = fa.SourceName()
+	case *chezmoi.File, *chezmoi.Hardlink, *chezmoi.Symlink:
+		fa := chezmoi.ParseFileAttributes(oldBase)
+		fa.Name = newName
+		fa.Template = fa.Template || template
+		newBase = fa.SourceName()
+	default:
+		return "", "", fmt.Errorf("%s: cannot be moved", entry.TargetName())
+	}
+
+	oldSourcePath := filepath.Join(ts.SourceDir, oldSourceName)
+	newSourcePath := filepath.Join(ts.SourceDir, newParentDirSourceName, newBase)
+	return oldSourcePath, newSourcePath, nil
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestMvCommand(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		root    interface{}
+		wantErr bool
+		tests   interface{}
+	}{
+		{
+			name: "file",
+			args: []string{"/home/user/.bashrc", "/home/user/.bashrc.local"},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc": "# contents of .bashrc\n",
+					".local/share/chezmoi/private_dot_bashrc.tmpl": "# contents of .bashrc\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_bashrc.tmpl",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_bashrc.local.tmpl",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of .bashrc\n"),
+				),
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.bashrc.local",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of .bashrc\n"),
+				),
+			},
+		},
+		{
+			name: "file_into_dir",
+			args: []string{"/home/user/foo", "/home/user/.config/foo"},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					"foo":                                   "foo",
+					".local/share/chezmoi/executable_foo":   "foo",
+					".local/share/chezmoi/exact_dot_config": &vfst.Dir{Perm: 0755},
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/exact_dot_config/executable_foo",
+					vfst.TestModeIsRegular,
+				),
+				vfst.TestPath("/home/user/.config/foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("foo"),
+				),
+			},
+		},
+		{
+			name: "dir_not_in_destination",
+			args: []string{"/home/user/.foo", "/home/user/.bar"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/private_dot_foo/baz": "baz",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_bar/baz",
+					vfst.TestModeIsRegular,
+				),
+				vfst.TestPath("/home/user/.bar",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name: "target_exists",
+			args: []string{"/home/user/foo", "/home/user/bar"},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					"bar":                      "bar",
+					".local/share/chezmoi/foo": "foo",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "parent_not_managed",
+			args: []string{"/home/user/foo", "/home/user/dir/foo"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/foo": "foo",
+			},
+			wantErr: true,
+		},
+		{
+			name: "not_managed",
+			args: []string{"/home/user/foo", "/home/user/bar"},
+			root: map[string]interface{}{
+				"/home/user/foo":                  "foo",
+				"/home/user/.local/share/chezmoi": &vfst.Dir{Perm: 0700},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(fs)
+			err = c.runMvCmd(nil, tc.args)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			vfst.RunTests(t, fs, "", tc.tests)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func init() {
+	config.addTemplateFunc("output", config.outputFunc)
+}
+
+// outputFunc returns the standard output of name with args. Unlike secrets,
+// the output is not cached, so every call runs the command.
+func (c *Config) outputFunc(name string, args ...string) string {
+	output, err := c.templateFuncCmdOutput(name, args, nil, os.Stderr)
+	if err != nil {
+		panic(fmt.Errorf("output: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
+	}
+	return string(output)
+}
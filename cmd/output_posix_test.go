@@ -0,0 +1,21 @@
+// +build !windows
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestOutputFunc(t *testing.T) {
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	assert.Equal(t, "a b\n", c.outputFunc("echo", "a", "b"))
+	assert.Panics(t, func() {
+		c.outputFunc("sh", "-c", "echo ok; exit 1")
+	})
+}
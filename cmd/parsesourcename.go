@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var parseSourceNameCmd = &cobra.Command{
+	Use:     "parse-source-name source-name",
+	Args:    cobra.ExactArgs(1),
+	Short:   "Print the attributes and target name parsed from a source name",
+	Long:    mustGetLongHelp("parse-source-name"),
+	Example: getExample("parse-source-name"),
+	RunE:    config.runParseSourceNameCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(parseSourceNameCmd)
+}
+
+func (c *Config) runParseSourceNameCmd(cmd *cobra.Command, args []string) error {
+	sourceName := args[0]
+	components := strings.FieldsFunc(sourceName, func(r rune) bool {
+		return r == '/' || r == filepath.Separator
+	})
+	if len(components) == 0 {
+		return fmt.Errorf("%q: invalid source name", sourceName)
+	}
+	lastIsDir := strings.HasSuffix(sourceName, "/") || strings.HasSuffix(sourceName, string(filepath.Separator))
+
+	w := c.Stdout
+	names := make([]string, 0, len(components))
+	for i, component := range components {
+		if strings.HasPrefix(component, ".") {
+			return fmt.Errorf("%s: not a source state entry", component)
+		}
+		psn := chezmoi.ParseSourceName(component, i != len(components)-1 || lastIsDir)
+		printExplainField(w, "source", component)
+		printExplainField(w, "type", psn.Type)
+		printExplainField(w, "attributes", strings.Join(psn.Attributes, ", "))
+		printExplainField(w, "name", psn.Name)
+		printExplainField(w, "misplaced", strings.Join(psn.Misplaced, ", "))
+		fmt.Fprintln(w)
+		names = append(names, psn.Name)
+	}
+	printExplainField(w, "target", filepath.Join(names...))
+	return nil
+}
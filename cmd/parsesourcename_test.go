@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestParseSourceNameCmd(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		sourceName string
+		wantStdout string
+		wantErr    bool
+	}{
+		{
+			name:       "file",
+			sourceName: "private_dot_ssh/private_encrypted_id_rsa.tmpl",
+			wantStdout: strings.Join([]string{
+				"source: private_dot_ssh",
+				"type: dir",
+				"attributes: private",
+				"name: .ssh",
+				"misplaced: -",
+				"",
+				"source: private_encrypted_id_rsa.tmpl",
+				"type: file",
+				"attributes: private, template",
+				"name: encrypted_id_rsa",
+				"misplaced: encrypted_",
+				"",
+				"target: " + filepath.Join(".ssh", "encrypted_id_rsa"),
+				"",
+			}, "\n"),
+		},
+		{
+			name:       "dir",
+			sourceName: "exact_dot_config/",
+			wantStdout: strings.Join([]string{
+				"source: exact_dot_config",
+				"type: dir",
+				"attributes: exact",
+				"name: .config",
+				"misplaced: -",
+				"",
+				"target: .config",
+				"",
+			}, "\n"),
+		},
+		{
+			name:       "special",
+			sourceName: ".chezmoiignore",
+			wantErr:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{})
+			require.NoError(t, err)
+			defer cleanup()
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			err = c.runParseSourceNameCmd(nil, []string{tc.sourceName})
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantStdout, stdout.String())
+		})
+	}
+}
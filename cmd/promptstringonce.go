@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+type promptStringOnceConfig struct {
+	Encrypt bool
+}
+
+// A promptStringOnceValue is a value stored by promptStringOnce.
+type promptStringOnceValue struct {
+	Encrypted bool   `json:"encrypted"`
+	Value     []byte `json:"value"`
+}
+
+// An openPersistentState is a persistent state that is currently open, so
+// that template functions can use it without opening it a second time.
+type openPersistentState struct {
+	chezmoi.PersistentState
+	c        *Config
+	readOnly bool
+}
+
+var promptStringOnceCache = make(map[string]string)
+
+func init() {
+	config.addTemplateFunc("promptStringOnce", config.promptStringOnceFunc)
+}
+
+// Close closes s.
+func (s *openPersistentState) Close() error {
+	if s.c.persistentState == s {
+		s.c.persistentState = nil
+	}
+	return s.PersistentState.Close()
+}
+
+// withOpenPersistentState calls f with the open persistent state, opening it,
+// read-only in dry run mode, for the duration of the call if it is not already
+// open.
+func (c *Config) withOpenPersistentState(f func(*openPersistentState) error) error {
+	if c.persistentState != nil {
+		return f(c.persistentState)
+	}
+	if _, err := c.getPersistentState(nil); err != nil {
+		return err
+	}
+	persistentState := c.persistentState
+	defer persistentState.Close()
+	return f(persistentState)
+}
+
+func (c *Config) promptStringOnceFunc(key, prompt string) string {
+	if value, ok := promptStringOnceCache[key]; ok {
+		return value
+	}
+	value, err := c.getPromptStringOnceValue(key, prompt)
+	if err != nil {
+		panic(fmt.Errorf("promptStringOnce %q: %w", key, err))
+	}
+	promptStringOnceCache[key] = value
+	return value
+}
+
+// getPromptStringOnceValue returns the value stored for key in the persistent
+// state. If there is no stored value then the user is prompted for one with
+// prompt, and it is stored, encrypted if promptStringOnce.encrypt is set,
+// unless the persistent state is read-only.
+func (c *Config) getPromptStringOnceValue(key, prompt string) (string, error) {
+	var value string
+	err := c.withOpenPersistentState(func(persistentState *openPersistentState) error {
+		var err error
+		value, err = c.getPromptStringOnceValueFromState(persistentState, key, prompt)
+		return err
+	})
+	return value, err
+}
+
+func (c *Config) getPromptStringOnceValueFromState(persistentState *openPersistentState, key, prompt string) (string, error) {
+	data, err := persistentState.Get(c.promptOnceBucket, []byte(key))
+	if err != nil {
+		return "", err
+	}
+	if data != nil {
+		var psov promptStringOnceValue
+		if err := json.Unmarshal(data, &psov); err != nil {
+			return "", err
+		}
+		if !psov.Encrypted {
+			return string(psov.Value), nil
+		}
+		encryption, err := c.getEncryption()
+		if err != nil {
+			return "", err
+		}
+		plaintext, err := encryption.Decrypt(psov.Value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", key, err)
+		}
+		return string(plaintext), nil
+	}
+
+	value := c.promptString(prompt)
+	if persistentState.readOnly {
+		return value, nil
+	}
+	psov := promptStringOnceValue{
+		Value: []byte(value),
+	}
+	if c.PromptStringOnce.Encrypt {
+		encryption, err := c.getEncryption()
+		if err != nil {
+			return "", err
+		}
+		ciphertext, err := encryption.Encrypt(key, psov.Value)
+		if err != nil {
+			return "", err
+		}
+		psov.Encrypted = true
+		psov.Value = ciphertext
+	}
+	data, err = json.Marshal(&psov)
+	if err != nil {
+		return "", err
+	}
+	if err := persistentState.Set(c.promptOnceBucket, []byte(key), data); err != nil {
+		return "", err
+	}
+	return value, nil
+}
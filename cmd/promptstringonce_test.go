@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestPromptStringOnce(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	// In dry run mode, the value is not stored.
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs,
+		withStdin(bytes.NewBufferString("http://proxy.example.com:3128\n")),
+		withStdout(stdout),
+	)
+	c.DryRun = true
+	assert.Equal(t, "http://proxy.example.com:3128", c.promptStringOnceFunc("testProxy", "Proxy URL"))
+	assert.Equal(t, "Proxy URL? ", stdout.String())
+	delete(promptStringOnceCache, "testProxy")
+
+	c = newTestConfig(fs,
+		withStdin(bytes.NewBufferString("http://proxy.example.com:8080\n")),
+		withStdout(&bytes.Buffer{}),
+	)
+	assert.Equal(t, "http://proxy.example.com:8080", c.promptStringOnceFunc("testProxy", "Proxy URL"))
+	delete(promptStringOnceCache, "testProxy")
+
+	// The stored value is used without prompting.
+	stdout = &bytes.Buffer{}
+	c = newTestConfig(fs,
+		withStdin(&bytes.Buffer{}),
+		withStdout(stdout),
+	)
+	assert.Equal(t, "http://proxy.example.com:8080", c.promptStringOnceFunc("testProxy", "Proxy URL"))
+	assert.Equal(t, "", stdout.String())
+	assert.Nil(t, c.persistentState)
+
+	// An already open persistent state is used.
+	persistentState, err := c.getPersistentState(nil)
+	require.NoError(t, err)
+	defer persistentState.Close()
+	delete(promptStringOnceCache, "testProxy")
+	assert.Equal(t, "http://proxy.example.com:8080", c.promptStringOnceFunc("testProxy", "Proxy URL"))
+	value, err := persistentState.Get(c.promptOnceBucket, []byte("testProxy"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"encrypted":false,"value":"aHR0cDovL3Byb3h5LmV4YW1wbGUuY29tOjgwODA="}`, string(value))
+}
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+type proxyConfig struct {
+	URL        string
+	NoProxy    string
+	PAC        string
+	PACCommand string
+	PACArgs    []string
+}
+
+// A systemProxy is the proxy configuration of the operating system.
+type systemProxy struct {
+	HTTP       string
+	HTTPS      string
+	NoProxy    []string
+	PAC        string
+	AutoDetect bool
+}
+
+// A proxyCache caches system proxy settings, PAC scripts, and the results of
+// evaluating them, which are expensive to determine and do not change while
+// chezmoi is running.
+type proxyCache struct {
+	systemProxyOnce sync.Once
+	systemProxy     *systemProxy
+	sync.Mutex
+	pacScripts  map[string][]byte
+	pacResults  map[string]*url.URL
+	pacWarnings map[string]bool
+}
+
+// useProxy makes all of chezmoi's HTTP requests use the proxies returned by
+// getProxy.
+func (c *Config) useProxy() {
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return c.getProxy(req.URL)
+		}
+	}
+}
+
+// getProxy returns the proxy to use for u, or nil if u should be fetched
+// directly. The proxy is, in order of precedence, the proxy configured with
+// proxy.url, the result of the PAC file configured with proxy.pac, the proxies
+// in the environment, or the system proxy settings. The system PAC file is
+// only used if systemPAC is true. Loopback addresses are never proxied.
+func (c *Config) getProxy(u *url.URL) (*url.URL, error) {
+	host := u.Hostname()
+	if host == "localhost" || net.ParseIP(host).IsLoopback() {
+		return nil, nil
+	}
+
+	switch {
+	case c.Proxy.URL != "":
+		if matchNoProxy(host, splitNoProxy(c.Proxy.NoProxy)) {
+			return nil, nil
+		}
+		return parseProxyURL(c.Proxy.URL)
+	case c.Proxy.PAC != "":
+		return c.getPACProxyOrDirect(c.Proxy.PAC, false, u), nil
+	case proxyEnvSet(os.Getenv):
+		return http.ProxyFromEnvironment(&http.Request{URL: u})
+	}
+
+	systemProxy := c.getSystemProxy()
+	if systemProxy == nil {
+		return nil, nil
+	}
+	if systemPAC && (systemProxy.PAC != "" || systemProxy.AutoDetect) {
+		return c.getPACProxyOrDirect(systemProxy.PAC, systemProxy.AutoDetect, u), nil
+	}
+	if matchNoProxy(host, systemProxy.NoProxy) {
+		return nil, nil
+	}
+	proxy := systemProxy.HTTP
+	if u.Scheme == "https" && systemProxy.HTTPS != "" {
+		proxy = systemProxy.HTTPS
+	}
+	if proxy == "" {
+		return nil, nil
+	}
+	return parseProxyURL(proxy)
+}
+
+// getPACProxyOrDirect returns the proxy for u from the PAC file pac. If the PAC
+// file cannot be fetched or evaluated then a warning is printed, once for each
+// PAC file, and u is fetched directly.
+func (c *Config) getPACProxyOrDirect(pac string, autoDetect bool, u *url.URL) *url.URL {
+	proxyURL, err := c.getPACProxy(pac, autoDetect, u)
+	if err == nil {
+		return proxyURL
+	}
+	c.proxyCache.Lock()
+	defer c.proxyCache.Unlock()
+	if !c.proxyCache.pacWarnings[pac] {
+		fmt.Fprintf(c.Stderr, "warning: %v, connecting directly\n", err)
+		if c.proxyCache.pacWarnings == nil {
+			c.proxyCache.pacWarnings = make(map[string]bool)
+		}
+		c.proxyCache.pacWarnings[pac] = true
+	}
+	return nil
+}
+
+// getSystemProxy returns the system proxy settings, or nil if there are none.
+func (c *Config) getSystemProxy() *systemProxy {
+	c.proxyCache.systemProxyOnce.Do(func() {
+		c.proxyCache.systemProxy = c.readSystemProxy()
+	})
+	return c.proxyCache.systemProxy
+}
+
+// getProxyData returns template data describing the proxy settings: the HTTP
+// and HTTPS proxies, the hosts that are not proxied, and the PAC file, from
+// the config file, the environment, or the system proxy settings.
+func (c *Config) getProxyData() map[string]interface{} {
+	data := map[string]interface{}{
+		"http":    "",
+		"https":   "",
+		"noProxy": []string{},
+		"pac":     "",
+	}
+	switch {
+	case c.Proxy.URL != "":
+		data["http"] = c.Proxy.URL
+		data["https"] = c.Proxy.URL
+		data["noProxy"] = splitNoProxy(c.Proxy.NoProxy)
+	case c.Proxy.PAC != "":
+		data["pac"] = c.Proxy.PAC
+	case proxyEnvSet(os.Getenv):
+		data["http"] = getenvEitherCase(os.Getenv, "HTTP_PROXY")
+		data["https"] = getenvEitherCase(os.Getenv, "HTTPS_PROXY")
+		data["noProxy"] = splitNoProxy(getenvEitherCase(os.Getenv, "NO_PROXY"))
+	default:
+		if systemProxy := c.getSystemProxy(); systemProxy != nil {
+			data["http"] = systemProxy.HTTP
+			data["https"] = systemProxy.HTTPS
+			if systemProxy.NoProxy != nil {
+				data["noProxy"] = systemProxy.NoProxy
+			}
+			if systemPAC {
+				data["pac"] = systemProxy.PAC
+			}
+		}
+	}
+	return data
+}
+
+// proxyEnvSet returns whether any of the HTTP or HTTPS proxy environment
+// variables are set.
+func proxyEnvSet(getenv func(string) string) bool {
+	return getenvEitherCase(getenv, "HTTP_PROXY") != "" || getenvEitherCase(getenv, "HTTPS_PROXY") != ""
+}
+
+// getenvEitherCase returns the value of the environment variable key, or of
+// its lowercase equivalent if key is not set.
+func getenvEitherCase(getenv func(string) string, key string) string {
+	if value := getenv(key); value != "" {
+		return value
+	}
+	return getenv(strings.ToLower(key))
+}
+
+// parseProxyURL parses proxy, which may omit the http:// scheme.
+func parseProxyURL(proxy string) (*url.URL, error) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid proxy: %w", proxy, err)
+	}
+	return proxyURL, nil
+}
+
+// splitNoProxy splits a comma- or space-separated list of hosts that are not
+// proxied.
+func splitNoProxy(noProxy string) []string {
+	return strings.FieldsFunc(noProxy, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// matchNoProxy returns whether host matches any of the patterns in noProxy.
+// Patterns are host names, which also match their subdomains, optionally with
+// a leading "." or "*.", IP addresses, CIDR ranges with omitted trailing zero
+// octets, "*", which matches everything, and "<local>", which matches host
+// names without a domain.
+func matchNoProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, pattern := range noProxy {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "":
+			continue
+		case pattern == "*":
+			return true
+		case pattern == "<local>":
+			if ip == nil && !strings.Contains(host, ".") {
+				return true
+			}
+		case strings.Contains(pattern, "/"):
+			if _, ipNet, err := net.ParseCIDR(padCIDR(pattern)); err == nil && ip != nil && ipNet.Contains(ip) {
+				return true
+			}
+		default:
+			if i := strings.LastIndex(pattern, ":"); i != -1 && net.ParseIP(pattern) == nil {
+				pattern = pattern[:i]
+			}
+			pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "*"), ".")
+			if host == pattern || strings.HasSuffix(host, "."+pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// padCIDR pads the IPv4 address in cidr with zero octets, so that, for example,
+// 169.254/16 becomes 169.254.0.0/16.
+func padCIDR(cidr string) string {
+	i := strings.Index(cidr, "/")
+	addr, bits := cidr[:i], cidr[i:]
+	if strings.Contains(addr, ":") {
+		return cidr
+	}
+	for strings.Count(addr, ".") < 3 {
+		addr += ".0"
+	}
+	return addr + bits
+}
+
+// parseSCUtilProxyOutput parses the output of scutil --proxy on macOS.
+func parseSCUtilProxyOutput(output []byte) *systemProxy {
+	values := make(map[string]string)
+	var exceptions []string
+	inExceptions := false
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case strings.HasPrefix(line, "ExceptionsList : <array>"):
+			inExceptions = true
+		case inExceptions && line == "}":
+			inExceptions = false
+		default:
+			fields := strings.SplitN(line, " : ", 2)
+			if len(fields) != 2 {
+				continue
+			}
+			if inExceptions {
+				exceptions = append(exceptions, fields[1])
+			} else {
+				values[fields[0]] = fields[1]
+			}
+		}
+	}
+
+	proxy := &systemProxy{
+		NoProxy: exceptions,
+	}
+	if values["HTTPEnable"] == "1" && values["HTTPProxy"] != "" {
+		proxy.HTTP = net.JoinHostPort(values["HTTPProxy"], defaultString(values["HTTPPort"], "80"))
+	}
+	if values["HTTPSEnable"] == "1" && values["HTTPSProxy"] != "" {
+		proxy.HTTPS = net.JoinHostPort(values["HTTPSProxy"], defaultString(values["HTTPSPort"], "443"))
+	}
+	if values["ProxyAutoConfigEnable"] == "1" {
+		proxy.PAC = values["ProxyAutoConfigURLString"]
+	}
+	if proxy.HTTP == "" && proxy.HTTPS == "" && proxy.PAC == "" {
+		return nil
+	}
+	return proxy
+}
+
+// parseWinHTTPProxy parses the proxy and proxy bypass lists returned by
+// WinHTTP. proxy is either a single proxy for all schemes or a list of
+// scheme=proxy pairs separated by semicolons or whitespace, and proxyBypass is
+// a list of hosts separated by semicolons or whitespace.
+func parseWinHTTPProxy(proxy, proxyBypass string) (string, string, []string) {
+	separators := func(r rune) bool {
+		return r == ';' || r == ' ' || r == '\t' || r == '\r' || r == '\n'
+	}
+	var httpProxy, httpsProxy string
+	for _, entry := range strings.FieldsFunc(proxy, separators) {
+		fields := strings.SplitN(entry, "=", 2)
+		switch {
+		case len(fields) == 1:
+			if httpProxy == "" {
+				httpProxy = entry
+			}
+			if httpsProxy == "" {
+				httpsProxy = entry
+			}
+		case fields[0] == "http":
+			httpProxy = fields[1]
+		case fields[0] == "https":
+			httpsProxy = fields[1]
+		}
+	}
+	return httpProxy, httpsProxy, strings.FieldsFunc(proxyBypass, separators)
+}
+
+// parsePACResult returns the first proxy in result, the return value of a PAC
+// file's FindProxyForURL function, or nil if the first entry is DIRECT.
+func parsePACResult(result string) (*url.URL, error) {
+	for _, entry := range strings.Split(result, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		switch keyword := strings.ToUpper(fields[0]); {
+		case keyword == "DIRECT":
+			return nil, nil
+		case len(fields) != 2:
+			return nil, fmt.Errorf("%s: invalid PAC result", result)
+		case keyword == "PROXY" || keyword == "HTTP":
+			return parseProxyURL("http://" + fields[1])
+		case keyword == "HTTPS":
+			return parseProxyURL("https://" + fields[1])
+		case keyword == "SOCKS" || keyword == "SOCKS5":
+			return parseProxyURL("socks5://" + fields[1])
+		default:
+			return nil, fmt.Errorf("%s: unsupported PAC result", result)
+		}
+	}
+	return nil, nil
+}
+
+// defaultString returns s, or defaultValue if s is empty.
+func defaultString(s, defaultValue string) string {
+	if s == "" {
+		return defaultValue
+	}
+	return s
+}
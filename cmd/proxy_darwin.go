@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"os/exec"
+)
+
+// readSystemProxy returns the system proxy settings reported by scutil.
+func (c *Config) readSystemProxy() *systemProxy {
+	output, err := exec.Command("scutil", "--proxy").Output()
+	if err != nil {
+		return nil
+	}
+	return parseSCUtilProxyOutput(output)
+}
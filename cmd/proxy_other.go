@@ -0,0 +1,9 @@
+// +build !darwin,!windows
+
+package cmd
+
+// readSystemProxy returns nil as there are no system proxy settings other than
+// the environment on this platform.
+func (c *Config) readSystemProxy() *systemProxy {
+	return nil
+}
@@ -0,0 +1,219 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// systemPAC is false as the system PAC file would have to be evaluated by
+// proxy.pacCommand, which runs it with the user's privileges. Only PAC files
+// explicitly configured with proxy.pac are evaluated.
+const systemPAC = false
+
+// pacPrelude defines the functions available to PAC files. Name resolution is
+// done by chezmoi before the PAC file is evaluated, so dnsResolve only
+// resolves the host of the URL and myIpAddress returns the address of the
+// interface with the default route.
+const pacPrelude = `var __hosts = %s;
+var __myIpAddress = %s;
+function dnsResolve(host) { return __hosts.hasOwnProperty(host) ? __hosts[host] : null; }
+function myIpAddress() { return __myIpAddress; }
+function isPlainHostName(host) { return host.indexOf(".") < 0; }
+function dnsDomainIs(host, domain) { return host.length >= domain.length && host.substring(host.length - domain.length) === domain; }
+function localHostOrDomainIs(host, hostdom) { return host === hostdom || hostdom.lastIndexOf(host + ".", 0) === 0; }
+function isResolvable(host) { return dnsResolve(host) !== null; }
+function dnsDomainLevels(host) { return host.split(".").length - 1; }
+function __ipToInt(ip) {
+	var o = ip.split(".");
+	return o.length === 4 ? ((o[0] << 24) | (o[1] << 16) | (o[2] << 8) | o[3]) >>> 0 : null;
+}
+function isInNet(host, pattern, mask) {
+	var ip = /^\d+\.\d+\.\d+\.\d+$/.test(host) ? host : dnsResolve(host);
+	if (ip === null) { return false; }
+	var m = __ipToInt(mask);
+	return ((__ipToInt(ip) & m) >>> 0) === ((__ipToInt(pattern) & m) >>> 0);
+}
+function shExpMatch(str, shexp) {
+	var re = shexp.replace(/[.+^${}()|[\]\\]/g, "\\$&").replace(/\*/g, ".*").replace(/\?/g, ".");
+	return new RegExp("^" + re + "$").test(str);
+}
+var __days = ["SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"];
+function weekdayRange(wd1, wd2, gmt) {
+	if (wd2 === "GMT") { gmt = wd2; wd2 = undefined; }
+	var now = new Date(), day = gmt === "GMT" ? now.getUTCDay() : now.getDay();
+	var d1 = __days.indexOf(wd1), d2 = wd2 === undefined ? d1 : __days.indexOf(wd2);
+	return d1 <= d2 ? d1 <= day && day <= d2 : day >= d1 || day <= d2;
+}
+function timeRange() {
+	var args = Array.prototype.slice.call(arguments), gmt = args[args.length - 1] === "GMT";
+	if (gmt) { args.pop(); }
+	var now = new Date();
+	var h = gmt ? now.getUTCHours() : now.getHours();
+	var s = h * 3600 + (gmt ? now.getUTCMinutes() : now.getMinutes()) * 60 + (gmt ? now.getUTCSeconds() : now.getSeconds());
+	switch (args.length) {
+	case 1: return h === args[0];
+	case 2: return args[0] <= h && h < args[1];
+	case 4: return args[0] * 3600 + args[1] * 60 <= s && s < args[2] * 3600 + args[3] * 60;
+	case 6: return args[0] * 3600 + args[1] * 60 + args[2] <= s && s <= args[3] * 3600 + args[4] * 60 + args[5];
+	default: return false;
+	}
+}
+function dateRange() { return false; }
+function alert() {}
+`
+
+// pacEpilogue calls FindProxyForURL and outputs its result. node writes it to
+// stdout explicitly, osascript prints the value of the last statement.
+const pacEpilogue = `
+var __result = String(FindProxyForURL(%s, %s));
+if (typeof process !== "undefined") { process.stdout.write(__result + "\n"); }
+__result;
+`
+
+// getPACProxy returns the proxy for u from the PAC file pac, which is
+// evaluated with proxy.pacCommand. Web Proxy Auto-Discovery is only supported
+// on Windows, so autoDetect is ignored.
+func (c *Config) getPACProxy(pac string, autoDetect bool, u *url.URL) (*url.URL, error) {
+	if pac == "" {
+		return nil, nil
+	}
+
+	// As in browsers, only the scheme and host of the URL are passed to the
+	// PAC file, so the result can be cached for each scheme and host.
+	pacURL := u.Scheme + "://" + u.Host + "/"
+	key := pac + " " + pacURL
+
+	c.proxyCache.Lock()
+	defer c.proxyCache.Unlock()
+	if proxyURL, ok := c.proxyCache.pacResults[key]; ok {
+		return proxyURL, nil
+	}
+
+	script, ok := c.proxyCache.pacScripts[pac]
+	if !ok {
+		var err error
+		script, err = readPACScript(pac)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pac, err)
+		}
+		if c.proxyCache.pacScripts == nil {
+			c.proxyCache.pacScripts = make(map[string][]byte)
+		}
+		c.proxyCache.pacScripts[pac] = script
+	}
+
+	result, err := c.evalPACScript(script, pacURL, u.Hostname())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pac, err)
+	}
+	proxyURL, err := parsePACResult(result)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pac, err)
+	}
+	if c.proxyCache.pacResults == nil {
+		c.proxyCache.pacResults = make(map[string]*url.URL)
+	}
+	c.proxyCache.pacResults[key] = proxyURL
+	return proxyURL, nil
+}
+
+// readPACScript returns the contents of the PAC file pac, which is either an
+// https URL or a filename. PAC files are always fetched without a proxy. PAC
+// files are evaluated as code, so they are not fetched over plain http.
+func readPACScript(pac string) ([]byte, error) {
+	switch pacURL, err := url.Parse(pac); {
+	case err == nil && pacURL.Scheme == "http":
+		return nil, errors.New("PAC files must be fetched with https")
+	case err == nil && pacURL.Scheme == "https":
+	case err == nil && pacURL.Scheme == "file":
+		return ioutil.ReadFile(pacURL.Path)
+	default:
+		return ioutil.ReadFile(pac)
+	}
+	client := &http.Client{
+		Transport: &http.Transport{},
+		Timeout:   30 * time.Second,
+	}
+	resp, err := client.Get(pac)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// evalPACScript returns the result of calling the FindProxyForURL function of
+// script with rawURL and host.
+func (c *Config) evalPACScript(script []byte, rawURL, host string) (string, error) {
+	hosts := make(map[string]string)
+	if ips, err := net.LookupIP(host); err == nil {
+		for _, ip := range ips {
+			if ip4 := ip.To4(); ip4 != nil {
+				hosts[host] = ip4.String()
+				break
+			}
+		}
+	}
+	myIPAddress := getPrimaryIP("udp4", "192.0.2.1:9")
+	if myIPAddress == "" {
+		myIPAddress = "127.0.0.1"
+	}
+	hostsJSON, err := json.Marshal(hosts)
+	if err != nil {
+		return "", err
+	}
+	myIPAddressJSON, err := json.Marshal(myIPAddress)
+	if err != nil {
+		return "", err
+	}
+	rawURLJSON, err := json.Marshal(rawURL)
+	if err != nil {
+		return "", err
+	}
+	hostJSON, err := json.Marshal(host)
+	if err != nil {
+		return "", err
+	}
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, pacPrelude, hostsJSON, myIPAddressJSON)
+	b.Write(script)
+	fmt.Fprintf(b, pacEpilogue, rawURLJSON, hostJSON)
+
+	name, args := c.getPACCommand()
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = b
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// getPACCommand returns the command used to evaluate PAC files, by default
+// osascript on macOS and node elsewhere.
+func (c *Config) getPACCommand() (string, []string) {
+	switch {
+	case c.Proxy.PACCommand != "":
+		return c.Proxy.PACCommand, c.Proxy.PACArgs
+	case runtime.GOOS == "darwin":
+		return "osascript", []string{"-l", "JavaScript"}
+	default:
+		return "node", nil
+	}
+}
@@ -0,0 +1,125 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestGetPACProxy(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node not found in $PATH")
+	}
+
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-proxy-pac")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	pacFile := filepath.Join(tempDir, "proxy.pac")
+	require.NoError(t, ioutil.WriteFile(pacFile, []byte(""+
+		"function FindProxyForURL(url, host) {\n"+
+		"  if (isPlainHostName(host) || dnsDomainIs(host, \".corp.example.com\")) {\n"+
+		"    return \"DIRECT\";\n"+
+		"  }\n"+
+		"  if (shExpMatch(url, \"https://*.github.com/*\")) {\n"+
+		"    return \"PROXY github-proxy.example.com:3128\";\n"+
+		"  }\n"+
+		"  if (isInNet(host, \"10.0.0.0\", \"255.0.0.0\")) {\n"+
+		"    return \"DIRECT\";\n"+
+		"  }\n"+
+		"  return \"PROXY proxy.example.com:3128; DIRECT\";\n"+
+		"}\n",
+	), 0o666))
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	c.Proxy.PAC = pacFile
+	for _, tc := range []struct {
+		rawURL   string
+		expected string
+	}{
+		{rawURL: "https://intranet/", expected: ""},
+		{rawURL: "https://git.corp.example.com/", expected: ""},
+		{rawURL: "https://api.github.com/repos", expected: "http://github-proxy.example.com:3128"},
+		{rawURL: "http://10.1.2.3/", expected: ""},
+		{rawURL: "https://example.com/", expected: "http://proxy.example.com:3128"},
+	} {
+		t.Run(tc.rawURL, func(t *testing.T) {
+			u, err := url.Parse(tc.rawURL)
+			require.NoError(t, err)
+			proxyURL, err := c.getProxy(u)
+			require.NoError(t, err)
+			if tc.expected == "" {
+				assert.Nil(t, proxyURL)
+			} else {
+				assert.Equal(t, tc.expected, proxyURL.String())
+			}
+		})
+	}
+}
+
+func TestGetPACProxyFallback(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-proxy-pac")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	pacFile := filepath.Join(tempDir, "proxy.pac")
+	require.NoError(t, ioutil.WriteFile(pacFile, []byte("function FindProxyForURL(url, host) { return \"DIRECT\"; }\n"), 0o666))
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name           string
+		pac            string
+		pacCommand     string
+		expectedStderr string
+	}{
+		{
+			name:           "http",
+			pac:            "http://wpad.example.com/proxy.pac",
+			expectedStderr: "warning: http://wpad.example.com/proxy.pac: PAC files must be fetched with https, connecting directly\n",
+		},
+		{
+			name:           "missing",
+			pac:            filepath.Join(tempDir, "missing.pac"),
+			expectedStderr: "warning: " + filepath.Join(tempDir, "missing.pac") + ": ",
+		},
+		{
+			name:           "command_fails",
+			pac:            pacFile,
+			pacCommand:     "false",
+			expectedStderr: "warning: " + pacFile + ": false: exit status 1, connecting directly\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			c := newTestConfig(fs)
+			c.Stderr = stderr
+			c.Proxy.PAC = tc.pac
+			c.Proxy.PACCommand = tc.pacCommand
+			for _, rawURL := range []string{"https://example.com/", "https://example.org/"} {
+				u, err := url.Parse(rawURL)
+				require.NoError(t, err)
+				proxyURL, err := c.getProxy(u)
+				require.NoError(t, err)
+				assert.Nil(t, proxyURL)
+			}
+			assert.True(t, strings.HasPrefix(stderr.String(), tc.expectedStderr))
+			assert.Equal(t, 1, strings.Count(stderr.String(), "warning: "))
+		})
+	}
+}
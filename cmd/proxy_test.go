@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestGetProxy(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	c.Proxy.URL = "proxy.example.com:3128"
+	c.Proxy.NoProxy = "internal.example.com,10.0.0.0/8"
+	for _, tc := range []struct {
+		rawURL   string
+		expected string
+	}{
+		{rawURL: "https://github.com/", expected: "http://proxy.example.com:3128"},
+		{rawURL: "https://git.internal.example.com/", expected: ""},
+		{rawURL: "http://10.1.2.3/", expected: ""},
+		{rawURL: "http://127.0.0.1:8080/", expected: ""},
+		{rawURL: "http://localhost/", expected: ""},
+	} {
+		t.Run(tc.rawURL, func(t *testing.T) {
+			u, err := url.Parse(tc.rawURL)
+			require.NoError(t, err)
+			proxyURL, err := c.getProxy(u)
+			require.NoError(t, err)
+			if tc.expected == "" {
+				assert.Nil(t, proxyURL)
+			} else {
+				assert.Equal(t, tc.expected, proxyURL.String())
+			}
+		})
+	}
+}
+
+func TestMatchNoProxy(t *testing.T) {
+	noProxy := []string{"*.corp.example.com", ".example.org", "example.net:8080", "169.254/16", "fd00::/8", "<local>"}
+	for _, tc := range []struct {
+		host     string
+		expected bool
+	}{
+		{host: "git.corp.example.com", expected: true},
+		{host: "corp.example.com", expected: true},
+		{host: "example.com", expected: false},
+		{host: "www.example.org", expected: true},
+		{host: "example.net", expected: true},
+		{host: "169.254.169.254", expected: true},
+		{host: "169.255.0.1", expected: false},
+		{host: "fd00::1", expected: true},
+		{host: "intranet", expected: true},
+		{host: "github.com", expected: false},
+	} {
+		assert.Equal(t, tc.expected, matchNoProxy(tc.host, noProxy), tc.host)
+	}
+	assert.True(t, matchNoProxy("github.com", []string{"*"}))
+}
+
+func TestParseSCUtilProxyOutput(t *testing.T) {
+	assert.Nil(t, parseSCUtilProxyOutput([]byte("<dictionary> {\n  FTPPassive : 1\n}\n")))
+	assert.Equal(t, &systemProxy{
+		HTTP:    "proxy.example.com:8080",
+		HTTPS:   "proxy.example.com:8443",
+		NoProxy: []string{"*.local", "169.254/16"},
+		PAC:     "http://pac.example.com/proxy.pac",
+	}, parseSCUtilProxyOutput([]byte(""+
+		"<dictionary> {\n"+
+		"  ExceptionsList : <array> {\n"+
+		"    0 : *.local\n"+
+		"    1 : 169.254/16\n"+
+		"  }\n"+
+		"  FTPPassive : 1\n"+
+		"  HTTPEnable : 1\n"+
+		"  HTTPPort : 8080\n"+
+		"  HTTPProxy : proxy.example.com\n"+
+		"  HTTPSEnable : 1\n"+
+		"  HTTPSPort : 8443\n"+
+		"  HTTPSProxy : proxy.example.com\n"+
+		"  ProxyAutoConfigEnable : 1\n"+
+		"  ProxyAutoConfigURLString : http://pac.example.com/proxy.pac\n"+
+		"}\n",
+	)))
+}
+
+func TestParseWinHTTPProxy(t *testing.T) {
+	for _, tc := range []struct {
+		proxy         string
+		proxyBypass   string
+		expectedHTTP  string
+		expectedHTTPS string
+		expectedNo    []string
+	}{
+		{
+			proxy:         "proxy.example.com:3128",
+			proxyBypass:   "*.example.com;<local>",
+			expectedHTTP:  "proxy.example.com:3128",
+			expectedHTTPS: "proxy.example.com:3128",
+			expectedNo:    []string{"*.example.com", "<local>"},
+		},
+		{
+			proxy:         "http=proxy.example.com:80;https=proxy.example.com:443;ftp=ftp.example.com:21",
+			expectedHTTP:  "proxy.example.com:80",
+			expectedHTTPS: "proxy.example.com:443",
+			expectedNo:    []string{},
+		},
+	} {
+		actualHTTP, actualHTTPS, actualNo := parseWinHTTPProxy(tc.proxy, tc.proxyBypass)
+		assert.Equal(t, tc.expectedHTTP, actualHTTP)
+		assert.Equal(t, tc.expectedHTTPS, actualHTTPS)
+		assert.Equal(t, tc.expectedNo, actualNo)
+	}
+}
+
+func TestParsePACResult(t *testing.T) {
+	for _, tc := range []struct {
+		result   string
+		expected string
+		wantErr  bool
+	}{
+		{result: "DIRECT", expected: ""},
+		{result: "", expected: ""},
+		{result: "PROXY proxy.example.com:3128; DIRECT", expected: "http://proxy.example.com:3128"},
+		{result: "HTTPS proxy.example.com:443", expected: "https://proxy.example.com:443"},
+		{result: "SOCKS5 socks.example.com:1080", expected: "socks5://socks.example.com:1080"},
+		{result: "PROXY", wantErr: true},
+		{result: "QUIC proxy.example.com:443", wantErr: true},
+	} {
+		proxyURL, err := parsePACResult(tc.result)
+		if tc.wantErr {
+			assert.Error(t, err, tc.result)
+			continue
+		}
+		require.NoError(t, err, tc.result)
+		if tc.expected == "" {
+			assert.Nil(t, proxyURL, tc.result)
+		} else {
+			assert.Equal(t, tc.expected, proxyURL.String(), tc.result)
+		}
+	}
+}
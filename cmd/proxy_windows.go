@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	winHTTPAccessTypeNamedProxy   = 3
+	winHTTPAutoProxyAutoDetect    = 0x00000001
+	winHTTPAutoProxyConfigURL     = 0x00000002
+	winHTTPAutoDetectTypeDHCP     = 0x00000001
+	winHTTPAutoDetectTypeDNSA     = 0x00000002
+	winHTTPAccessTypeDefaultProxy = 0
+)
+
+var (
+	winHTTP                                   = windows.NewLazySystemDLL("winhttp.dll")
+	procWinHTTPCloseHandle                    = winHTTP.NewProc("WinHttpCloseHandle")
+	procWinHTTPGetIEProxyConfigForCurrentUser = winHTTP.NewProc("WinHttpGetIEProxyConfigForCurrentUser")
+	procWinHTTPGetProxyForURL                 = winHTTP.NewProc("WinHttpGetProxyForUrl")
+	procWinHTTPOpen                           = winHTTP.NewProc("WinHttpOpen")
+	procGlobalFree                            = windows.NewLazySystemDLL("kernel32.dll").NewProc("GlobalFree")
+)
+
+// A winHTTPCurrentUserIEProxyConfig is a WINHTTP_CURRENT_USER_IE_PROXY_CONFIG.
+type winHTTPCurrentUserIEProxyConfig struct {
+	autoDetect    int32
+	autoConfigURL *uint16
+	proxy         *uint16
+	proxyBypass   *uint16
+}
+
+// A winHTTPAutoProxyOptions is a WINHTTP_AUTOPROXY_OPTIONS.
+type winHTTPAutoProxyOptions struct {
+	flags                 uint32
+	autoDetectFlags       uint32
+	autoConfigURL         *uint16
+	reserved1             uintptr
+	reserved2             uint32
+	autoLogonIfChallenged int32
+}
+
+// A winHTTPProxyInfo is a WINHTTP_PROXY_INFO.
+type winHTTPProxyInfo struct {
+	accessType  uint32
+	proxy       *uint16
+	proxyBypass *uint16
+}
+
+// systemPAC is true as WinHTTP evaluates PAC files itself, as it does for
+// other programs that use the system proxy settings.
+const systemPAC = true
+
+// readSystemProxy returns the current user's proxy settings, as configured in
+// the Internet Options control panel, reported by WinHTTP.
+func (c *Config) readSystemProxy() *systemProxy {
+	var config winHTTPCurrentUserIEProxyConfig
+	if r, _, _ := procWinHTTPGetIEProxyConfigForCurrentUser.Call(uintptr(unsafe.Pointer(&config))); r == 0 {
+		return nil
+	}
+	autoConfigURL := takeGlobalUTF16String(config.autoConfigURL)
+	proxy := takeGlobalUTF16String(config.proxy)
+	proxyBypass := takeGlobalUTF16String(config.proxyBypass)
+
+	httpProxy, httpsProxy, noProxy := parseWinHTTPProxy(proxy, proxyBypass)
+	if httpProxy == "" && httpsProxy == "" && autoConfigURL == "" && config.autoDetect == 0 {
+		return nil
+	}
+	return &systemProxy{
+		HTTP:       httpProxy,
+		HTTPS:      httpsProxy,
+		NoProxy:    noProxy,
+		PAC:        autoConfigURL,
+		AutoDetect: config.autoDetect != 0,
+	}
+}
+
+// getPACProxy returns the proxy for u from the PAC file pac, or from the PAC
+// file found by Web Proxy Auto-Discovery if autoDetect is true, as evaluated
+// by WinHTTP.
+func (c *Config) getPACProxy(pac string, autoDetect bool, u *url.URL) (*url.URL, error) {
+	key := fmt.Sprintf("%s %t %s://%s/", pac, autoDetect, u.Scheme, u.Host)
+	c.proxyCache.Lock()
+	defer c.proxyCache.Unlock()
+	if proxyURL, ok := c.proxyCache.pacResults[key]; ok {
+		return proxyURL, nil
+	}
+
+	session, _, err := procWinHTTPOpen.Call(0, winHTTPAccessTypeDefaultProxy, 0, 0, 0)
+	if session == 0 {
+		return nil, fmt.Errorf("WinHttpOpen: %w", err)
+	}
+	defer procWinHTTPCloseHandle.Call(session) //nolint:errcheck
+
+	var options winHTTPAutoProxyOptions
+	options.autoLogonIfChallenged = 1
+	if autoDetect {
+		options.flags |= winHTTPAutoProxyAutoDetect
+		options.autoDetectFlags = winHTTPAutoDetectTypeDHCP | winHTTPAutoDetectTypeDNSA
+	}
+	if pac != "" {
+		options.flags |= winHTTPAutoProxyConfigURL
+		options.autoConfigURL, err = windows.UTF16PtrFromString(pac)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rawURL, err := windows.UTF16PtrFromString(u.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var info winHTTPProxyInfo
+	if r, _, err := procWinHTTPGetProxyForURL.Call(session, uintptr(unsafe.Pointer(rawURL)), uintptr(unsafe.Pointer(&options)), uintptr(unsafe.Pointer(&info))); r == 0 {
+		return nil, fmt.Errorf("%s: WinHttpGetProxyForUrl: %w", pac, err)
+	}
+	proxy := takeGlobalUTF16String(info.proxy)
+	_ = takeGlobalUTF16String(info.proxyBypass)
+
+	var proxyURL *url.URL
+	if info.accessType == winHTTPAccessTypeNamedProxy {
+		httpProxy, httpsProxy, _ := parseWinHTTPProxy(proxy, "")
+		if u.Scheme == "https" && httpsProxy != "" {
+			httpProxy = httpsProxy
+		}
+		if proxyURL, err = parseProxyURL(httpProxy); err != nil {
+			return nil, err
+		}
+	}
+	if c.proxyCache.pacResults == nil {
+		c.proxyCache.pacResults = make(map[string]*url.URL)
+	}
+	c.proxyCache.pacResults[key] = proxyURL
+	return proxyURL, nil
+}
+
+// takeGlobalUTF16String returns the string at p and frees p, which was
+// allocated by WinHTTP with GlobalAlloc.
+func takeGlobalUTF16String(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	s := windows.UTF16PtrToString(p)
+	procGlobalFree.Call(uintptr(unsafe.Pointer(p))) //nolint:errcheck
+	return s
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -51,19 +52,14 @@ PATH:
 		case err != nil:
 			return err
 		}
-		if !c.purge.force {
-			choice, err := c.prompt(fmt.Sprintf("Remove %s", path), "ynqa")
-			if err != nil {
-				return err
-			}
-			switch choice {
-			case 'a':
-				c.purge.force = true
-			case 'n':
-				continue PATH
-			case 'q':
-				return nil
-			}
+		ok, err := c.confirm(fmt.Sprintf("Remove %s", path), &c.purge.force)
+		switch {
+		case errors.Is(err, errConfirmQuit):
+			return nil
+		case err != nil:
+			return err
+		case !ok:
+			continue PATH
 		}
 		if err := c.mutator.RemoveAll(path); err != nil {
 			return err
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"io"
+	"time"
+)
+
+// A rateLimitedReader is an io.Reader that reads from r at no more than
+// bytesPerSecond on average.
+type rateLimitedReader struct {
+	r              io.Reader
+	bytesPerSecond int64
+	start          time.Time
+	n              int64
+	now            func() time.Time
+	sleep          func(time.Duration)
+}
+
+// newRateLimitedReader returns a new rateLimitedReader that reads from r at no
+// more than bytesPerSecond.
+func newRateLimitedReader(r io.Reader, bytesPerSecond int64) *rateLimitedReader {
+	return &rateLimitedReader{
+		r:              r,
+		bytesPerSecond: bytesPerSecond,
+		now:            time.Now,
+		sleep:          time.Sleep,
+	}
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if r.start.IsZero() {
+		r.start = r.now()
+	}
+	// Read in chunks of at most a tenth of a second's worth of data so that
+	// bursts are short.
+	if chunk := r.bytesPerSecond / 10; chunk > 0 && int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	expected := time.Duration(float64(r.n) / float64(r.bytesPerSecond) * float64(time.Second))
+	if delay := expected - r.now().Sub(r.start); delay > 0 {
+		r.sleep(delay)
+	}
+	return n, err
+}
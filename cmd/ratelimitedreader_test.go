@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitedReader(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := bytes.Repeat([]byte{'a'}, 2500)
+	r := newRateLimitedReader(bytes.NewReader(data), 1000)
+	r.now = func() time.Time {
+		return now
+	}
+	var sleeps []time.Duration
+	r.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	}
+
+	actual, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, actual)
+	var total time.Duration
+	for _, sleep := range sleeps {
+		assert.True(t, sleep <= 100*time.Millisecond)
+		total += sleep
+	}
+	assert.Equal(t, 2500*time.Millisecond, total)
+}
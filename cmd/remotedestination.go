@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// remoteFactsScript prints the facts about the remote system that are used as
+// template data, one per line. Containers often run as users without names, so
+// the user ID is printed instead.
+const remoteFactsScript = `uname -s && uname -m && uname -n && { id -un 2>/dev/null || id -u; } && printf '%s\n' "$HOME"`
+
+// A remoteDestination is a destination directory on a remote system, either a
+// remote host accessed with ssh or a container, that is read and updated by
+// running commands on the remote system.
+type remoteDestination struct {
+	url      string
+	name     string
+	fs       *chezmoi.RemoteFS
+	mutator  *chezmoi.RemoteMutator
+	close    func()
+	os       string
+	arch     string
+	hostname string
+	username string
+	homeDir  string
+}
+
+// isRemoteDestination returns whether destDir is the URL of a remote
+// destination.
+func isRemoteDestination(destDir string) bool {
+	return isSSHDestination(destDir) || isContainerDestination(destDir)
+}
+
+// useRemoteDestination replaces the destination directory, which must be the
+// URL of a remote destination, with the directory on the remote system and
+// makes all changes to the remote system.
+func (c *Config) useRemoteDestination(cmd *cobra.Command) error {
+	switch {
+	case runtime.GOOS == "windows":
+		return fmt.Errorf("%s: remote destinations are not supported on windows", c.DestDir)
+	case getCommandName(cmd) != "apply":
+		return fmt.Errorf("%s: remote destinations are only supported by apply", c.DestDir)
+	case c.user != "":
+		return fmt.Errorf("%s: remote destinations cannot be used with --user", c.DestDir)
+	case c.Remove:
+		return fmt.Errorf("%s: remote destinations cannot be used with --remove", c.DestDir)
+	}
+
+	var d *remoteDestination
+	var remoteDir string
+	var err error
+	if isSSHDestination(c.DestDir) {
+		d, remoteDir, err = c.newSSHDestination(c.DestDir)
+	} else {
+		d, remoteDir, err = c.newContainerDestination(c.DestDir)
+	}
+	if err != nil {
+		return err
+	}
+	c.remoteDestination = d
+
+	if err := d.getFacts(); err != nil {
+		c.closeRemoteDestination()
+		return fmt.Errorf("%s: %w", d.url, err)
+	}
+	remoteDir, err = d.absDir(remoteDir)
+	if err != nil {
+		c.closeRemoteDestination()
+		return err
+	}
+	c.DestDir = remoteDir
+	d.mutator = chezmoi.NewRemoteMutator(d.fs)
+	c.mutator = d.mutator
+	return nil
+}
+
+// closeRemoteDestination releases any resources used to access the remote
+// destination, if any.
+func (c *Config) closeRemoteDestination() {
+	d := c.remoteDestination
+	if d == nil {
+		return
+	}
+	if d.close != nil {
+		d.close()
+	}
+	c.remoteDestination = nil
+}
+
+// absDir returns the absolute path of dir on the remote system. An empty dir is
+// the remote user's home directory, and a dir of ~, or beginning with ~/, is
+// relative to it.
+func (d *remoteDestination) absDir(dir string) (string, error) {
+	switch {
+	case dir == "" || dir == "~":
+		dir = d.homeDir
+	case strings.HasPrefix(dir, "~/"):
+		dir = path.Join(d.homeDir, dir[2:])
+	}
+	if !path.IsAbs(dir) {
+		return "", fmt.Errorf("%s: remote directory must be absolute", d.url)
+	}
+	return dir, nil
+}
+
+// checkRemoteExternals returns an error if ts contains a git-repo external,
+// which cannot be applied to a remote destination as the git commands that
+// inspect the repository run on the local system.
+func checkRemoteExternals(ts *chezmoi.TargetState) error {
+	for _, entry := range ts.AllEntries() {
+		external, ok := entry.(*chezmoi.External)
+		if !ok || ts.TargetIgnore.Match(external.TargetName()) {
+			continue
+		}
+		if external.Type == chezmoi.ExternalTypeGitRepo {
+			return fmt.Errorf("%s: git-repo externals cannot be applied to remote destinations", external.TargetName())
+		}
+	}
+	return nil
+}
+
+// getFacts sets the facts about the remote system.
+func (d *remoteDestination) getFacts() error {
+	output, err := d.fs.Output(nil, remoteFactsScript)
+	if err != nil {
+		return err
+	}
+	facts := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	if len(facts) != 5 || facts[4] == "" {
+		return fmt.Errorf("cannot determine remote system facts")
+	}
+	d.os = remoteOS(facts[0])
+	d.arch = remoteArch(facts[1])
+	d.hostname = facts[2]
+	d.username = facts[3]
+	d.homeDir = facts[4]
+	return nil
+}
+
+// setRemoteData overrides the facts in data that describe the machine with the
+// facts about the remote system. Facts that cannot be determined cheaply on the
+// remote system are removed.
+func (d *remoteDestination) setRemoteData(data map[string]interface{}) error {
+	for _, key := range []string{"chassis", "display", "group", "hasBattery", "kernel", "machineID", "network", "xdg"} {
+		delete(data, key)
+	}
+	data["android"] = false
+	data["os"] = d.os
+	data["arch"] = d.arch
+	data["fullHostname"] = d.hostname
+	data["fqdnHostname"] = d.hostname
+	data["hostname"] = strings.SplitN(d.hostname, ".", 2)[0]
+	data["username"] = d.username
+	data["homedir"] = d.homeDir
+	osRelease, err := readOSRelease(d.fs, []string{"/etc/os-release", "/usr/lib/os-release"})
+	switch {
+	case err == nil:
+		data["osRelease"] = upperSnakeCaseToCamelCaseMap(osRelease)
+	case os.IsNotExist(err):
+		delete(data, "osRelease")
+	default:
+		return err
+	}
+	return nil
+}
+
+// remoteOS returns the GOOS equivalent of sysname, the output of uname -s.
+func remoteOS(sysname string) string {
+	switch sysname = strings.ToLower(sysname); sysname {
+	case "sunos":
+		return "solaris"
+	default:
+		return sysname
+	}
+}
+
+// remoteArch returns the GOARCH equivalent of machine, the output of uname -m.
+func remoteArch(machine string) string {
+	switch machine {
+	case "x86_64", "amd64":
+		return "amd64"
+	case "aarch64", "arm64":
+		return "arm64"
+	case "i386", "i486", "i586", "i686", "i86pc":
+		return "386"
+	case "armv5l", "armv6l", "armv7l":
+		return "arm"
+	default:
+		return machine
+	}
+}
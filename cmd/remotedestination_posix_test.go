@@ -0,0 +1,206 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestRemoteDestinationApply(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("external\n"))
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name               string
+		destDir            string
+		setCommand         func(*Config, string)
+		expectedArgsSuffix string
+	}{
+		{
+			name:    "ssh",
+			destDir: "ssh://user@remote/~",
+			setCommand: func(c *Config, command string) {
+				c.SSH.Command = command
+			},
+			expectedArgsSuffix: " -- user@remote",
+		},
+		{
+			name:    "docker",
+			destDir: "docker://user@debug",
+			setCommand: func(c *Config, command string) {
+				c.Docker.Command = command
+			},
+			expectedArgsSuffix: "exec --interactive --user user debug sh -c",
+		},
+		{
+			name:    "podman",
+			destDir: "podman://debug/~",
+			setCommand: func(c *Config, command string) {
+				c.Podman.Command = command
+				c.Podman.Args = []string{"--remote"}
+			},
+			expectedArgsSuffix: "--remote exec --interactive debug sh -c",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiexternal.toml":  "[\".external\"]\ntype = \"file\"\nurl = \"" + server.URL + "/external\"\n",
+					"dot_bashrc":             "bashrc",
+					"dot_gitconfig.tmpl":     "{{ .chezmoi.hostname }} {{ .chezmoi.os }} {{ .chezmoi.homedir }}\n",
+					"executable_dot_profile": "profile",
+					"private_dot_ssh/keys":   "keys",
+					"symlink_dot_link":       ".bashrc",
+					"run_once_install.sh":    "#!/bin/sh\necho $CHEZMOI_SOURCE_FILE >> installed\n",
+				},
+				"/bin":         &vfst.Dir{Perm: 0755},
+				"/remote/home": &vfst.Dir{Perm: 0755},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			remoteHome, err := fs.RawPath("/remote/home")
+			require.NoError(t, err)
+			argsLog, err := fs.RawPath("/args.log")
+			require.NoError(t, err)
+
+			// The fake command logs its arguments and runs the remote
+			// command, which is always its last argument, locally with the
+			// remote home directory.
+			require.NoError(t, fs.WriteFile("/bin/remote", []byte(""+
+				"#!/bin/sh\n"+
+				"args=\n"+
+				"while [ $# -gt 1 ]; do args=\"$args $1\"; shift; done\n"+
+				"printf '%s\\n' \"$args\" >> "+argsLog+"\n"+
+				"HOME="+remoteHome+" exec sh -c \"$1\"\n",
+			), 0755))
+			command, err := fs.RawPath("/bin/remote")
+			require.NoError(t, err)
+			hostname, err := os.Hostname()
+			require.NoError(t, err)
+
+			for i := 0; i < 2; i++ {
+				stdout := &bytes.Buffer{}
+				c := newTestConfig(fs, withStdout(stdout))
+				tc.setCommand(c, command)
+				c.DestDir = tc.destDir
+				c.apply.force = true
+				require.NoError(t, c.useRemoteDestination(applyCmd))
+				assert.Equal(t, remoteHome, c.DestDir)
+				err := c.runApplyCmd(applyCmd, nil)
+				c.closeRemoteDestination()
+				require.NoError(t, err)
+			}
+
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/remote/home/.bashrc",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0644),
+					vfst.TestContentsString("bashrc"),
+				),
+				vfst.TestPath("/remote/home/.gitconfig",
+					vfst.TestContentsString(strings.SplitN(hostname, ".", 2)[0]+" "+runtime.GOOS+" "+remoteHome+"\n"),
+				),
+				vfst.TestPath("/remote/home/.profile",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0755),
+					vfst.TestContentsString("profile"),
+				),
+				vfst.TestPath("/remote/home/.ssh",
+					vfst.TestIsDir,
+					vfst.TestModePerm(0700),
+				),
+				vfst.TestPath("/remote/home/.ssh/keys",
+					vfst.TestContentsString("keys"),
+				),
+				vfst.TestPath("/remote/home/.link",
+					vfst.TestModeType(os.ModeSymlink),
+					vfst.TestSymlinkTarget(".bashrc"),
+				),
+				vfst.TestPath("/remote/home/installed",
+					vfst.TestContentsString("run_once_install.sh\n"),
+				),
+				vfst.TestPath("/remote/home/.external",
+					vfst.TestContentsString("external\n"),
+				),
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/.chezmoiexternal.lock",
+					vfst.TestModeIsRegular,
+				),
+			)
+
+			data, err := fs.ReadFile("/args.log")
+			require.NoError(t, err)
+			for _, args := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+				assert.True(t, strings.HasSuffix(args, tc.expectedArgsSuffix), args)
+			}
+		})
+	}
+}
+
+func TestRemoteDestinationErrors(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/bin": &vfst.Dir{Perm: 0755},
+		"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": "" +
+			"[\".repo\"]\n" +
+			"type = \"git-repo\"\n" +
+			"url = \"https://github.com/example/repo.git\"\n",
+		"/remote/home": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	c.DestDir = "ssh://remote"
+	assert.Error(t, c.useRemoteDestination(diffCmd))
+
+	c = newTestConfig(fs)
+	c.DestDir = "ssh://remote"
+	c.Remove = true
+	assert.Error(t, c.useRemoteDestination(applyCmd))
+
+	c = newTestConfig(fs)
+	c.SSH.Command = "false"
+	c.DestDir = "ssh://remote"
+	assert.Error(t, c.useRemoteDestination(applyCmd))
+	assert.Nil(t, c.remoteDestination)
+
+	c = newTestConfig(fs)
+	c.Docker.Command = "false"
+	c.DestDir = "docker://debug"
+	assert.Error(t, c.useRemoteDestination(applyCmd))
+	assert.Nil(t, c.remoteDestination)
+
+	// git-repo externals are rejected before anything is applied.
+	remoteHome, err := fs.RawPath("/remote/home")
+	require.NoError(t, err)
+	require.NoError(t, fs.WriteFile("/bin/remote", []byte(""+
+		"#!/bin/sh\n"+
+		"while [ $# -gt 1 ]; do shift; done\n"+
+		"HOME="+remoteHome+" exec sh -c \"$1\"\n",
+	), 0755))
+	command, err := fs.RawPath("/bin/remote")
+	require.NoError(t, err)
+	c = newTestConfig(fs)
+	c.SSH.Command = command
+	c.DestDir = "ssh://remote"
+	c.apply.force = true
+	require.NoError(t, c.useRemoteDestination(applyCmd))
+	err = c.runApplyCmd(applyCmd, nil)
+	c.closeRemoteDestination()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ".repo: git-repo externals cannot be applied to remote destinations")
+}
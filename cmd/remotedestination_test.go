@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteArchAndOS(t *testing.T) {
+	assert.Equal(t, "amd64", remoteArch("x86_64"))
+	assert.Equal(t, "arm64", remoteArch("aarch64"))
+	assert.Equal(t, "386", remoteArch("i686"))
+	assert.Equal(t, "riscv64", remoteArch("riscv64"))
+	assert.Equal(t, "linux", remoteOS("Linux"))
+	assert.Equal(t, "darwin", remoteOS("Darwin"))
+	assert.Equal(t, "solaris", remoteOS("SunOS"))
+}
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type removeCmdConfig struct {
@@ -43,24 +46,22 @@ func (c *Config) runRemoveCmd(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 	for _, entry := range entries {
-		destDirPath := filepath.Join(c.DestDir, entry.TargetName())
+		destDirPath := chezmoi.TargetPath(c.DestDir, entry.TargetName())
 		sourceDirPath := filepath.Join(c.SourceDir, entry.SourceName())
-		if !c.remove.force {
-			choice, err := c.prompt(fmt.Sprintf("Remove %s and %s", destDirPath, sourceDirPath), "ynqa")
-			if err != nil {
-				return err
-			}
-			switch choice {
-			case 'y':
-			case 'n':
-				continue
-			case 'q':
-				return nil
-			case 'a':
-				c.remove.force = true
-			}
+		ok, err := c.confirm(fmt.Sprintf("Remove %s and %s", destDirPath, sourceDirPath), &c.remove.force)
+		switch {
+		case errors.Is(err, errConfirmQuit):
+			return nil
+		case err != nil:
+			return err
+		case !ok:
+			continue
+		}
+		mutator := c.mutator
+		if c.privilegedMutator != nil && filepath.IsAbs(entry.TargetName()) {
+			mutator = c.privilegedMutator
 		}
-		if err := c.mutator.RemoveAll(destDirPath); err != nil && !os.IsNotExist(err) {
+		if err := mutator.RemoveAll(destDirPath); err != nil && !os.IsNotExist(err) {
 			return err
 		}
 		if err := c.mutator.RemoveAll(sourceDirPath); err != nil && !os.IsNotExist(err) {
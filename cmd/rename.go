@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// getRenameFunc returns a chezmoi.RenameFunc that records files that were
+// moved in the destination directory as renames in the source directory. If
+// force is false then the user is prompted first, if stdin is a terminal.
+func (c *Config) getRenameFunc(force bool) chezmoi.RenameFunc {
+	return func(oldSourcePath, newSourcePath string) (bool, error) {
+		oldSourceName, err := filepath.Rel(c.SourceDir, oldSourcePath)
+		if err != nil {
+			return false, err
+		}
+		newSourceName, err := filepath.Rel(c.SourceDir, newSourcePath)
+		if err != nil {
+			return false, err
+		}
+		if !force {
+			if stdin, ok := c.Stdin.(*os.File); !ok || !terminal.IsTerminal(int(stdin.Fd())) {
+				return false, nil
+			}
+			choice, err := c.prompt(fmt.Sprintf("Record %s as a rename of %s", newSourceName, oldSourceName), "yn")
+			if err != nil {
+				return false, err
+			}
+			if choice == 'n' {
+				return false, nil
+			}
+		}
+		return true, c.renameSource(oldSourceName, newSourceName)
+	}
+}
+
+// renameSource renames oldSourceName to newSourceName in the source
+// directory. If the source directory is a repository of the source VCS then it
+// uses the source VCS so that the rename is recorded.
+func (c *Config) renameSource(oldSourceName, newSourceName string) error {
+	oldSourcePath := filepath.Join(c.SourceDir, oldSourceName)
+	if vcs, err := c.getVCS(); err == nil {
+		if moveArgs := vcs.MoveArgs(oldSourceName, newSourceName); moveArgs != nil && c.sourceDirIsVCSRepo() {
+			if err := c.run(c.SourceDir, c.SourceVCS.Command, moveArgs...); err != nil {
+				return err
+			}
+			// The source VCS does not move untracked files.
+			if _, err := c.fs.Lstat(oldSourcePath); os.IsNotExist(err) {
+				return nil
+			}
+		}
+	}
+	return c.mutator.Rename(oldSourcePath, filepath.Join(c.SourceDir, newSourceName))
+}
+
+// sourceDirIsVCSRepo returns true if the source directory is a repository of
+// the source VCS.
+func (c *Config) sourceDirIsVCSRepo() bool {
+	command := filepath.Base(c.SourceVCS.Command)
+	command = strings.TrimSuffix(command, filepath.Ext(command))
+	info, err := c.fs.Stat(filepath.Join(c.SourceDir, "."+command))
+	return err == nil && info.IsDir()
+}
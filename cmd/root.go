@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
@@ -42,6 +43,16 @@ func init() {
 		printErrorAndExit(err)
 	}
 
+	config.homeDir = chezmoi.NormalPath(homeDir)
+
+	// Termux has no /tmp, so use the Termux temporary directory if $TMPDIR is
+	// not set.
+	if termuxPrefix := getTermuxPrefix(os.Getenv); termuxPrefix != "" && os.Getenv("TMPDIR") == "" {
+		if err := os.Setenv("TMPDIR", filepath.Join(termuxPrefix, "tmp")); err != nil {
+			printErrorAndExit(err)
+		}
+	}
+
 	config.bds, err = xdg.NewBaseDirectorySpecification()
 	if err != nil {
 		printErrorAndExit(err)
@@ -51,6 +62,8 @@ func init() {
 
 	persistentFlags.StringVarP(&config.configFile, "config", "c", getDefaultConfigFile(config.bds), "config file")
 
+	persistentFlags.StringToStringVar(&config.dataOverrides, "data", nil, "override template data")
+
 	persistentFlags.BoolVarP(&config.DryRun, "dry-run", "n", false, "dry run")
 	panicOnError(viper.BindPFlag("dry-run", persistentFlags.Lookup("dry-run")))
 
@@ -75,38 +88,57 @@ func init() {
 	persistentFlags.BoolVar(&config.Debug, "debug", false, "write debug logs")
 	panicOnError(viper.BindPFlag("debug", persistentFlags.Lookup("debug")))
 
+	persistentFlags.StringVar(&config.user, "user", "", "apply to user's home directory as user")
+
 	cobra.OnInitialize(func() {
+		if err := setFlagsFromEnv(rootCmd.PersistentFlags(), os.Getenv); err != nil {
+			printErrorAndExit(err)
+		}
+
+		var configFiles []string
+		if systemConfigFile := config.getSystemConfigFile(vfs.OSFS); systemConfigFile != "" {
+			configFiles = append(configFiles, systemConfigFile)
+		}
 		_, err := os.Stat(config.configFile)
 		switch {
 		case err == nil:
-			viper.SetConfigFile(config.configFile)
-			config.err = viper.ReadInConfig()
-			if config.err == nil {
-				config.err = viper.Unmarshal(&config)
-			}
-			if config.err == nil {
-				config.err = config.validateData()
-			}
-			if config.err != nil {
-				rootCmd.Printf("warning: %s: %v\n", config.configFile, config.err)
-			}
-			if config.GPGRecipient != "" {
-				rootCmd.Printf("" +
-					"warning: your config file uses gpgRecipient which will be deprecated in v2\n" +
-					"warning: to disable this warning, set gpg.recipient in your config file instead\n",
-				)
-			}
-			if config.SourceVCS.Command != "" && !config.SourceVCS.NotGit && !strings.Contains(filepath.Base(config.SourceVCS.Command), "git") {
-				rootCmd.Printf("" +
-					"warning: it looks like you are using a version control system that is not git which will be deprecated in v2\n" +
-					"warning: please report this at https://github.com/twpayne/chezmoi/issues/459\n" +
-					"warning: to disable this warning, set sourceVCS.notGit = true in your config file\n",
-				)
-			}
+			configFiles = append(configFiles, config.configFile)
 		case os.IsNotExist(err):
 		default:
 			printErrorAndExit(err)
 		}
+		if len(configFiles) == 0 {
+			return
+		}
+
+		config.err = readConfigFiles(viper.GetViper(), vfs.OSFS, configFiles)
+		if config.err == nil {
+			config.err = viper.Unmarshal(&config)
+		}
+		if config.err == nil {
+			config.err = config.validateData()
+		}
+		if config.err == nil {
+			for _, configFile := range configFiles {
+				config.warnUnknownConfigKeys(vfs.OSFS, configFile)
+			}
+		}
+		if config.err != nil {
+			rootCmd.Printf("warning: %s: %v\n", configFiles[len(configFiles)-1], config.err)
+		}
+		if config.GPGRecipient != "" {
+			rootCmd.Printf("" +
+				"warning: your config file uses gpgRecipient which will be deprecated in v2\n" +
+				"warning: to disable this warning, set gpg.recipient in your config file instead\n",
+			)
+		}
+		if config.SourceVCS.Command != "" && !config.SourceVCS.NotGit && !strings.Contains(filepath.Base(config.SourceVCS.Command), "git") {
+			rootCmd.Printf("" +
+				"warning: it looks like you are using a version control system that is not git which will be deprecated in v2\n" +
+				"warning: please report this at https://github.com/twpayne/chezmoi/issues/459\n" +
+				"warning: to disable this warning, set sourceVCS.notGit = true in your config file\n",
+			)
+		}
 	})
 }
 
@@ -134,7 +166,18 @@ func Execute() {
 	}
 	rootCmd.Version = strings.Join(versionComponents, ", ")
 
-	if err := rootCmd.Execute(); err != nil {
+	if err := expandOSArgsAliases(); err != nil {
+		printErrorAndExit(err)
+	}
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	config.closeRemoteDestination()
+	if config.Metrics.File != "" || config.Metrics.Statsd != "" {
+		if err := config.writeMetrics(vfs.OSFS, config.newCommandMetrics(cmd, start, err)); err != nil {
+			rootCmd.Printf("warning: metrics: %v\n", err)
+		}
+	}
+	if err != nil {
 		printErrorAndExit(err)
 	}
 }
@@ -169,14 +212,41 @@ func (c *Config) persistentPreRunRootE(cmd *cobra.Command, args []string) error
 
 	c.fs = vfs.OSFS
 	c.mutator = chezmoi.NewFSMutator(config.fs)
+	if isRemoteDestination(c.DestDir) {
+		if err := c.useRemoteDestination(cmd); err != nil {
+			return err
+		}
+	}
+	if c.user != "" {
+		if err := c.useUser(c.user); err != nil {
+			return err
+		}
+	}
+	if c.RemoveToTrash && c.remoteDestination == nil {
+		c.mutator = chezmoi.NewTrashMutator(c.mutator, c.isTrashPath, c.newTrashFunc())
+	}
+	if (c.DegradedFS.Auto || len(c.DegradedFS.Paths) != 0) && c.remoteDestination == nil {
+		c.mutator = chezmoi.NewDegradedMutator(c.mutator, config.fs, c.isDegradedPath, c.Stderr)
+	}
+	c.privilegedMutator = c.mutator
+	if c.Sudo.Command != "" && c.remoteDestination == nil {
+		c.privilegedMutator = chezmoi.NewPrivilegedMutator(config.fs, c.Sudo.Command, c.Sudo.Args, c.makeSecretTempDir)
+	}
 	if c.DryRun {
 		c.mutator = chezmoi.NullMutator{}
+		c.privilegedMutator = c.mutator
+	}
+	if !c.CrossFileSystems && c.remoteDestination == nil {
+		c.mutator = chezmoi.NewOneFileSystemMutator(c.mutator, config.fs)
+		c.privilegedMutator = chezmoi.NewOneFileSystemMutator(c.privilegedMutator, config.fs)
 	}
 	if c.Debug {
 		c.mutator = chezmoi.NewDebugMutator(c.mutator)
+		c.privilegedMutator = chezmoi.NewDebugMutator(c.privilegedMutator)
 	}
 	if c.Verbose {
 		c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
+		c.privilegedMutator = chezmoi.NewVerboseMutator(c.Stdout, c.privilegedMutator, c.colored, c.maxDiffDataSize)
 	}
 
 	info, err := c.fs.Stat(c.SourceDir)
@@ -195,10 +265,22 @@ func (c *Config) persistentPreRunRootE(cmd *cobra.Command, args []string) error
 		return err
 	}
 
+	if err := c.setChildEnv(cmd); err != nil {
+		return err
+	}
+
+	c.useProxy()
+
 	// Apply any fixes for snap, if needed.
 	return c.snapFix()
 }
 
+// getCommandName returns the full name of cmd without the name of the root
+// command, for example "fleet apply".
+func getCommandName(cmd *cobra.Command) string {
+	return strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " ")
+}
+
 func getExample(command string) string {
 	return helps[command].example
 }
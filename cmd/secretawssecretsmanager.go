@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+type awsSecretsManagerConfig struct {
+	Profile  string
+	Region   string
+	Endpoint string
+	client   *secretsmanager.SecretsManager
+}
+
+var (
+	awsSecretsManagerRawCache = make(map[string]string)
+	awsSecretsManagerCache    = make(map[string]interface{})
+)
+
+func init() {
+	config.addTemplateFunc("awsSecretsManager", config.awsSecretsManagerFunc)
+	config.addTemplateFunc("awsSecretsManagerRaw", config.awsSecretsManagerRawFunc)
+}
+
+func (c *Config) awsSecretsManagerFunc(arn string) interface{} {
+	if data, ok := awsSecretsManagerCache[arn]; ok {
+		return data
+	}
+	secret := c.awsSecretsManagerRawFunc(arn)
+	var data interface{}
+	if err := json.Unmarshal([]byte(secret), &data); err != nil {
+		panic(fmt.Errorf("awsSecretsManager: %s: %w", arn, err))
+	}
+	awsSecretsManagerCache[arn] = data
+	return data
+}
+
+func (c *Config) awsSecretsManagerRawFunc(arn string) string {
+	if secret, ok := awsSecretsManagerRawCache[arn]; ok {
+		return secret
+	}
+	secret, err := c.getAWSSecretsManagerSecret(arn)
+	if err != nil {
+		panic(fmt.Errorf("awsSecretsManager: %s: %w", arn, err))
+	}
+	awsSecretsManagerRawCache[arn] = secret
+	return secret
+}
+
+// getAWSSecretsManagerSecret returns the current value of the secret arn,
+// which may be an ARN or a name. Credentials are found using the standard AWS
+// credential chain.
+func (c *Config) getAWSSecretsManagerSecret(arn string) (string, error) {
+	if c.AWSSecretsManager.client == nil {
+		awsConfig := aws.Config{}
+		if c.AWSSecretsManager.Region != "" {
+			awsConfig.Region = aws.String(c.AWSSecretsManager.Region)
+		}
+		if c.AWSSecretsManager.Endpoint != "" {
+			awsConfig.Endpoint = aws.String(c.AWSSecretsManager.Endpoint)
+		}
+		sess, err := session.NewSessionWithOptions(session.Options{
+			Config:            awsConfig,
+			Profile:           c.AWSSecretsManager.Profile,
+			SharedConfigState: session.SharedConfigEnable,
+		})
+		if err != nil {
+			return "", err
+		}
+		c.AWSSecretsManager.client = secretsmanager.New(sess)
+	}
+
+	ctx := context.Background()
+	if c.Template.FuncTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Template.FuncTimeout)
+		defer cancel()
+	}
+	output, err := c.AWSSecretsManager.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(arn),
+	})
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return "", fmt.Errorf("timed out after %s, increase template.funcTimeout if needed", c.Template.FuncTimeout)
+	case err != nil:
+		return "", err
+	case output.SecretString == nil:
+		return "", fmt.Errorf("secret is binary")
+	}
+	return *output.SecretString, nil
+}
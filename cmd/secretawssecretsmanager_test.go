@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAWSSecretsManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			http.Error(w, "unexpected target", http.StatusBadRequest)
+			return
+		}
+		var input struct {
+			SecretId string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch input.SecretId {
+		case "json":
+			_, _ = w.Write([]byte(`{"Name":"json","SecretString":"{\"username\":\"alice\",\"password\":\"secret\"}"}`))
+		case "raw":
+			_, _ = w.Write([]byte(`{"Name":"raw","SecretString":"secret"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer server.Close()
+
+	defer setTestAWSCredentials(t)()
+
+	c := newConfig()
+	c.AWSSecretsManager.Region = "us-east-1"
+	c.AWSSecretsManager.Endpoint = server.URL
+
+	assert.Equal(t, map[string]interface{}{
+		"username": "alice",
+		"password": "secret",
+	}, c.awsSecretsManagerFunc("json"))
+	assert.Equal(t, "secret", c.awsSecretsManagerRawFunc("raw"))
+	assert.Panics(t, func() {
+		c.awsSecretsManagerFunc("raw")
+	})
+	assert.Panics(t, func() {
+		c.awsSecretsManagerRawFunc("missing")
+	})
+}
+
+// setTestAWSCredentials sets the environment variables for static AWS
+// credentials and returns a function that restores the environment.
+func setTestAWSCredentials(t *testing.T) func() {
+	return setTestEnv(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":           "id",
+		"AWS_SECRET_ACCESS_KEY":       "key",
+		"AWS_CONFIG_FILE":             os.DevNull,
+		"AWS_SHARED_CREDENTIALS_FILE": os.DevNull,
+	})
+}
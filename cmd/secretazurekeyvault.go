@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// azureKeyVaultResource is the resource for which access tokens are requested.
+const azureKeyVaultResource = "https://vault.azure.net"
+
+type azureKeyVaultConfig struct {
+	DefaultVault string
+	Vaults       map[string]azureKeyVaultVaultConfig
+	tokens       map[azureKeyVaultTokenKey]string
+}
+
+// An azureKeyVaultVaultConfig is the configuration of a single vault.
+type azureKeyVaultVaultConfig struct {
+	URL      string
+	TenantID string
+	ClientID string
+}
+
+type azureKeyVaultTokenKey struct {
+	tenantID string
+	clientID string
+}
+
+type azureKeyVaultSecretKey struct {
+	vault  string
+	secret string
+}
+
+var azureKeyVaultCache = make(map[azureKeyVaultSecretKey]string)
+
+func init() {
+	config.addTemplateFunc("azureKeyVault", config.azureKeyVaultFunc)
+}
+
+func (c *Config) azureKeyVaultFunc(secret string, args ...string) string {
+	var vault string
+	switch len(args) {
+	case 0:
+		vault = c.AzureKeyVault.DefaultVault
+	case 1:
+		vault = args[0]
+	default:
+		panic(fmt.Errorf("azureKeyVault: expected 1 or 2 arguments, got %d", len(args)+1))
+	}
+	if vault == "" {
+		panic(fmt.Errorf("azureKeyVault: %s: no vault given and azureKeyVault.defaultVault not set", secret))
+	}
+	key := azureKeyVaultSecretKey{
+		vault:  vault,
+		secret: secret,
+	}
+	if value, ok := azureKeyVaultCache[key]; ok {
+		return value
+	}
+	value, err := c.getAzureKeyVaultSecret(vault, secret)
+	if err != nil {
+		panic(fmt.Errorf("azureKeyVault: %s: %s: %w", vault, secret, err))
+	}
+	azureKeyVaultCache[key] = value
+	return value
+}
+
+// getAzureKeyVaultSecret returns the current value of secret in vault.
+func (c *Config) getAzureKeyVaultSecret(vault, secret string) (string, error) {
+	// Config file keys, including vault names, are case insensitive.
+	vaultConfig := c.AzureKeyVault.Vaults[strings.ToLower(vault)]
+	vaultURL := vaultConfig.URL
+	if vaultURL == "" {
+		vaultURL = "https://" + vault + ".vault.azure.net"
+	}
+	token, err := c.getAzureAccessToken(vaultConfig.TenantID, vaultConfig.ClientID)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(vaultURL, "/")+"/secrets/"+url.PathEscape(secret)+"?api-version=7.4", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	var result struct {
+		Value string `json:"value"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := c.azureDo(req, &result); err != nil {
+		if result.Error != nil {
+			return "", fmt.Errorf("%w: %s", err, result.Error.Message)
+		}
+		return "", err
+	}
+	return result.Value, nil
+}
+
+// getAzureAccessToken returns an access token for Azure Key Vault, using the
+// first of the following credentials that succeeds, like the Azure SDKs'
+// DefaultAzureCredential: a service principal's client secret from the
+// environment, a managed identity, and the Azure CLI.
+func (c *Config) getAzureAccessToken(tenantID, clientID string) (string, error) {
+	key := azureKeyVaultTokenKey{
+		tenantID: tenantID,
+		clientID: clientID,
+	}
+	if token, ok := c.AzureKeyVault.tokens[key]; ok {
+		return token, nil
+	}
+	credentials := []struct {
+		name     string
+		getToken func(tenantID, clientID string) (string, error)
+	}{
+		{"environment", c.getAzureEnvironmentToken},
+		{"managed identity", c.getAzureManagedIdentityToken},
+		{"Azure CLI", c.getAzureCLIToken},
+	}
+	var errs []string
+	for _, credential := range credentials {
+		token, err := credential.getToken(tenantID, clientID)
+		if err == nil {
+			if c.AzureKeyVault.tokens == nil {
+				c.AzureKeyVault.tokens = make(map[azureKeyVaultTokenKey]string)
+			}
+			c.AzureKeyVault.tokens[key] = token
+			return token, nil
+		}
+		errs = append(errs, credential.name+": "+err.Error())
+	}
+	return "", fmt.Errorf("no credentials: %s", strings.Join(errs, "; "))
+}
+
+// getAzureEnvironmentToken returns an access token for the service principal
+// configured by the AZURE_TENANT_ID, AZURE_CLIENT_ID, and AZURE_CLIENT_SECRET
+// environment variables.
+func (c *Config) getAzureEnvironmentToken(tenantID, clientID string) (string, error) {
+	if tenantID == "" {
+		tenantID = os.Getenv("AZURE_TENANT_ID")
+	}
+	if clientID == "" {
+		clientID = os.Getenv("AZURE_CLIENT_ID")
+	}
+	clientSecret := os.Getenv("AZURE_CLIENT_SECRET")
+	if tenantID == "" || clientID == "" || clientSecret == "" {
+		return "", errors.New("AZURE_TENANT_ID, AZURE_CLIENT_ID, or AZURE_CLIENT_SECRET not set")
+	}
+	authorityHost := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authorityHost == "" {
+		authorityHost = "https://login.microsoftonline.com"
+	}
+	form := url.Values{
+		"grant_type":    []string{"client_credentials"},
+		"client_id":     []string{clientID},
+		"client_secret": []string{clientSecret},
+		"scope":         []string{azureKeyVaultResource + "/.default"},
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(authorityHost, "/")+"/"+url.PathEscape(tenantID)+"/oauth2/v2.0/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := c.azureDo(req, &result); err != nil {
+		if result.ErrorDescription != "" {
+			return "", fmt.Errorf("%w: %s", err, result.ErrorDescription)
+		}
+		return "", err
+	}
+	return result.AccessToken, nil
+}
+
+// getAzureManagedIdentityToken returns an access token for the managed
+// identity of the Azure host, using the App Service identity endpoint if it is
+// set and the Instance Metadata Service otherwise.
+func (c *Config) getAzureManagedIdentityToken(tenantID, clientID string) (string, error) {
+	if clientID == "" {
+		clientID = os.Getenv("AZURE_CLIENT_ID")
+	}
+	query := url.Values{
+		"resource": []string{azureKeyVaultResource},
+	}
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	var req *http.Request
+	var err error
+	timeout := c.Template.FuncTimeout
+	if identityEndpoint := os.Getenv("IDENTITY_ENDPOINT"); identityEndpoint != "" {
+		query.Set("api-version", "2019-08-01")
+		req, err = http.NewRequest(http.MethodGet, identityEndpoint+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("X-IDENTITY-HEADER", os.Getenv("IDENTITY_HEADER"))
+	} else {
+		query.Set("api-version", "2018-02-01")
+		req, err = http.NewRequest(http.MethodGet, "http://169.254.169.254/metadata/identity/oauth2/token?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata", "true")
+		// The Instance Metadata Service is only reachable on Azure hosts, so
+		// give up quickly elsewhere.
+		timeout = time.Second
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := c.azureDoWithTimeout(req, &result, timeout); err != nil {
+		return "", err
+	}
+	return result.AccessToken, nil
+}
+
+// getAzureCLIToken returns an access token for the user logged in to the
+// Azure CLI.
+func (c *Config) getAzureCLIToken(tenantID, clientID string) (string, error) {
+	args := []string{"account", "get-access-token", "--output", "json", "--resource", azureKeyVaultResource}
+	if tenantID != "" {
+		args = append(args, "--tenant", tenantID)
+	}
+	output, err := c.templateFuncCmdOutput("az", args, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("no access token")
+	}
+	return result.AccessToken, nil
+}
+
+// azureDo makes req and decodes the JSON response into result, which is
+// decoded even if the request fails so that callers can report error details.
+func (c *Config) azureDo(req *http.Request, result interface{}) error {
+	return c.azureDoWithTimeout(req, result, c.Template.FuncTimeout)
+}
+
+func (c *Config) azureDoWithTimeout(req *http.Request, result interface{}, timeout time.Duration) error {
+	client := &http.Client{
+		Timeout: timeout,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	jsonErr := json.Unmarshal(data, result)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Host+req.URL.Path, resp.Status)
+	}
+	return jsonErr
+}
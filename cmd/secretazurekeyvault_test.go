@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAzureKeyVault(t *testing.T) {
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/tenant/oauth2/v2.0/token":
+			tokenRequests++
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "client", r.PostForm.Get("client_id"))
+			assert.Equal(t, "https://vault.azure.net/.default", r.PostForm.Get("scope"))
+			if r.PostForm.Get("client_secret") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"invalid client secret"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"token_type":"Bearer","expires_in":3599,"access_token":"token"}`))
+		case r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":"Unauthorized","message":"unauthorized"}}`))
+		case r.URL.Path == "/myvault/secrets/github-token":
+			_, _ = w.Write([]byte(`{"value":"ghp_secret","id":"https://myvault.vault.azure.net/secrets/github-token/1"}`))
+		case r.URL.Path == "/othervault/secrets/github-token":
+			_, _ = w.Write([]byte(`{"value":"ghp_other"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"secret not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	defer setTestEnv(t, map[string]string{
+		"AZURE_AUTHORITY_HOST": server.URL,
+		"AZURE_TENANT_ID":      "tenant",
+		"AZURE_CLIENT_ID":      "client",
+		"AZURE_CLIENT_SECRET":  "secret",
+	})()
+
+	c := newConfig()
+	c.AzureKeyVault.DefaultVault = "MyVault"
+	c.AzureKeyVault.Vaults = map[string]azureKeyVaultVaultConfig{
+		"myvault": {
+			URL: server.URL + "/myvault",
+		},
+		"othervault": {
+			URL: server.URL + "/othervault/",
+		},
+	}
+
+	assert.Equal(t, "ghp_secret", c.azureKeyVaultFunc("github-token"))
+	assert.Equal(t, "ghp_other", c.azureKeyVaultFunc("github-token", "othervault"))
+	assert.Equal(t, 1, tokenRequests)
+	assert.Panics(t, func() {
+		c.azureKeyVaultFunc("missing")
+	})
+	assert.Panics(t, func() {
+		c.azureKeyVaultFunc("github-token", "othervault", "extra")
+	})
+}
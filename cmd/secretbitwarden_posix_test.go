@@ -0,0 +1,60 @@
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestBitwardenFuncRBW(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-rbw")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	rbw := filepath.Join(tempDir, "rbw")
+	require.NoError(t, ioutil.WriteFile(rbw, []byte(""+
+		"#!/bin/sh\n"+
+		"if [ \"$*\" != \"get --raw example.com\" ]; then\n"+
+		"\techo \"unexpected arguments: $*\" >&2\n"+
+		"\texit 1\n"+
+		"fi\n"+
+		"echo '{\"id\":\"id1\",\"folder\":null,\"name\":\"example.com\",\"data\":{\"username\":\"alice\",\"password\":\"secret\",\"totp\":null,\"uris\":[{\"uri\":\"https://example.com\",\"match_type\":null}]},\"fields\":[{\"name\":\"pin\",\"value\":\"1234\"}],\"notes\":\"notes\",\"history\":[]}'\n",
+	), 0755))
+
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	c.Bitwarden.Command = rbw
+
+	var data interface{}
+	require.NotPanics(t, func() {
+		data = c.bitwardenFunc("item", "example.com")
+	})
+	assert.Equal(t, map[string]interface{}{
+		"id":    "id1",
+		"name":  "example.com",
+		"notes": "notes",
+		"fields": []interface{}{
+			map[string]interface{}{"name": "pin", "value": "1234"},
+		},
+		"login": map[string]interface{}{
+			"username": "alice",
+			"password": "secret",
+			"totp":     nil,
+			"uris": []interface{}{
+				map[string]interface{}{"uri": "https://example.com", "match": nil},
+			},
+		},
+	}, data)
+
+	assert.Panics(t, func() {
+		c.bitwardenFunc("password", "example.com")
+	})
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var dopplerCmd = &cobra.Command{
+	Use:     "doppler [args...]",
+	Short:   "Execute the Doppler CLI",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runDopplerCmd,
+}
+
+type dopplerCmdConfig struct {
+	Command  string
+	Project  string
+	Config   string
+	Token    string
+	Endpoint string
+}
+
+type dopplerKey struct {
+	project string
+	config  string
+}
+
+var dopplerCache = make(map[dopplerKey]map[string]interface{})
+
+func init() {
+	config.Doppler.Command = "doppler"
+	config.addTemplateFunc("doppler", config.dopplerFunc)
+
+	secretCmd.AddCommand(dopplerCmd)
+}
+
+func (c *Config) runDopplerCmd(cmd *cobra.Command, args []string) error {
+	return c.run("", c.Doppler.Command, args...)
+}
+
+// dopplerFunc returns the secrets of a Doppler config. args are an optional
+// project and config, which default to doppler.project and doppler.config.
+func (c *Config) dopplerFunc(args ...string) map[string]interface{} {
+	key := dopplerKey{
+		project: c.Doppler.Project,
+		config:  c.Doppler.Config,
+	}
+	switch len(args) {
+	case 0:
+	case 1:
+		key.project = args[0]
+	case 2:
+		key.project, key.config = args[0], args[1]
+	default:
+		panic(fmt.Errorf("doppler: expected 0, 1, or 2 arguments, got %d", len(args)))
+	}
+	if secrets, ok := dopplerCache[key]; ok {
+		return secrets
+	}
+	var secrets map[string]interface{}
+	var err error
+	if c.Doppler.Token != "" {
+		secrets, err = c.dopplerAPISecrets(key)
+	} else {
+		secrets, err = c.dopplerCLISecrets(key)
+	}
+	if err != nil {
+		panic(fmt.Errorf("doppler: %w", err))
+	}
+	dopplerCache[key] = secrets
+	return secrets
+}
+
+// dopplerAPISecrets returns the secrets of key using the Doppler API and
+// doppler.token. The project and config may be empty for service tokens, which
+// can only access a single config.
+func (c *Config) dopplerAPISecrets(key dopplerKey) (map[string]interface{}, error) {
+	query := url.Values{}
+	query.Set("format", "json")
+	if key.project != "" {
+		query.Set("project", key.project)
+	}
+	if key.config != "" {
+		query.Set("config", key.config)
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(c.Doppler.Endpoint, "/")+"/v3/configs/config/secrets/download?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Doppler.Token)
+	client := &http.Client{
+		Timeout: c.Template.FuncTimeout,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var result struct {
+			Messages []string `json:"messages"`
+		}
+		if json.Unmarshal(data, &result) == nil && len(result.Messages) != 0 {
+			return nil, fmt.Errorf("%s: %s", resp.Status, strings.Join(result.Messages, ", "))
+		}
+		return nil, errors.New(resp.Status)
+	}
+	var secrets map[string]interface{}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// dopplerCLISecrets returns the secrets of key using the Doppler CLI. If the
+// project or config is empty then the CLI uses the project and config
+// configured for the current directory with doppler setup.
+func (c *Config) dopplerCLISecrets(key dopplerKey) (map[string]interface{}, error) {
+	name := c.Doppler.Command
+	args := []string{"secrets", "download", "--no-file", "--format", "json"}
+	if key.project != "" {
+		args = append(args, "--project", key.project)
+	}
+	if key.config != "" {
+		args = append(args, "--config", key.config)
+	}
+	output, err := c.templateFuncCmdOutput(name, args, os.Stdin, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output)
+	}
+	var secrets map[string]interface{}
+	if err := json.Unmarshal(output, &secrets); err != nil {
+		return nil, fmt.Errorf("%s %s: %w", name, chezmoi.ShellQuoteArgs(args), err)
+	}
+	return secrets, nil
+}
@@ -0,0 +1,49 @@
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestDopplerFuncCLI(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-doppler")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	doppler := filepath.Join(tempDir, "doppler")
+	require.NoError(t, ioutil.WriteFile(doppler, []byte(""+
+		"#!/bin/sh\n"+
+		"case \"$*\" in\n"+
+		"\"secrets download --no-file --format json\")\n"+
+		"\techo '{\"API_KEY\":\"setup-key\"}'\n"+
+		"\t;;\n"+
+		"\"secrets download --no-file --format json --project backend --config prd\")\n"+
+		"\techo '{\"API_KEY\":\"prd-key\"}'\n"+
+		"\t;;\n"+
+		"*)\n"+
+		"\techo \"unexpected arguments: $*\" >&2\n"+
+		"\texit 1\n"+
+		"esac\n",
+	), 0755))
+
+	dopplerCache = make(map[dopplerKey]map[string]interface{})
+
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	c.Doppler.Command = doppler
+
+	assert.Equal(t, map[string]interface{}{"API_KEY": "setup-key"}, c.dopplerFunc())
+	assert.Equal(t, map[string]interface{}{"API_KEY": "prd-key"}, c.dopplerFunc("backend", "prd"))
+	assert.Panics(t, func() {
+		c.dopplerFunc("frontend")
+	})
+}
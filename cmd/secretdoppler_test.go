@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDopplerFuncAPI(t *testing.T) {
+	secretRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Header.Get("Authorization") != "Bearer dp.st.token":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"messages":["Invalid Auth token"],"success":false}`))
+		case r.URL.Path != "/v3/configs/config/secrets/download" || r.URL.Query().Get("format") != "json":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Query().Get("project") == "backend" && r.URL.Query().Get("config") == "dev":
+			secretRequests++
+			_, _ = w.Write([]byte(`{"API_KEY":"dev-key","DOPPLER_CONFIG":"dev"}`))
+		case r.URL.Query().Get("project") == "backend" && r.URL.Query().Get("config") == "prd":
+			_, _ = w.Write([]byte(`{"API_KEY":"prd-key","DOPPLER_CONFIG":"prd"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"messages":["Could not find requested config"],"success":false}`))
+		}
+	}))
+	defer server.Close()
+
+	dopplerCache = make(map[dopplerKey]map[string]interface{})
+
+	c := newConfig()
+	c.Doppler.Endpoint = server.URL
+	c.Doppler.Token = "dp.st.token"
+	c.Doppler.Project = "backend"
+	c.Doppler.Config = "dev"
+
+	assert.Equal(t, "dev-key", c.dopplerFunc()["API_KEY"])
+	assert.Equal(t, "dev-key", c.dopplerFunc("backend")["API_KEY"])
+	assert.Equal(t, "prd-key", c.dopplerFunc("backend", "prd")["API_KEY"])
+	assert.Equal(t, 1, secretRequests)
+	assert.Panics(t, func() {
+		c.dopplerFunc("frontend", "dev")
+	})
+	assert.Panics(t, func() {
+		c.dopplerFunc("backend", "dev", "extra")
+	})
+}
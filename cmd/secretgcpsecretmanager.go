@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// gcpSecretManagerScope is the OAuth2 scope required to access secrets.
+const gcpSecretManagerScope = "https://www.googleapis.com/auth/cloud-platform"
+
+type gcpSecretManagerConfig struct {
+	Project  string
+	Endpoint string
+	client   *http.Client
+}
+
+type gcpSecretManagerKey struct {
+	name    string
+	version string
+}
+
+var gcpSecretManagerCache = make(map[gcpSecretManagerKey]string)
+
+func init() {
+	config.addTemplateFunc("gcpSecretManager", config.gcpSecretManagerFunc)
+	config.addTemplateFunc("gcpSecretManagerVersion", config.gcpSecretManagerVersionFunc)
+}
+
+func (c *Config) gcpSecretManagerFunc(name string) string {
+	return c.gcpSecretManagerVersionFunc(name, "latest")
+}
+
+func (c *Config) gcpSecretManagerVersionFunc(name, version string) string {
+	key := gcpSecretManagerKey{
+		name:    name,
+		version: version,
+	}
+	if value, ok := gcpSecretManagerCache[key]; ok {
+		return value
+	}
+	value, err := c.getGCPSecretManagerSecret(name, version)
+	if err != nil {
+		panic(fmt.Errorf("gcpSecretManager: %s: %s: %w", name, version, err))
+	}
+	gcpSecretManagerCache[key] = value
+	return value
+}
+
+// getGCPSecretManagerSecret returns the value of version of the secret name,
+// which may be a secret ID or a full resource name of the form
+// projects/*/secrets/*. Credentials are found using Application Default
+// Credentials.
+func (c *Config) getGCPSecretManagerSecret(name, version string) (string, error) {
+	ctx := context.Background()
+	if c.GCPSecretManager.client == nil {
+		credentials, err := google.FindDefaultCredentials(ctx, gcpSecretManagerScope)
+		if err != nil {
+			return "", err
+		}
+		if c.GCPSecretManager.Project == "" {
+			c.GCPSecretManager.Project = credentials.ProjectID
+		}
+		c.GCPSecretManager.client = oauth2.NewClient(ctx, credentials.TokenSource)
+		c.GCPSecretManager.client.Timeout = c.Template.FuncTimeout
+	}
+
+	if !strings.HasPrefix(name, "projects/") {
+		project := c.GCPSecretManager.Project
+		if project == "" {
+			project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+		}
+		if project == "" {
+			return "", errors.New("no project given and gcpSecretManager.project not set")
+		}
+		name = "projects/" + project + "/secrets/" + name
+	}
+
+	resp, err := c.GCPSecretManager.client.Get(strings.TrimSuffix(c.GCPSecretManager.Endpoint, "/") + "/v1/" + name + "/versions/" + version + ":access")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	jsonErr := json.Unmarshal(data, &result)
+	switch {
+	case resp.StatusCode != http.StatusOK && result.Error != nil:
+		return "", fmt.Errorf("%s: %s", resp.Status, result.Error.Message)
+	case resp.StatusCode != http.StatusOK:
+		return "", errors.New(resp.Status)
+	case jsonErr != nil:
+		return "", jsonErr
+	}
+	value, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCPSecretManager(t *testing.T) {
+	secretRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/token":
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+			_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+		case r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":401,"message":"unauthorized"}}`))
+		case r.URL.Path == "/v1/projects/my-project/secrets/github-token/versions/latest:access":
+			secretRequests++
+			_, _ = w.Write([]byte(`{"name":"projects/1/secrets/github-token/versions/2","payload":{"data":"Z2hwX3NlY3JldA=="}}`))
+		case r.URL.Path == "/v1/projects/other-project/secrets/github-token/versions/1:access":
+			_, _ = w.Write([]byte(`{"name":"projects/2/secrets/github-token/versions/1","payload":{"data":"Z2hwX290aGVy"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"Secret not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	credentials, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "my-project",
+		"private_key_id": "key",
+		"private_key": string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		})),
+		"client_email": "chezmoi@my-project.iam.gserviceaccount.com",
+		"token_uri":    server.URL + "/token",
+	})
+	require.NoError(t, err)
+	credentialsFile, err := ioutil.TempFile("", "chezmoi-gcp-credentials")
+	require.NoError(t, err)
+	defer os.Remove(credentialsFile.Name())
+	_, err = credentialsFile.Write(credentials)
+	require.NoError(t, err)
+	require.NoError(t, credentialsFile.Close())
+
+	defer setTestEnv(t, map[string]string{
+		"GOOGLE_APPLICATION_CREDENTIALS": credentialsFile.Name(),
+	})()
+
+	c := newConfig()
+	c.GCPSecretManager.Endpoint = server.URL
+
+	assert.Equal(t, "ghp_secret", c.gcpSecretManagerFunc("github-token"))
+	assert.Equal(t, "ghp_secret", c.gcpSecretManagerVersionFunc("github-token", "latest"))
+	assert.Equal(t, 1, secretRequests)
+	assert.Equal(t, "ghp_other", c.gcpSecretManagerVersionFunc("projects/other-project/secrets/github-token", "1"))
+	assert.Panics(t, func() {
+		c.gcpSecretManagerFunc("missing")
+	})
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -46,10 +45,7 @@ func (c *Config) secretFunc(args ...string) string {
 		return value
 	}
 	name := c.GenericSecret.Command
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.templateFuncCmdOutput(name, args, os.Stdin, os.Stderr)
 	if err != nil {
 		panic(fmt.Errorf("secret: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
@@ -64,10 +60,7 @@ func (c *Config) secretJSONFunc(args ...string) interface{} {
 		return value
 	}
 	name := c.GenericSecret.Command
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.templateFuncCmdOutput(name, args, os.Stdin, os.Stderr)
 	if err != nil {
 		panic(fmt.Errorf("secretJSON: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
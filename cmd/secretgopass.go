@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
-	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
@@ -17,40 +22,129 @@ var gopassCmd = &cobra.Command{
 	RunE:    config.runSecretGopassCmd,
 }
 
+var (
+	// gopass show parses secrets and may reformat them since version 1.12.0,
+	// which added --noparsing to disable this. Earlier versions never parse
+	// secrets.
+	gopassNoParsingMinVersion = semver.Version{Major: 1, Minor: 12, Patch: 0}
+	gopassFieldRegexp         = regexp.MustCompile(`\A([^\s:][^:]*):\s*(.*)\z`)
+	gopassVersionArgs         = []string{"--version"}
+	gopassVersionRegexp       = regexp.MustCompile(`gopass\s+(\d+\.\d+\.\d+)`)
+)
+
 type gopassCmdConfig struct {
-	Command string
+	Command          string
+	versionCheckOnce sync.Once
+	noParsing        bool
 }
 
-var gopassCache = make(map[string]string)
+var (
+	gopassRawCache    = make(map[string]string)
+	gopassFieldsCache = make(map[string]map[string]interface{})
+)
 
 func init() {
 	secretCmd.AddCommand(gopassCmd)
 
 	config.Gopass.Command = "gopass"
 	config.addTemplateFunc("gopass", config.gopassFunc)
+	config.addTemplateFunc("gopassFields", config.gopassFieldsFunc)
+	config.addTemplateFunc("gopassRaw", config.gopassRawFunc)
 }
 
 func (c *Config) runSecretGopassCmd(cmd *cobra.Command, args []string) error {
-	return c.run("", c.Pass.Command, args...)
+	return c.run("", c.Gopass.Command, args...)
 }
 
 func (c *Config) gopassFunc(id string) string {
-	if s, ok := gopassCache[id]; ok {
-		return s
+	output := c.gopassRawFunc(id)
+	if index := strings.IndexByte(output, '\n'); index != -1 {
+		return output[:index]
+	}
+	return output
+}
+
+func (c *Config) gopassFieldsFunc(id string) map[string]interface{} {
+	if fields, ok := gopassFieldsCache[id]; ok {
+		return fields
+	}
+	fields, err := gopassParseFields(c.gopassRawFunc(id))
+	if err != nil {
+		panic(fmt.Errorf("gopass: %s: %w", id, err))
+	}
+	gopassFieldsCache[id] = fields
+	return fields
+}
+
+func (c *Config) gopassRawFunc(id string) string {
+	c.Gopass.versionCheckOnce.Do(func() {
+		panicOnError(c.gopassVersionCheck())
+	})
+	if output, ok := gopassRawCache[id]; ok {
+		return output
 	}
 	name := c.Gopass.Command
-	args := []string{"show", id}
-	cmd := exec.Command(name, args...)
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	args := []string{"show"}
+	if c.Gopass.noParsing {
+		args = append(args, "--noparsing")
+	}
+	args = append(args, id)
+	output, err := c.templateFuncCmdOutput(name, args, nil, nil)
 	if err != nil {
 		panic(fmt.Errorf("gopass: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
 	}
-	var password string
-	if index := bytes.IndexByte(output, '\n'); index != -1 {
-		password = string(output[:index])
-	} else {
-		password = string(output)
+	gopassRawCache[id] = string(output)
+	return gopassRawCache[id]
+}
+
+// gopassVersionCheck sets c.Gopass.noParsing if the gopass CLI supports
+// --noparsing.
+func (c *Config) gopassVersionCheck() error {
+	name := c.Gopass.Command
+	output, err := c.templateFuncCmdOutput(name, gopassVersionArgs, nil, nil)
+	if err != nil {
+		return fmt.Errorf("gopass: %s %s: %w", name, chezmoi.ShellQuoteArgs(gopassVersionArgs), err)
+	}
+	m := gopassVersionRegexp.FindSubmatch(output)
+	if m == nil {
+		return fmt.Errorf("gopass: could not extract version from %q", output)
+	}
+	version, err := semver.NewVersion(string(m[1]))
+	if err != nil {
+		return err
+	}
+	c.Gopass.noParsing = !version.LessThan(gopassNoParsingMinVersion)
+	return nil
+}
+
+// gopassParseFields returns the fields in the body of the gopass secret
+// output, i.e. everything after the password on the first line. If the body
+// starts with a YAML document separator then it is parsed as YAML, otherwise
+// each line of the form key: value is a field.
+func gopassParseFields(output string) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	index := strings.IndexByte(output, '\n')
+	if index == -1 {
+		return fields, nil
+	}
+	body := output[index+1:]
+	if strings.HasPrefix(body, "---\n") {
+		if err := yaml.Unmarshal([]byte(body), &fields); err != nil {
+			return nil, err
+		}
+		for key, value := range fields {
+			fields[key] = normalizeYAMLValue(value)
+		}
+		return fields, nil
+	}
+	s := bufio.NewScanner(bytes.NewBufferString(body))
+	for s.Scan() {
+		if m := gopassFieldRegexp.FindStringSubmatch(s.Text()); m != nil {
+			fields[strings.TrimSpace(m[1])] = m[2]
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
 	}
-	gopassCache[id] = password
-	return gopassCache[id]
+	return fields, nil
 }
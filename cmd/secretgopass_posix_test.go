@@ -0,0 +1,69 @@
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestGopassFuncs(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		version         string
+		expectedShowArg string
+	}{
+		{
+			name:            "noparsing",
+			version:         "gopass 1.12.6 go1.16.3 linux amd64",
+			expectedShowArg: "show --noparsing example.com",
+		},
+		{
+			name:            "old",
+			version:         "gopass 1.10.1 go1.15.2 linux amd64",
+			expectedShowArg: "show example.com",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir, err := ioutil.TempDir("", "chezmoi-test-gopass")
+			require.NoError(t, err)
+			defer os.RemoveAll(tempDir)
+			log := filepath.Join(tempDir, "log")
+			gopass := filepath.Join(tempDir, "gopass")
+			require.NoError(t, ioutil.WriteFile(gopass, []byte(""+
+				"#!/bin/sh\n"+
+				"echo \"$*\" >> "+log+"\n"+
+				"if [ \"$1\" = \"--version\" ]; then\n"+
+				"\techo '"+tc.version+"'\n"+
+				"\texit 0\n"+
+				"fi\n"+
+				"echo 'pw'\n"+
+				"echo 'login: alice'\n",
+			), 0755))
+
+			gopassRawCache = make(map[string]string)
+			gopassFieldsCache = make(map[string]map[string]interface{})
+			c := newConfig(
+				withMutator(chezmoi.NullMutator{}),
+			)
+			c.Gopass.Command = gopass
+
+			assert.Equal(t, "pw", c.gopassFunc("example.com"))
+			assert.Equal(t, "pw\nlogin: alice\n", c.gopassRawFunc("example.com"))
+			assert.Equal(t, map[string]interface{}{"login": "alice"}, c.gopassFieldsFunc("example.com"))
+			assert.Equal(t, "alice", c.gopassFieldsFunc("example.com")["login"])
+
+			data, err := ioutil.ReadFile(log)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"--version", tc.expectedShowArg}, strings.Split(strings.TrimSpace(string(data)), "\n"))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGopassParseFields(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		output   string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "password_only",
+			output:   "secret\n",
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "password_only_without_newline",
+			output:   "secret",
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "key_value",
+			output: "secret\n" +
+				"login: alice\n" +
+				"url: https://example.com:8443/login\n" +
+				"some notes\n",
+			expected: map[string]interface{}{
+				"login": "alice",
+				"url":   "https://example.com:8443/login",
+			},
+		},
+		{
+			name: "yaml",
+			output: "secret\n" +
+				"---\n" +
+				"login: alice\n" +
+				"port: 8443\n" +
+				"recovery:\n" +
+				"  codes:\n" +
+				"  - abc\n" +
+				"  - def\n",
+			expected: map[string]interface{}{
+				"login": "alice",
+				"port":  8443,
+				"recovery": map[string]interface{}{
+					"codes": []interface{}{"abc", "def"},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := gopassParseFields(tc.output)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
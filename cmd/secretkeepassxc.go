@@ -6,12 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"regexp"
 	"strings"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
+	keyring "github.com/zalando/go-keyring"
 	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -25,9 +25,21 @@ var keePassXCCmd = &cobra.Command{
 }
 
 type keePassXCCmdConfig struct {
-	Command  string
-	Database string
-	Args     []string
+	Command    string
+	Database   string
+	Args       []string
+	KeyFile    string
+	NoPassword bool
+	Keyring    keePassXCKeyringConfig
+	Mode       string
+	Browser    keePassXCBrowserConfig
+}
+
+// A keePassXCKeyringConfig identifies a KeePassXC database password stored in
+// the system keyring.
+type keePassXCKeyringConfig struct {
+	Service string
+	User    string
 }
 
 type keePassXCAttributeCacheKey struct {
@@ -40,6 +52,7 @@ var (
 	keePassXCCache                       = make(map[string]map[string]string)
 	keePassXCAttributeCache              = make(map[keePassXCAttributeCacheKey]string)
 	keePassXCPairRegexp                  = regexp.MustCompile(`^([^:]+): (.*)$`)
+	keePassXCPromptPrefix                = "Insert password to unlock "
 	keePassXCPassword                    string
 	keePassXCNeedShowProtectedArgVersion = semver.Version{Major: 2, Minor: 5, Patch: 1}
 )
@@ -62,8 +75,7 @@ func (c *Config) getKeePassXCVersion() *semver.Version {
 	}
 	name := c.KeePassXC.Command
 	args := []string{"--version"}
-	cmd := exec.Command(name, args...)
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.templateFuncCmdOutput(name, args, nil, nil)
 	if err != nil {
 		panic(fmt.Errorf("keepassxc: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
 	}
@@ -78,6 +90,14 @@ func (c *Config) keePassXCFunc(entry string) map[string]string {
 	if data, ok := keePassXCCache[entry]; ok {
 		return data
 	}
+	if c.KeePassXC.Mode == "browser" {
+		data, err := c.keePassXCBrowserData(entry)
+		if err != nil {
+			panic(fmt.Errorf("keepassxc: %s: %w", entry, err))
+		}
+		keePassXCCache[entry] = data
+		return data
+	}
 	if c.KeePassXC.Database == "" {
 		panic(errors.New("keepassxc: keepassxc.database not set"))
 	}
@@ -86,8 +106,7 @@ func (c *Config) keePassXCFunc(entry string) map[string]string {
 	if c.getKeePassXCVersion().Compare(keePassXCNeedShowProtectedArgVersion) >= 0 {
 		args = append(args, "--show-protected")
 	}
-	args = append(args, c.KeePassXC.Args...)
-	args = append(args, c.KeePassXC.Database, entry)
+	args = append(args, c.keePassXCCLIArgs(entry)...)
 	output, err := c.runKeePassXCCLICommand(name, args)
 	if err != nil {
 		panic(fmt.Errorf("keepassxc: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
@@ -108,6 +127,14 @@ func (c *Config) keePassXCAttributeFunc(entry, attribute string) string {
 	if data, ok := keePassXCAttributeCache[key]; ok {
 		return data
 	}
+	if c.KeePassXC.Mode == "browser" {
+		value, ok := c.keePassXCFunc(entry)[attribute]
+		if !ok {
+			panic(fmt.Errorf("keepassxc: %s: %s: attribute not found", entry, attribute))
+		}
+		keePassXCAttributeCache[key] = value
+		return value
+	}
 	if c.KeePassXC.Database == "" {
 		panic(errors.New("keepassxc: keepassxc.database not set"))
 	}
@@ -116,8 +143,7 @@ func (c *Config) keePassXCAttributeFunc(entry, attribute string) string {
 	if c.getKeePassXCVersion().Compare(keePassXCNeedShowProtectedArgVersion) >= 0 {
 		args = append(args, "--show-protected")
 	}
-	args = append(args, c.KeePassXC.Args...)
-	args = append(args, c.KeePassXC.Database, entry)
+	args = append(args, c.keePassXCCLIArgs(entry)...)
 	output, err := c.runKeePassXCCLICommand(name, args)
 	if err != nil {
 		panic(fmt.Errorf("keepassxc: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
@@ -127,27 +153,55 @@ func (c *Config) keePassXCAttributeFunc(entry, attribute string) string {
 	return outputStr
 }
 
+// keePassXCCLIArgs returns the arguments to keepassxc-cli that unlock the
+// database and select entry.
+func (c *Config) keePassXCCLIArgs(entry string) []string {
+	var args []string
+	if c.KeePassXC.KeyFile != "" {
+		args = append(args, "--key-file", c.KeePassXC.KeyFile)
+	}
+	if c.KeePassXC.NoPassword {
+		args = append(args, "--no-password")
+	}
+	args = append(args, c.KeePassXC.Args...)
+	return append(args, c.KeePassXC.Database, entry)
+}
+
+// runKeePassXCCLICommand runs keepassxc-cli, which reads the database password
+// from stdin. The password is read from the keyring if keepassxc.keyring is
+// set, otherwise the user is prompted for it.
 func (c *Config) runKeePassXCCLICommand(name string, args []string) ([]byte, error) {
+	if c.KeePassXC.NoPassword {
+		return c.templateFuncCmdOutput(name, args, nil, c.Stderr)
+	}
 	if keePassXCPassword == "" {
-		fmt.Printf("Insert password to unlock %s: ", c.KeePassXC.Database)
-		password, err := terminal.ReadPassword(int(os.Stdout.Fd()))
-		fmt.Println()
-		if err != nil {
-			return nil, err
+		if c.KeePassXC.Keyring.Service != "" {
+			password, err := keyring.Get(c.KeePassXC.Keyring.Service, c.KeePassXC.Keyring.User)
+			if err != nil {
+				return nil, fmt.Errorf("keyring %q %q: %w", c.KeePassXC.Keyring.Service, c.KeePassXC.Keyring.User, err)
+			}
+			keePassXCPassword = password
+		} else {
+			fmt.Printf("%s%s: ", keePassXCPromptPrefix, c.KeePassXC.Database)
+			password, err := terminal.ReadPassword(int(os.Stdout.Fd()))
+			fmt.Println()
+			if err != nil {
+				return nil, err
+			}
+			keePassXCPassword = string(password)
 		}
-		keePassXCPassword = string(password)
 	}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = bytes.NewBufferString(keePassXCPassword + "\n")
-	cmd.Stderr = c.Stderr
-	return c.mutator.IdempotentCmdOutput(cmd)
+	return c.templateFuncCmdOutput(name, args, bytes.NewBufferString(keePassXCPassword+"\n"), c.Stderr)
 }
 
 func parseKeyPassXCOutput(output []byte) (map[string]string, error) {
 	data := make(map[string]string)
 	s := bufio.NewScanner(bytes.NewReader(output))
 	for i := 0; s.Scan(); i++ {
-		if i == 0 {
+		// Skip the password prompt, which is not printed with
+		// --no-password. The prompt itself matches keePassXCPairRegexp so it
+		// must be recognized by its prefix.
+		if i == 0 && strings.HasPrefix(s.Text(), keePassXCPromptPrefix) {
 			continue
 		}
 		match := keePassXCPairRegexp.FindStringSubmatch(s.Text())
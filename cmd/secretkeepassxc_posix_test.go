@@ -0,0 +1,239 @@
+// +build !windows
+
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	keyring "github.com/zalando/go-keyring"
+	"golang.org/x/crypto/nacl/box"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestKeePassXCFuncNonInteractive(t *testing.T) {
+	keyring.MockInit()
+	require.NoError(t, keyring.Set("keepassxc", "user", "secret"))
+
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-keepassxc")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	keePassXCCLI := filepath.Join(tempDir, "keepassxc-cli")
+	require.NoError(t, ioutil.WriteFile(keePassXCCLI, []byte(""+
+		"#!/bin/sh\n"+
+		"if [ \"$1\" = \"--version\" ]; then\n"+
+		"\techo 2.6.0\n"+
+		"\texit 0\n"+
+		"fi\n"+
+		"read -r password\n"+
+		"case \"$password:$*\" in\n"+
+		"\"secret:show --show-protected --key-file db.keyx db.kdbx example.com\")\n"+
+		"\techo 'Insert password to unlock db.kdbx: '\n"+
+		"\t;;\n"+
+		"\":show --show-protected --key-file db.keyx --no-password db.kdbx example.com\")\n"+
+		"\t;;\n"+
+		"*)\n"+
+		"\techo \"unexpected password or arguments: $*\" >&2\n"+
+		"\texit 1\n"+
+		"esac\n"+
+		"echo 'Title: example.com'\n"+
+		"echo 'UserName: alice'\n"+
+		"echo 'Password: pw'\n",
+	), 0755))
+
+	for _, tc := range []struct {
+		name       string
+		noPassword bool
+	}{
+		{
+			name: "keyring",
+		},
+		{
+			name:       "no_password",
+			noPassword: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			keePassXCVersion = nil
+			keePassXCCache = make(map[string]map[string]string)
+			keePassXCPassword = ""
+
+			c := newConfig(
+				withMutator(chezmoi.NullMutator{}),
+			)
+			c.KeePassXC.Command = keePassXCCLI
+			c.KeePassXC.Database = "db.kdbx"
+			c.KeePassXC.KeyFile = "db.keyx"
+			c.KeePassXC.NoPassword = tc.noPassword
+			c.KeePassXC.Keyring.Service = "keepassxc"
+			c.KeePassXC.Keyring.User = "user"
+
+			assert.Equal(t, map[string]string{
+				"Title":    "example.com",
+				"UserName": "alice",
+				"Password": "pw",
+			}, c.keePassXCFunc("example.com"))
+		})
+	}
+}
+
+func TestParseKeyPassXCOutput(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		output   string
+		expected map[string]string
+	}{
+		{
+			name: "prompt",
+			output: "Insert password to unlock /home/user/Passwords.kdbx: \n" +
+				"Title: example.com\n" +
+				"UserName: alice\n",
+			expected: map[string]string{
+				"Title":    "example.com",
+				"UserName": "alice",
+			},
+		},
+		{
+			name: "no_prompt",
+			output: "Title: example.com\n" +
+				"UserName: alice\n",
+			expected: map[string]string{
+				"Title":    "example.com",
+				"UserName": "alice",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parseKeyPassXCOutput([]byte(tc.output))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestKeePassXCFuncBrowser(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-keepassxc-browser")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	socket := filepath.Join(tempDir, keePassXCBrowserSocketName)
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	serverPublicKey, serverPrivateKey, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		decoder := json.NewDecoder(conn)
+		var clientPublicKey *[32]byte
+		for {
+			var request keePassXCBrowserMessage
+			if err := decoder.Decode(&request); err != nil {
+				return
+			}
+			nonce := decodeTestKeePassXCBrowserNonce(t, request.Nonce)
+			incrementKeePassXCBrowserNonce(&nonce)
+			response := keePassXCBrowserMessage{
+				Action:   request.Action,
+				Nonce:    base64.StdEncoding.EncodeToString(nonce[:]),
+				ClientID: request.ClientID,
+			}
+			if request.Action == "change-public-keys" {
+				clientPublicKey, err = decodeKeePassXCBrowserKey(request.PublicKey)
+				assert.NoError(t, err)
+				response.PublicKey = base64.StdEncoding.EncodeToString(serverPublicKey[:])
+			} else {
+				requestNonce := decodeTestKeePassXCBrowserNonce(t, request.Nonce)
+				ciphertext, err := base64.StdEncoding.DecodeString(request.Message)
+				assert.NoError(t, err)
+				plaintext, ok := box.Open(nil, ciphertext, &requestNonce, clientPublicKey, serverPrivateKey)
+				assert.True(t, ok)
+				var message struct {
+					Action string              `json:"action"`
+					URL    string              `json:"url"`
+					Keys   []map[string]string `json:"keys"`
+				}
+				assert.NoError(t, json.Unmarshal(plaintext, &message))
+				assert.Equal(t, "get-logins", message.Action)
+				assert.Equal(t, []map[string]string{{"id": "chezmoi", "key": "idkey"}}, message.Keys)
+				var result interface{}
+				switch message.URL {
+				case "https://example.com":
+					result = map[string]interface{}{
+						"count": 1,
+						"entries": []interface{}{
+							map[string]interface{}{
+								"login":    "alice",
+								"name":     "example.com",
+								"password": "pw",
+								"uuid":     "0123456789abcdef",
+								"stringFields": []interface{}{
+									map[string]string{"KPH: otp": "123456"},
+								},
+							},
+						},
+						"success": "true",
+					}
+				default:
+					result = map[string]interface{}{
+						"error":     "No logins found",
+						"errorCode": "15",
+					}
+				}
+				plaintext, err = json.Marshal(result)
+				assert.NoError(t, err)
+				response.Message = base64.StdEncoding.EncodeToString(box.Seal(nil, plaintext, &nonce, clientPublicKey, serverPrivateKey))
+			}
+			data, err := json.Marshal(response)
+			assert.NoError(t, err)
+			if _, err := conn.Write(data); err != nil {
+				return
+			}
+		}
+	}()
+
+	keePassXCCache = make(map[string]map[string]string)
+	keePassXCAttributeCache = make(map[keePassXCAttributeCacheKey]string)
+
+	c := newConfig()
+	c.KeePassXC.Mode = "browser"
+	c.KeePassXC.Browser.Socket = socket
+	c.KeePassXC.Browser.ID = "chezmoi"
+	c.KeePassXC.Browser.Key = "idkey"
+
+	assert.Equal(t, map[string]string{
+		"Title":    "example.com",
+		"UserName": "alice",
+		"Password": "pw",
+		"UUID":     "0123456789abcdef",
+		"otp":      "123456",
+	}, c.keePassXCFunc("https://example.com"))
+	assert.Equal(t, "123456", c.keePassXCAttributeFunc("https://example.com", "otp"))
+	assert.Panics(t, func() {
+		c.keePassXCAttributeFunc("https://example.com", "missing")
+	})
+	assert.Panics(t, func() {
+		c.keePassXCFunc("https://example.org")
+	})
+}
+
+func decodeTestKeePassXCBrowserNonce(t *testing.T, s string) [24]byte {
+	var nonce [24]byte
+	data, err := base64.StdEncoding.DecodeString(s)
+	assert.NoError(t, err)
+	copy(nonce[:], data)
+	return nonce
+}
@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// keePassXCBrowserSocketName is the name of the socket or named pipe on which
+// KeePassXC listens for browser integration requests.
+const keePassXCBrowserSocketName = "org.keepassxc.KeePassXC.BrowserServer"
+
+var keePassXCBrowserCmd = &cobra.Command{
+	Use:   "keepassxc-browser",
+	Args:  cobra.NoArgs,
+	Short: "Interact with KeePassXC's browser integration",
+}
+
+var keePassXCBrowserAssociateCmd = &cobra.Command{
+	Use:     "associate",
+	Args:    cobra.NoArgs,
+	Short:   "Associate chezmoi with KeePassXC's browser integration",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runKeePassXCBrowserAssociateCmd,
+}
+
+// A keePassXCBrowserConfig configures access to KeePassXC's browser
+// integration.
+type keePassXCBrowserConfig struct {
+	Socket string
+	ID     string
+	Key    string
+	client *keePassXCBrowserClient
+}
+
+// A keePassXCBrowserClient is a client of KeePassXC's browser integration
+// protocol, in which requests and responses are encrypted with NaCl boxes.
+type keePassXCBrowserClient struct {
+	conn            io.ReadWriteCloser
+	timeout         time.Duration
+	decoder         *json.Decoder
+	clientID        string
+	publicKey       *[32]byte
+	privateKey      *[32]byte
+	serverPublicKey *[32]byte
+}
+
+// A keePassXCBrowserMessage is a message sent to or received from KeePassXC.
+type keePassXCBrowserMessage struct {
+	Action    string `json:"action"`
+	Message   string `json:"message,omitempty"`
+	Nonce     string `json:"nonce"`
+	ClientID  string `json:"clientID"`
+	PublicKey string `json:"publicKey,omitempty"`
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+// A keePassXCBrowserEntry is an entry returned by KeePassXC.
+type keePassXCBrowserEntry struct {
+	Login        string              `json:"login"`
+	Name         string              `json:"name"`
+	Password     string              `json:"password"`
+	UUID         string              `json:"uuid"`
+	StringFields []map[string]string `json:"stringFields"`
+}
+
+func init() {
+	secretCmd.AddCommand(keePassXCBrowserCmd)
+	keePassXCBrowserCmd.AddCommand(keePassXCBrowserAssociateCmd)
+}
+
+func (c *Config) runKeePassXCBrowserAssociateCmd(cmd *cobra.Command, args []string) error {
+	// Do not time out while the user confirms the association.
+	client, err := c.getKeePassXCBrowserClient(0)
+	if err != nil {
+		return err
+	}
+	defer client.close()
+	fmt.Fprintln(c.Stdout, "Confirm the association in KeePassXC...")
+	id, key, err := client.associate()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.Stdout, "Add the following to your config file:\n\n"+
+		"    [keepassxc]\n"+
+		"      mode = \"browser\"\n"+
+		"    [keepassxc.browser]\n"+
+		"      id = %q\n"+
+		"      key = %q\n", id, key)
+	return nil
+}
+
+// keePassXCBrowserData returns the data of the first entry that KeePassXC's
+// browser integration returns for url, with the same keys as the output of
+// keepassxc-cli show and the entry's advanced string fields, which KeePassXC
+// only returns if their names start with KPH:.
+func (c *Config) keePassXCBrowserData(url string) (map[string]string, error) {
+	if c.KeePassXC.Browser.ID == "" || c.KeePassXC.Browser.Key == "" {
+		return nil, errors.New("keepassxc.browser.id or keepassxc.browser.key not set, run chezmoi secret keepassxc-browser associate")
+	}
+	if c.KeePassXC.Browser.client == nil {
+		client, err := c.getKeePassXCBrowserClient(c.Template.FuncTimeout)
+		if err != nil {
+			return nil, err
+		}
+		c.KeePassXC.Browser.client = client
+	}
+	entries, err := c.KeePassXC.Browser.client.getLogins(url, c.KeePassXC.Browser.ID, c.KeePassXC.Browser.Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no entries found")
+	}
+	entry := entries[0]
+	data := map[string]string{
+		"Title":    entry.Name,
+		"UserName": entry.Login,
+		"Password": entry.Password,
+		"UUID":     entry.UUID,
+	}
+	for _, stringField := range entry.StringFields {
+		for key, value := range stringField {
+			data[strings.TrimPrefix(key, "KPH: ")] = value
+		}
+	}
+	return data, nil
+}
+
+// getKeePassXCBrowserClient returns a new connection to KeePassXC's browser
+// integration, whose requests time out after timeout, if non-zero.
+func (c *Config) getKeePassXCBrowserClient(timeout time.Duration) (*keePassXCBrowserClient, error) {
+	socket := c.KeePassXC.Browser.Socket
+	if socket == "" {
+		var err error
+		if socket, err = defaultKeePassXCBrowserSocket(c.fs); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := dialKeePassXCBrowser(socket)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newKeePassXCBrowserClient(conn, timeout)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w", socket, err)
+	}
+	return client, nil
+}
+
+// newKeePassXCBrowserClient returns a new keePassXCBrowserClient that
+// communicates over conn, after exchanging public keys with KeePassXC.
+func newKeePassXCBrowserClient(conn io.ReadWriteCloser, timeout time.Duration) (*keePassXCBrowserClient, error) {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := randomBase64(24)
+	if err != nil {
+		return nil, err
+	}
+	client := &keePassXCBrowserClient{
+		conn:       conn,
+		timeout:    timeout,
+		decoder:    json.NewDecoder(conn),
+		clientID:   clientID,
+		publicKey:  publicKey,
+		privateKey: privateKey,
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	response, err := client.roundTrip(&keePassXCBrowserMessage{
+		Action:    "change-public-keys",
+		Nonce:     base64.StdEncoding.EncodeToString(nonce[:]),
+		ClientID:  clientID,
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey[:]),
+	}, &nonce)
+	if err != nil {
+		return nil, err
+	}
+	serverPublicKey, err := decodeKeePassXCBrowserKey(response.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("public key: %w", err)
+	}
+	client.serverPublicKey = serverPublicKey
+	return client, nil
+}
+
+// associate asks KeePassXC to associate a new identification key, which the
+// user must confirm, and returns the name that the user chose and the key.
+func (cl *keePassXCBrowserClient) associate() (string, string, error) {
+	idKey, _, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	key := base64.StdEncoding.EncodeToString(idKey[:])
+	var response struct {
+		ID string `json:"id"`
+	}
+	if err := cl.request("associate", map[string]interface{}{
+		"key":   base64.StdEncoding.EncodeToString(cl.publicKey[:]),
+		"idKey": key,
+	}, &response); err != nil {
+		return "", "", err
+	}
+	return response.ID, key, nil
+}
+
+// getLogins returns the entries that match url.
+func (cl *keePassXCBrowserClient) getLogins(url, id, key string) ([]keePassXCBrowserEntry, error) {
+	var response struct {
+		Entries []keePassXCBrowserEntry `json:"entries"`
+	}
+	if err := cl.request("get-logins", map[string]interface{}{
+		"url": url,
+		"keys": []map[string]string{
+			{
+				"id":  id,
+				"key": key,
+			},
+		},
+	}, &response); err != nil {
+		return nil, err
+	}
+	return response.Entries, nil
+}
+
+// request sends an encrypted request with action and message and decrypts the
+// response into response.
+func (cl *keePassXCBrowserClient) request(action string, message map[string]interface{}, response interface{}) error {
+	message["action"] = action
+	plaintext, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+	encryptedResponse, err := cl.roundTrip(&keePassXCBrowserMessage{
+		Action:   action,
+		Message:  base64.StdEncoding.EncodeToString(box.Seal(nil, plaintext, &nonce, cl.serverPublicKey, cl.privateKey)),
+		Nonce:    base64.StdEncoding.EncodeToString(nonce[:]),
+		ClientID: cl.clientID,
+	}, &nonce)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encryptedResponse.Message)
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	var responseNonce [24]byte
+	copy(responseNonce[:], nonce[:])
+	incrementKeePassXCBrowserNonce(&responseNonce)
+	decrypted, ok := box.Open(nil, ciphertext, &responseNonce, cl.serverPublicKey, cl.privateKey)
+	if !ok {
+		return fmt.Errorf("%s: cannot decrypt response", action)
+	}
+	var result struct {
+		Success   string `json:"success"`
+		Error     string `json:"error"`
+		ErrorCode string `json:"errorCode"`
+	}
+	if err := json.Unmarshal(decrypted, &result); err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	if result.Error != "" {
+		return fmt.Errorf("%s: %s (error %s)", action, result.Error, result.ErrorCode)
+	}
+	return json.Unmarshal(decrypted, response)
+}
+
+// roundTrip sends request and returns the response, checking that it is a
+// successful response to request, whose nonce is nonce.
+func (cl *keePassXCBrowserClient) roundTrip(request *keePassXCBrowserMessage, nonce *[24]byte) (*keePassXCBrowserMessage, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	if netConn, ok := cl.conn.(net.Conn); ok && cl.timeout > 0 {
+		if err := netConn.SetDeadline(time.Now().Add(cl.timeout)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := cl.conn.Write(data); err != nil {
+		return nil, err
+	}
+	var response keePassXCBrowserMessage
+	if err := cl.decoder.Decode(&response); err != nil {
+		return nil, fmt.Errorf("%s: %w", request.Action, err)
+	}
+	switch {
+	case response.Error != "":
+		return nil, fmt.Errorf("%s: %s (error %s)", request.Action, response.Error, response.ErrorCode)
+	case response.Action != request.Action:
+		return nil, fmt.Errorf("%s: unexpected response %s", request.Action, response.Action)
+	}
+	// KeePassXC responds with the request's nonce incremented.
+	var expectedNonce [24]byte
+	copy(expectedNonce[:], nonce[:])
+	incrementKeePassXCBrowserNonce(&expectedNonce)
+	if response.Nonce != base64.StdEncoding.EncodeToString(expectedNonce[:]) {
+		return nil, fmt.Errorf("%s: invalid nonce", request.Action)
+	}
+	return &response, nil
+}
+
+func (cl *keePassXCBrowserClient) close() error {
+	return cl.conn.Close()
+}
+
+// decodeKeePassXCBrowserKey decodes the base64-encoded NaCl key s.
+func decodeKeePassXCBrowserKey(s string) (*[32]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 32 {
+		return nil, fmt.Errorf("invalid length %d", len(data))
+	}
+	var key [32]byte
+	copy(key[:], data)
+	return &key, nil
+}
+
+// incrementKeePassXCBrowserNonce increments nonce as a little-endian number,
+// like libsodium's sodium_increment.
+func incrementKeePassXCBrowserNonce(nonce *[24]byte) {
+	for i := range nonce {
+		nonce[i]++
+		if nonce[i] != 0 {
+			return
+		}
+	}
+}
+
+// randomBase64 returns n random bytes, base64-encoded.
+func randomBase64(n int) (string, error) {
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,36 @@
+// +build !windows
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// defaultKeePassXCBrowserSocket returns the path of the socket on which
+// KeePassXC listens, which depends on its version and on the OS.
+func defaultKeePassXCBrowserSocket(fs vfs.Stater) (string, error) {
+	var candidates []string
+	if xdgRuntimeDir := os.Getenv("XDG_RUNTIME_DIR"); xdgRuntimeDir != "" {
+		candidates = append(candidates,
+			filepath.Join(xdgRuntimeDir, "app", "org.keepassxc.KeePassXC", keePassXCBrowserSocketName),
+			filepath.Join(xdgRuntimeDir, keePassXCBrowserSocketName),
+		)
+	}
+	candidates = append(candidates, filepath.Join(os.TempDir(), keePassXCBrowserSocketName))
+	for _, candidate := range candidates {
+		if _, err := fs.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("KeePassXC browser integration socket not found, is KeePassXC running with browser integration enabled?")
+}
+
+func dialKeePassXCBrowser(socket string) (io.ReadWriteCloser, error) {
+	return net.Dial("unix", socket)
+}
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// defaultKeePassXCBrowserSocket returns the path of the named pipe on which
+// KeePassXC listens.
+func defaultKeePassXCBrowserSocket(fs vfs.Stater) (string, error) {
+	return `\\.\pipe\` + keePassXCBrowserSocketName + "_" + os.Getenv("USERNAME"), nil
+}
+
+func dialKeePassXCBrowser(socket string) (io.ReadWriteCloser, error) {
+	return os.OpenFile(socket, os.O_RDWR, 0)
+}
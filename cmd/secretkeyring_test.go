@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	keyring "github.com/zalando/go-keyring"
+)
+
+func TestKeyring(t *testing.T) {
+	keyring.MockInit()
+
+	b := &bytes.Buffer{}
+	c := newConfig(
+		withStdout(b),
+	)
+	c.keyring.service = "github"
+	c.keyring.user = "user"
+	c.keyring.password = "ghp_secret"
+	require.NoError(t, c.runKeyringSetCmd(nil, nil))
+
+	require.NoError(t, c.runKeyringGetCmd(nil, nil))
+	assert.Equal(t, "ghp_secret\n", b.String())
+	assert.Equal(t, "ghp_secret", c.keyringFunc("github", "user"))
+
+	require.NoError(t, c.runKeyringDeleteCmd(nil, nil))
+	assert.Error(t, c.runKeyringGetCmd(nil, nil))
+	assert.Panics(t, func() {
+		c.keyringFunc("github", "user")
+	})
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	keyring "github.com/zalando/go-keyring"
+)
+
+var keyringDeleteCmd = &cobra.Command{
+	Use:     "delete",
+	Args:    cobra.NoArgs,
+	Short:   "Delete a password from keyring",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runKeyringDeleteCmd,
+}
+
+func init() {
+	keyringCmd.AddCommand(keyringDeleteCmd)
+}
+
+func (c *Config) runKeyringDeleteCmd(cmd *cobra.Command, args []string) error {
+	delete(keyringCache, keyringKey{
+		service: c.keyring.service,
+		user:    c.keyring.user,
+	})
+	return keyring.Delete(c.keyring.service, c.keyring.user)
+}
@@ -24,6 +24,6 @@ func (c *Config) runKeyringGetCmd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println(password)
-	return nil
+	_, err = fmt.Fprintln(c.Stdout, password)
+	return err
 }
@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"regexp"
 	"strings"
 	"sync"
@@ -54,10 +53,7 @@ func (c *Config) runLastpassCmd(cmd *cobra.Command, args []string) error {
 
 func (c *Config) lastpassOutput(args ...string) ([]byte, error) {
 	name := c.Lastpass.Command
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.templateFuncCmdOutput(name, args, os.Stdin, os.Stderr)
 	if err != nil {
 		return nil, err
 	}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 
 	"github.com/spf13/cobra"
 
@@ -19,7 +18,10 @@ var onepasswordCmd = &cobra.Command{
 }
 
 type onepasswordCmdConfig struct {
-	Command string
+	Command      string
+	ConnectHost  string
+	ConnectToken string
+	ConnectVault string
 }
 
 var (
@@ -29,6 +31,8 @@ var (
 
 func init() {
 	config.Onepassword.Command = "op"
+	config.Onepassword.ConnectHost = os.Getenv("OP_CONNECT_HOST")
+	config.Onepassword.ConnectToken = os.Getenv("OP_CONNECT_TOKEN")
 	config.addTemplateFunc("onepassword", config.onepasswordFunc)
 	config.addTemplateFunc("onepasswordDocument", config.onepasswordDocumentFunc)
 
@@ -43,12 +47,21 @@ func (c *Config) onepasswordFunc(item string) interface{} {
 	if data, ok := onepasswordCache[item]; ok {
 		return data
 	}
+	if c.Onepassword.ConnectHost != "" {
+		output, err := c.getOnepasswordConnectItem(item)
+		if err != nil {
+			panic(fmt.Errorf("onepassword: %s: %w", c.Onepassword.ConnectHost, err))
+		}
+		data, err := onepasswordConnectItemData(output)
+		if err != nil {
+			panic(fmt.Errorf("onepassword: %s: %w", c.Onepassword.ConnectHost, err))
+		}
+		onepasswordCache[item] = data
+		return data
+	}
 	name := c.Onepassword.Command
 	args := []string{"get", "item", item}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.templateFuncCmdOutput(name, args, os.Stdin, os.Stderr)
 	if err != nil {
 		panic(fmt.Errorf("onepassword: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
@@ -64,12 +77,17 @@ func (c *Config) onepasswordDocumentFunc(item string) interface{} {
 	if output, ok := onepasswordDocumentCache[item]; ok {
 		return output
 	}
+	if c.Onepassword.ConnectHost != "" {
+		output, err := c.getOnepasswordConnectDocument(item)
+		if err != nil {
+			panic(fmt.Errorf("onepassword: %s: %w", c.Onepassword.ConnectHost, err))
+		}
+		onepasswordDocumentCache[item] = string(output)
+		return string(output)
+	}
 	name := c.Onepassword.Command
 	args := []string{"get", "document", item}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.templateFuncCmdOutput(name, args, os.Stdin, os.Stderr)
 	if err != nil {
 		panic(fmt.Errorf("onepassword: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnepasswordConnect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.RequestURI() {
+		case "/v1/vaults":
+			_, _ = w.Write([]byte(`[{"id":"vault1"},{"id":"vault2"}]`))
+		case "/v1/vaults/vault2/items/login1":
+			_, _ = w.Write([]byte(`{
+				"id": "login1",
+				"title": "Example",
+				"vault": {"id": "vault2"},
+				"category": "LOGIN",
+				"urls": [{"primary": true, "href": "https://example.com"}],
+				"fields": [
+					{"id": "username", "type": "STRING", "purpose": "USERNAME", "label": "username", "value": "alice"},
+					{"id": "password", "type": "CONCEALED", "purpose": "PASSWORD", "label": "password", "value": "secret"}
+				]
+			}`))
+		case "/v1/vaults/vault1/items?filter=title+eq+%22Key%22":
+			_, _ = w.Write([]byte(`[{"id":"document1"}]`))
+		case "/v1/vaults/vault1/items/document1":
+			_, _ = w.Write([]byte(`{
+				"id": "document1",
+				"title": "Key",
+				"vault": {"id": "vault1"},
+				"category": "DOCUMENT",
+				"files": [{"id": "file1", "name": "key", "content_path": "/v1/vaults/vault1/items/document1/files/file1/content"}]
+			}`))
+		case "/v1/vaults/vault1/items/document1/files/file1/content":
+			_, _ = w.Write([]byte("contents of key\n"))
+		case "/v1/vaults/vault1/items?filter=title+eq+%22login1%22",
+			"/v1/vaults/vault2/items?filter=title+eq+%22Key%22",
+			"/v1/vaults/vault1/items?filter=title+eq+%22missing%22",
+			"/v1/vaults/vault2/items?filter=title+eq+%22missing%22":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := newConfig()
+	c.Onepassword.ConnectHost = server.URL
+	c.Onepassword.ConnectToken = "token"
+
+	var data interface{}
+	require.NotPanics(t, func() {
+		data = c.onepasswordFunc("login1")
+	})
+	item, ok := data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "login1", item["uuid"])
+	assert.Equal(t, "vault2", item["vaultUuid"])
+	assert.Equal(t, "secret", item["details"].(map[string]interface{})["password"])
+	assert.Equal(t, "https://example.com", item["overview"].(map[string]interface{})["url"])
+
+	assert.Equal(t, "contents of key\n", c.onepasswordDocumentFunc("Key"))
+
+	assert.Panics(t, func() {
+		c.onepasswordFunc("missing")
+	})
+
+	c.Onepassword.ConnectToken = "wrong"
+	assert.Panics(t, func() {
+		c.onepasswordFunc("unauthorized")
+	})
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// errOnepasswordConnectNotFound is returned when a 1Password Connect server
+// does not have the requested resource.
+var errOnepasswordConnectNotFound = errors.New("not found")
+
+// A onepasswordConnectItem is an item returned by the 1Password Connect API.
+type onepasswordConnectItem struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Vault struct {
+		ID string `json:"id"`
+	} `json:"vault"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+	URLs     []struct {
+		Primary bool   `json:"primary"`
+		Href    string `json:"href"`
+	} `json:"urls"`
+	Fields []struct {
+		ID      string `json:"id"`
+		Type    string `json:"type"`
+		Purpose string `json:"purpose"`
+		Label   string `json:"label"`
+		Value   string `json:"value"`
+	} `json:"fields"`
+	Files []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		ContentPath string `json:"content_path"`
+	} `json:"files"`
+}
+
+// onepasswordConnectItemData returns item as structured data in the same
+// shape as the output of op get item, so that templates work with both. The
+// original item is available as connectItem.
+func onepasswordConnectItemData(data []byte) (interface{}, error) {
+	var item onepasswordConnectItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	var connectItem interface{}
+	if err := json.Unmarshal(data, &connectItem); err != nil {
+		return nil, err
+	}
+	fields := make([]interface{}, 0, len(item.Fields))
+	details := map[string]interface{}{}
+	for _, field := range item.Fields {
+		designation := strings.ToLower(field.Purpose)
+		fields = append(fields, map[string]interface{}{
+			"designation": designation,
+			"name":        field.Label,
+			"type":        field.Type,
+			"value":       field.Value,
+		})
+		switch {
+		case field.Purpose == "PASSWORD" || field.Purpose == "" && field.ID == "password":
+			details["password"] = field.Value
+		case field.Purpose == "NOTES":
+			details["notesPlain"] = field.Value
+		}
+	}
+	details["fields"] = fields
+	overview := map[string]interface{}{
+		"title": item.Title,
+		"tags":  item.Tags,
+	}
+	for i, u := range item.URLs {
+		if i == 0 || u.Primary {
+			overview["url"] = u.Href
+		}
+	}
+	return map[string]interface{}{
+		"uuid":        item.ID,
+		"vaultUuid":   item.Vault.ID,
+		"details":     details,
+		"overview":    overview,
+		"connectItem": connectItem,
+	}, nil
+}
+
+// getOnepasswordConnectItem returns the raw JSON of the item with the given
+// UUID or title from the configured 1Password Connect server. If no vault is
+// configured then all vaults that the token can access are searched.
+func (c *Config) getOnepasswordConnectItem(item string) ([]byte, error) {
+	var vaultIDs []string
+	if c.Onepassword.ConnectVault != "" {
+		vaultIDs = []string{c.Onepassword.ConnectVault}
+	} else {
+		data, err := c.onepasswordConnectGet("/v1/vaults")
+		if err != nil {
+			return nil, err
+		}
+		var vaults []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(data, &vaults); err != nil {
+			return nil, err
+		}
+		for _, vault := range vaults {
+			vaultIDs = append(vaultIDs, vault.ID)
+		}
+	}
+	for _, vaultID := range vaultIDs {
+		data, err := c.onepasswordConnectGet("/v1/vaults/" + url.PathEscape(vaultID) + "/items/" + url.PathEscape(item))
+		switch {
+		case err == nil:
+			return data, nil
+		case !errors.Is(err, errOnepasswordConnectNotFound):
+			return nil, err
+		}
+		filter := url.Values{}
+		filter.Set("filter", fmt.Sprintf("title eq %q", item))
+		data, err = c.onepasswordConnectGet("/v1/vaults/" + url.PathEscape(vaultID) + "/items?" + filter.Encode())
+		if err != nil {
+			return nil, err
+		}
+		var items []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		switch len(items) {
+		case 0:
+		case 1:
+			return c.onepasswordConnectGet("/v1/vaults/" + url.PathEscape(vaultID) + "/items/" + url.PathEscape(items[0].ID))
+		default:
+			return nil, fmt.Errorf("%s: multiple items found", item)
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", item, errOnepasswordConnectNotFound)
+}
+
+// getOnepasswordConnectDocument returns the contents of the first file of the
+// document with the given UUID or title from the configured 1Password Connect
+// server.
+func (c *Config) getOnepasswordConnectDocument(item string) ([]byte, error) {
+	data, err := c.getOnepasswordConnectItem(item)
+	if err != nil {
+		return nil, err
+	}
+	var connectItem onepasswordConnectItem
+	if err := json.Unmarshal(data, &connectItem); err != nil {
+		return nil, err
+	}
+	if len(connectItem.Files) == 0 {
+		return nil, fmt.Errorf("%s: no files", item)
+	}
+	contentPath := connectItem.Files[0].ContentPath
+	if contentPath == "" {
+		contentPath = "/v1/vaults/" + url.PathEscape(connectItem.Vault.ID) + "/items/" + url.PathEscape(connectItem.ID) + "/files/" + url.PathEscape(connectItem.Files[0].ID) + "/content"
+	}
+	return c.onepasswordConnectGet(contentPath)
+}
+
+// onepasswordConnectGet returns the body of a GET request for path on the
+// configured 1Password Connect server.
+func (c *Config) onepasswordConnectGet(path string) ([]byte, error) {
+	if c.Onepassword.ConnectToken == "" {
+		return nil, errors.New("onepassword.connectToken not set")
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(c.Onepassword.ConnectHost, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Onepassword.ConnectToken)
+	client := &http.Client{
+		Timeout: c.Template.FuncTimeout,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return data, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", req.URL, errOnepasswordConnectNotFound)
+	default:
+		return nil, fmt.Errorf("%s: %s", req.URL, resp.Status)
+	}
+}
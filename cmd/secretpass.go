@@ -3,7 +3,6 @@ package cmd
 import (
 	"bytes"
 	"fmt"
-	"os/exec"
 
 	"github.com/spf13/cobra"
 
@@ -40,8 +39,7 @@ func (c *Config) passFunc(id string) string {
 	}
 	name := c.Pass.Command
 	args := []string{"show", id}
-	cmd := exec.Command(name, args...)
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.templateFuncCmdOutput(name, args, nil, nil)
 	if err != nil {
 		panic(fmt.Errorf("pass: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
 	}
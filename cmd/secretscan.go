@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// A secretPattern is a pattern that matches a likely secret.
+type secretPattern struct {
+	description string
+	regexp      *regexp.Regexp
+}
+
+// secretPatterns are the patterns of well-known kinds of secret.
+var secretPatterns = []secretPattern{
+	{"private key", regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{"AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[0-9A-Za-z]{36,}|github_pat_[0-9A-Za-z_]{22,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[0-9A-Za-z_-]{20,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{"npm token", regexp.MustCompile(`\bnpm_[0-9A-Za-z]{36}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[0-9A-Za-z-]{10,}`)},
+	{"Stripe key", regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}`)},
+}
+
+var (
+	// secretCandidateRegexp matches strings that might be random tokens.
+	secretCandidateRegexp = regexp.MustCompile(`[0-9A-Za-z+/=_-]{24,}`)
+	// secretCandidateClassRegexps all match random tokens.
+	secretCandidateClassRegexps = []*regexp.Regexp{
+		regexp.MustCompile(`[0-9]`),
+		regexp.MustCompile(`[A-Z]`),
+		regexp.MustCompile(`[a-z]`),
+	}
+)
+
+// minSecretEntropy is the minimum Shannon entropy, in bits per character, of a
+// random token. It is above the maximum entropy of hexadecimal strings, so that
+// hashes like git commit IDs are not reported.
+const minSecretEntropy = 4.2
+
+// A secretFinding is a likely secret.
+type secretFinding struct {
+	Line        int    `json:"line"`
+	Description string `json:"description"`
+}
+
+// scanForSecrets returns the likely secrets in contents. Only the first line
+// of each kind of secret is reported, so that, for example, the lines of a
+// private key are not reported individually.
+func scanForSecrets(contents []byte) []secretFinding {
+	var findings []secretFinding
+	found := make(map[string]bool)
+	addFinding := func(lineNumber int, description string) {
+		if !found[description] {
+			findings = append(findings, secretFinding{
+				Line:        lineNumber,
+				Description: description,
+			})
+			found[description] = true
+		}
+	}
+	for i, line := range strings.Split(string(contents), "\n") {
+		lineNumber := i + 1
+		matched := false
+		for _, pattern := range secretPatterns {
+			if pattern.regexp.MatchString(line) {
+				addFinding(lineNumber, pattern.description)
+				matched = true
+			}
+		}
+		if matched {
+			continue
+		}
+	CANDIDATE:
+		for _, candidate := range secretCandidateRegexp.FindAllString(line, -1) {
+			for _, classRegexp := range secretCandidateClassRegexps {
+				if !classRegexp.MatchString(candidate) {
+					continue CANDIDATE
+				}
+			}
+			if shannonEntropy(candidate) >= minSecretEntropy {
+				addFinding(lineNumber, "high-entropy string")
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func (f secretFinding) String() string {
+	return fmt.Sprintf("line %d: %s", f.Line, f.Description)
+}
+
+// formatSecretFindings returns a warning about findings in path.
+func formatSecretFindings(path string, findings []secretFinding) string {
+	descriptions := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		descriptions = append(descriptions, finding.String())
+	}
+	return fmt.Sprintf("warning: %s: may contain secrets (%s), use --encrypt or a template that reads them from a password manager", path, strings.Join(descriptions, ", "))
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+type secretConfig struct {
+	TempDir string
+}
+
+var (
+	secretTempDirsMutex sync.Mutex
+	secretTempDirs      = make(map[string]struct{})
+)
+
+// makeSecretTempDir creates a new private temporary directory for plaintext
+// secrets and returns its name and a function that removes it. The directory
+// is also removed if chezmoi is interrupted.
+func (c *Config) makeSecretTempDir() (string, func(), error) {
+	handleInterrupts()
+
+	tempDir, err := ioutil.TempDir(c.getSecretTempDirParent(), "chezmoi")
+	if err != nil {
+		return "", nil, err
+	}
+
+	secretTempDirsMutex.Lock()
+	secretTempDirs[tempDir] = struct{}{}
+	secretTempDirsMutex.Unlock()
+
+	return tempDir, func() {
+		secretTempDirsMutex.Lock()
+		delete(secretTempDirs, tempDir)
+		secretTempDirsMutex.Unlock()
+		_ = os.RemoveAll(tempDir)
+	}, nil
+}
+
+// getSecretTempDirParent returns the directory in which to create temporary
+// directories for plaintext secrets. This is secret.tempDir if set, otherwise
+// $XDG_RUNTIME_DIR if it exists, as it is typically a private in-memory
+// filesystem, otherwise the system temporary directory.
+func (c *Config) getSecretTempDirParent() string {
+	if c.Secret.TempDir != "" {
+		return c.Secret.TempDir
+	}
+	if c.bds.RuntimeDir != "" {
+		if info, err := os.Stat(c.bds.RuntimeDir); err == nil && info.IsDir() {
+			return c.bds.RuntimeDir
+		}
+	}
+	return ""
+}
+
+// removeSecretTempDirs removes all temporary directories for plaintext secrets.
+// It leaves secretTempDirsMutex locked so that no more are created.
+func removeSecretTempDirs() {
+	secretTempDirsMutex.Lock()
+	for tempDir := range secretTempDirs {
+		_ = os.RemoveAll(tempDir)
+	}
+}
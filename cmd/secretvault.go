@@ -4,7 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
+	"path"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -19,14 +20,36 @@ var vaultCmd = &cobra.Command{
 }
 
 type vaultCmdConfig struct {
-	Command string
+	Command        string
+	Address        string
+	Token          string
+	Namespace      string
+	AuthMethod     string
+	AuthMount      string
+	RoleID         string
+	SecretID       string
+	Role           string
+	AWSServerID    string
+	MinTTL         time.Duration
+	token          string
+	tokenExpiry    time.Time
+	tokenRenewable bool
 }
 
-var vaultCache = make(map[string]interface{})
+var (
+	vaultCache     = make(map[string]interface{})
+	vaultKVv2Cache = make(map[string]interface{})
+)
 
 func init() {
 	config.Vault.Command = "vault"
+	// vault.address is deliberately not set from $VAULT_ADDR, which the Vault
+	// CLI also uses, so that chezmoi only reads from the Vault server directly
+	// when it is configured to.
+	config.Vault.Token = os.Getenv("VAULT_TOKEN")
+	config.Vault.Namespace = os.Getenv("VAULT_NAMESPACE")
 	config.addTemplateFunc("vault", config.vaultFunc)
+	config.addTemplateFunc("vaultKVv2", config.vaultKVv2Func)
 
 	secretCmd.AddCommand(vaultCmd)
 }
@@ -39,12 +62,17 @@ func (c *Config) vaultFunc(key string) interface{} {
 	if data, ok := vaultCache[key]; ok {
 		return data
 	}
+	if c.Vault.Address != "" {
+		data, err := c.vaultRead(key)
+		if err != nil {
+			panic(fmt.Errorf("vault: %s: %w", key, err))
+		}
+		vaultCache[key] = data
+		return data
+	}
 	name := c.Vault.Command
 	args := []string{"kv", "get", "-format=json", key}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.templateFuncCmdOutput(name, args, os.Stdin, os.Stderr)
 	if err != nil {
 		panic(fmt.Errorf("vault: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
@@ -55,3 +83,28 @@ func (c *Config) vaultFunc(key string) interface{} {
 	vaultCache[key] = data
 	return data
 }
+
+func (c *Config) vaultKVv2Func(mount, key string) interface{} {
+	secretPath := path.Join(mount, "data", key)
+	if data, ok := vaultKVv2Cache[secretPath]; ok {
+		return data
+	}
+	if c.Vault.Address == "" {
+		panic(fmt.Errorf("vaultKVv2: %s: vault.address not set", secretPath))
+	}
+	result, err := c.vaultRead(secretPath)
+	if err != nil {
+		panic(fmt.Errorf("vaultKVv2: %s: %w", secretPath, err))
+	}
+	var data interface{}
+	if resultMap, ok := result.(map[string]interface{}); ok {
+		if dataMap, ok := resultMap["data"].(map[string]interface{}); ok {
+			data = dataMap["data"]
+		}
+	}
+	if data == nil {
+		panic(fmt.Errorf("vaultKVv2: %s: no data", secretPath))
+	}
+	vaultKVv2Cache[secretPath] = data
+	return data
+}
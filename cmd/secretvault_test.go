@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultClient(t *testing.T) {
+	defer setTestAWSCredentials(t)()
+
+	const kvV2Response = `{"data":{"data":{"password":"secret"},"metadata":{"version":2}}}`
+
+	for _, tc := range []struct {
+		name       string
+		authMethod string
+		authMount  string
+		token      string
+		login      func(t *testing.T, path string, data map[string]interface{}) string
+		renew      bool
+	}{
+		{
+			name:  "token",
+			token: "token1",
+		},
+		{
+			name:  "token_renew",
+			token: "token1",
+			renew: true,
+		},
+		{
+			name:       "approle",
+			authMethod: "approle",
+			login: func(t *testing.T, path string, data map[string]interface{}) string {
+				assert.Equal(t, "/v1/auth/approle/login", path)
+				assert.Equal(t, map[string]interface{}{
+					"role_id":   "role",
+					"secret_id": "secret",
+				}, data)
+				return "token1"
+			},
+		},
+		{
+			name:       "aws",
+			authMethod: "aws",
+			authMount:  "aws-prod",
+			login: func(t *testing.T, path string, data map[string]interface{}) string {
+				assert.Equal(t, "/v1/auth/aws-prod/login", path)
+				assert.Equal(t, "role", data["role"])
+				assert.Equal(t, "POST", data["iam_http_request_method"])
+				decode := func(key string) string {
+					value, err := base64.StdEncoding.DecodeString(data[key].(string))
+					require.NoError(t, err)
+					return string(value)
+				}
+				assert.Equal(t, "https://sts.amazonaws.com/", decode("iam_request_url"))
+				assert.Contains(t, decode("iam_request_body"), "Action=GetCallerIdentity")
+				var headers map[string][]string
+				require.NoError(t, json.Unmarshal([]byte(decode("iam_request_headers")), &headers))
+				assert.Equal(t, []string{"vault.example.com"}, headers["X-Vault-Aws-Iam-Server-Id"])
+				assert.Contains(t, headers["Authorization"][0], "Credential=id/")
+				return "token1"
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			renewed := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "ns1", r.Header.Get("X-Vault-Namespace"))
+				token := r.Header.Get("X-Vault-Token")
+				switch {
+				case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/login"):
+					var data map[string]interface{}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&data))
+					_, _ = w.Write([]byte(`{"auth":{"client_token":"` + tc.login(t, r.URL.Path, data) + `","lease_duration":3600,"renewable":true}}`))
+				case r.URL.Path == "/v1/auth/token/lookup-self" && token == "token1":
+					if tc.renew {
+						_, _ = w.Write([]byte(`{"data":{"ttl":60,"renewable":true}}`))
+					} else {
+						_, _ = w.Write([]byte(`{"data":{"ttl":0,"renewable":false}}`))
+					}
+				case r.URL.Path == "/v1/auth/token/renew-self" && token == "token1":
+					renewed = true
+					_, _ = w.Write([]byte(`{"auth":{"client_token":"token2","lease_duration":3600,"renewable":true}}`))
+				case r.URL.Path == "/v1/kv/data/"+tc.name && (token == "token1" && !tc.renew || token == "token2"):
+					_, _ = w.Write([]byte(kvV2Response))
+				case token != "token1" && token != "token2":
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"errors":[]}`))
+				}
+			}))
+			defer server.Close()
+
+			c := newConfig()
+			c.Vault.Address = server.URL
+			c.Vault.Token = tc.token
+			c.Vault.Namespace = "ns1"
+			c.Vault.AuthMethod = tc.authMethod
+			c.Vault.AuthMount = tc.authMount
+			c.Vault.RoleID = "role"
+			c.Vault.SecretID = "secret"
+			c.Vault.Role = "role"
+			c.Vault.AWSServerID = "vault.example.com"
+
+			assert.Equal(t, map[string]interface{}{
+				"password": "secret",
+			}, c.vaultKVv2Func("kv", tc.name))
+			assert.Equal(t, map[string]interface{}{
+				"data": map[string]interface{}{
+					"data": map[string]interface{}{
+						"password": "secret",
+					},
+					"metadata": map[string]interface{}{
+						"version": float64(2),
+					},
+				},
+			}, c.vaultFunc("kv/data/"+tc.name))
+			assert.Equal(t, tc.renew, renewed)
+			assert.Panics(t, func() {
+				c.vaultFunc("kv/data/missing")
+			})
+		})
+	}
+}
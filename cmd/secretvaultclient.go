@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// A vaultResponse is a response from the Vault HTTP API.
+type vaultResponse struct {
+	Auth *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+// vaultRead returns the response to a read of path, as returned by vault read
+// -format=json.
+func (c *Config) vaultRead(path string) (interface{}, error) {
+	if err := c.vaultEnsureToken(); err != nil {
+		return nil, err
+	}
+	data, err := c.vaultRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// vaultEnsureToken ensures that c has a Vault token that will not expire
+// within vault.minTTL, logging in or renewing the token if needed.
+func (c *Config) vaultEnsureToken() error {
+	switch {
+	case c.Vault.token == "":
+		return c.vaultLogin()
+	case c.Vault.tokenExpiry.IsZero():
+		return nil
+	case time.Until(c.Vault.tokenExpiry) >= c.Vault.MinTTL:
+		return nil
+	case c.Vault.tokenRenewable:
+		resp, err := c.vaultAuthRequest("auth/token/renew-self", map[string]interface{}{})
+		if err != nil {
+			return fmt.Errorf("renew token: %w", err)
+		}
+		c.vaultSetToken(resp)
+		return nil
+	case c.Vault.AuthMethod != "" && c.Vault.AuthMethod != "token":
+		return c.vaultLogin()
+	default:
+		return nil
+	}
+}
+
+// vaultLogin logs in to Vault with the configured authentication method.
+func (c *Config) vaultLogin() error {
+	mount := c.Vault.AuthMount
+	switch c.Vault.AuthMethod {
+	case "", "token":
+		token := c.Vault.Token
+		if token == "" {
+			data, err := c.fs.ReadFile(filepath.Join(c.homeDir, ".vault-token"))
+			switch {
+			case os.IsNotExist(err):
+				return errors.New("vault.token not set")
+			case err != nil:
+				return err
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		c.Vault.token = token
+		data, err := c.vaultRequest(http.MethodGet, "auth/token/lookup-self", nil)
+		if err != nil {
+			return fmt.Errorf("lookup token: %w", err)
+		}
+		var lookup struct {
+			Data struct {
+				TTL       int64 `json:"ttl"`
+				Renewable bool  `json:"renewable"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(data, &lookup); err != nil {
+			return fmt.Errorf("lookup token: %w", err)
+		}
+		if lookup.Data.TTL > 0 {
+			c.Vault.tokenExpiry = time.Now().Add(time.Duration(lookup.Data.TTL) * time.Second)
+			c.Vault.tokenRenewable = lookup.Data.Renewable
+		}
+		return c.vaultEnsureToken()
+	case "approle":
+		if mount == "" {
+			mount = "approle"
+		}
+		resp, err := c.vaultAuthRequest(path.Join("auth", mount, "login"), map[string]interface{}{
+			"role_id":   c.Vault.RoleID,
+			"secret_id": c.Vault.SecretID,
+		})
+		if err != nil {
+			return fmt.Errorf("approle login: %w", err)
+		}
+		c.vaultSetToken(resp)
+		return nil
+	case "aws":
+		if mount == "" {
+			mount = "aws"
+		}
+		loginData, err := c.vaultAWSLoginData()
+		if err != nil {
+			return fmt.Errorf("aws login: %w", err)
+		}
+		resp, err := c.vaultAuthRequest(path.Join("auth", mount, "login"), loginData)
+		if err != nil {
+			return fmt.Errorf("aws login: %w", err)
+		}
+		c.vaultSetToken(resp)
+		return nil
+	default:
+		return fmt.Errorf("%s: unsupported authentication method", c.Vault.AuthMethod)
+	}
+}
+
+// vaultAWSLoginData returns the data for logging in to Vault's aws
+// authentication method using a signed sts:GetCallerIdentity request made with
+// the standard AWS credential chain.
+func (c *Config) vaultAWSLoginData() (map[string]interface{}, error) {
+	// Vault verifies requests with the global STS endpoint by default, which
+	// is in us-east-1.
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Region: aws.String("us-east-1"),
+		},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, _ := sts.New(sess).GetCallerIdentityRequest(nil)
+	if c.Vault.AWSServerID != "" {
+		req.HTTPRequest.Header.Set("X-Vault-AWS-IAM-Server-ID", c.Vault.AWSServerID)
+	}
+	if err := req.Sign(); err != nil {
+		return nil, err
+	}
+	headers, err := json.Marshal(req.HTTPRequest.Header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := req.Body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"role":                    c.Vault.Role,
+		"iam_http_request_method": req.HTTPRequest.Method,
+		"iam_request_url":         base64.StdEncoding.EncodeToString([]byte(req.HTTPRequest.URL.String())),
+		"iam_request_headers":     base64.StdEncoding.EncodeToString(headers),
+		"iam_request_body":        base64.StdEncoding.EncodeToString(body),
+	}, nil
+}
+
+// vaultAuthRequest posts data to path and returns the response, which must
+// contain a token.
+func (c *Config) vaultAuthRequest(path string, data map[string]interface{}) (*vaultResponse, error) {
+	reqBody, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := c.vaultRequest(http.MethodPost, path, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	var resp vaultResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return nil, errors.New("no token in response")
+	}
+	return &resp, nil
+}
+
+// vaultSetToken sets c's Vault token from resp.
+func (c *Config) vaultSetToken(resp *vaultResponse) {
+	c.Vault.token = resp.Auth.ClientToken
+	c.Vault.tokenRenewable = resp.Auth.Renewable
+	if resp.Auth.LeaseDuration > 0 {
+		c.Vault.tokenExpiry = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second)
+	} else {
+		c.Vault.tokenExpiry = time.Time{}
+	}
+}
+
+// vaultRequest makes a request to the Vault HTTP API and returns the response
+// body.
+func (c *Config) vaultRequest(method, path string, body io.Reader) ([]byte, error) {
+	url := strings.TrimSuffix(c.Vault.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Vault.token != "" {
+		req.Header.Set("X-Vault-Token", c.Vault.token)
+	}
+	if c.Vault.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.Vault.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := &http.Client{
+		Timeout: c.Template.FuncTimeout,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp vaultResponse
+		if err := json.Unmarshal(data, &errResp); err == nil && len(errResp.Errors) > 0 {
+			return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.Join(errResp.Errors, ", "))
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	return data, nil
+}
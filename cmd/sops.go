@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+type sopsConfig struct {
+	Command string
+}
+
+// getSOPSData returns the decrypted contents of the SOPS data file in the
+// source directory, or nil if it does not exist. SOPS finds the keys itself,
+// using the metadata in the file, so any of its backends, for example age or
+// a cloud KMS, can be used.
+func (c *Config) getSOPSData() (map[string]interface{}, error) {
+	path := filepath.Join(c.SourceDir, chezmoi.SOPSDataName)
+	switch _, err := c.fs.Stat(path); {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	args := []string{"--decrypt", "--output-type", "json", path}
+	//nolint:gosec
+	cmd := exec.Command(c.SOPS.Command, args...)
+	cmd.Stderr = c.Stderr
+	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s %s: %w", path, c.SOPS.Command, chezmoi.ShellQuoteArgs(args), err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(output, &data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
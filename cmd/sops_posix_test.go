@@ -0,0 +1,72 @@
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestSOPSData(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-sops")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	sops := filepath.Join(tempDir, "sops")
+	require.NoError(t, ioutil.WriteFile(sops, []byte(""+
+		"#!/bin/sh\n"+
+		"if [ \"$*\" != \"--decrypt --output-type json /home/user/.local/share/chezmoi/secrets.sops.yaml\" ]; then\n"+
+		"\techo \"unexpected arguments: $*\" >&2\n"+
+		"\texit 1\n"+
+		"fi\n"+
+		"echo '{\"github\":{\"token\":\"ghp_secret\"}}'\n",
+	), 0755))
+
+	for _, tc := range []struct {
+		name     string
+		root     interface{}
+		expected interface{}
+	}{
+		{
+			name: "sops",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/secrets.sops.yaml": "github:\n    token: ENC[AES256_GCM,data:...]\n",
+			},
+			expected: map[string]interface{}{
+				"github": map[string]interface{}{
+					"token": "ghp_secret",
+				},
+			},
+		},
+		{
+			name: "no_sops",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": &vfst.Dir{Perm: 0755},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+
+			c := newTestConfig(fs, withMutator(chezmoi.NullMutator{}))
+			c.SOPS.Command = sops
+
+			data, err := c.getData()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, data["sops"])
+
+			ts, err := c.getTargetState(nil)
+			require.NoError(t, err)
+			assert.Empty(t, ts.Entries)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+const sshDestinationPrefix = "ssh://"
+
+type sshConfig struct {
+	Command string
+	Args    []string
+}
+
+// isSSHDestination returns whether destDir is an ssh:// URL.
+func isSSHDestination(destDir string) bool {
+	return strings.HasPrefix(destDir, sshDestinationPrefix)
+}
+
+// newSSHDestination returns a new remoteDestination for rawURL, an ssh:// URL,
+// and the directory on the remote host.
+func (c *Config) newSSHDestination(rawURL string) (*remoteDestination, string, error) {
+	host, port, remoteDir, err := parseSSHDestination(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	args := append([]string{}, c.SSH.Args...)
+	if port != "" {
+		args = append(args, "-p", port)
+	}
+	d, err := newSSHRemoteDestination(c.SSH.Command, args, host)
+	if err != nil {
+		return nil, "", err
+	}
+	d.url = rawURL
+	return d, remoteDir, nil
+}
+
+// newSSHRemoteDestination returns a new remoteDestination for host, accessed by
+// running command with args.
+func newSSHRemoteDestination(command string, args []string, host string) (*remoteDestination, error) {
+	// Share a single connection between all of the ssh commands that chezmoi
+	// runs, which would otherwise each connect separately.
+	controlDir, err := ioutil.TempDir("", "chezmoi-ssh")
+	if err != nil {
+		return nil, err
+	}
+	controlPath := filepath.Join(controlDir, "control")
+	fsArgs := append([]string{}, args...)
+	fsArgs = append(fsArgs,
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath="+controlPath,
+		"-o", "ControlPersist=60",
+		"--", host,
+	)
+
+	return &remoteDestination{
+		url:  host,
+		name: host,
+		fs:   chezmoi.NewRemoteFS(command, fsArgs),
+		close: func() {
+			if _, err := os.Stat(controlPath); err == nil {
+				closeArgs := append([]string{}, args...)
+				closeArgs = append(closeArgs, "-o", "ControlPath="+controlPath, "-O", "exit", "--", host)
+				//nolint:gosec
+				cmd := exec.Command(command, closeArgs...)
+				_ = cmd.Run()
+			}
+			_ = os.RemoveAll(controlDir)
+		},
+	}, nil
+}
+
+// parseSSHDestination parses rawURL, an ssh://[user@]host[:port][/path] URL,
+// and returns the ssh host, port, and path. A path of ~, or beginning with ~/,
+// is relative to the remote user's home directory.
+func parseSSHDestination(rawURL string) (string, string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", "", err
+	}
+	host := u.Hostname()
+	switch {
+	case u.Scheme != "ssh":
+		return "", "", "", fmt.Errorf("%s: not an ssh:// URL", rawURL)
+	case host == "" || strings.HasPrefix(host, "-"):
+		return "", "", "", fmt.Errorf("%s: invalid host", rawURL)
+	case u.RawQuery != "" || u.Fragment != "":
+		return "", "", "", fmt.Errorf("%s: invalid ssh destination", rawURL)
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return "", "", "", errors.New("ssh destinations cannot contain passwords")
+		}
+		// The user is the start of the ssh destination argument, so it must
+		// not be interpreted as an option.
+		username := u.User.Username()
+		if username == "" || strings.HasPrefix(username, "-") {
+			return "", "", "", fmt.Errorf("%s: invalid user", rawURL)
+		}
+		host = username + "@" + host
+	}
+	return host, u.Port(), remoteDestinationPath(u.Path), nil
+}
+
+// remoteDestinationPath returns the remote directory of a remote destination
+// URL with path p. A path of / is the remote user's home directory, and a path
+// beginning with /~ is relative to it.
+func remoteDestinationPath(p string) string {
+	switch {
+	case p == "/":
+		return ""
+	case strings.HasPrefix(p, "/~"):
+		return p[1:]
+	default:
+		return p
+	}
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import "errors"
+
+func init() {
+	config.addTemplateFunc("targetContents", config.targetContentsFunc)
+}
+
+// targetContentsFunc is replaced by the target state when it executes a source
+// state template. It is registered so that templates in .chezmoitemplates that
+// call targetContents can be parsed, and is only called from templates that
+// are not executed by a target state, for example those included with
+// includeTemplate.
+func (c *Config) targetContentsFunc(target string) string {
+	panic(errors.New("targetContents: only available in source state templates"))
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// templateFuncCmdOutput returns the output of name with args, run by a
+// template function with stdin and stderr, either of which may be nil. The
+// command is killed if it does not exit within c.Template.FuncTimeout, so that
+// a hung password manager CLI does not hang chezmoi.
+func (c *Config) templateFuncCmdOutput(name string, args []string, stdin io.Reader, stderr io.Writer) ([]byte, error) {
+	ctx := context.Background()
+	if c.Template.FuncTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Template.FuncTimeout)
+		defer cancel()
+	}
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+	cmd.Stderr = stderr
+	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("timed out after %s, increase template.funcTimeout if needed", c.Template.FuncTimeout)
+	}
+	return output, err
+}
@@ -0,0 +1,75 @@
+// +build !windows
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestTemplateFuncCmdOutput(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		funcTimeout   time.Duration
+		args          []string
+		wantOutput    string
+		wantErrString string
+	}{
+		{
+			name:        "ok",
+			funcTimeout: time.Minute,
+			args:        []string{"-c", "echo ok"},
+			wantOutput:  "ok\n",
+		},
+		{
+			name:        "no_timeout",
+			funcTimeout: 0,
+			args:        []string{"-c", "sleep 0.1; echo ok"},
+			wantOutput:  "ok\n",
+		},
+		{
+			name:          "timeout",
+			funcTimeout:   100 * time.Millisecond,
+			args:          []string{"-c", "exec sleep 10"},
+			wantErrString: "timed out after 100ms",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newConfig(
+				withMutator(chezmoi.NullMutator{}),
+			)
+			c.Template.FuncTimeout = tc.funcTimeout
+			start := time.Now()
+			output, err := c.templateFuncCmdOutput("sh", tc.args, nil, nil)
+			if tc.wantErrString != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErrString)
+				assert.True(t, time.Since(start) < 5*time.Second)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantOutput, string(output))
+		})
+	}
+}
+
+func TestSecretFuncTimeout(t *testing.T) {
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+		withGenericSecretCmdConfig(genericSecretCmdConfig{
+			Command: "sleep",
+		}),
+	)
+	c.Template.FuncTimeout = 100 * time.Millisecond
+	defer func() {
+		err, ok := recover().(error)
+		require.True(t, ok)
+		assert.Equal(t, "secret: sleep 10: timed out after 100ms, increase template.funcTimeout if needed\n", err.Error())
+	}()
+	c.secretFunc("10")
+}
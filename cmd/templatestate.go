@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// templateStateCache caches the values read and written by stateGet and
+// stateSet, so that values set in dry run mode, which are not stored, are still
+// visible to later templates.
+var templateStateCache = make(map[string]interface{})
+
+func init() {
+	config.addTemplateFunc("stateGet", config.stateGetFunc)
+	config.addTemplateFunc("stateSet", config.stateSetFunc)
+}
+
+func (c *Config) stateGetFunc(namespace, key string) interface{} {
+	value, err := c.getTemplateState(namespace, key)
+	if err != nil {
+		panic(fmt.Errorf("stateGet %q %q: %w", namespace, key, err))
+	}
+	return value
+}
+
+func (c *Config) stateSetFunc(namespace, key string, value interface{}) string {
+	if err := c.setTemplateState(namespace, key, value); err != nil {
+		panic(fmt.Errorf("stateSet %q %q: %w", namespace, key, err))
+	}
+	return ""
+}
+
+// getTemplateState returns the value stored for key in namespace, or nil if
+// there is no stored value.
+func (c *Config) getTemplateState(namespace, key string) (interface{}, error) {
+	stateKey, err := c.templateStateKey(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := templateStateCache[stateKey]; ok {
+		return value, nil
+	}
+
+	var value interface{}
+	if err := c.withOpenPersistentState(func(persistentState *openPersistentState) error {
+		data, err := persistentState.Get(c.stateFuncsBucket, []byte(stateKey))
+		if err != nil || data == nil {
+			return err
+		}
+		return json.Unmarshal(data, &value)
+	}); err != nil {
+		return nil, err
+	}
+	templateStateCache[stateKey] = value
+	return value, nil
+}
+
+// setTemplateState stores value for key in namespace, unless the persistent
+// state is read-only.
+func (c *Config) setTemplateState(namespace, key string, value interface{}) error {
+	stateKey, err := c.templateStateKey(namespace, key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if err := c.withOpenPersistentState(func(persistentState *openPersistentState) error {
+		if persistentState.readOnly {
+			return nil
+		}
+		return persistentState.Set(c.stateFuncsBucket, []byte(stateKey), data)
+	}); err != nil {
+		return err
+	}
+	// Cache the value as it will be read back, so that, for example, integers
+	// are always float64s.
+	var cachedValue interface{}
+	if err := json.Unmarshal(data, &cachedValue); err != nil {
+		return err
+	}
+	templateStateCache[stateKey] = cachedValue
+	return nil
+}
+
+// templateStateKey returns the key in the persistent state for key in
+// namespace.
+func (c *Config) templateStateKey(namespace, key string) (string, error) {
+	switch {
+	case !c.Template.State:
+		return "", errors.New("template state is not enabled, set template.state to enable it")
+	case namespace == "" || strings.Contains(namespace, "/"):
+		return "", fmt.Errorf("%q: invalid namespace", namespace)
+	case key == "":
+		return "", errors.New("empty key")
+	}
+	return namespace + "/" + key, nil
+}
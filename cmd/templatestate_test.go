@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestTemplateState(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	defer func() {
+		templateStateCache = make(map[string]interface{})
+	}()
+
+	newTestTemplateStateConfig := func(dryRun bool) *Config {
+		templateStateCache = make(map[string]interface{})
+		c := newTestConfig(fs)
+		c.DryRun = dryRun
+		c.Template.State = true
+		return c
+	}
+
+	// The template state must be enabled.
+	c := newTestConfig(fs)
+	assert.Panics(t, func() {
+		c.stateGetFunc("ports", "web")
+	})
+
+	// Namespaces and keys are validated.
+	c = newTestTemplateStateConfig(false)
+	assert.Panics(t, func() {
+		c.stateGetFunc("", "web")
+	})
+	assert.Panics(t, func() {
+		c.stateGetFunc("ports/web", "")
+	})
+	assert.Panics(t, func() {
+		c.stateSetFunc("ports", "", 8080)
+	})
+
+	// Unset values are nil.
+	assert.Nil(t, c.stateGetFunc("ports", "web"))
+
+	// In dry run mode, values are visible to later templates but not stored.
+	c = newTestTemplateStateConfig(true)
+	assert.Equal(t, "", c.stateSetFunc("ports", "web", 8080))
+	assert.Equal(t, float64(8080), c.stateGetFunc("ports", "web"))
+	c = newTestTemplateStateConfig(false)
+	assert.Nil(t, c.stateGetFunc("ports", "web"))
+
+	// Values are stored and namespaced.
+	c = newTestTemplateStateConfig(false)
+	assert.Equal(t, "", c.stateSetFunc("ports", "web", 8081))
+	assert.Equal(t, "", c.stateSetFunc("hosts", "web", "web.example.com"))
+	c = newTestTemplateStateConfig(false)
+	assert.Equal(t, float64(8081), c.stateGetFunc("ports", "web"))
+	assert.Equal(t, "web.example.com", c.stateGetFunc("hosts", "web"))
+	assert.Nil(t, c.persistentState)
+
+	// An already open persistent state is used.
+	c = newTestTemplateStateConfig(false)
+	persistentState, err := c.getPersistentState(nil)
+	require.NoError(t, err)
+	defer persistentState.Close()
+	assert.Equal(t, "", c.stateSetFunc("ports", "db", 5432))
+	value, err := persistentState.Get(c.stateFuncsBucket, []byte("ports/db"))
+	require.NoError(t, err)
+	assert.Equal(t, "5432", string(value))
+}
+
+func TestTemplateStateApply(t *testing.T) {
+	template := func(defaultPort int) string {
+		return "" +
+			"{{ $port := stateGet \"ports\" \"web\" -}}\n" +
+			"{{ if not $port }}{{ $port = " + strconv.Itoa(defaultPort) + " }}{{ stateSet \"ports\" \"web\" $port }}{{ end -}}\n" +
+			"port={{ $port }}\n"
+	}
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".local/share/chezmoi/dot_port.tmpl": template(8080),
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	defer func() {
+		templateStateCache = make(map[string]interface{})
+	}()
+
+	apply := func() {
+		templateStateCache = make(map[string]interface{})
+		c := newTestConfig(fs)
+		c.Template.State = true
+		c.addTemplateFunc("stateGet", c.stateGetFunc)
+		c.addTemplateFunc("stateSet", c.stateSetFunc)
+		require.NoError(t, c.runApplyCmd(nil, nil))
+		vfst.RunTests(t, fs, "",
+			vfst.TestPath("/home/user/.port",
+				vfst.TestContentsString("port=8080\n"),
+			),
+		)
+	}
+
+	// The first apply stores the port and later applies reuse it.
+	apply()
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_port.tmpl", []byte(template(9090)), 0666))
+	apply()
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"strings"
+)
+
+const defaultTermuxPrefix = "/data/data/com.termux/files/usr"
+
+// getTermuxPrefix returns the Termux prefix directory, or the empty string if
+// chezmoi is not running in Termux. Termux sets $TERMUX_VERSION and sets
+// $PREFIX to its prefix directory.
+func getTermuxPrefix(getenv func(string) string) string {
+	prefix := getenv("PREFIX")
+	if getenv("TERMUX_VERSION") == "" && !strings.Contains(prefix, "/com.termux/") {
+		return ""
+	}
+	if prefix == "" {
+		return defaultTermuxPrefix
+	}
+	return prefix
+}
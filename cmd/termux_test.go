@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTermuxPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "not_termux",
+			env: map[string]string{
+				"PREFIX": "/usr/local",
+			},
+			want: "",
+		},
+		{
+			name: "termux",
+			env: map[string]string{
+				"PREFIX":         "/data/data/com.termux/files/usr",
+				"TERMUX_VERSION": "0.118.0",
+			},
+			want: "/data/data/com.termux/files/usr",
+		},
+		{
+			name: "termux_prefix_only",
+			env: map[string]string{
+				"PREFIX": "/data/data/com.termux/files/usr",
+			},
+			want: "/data/data/com.termux/files/usr",
+		},
+		{
+			name: "termux_version_only",
+			env: map[string]string{
+				"TERMUX_VERSION": "0.118.0",
+			},
+			want: defaultTermuxPrefix,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				return tc.env[key]
+			}
+			assert.Equal(t, tc.want, getTermuxPrefix(getenv))
+		})
+	}
+}
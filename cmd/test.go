@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/diff"
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+	"gopkg.in/yaml.v2"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+const testsDirName = ".chezmoitests"
+
+var testCmd = &cobra.Command{
+	Use:     "test [names...]",
+	Short:   "Test that templates render as expected with fixture data",
+	Long:    mustGetLongHelp("test"),
+	Example: getExample("test"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runTestCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+}
+
+func (c *Config) runTestCmd(cmd *cobra.Command, args []string) error {
+	testsDir := filepath.Join(c.SourceDir, testsDirName)
+	names, err := c.getTestNames(testsDir, args)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, name := range names {
+		ok, err := c.runTest(filepath.Join(testsDir, name))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if ok {
+			fmt.Fprintf(c.Stdout, "ok\t%s\n", name)
+		} else {
+			fmt.Fprintf(c.Stdout, "FAIL\t%s\n", name)
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, len(names))
+	}
+	return nil
+}
+
+// getTestNames returns the sorted names of the tests in testsDir. If args is
+// not empty then only the tests named in args are returned.
+func (c *Config) getTestNames(testsDir string, args []string) ([]string, error) {
+	if len(args) != 0 {
+		for _, arg := range args {
+			if info, err := c.fs.Stat(filepath.Join(testsDir, arg)); err != nil || !info.IsDir() {
+				return nil, fmt.Errorf("%s: test not found", arg)
+			}
+		}
+		return args, nil
+	}
+	infos, err := c.fs.ReadDir(testsDir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, info := range infos {
+		if info.IsDir() {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// runTest renders the target state with the data in testDir and compares it
+// with the expected target state in testDir/expected, printing the
+// differences. It returns true if they match.
+func (c *Config) runTest(testDir string) (bool, error) {
+	data, err := c.getTestData(testDir)
+	if err != nil {
+		return false, err
+	}
+	ts, err := c.getTargetStateWithData(vfs.NewReadOnlyFS(c.fs), c.SourceDir, data, nil)
+	if err != nil {
+		return false, err
+	}
+	entries := make(map[string]chezmoi.Entry)
+	addEntriesByTargetName(entries, ts.Entries)
+
+	ok := true
+	expectedDir := filepath.Join(testDir, "expected")
+	if err := vfs.Walk(c.fs, expectedDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		targetName, err := filepath.Rel(expectedDir, path)
+		if err != nil {
+			return err
+		}
+		expected, err := c.fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		entry, found := entries[targetName]
+		if !found {
+			fmt.Fprintf(c.Stdout, "%s: not in target state\n", targetName)
+			ok = false
+			return nil
+		}
+		var actual []byte
+		switch entry := entry.(type) {
+		case *chezmoi.Dir:
+			fmt.Fprintf(c.Stdout, "%s: is a directory\n", targetName)
+			ok = false
+			return nil
+		case *chezmoi.File:
+			if actual, err = entry.Contents(); err != nil {
+				return err
+			}
+		case *chezmoi.Script:
+			if actual, err = entry.Contents(); err != nil {
+				return err
+			}
+		case *chezmoi.Symlink:
+			linkname, err := entry.Linkname()
+			if err != nil {
+				return err
+			}
+			actual = []byte(linkname)
+			expected = bytes.TrimSpace(expected)
+		}
+		if !bytes.Equal(actual, expected) {
+			ok = false
+			return writeTestDiff(c.Stdout, targetName, expected, actual)
+		}
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// getTestData returns the template data for the test in testDir, read from
+// the first of data.json, data.toml, and data.yaml that exists. Values in the
+// file's chezmoi key override the automatically populated .chezmoi variables.
+func (c *Config) getTestData(testDir string) (map[string]interface{}, error) {
+	defaultData, err := c.getDefaultData()
+	if err != nil {
+		return nil, err
+	}
+	testData := make(map[string]interface{})
+	for _, format := range []struct {
+		filename  string
+		unmarshal func([]byte, map[string]interface{}) error
+	}{
+		{
+			filename: "data.json",
+			unmarshal: func(b []byte, m map[string]interface{}) error {
+				return json.Unmarshal(b, &m)
+			},
+		},
+		{
+			filename: "data.toml",
+			unmarshal: func(b []byte, m map[string]interface{}) error {
+				tree, err := toml.LoadBytes(b)
+				if err != nil {
+					return err
+				}
+				for key, value := range tree.ToMap() {
+					m[key] = value
+				}
+				return nil
+			},
+		},
+		{
+			filename: "data.yaml",
+			unmarshal: func(b []byte, m map[string]interface{}) error {
+				var value map[string]interface{}
+				if err := yaml.Unmarshal(b, &value); err != nil {
+					return err
+				}
+				for key, value := range value {
+					m[key] = normalizeYAMLValue(value)
+				}
+				return nil
+			},
+		},
+	} {
+		b, err := c.fs.ReadFile(filepath.Join(testDir, format.filename))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if err := format.unmarshal(b, testData); err != nil {
+			return nil, fmt.Errorf("%s: %w", format.filename, err)
+		}
+		break
+	}
+
+	if chezmoiData, ok := testData["chezmoi"].(map[string]interface{}); ok {
+		for key, value := range chezmoiData {
+			defaultData[key] = value
+		}
+	}
+	testData["chezmoi"] = defaultData
+	return testData, nil
+}
+
+// addEntriesByTargetName adds all entries in entries, including scripts, to m,
+// indexed by target name.
+func addEntriesByTargetName(m map[string]chezmoi.Entry, entries map[string]chezmoi.Entry) {
+	for _, entry := range entries {
+		m[entry.TargetName()] = entry
+		if dir, ok := entry.(*chezmoi.Dir); ok {
+			addEntriesByTargetName(m, dir.Entries)
+		}
+	}
+}
+
+// normalizeYAMLValue converts the map[interface{}]interface{}s returned by
+// gopkg.in/yaml.v2 to map[string]interface{}s.
+func normalizeYAMLValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			m[fmt.Sprintf("%v", k)] = normalizeYAMLValue(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range value {
+			value[i] = normalizeYAMLValue(v)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// writeTestDiff writes a unified diff from expected to actual for the target
+// with targetName to w.
+func writeTestDiff(w io.Writer, targetName string, expected, actual []byte) error {
+	ab := diff.Strings(splitTestLines(expected), splitTestLines(actual))
+	e := diff.Myers(context.Background(), ab).WithContextSize(3)
+	_, err := e.WriteUnified(w, ab, diff.Names(
+		filepath.Join("expected", targetName),
+		filepath.Join("actual", targetName),
+	))
+	return err
+}
+
+func splitTestLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestTestCmd(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		args       []string
+		wantStdout string
+		wantErr    bool
+	}{
+		{
+			name: "all",
+			wantStdout: strings.Join([]string{
+				"ok\tjson",
+				"--- expected/.gitconfig",
+				"+++ actual/.gitconfig",
+				"@@ -1,2 +1,2 @@",
+				" [user]",
+				"-\temail = wrong@example.com",
+				"+\temail = toml@example.com",
+				"FAIL\ttoml",
+				"ok\tyaml",
+				"",
+			}, "\n"),
+			wantErr: true,
+		},
+		{
+			name: "selected",
+			args: []string{"yaml"},
+			wantStdout: strings.Join([]string{
+				"ok\tyaml",
+				"",
+			}, "\n"),
+		},
+		{
+			name:    "missing",
+			args:    []string{"missing"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_gitconfig.tmpl":     "[user]\n\temail = {{ .email }}\n",
+					"symlink_dot_vimrc.tmpl": "{{ if eq .chezmoi.os \"windows\" }}_vimrc{{ else }}.vim/vimrc{{ end }}\n",
+					".chezmoitests": map[string]interface{}{
+						"json": map[string]interface{}{
+							"data.json":           `{"email":"json@example.com","chezmoi":{"os":"windows"}}`,
+							"expected/.gitconfig": "[user]\n\temail = json@example.com\n",
+							"expected/.vimrc":     "_vimrc\n",
+						},
+						"toml": map[string]interface{}{
+							"data.toml":           "email = \"toml@example.com\"\n",
+							"expected/.gitconfig": "[user]\n\temail = wrong@example.com\n",
+						},
+						"yaml": map[string]interface{}{
+							"data.yaml":           "email: yaml@example.com\nchezmoi:\n  os: linux\n",
+							"expected/.gitconfig": "[user]\n\temail = yaml@example.com\n",
+							"expected/.vimrc":     ".vim/vimrc\n",
+						},
+					},
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			err = c.runTestCmd(nil, tc.args)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantStdout, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// The test vectors are from RFC 6238 appendix B, truncated to six digits.
+	// The secret is the base32 encoding of "12345678901234567890".
+	for _, tc := range []struct {
+		name     string
+		secret   string
+		time     int64
+		expected string
+	}{
+		{
+			name:     "59",
+			secret:   "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+			time:     59,
+			expected: "287082",
+		},
+		{
+			name:     "1111111109",
+			secret:   "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+			time:     1111111109,
+			expected: "081804",
+		},
+		{
+			name:     "1234567890",
+			secret:   "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+			time:     1234567890,
+			expected: "005924",
+		},
+		{
+			name:     "2000000000",
+			secret:   "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+			time:     2000000000,
+			expected: "279037",
+		},
+		{
+			name:     "grouped_lower_case_padded",
+			secret:   "gezd gnbv gy3t qojq gezd gnbv gy3t qojq====",
+			time:     2000000000,
+			expected: "279037",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := totpCode(tc.secret, time.Unix(tc.time, 0))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+
+	for _, secret := range []string{"", "not base32!"} {
+		_, err := totpCode(secret, time.Now())
+		assert.Error(t, err)
+	}
+}
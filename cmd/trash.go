@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// isTrashPath returns true if path should be moved to the trash instead of
+// being removed. Only targets in the destination directory are moved to the
+// trash, not files in the source directory.
+func (c *Config) isTrashPath(path string) bool {
+	return isInDir(path, c.DestDir) && !isInDir(path, c.SourceDir)
+}
+
+// newTrashFunc returns a function that moves a file to the trash of the
+// current OS.
+func (c *Config) newTrashFunc() func(string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return chezmoi.NewDirTrash(c.fs, filepath.Join(c.homeDir, ".Trash")).Trash
+	case "windows":
+		return recycle
+	default:
+		return chezmoi.NewXDGTrash(c.fs, filepath.Join(c.bds.DataHome, "Trash")).Trash
+	}
+}
+
+// isInDir returns true if path is dir or is in dir.
+func isInDir(path, dir string) bool {
+	dir = filepath.Clean(dir)
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// recycle moves name to the Windows recycle bin.
+func recycle(name string) error {
+	info, err := os.Lstat(name)
+	if err != nil {
+		return err
+	}
+	method := "DeleteFile"
+	if info.IsDir() {
+		method = "DeleteDirectory"
+	}
+	script := fmt.Sprintf(""+
+		"Add-Type -AssemblyName Microsoft.VisualBasic; "+
+		"[Microsoft.VisualBasic.FileIO.FileSystem]::%s('%s', 'OnlyErrorDialogs', 'SendToRecycleBin')",
+		method, strings.ReplaceAll(name, "'", "''"),
+	)
+	//nolint:gosec
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: cannot move to recycle bin: %w\n%s", name, err, output)
+	}
+	return nil
+}
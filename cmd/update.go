@@ -8,7 +8,9 @@ import (
 )
 
 type updateCmdConfig struct {
-	apply bool
+	apply  bool
+	force  bool
+	report string
 }
 
 var updateCmd = &cobra.Command{
@@ -26,6 +28,9 @@ func init() {
 
 	persistentFlags := updateCmd.PersistentFlags()
 	persistentFlags.BoolVarP(&config.update.apply, "apply", "a", true, "apply after pulling")
+	persistentFlags.BoolVarP(&config.update.force, "force", "f", false, "overwrite modified files without prompting")
+	persistentFlags.StringVar(&config.update.report, "report", "", "write a JSON report of applying to file")
+	panicOnError(updateCmd.MarkPersistentFlagFilename("report"))
 }
 
 func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
@@ -60,7 +65,7 @@ func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		defer persistentState.Close()
-		if err := c.applyArgs(nil, persistentState); err != nil {
+		if err := c.applyArgsAndReport(nil, persistentState, c.update.report, &c.update.force); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestUpdateCmd(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, newTestConfig(fs).runInitCmd(nil, []string{filepath.Join(wd, "testdata/gitrepo")}))
+
+	c := newTestConfig(fs)
+	c.update.apply = false
+	require.NoError(t, c.runUpdateCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	c = newTestConfig(fs)
+	c.update.apply = true
+	require.NoError(t, c.runUpdateCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString(lines("# contents of .bashrc\n")),
+		),
+	)
+}
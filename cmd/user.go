@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// useUser sets the destination directory to the home directory of username
+// and, if chezmoi is running as root, makes username the owner of everything
+// that chezmoi creates there.
+func (c *Config) useUser(username string) error {
+	u, err := user.Lookup(username)
+	if err != nil {
+		return err
+	}
+	c.DestDir = u.HomeDir
+	if os.Geteuid() != 0 {
+		return nil
+	}
+	uid, gid, err := getUserIDs(u)
+	if err != nil {
+		return err
+	}
+	c.mutator = chezmoi.NewChownMutator(c.mutator, c.fs, c.DestDir, uid, gid)
+	return nil
+}
+
+// checkDestDirOwner returns an error if euid is root and destDir in fs is
+// owned by another user. This usually means that chezmoi was run with sudo and
+// $HOME still points to the invoking user's home directory, so applying would
+// leave files owned by root in their home directory.
+func checkDestDirOwner(fs vfs.FS, destDir string, euid int, getenv func(string) string) error {
+	if euid != 0 {
+		return nil
+	}
+	info, err := fs.Stat(destDir)
+	if err != nil {
+		return nil
+	}
+	uid, ok := getOwnerUID(info)
+	if !ok || uid == 0 {
+		return nil
+	}
+	userFlag := "--user"
+	if sudoUser := getenv("SUDO_USER"); sudoUser != "" {
+		userFlag += " " + sudoUser
+	}
+	return fmt.Errorf("%s: owned by uid %d but running as root, use %s to apply as its owner or --force to apply anyway", destDir, uid, userFlag)
+}
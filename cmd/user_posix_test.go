@@ -0,0 +1,76 @@
+// +build !windows
+
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestCheckDestDirOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the owner of files requires root")
+	}
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+		"/root":      &vfst.Dir{Perm: 0700},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	require.NoError(t, fs.Lchown("/home/user", 1000, 1000))
+
+	for _, tc := range []struct {
+		name    string
+		destDir string
+		euid    int
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "user",
+			destDir: "/home/user",
+			euid:    1000,
+		},
+		{
+			name:    "root_own_home",
+			destDir: "/root",
+			euid:    0,
+		},
+		{
+			name:    "root_nonexistent",
+			destDir: "/home/other",
+			euid:    0,
+		},
+		{
+			name:    "root_other_home",
+			destDir: "/home/user",
+			euid:    0,
+			wantErr: "/home/user: owned by uid 1000 but running as root, use --user to apply as its owner or --force to apply anyway",
+		},
+		{
+			name:    "sudo_other_home",
+			destDir: "/home/user",
+			euid:    0,
+			env: map[string]string{
+				"SUDO_USER": "user",
+			},
+			wantErr: "/home/user: owned by uid 1000 but running as root, use --user user to apply as its owner or --force to apply anyway",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				return tc.env[key]
+			}
+			err := checkDestDirOwner(fs, tc.destDir, tc.euid, getenv)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
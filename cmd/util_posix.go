@@ -4,6 +4,10 @@ package cmd
 
 import (
 	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
 	"syscall"
 )
 
@@ -12,6 +16,46 @@ func enableVirtualTerminalProcessingOnWindows(w io.Writer) error {
 	return nil
 }
 
+// getOwnerUID returns the uid of the owner of the file with info.
+func getOwnerUID(info os.FileInfo) (int, bool) {
+	statT, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(statT.Uid), true
+}
+
+// getRootDir returns the root directory. In Termux, this is the Termux prefix
+// directory, as the Android root directory is read-only.
+func getRootDir(homeDir string) string {
+	if termuxPrefix := getTermuxPrefix(os.Getenv); termuxPrefix != "" {
+		return termuxPrefix
+	}
+	return "/"
+}
+
+// getSystemConfigDir returns the directory containing the system config file.
+// In Termux, this is relative to the Termux prefix directory.
+func getSystemConfigDir() string {
+	if termuxPrefix := getTermuxPrefix(os.Getenv); termuxPrefix != "" {
+		return filepath.Join(termuxPrefix, "etc", "chezmoi")
+	}
+	return "/etc/chezmoi"
+}
+
+// getUserIDs returns the uid and gid of u.
+func getUserIDs(u *user.User) (int, int, error) {
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return 0, 0, err
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uid, gid, nil
+}
+
 func getUmask() int {
 	umask := syscall.Umask(0)
 	syscall.Umask(umask)
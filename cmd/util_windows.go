@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"io"
 	"os"
+	"os/user"
+	"path/filepath"
 	"strings"
 
 	"golang.org/x/sys/windows"
@@ -23,6 +26,41 @@ func enableVirtualTerminalProcessingOnWindows(w io.Writer) error {
 	return windows.SetConsoleMode(windows.Handle(f.Fd()), dwMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
 }
 
+// getOwnerUID returns false, as Windows files do not have owner uids.
+func getOwnerUID(info os.FileInfo) (int, bool) {
+	return 0, false
+}
+
+// getRootDir returns the root of the volume containing homeDir. If homeDir is
+// on a network share, for example with a roaming profile, then it returns the
+// root of the system drive instead.
+func getRootDir(homeDir string) string {
+	volumeName := filepath.VolumeName(homeDir)
+	if strings.HasPrefix(volumeName, `\\`) {
+		volumeName = os.Getenv("SystemDrive")
+		if volumeName == "" {
+			volumeName = "C:"
+		}
+	}
+	return volumeName + `\`
+}
+
+// getSystemConfigDir returns the directory containing the system config file,
+// which is in %ProgramData%.
+func getSystemConfigDir() string {
+	programData := os.Getenv("ProgramData")
+	if programData == "" {
+		programData = `C:\ProgramData`
+	}
+	return filepath.Join(programData, "chezmoi")
+}
+
+// getUserIDs returns an error, as changing the owner of files is not
+// supported on Windows.
+func getUserIDs(u *user.User) (int, int, error) {
+	return 0, 0, errors.New("changing the owner of files is not supported on Windows")
+}
+
 func getUmask() int {
 	return 0
 }
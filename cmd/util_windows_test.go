@@ -2,7 +2,41 @@
 
 package cmd
 
-import "strings"
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRootDir(t *testing.T) {
+	systemDrive := os.Getenv("SystemDrive")
+	if systemDrive == "" {
+		systemDrive = "C:"
+	}
+	for _, tc := range []struct {
+		homeDir string
+		want    string
+	}{
+		{
+			homeDir: `C:\Users\user`,
+			want:    `C:\`,
+		},
+		{
+			homeDir: `D:\Users\user`,
+			want:    `D:\`,
+		},
+		{
+			homeDir: `\\server\share\user`,
+			want:    systemDrive + `\`,
+		},
+	} {
+		t.Run(tc.homeDir, func(t *testing.T) {
+			assert.Equal(t, tc.want, getRootDir(tc.homeDir))
+		})
+	}
+}
 
 func lines(s string) string {
 	return strings.Replace(s, "\n", "\r\n", -1)
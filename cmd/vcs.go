@@ -8,6 +8,7 @@ type VCS interface {
 	CloneArgs(string, string) []string
 	CommitArgs(string) []string
 	InitArgs() []string
+	MoveArgs(string, string) []string
 	ParseStatusOutput([]byte) (interface{}, error)
 	PullArgs() []string
 	PushArgs() []string
@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 	bolt "go.etcd.io/bbolt"
@@ -18,16 +23,20 @@ var verifyCmd = &cobra.Command{
 	RunE:    config.runVerifyCmd,
 }
 
+type verifyCmdConfig struct {
+	fix bool
+}
+
 func init() {
 	rootCmd.AddCommand(verifyCmd)
 
+	persistentFlags := verifyCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.verify.fix, "fix", false, "fix permissions and symlink targets")
+
 	markRemainingZshCompPositionalArgumentsAsFiles(verifyCmd, 1)
 }
 
 func (c *Config) runVerifyCmd(cmd *cobra.Command, args []string) error {
-	mutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
-	c.mutator = mutator
-
 	persistentState, err := c.getPersistentState(&bolt.Options{
 		ReadOnly: true,
 	})
@@ -36,11 +45,216 @@ func (c *Config) runVerifyCmd(cmd *cobra.Command, args []string) error {
 	}
 	defer persistentState.Close()
 
-	if err := c.applyArgs(args, persistentState); err != nil {
+	failed, err := c.verifyArgs(args, persistentState)
+	if err != nil {
 		return err
 	}
-	if mutator.Mutated() {
+	if failed {
 		os.Exit(1)
 	}
 	return nil
 }
+
+// verifyArgs verifies args, or all targets if args is empty, and prints the
+// problems found by category. It returns true if verification failed.
+func (c *Config) verifyArgs(args []string, persistentState chezmoi.PersistentState) (bool, error) {
+	c.DryRun = true // Prevent scripts from running.
+
+	var failed bool
+
+	// Externals are checked before targets are applied, as applying them
+	// would replace cached contents that do not match their checksums.
+	if len(args) == 0 {
+		ts, err := c.getTargetState(nil)
+		if err != nil {
+			return false, err
+		}
+		externalProblems, err := c.getExternalProblems(ts)
+		if err != nil {
+			return false, err
+		}
+		names := make([]string, 0, len(externalProblems))
+		for name := range externalProblems {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(c.Stdout, "externals: %s: %s\n", name, externalProblems[name])
+			failed = true
+		}
+	}
+
+	if c.verify.fix {
+		unfixed, err := c.verifyFix(args, persistentState)
+		if err != nil {
+			return false, err
+		}
+		failed = failed || unfixed
+	} else {
+		mutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
+		c.mutator = mutator
+		c.privilegedMutator = mutator
+		if err := c.applyArgs(args, persistentState); err != nil {
+			return false, err
+		}
+		if mutator.Mutated() {
+			fmt.Fprintln(c.Stdout, "targets: destination state does not match target state")
+			failed = true
+		}
+	}
+
+	// Orphaned script state can only be detected when verifying all targets.
+	if len(args) == 0 {
+		ts, err := c.getTargetState(nil)
+		if err != nil {
+			return false, err
+		}
+		orphanedScriptNames, err := c.getOrphanedScriptNames(ts, persistentState)
+		if err != nil {
+			return false, err
+		}
+		for _, name := range orphanedScriptNames {
+			fmt.Fprintf(c.Stdout, "scripts: %s: run once state, but no script\n", name)
+			failed = true
+		}
+	}
+
+	return failed, nil
+}
+
+// getExternalProblems returns the problems with the externals in ts, indexed
+// by target name. Files and archives are checked using the on-disk cache, and
+// are not downloaded, against their checksums, which include the checksums
+// recorded in the external lock. Git repositories that are locked to a commit
+// are checked using their clone in the destination directory.
+func (c *Config) getExternalProblems(ts *chezmoi.TargetState) (map[string]string, error) {
+	externalProblems := make(map[string]string)
+	for _, entry := range ts.AllEntries() {
+		external, ok := entry.(*chezmoi.External)
+		if !ok || ts.TargetIgnore.Match(external.TargetName()) {
+			continue
+		}
+		if external.Type == chezmoi.ExternalTypeGitRepo {
+			if external.Commit == "" {
+				continue
+			}
+			targetPath := chezmoi.TargetPath(ts.DestDir, external.TargetName())
+			if _, err := c.fs.Stat(filepath.Join(targetPath, ".git")); os.IsNotExist(err) {
+				continue
+			}
+			//nolint:gosec
+			output, err := exec.Command("git", "-C", targetPath, "rev-parse", "HEAD").Output()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", targetPath, err)
+			}
+			if head := strings.TrimSpace(string(output)); head != external.Commit {
+				externalProblems[external.TargetName()] = fmt.Sprintf("commit %s does not match %s", head, external.Commit)
+			}
+			continue
+		}
+		if external.URL == "" {
+			continue
+		}
+		data, err := c.fs.ReadFile(c.externalCacheFilename(external.URL))
+		switch {
+		case os.IsNotExist(err):
+			continue
+		case err != nil:
+			return nil, err
+		}
+		if err := external.Verify(data); err != nil {
+			externalProblems[external.TargetName()] = "cached contents do not match checksum"
+		}
+	}
+	return externalProblems, nil
+}
+
+// verifyFix fixes the permissions and symlink targets of args and prints the
+// targets that were fixed and the targets that could not be fixed. It returns
+// true if any targets could not be fixed.
+func (c *Config) verifyFix(args []string, persistentState chezmoi.PersistentState) (bool, error) {
+	mutator := chezmoi.NewFixMutator(c.mutator, c.fs)
+	c.mutator = mutator
+	fixMutators := []*chezmoi.FixMutator{mutator}
+	if c.privilegedMutator != nil {
+		privilegedMutator := chezmoi.NewFixMutator(c.privilegedMutator, c.fs)
+		c.privilegedMutator = privilegedMutator
+		fixMutators = append(fixMutators, privilegedMutator)
+	}
+	if err := c.applyArgs(args, persistentState); err != nil {
+		return false, err
+	}
+	fixed := make(map[string]struct{})
+	unfixed := make(map[string]struct{})
+	for _, m := range fixMutators {
+		for name := range m.Fixed() {
+			fixed[name] = struct{}{}
+		}
+		for name := range m.Unfixed() {
+			unfixed[name] = struct{}{}
+		}
+	}
+	for _, name := range sortedKeys(fixed) {
+		fmt.Fprintf(c.Stdout, "targets: %s: fixed\n", name)
+	}
+	for _, name := range sortedKeys(unfixed) {
+		fmt.Fprintf(c.Stdout, "targets: %s: does not match target state\n", name)
+	}
+	return len(unfixed) != 0, nil
+}
+
+// getOrphanedScriptNames returns the sorted names of scripts that have a run
+// once state in persistentState but are no longer in ts.
+func (c *Config) getOrphanedScriptNames(ts *chezmoi.TargetState, persistentState chezmoi.PersistentState) ([]string, error) {
+	orphanedScriptStateKeys, err := c.getOrphanedScriptStateKeys(ts, persistentState)
+	if err != nil {
+		return nil, err
+	}
+	orphanedScriptNames := make(map[string]struct{}, len(orphanedScriptStateKeys))
+	for name := range orphanedScriptStateKeys {
+		orphanedScriptNames[name] = struct{}{}
+	}
+	return sortedKeys(orphanedScriptNames), nil
+}
+
+// getOrphanedScriptStateKeys returns the keys of all run once states in
+// persistentState, indexed by script name, for scripts that are no longer in
+// ts.
+func (c *Config) getOrphanedScriptStateKeys(ts *chezmoi.TargetState, persistentState chezmoi.PersistentState) (map[string][][]byte, error) {
+	scriptNames := make(map[string]struct{})
+	addOnceScriptNames(scriptNames, ts.Entries)
+	orphanedScriptStateKeys := make(map[string][][]byte)
+	if err := persistentState.ForEach(c.scriptStateBucket, func(k, v []byte) error {
+		// Keys are the script's name, followed by a colon and the SHA256 of
+		// its contents for run once scripts.
+		name := string(k)
+		if index := strings.LastIndexByte(name, ':'); index != -1 {
+			name = name[:index]
+		}
+		if _, ok := scriptNames[name]; !ok {
+			key := make([]byte, len(k))
+			copy(key, k)
+			orphanedScriptStateKeys[name] = append(orphanedScriptStateKeys[name], key)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return orphanedScriptStateKeys, nil
+}
+
+// addOnceScriptNames adds the names of all run once and run on change scripts
+// in entries to scriptNames. ts.AllEntries cannot be used as it does not
+// include scripts.
+func addOnceScriptNames(scriptNames map[string]struct{}, entries map[string]chezmoi.Entry) {
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case *chezmoi.Dir:
+			addOnceScriptNames(scriptNames, entry.Entries)
+		case *chezmoi.Script:
+			if entry.Once || entry.OnChange {
+				scriptNames[entry.TargetName()] = struct{}{}
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestGetOrphanedScriptNames(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"run_once_bar.sh": "#!/bin/sh\n",
+			"run_foo.sh":      "#!/bin/sh\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	c := newTestConfig(fs)
+	persistentState, err := c.getPersistentState(nil)
+	require.NoError(t, err)
+	defer persistentState.Close()
+	for _, key := range []string{
+		"bar.sh:0123456789abcdef",
+		"bar.sh:fedcba9876543210",
+		"baz.sh:0123456789abcdef",
+		"foo.sh:0123456789abcdef",
+	} {
+		require.NoError(t, persistentState.Set(c.scriptStateBucket, []byte(key), []byte("{}")))
+	}
+	ts, err := c.getTargetState(nil)
+	require.NoError(t, err)
+	orphanedScriptNames, err := c.getOrphanedScriptNames(ts, persistentState)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"baz.sh", "foo.sh"}, orphanedScriptNames)
+}
+
+func TestVerifyFix(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# local edit\n",
+			".ssh": &vfst.Dir{
+				Perm: 0755,
+				Entries: map[string]interface{}{
+					"id_rsa": &vfst.File{
+						Perm:     0644,
+						Contents: []byte("key\n"),
+					},
+				},
+			},
+			".link":  &vfst.Symlink{Target: "old"},
+			".other": "not a symlink\n",
+			".local/share/chezmoi": map[string]interface{}{
+				"dot_bashrc":        "# bashrc\n",
+				"private_dot_ssh":   &vfst.Dir{Perm: 0700, Entries: map[string]interface{}{"private_id_rsa": "key\n"}},
+				"symlink_dot_link":  "new",
+				"symlink_dot_other": "new",
+				"run_script.sh":     "#!/bin/sh\nexit 1\n",
+				"dot_missing":       "missing\n",
+				"dot_ignore":        "ignore\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withMutator(chezmoi.NewFSMutator(fs)),
+		withStdout(stdout),
+	)
+	c.DryRun = true // Prevent scripts from running.
+	c.Verbose = false
+	persistentState, err := c.getPersistentState(nil)
+	require.NoError(t, err)
+	defer persistentState.Close()
+
+	failed, err := c.verifyFix(nil, persistentState)
+	require.NoError(t, err)
+	assert.True(t, failed)
+	assert.Equal(t, strings.Join([]string{
+		"targets: /home/user/.link: fixed",
+		"targets: /home/user/.ssh: fixed",
+		"targets: /home/user/.ssh/id_rsa: fixed",
+		"targets: /home/user/.bashrc: does not match target state",
+		"targets: /home/user/.ignore: does not match target state",
+		"targets: /home/user/.missing: does not match target state",
+		"targets: /home/user/.other: does not match target state",
+		"",
+	}, "\n"), stdout.String())
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# local edit\n"),
+		),
+		vfst.TestPath("/home/user/.ssh",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0700),
+		),
+		vfst.TestPath("/home/user/.ssh/id_rsa",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0600),
+			vfst.TestContentsString("key\n"),
+		),
+		vfst.TestPath("/home/user/.link",
+			vfst.TestModeType(os.ModeSymlink),
+			vfst.TestSymlinkTarget("new"),
+		),
+		vfst.TestPath("/home/user/.other",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("not a symlink\n"),
+		),
+		vfst.TestPath("/home/user/.missing",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
+
+func TestVerifyExternals(t *testing.T) {
+	contents := []byte("# contents\n")
+	contentsSHA256 := sha256.Sum256(contents)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(contents)
+	}))
+	defer server.Close()
+	url := server.URL + "/file"
+
+	for _, tc := range []struct {
+		name           string
+		cachedContents string
+		expectedFailed bool
+		expectedStdout string
+	}{
+		{
+			name:           "ok",
+			cachedContents: string(contents),
+		},
+		{
+			name:           "tampered",
+			cachedContents: "# tampered\n",
+			expectedFailed: true,
+			expectedStdout: "externals: .file: cached contents do not match checksum\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".file": string(contents),
+					".local/share/chezmoi/.chezmoiexternal.toml": "" +
+						"[\".file\"]\n" +
+						"    type = \"file\"\n" +
+						"    url = \"" + url + "\"\n" +
+						"    checksum = \"" + hex.EncodeToString(contentsSHA256[:]) + "\"\n",
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			cacheFilename := c.externalCacheFilename(url)
+			require.NoError(t, vfs.MkdirAll(fs, filepath.Dir(cacheFilename), 0700))
+			require.NoError(t, fs.WriteFile(cacheFilename, []byte(tc.cachedContents), 0600))
+			persistentState, err := c.getPersistentState(nil)
+			require.NoError(t, err)
+			defer persistentState.Close()
+
+			failed, err := c.verifyArgs(nil, persistentState)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFailed, failed)
+			assert.Equal(t, tc.expectedStdout, stdout.String())
+		})
+	}
+}
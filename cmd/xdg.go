@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// xdgDirs maps XDG base directory names to their path prefix names and
+// environment variables.
+var xdgDirs = []struct {
+	name       string
+	pathPrefix string
+	envVar     string
+}{
+	{name: "cacheHome", pathPrefix: "{xdg-cache}", envVar: "XDG_CACHE_HOME"},
+	{name: "configHome", pathPrefix: "{xdg-config}", envVar: "XDG_CONFIG_HOME"},
+	{name: "dataHome", pathPrefix: "{xdg-data}", envVar: "XDG_DATA_HOME"},
+	{name: "runtimeDir", pathPrefix: "{xdg-runtime}", envVar: "XDG_RUNTIME_DIR"},
+	{name: "stateHome", pathPrefix: "{xdg-state}", envVar: "XDG_STATE_HOME"},
+}
+
+// getXDGDirs returns the user's XDG base directories. If the corresponding
+// environment variable is not set then the platform's conventional directory
+// is used, so, for example, configHome is ~/.config on Linux, ~/Library/Application
+// Support on macOS, and %APPDATA% on Windows.
+func (c *Config) getXDGDirs() map[string]string {
+	homeDir := c.homeDir
+	dirs := map[string]string{
+		"cacheHome":  c.bds.CacheHome,
+		"configHome": c.bds.ConfigHome,
+		"dataHome":   c.bds.DataHome,
+		"runtimeDir": c.bds.RuntimeDir,
+		"stateHome":  filepath.Join(homeDir, ".local", "state"),
+	}
+	var defaults map[string]string
+	switch runtime.GOOS {
+	case "darwin":
+		defaults = map[string]string{
+			"cacheHome":  filepath.Join(homeDir, "Library", "Caches"),
+			"configHome": filepath.Join(homeDir, "Library", "Application Support"),
+			"dataHome":   filepath.Join(homeDir, "Library", "Application Support"),
+			"stateHome":  filepath.Join(homeDir, "Library", "Application Support"),
+		}
+	case "windows":
+		localAppData := os.Getenv("LOCALAPPDATA")
+		defaults = map[string]string{
+			"cacheHome":  localAppData,
+			"configHome": os.Getenv("APPDATA"),
+			"dataHome":   localAppData,
+			"stateHome":  localAppData,
+		}
+	}
+	for _, xdgDir := range xdgDirs {
+		if value := os.Getenv(xdgDir.envVar); value != "" {
+			dirs[xdgDir.name] = value
+		} else if value, ok := defaults[xdgDir.name]; ok {
+			dirs[xdgDir.name] = value
+		}
+	}
+	return dirs
+}
+
+// getPathPrefixes returns the path prefixes for the root directory and the XDG
+// base directories. Directories in the user's home directory are relative to
+// it.
+func (c *Config) getPathPrefixes() map[string]string {
+	dirs := c.getXDGDirs()
+	pathPrefixes := map[string]string{
+		"{root}": getRootDir(c.homeDir),
+	}
+	for _, xdgDir := range xdgDirs {
+		dir := dirs[xdgDir.name]
+		if dir == "" {
+			pathPrefixes[xdgDir.pathPrefix] = ""
+			continue
+		}
+		if relDir, err := filepath.Rel(c.homeDir, dir); err == nil && relDir != ".." && !strings.HasPrefix(relDir, ".."+string(filepath.Separator)) {
+			dir = relDir
+		}
+		pathPrefixes[xdgDir.pathPrefix] = dir
+	}
+	return pathPrefixes
+}
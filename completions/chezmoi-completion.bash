@@ -338,6 +338,8 @@ _chezmoi_add()
 
     flags+=("--autotemplate")
     flags+=("-a")
+    flags+=("--blob")
+    flags+=("--compress")
     flags+=("--empty")
     flags+=("-e")
     flags+=("--encrypt")
@@ -349,6 +351,7 @@ _chezmoi_add()
     flags+=("-p")
     flags+=("--recursive")
     flags+=("-r")
+    flags+=("--rename")
     flags+=("--template")
     flags+=("-T")
     flags+=("--color=")
@@ -356,6 +359,8 @@ _chezmoi_add()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -367,6 +372,54 @@ _chezmoi_add()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_affected()
+{
+    last_command="chezmoi_affected"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--template=")
+    two_word_flags+=("--template")
+    flags_with_completion+=("--template")
+    flags_completion+=("_filedir")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -389,11 +442,21 @@ _chezmoi_apply()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--force")
+    flags+=("-f")
+    flags+=("--interactive")
+    flags+=("-i")
+    flags+=("--report=")
+    two_word_flags+=("--report")
+    flags_with_completion+=("--report")
+    flags_completion+=("_filedir")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -405,6 +468,8 @@ _chezmoi_apply()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -432,6 +497,145 @@ _chezmoi_archive()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_audit_externals()
+{
+    last_command="chezmoi_audit_externals"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--max-size=")
+    two_word_flags+=("--max-size")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_audit_secrets()
+{
+    last_command="chezmoi_audit_secrets"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--rendered")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_audit()
+{
+    last_command="chezmoi_audit"
+
+    command_aliases=()
+
+    commands=()
+    commands+=("externals")
+    commands+=("secrets")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -443,6 +647,8 @@ _chezmoi_archive()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -470,6 +676,8 @@ _chezmoi_cat()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -481,6 +689,8 @@ _chezmoi_cat()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -508,6 +718,8 @@ _chezmoi_cd()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -519,6 +731,8 @@ _chezmoi_cd()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -541,11 +755,15 @@ _chezmoi_chattr()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--recursive")
+    flags+=("-r")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -557,6 +775,8 @@ _chezmoi_chattr()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -594,6 +814,8 @@ _chezmoi_completion()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -605,6 +827,8 @@ _chezmoi_completion()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -616,9 +840,9 @@ _chezmoi_completion()
     noun_aliases=()
 }
 
-_chezmoi_data()
+_chezmoi_config_validate()
 {
-    last_command="chezmoi_data"
+    last_command="chezmoi_config_validate"
 
     command_aliases=()
 
@@ -630,14 +854,13 @@ _chezmoi_data()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--format=")
-    two_word_flags+=("--format")
-    two_word_flags+=("-f")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -649,6 +872,8 @@ _chezmoi_data()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -657,13 +882,14 @@ _chezmoi_data()
     noun_aliases=()
 }
 
-_chezmoi_diff()
+_chezmoi_config()
 {
-    last_command="chezmoi_diff"
+    last_command="chezmoi_config"
 
     command_aliases=()
 
     commands=()
+    commands+=("validate")
 
     flags=()
     two_word_flags=()
@@ -671,15 +897,13 @@ _chezmoi_diff()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--format=")
-    two_word_flags+=("--format")
-    two_word_flags+=("-f")
-    flags+=("--no-pager")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -691,6 +915,686 @@ _chezmoi_diff()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_cp()
+{
+    last_command="chezmoi_cp"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--template")
+    flags+=("-T")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_data()
+{
+    last_command="chezmoi_data"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_diff()
+{
+    last_command="chezmoi_diff"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--from-ref=")
+    two_word_flags+=("--from-ref")
+    flags+=("--no-pager")
+    flags+=("--to-ref=")
+    two_word_flags+=("--to-ref")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_docs()
+{
+    last_command="chezmoi_docs"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_doctor()
+{
+    last_command="chezmoi_doctor"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_dump()
+{
+    last_command="chezmoi_dump"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--recursive")
+    flags+=("-r")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_edit()
+{
+    last_command="chezmoi_edit"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--apply")
+    flags+=("-a")
+    flags+=("--diff")
+    flags+=("-d")
+    flags+=("--prompt")
+    flags+=("-p")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_edit-config()
+{
+    last_command="chezmoi_edit-config"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_execute-template()
+{
+    last_command="chezmoi_execute-template"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--init")
+    flags+=("-i")
+    flags+=("--promptBool=")
+    two_word_flags+=("--promptBool")
+    flags+=("--promptInt=")
+    two_word_flags+=("--promptInt")
+    flags+=("--promptString=")
+    two_word_flags+=("--promptString")
+    two_word_flags+=("-p")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_explain()
+{
+    last_command="chezmoi_explain"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_externals_update()
+{
+    last_command="chezmoi_externals_update"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_externals()
+{
+    last_command="chezmoi_externals"
+
+    command_aliases=()
+
+    commands=()
+    commands+=("update")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_fleet_apply()
+{
+    last_command="chezmoi_fleet_apply"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--hosts=")
+    two_word_flags+=("--hosts")
+    flags_with_completion+=("--hosts")
+    flags_completion+=("__chezmoi_handle_filename_extension_flag yaml|yml")
+    flags+=("--log-dir=")
+    two_word_flags+=("--log-dir")
+    flags+=("--parallel=")
+    two_word_flags+=("--parallel")
+    flags+=("--report=")
+    two_word_flags+=("--report")
+    flags_with_completion+=("--report")
+    flags_completion+=("_filedir")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_flag+=("--hosts=")
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_fleet()
+{
+    last_command="chezmoi_fleet"
+
+    command_aliases=()
+
+    commands=()
+    commands+=("apply")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_forget()
+{
+    last_command="chezmoi_forget"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -699,9 +1603,9 @@ _chezmoi_diff()
     noun_aliases=()
 }
 
-_chezmoi_docs()
+_chezmoi_gc()
 {
-    last_command="chezmoi_docs"
+    last_command="chezmoi_gc"
 
     command_aliases=()
 
@@ -718,6 +1622,8 @@ _chezmoi_docs()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -729,6 +1635,8 @@ _chezmoi_docs()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -737,9 +1645,9 @@ _chezmoi_docs()
     noun_aliases=()
 }
 
-_chezmoi_doctor()
+_chezmoi_git()
 {
-    last_command="chezmoi_doctor"
+    last_command="chezmoi_git"
 
     command_aliases=()
 
@@ -756,6 +1664,8 @@ _chezmoi_doctor()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -767,6 +1677,8 @@ _chezmoi_doctor()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -775,9 +1687,9 @@ _chezmoi_doctor()
     noun_aliases=()
 }
 
-_chezmoi_dump()
+_chezmoi_hg()
 {
-    last_command="chezmoi_dump"
+    last_command="chezmoi_hg"
 
     command_aliases=()
 
@@ -789,16 +1701,13 @@ _chezmoi_dump()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--format=")
-    two_word_flags+=("--format")
-    two_word_flags+=("-f")
-    flags+=("--recursive")
-    flags+=("-r")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -810,6 +1719,8 @@ _chezmoi_dump()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -818,9 +1729,9 @@ _chezmoi_dump()
     noun_aliases=()
 }
 
-_chezmoi_edit()
+_chezmoi_import()
 {
-    last_command="chezmoi_edit"
+    last_command="chezmoi_import"
 
     command_aliases=()
 
@@ -832,17 +1743,20 @@ _chezmoi_edit()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--apply")
-    flags+=("-a")
-    flags+=("--diff")
-    flags+=("-d")
-    flags+=("--prompt")
-    flags+=("-p")
+    flags+=("--exact")
+    flags+=("-x")
+    flags+=("--remove-destination")
+    flags+=("-r")
+    flags+=("--rename")
+    flags+=("--strip-components=")
+    two_word_flags+=("--strip-components")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -854,6 +1768,8 @@ _chezmoi_edit()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -862,9 +1778,9 @@ _chezmoi_edit()
     noun_aliases=()
 }
 
-_chezmoi_edit-config()
+_chezmoi_init()
 {
-    last_command="chezmoi_edit-config"
+    last_command="chezmoi_init"
 
     command_aliases=()
 
@@ -876,11 +1792,20 @@ _chezmoi_edit-config()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--apply")
+    flags+=("--force")
+    flags+=("-f")
+    flags+=("--one-shot")
+    flags+=("--template=")
+    two_word_flags+=("--template")
+    flags+=("--wizard")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -892,6 +1817,8 @@ _chezmoi_edit-config()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -900,9 +1827,9 @@ _chezmoi_edit-config()
     noun_aliases=()
 }
 
-_chezmoi_execute-template()
+_chezmoi_managed()
 {
-    last_command="chezmoi_execute-template"
+    last_command="chezmoi_managed"
 
     command_aliases=()
 
@@ -914,16 +1841,20 @@ _chezmoi_execute-template()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--init")
-    flags+=("-i")
-    flags+=("--promptString=")
-    two_word_flags+=("--promptString")
-    two_word_flags+=("-p")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--long")
+    flags+=("-l")
+    flags+=("--sort=")
+    two_word_flags+=("--sort")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -935,6 +1866,8 @@ _chezmoi_execute-template()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -943,9 +1876,9 @@ _chezmoi_execute-template()
     noun_aliases=()
 }
 
-_chezmoi_forget()
+_chezmoi_merge()
 {
-    last_command="chezmoi_forget"
+    last_command="chezmoi_merge"
 
     command_aliases=()
 
@@ -962,6 +1895,8 @@ _chezmoi_forget()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -973,6 +1908,8 @@ _chezmoi_forget()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -981,9 +1918,9 @@ _chezmoi_forget()
     noun_aliases=()
 }
 
-_chezmoi_git()
+_chezmoi_mv()
 {
-    last_command="chezmoi_git"
+    last_command="chezmoi_mv"
 
     command_aliases=()
 
@@ -1000,6 +1937,8 @@ _chezmoi_git()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1011,6 +1950,8 @@ _chezmoi_git()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1019,9 +1960,9 @@ _chezmoi_git()
     noun_aliases=()
 }
 
-_chezmoi_hg()
+_chezmoi_parse-source-name()
 {
-    last_command="chezmoi_hg"
+    last_command="chezmoi_parse-source-name"
 
     command_aliases=()
 
@@ -1038,6 +1979,8 @@ _chezmoi_hg()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1049,6 +1992,8 @@ _chezmoi_hg()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1057,9 +2002,9 @@ _chezmoi_hg()
     noun_aliases=()
 }
 
-_chezmoi_import()
+_chezmoi_purge()
 {
-    last_command="chezmoi_import"
+    last_command="chezmoi_purge"
 
     command_aliases=()
 
@@ -1071,17 +2016,15 @@ _chezmoi_import()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--exact")
-    flags+=("-x")
-    flags+=("--remove-destination")
-    flags+=("-r")
-    flags+=("--strip-components=")
-    two_word_flags+=("--strip-components")
+    flags+=("--force")
+    flags+=("-f")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1093,6 +2036,8 @@ _chezmoi_import()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1101,9 +2046,9 @@ _chezmoi_import()
     noun_aliases=()
 }
 
-_chezmoi_init()
+_chezmoi_remove()
 {
-    last_command="chezmoi_init"
+    last_command="chezmoi_remove"
 
     command_aliases=()
 
@@ -1115,12 +2060,15 @@ _chezmoi_init()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--apply")
+    flags+=("--force")
+    flags+=("-f")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1132,6 +2080,8 @@ _chezmoi_init()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1140,9 +2090,9 @@ _chezmoi_init()
     noun_aliases=()
 }
 
-_chezmoi_managed()
+_chezmoi_secret_bitwarden()
 {
-    last_command="chezmoi_managed"
+    last_command="chezmoi_secret_bitwarden"
 
     command_aliases=()
 
@@ -1154,14 +2104,13 @@ _chezmoi_managed()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--include=")
-    two_word_flags+=("--include")
-    two_word_flags+=("-i")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1173,6 +2122,8 @@ _chezmoi_managed()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1181,9 +2132,9 @@ _chezmoi_managed()
     noun_aliases=()
 }
 
-_chezmoi_merge()
+_chezmoi_secret_doppler()
 {
-    last_command="chezmoi_merge"
+    last_command="chezmoi_secret_doppler"
 
     command_aliases=()
 
@@ -1200,6 +2151,8 @@ _chezmoi_merge()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1211,6 +2164,8 @@ _chezmoi_merge()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1219,9 +2174,9 @@ _chezmoi_merge()
     noun_aliases=()
 }
 
-_chezmoi_purge()
+_chezmoi_secret_generic()
 {
-    last_command="chezmoi_purge"
+    last_command="chezmoi_secret_generic"
 
     command_aliases=()
 
@@ -1233,13 +2188,13 @@ _chezmoi_purge()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--force")
-    flags+=("-f")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1251,6 +2206,8 @@ _chezmoi_purge()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1259,9 +2216,9 @@ _chezmoi_purge()
     noun_aliases=()
 }
 
-_chezmoi_remove()
+_chezmoi_secret_gopass()
 {
-    last_command="chezmoi_remove"
+    last_command="chezmoi_secret_gopass"
 
     command_aliases=()
 
@@ -1273,13 +2230,13 @@ _chezmoi_remove()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--force")
-    flags+=("-f")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1291,6 +2248,8 @@ _chezmoi_remove()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1299,9 +2258,9 @@ _chezmoi_remove()
     noun_aliases=()
 }
 
-_chezmoi_secret_bitwarden()
+_chezmoi_secret_keepassxc()
 {
-    last_command="chezmoi_secret_bitwarden"
+    last_command="chezmoi_secret_keepassxc"
 
     command_aliases=()
 
@@ -1318,6 +2277,8 @@ _chezmoi_secret_bitwarden()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1329,6 +2290,8 @@ _chezmoi_secret_bitwarden()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1337,9 +2300,9 @@ _chezmoi_secret_bitwarden()
     noun_aliases=()
 }
 
-_chezmoi_secret_generic()
+_chezmoi_secret_keepassxc-browser_associate()
 {
-    last_command="chezmoi_secret_generic"
+    last_command="chezmoi_secret_keepassxc-browser_associate"
 
     command_aliases=()
 
@@ -1356,6 +2319,8 @@ _chezmoi_secret_generic()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1367,6 +2332,8 @@ _chezmoi_secret_generic()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1375,13 +2342,14 @@ _chezmoi_secret_generic()
     noun_aliases=()
 }
 
-_chezmoi_secret_gopass()
+_chezmoi_secret_keepassxc-browser()
 {
-    last_command="chezmoi_secret_gopass"
+    last_command="chezmoi_secret_keepassxc-browser"
 
     command_aliases=()
 
     commands=()
+    commands+=("associate")
 
     flags=()
     two_word_flags=()
@@ -1394,6 +2362,8 @@ _chezmoi_secret_gopass()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1405,6 +2375,8 @@ _chezmoi_secret_gopass()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1413,9 +2385,9 @@ _chezmoi_secret_gopass()
     noun_aliases=()
 }
 
-_chezmoi_secret_keepassxc()
+_chezmoi_secret_keyring_delete()
 {
-    last_command="chezmoi_secret_keepassxc"
+    last_command="chezmoi_secret_keyring_delete"
 
     command_aliases=()
 
@@ -1432,6 +2404,8 @@ _chezmoi_secret_keepassxc()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1440,9 +2414,13 @@ _chezmoi_secret_keepassxc()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--service=")
+    two_word_flags+=("--service")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1470,6 +2448,8 @@ _chezmoi_secret_keyring_get()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1514,6 +2494,8 @@ _chezmoi_secret_keyring_set()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1544,6 +2526,7 @@ _chezmoi_secret_keyring()
     command_aliases=()
 
     commands=()
+    commands+=("delete")
     commands+=("get")
     commands+=("set")
 
@@ -1555,13 +2538,13 @@ _chezmoi_secret_keyring()
 
     flags+=("--service=")
     two_word_flags+=("--service")
-    flags+=("--user=")
-    two_word_flags+=("--user")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1573,12 +2556,13 @@ _chezmoi_secret_keyring()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
     must_have_one_flag=()
     must_have_one_flag+=("--service=")
-    must_have_one_flag+=("--user=")
     must_have_one_noun=()
     noun_aliases=()
 }
@@ -1602,6 +2586,8 @@ _chezmoi_secret_lastpass()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1613,6 +2599,8 @@ _chezmoi_secret_lastpass()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1640,6 +2628,8 @@ _chezmoi_secret_onepassword()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1651,6 +2641,8 @@ _chezmoi_secret_onepassword()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1678,6 +2670,8 @@ _chezmoi_secret_pass()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1689,6 +2683,8 @@ _chezmoi_secret_pass()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1716,6 +2712,8 @@ _chezmoi_secret_vault()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1727,6 +2725,8 @@ _chezmoi_secret_vault()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1743,9 +2743,11 @@ _chezmoi_secret()
 
     commands=()
     commands+=("bitwarden")
+    commands+=("doppler")
     commands+=("generic")
     commands+=("gopass")
     commands+=("keepassxc")
+    commands+=("keepassxc-browser")
     commands+=("keyring")
     commands+=("lastpass")
     commands+=("onepassword")
@@ -1763,6 +2765,8 @@ _chezmoi_secret()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1774,6 +2778,8 @@ _chezmoi_secret()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1801,6 +2807,8 @@ _chezmoi_source()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1812,6 +2820,8 @@ _chezmoi_source()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1839,6 +2849,50 @@ _chezmoi_source-path()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_test()
+{
+    last_command="chezmoi_test"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1850,6 +2904,8 @@ _chezmoi_source-path()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1877,6 +2933,8 @@ _chezmoi_unmanaged()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1888,6 +2946,8 @@ _chezmoi_unmanaged()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1912,11 +2972,19 @@ _chezmoi_update()
 
     flags+=("--apply")
     flags+=("-a")
+    flags+=("--force")
+    flags+=("-f")
+    flags+=("--report=")
+    two_word_flags+=("--report")
+    flags_with_completion+=("--report")
+    flags_completion+=("_filedir")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1928,6 +2996,8 @@ _chezmoi_update()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1966,6 +3036,50 @@ _chezmoi_upgrade()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_upgrade-externals()
+{
+    last_command="chezmoi_upgrade-externals"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1977,6 +3091,8 @@ _chezmoi_upgrade()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -1999,11 +3115,14 @@ _chezmoi_verify()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--fix")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2015,6 +3134,8 @@ _chezmoi_verify()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -2035,12 +3156,16 @@ _chezmoi_root_command()
         command_aliases+=("manage")
         aliashash["manage"]="add"
     fi
+    commands+=("affected")
     commands+=("apply")
     commands+=("archive")
+    commands+=("audit")
     commands+=("cat")
     commands+=("cd")
     commands+=("chattr")
     commands+=("completion")
+    commands+=("config")
+    commands+=("cp")
     commands+=("data")
     commands+=("diff")
     commands+=("docs")
@@ -2049,17 +3174,23 @@ _chezmoi_root_command()
     commands+=("edit")
     commands+=("edit-config")
     commands+=("execute-template")
+    commands+=("explain")
+    commands+=("externals")
+    commands+=("fleet")
     commands+=("forget")
     if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
         command_aliases+=("unmanage")
         aliashash["unmanage"]="forget"
     fi
+    commands+=("gc")
     commands+=("git")
     commands+=("hg")
     commands+=("import")
     commands+=("init")
     commands+=("managed")
     commands+=("merge")
+    commands+=("mv")
+    commands+=("parse-source-name")
     commands+=("purge")
     commands+=("remove")
     if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
@@ -2069,9 +3200,11 @@ _chezmoi_root_command()
     commands+=("secret")
     commands+=("source")
     commands+=("source-path")
+    commands+=("test")
     commands+=("unmanaged")
     commands+=("update")
     commands+=("upgrade")
+    commands+=("upgrade-externals")
     commands+=("verify")
 
     flags=()
@@ -2085,6 +3218,8 @@ _chezmoi_root_command()
     flags+=("--config=")
     two_word_flags+=("--config")
     two_word_flags+=("-c")
+    flags+=("--data=")
+    two_word_flags+=("--data")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2096,6 +3231,8 @@ _chezmoi_root_command()
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
+    flags+=("--user=")
+    two_word_flags+=("--user")
     flags+=("--verbose")
     flags+=("-v")
 
@@ -0,0 +1,300 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"filippo.io/age/armor"
+	keyring "github.com/zalando/go-keyring"
+)
+
+const ageSecretKeyPrefix = "AGE-SECRET-KEY-"
+
+// Age encrypts and decrypts data with age, see https://age-encryption.org.
+// Encryption and decryption are built in, so no age installation is needed.
+type Age struct {
+	Command        string
+	Identity       string
+	Identities     []string
+	Keyring        AgeKeyring
+	Recipient      string
+	Recipients     []string
+	RecipientsFile string
+	// ResolveRecipient, if set, returns the SSH public keys, in
+	// authorized_keys format, of recipients of the form forge:username.
+	ResolveRecipient func(spec string) ([]string, error) `mapstructure:"-"`
+	keyringSecret    *string
+}
+
+// An AgeKeyring identifies an age identity or passphrase stored in the
+// system keyring.
+type AgeKeyring struct {
+	Service string
+	User    string
+}
+
+// Decrypt implements Encryption.Decrypt.
+func (a *Age) Decrypt(ciphertext []byte) ([]byte, error) {
+	pluginNames, err := a.PluginNames()
+	if err != nil {
+		return nil, err
+	}
+	if len(pluginNames) != 0 {
+		return a.decryptWithPlugins(ciphertext, pluginNames)
+	}
+	identities, err := a.identities()
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader = bytes.NewReader(ciphertext)
+	if bytes.HasPrefix(ciphertext, []byte(armor.Header)) {
+		r = armor.NewReader(r)
+	}
+	plaintextReader, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(plaintextReader)
+}
+
+// Encrypt implements Encryption.Encrypt. The ciphertext is armored so that it
+// can be stored in a text file.
+func (a *Age) Encrypt(filename string, plaintext []byte) ([]byte, error) {
+	pluginNames, err := a.PluginNames()
+	if err != nil {
+		return nil, err
+	}
+	if len(pluginNames) != 0 {
+		return a.encryptWithPlugins(filename, plaintext, pluginNames)
+	}
+	recipients, err := a.recipients()
+	if err != nil {
+		return nil, err
+	}
+	b := &bytes.Buffer{}
+	armorWriter := armor.NewWriter(b)
+	w, err := age.Encrypt(armorWriter, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := armorWriter.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// identities returns a's identities, read from its identity files and the
+// keyring.
+func (a *Age) identities() ([]age.Identity, error) {
+	var identities []age.Identity
+	secret, err := a.getKeyringSecret()
+	switch {
+	case err != nil:
+		return nil, err
+	case strings.HasPrefix(secret, ageSecretKeyPrefix):
+		keyringIdentities, err := age.ParseIdentities(strings.NewReader(secret))
+		if err != nil {
+			return nil, fmt.Errorf("keyring: %w", err)
+		}
+		identities = append(identities, keyringIdentities...)
+	case secret != "":
+		identity, err := age.NewScryptIdentity(secret)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+	identityFiles := a.identityFiles()
+	if len(identityFiles) == 0 && len(identities) == 0 {
+		return nil, errors.New("no age identity, set age.identity or age.keyring in your config file")
+	}
+	for _, identityFile := range identityFiles {
+		data, err := ioutil.ReadFile(identityFile)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+			identity, err := agessh.ParseIdentity(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", identityFile, err)
+			}
+			identities = append(identities, identity)
+			continue
+		}
+		fileIdentities, err := age.ParseIdentities(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", identityFile, err)
+		}
+		identities = append(identities, fileIdentities...)
+	}
+	return identities, nil
+}
+
+// identityFiles returns a's identity files.
+func (a *Age) identityFiles() []string {
+	var identityFiles []string
+	if a.Identity != "" {
+		identityFiles = append(identityFiles, a.Identity)
+	}
+	return append(identityFiles, a.Identities...)
+}
+
+// recipients returns a's recipients. If the keyring contains a passphrase then
+// it is the only recipient. Otherwise, if no recipients are set then the
+// recipients of a's identities are used.
+func (a *Age) recipients() ([]age.Recipient, error) {
+	secret, err := a.getKeyringSecret()
+	if err != nil {
+		return nil, err
+	}
+	if secret != "" && !strings.HasPrefix(secret, ageSecretKeyPrefix) {
+		if a.Recipient != "" || len(a.Recipients) != 0 || a.RecipientsFile != "" {
+			return nil, errors.New("age passphrase in keyring cannot be combined with other recipients")
+		}
+		recipient, err := age.NewScryptRecipient(secret)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: %w", err)
+		}
+		return []age.Recipient{recipient}, nil
+	}
+	recipientStrs, err := a.resolveRecipients()
+	if err != nil {
+		return nil, err
+	}
+	var recipients []age.Recipient
+	for _, s := range recipientStrs {
+		recipient, err := parseAgeRecipient(s)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	if a.RecipientsFile != "" {
+		fileRecipients, err := parseAgeRecipientsFile(a.RecipientsFile)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, fileRecipients...)
+	}
+	if len(recipients) != 0 {
+		return recipients, nil
+	}
+	identities, err := a.identities()
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		switch identity := identity.(type) {
+		case *age.X25519Identity:
+			recipients = append(recipients, identity.Recipient())
+		case interface{ Recipient() age.Recipient }:
+			recipients = append(recipients, identity.Recipient())
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("no age recipients, set age.recipient in your config file")
+	}
+	return recipients, nil
+}
+
+// getKeyringSecret returns the identity or passphrase stored in the keyring, or
+// the empty string if the keyring is not used. The keyring is only queried
+// once.
+func (a *Age) getKeyringSecret() (string, error) {
+	if a.Keyring.Service == "" {
+		return "", nil
+	}
+	if a.keyringSecret == nil {
+		secret, err := keyring.Get(a.Keyring.Service, a.Keyring.User)
+		if err != nil {
+			return "", fmt.Errorf("keyring: %s: %w", a.Keyring.Service, err)
+		}
+		secret = strings.TrimSpace(secret)
+		a.keyringSecret = &secret
+	}
+	return *a.keyringSecret, nil
+}
+
+// resolveRecipients returns a's recipients as strings. Recipients of the form
+// forge:username are replaced with the user's SSH public keys.
+func (a *Age) resolveRecipients() ([]string, error) {
+	var recipients []string
+	for _, recipient := range append([]string{a.Recipient}, a.Recipients...) {
+		switch {
+		case recipient == "":
+		case isAgeForgeRecipient(recipient):
+			if a.ResolveRecipient == nil {
+				return nil, fmt.Errorf("%s: cannot resolve recipient", recipient)
+			}
+			keys, err := a.ResolveRecipient(recipient)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", recipient, err)
+			}
+			var supportedKeys []string
+			for _, key := range keys {
+				if _, err := agessh.ParseRecipient(key); err == nil {
+					supportedKeys = append(supportedKeys, key)
+				}
+			}
+			if len(supportedKeys) == 0 {
+				return nil, fmt.Errorf("%s: no ssh-ed25519 or ssh-rsa keys", recipient)
+			}
+			recipients = append(recipients, supportedKeys...)
+		default:
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients, nil
+}
+
+// isAgeForgeRecipient returns true if recipient has the form forge:username.
+func isAgeForgeRecipient(recipient string) bool {
+	return !strings.HasPrefix(recipient, "age1") && !strings.HasPrefix(recipient, "ssh-") && strings.Contains(recipient, ":")
+}
+
+// parseAgeRecipient parses an age recipient, which is either a native X25519
+// recipient or an SSH public key.
+func parseAgeRecipient(s string) (age.Recipient, error) {
+	if strings.HasPrefix(s, "ssh-") {
+		return agessh.ParseRecipient(s)
+	}
+	return age.ParseX25519Recipient(s)
+}
+
+// parseAgeRecipientsFile returns the recipients in the file at path, one per
+// line, ignoring empty lines and comments.
+func parseAgeRecipientsFile(path string) ([]age.Recipient, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var recipients []age.Recipient
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		recipient, err := parseAgeRecipient(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, s.Err()
+}
@@ -0,0 +1,183 @@
+package chezmoi
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	keyring "github.com/zalando/go-keyring"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestAge(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-age")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile := filepath.Join(tempDir, "key.txt")
+	require.NoError(t, ioutil.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600))
+	recipientsFile := filepath.Join(tempDir, "recipients.txt")
+	require.NoError(t, ioutil.WriteFile(recipientsFile, []byte("# comment\n\n"+identity.Recipient().String()+"\n"), 0600))
+
+	for _, tc := range []struct {
+		name string
+		age  *Age
+	}{
+		{
+			name: "identity",
+			age: &Age{
+				Identity: identityFile,
+			},
+		},
+		{
+			name: "recipient",
+			age: &Age{
+				Identity:  identityFile,
+				Recipient: identity.Recipient().String(),
+			},
+		},
+		{
+			name: "recipients_file",
+			age: &Age{
+				Identities:     []string{identityFile},
+				RecipientsFile: recipientsFile,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := "filename.txt"
+			plaintext := []byte("plaintext")
+
+			ciphertext, err := tc.age.Encrypt(filename, plaintext)
+			require.NoError(t, err)
+			assert.NotEqual(t, plaintext, ciphertext)
+
+			actualPlaintext, err := tc.age.Decrypt(ciphertext)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, actualPlaintext)
+		})
+	}
+}
+
+func TestAgeKeyring(t *testing.T) {
+	keyring.MockInit()
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	require.NoError(t, keyring.Set("chezmoi-test-age", "identity", identity.String()))
+	require.NoError(t, keyring.Set("chezmoi-test-age", "passphrase", "passphrase"))
+
+	for _, tc := range []struct {
+		name string
+		age  *Age
+	}{
+		{
+			name: "identity",
+			age: &Age{
+				Keyring: AgeKeyring{
+					Service: "chezmoi-test-age",
+					User:    "identity",
+				},
+			},
+		},
+		{
+			name: "passphrase",
+			age: &Age{
+				Keyring: AgeKeyring{
+					Service: "chezmoi-test-age",
+					User:    "passphrase",
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := "filename.txt"
+			plaintext := []byte("plaintext")
+
+			ciphertext, err := tc.age.Encrypt(filename, plaintext)
+			require.NoError(t, err)
+			assert.NotEqual(t, plaintext, ciphertext)
+
+			actualPlaintext, err := tc.age.Decrypt(ciphertext)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, actualPlaintext)
+		})
+	}
+
+	a := &Age{
+		Keyring: AgeKeyring{
+			Service: "chezmoi-test-age",
+			User:    "missing",
+		},
+	}
+	_, err = a.Decrypt([]byte("ciphertext"))
+	assert.Error(t, err)
+}
+
+func TestAgeSSH(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-age-ssh")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	identityFile := filepath.Join(tempDir, "id_rsa")
+	require.NoError(t, ioutil.WriteFile(identityFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	}), 0600))
+	publicKey, err := ssh.NewPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	authorizedKey := string(ssh.MarshalAuthorizedKey(publicKey))
+
+	a := &Age{
+		Identity:  identityFile,
+		Recipient: "github:user",
+		ResolveRecipient: func(spec string) ([]string, error) {
+			if spec != "github:user" {
+				return nil, errors.New("unknown user")
+			}
+			return []string{
+				"ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBOr1+kXUaBTrgqEOHH2vmGgJDcvOYUdGlJoz60QafhTHBBk2hSr8w/PbMUhEvC4MAdmPgU5/gsppkxzXJnsNKmg= user@host",
+				authorizedKey,
+			}, nil
+		},
+	}
+	filename := "filename.txt"
+	plaintext := []byte("plaintext")
+	ciphertext, err := a.Encrypt(filename, plaintext)
+	require.NoError(t, err)
+	actualPlaintext, err := a.Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, actualPlaintext)
+
+	a.Recipient = "gitlab:user"
+	_, err = a.Encrypt(filename, plaintext)
+	assert.Error(t, err)
+
+	a.Recipient = ""
+	ciphertext, err = a.Encrypt(filename, plaintext)
+	require.NoError(t, err)
+	actualPlaintext, err = a.Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, actualPlaintext)
+}
+
+func TestAgeNoIdentity(t *testing.T) {
+	a := &Age{}
+	_, err := a.Encrypt("filename.txt", []byte("plaintext"))
+	assert.Error(t, err)
+	_, err = a.Decrypt([]byte("ciphertext"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package chezmoi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+const agePluginIdentityPrefix = "AGE-PLUGIN-"
+
+// PluginNames returns the names of the age plugins, for example yubikey or
+// tpm, needed by a's identities and recipients.
+func (a *Age) PluginNames() ([]string, error) {
+	var lines []string
+	for _, identityFile := range a.identityFiles() {
+		data, err := ioutil.ReadFile(identityFile)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, strings.Split(string(data), "\n")...)
+	}
+	lines = append(lines, a.Recipient)
+	lines = append(lines, a.Recipients...)
+	if a.RecipientsFile != "" {
+		data, err := ioutil.ReadFile(a.RecipientsFile)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, strings.Split(string(data), "\n")...)
+	}
+	pluginNamesSet := make(map[string]bool)
+	for _, line := range lines {
+		if pluginName, ok := agePluginName(strings.TrimSpace(line)); ok {
+			pluginNamesSet[pluginName] = true
+		}
+	}
+	pluginNames := make([]string, 0, len(pluginNamesSet))
+	for pluginName := range pluginNamesSet {
+		pluginNames = append(pluginNames, pluginName)
+	}
+	sort.Strings(pluginNames)
+	return pluginNames, nil
+}
+
+// decryptWithPlugins decrypts ciphertext with the age command, which runs the
+// plugins. Any PIN or touch prompts from the plugins are made on the terminal.
+func (a *Age) decryptWithPlugins(ciphertext []byte, pluginNames []string) ([]byte, error) {
+	if a.Keyring.Service != "" {
+		return nil, errors.New("age plugins cannot be combined with age.keyring")
+	}
+	args := []string{"--decrypt"}
+	for _, identityFile := range a.identityFiles() {
+		args = append(args, "--identity", identityFile)
+	}
+	return a.runWithPlugins(args, ciphertext, pluginNames)
+}
+
+// encryptWithPlugins encrypts plaintext with the age command, which runs the
+// plugins. If no recipients are set then plaintext is encrypted to the
+// recipients of a's identities.
+func (a *Age) encryptWithPlugins(filename string, plaintext []byte, pluginNames []string) ([]byte, error) {
+	if a.Keyring.Service != "" {
+		return nil, errors.New("age plugins cannot be combined with age.keyring")
+	}
+	recipients, err := a.resolveRecipients()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"--encrypt", "--armor"}
+	for _, recipient := range recipients {
+		args = append(args, "--recipient", recipient)
+	}
+	if a.RecipientsFile != "" {
+		args = append(args, "--recipients-file", a.RecipientsFile)
+	}
+	if len(args) == 2 {
+		for _, identityFile := range a.identityFiles() {
+			args = append(args, "--identity", identityFile)
+		}
+	}
+	ciphertext, err := a.runWithPlugins(args, plaintext, pluginNames)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return ciphertext, nil
+}
+
+// runWithPlugins runs the age command with args and input as its standard
+// input and returns its standard output. It first checks that the age command
+// and all plugins can be found.
+func (a *Age) runWithPlugins(args []string, input []byte, pluginNames []string) ([]byte, error) {
+	command := a.Command
+	if command == "" {
+		command = "age"
+	}
+	if _, err := exec.LookPath(command); err != nil {
+		return nil, fmt.Errorf("%s not found in $PATH, it is needed for age plugins", command)
+	}
+	for _, pluginName := range pluginNames {
+		if _, err := exec.LookPath("age-plugin-" + pluginName); err != nil {
+			return nil, fmt.Errorf("age-plugin-%s not found in $PATH", pluginName)
+		}
+	}
+	//nolint:gosec
+	cmd := exec.Command(command, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	stdout := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w", command, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// agePluginName returns the name of the plugin needed by the age identity or
+// recipient s, if any. Plugin identities are of the form
+// AGE-PLUGIN-NAME-1... and plugin recipients of the form age1name1...,
+// where the last 1 separates the Bech32 human-readable part from the data.
+func agePluginName(s string) (string, bool) {
+	i := strings.LastIndex(s, "1")
+	if i == -1 {
+		return "", false
+	}
+	hrp := s[:i]
+	switch {
+	case strings.HasPrefix(hrp, agePluginIdentityPrefix):
+		pluginName := strings.TrimSuffix(strings.TrimPrefix(hrp, agePluginIdentityPrefix), "-")
+		return strings.ToLower(pluginName), pluginName != ""
+	case strings.HasPrefix(hrp, "age1"):
+		pluginName := strings.TrimPrefix(hrp, "age1")
+		return pluginName, pluginName != ""
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,87 @@
+package chezmoi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgePluginName(t *testing.T) {
+	for _, tc := range []struct {
+		s              string
+		wantPluginName string
+		wantOK         bool
+	}{
+		{
+			s: "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p",
+		},
+		{
+			s: "AGE-SECRET-KEY-1QQPQQ8NRWZ8NPRAZQW3N3FAPGYEL7M6DZPJ85V0S5XF3ZSQQF7NQ8FN6SJ",
+		},
+		{
+			s:              "age1yubikey1qwt50d05nh5vutpdzmlg5wn80xq5negm4uj9ghv0snvdd3yysf5yw3rhl3t",
+			wantPluginName: "yubikey",
+			wantOK:         true,
+		},
+		{
+			s:              "AGE-PLUGIN-YUBIKEY-1FWZ6QQYZ96QJ2QSQYQQQ",
+			wantPluginName: "yubikey",
+			wantOK:         true,
+		},
+		{
+			s:              "age1tpm1qg86fn5esp30u9h6jy6zvu9gcsvnac09vn8jzjxt8s3qtlcv5h2x287wm36",
+			wantPluginName: "tpm",
+			wantOK:         true,
+		},
+		{
+			s: "# public key: age1yubikey1qwt50d05nh5vutpdzmlg5wn80xq5negm4uj9ghv0snvdd3yysf5yw3rhl3t",
+		},
+		{
+			s: "",
+		},
+	} {
+		t.Run(tc.s, func(t *testing.T) {
+			pluginName, ok := agePluginName(tc.s)
+			assert.Equal(t, tc.wantPluginName, pluginName)
+			assert.Equal(t, tc.wantOK, ok)
+		})
+	}
+}
+
+func TestAgePluginNames(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-age-plugin")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	identityFile := filepath.Join(tempDir, "key.txt")
+	require.NoError(t, ioutil.WriteFile(identityFile, []byte(
+		"# public key: age1yubikey1qwt50d05nh5vutpdzmlg5wn80xq5negm4uj9ghv0snvdd3yysf5yw3rhl3t\n"+
+			"AGE-PLUGIN-YUBIKEY-1FWZ6QQYZ96QJ2QSQYQQQ\n",
+	), 0600))
+
+	a := &Age{
+		Command:  "age",
+		Identity: identityFile,
+		Recipients: []string{
+			"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p",
+			"age1tpm1qg86fn5esp30u9h6jy6zvu9gcsvnac09vn8jzjxt8s3qtlcv5h2x287wm36",
+		},
+	}
+	pluginNames, err := a.PluginNames()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tpm", "yubikey"}, pluginNames)
+
+	oldPath := os.Getenv("PATH")
+	require.NoError(t, os.Setenv("PATH", tempDir))
+	defer func() {
+		_ = os.Setenv("PATH", oldPath)
+	}()
+	_, err = a.Decrypt([]byte("ciphertext"))
+	assert.Error(t, err)
+	_, err = a.Encrypt("filename.txt", []byte("plaintext"))
+	assert.Error(t, err)
+}
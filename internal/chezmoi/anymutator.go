@@ -31,12 +31,24 @@ func (m *AnyMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 	return m.m.IdempotentCmdOutput(cmd)
 }
 
+// Link implements Mutator.Link.
+func (m *AnyMutator) Link(oldname, newname string) error {
+	m.mutated = true
+	return m.m.Link(oldname, newname)
+}
+
 // Mkdir implements Mutator.Mkdir.
 func (m *AnyMutator) Mkdir(name string, perm os.FileMode) error {
 	m.mutated = true
 	return m.m.Mkdir(name, perm)
 }
 
+// Mkfifo implements Mutator.Mkfifo.
+func (m *AnyMutator) Mkfifo(name string, perm os.FileMode) error {
+	m.mutated = true
+	return m.m.Mkfifo(name, perm)
+}
+
 // Mutated returns true if any of its methods have been called.
 func (m *AnyMutator) Mutated() bool {
 	return m.mutated
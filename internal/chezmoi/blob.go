@@ -0,0 +1,152 @@
+package chezmoi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+const (
+	blobsDirName      = ".chezmoiblobs"
+	blobPointerPrefix = "chezmoi-blob sha256:"
+)
+
+// parseBlobPointer parses data as a pointer to a blob in the blob store. It
+// returns the SHA256 of the blob's contents, or nil if data is not a blob
+// pointer.
+func parseBlobPointer(data []byte) *[sha256.Size]byte {
+	if len(data) != len(blobPointerPrefix)+2*sha256.Size+1 || !bytes.HasPrefix(data, []byte(blobPointerPrefix)) || data[len(data)-1] != '\n' {
+		return nil
+	}
+	var sum [sha256.Size]byte
+	if _, err := hex.Decode(sum[:], data[len(blobPointerPrefix):len(data)-1]); err != nil {
+		return nil
+	}
+	return &sum
+}
+
+// AddBlob adds contents to the blob store in ts.SourceDir, if it is not
+// already there, and returns a pointer to it.
+func (ts *TargetState) AddBlob(fs vfs.FS, contents []byte, mutator Mutator) ([]byte, error) {
+	sum := sha256.Sum256(contents)
+	path := ts.blobPath(sum)
+	switch _, err := fs.Lstat(path); {
+	case os.IsNotExist(err):
+		for _, dir := range []string{filepath.Dir(filepath.Dir(path)), filepath.Dir(path)} {
+			switch _, err := fs.Stat(dir); {
+			case os.IsNotExist(err):
+				if err := mutator.Mkdir(dir, 0777&^ts.Umask); err != nil {
+					return nil, err
+				}
+			case err != nil:
+				return nil, err
+			}
+		}
+		if err := mutator.WriteFile(path, contents, 0666&^ts.Umask, nil); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	}
+	if ts.blobs == nil {
+		ts.blobs = make(map[[sha256.Size]byte][]byte)
+	}
+	ts.blobs[sum] = contents
+	return []byte(fmt.Sprintf("%s%x\n", blobPointerPrefix, sum)), nil
+}
+
+// UnreferencedBlobs returns the sorted paths of the blobs in the blob store in
+// ts.SourceDir that no file in the source directory points to.
+func (ts *TargetState) UnreferencedBlobs(fs vfs.FS) ([]string, error) {
+	blobsDir := filepath.Join(ts.SourceDir, blobsDirName)
+	blobPointerSize := int64(len(blobPointerPrefix) + 2*sha256.Size + 1)
+	referencedBlobPaths := make(map[string]bool)
+	if err := vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case info.IsDir() && path == blobsDir:
+			return filepath.SkipDir
+		case !info.Mode().IsRegular() || info.Size() != blobPointerSize:
+			return nil
+		}
+		data, err := fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if sum := parseBlobPointer(data); sum != nil {
+			referencedBlobPaths[ts.blobPath(*sum)] = true
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var unreferencedBlobPaths []string
+	if err := vfs.Walk(fs, blobsDir, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case os.IsNotExist(err):
+			return nil
+		case err != nil:
+			return err
+		case info.IsDir() || referencedBlobPaths[path]:
+			return nil
+		}
+		unreferencedBlobPaths = append(unreferencedBlobPaths, path)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(unreferencedBlobPaths)
+	return unreferencedBlobPaths, nil
+}
+
+// blobPath returns the path of the blob with SHA256 sum.
+func (ts *TargetState) blobPath(sum [sha256.Size]byte) string {
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(ts.SourceDir, blobsDirName, name[:2], name)
+}
+
+// resolveBlobPointer returns the contents of the blob that data points to and
+// their SHA256 if data is a blob pointer, or data and nil otherwise. Blobs are
+// read and verified once and then shared by all files that point to them.
+// sourceName is the source name of the file containing data.
+func (ts *TargetState) resolveBlobPointer(fs vfs.FS, sourceName string, data []byte) ([]byte, *[sha256.Size]byte, error) {
+	sum := parseBlobPointer(data)
+	if sum == nil {
+		return data, nil, nil
+	}
+	if contents, ok := ts.blobs[*sum]; ok {
+		return contents, sum, nil
+	}
+	path := ts.blobPath(*sum)
+	contents, err := fs.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil, fmt.Errorf("%s: blob %x not found", sourceName, *sum)
+	case err != nil:
+		return nil, nil, err
+	case sha256.Sum256(contents) != *sum:
+		return nil, nil, fmt.Errorf("%s: corrupt blob", path)
+	}
+	if ts.blobs == nil {
+		ts.blobs = make(map[[sha256.Size]byte][]byte)
+	}
+	ts.blobs[*sum] = contents
+	return contents, sum, nil
+}
+
+// sourceContentsSHA256 returns the SHA256 of the contents of a source file
+// with data, without reading the blob if data is a blob pointer.
+func sourceContentsSHA256(data []byte) [sha256.Size]byte {
+	if sum := parseBlobPointer(data); sum != nil {
+		return *sum
+	}
+	return sha256.Sum256(data)
+}
@@ -0,0 +1,113 @@
+package chezmoi
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestParseBlobPointer(t *testing.T) {
+	sum := sha256.Sum256([]byte("contents"))
+	for _, tc := range []struct {
+		name string
+		data string
+		want *[sha256.Size]byte
+	}{
+		{
+			name: "pointer",
+			data: "chezmoi-blob sha256:d1b2a59fbea7e20077af9f91b27e95e865061b270be03ff539ab3b73587882e8\n",
+			want: &sum,
+		},
+		{
+			name: "empty",
+			data: "",
+		},
+		{
+			name: "no_newline",
+			data: "chezmoi-blob sha256:d1b2a59fbea7e20077af9f91b27e95e865061b270be03ff539ab3b73587882e8",
+		},
+		{
+			name: "short",
+			data: "chezmoi-blob sha256:d1b2\n",
+		},
+		{
+			name: "not_hex",
+			data: "chezmoi-blob sha256:z1b2a59fbea7e20077af9f91b27e95e865061b270be03ff539ab3b73587882e8\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, parseBlobPointer([]byte(tc.data)))
+		})
+	}
+}
+
+func TestTargetStateBlobs(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bar":                 "contents",
+			".foo":                 "contents",
+			".local/share/chezmoi": &vfst.Dir{Perm: 0700},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	mutator := NewFSMutator(fs)
+	for _, targetPath := range []string{"/home/user/.bar", "/home/user/.foo"} {
+		require.NoError(t, ts.Add(fs, AddOptions{Blob: true}, targetPath, nil, false, mutator))
+	}
+
+	ts = NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Evaluate())
+	for _, name := range []string{".bar", ".foo"} {
+		file, ok := ts.Entries[name].(*File)
+		require.True(t, ok)
+		contents, err := file.Contents()
+		require.NoError(t, err)
+		assert.Equal(t, []byte("contents"), contents)
+		assert.True(t, file.Blob())
+		contentsSHA256, err := file.ContentsSHA256()
+		require.NoError(t, err)
+		assert.Equal(t, sha256.Sum256([]byte("contents")), contentsSHA256)
+	}
+	assert.Len(t, ts.blobs, 1)
+}
+
+func TestTargetStateBlobErrors(t *testing.T) {
+	for name, blobs := range map[string]interface{}{
+		"missing": &vfst.Dir{Perm: 0755},
+		"corrupt": map[string]interface{}{
+			"d1/d1b2a59fbea7e20077af9f91b27e95e865061b270be03ff539ab3b73587882e8": "corrupt",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiblobs": blobs,
+					"dot_foo":       "chezmoi-blob sha256:d1b2a59fbea7e20077af9f91b27e95e865061b270be03ff539ab3b73587882e8\n",
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+			)
+			require.NoError(t, ts.Populate(fs, nil))
+			assert.Error(t, ts.Evaluate())
+		})
+	}
+}
@@ -61,6 +61,13 @@ func TestBoltPersistentState(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, value, actualValue)
 
+	actualValues := make(map[string]string)
+	require.NoError(t, b.ForEach(bucket, func(k, v []byte) error {
+		actualValues[string(k)] = string(v)
+		return nil
+	}))
+	assert.Equal(t, map[string]string{string(key): string(value)}, actualValues)
+
 	require.NoError(t, b.Close())
 
 	b, err = NewBoltPersistentState(fs, path, vfst.DefaultUmask, nil)
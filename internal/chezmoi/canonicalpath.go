@@ -0,0 +1,95 @@
+package chezmoi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinks is the maximum number of symlinks that CanonicalPath follows.
+const maxSymlinks = 255
+
+// An LstatReadlinker implements Lstat and Readlink.
+type LstatReadlinker interface {
+	Lstat(name string) (os.FileInfo, error)
+	Readlink(name string) (string, error)
+}
+
+// CanonicalPath returns the absolute path with all symlinks in path resolved
+// in fs, like filepath.EvalSymlinks. Unlike filepath.EvalSymlinks, path does
+// not need to exist: the components after the first one that does not exist
+// are appended unchanged.
+func CanonicalPath(fs LstatReadlinker, path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	symlinks := 0
+	volumeName := filepath.VolumeName(path)
+	canonicalPath := volumeName + string(filepath.Separator)
+	components := splitPathList(strings.TrimPrefix(path, canonicalPath))
+	for len(components) > 0 {
+		component := components[0]
+		components = components[1:]
+		nextPath := filepath.Join(canonicalPath, component)
+		info, err := fs.Lstat(nextPath)
+		switch {
+		case os.IsNotExist(err):
+			return filepath.Join(append([]string{nextPath}, components...)...), nil
+		case err != nil:
+			return "", err
+		case info.Mode()&os.ModeType != os.ModeSymlink:
+			canonicalPath = nextPath
+			continue
+		}
+		symlinks++
+		if symlinks > maxSymlinks {
+			return "", fmt.Errorf("%s: too many levels of symbolic links", path)
+		}
+		linkname, err := fs.Readlink(nextPath)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(linkname) {
+			canonicalPath = filepath.VolumeName(linkname) + string(filepath.Separator)
+			linkname = strings.TrimPrefix(linkname, canonicalPath)
+		}
+		components = append(splitPathList(linkname), components...)
+	}
+	return canonicalPath, nil
+}
+
+// RelTargetName returns the target name of targetPath in destDir. If
+// targetPath reaches destDir through a different path, for example because
+// destDir or one of its ancestors is a symlink, then the resolved paths of
+// targetPath's parent directory and destDir are compared instead. targetPath
+// itself is never resolved, as it may be a managed symlink.
+func RelTargetName(fs LstatReadlinker, destDir, targetPath string) (string, error) {
+	targetName, err := filepath.Rel(destDir, targetPath)
+	if err != nil {
+		return "", err
+	}
+	if !isOutside(targetName) {
+		return targetName, nil
+	}
+	canonicalDestDir, err := CanonicalPath(fs, destDir)
+	if err != nil {
+		return "", err
+	}
+	canonicalParentDir, err := CanonicalPath(fs, filepath.Dir(targetPath))
+	if err != nil {
+		return "", err
+	}
+	canonicalTargetName, err := filepath.Rel(canonicalDestDir, filepath.Join(canonicalParentDir, filepath.Base(targetPath)))
+	if err != nil || isOutside(canonicalTargetName) {
+		return targetName, err
+	}
+	return canonicalTargetName, nil
+}
+
+// isOutside returns true if the relative path relPath is outside the directory
+// that it is relative to.
+func isOutside(relPath string) bool {
+	return relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
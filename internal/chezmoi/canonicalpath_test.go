@@ -0,0 +1,100 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestCanonicalPath(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home":      &vfst.Symlink{Target: "usr/home"},
+		"/usr/home":  map[string]interface{}{"user/.bashrc": "# bashrc\n"},
+		"/tmp":       &vfst.Symlink{Target: "private/tmp"},
+		"/private":   map[string]interface{}{"tmp": &vfst.Dir{Perm: 0755}},
+		"/link":      &vfst.Symlink{Target: "home/user/.bashrc"},
+		"/loop":      &vfst.Symlink{Target: "loop"},
+		"/usr/local": &vfst.Symlink{Target: "../private/./tmp/.."},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "/", want: "/"},
+		{path: "/usr/home/user", want: "/usr/home/user"},
+		{path: "/home/user", want: "/usr/home/user"},
+		{path: "/home/user/.bashrc", want: "/usr/home/user/.bashrc"},
+		{path: "/home/user/nonexistent/file", want: "/usr/home/user/nonexistent/file"},
+		{path: "/tmp/foo", want: "/private/tmp/foo"},
+		{path: "/link", want: "/usr/home/user/.bashrc"},
+		{path: "/usr/local/tmp", want: "/private/tmp"},
+		{path: "/loop", wantErr: true},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			got, err := CanonicalPath(fs, tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRelTargetName(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home": &vfst.Symlink{Target: "usr/home"},
+		"/usr/home/user": map[string]interface{}{
+			".bashrc": &vfst.Symlink{Target: "dotfiles/bashrc"},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name       string
+		destDir    string
+		targetPath string
+		want       string
+	}{
+		{
+			name:       "same",
+			destDir:    "/home/user",
+			targetPath: "/home/user/.bashrc",
+			want:       ".bashrc",
+		},
+		{
+			name:       "resolved_target",
+			destDir:    "/home/user",
+			targetPath: "/usr/home/user/.bashrc",
+			want:       ".bashrc",
+		},
+		{
+			name:       "resolved_dest_dir",
+			destDir:    "/usr/home/user",
+			targetPath: "/home/user/.config/foo",
+			want:       ".config/foo",
+		},
+		{
+			name:       "outside",
+			destDir:    "/home/user",
+			targetPath: "/etc/hosts",
+			want:       "../../etc/hosts",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := RelTargetName(fs, tc.destDir, tc.targetPath)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
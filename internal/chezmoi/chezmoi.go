@@ -3,8 +3,10 @@ package chezmoi
 import (
 	"archive/tar"
 	"bytes"
+	"context"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -14,15 +16,23 @@ import (
 
 // Suffixes and prefixes.
 const (
+	afterPrefix      = "after_"
+	beforePrefix     = "before_"
+	compressedPrefix = "compressed_"
 	dotPrefix        = "dot_"
 	emptyPrefix      = "empty_"
 	encryptedPrefix  = "encrypted_"
 	exactPrefix      = "exact_"
 	executablePrefix = "executable_"
+	fifoPrefix       = "fifo_"
+	hardlinkPrefix   = "hardlink_"
+	literalPrefix    = "literal_"
 	oncePrefix       = "once_"
+	onchangePrefix   = "onchange_"
 	privatePrefix    = "private_"
 	runPrefix        = "run_"
 	symlinkPrefix    = "symlink_"
+	literalSuffix    = ".literal"
 	TemplateSuffix   = ".tmpl"
 )
 
@@ -30,16 +40,28 @@ const (
 type PersistentState interface {
 	Close() error
 	Delete(bucket, key []byte) error
+	ForEach(bucket []byte, fn func(k, v []byte) error) error
 	Get(bucket, key []byte) ([]byte, error)
 	Set(bucket, key, value []byte) error
 }
 
+// A PreviewFunc applies an entry as a dry run with mutator, writing the
+// contents of any script that would be run to stdout.
+type PreviewFunc func(mutator Mutator, stdout io.Writer) error
+
 // An ApplyOptions is a big ball of mud for things that affect Entry.Apply.
 type ApplyOptions struct {
+	ConfirmApply      func(entry Entry, preview PreviewFunc) (bool, error)
+	ConfirmOverwrite  func(targetPath string, info os.FileInfo) (bool, error)
+	Context           context.Context
 	DestDir           string
 	DryRun            bool
+	FIFOs             bool
 	Ignore            func(string) bool
+	NewScriptCmd      func(name string, contents []byte, dir string, env []string) (*exec.Cmd, func(), error)
+	OnRunScript       func(targetPath string)
 	PersistentState   PersistentState
+	PrivilegedMutator Mutator
 	Remove            bool
 	ScriptStateBucket []byte
 	Stdout            io.Writer
@@ -47,7 +69,17 @@ type ApplyOptions struct {
 	Verbose           bool
 }
 
-// An Entry is either a Dir, a File, or a Symlink.
+// MutatorFor returns the Mutator to use to apply entry. This is
+// o.PrivilegedMutator for entries outside the target directory, if set, and
+// mutator otherwise.
+func (o *ApplyOptions) MutatorFor(entry Entry, mutator Mutator) Mutator {
+	if o.PrivilegedMutator != nil && filepath.IsAbs(entry.TargetName()) {
+		return o.PrivilegedMutator
+	}
+	return mutator
+}
+
+// An Entry is either a Dir, a FIFO, a File, a Hardlink, or a Symlink.
 type Entry interface {
 	AppendAllEntries(allEntries []Entry) []Entry
 	Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error
@@ -93,7 +125,7 @@ func parseSourceFilePath(path string) parsedSourceFilePath {
 	components := splitPathList(path)
 	das := parseDirNameComponents(components[0 : len(components)-1])
 	sourceName := components[len(components)-1]
-	if strings.HasPrefix(sourceName, runPrefix) {
+	if isScriptSourceName(sourceName) {
 		sa := ParseScriptAttributes(sourceName)
 		return parsedSourceFilePath{
 			dirAttributes:    das,
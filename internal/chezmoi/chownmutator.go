@@ -0,0 +1,109 @@
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A ChownMutator wraps another Mutator and changes the owner of every
+// directory, file, and symlink that it creates in a directory. It is used
+// when running as root on behalf of another user, so that the user owns the
+// files in their home directory.
+type ChownMutator struct {
+	m   Mutator
+	fs  vfs.FS
+	dir string
+	uid int
+	gid int
+}
+
+// NewChownMutator returns a new ChownMutator that changes the owner of
+// everything it creates in dir to uid and gid.
+func NewChownMutator(m Mutator, fs vfs.FS, dir string, uid, gid int) *ChownMutator {
+	return &ChownMutator{
+		m:   m,
+		fs:  fs,
+		dir: filepath.Clean(dir),
+		uid: uid,
+		gid: gid,
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *ChownMutator) Chmod(name string, mode os.FileMode) error {
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *ChownMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Link implements Mutator.Link.
+func (m *ChownMutator) Link(oldname, newname string) error {
+	return m.m.Link(oldname, newname)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *ChownMutator) Mkdir(name string, perm os.FileMode) error {
+	if err := m.m.Mkdir(name, perm); err != nil {
+		return err
+	}
+	return m.lchown(name)
+}
+
+// Mkfifo implements Mutator.Mkfifo.
+func (m *ChownMutator) Mkfifo(name string, perm os.FileMode) error {
+	if err := m.m.Mkfifo(name, perm); err != nil {
+		return err
+	}
+	return m.lchown(name)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *ChownMutator) RemoveAll(name string) error {
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *ChownMutator) Rename(oldpath, newpath string) error {
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *ChownMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *ChownMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *ChownMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if err := m.m.WriteFile(name, data, perm, currData); err != nil {
+		return err
+	}
+	return m.lchown(name)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *ChownMutator) WriteSymlink(oldname, newname string) error {
+	if err := m.m.WriteSymlink(oldname, newname); err != nil {
+		return err
+	}
+	return m.lchown(newname)
+}
+
+// lchown changes the owner of name, if it is in m.dir.
+func (m *ChownMutator) lchown(name string) error {
+	if name != m.dir && !strings.HasPrefix(name, m.dir+string(filepath.Separator)) {
+		return nil
+	}
+	return m.fs.Lchown(name, m.uid, m.gid)
+}
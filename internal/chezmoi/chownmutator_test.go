@@ -0,0 +1,53 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+// A recordingChownFS records calls to Lchown instead of changing owners, so
+// tests do not need to run as root.
+type recordingChownFS struct {
+	vfs.FS
+	owners map[string][2]int
+}
+
+func (fs *recordingChownFS) Lchown(name string, uid, gid int) error {
+	fs.owners[name] = [2]int{uid, gid}
+	return nil
+}
+
+func TestChownMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+		"/root":      &vfst.Dir{Perm: 0700},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	chownFS := &recordingChownFS{
+		FS:     fs,
+		owners: make(map[string][2]int),
+	}
+	m := NewChownMutator(NewFSMutator(fs), chownFS, "/home/user", 1000, 100)
+	require.NoError(t, m.Mkdir("/home/user/.ssh", 0700))
+	require.NoError(t, m.WriteFile("/home/user/.ssh/config", []byte("# config\n"), 0600, nil))
+	require.NoError(t, m.WriteSymlink(".ssh/config", "/home/user/.sshconfig"))
+	require.NoError(t, m.WriteFile("/root/.bashrc", []byte("# bashrc\n"), 0644, nil))
+	assert.Equal(t, map[string][2]int{
+		"/home/user/.ssh":        {1000, 100},
+		"/home/user/.ssh/config": {1000, 100},
+		"/home/user/.sshconfig":  {1000, 100},
+	}, chownFS.owners)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.ssh/config",
+			vfst.TestContentsString("# config\n"),
+		),
+		vfst.TestPath("/root/.bashrc",
+			vfst.TestContentsString("# bashrc\n"),
+		),
+	)
+}
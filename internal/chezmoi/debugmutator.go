@@ -38,6 +38,13 @@ func (m *DebugMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 	return output, err
 }
 
+// Link implements Mutator.Link.
+func (m *DebugMutator) Link(oldname, newname string) error {
+	return Debugf("Link(%q, %q)", []interface{}{oldname, newname}, func() error {
+		return m.m.Link(oldname, newname)
+	})
+}
+
 // Mkdir implements Mutator.Mkdir.
 func (m *DebugMutator) Mkdir(name string, perm os.FileMode) error {
 	return Debugf("Mkdir(%q, 0%o)", []interface{}{name, perm}, func() error {
@@ -45,6 +52,13 @@ func (m *DebugMutator) Mkdir(name string, perm os.FileMode) error {
 	})
 }
 
+// Mkfifo implements Mutator.Mkfifo.
+func (m *DebugMutator) Mkfifo(name string, perm os.FileMode) error {
+	return Debugf("Mkfifo(%q, 0%o)", []interface{}{name, perm}, func() error {
+		return m.m.Mkfifo(name, perm)
+	})
+}
+
 // RemoveAll implements Mutator.RemoveAll.
 func (m *DebugMutator) RemoveAll(name string) error {
 	return Debugf("RemoveAll(%q)", []interface{}{name}, func() error {
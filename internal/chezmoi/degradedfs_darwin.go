@@ -0,0 +1,42 @@
+// +build darwin
+
+package chezmoi
+
+import (
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+var degradedFSTypeNames = map[string]bool{
+	"afpfs":  true,
+	"exfat":  true,
+	"msdos":  true,
+	"nfs":    true,
+	"smbfs":  true,
+	"webdav": true,
+}
+
+// IsDegradedFS returns true if path, or its nearest existing parent directory,
+// is on a filesystem that might not support permissions or symlinks, for
+// example a Samba, NFS, or FAT filesystem.
+func IsDegradedFS(path string) bool {
+	for {
+		var statfs unix.Statfs_t
+		if err := unix.Statfs(path, &statfs); err == nil {
+			typeName := make([]byte, 0, len(statfs.Fstypename))
+			for _, c := range statfs.Fstypename {
+				if c == 0 {
+					break
+				}
+				typeName = append(typeName, byte(c))
+			}
+			return degradedFSTypeNames[string(typeName)]
+		}
+		parentDir := filepath.Dir(path)
+		if parentDir == path {
+			return false
+		}
+		path = parentDir
+	}
+}
@@ -0,0 +1,43 @@
+// +build linux
+
+package chezmoi
+
+import (
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// Filesystem magic numbers not defined by golang.org/x/sys/unix, see statfs(2).
+const (
+	cifsMagicNumber = 0xff534d42
+	exfatSuperMagic = 0x2011bab0
+	fuseSuperMagic  = 0x65735546
+	ntfsSuperMagic  = 0x5346544e
+	sdcardfsMagic   = 0x5dca2df5
+	smb2MagicNumber = 0xfe534d42
+)
+
+// IsDegradedFS returns true if path, or its nearest existing parent directory,
+// is on a filesystem that might not support permissions or symlinks, for
+// example a Samba, NFS, or FAT filesystem, or Android shared storage.
+func IsDegradedFS(path string) bool {
+	for {
+		var statfs unix.Statfs_t
+		if err := unix.Statfs(path, &statfs); err == nil {
+			//nolint:unconvert
+			switch uint32(statfs.Type) {
+			case cifsMagicNumber, exfatSuperMagic, fuseSuperMagic, ntfsSuperMagic, sdcardfsMagic, smb2MagicNumber,
+				unix.MSDOS_SUPER_MAGIC, unix.NFS_SUPER_MAGIC, unix.SMB_SUPER_MAGIC:
+				return true
+			default:
+				return false
+			}
+		}
+		parentDir := filepath.Dir(path)
+		if parentDir == path {
+			return false
+		}
+		path = parentDir
+	}
+}
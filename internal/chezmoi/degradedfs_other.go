@@ -0,0 +1,8 @@
+// +build !darwin,!linux
+
+package chezmoi
+
+// IsDegradedFS always returns false on this system.
+func IsDegradedFS(path string) bool {
+	return false
+}
@@ -0,0 +1,151 @@
+package chezmoi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A DegradedMutator wraps another Mutator for filesystems that do not support
+// permissions or symlinks, for example Samba and some NFS mounts. On such
+// filesystems, failures to change permissions are reported as warnings and
+// symlinks are replaced by copies of their targets. Each kind of warning is
+// only written once.
+type DegradedMutator struct {
+	m          Mutator
+	fs         vfs.FS
+	isDegraded func(string) bool
+	w          io.Writer
+	warned     map[string]bool
+}
+
+// NewDegradedMutator returns a new DegradedMutator that degrades operations on
+// the paths for which isDegraded returns true, writing warnings to w.
+func NewDegradedMutator(m Mutator, fs vfs.FS, isDegraded func(string) bool, w io.Writer) *DegradedMutator {
+	return &DegradedMutator{
+		m:          m,
+		fs:         fs,
+		isDegraded: isDegraded,
+		w:          w,
+		warned:     make(map[string]bool),
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *DegradedMutator) Chmod(name string, mode os.FileMode) error {
+	err := m.m.Chmod(name, mode)
+	if err == nil || !m.isDegraded(name) {
+		return err
+	}
+	m.warnOncef("chmod", "%s: cannot change permissions to %03o: %v", name, mode, err)
+	return nil
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *DegradedMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Link implements Mutator.Link.
+func (m *DegradedMutator) Link(oldname, newname string) error {
+	return m.m.Link(oldname, newname)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *DegradedMutator) Mkdir(name string, perm os.FileMode) error {
+	return m.m.Mkdir(name, perm)
+}
+
+// Mkfifo implements Mutator.Mkfifo.
+func (m *DegradedMutator) Mkfifo(name string, perm os.FileMode) error {
+	return m.m.Mkfifo(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *DegradedMutator) RemoveAll(name string) error {
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *DegradedMutator) Rename(oldpath, newpath string) error {
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *DegradedMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *DegradedMutator) Stat(path string) (os.FileInfo, error) {
+	return m.m.Stat(path)
+}
+
+// WriteFile implements Mutator.WriteFile. Atomic writes set the permissions of
+// a temporary file, so if they fail because permissions cannot be set then the
+// file is written directly.
+func (m *DegradedMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	err := m.m.WriteFile(name, data, perm, currData)
+	if err == nil || !m.isDegraded(name) || !isPermissionError(err) {
+		return err
+	}
+	if err := m.fs.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+	m.warnOncef("writeFile", "%s: cannot set permissions to %03o, writing files directly: %v", name, perm, err)
+	return nil
+}
+
+// WriteSymlink implements Mutator.WriteSymlink. If the symlink cannot be
+// created then a copy of its target is written instead.
+func (m *DegradedMutator) WriteSymlink(oldname, newname string) error {
+	err := m.m.WriteSymlink(oldname, newname)
+	if err == nil || !m.isDegraded(newname) {
+		return err
+	}
+	target := oldname
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(newname), target)
+	}
+	info, statErr := m.fs.Stat(target)
+	if statErr != nil {
+		return fmt.Errorf("%s: cannot create symlink and cannot copy %s: %w", newname, target, statErr)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s: cannot create symlink and %s is not a regular file: %w", newname, target, err)
+	}
+	data, err := m.fs.ReadFile(target)
+	if err != nil {
+		return err
+	}
+	if err := m.fs.RemoveAll(newname); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := m.fs.WriteFile(newname, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	m.warnOncef("writeSymlink", "%s: cannot create symlink, copied %s instead", newname, target)
+	return nil
+}
+
+// warnOncef writes a warning, unless a warning of kind has already been
+// written.
+func (m *DegradedMutator) warnOncef(kind, format string, args ...interface{}) {
+	if m.warned[kind] {
+		return
+	}
+	m.warned[kind] = true
+	_, _ = fmt.Fprintf(m.w, "chezmoi: warning: "+format+"\n", args...)
+}
+
+// isPermissionError returns true if err is an error that occurs on filesystems
+// that do not support permissions.
+func isPermissionError(err error) bool {
+	return os.IsPermission(err) || errors.Is(err, syscall.EROFS)
+}
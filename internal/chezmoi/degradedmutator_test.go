@@ -0,0 +1,92 @@
+package chezmoi
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+var errUnsupported = errors.New("operation not supported")
+
+// An unsupportedMutator simulates a filesystem that does not support
+// permissions or symlinks.
+type unsupportedMutator struct {
+	Mutator
+}
+
+func (unsupportedMutator) Chmod(name string, mode os.FileMode) error {
+	return errUnsupported
+}
+
+func (unsupportedMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if strings.HasSuffix(name, "/eio") {
+		return &os.PathError{Op: "write", Path: name, Err: syscall.EIO}
+	}
+	return &os.PathError{Op: "chmod", Path: name, Err: syscall.EPERM}
+}
+
+func (unsupportedMutator) WriteSymlink(oldname, newname string) error {
+	return errUnsupported
+}
+
+func TestDegradedMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			"share": map[string]interface{}{
+				"foo": "foo",
+			},
+			"local": &vfst.Dir{Perm: 0755},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	isDegraded := func(path string) bool {
+		return strings.HasPrefix(path, "/home/user/share/")
+	}
+	w := &bytes.Buffer{}
+	m := NewDegradedMutator(unsupportedMutator{Mutator: NewFSMutator(fs)}, fs, isDegraded, w)
+
+	assert.NoError(t, m.Chmod("/home/user/share/foo", 0600))
+	assert.NoError(t, m.WriteFile("/home/user/share/bar", []byte("bar"), 0644, nil))
+	assert.NoError(t, m.WriteFile("/home/user/share/bar2", []byte("bar2"), 0644, nil))
+	assert.Error(t, m.WriteFile("/home/user/share/eio", []byte("eio"), 0644, nil))
+	assert.NoError(t, m.WriteSymlink("foo", "/home/user/share/baz"))
+	assert.Error(t, m.WriteSymlink("missing", "/home/user/share/qux"))
+
+	assert.Error(t, m.Chmod("/home/user/local", 0700))
+	assert.Error(t, m.WriteFile("/home/user/local/bar", []byte("bar"), 0644, nil))
+	assert.Error(t, m.WriteSymlink("bar", "/home/user/local/baz"))
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/share/bar",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("bar"),
+		),
+		vfst.TestPath("/home/user/share/bar2",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("bar2"),
+		),
+		vfst.TestPath("/home/user/share/eio",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/share/baz",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("foo"),
+		),
+		vfst.TestPath("/home/user/share/qux",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/local/bar",
+			vfst.TestDoesNotExist,
+		),
+	)
+	assert.Equal(t, 3, strings.Count(w.String(), "chezmoi: warning: "))
+}
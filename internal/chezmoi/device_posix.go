@@ -0,0 +1,18 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"os"
+	"syscall"
+)
+
+// getDevice returns the device number of the filesystem that info is on.
+func getDevice(info os.FileInfo) (uint64, bool) {
+	statT, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	//nolint:unconvert
+	return uint64(statT.Dev), true
+}
@@ -0,0 +1,12 @@
+// +build windows
+
+package chezmoi
+
+import (
+	"os"
+)
+
+// getDevice always returns false on Windows.
+func getDevice(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -4,7 +4,6 @@ import (
 	"archive/tar"
 	"os"
 	"path/filepath"
-	"strings"
 
 	vfs "github.com/twpayne/go-vfs"
 )
@@ -23,36 +22,30 @@ type Dir struct {
 	Exact      bool
 	Perm       os.FileMode
 	Entries    map[string]Entry
+	implicit   bool
+	ExtraAttributes
 }
 
 type dirConcreteValue struct {
-	Type       string        `json:"type" yaml:"type"`
-	SourcePath string        `json:"sourcePath" yaml:"sourcePath"`
-	TargetPath string        `json:"targetPath" yaml:"targetPath"`
-	Exact      bool          `json:"exact" yaml:"exact"`
-	Perm       int           `json:"perm" yaml:"perm"`
-	Entries    []interface{} `json:"entries" yaml:"entries"`
+	Type            string        `json:"type" yaml:"type"`
+	SourcePath      string        `json:"sourcePath" yaml:"sourcePath"`
+	TargetPath      string        `json:"targetPath" yaml:"targetPath"`
+	Exact           bool          `json:"exact" yaml:"exact"`
+	Perm            int           `json:"perm" yaml:"perm"`
+	Entries         []interface{} `json:"entries" yaml:"entries"`
+	ExtraAttributes `yaml:",inline"`
 }
 
 // ParseDirAttributes parses a single directory name.
 func ParseDirAttributes(sourceName string) DirAttributes {
-	name := sourceName
+	t := dirSourceNameGrammar.tokenize(sourceName)
 	perm := os.FileMode(0777)
-	exact := false
-	if strings.HasPrefix(name, exactPrefix) {
-		name = strings.TrimPrefix(name, exactPrefix)
-		exact = true
-	}
-	if strings.HasPrefix(name, privatePrefix) {
-		name = strings.TrimPrefix(name, privatePrefix)
+	if t.prefixes[privatePrefix] {
 		perm &= 0700
 	}
-	if strings.HasPrefix(name, dotPrefix) {
-		name = "." + strings.TrimPrefix(name, dotPrefix)
-	}
 	return DirAttributes{
-		Name:  name,
-		Exact: exact,
+		Name:  t.name,
+		Exact: t.prefixes[exactPrefix],
 		Perm:  perm,
 	}
 }
@@ -66,12 +59,7 @@ func (da DirAttributes) SourceName() string {
 	if da.Perm&os.FileMode(077) == os.FileMode(0) {
 		sourceName += privatePrefix
 	}
-	if strings.HasPrefix(da.Name, ".") {
-		sourceName += dotPrefix + strings.TrimPrefix(da.Name, ".")
-	} else {
-		sourceName += da.Name
-	}
-	return sourceName
+	return dirSourceNameGrammar.sourceName(sourceName, da.Name, false)
 }
 
 // newDir returns a new directory state.
@@ -99,7 +87,10 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 	if applyOptions.Ignore(d.targetName) {
 		return nil
 	}
-	targetPath := filepath.Join(applyOptions.DestDir, d.targetName)
+	if err := d.ExtraAttributes.checkApply(d.targetName); err != nil {
+		return err
+	}
+	targetPath := TargetPath(applyOptions.DestDir, d.targetName)
 	var info os.FileInfo
 	var err error
 	if follow {
@@ -109,11 +100,14 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 	}
 	switch {
 	case err == nil && info.IsDir():
-		if info.Mode().Perm() != d.Perm&^applyOptions.Umask {
+		if !d.implicit && info.Mode().Perm() != d.Perm&^applyOptions.Umask {
 			if err := mutator.Chmod(targetPath, d.Perm&^applyOptions.Umask); err != nil {
 				return err
 			}
 		}
+	case err == nil && info.Mode()&os.ModeType == os.ModeSymlink && isDirSymlink(fs, targetPath):
+		// A symlink to a directory, for example to a directory that was moved
+		// to another disk, is used as the directory rather than replaced.
 	case err == nil:
 		if err := mutator.RemoveAll(targetPath); err != nil {
 			return err
@@ -126,23 +120,23 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 	default:
 		return err
 	}
-	for _, entryName := range sortedEntryNames(d.Entries) {
-		if err := d.Entries[entryName].Apply(fs, mutator, follow, applyOptions); err != nil {
-			return err
-		}
+	if err := ApplyEntries(fs, mutator, follow, applyOptions, sortedEntries(d.Entries)); err != nil {
+		return err
 	}
 	if d.Exact {
+		// In dry run mode, a directory that would be created does not exist
+		// and so has nothing to remove.
 		infos, err := fs.ReadDir(targetPath)
-		if err != nil {
+		if err != nil && !(applyOptions.DryRun && os.IsNotExist(err)) {
 			return err
 		}
 		for _, info := range infos {
-			name := info.Name()
+			name := normalizeName(info.Name())
 			if _, ok := d.Entries[name]; !ok {
 				if applyOptions.Ignore(filepath.Join(d.targetName, name)) {
 					continue
 				}
-				if err := mutator.RemoveAll(filepath.Join(targetPath, name)); err != nil {
+				if err := mutator.RemoveAll(filepath.Join(targetPath, info.Name())); err != nil {
 					return err
 				}
 			}
@@ -169,12 +163,13 @@ func (d *Dir) ConcreteValue(ignore func(string) bool, sourceDir string, umask os
 		}
 	}
 	return &dirConcreteValue{
-		Type:       "dir",
-		SourcePath: filepath.Join(sourceDir, d.SourceName()),
-		TargetPath: d.TargetName(),
-		Exact:      d.Exact,
-		Perm:       int(d.Perm &^ umask),
-		Entries:    entryConcreteValues,
+		Type:            "dir",
+		SourcePath:      filepath.Join(sourceDir, d.SourceName()),
+		TargetPath:      d.TargetName(),
+		Exact:           d.Exact,
+		Perm:            int(d.Perm &^ umask),
+		Entries:         entryConcreteValues,
+		ExtraAttributes: d.ExtraAttributes,
 	}, nil
 }
 
@@ -225,3 +220,9 @@ func (d *Dir) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *t
 	}
 	return nil
 }
+
+// isDirSymlink returns true if the symlink at path in fs points to a directory.
+func isDirSymlink(fs vfs.FS, path string) bool {
+	info, err := fs.Stat(path)
+	return err == nil && info.IsDir()
+}
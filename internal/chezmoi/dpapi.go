@@ -0,0 +1,36 @@
+package chezmoi
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+const dpapiPEMType = "CHEZMOI DPAPI ENCRYPTED DATA"
+
+// DPAPI encrypts data with the Windows Data Protection API, so that it can
+// only be decrypted by the same user, without needing any extra tools or keys.
+// Encrypted data is stored PEM-encoded so that it can be stored in a text
+// file.
+type DPAPI struct{}
+
+// Decrypt implements Encryption.Decrypt.
+func (d *DPAPI) Decrypt(ciphertext []byte) ([]byte, error) {
+	block, _ := pem.Decode(ciphertext)
+	if block == nil || block.Type != dpapiPEMType {
+		return nil, errors.New("not DPAPI encrypted data")
+	}
+	return dpapiUnprotect(block.Bytes)
+}
+
+// Encrypt implements Encryption.Encrypt.
+func (d *DPAPI) Encrypt(filename string, plaintext []byte) ([]byte, error) {
+	data, err := dpapiProtect(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  dpapiPEMType,
+		Bytes: data,
+	}), nil
+}
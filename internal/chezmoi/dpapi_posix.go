@@ -0,0 +1,15 @@
+// +build !windows
+
+package chezmoi
+
+import "errors"
+
+var errDPAPIUnsupported = errors.New("DPAPI is only available on Windows")
+
+func dpapiProtect(plaintext []byte) ([]byte, error) {
+	return nil, errDPAPIUnsupported
+}
+
+func dpapiUnprotect(ciphertext []byte) ([]byte, error) {
+	return nil, errDPAPIUnsupported
+}
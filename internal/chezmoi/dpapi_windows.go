@@ -0,0 +1,70 @@
+// +build windows
+
+package chezmoi
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// cryptProtectUIForbidden stops DPAPI from prompting the user.
+const cryptProtectUIForbidden = 0x1
+
+var (
+	crypt32                = windows.NewLazySystemDLL("crypt32.dll")
+	procCryptProtectData   = crypt32.NewProc("CryptProtectData")
+	procCryptUnprotectData = crypt32.NewProc("CryptUnprotectData")
+)
+
+// A dataBlob is a DATA_BLOB.
+type dataBlob struct {
+	cbData uint32
+	pbData *byte
+}
+
+func newDataBlob(data []byte) *dataBlob {
+	if len(data) == 0 {
+		return &dataBlob{}
+	}
+	return &dataBlob{
+		cbData: uint32(len(data)),
+		pbData: &data[0],
+	}
+}
+
+// bytes returns a copy of b's data and frees it.
+func (b *dataBlob) bytes() []byte {
+	defer func() {
+		_, _ = windows.LocalFree(windows.Handle(unsafe.Pointer(b.pbData)))
+	}()
+	data := make([]byte, b.cbData)
+	copy(data, (*[1 << 30]byte)(unsafe.Pointer(b.pbData))[:b.cbData:b.cbData])
+	return data
+}
+
+func dpapiProtect(plaintext []byte) ([]byte, error) {
+	var out dataBlob
+	if r, _, err := procCryptProtectData.Call(
+		uintptr(unsafe.Pointer(newDataBlob(plaintext))),
+		0, 0, 0, 0,
+		cryptProtectUIForbidden,
+		uintptr(unsafe.Pointer(&out)),
+	); r == 0 {
+		return nil, err
+	}
+	return out.bytes(), nil
+}
+
+func dpapiUnprotect(ciphertext []byte) ([]byte, error) {
+	var out dataBlob
+	if r, _, err := procCryptUnprotectData.Call(
+		uintptr(unsafe.Pointer(newDataBlob(ciphertext))),
+		0, 0, 0, 0,
+		cryptProtectUIForbidden,
+		uintptr(unsafe.Pointer(&out)),
+	); r == 0 {
+		return nil, err
+	}
+	return out.bytes(), nil
+}
@@ -0,0 +1,8 @@
+package chezmoi
+
+// An Encryption encrypts and decrypts the contents of files. filename is the
+// name of the plaintext, which some encryptions record.
+type Encryption interface {
+	Decrypt(ciphertext []byte) ([]byte, error)
+	Encrypt(filename string, plaintext []byte) ([]byte, error)
+}
@@ -0,0 +1,885 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+const externalName = ".chezmoiexternal.toml"
+
+// External types.
+const (
+	ExternalTypeArchive       = "archive"
+	ExternalTypeFile          = "file"
+	ExternalTypeGitHubRelease = "github-release"
+	ExternalTypeGitRepo       = "git-repo"
+)
+
+// archiveSuffixes are the suffixes of URLs that readArchive can read.
+var archiveSuffixes = []string{
+	".7z",
+	".dmg",
+	".tar",
+	".tar.bz2",
+	".tar.gz",
+	".tar.xz",
+	".tar.zst",
+	".tbz2",
+	".tgz",
+	".txz",
+	".tzst",
+	".zip",
+}
+
+// A FetchURLFunc returns the contents of url, which may be cached for up to
+// refreshPeriod, or indefinitely if refreshPeriod is zero. verify is called on
+// the contents before they are cached or returned.
+type FetchURLFunc func(url string, refreshPeriod time.Duration, verify func([]byte) error) ([]byte, error)
+
+// A ResolveReleaseFunc resolves the release of a github-release external that
+// is not locked to a release with an asset for this machine, and calls SetLock
+// on it.
+type ResolveReleaseFunc func(e *External) error
+
+// An External represents the target state of a file, archive, or git
+// repository that is downloaded from a URL rather than stored in the source
+// directory. Externals are locked to what they first resolve to: the checksum
+// of a file or archive, the commit of a git repository, or the release of a
+// github-release external, whose URL and checksum are set from the release.
+type External struct {
+	sourceName      string
+	targetName      string
+	Type            string
+	URL             string
+	Checksum        string
+	Executable      bool
+	Exact           bool
+	StripComponents int
+	NestedExternals bool
+	RefreshPeriod   time.Duration
+	Repo            string
+	Version         string
+	Asset           string
+	Commit          string
+	Lock            *ExternalLockEntry
+	lock            *ExternalLock
+	writeLock       func() error
+	fetchURL        FetchURLFunc
+	resolveRelease  ResolveReleaseFunc
+	sevenZip        *SevenZip
+	xz              *Xz
+	zstd            *Zstd
+	nested          bool
+	entry           Entry
+	entryErr        error
+}
+
+// An externalConfig is a single entry in a .chezmoiexternal.toml file.
+type externalConfig struct {
+	Type            string `toml:"type"`
+	URL             string `toml:"url"`
+	Checksum        string `toml:"checksum"`
+	Executable      bool   `toml:"executable"`
+	Exact           bool   `toml:"exact"`
+	StripComponents int    `toml:"stripComponents"`
+	NestedExternals bool   `toml:"nestedExternals"`
+	RefreshPeriod   string `toml:"refreshPeriod"`
+	Repo            string `toml:"repo"`
+	Version         string `toml:"version"`
+	Asset           string `toml:"asset"`
+}
+
+type externalConcreteValue struct {
+	Type            string `json:"type" yaml:"type"`
+	SourcePath      string `json:"sourcePath" yaml:"sourcePath"`
+	TargetPath      string `json:"targetPath" yaml:"targetPath"`
+	ExternalType    string `json:"externalType" yaml:"externalType"`
+	URL             string `json:"url" yaml:"url"`
+	Checksum        string `json:"checksum" yaml:"checksum"`
+	Executable      bool   `json:"executable" yaml:"executable"`
+	Exact           bool   `json:"exact" yaml:"exact"`
+	StripComponents int    `json:"stripComponents" yaml:"stripComponents"`
+	RefreshPeriod   string `json:"refreshPeriod" yaml:"refreshPeriod"`
+	Repo            string `json:"repo,omitempty" yaml:"repo,omitempty"`
+	Version         string `json:"version,omitempty" yaml:"version,omitempty"`
+	Asset           string `json:"asset,omitempty" yaml:"asset,omitempty"`
+	Release         string `json:"release,omitempty" yaml:"release,omitempty"`
+	Commit          string `json:"commit,omitempty" yaml:"commit,omitempty"`
+}
+
+// A namedExternal is an external declared in a .chezmoiexternal.toml file,
+// with its target name relative to the directory containing the file.
+type namedExternal struct {
+	relName  string
+	external *External
+}
+
+// A deferredExternal is a .chezmoiexternal.toml file found while populating.
+type deferredExternal struct {
+	path       string
+	sourceName string
+	dns        []string
+}
+
+// An archiveMember is a single member of an archive.
+type archiveMember struct {
+	name     string
+	typeflag byte
+	perm     os.FileMode
+	contents []byte
+	linkname string
+}
+
+// AppendAllEntries appends e to allEntries.
+func (e *External) AppendAllEntries(allEntries []Entry) []Entry {
+	return append(allEntries, e)
+}
+
+// Apply ensures that the state of e's target in fs matches e.
+func (e *External) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
+	if applyOptions.Ignore(e.targetName) {
+		return nil
+	}
+	if e.Type == ExternalTypeGitRepo {
+		return e.applyGitRepo(fs, mutator, applyOptions)
+	}
+	entry, err := e.Entry()
+	if err != nil {
+		return err
+	}
+	// The contents of an external are confirmed as a whole.
+	entryApplyOptions := *applyOptions
+	entryApplyOptions.ConfirmApply = nil
+	return entry.Apply(fs, mutator, follow, &entryApplyOptions)
+}
+
+// ConcreteValue implements Entry.ConcreteValue.
+func (e *External) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(e.targetName) {
+		return nil, nil
+	}
+	return &externalConcreteValue{
+		Type:            "external",
+		SourcePath:      filepath.Join(sourceDir, e.SourceName()),
+		TargetPath:      e.TargetName(),
+		ExternalType:    e.Type,
+		URL:             e.URL,
+		Checksum:        e.Checksum,
+		Executable:      e.Executable,
+		Exact:           e.Exact,
+		StripComponents: e.StripComponents,
+		RefreshPeriod:   e.RefreshPeriod.String(),
+		Repo:            e.Repo,
+		Version:         e.Version,
+		Asset:           e.Asset,
+		Release:         e.Release(),
+		Commit:          e.Commit,
+	}, nil
+}
+
+// Entry returns the target state of e's downloaded file or archive. It is an
+// error to call Entry on a git repository.
+func (e *External) Entry() (Entry, error) {
+	if e.entry == nil && e.entryErr == nil {
+		e.entry, e.entryErr = e.newEntry()
+	}
+	return e.entry, e.entryErr
+}
+
+// Evaluate implements Entry.Evaluate. Externals are only downloaded when they
+// are applied or archived.
+func (e *External) Evaluate(ignore func(string) bool) error {
+	return nil
+}
+
+// Release returns the version of the release that e is locked to, or the empty
+// string if e is not a github-release external or is not locked.
+func (e *External) Release() string {
+	if e.Type != ExternalTypeGitHubRelease || e.Lock == nil {
+		return ""
+	}
+	return e.Lock.Version
+}
+
+// SetLock locks e to entry, which must be of e's type, and writes the lock. It
+// is an error if entry is a release without an asset that matches e's asset
+// pattern.
+func (e *External) SetLock(entry *ExternalLockEntry) error {
+	if e.nested || e.lock == nil {
+		return fmt.Errorf("%s: cannot lock", e.targetName)
+	}
+	if !e.applyLockEntry(entry) {
+		return fmt.Errorf("%s: %s %s: no asset matches %s", e.targetName, entry.Repo, entry.Version, e.Asset)
+	}
+	e.lock.Externals[e.targetName] = entry
+	if e.writeLock == nil {
+		return nil
+	}
+	return e.writeLock()
+}
+
+// SourceName implements Entry.SourceName.
+func (e *External) SourceName() string {
+	return e.sourceName
+}
+
+// TargetName implements Entry.TargetName.
+func (e *External) TargetName() string {
+	return e.targetName
+}
+
+// Verify returns an error if e has a checksum and data does not match it.
+func (e *External) Verify(data []byte) error {
+	if e.Checksum == "" {
+		return nil
+	}
+	contentsSHA256 := sha256.Sum256(data)
+	if got := hex.EncodeToString(contentsSHA256[:]); !strings.EqualFold(got, e.Checksum) {
+		return fmt.Errorf("%s: checksum mismatch, got %s, want %s", e.URL, got, e.Checksum)
+	}
+	return nil
+}
+
+// archive writes e to w. Git repositories are not included.
+func (e *External) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
+	if ignore(e.targetName) || e.Type == ExternalTypeGitRepo {
+		return nil
+	}
+	entry, err := e.Entry()
+	if err != nil {
+		return err
+	}
+	return entry.archive(w, ignore, headerTemplate, umask)
+}
+
+// applyGitRepo clones e's repository if it does not exist. If e is locked then
+// its commit is checked out, otherwise e is locked to the current commit. If e
+// cannot be locked then the repository is pulled if it has not been fetched
+// within e's refresh period.
+func (e *External) applyGitRepo(fs vfs.FS, mutator Mutator, applyOptions *ApplyOptions) error {
+	targetPath := TargetPath(applyOptions.DestDir, e.targetName)
+	switch _, err := fs.Stat(targetPath); {
+	case os.IsNotExist(err):
+		//nolint:gosec
+		if err := mutator.RunCmd(exec.Command("git", "clone", e.URL, targetPath)); err != nil {
+			return err
+		}
+		// In dry run mode the repository is not cloned.
+		if _, err := fs.Stat(targetPath); os.IsNotExist(err) {
+			return nil
+		}
+	case err != nil:
+		return err
+	}
+
+	switch {
+	case e.Commit != "":
+		head, err := gitHead(mutator, targetPath)
+		if err != nil || head == e.Commit {
+			return err
+		}
+		//nolint:gosec
+		if err := mutator.RunCmd(exec.Command("git", "-C", targetPath, "fetch", "--quiet", "origin")); err != nil {
+			return err
+		}
+		//nolint:gosec
+		return mutator.RunCmd(exec.Command("git", "-C", targetPath, "checkout", "--quiet", e.Commit))
+	case !e.nested && e.lock != nil:
+		head, err := gitHead(mutator, targetPath)
+		if err != nil {
+			return err
+		}
+		return e.SetLock(&ExternalLockEntry{
+			Type:   e.Type,
+			URL:    e.URL,
+			Commit: head,
+		})
+	}
+
+	if e.RefreshPeriod == 0 {
+		return nil
+	}
+	info, err := fs.Stat(filepath.Join(targetPath, ".git", "FETCH_HEAD"))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	case time.Since(info.ModTime()) < e.RefreshPeriod:
+		return nil
+	}
+	//nolint:gosec
+	return mutator.RunCmd(exec.Command("git", "-C", targetPath, "pull", "--ff-only"))
+}
+
+// fetch returns the contents of e's URL, resolving e's release first if
+// needed. If e has neither a checksum nor a lock then it is locked to the
+// checksum of the contents.
+func (e *External) fetch() ([]byte, error) {
+	if e.Type == ExternalTypeGitHubRelease && e.URL == "" {
+		if e.resolveRelease == nil {
+			return nil, fmt.Errorf("%s: cannot resolve release of %s", e.targetName, e.Repo)
+		}
+		if err := e.resolveRelease(e); err != nil {
+			return nil, err
+		}
+	}
+	if e.fetchURL == nil {
+		return nil, fmt.Errorf("%s: cannot fetch %s", e.targetName, e.URL)
+	}
+	data, err := e.fetchURL(e.URL, e.RefreshPeriod, e.Verify)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", e.targetName, err)
+	}
+	if e.Checksum == "" && e.Lock == nil && !e.nested && e.lock != nil {
+		dataSHA256 := sha256.Sum256(data)
+		if err := e.SetLock(&ExternalLockEntry{
+			Type:     e.Type,
+			URL:      e.URL,
+			Checksum: hex.EncodeToString(dataSHA256[:]),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// newEntry downloads e and returns its target state.
+func (e *External) newEntry() (Entry, error) {
+	data, err := e.fetch()
+	if err != nil {
+		return nil, err
+	}
+	externalType := e.Type
+	if externalType == ExternalTypeGitHubRelease {
+		externalType = ExternalTypeFile
+		if hasArchiveSuffix(e.URL) {
+			externalType = ExternalTypeArchive
+		}
+	}
+	switch externalType {
+	case ExternalTypeArchive:
+		members, err := e.readArchive(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.targetName, err)
+		}
+		return e.newArchiveDir(members)
+	case ExternalTypeFile:
+		perm := os.FileMode(0666)
+		if e.Executable {
+			perm = 0777
+		}
+		return &File{
+			sourceName: e.sourceName,
+			targetName: e.targetName,
+			Empty:      true,
+			Perm:       perm,
+			contents:   data,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%s: %s: unknown external type", e.targetName, e.Type)
+	}
+}
+
+// newArchiveDir returns a Dir containing members. If e.NestedExternals is set
+// and e was not itself declared in an archive, any .chezmoiexternal.toml files
+// in the archive are not included as files but instead add the externals that
+// they declare, so nesting is limited to one level.
+func (e *External) newArchiveDir(members []archiveMember) (*Dir, error) {
+	root := newDir(e.sourceName, e.targetName, e.Exact, 0777)
+	type nestedExternalsFile struct {
+		dirComponents []string
+		contents      []byte
+	}
+	var nestedExternalsFiles []nestedExternalsFile
+	for _, member := range members {
+		components := strings.Split(normalizeName(member.name), "/")
+		if len(components) <= e.StripComponents {
+			continue
+		}
+		components = components[e.StripComponents:]
+		dir, err := e.archiveDir(root, components[:len(components)-1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", e.targetName, member.name, err)
+		}
+		name := components[len(components)-1]
+		targetName := filepath.Join(e.targetName, filepath.Join(components...))
+		switch member.typeflag {
+		case tar.TypeDir:
+			if entry, ok := dir.Entries[name]; ok {
+				if existingDir, ok := entry.(*Dir); ok {
+					existingDir.Perm = member.perm
+					continue
+				}
+				return nil, fmt.Errorf("%s: %s: not a directory", e.targetName, member.name)
+			}
+			dir.Entries[name] = newDir(e.sourceName, targetName, e.Exact, member.perm)
+		case tar.TypeReg:
+			if name == externalName && e.NestedExternals && !e.nested {
+				nestedExternalsFiles = append(nestedExternalsFiles, nestedExternalsFile{
+					dirComponents: components[:len(components)-1],
+					contents:      member.contents,
+				})
+				continue
+			}
+			dir.Entries[name] = &File{
+				sourceName: e.sourceName,
+				targetName: targetName,
+				Empty:      true,
+				Perm:       member.perm,
+				contents:   member.contents,
+			}
+		case tar.TypeSymlink:
+			dir.Entries[name] = &Symlink{
+				sourceName: e.sourceName,
+				targetName: targetName,
+				linkname:   member.linkname,
+			}
+		}
+	}
+
+	for _, nestedExternalsFile := range nestedExternalsFiles {
+		externalPath := filepath.Join(e.targetName, filepath.Join(nestedExternalsFile.dirComponents...), externalName)
+		namedExternals, err := parseExternals(externalPath, nestedExternalsFile.contents)
+		if err != nil {
+			return nil, err
+		}
+		for _, namedExternal := range namedExternals {
+			components := append(append([]string{}, nestedExternalsFile.dirComponents...), strings.Split(namedExternal.relName, "/")...)
+			dir, err := e.archiveDir(root, components[:len(components)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", externalPath, namedExternal.relName, err)
+			}
+			name := components[len(components)-1]
+			if _, ok := dir.Entries[name]; ok {
+				return nil, fmt.Errorf("%s: %s: conflicts with archive member", externalPath, namedExternal.relName)
+			}
+			nested := namedExternal.external
+			if nested.Type == ExternalTypeGitHubRelease {
+				return nil, fmt.Errorf("%s: %s: github-release externals cannot be nested", externalPath, namedExternal.relName)
+			}
+			nested.sourceName = e.sourceName
+			nested.targetName = filepath.Join(e.targetName, filepath.Join(components...))
+			nested.fetchURL = e.fetchURL
+			nested.sevenZip = e.sevenZip
+			nested.xz = e.xz
+			nested.zstd = e.zstd
+			nested.nested = true
+			dir.Entries[name] = nested
+		}
+	}
+
+	return root, nil
+}
+
+// applyLock applies e's entry in its lock, if there is one that matches e. A
+// lock entry matches if it has e's type and e's URL or, for github-release
+// externals, e's repo.
+func (e *External) applyLock() {
+	if e.nested || e.lock == nil {
+		return
+	}
+	entry, ok := e.lock.Externals[e.targetName]
+	if !ok || entry.Type != e.Type {
+		return
+	}
+	switch e.Type {
+	case ExternalTypeArchive, ExternalTypeFile:
+		// A checksum in .chezmoiexternal.toml takes precedence.
+		if entry.URL != e.URL || e.Checksum != "" {
+			return
+		}
+	case ExternalTypeGitHubRelease:
+		if entry.Repo != e.Repo {
+			return
+		}
+	case ExternalTypeGitRepo:
+		if entry.URL != e.URL {
+			return
+		}
+	}
+	// A locked release that has no asset for this machine is resolved when
+	// it is fetched.
+	e.applyLockEntry(entry)
+}
+
+// applyLockEntry locks e to entry and returns true, unless entry is a release
+// that has no asset that matches e's asset pattern. Locked externals are not
+// refreshed.
+func (e *External) applyLockEntry(entry *ExternalLockEntry) bool {
+	e.Lock = entry
+	switch e.Type {
+	case ExternalTypeArchive, ExternalTypeFile:
+		e.Checksum = entry.Checksum
+	case ExternalTypeGitHubRelease:
+		_, asset := entry.matchAsset(e.Asset)
+		if asset == nil {
+			return false
+		}
+		e.URL = asset.URL
+		e.Checksum = asset.Checksum
+	case ExternalTypeGitRepo:
+		e.Commit = entry.Commit
+	}
+	e.RefreshPeriod = 0
+	return true
+}
+
+// archiveDir returns the directory in root with dirComponents, creating it and
+// its parents if needed.
+func (e *External) archiveDir(root *Dir, dirComponents []string) (*Dir, error) {
+	dir := root
+	for i, component := range dirComponents {
+		entry, ok := dir.Entries[component]
+		if !ok {
+			entry = newDir(e.sourceName, filepath.Join(e.targetName, filepath.Join(dirComponents[:i+1]...)), e.Exact, 0777)
+			dir.Entries[component] = entry
+		}
+		if dir, ok = entry.(*Dir); !ok {
+			return nil, errors.New("not a directory")
+		}
+	}
+	return dir, nil
+}
+
+// addExternals adds the externals in the .chezmoiexternal.toml file at
+// externalPath, in the directory with target names dns, to ts. Like
+// .chezmoiignore, the file is executed as a template.
+func (ts *TargetState) addExternals(fs vfs.FS, externalPath, sourceName string, dns []string) error {
+	data, err := ts.executeTemplate(fs, externalPath)
+	if err != nil {
+		return err
+	}
+	namedExternals, err := parseExternals(externalPath, data)
+	if err != nil {
+		return err
+	}
+	for _, namedExternal := range namedExternals {
+		components := append(append([]string{}, dns...), strings.Split(namedExternal.relName, "/")...)
+		entries, err := ts.findOrCreateEntries(components[:len(components)-1])
+		if err != nil {
+			return err
+		}
+		if entry, ok := entries[components[len(components)-1]]; ok {
+			return fmt.Errorf("%s: %s: conflicts with %s", externalPath, namedExternal.relName, entry.SourceName())
+		}
+		external := namedExternal.external
+		external.sourceName = sourceName
+		external.targetName = filepath.Join(components...)
+		external.fetchURL = ts.FetchURL
+		external.resolveRelease = ts.ResolveRelease
+		external.lock = ts.ExternalLock
+		external.writeLock = ts.WriteExternalLock
+		external.sevenZip = ts.SevenZip
+		external.xz = ts.Xz
+		external.zstd = ts.Zstd
+		external.applyLock()
+		entries[components[len(components)-1]] = external
+	}
+	return nil
+}
+
+// parseExternals returns the externals declared in data, the contents of the
+// .chezmoiexternal.toml file at externalPath, sorted by name.
+func parseExternals(externalPath string, data []byte) ([]namedExternal, error) {
+	var externalConfigs map[string]externalConfig
+	if err := toml.Unmarshal(data, &externalConfigs); err != nil {
+		return nil, fmt.Errorf("%s: %w", externalPath, err)
+	}
+	names := make([]string, 0, len(externalConfigs))
+	for name := range externalConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	namedExternals := make([]namedExternal, 0, len(names))
+	for _, name := range names {
+		ec := externalConfigs[name]
+		relName, err := cleanArchiveMemberName(filepath.ToSlash(normalizeName(name)))
+		if err != nil || relName == "" {
+			return nil, fmt.Errorf("%s: %s: invalid target name", externalPath, name)
+		}
+		switch ec.Type {
+		case ExternalTypeArchive, ExternalTypeFile:
+			if ec.URL == "" {
+				return nil, fmt.Errorf("%s: %s: no url", externalPath, name)
+			}
+		case ExternalTypeGitRepo:
+			switch {
+			case ec.URL == "":
+				return nil, fmt.Errorf("%s: %s: no url", externalPath, name)
+			case ec.Checksum != "":
+				return nil, fmt.Errorf("%s: %s: checksum not allowed, git-repo externals are locked to a commit", externalPath, name)
+			}
+		case ExternalTypeGitHubRelease:
+			switch {
+			case ec.URL != "":
+				return nil, fmt.Errorf("%s: %s: url not allowed, use repo and asset", externalPath, name)
+			case len(strings.Split(ec.Repo, "/")) != 2:
+				return nil, fmt.Errorf("%s: %s: %q: repo must be owner/name", externalPath, name, ec.Repo)
+			case ec.Asset == "":
+				return nil, fmt.Errorf("%s: %s: no asset", externalPath, name)
+			}
+			if _, err := path.Match(ec.Asset, ""); err != nil {
+				return nil, fmt.Errorf("%s: %s: asset: %w", externalPath, name, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s: %s: %s: unknown external type", externalPath, name, ec.Type)
+		}
+		var refreshPeriod time.Duration
+		if ec.RefreshPeriod != "" {
+			if refreshPeriod, err = time.ParseDuration(ec.RefreshPeriod); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", externalPath, name, err)
+			}
+		}
+		namedExternals = append(namedExternals, namedExternal{
+			relName: relName,
+			external: &External{
+				Type:            ec.Type,
+				URL:             ec.URL,
+				Checksum:        ec.Checksum,
+				Executable:      ec.Executable,
+				Exact:           ec.Exact,
+				StripComponents: ec.StripComponents,
+				NestedExternals: ec.NestedExternals,
+				RefreshPeriod:   refreshPeriod,
+				Repo:            ec.Repo,
+				Version:         ec.Version,
+				Asset:           ec.Asset,
+			},
+		})
+	}
+	return namedExternals, nil
+}
+
+// readArchive returns the members of the archive in data. The archive format
+// is determined from the suffix of e's URL. zstd, xz, and 7z archives are read
+// with the zstd, xz, and 7z commands, and dmg disk images are read with
+// hdiutil.
+func (e *External) readArchive(data []byte) ([]archiveMember, error) {
+	url := e.URL
+	var r io.Reader = bytes.NewReader(data)
+	switch {
+	case strings.HasSuffix(url, ".tar.gz") || strings.HasSuffix(url, ".tgz"):
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		r = gzipReader
+	case strings.HasSuffix(url, ".tar.bz2") || strings.HasSuffix(url, ".tbz2"):
+		r = bzip2.NewReader(r)
+	case strings.HasSuffix(url, ".tar.xz") || strings.HasSuffix(url, ".txz"):
+		if e.xz == nil {
+			return nil, fmt.Errorf("%s: xz not configured", url)
+		}
+		tarData, err := e.xz.Decompress(data)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(tarData)
+	case strings.HasSuffix(url, ".tar.zst") || strings.HasSuffix(url, ".tzst"):
+		if e.zstd == nil {
+			return nil, fmt.Errorf("%s: zstd not configured", url)
+		}
+		tarData, err := e.zstd.Decompress(data)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(tarData)
+	case strings.HasSuffix(url, ".tar"):
+	case strings.HasSuffix(url, ".zip"):
+		return readZipArchive(data)
+	case strings.HasSuffix(url, ".7z"):
+		if e.sevenZip == nil {
+			return nil, fmt.Errorf("%s: 7z not configured", url)
+		}
+		return e.sevenZip.readArchive(data)
+	case strings.HasSuffix(url, ".dmg"):
+		return readDMGArchive(data)
+	default:
+		return nil, fmt.Errorf("%s: unknown archive format", url)
+	}
+	var members []archiveMember
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return members, nil
+		} else if err != nil {
+			return nil, err
+		}
+		name, err := cleanArchiveMemberName(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		member := archiveMember{
+			name:     name,
+			typeflag: header.Typeflag,
+			perm:     os.FileMode(header.Mode).Perm(),
+			linkname: header.Linkname,
+		}
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeSymlink:
+		case tar.TypeReg:
+			if member.contents, err = ioutil.ReadAll(tarReader); err != nil {
+				return nil, err
+			}
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			return nil, fmt.Errorf("%s: unsupported typeflag '%c'", header.Name, header.Typeflag)
+		}
+		if name == "" {
+			continue
+		}
+		members = append(members, member)
+	}
+}
+
+// gitHead returns the commit checked out in the git repository at path.
+func gitHead(mutator Mutator, path string) (string, error) {
+	//nolint:gosec
+	output, err := mutator.IdempotentCmdOutput(exec.Command("git", "-C", path, "rev-parse", "HEAD"))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// hasArchiveSuffix returns true if url has the suffix of an archive format that
+// readArchive can read.
+func hasArchiveSuffix(url string) bool {
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(url, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readZipArchive returns the members of the zip archive in data.
+func readZipArchive(data []byte) ([]archiveMember, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	var members []archiveMember
+	for _, zipFile := range zipReader.File {
+		name, err := cleanArchiveMemberName(zipFile.Name)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			continue
+		}
+		info := zipFile.FileInfo()
+		member := archiveMember{
+			name: name,
+			perm: info.Mode().Perm(),
+		}
+		rc, err := zipFile.Open()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case info.IsDir():
+			member.typeflag = tar.TypeDir
+		case info.Mode()&os.ModeSymlink != 0:
+			member.typeflag = tar.TypeSymlink
+			member.linkname = string(contents)
+		case info.Mode().IsRegular():
+			member.typeflag = tar.TypeReg
+			member.contents = contents
+		default:
+			return nil, fmt.Errorf("%s: unsupported file type", zipFile.Name)
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// readDirArchive returns the members of the archive extracted to dir.
+// Metadata that macOS creates on disk images is skipped.
+func readDirArchive(dir string) ([]archiveMember, error) {
+	var members []archiveMember
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		switch relPath {
+		case ".DS_Store", ".Trashes", ".fseventsd":
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		member := archiveMember{
+			name: filepath.ToSlash(relPath),
+			perm: info.Mode().Perm(),
+		}
+		switch {
+		case info.IsDir():
+			member.typeflag = tar.TypeDir
+		case info.Mode()&os.ModeSymlink != 0:
+			member.typeflag = tar.TypeSymlink
+			if member.linkname, err = os.Readlink(path); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			member.typeflag = tar.TypeReg
+			if member.contents, err = ioutil.ReadFile(path); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: unsupported file type", relPath)
+		}
+		members = append(members, member)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// cleanArchiveMemberName returns name cleaned, or an error if name would
+// escape the archive's root.
+func cleanArchiveMemberName(name string) (string, error) {
+	cleanName := path.Clean(name)
+	switch {
+	case cleanName == ".":
+		return "", nil
+	case path.IsAbs(cleanName) || cleanName == ".." || strings.HasPrefix(cleanName, "../"):
+		return "", fmt.Errorf("%s: invalid archive member name", name)
+	default:
+		return cleanName, nil
+	}
+}
@@ -0,0 +1,38 @@
+package chezmoi
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// readDMGArchive returns the members of the disk image in data, which is
+// mounted read-only with hdiutil while its contents are read.
+func readDMGArchive(data []byte) (members []archiveMember, err error) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-dmg")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+	imagePath := filepath.Join(tempDir, "image.dmg")
+	if err := ioutil.WriteFile(imagePath, data, 0600); err != nil {
+		return nil, err
+	}
+	mountPoint := filepath.Join(tempDir, "mnt")
+	//nolint:gosec
+	attachCmd := exec.Command("hdiutil", "attach", "-nobrowse", "-noautoopen", "-noverify", "-readonly", "-quiet", "-mountpoint", mountPoint, imagePath)
+	attachCmd.Stderr = os.Stderr
+	if err := attachCmd.Run(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		//nolint:gosec
+		detachCmd := exec.Command("hdiutil", "detach", "-quiet", mountPoint)
+		detachCmd.Stderr = os.Stderr
+		if detachErr := detachCmd.Run(); err == nil {
+			err = detachErr
+		}
+	}()
+	return readDirArchive(mountPoint)
+}
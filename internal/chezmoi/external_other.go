@@ -0,0 +1,11 @@
+// +build !darwin
+
+package chezmoi
+
+import "errors"
+
+// readDMGArchive returns an error because disk images can only be read on
+// macOS.
+func readDMGArchive(data []byte) ([]archiveMember, error) {
+	return nil, errors.New("dmg archives are only supported on macOS")
+}
@@ -0,0 +1,518 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestExternal(t *testing.T) {
+	archiveData := newTestTarGz(t, []*tar.Header{
+		{Name: "pkg-1.0/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "pkg-1.0/bin/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "pkg-1.0/bin/pkg", Typeflag: tar.TypeReg, Mode: 0755, Size: int64(len("#!/bin/sh\n"))},
+		{Name: "pkg-1.0/README", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len("pkg\n"))},
+		{Name: "pkg-1.0/link", Typeflag: tar.TypeSymlink, Linkname: "README"},
+	}, [][]byte{nil, nil, []byte("#!/bin/sh\n"), []byte("pkg\n"), nil})
+	pluginExternals := "[\"nested\"]\ntype = \"file\"\nurl = \"https://example.com/file\"\n"
+	pluginArchiveData := newTestTarGz(t, []*tar.Header{
+		{Name: ".chezmoiexternal.toml", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(pluginExternals))},
+		{Name: "plugin.zsh", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len("plugin\n"))},
+	}, [][]byte{[]byte(pluginExternals), []byte("plugin\n")})
+	frameworkExternals := "" +
+		"[\"plugins/plugin\"]\n" +
+		"type = \"archive\"\n" +
+		"url = \"https://example.com/plugin.tar.gz\"\n" +
+		"[\"themes/theme.zsh\"]\n" +
+		"type = \"file\"\n" +
+		"url = \"https://example.com/file\"\n"
+	frameworkArchiveData := newTestTarGz(t, []*tar.Header{
+		{Name: "framework/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "framework/framework.sh", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len("framework\n"))},
+		{Name: "framework/custom/.chezmoiexternal.toml", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(frameworkExternals))},
+	}, [][]byte{nil, []byte("framework\n"), []byte(frameworkExternals)})
+	archiveSHA256 := sha256.Sum256(archiveData)
+	fileData := []byte("file\n")
+	fileSHA256 := sha256.Sum256(fileData)
+	urls := map[string][]byte{
+		"https://example.com/file":             fileData,
+		"https://example.com/framework.tar.gz": frameworkArchiveData,
+		"https://example.com/pkg-1.0.tar.gz":   archiveData,
+		"https://example.com/plugin.tar.gz":    pluginArchiveData,
+	}
+	fetchURL := func(url string, refreshPeriod time.Duration, verify func([]byte) error) ([]byte, error) {
+		data, ok := urls[url]
+		if !ok {
+			return nil, fmt.Errorf("%s: not found", url)
+		}
+		if err := verify(data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	for _, tc := range []struct {
+		name            string
+		root            interface{}
+		wantPopulateErr bool
+		wantApplyErr    bool
+		tests           []vfst.Test
+	}{
+		{
+			name: "file",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_dir/.chezmoiexternal.toml": fmt.Sprintf(`[file]
+type = "file"
+url = "https://example.com/file"
+checksum = "%s"
+executable = true
+`, hex.EncodeToString(fileSHA256[:])),
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.dir/file",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0755),
+					vfst.TestContents(fileData),
+				),
+			},
+		},
+		{
+			name: "archive",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": `[".pkg"]
+type = "archive"
+url = "https://example.com/pkg-1.0.tar.gz"
+stripComponents = 1
+refreshPeriod = "168h"
+`,
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.pkg",
+					vfst.TestIsDir,
+				),
+				vfst.TestPath("/home/user/.pkg/bin/pkg",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0755),
+					vfst.TestContentsString("#!/bin/sh\n"),
+				),
+				vfst.TestPath("/home/user/.pkg/README",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0644),
+					vfst.TestContentsString("pkg\n"),
+				),
+				vfst.TestPath("/home/user/.pkg/link",
+					vfst.TestModeType(os.ModeSymlink),
+					vfst.TestSymlinkTarget("README"),
+				),
+			},
+		},
+		{
+			name: "subdirectory",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/private_dot_config/nvim/.chezmoiexternal.toml": "" +
+					"[\"autoload/plug.vim\"]\n" +
+					"type = \"file\"\n" +
+					"url = \"https://example.com/file\"\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.config/nvim/autoload/plug.vim",
+					vfst.TestModeIsRegular,
+					vfst.TestContents(fileData),
+				),
+			},
+		},
+		{
+			name: "nested",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": "" +
+					"[\".framework\"]\n" +
+					"type = \"archive\"\n" +
+					"url = \"https://example.com/framework.tar.gz\"\n" +
+					"stripComponents = 1\n" +
+					"nestedExternals = true\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.framework/framework.sh",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("framework\n"),
+				),
+				vfst.TestPath("/home/user/.framework/custom/.chezmoiexternal.toml",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.framework/custom/themes/theme.zsh",
+					vfst.TestModeIsRegular,
+					vfst.TestContents(fileData),
+				),
+				vfst.TestPath("/home/user/.framework/custom/plugins/plugin/plugin.zsh",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("plugin\n"),
+				),
+				vfst.TestPath("/home/user/.framework/custom/plugins/plugin/.chezmoiexternal.toml",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString(pluginExternals),
+				),
+				vfst.TestPath("/home/user/.framework/custom/plugins/plugin/nested",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name: "nested_not_enabled",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": "" +
+					"[\".framework\"]\n" +
+					"type = \"archive\"\n" +
+					"url = \"https://example.com/framework.tar.gz\"\n" +
+					"stripComponents = 1\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.framework/framework.sh",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("framework\n"),
+				),
+				vfst.TestPath("/home/user/.framework/custom/.chezmoiexternal.toml",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString(frameworkExternals),
+				),
+				vfst.TestPath("/home/user/.framework/custom/themes",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.framework/custom/plugins",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name: "github_release",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiexternal.toml": `[".pkg"]
+type = "github-release"
+repo = "owner/pkg"
+asset = "pkg-*.tar.gz"
+stripComponents = 1
+`,
+					".chezmoiexternal.lock": fmt.Sprintf(`{
+  "externals": {
+    ".pkg": {
+      "type": "github-release",
+      "repo": "owner/pkg",
+      "version": "v1.0",
+      "assets": {
+        "pkg-1.0.tar.gz": {
+          "url": "https://example.com/pkg-1.0.tar.gz",
+          "checksum": "%s"
+        }
+      }
+    }
+  }
+}
+`, hex.EncodeToString(archiveSHA256[:])),
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.pkg/bin/pkg",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0755),
+					vfst.TestContents([]byte("#!/bin/sh\n")),
+				),
+			},
+		},
+		{
+			name: "github_release_unlocked",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": "[file]\ntype = \"github-release\"\nrepo = \"owner/pkg\"\nasset = \"file\"\n",
+			},
+			wantApplyErr: true,
+		},
+		{
+			name: "github_release_invalid_repo",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": "[file]\ntype = \"github-release\"\nrepo = \"pkg\"\nasset = \"file\"\n",
+			},
+			wantPopulateErr: true,
+		},
+		{
+			name: "locked_checksum_mismatch",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiexternal.toml": "[file]\ntype = \"file\"\nurl = \"https://example.com/file\"\n",
+					".chezmoiexternal.lock": `{"externals": {"file": {"type": "file", "url": "https://example.com/file", "checksum": "0000000000000000000000000000000000000000000000000000000000000000"}}}`,
+				},
+			},
+			wantApplyErr: true,
+		},
+		{
+			name: "lock_url_changed",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiexternal.toml": "[file]\ntype = \"file\"\nurl = \"https://example.com/file\"\n",
+					".chezmoiexternal.lock": `{"externals": {"file": {"type": "file", "url": "https://example.com/old", "checksum": "0000000000000000000000000000000000000000000000000000000000000000"}}}`,
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/file",
+					vfst.TestContents(fileData),
+				),
+			},
+		},
+		{
+			name: "checksum_mismatch",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": `[file]
+type = "file"
+url = "https://example.com/file"
+checksum = "0000000000000000000000000000000000000000000000000000000000000000"
+`,
+			},
+			wantApplyErr: true,
+		},
+		{
+			name: "git_repo_checksum",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": `[repo]
+type = "git-repo"
+url = "https://example.com/repo.git"
+checksum = "0000000000000000000000000000000000000000000000000000000000000000"
+`,
+			},
+			wantPopulateErr: true,
+		},
+		{
+			name: "conflict",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiexternal.toml": "[file]\ntype = \"file\"\nurl = \"https://example.com/file\"\n",
+					"file":                  "file\n",
+				},
+			},
+			wantPopulateErr: true,
+		},
+		{
+			name: "unknown_type",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": "[file]\ntype = \"unknown\"\nurl = \"https://example.com/file\"\n",
+			},
+			wantPopulateErr: true,
+		},
+		{
+			name: "escape",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": "[\"../file\"]\ntype = \"file\"\nurl = \"https://example.com/file\"\n",
+			},
+			wantPopulateErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+				WithFetchURL(fetchURL),
+				WithUmask(022),
+			)
+			err = ts.Populate(fs, nil)
+			if tc.wantPopulateErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			applyOptions := &ApplyOptions{
+				DestDir: ts.DestDir,
+				Ignore:  ts.TargetIgnore.Match,
+				Stdout:  os.Stdout,
+				Umask:   022,
+			}
+			err = ts.Apply(fs, NewFSMutator(fs), false, applyOptions)
+			if tc.wantApplyErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			vfst.RunTests(t, fs, "", tc.tests)
+		})
+	}
+}
+
+func TestExternalLock(t *testing.T) {
+	fileData := []byte("file\n")
+	fileSHA256 := sha256.Sum256(fileData)
+	fetchURL := func(url string, refreshPeriod time.Duration, verify func([]byte) error) ([]byte, error) {
+		if err := verify(fileData); err != nil {
+			return nil, err
+		}
+		return fileData, nil
+	}
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoiexternal.toml": "" +
+			"[file]\n" +
+			"type = \"file\"\n" +
+			"url = \"https://example.com/file\"\n" +
+			"[pinned]\n" +
+			"type = \"file\"\n" +
+			"url = \"https://example.com/file\"\n" +
+			"checksum = \"" + hex.EncodeToString(fileSHA256[:]) + "\"\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	externalLock := NewExternalLock()
+	writes := 0
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithExternalLock(externalLock, func() error {
+			writes++
+			return nil
+		}),
+		WithFetchURL(fetchURL),
+		WithUmask(022),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Apply(fs, NewFSMutator(fs), false, &ApplyOptions{
+		DestDir: ts.DestDir,
+		Ignore:  ts.TargetIgnore.Match,
+		Umask:   022,
+	}))
+	assert.Equal(t, 1, writes)
+	assert.Equal(t, map[string]*ExternalLockEntry{
+		"file": {
+			Type:     ExternalTypeFile,
+			URL:      "https://example.com/file",
+			Checksum: hex.EncodeToString(fileSHA256[:]),
+		},
+	}, externalLock.Externals)
+}
+
+func TestExternalReadArchive(t *testing.T) {
+	tarData := newTestTar(t, []*tar.Header{
+		{Name: "pkg-1.0/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "pkg-1.0/README", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len("pkg\n"))},
+	}, [][]byte{nil, []byte("pkg\n")})
+	expectedMembers := []archiveMember{
+		{name: "pkg-1.0", typeflag: tar.TypeDir, perm: 0755},
+		{name: "pkg-1.0/README", typeflag: tar.TypeReg, perm: 0644, contents: []byte("pkg\n")},
+	}
+
+	for _, tc := range []struct {
+		suffix  string
+		command string
+		args    []string
+	}{
+		{
+			suffix:  ".tar.xz",
+			command: "xz",
+			args:    []string{"--compress", "--stdout"},
+		},
+		{
+			suffix:  ".txz",
+			command: "xz",
+			args:    []string{"--compress", "--stdout"},
+		},
+		{
+			suffix:  ".tar.zst",
+			command: "zstd",
+			args:    []string{"--quiet", "--stdout"},
+		},
+		{
+			suffix:  ".tzst",
+			command: "zstd",
+			args:    []string{"--quiet", "--stdout"},
+		},
+	} {
+		t.Run(tc.suffix, func(t *testing.T) {
+			command, err := exec.LookPath(tc.command)
+			if err != nil {
+				t.Skip(tc.command + " not found in $PATH")
+			}
+			data, err := runFilter(command, tarData, tc.args...)
+			require.NoError(t, err)
+			e := &External{
+				URL:  "https://example.com/pkg-1.0" + tc.suffix,
+				xz:   &Xz{Command: command},
+				zstd: &Zstd{Command: command},
+			}
+			actualMembers, err := e.readArchive(data)
+			require.NoError(t, err)
+			assert.Equal(t, expectedMembers, actualMembers)
+		})
+	}
+
+	t.Run("not_configured", func(t *testing.T) {
+		for _, suffix := range []string{".7z", ".tar.xz", ".tar.zst"} {
+			e := &External{
+				URL: "https://example.com/pkg-1.0" + suffix,
+			}
+			_, err := e.readArchive(nil)
+			assert.Error(t, err)
+		}
+	})
+}
+
+func TestReadDirArchive(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-external")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "pkg-1.0", ".fseventsd"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "pkg-1.0", "README"), []byte("pkg\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, ".DS_Store"), nil, 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, ".fseventsd"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, ".fseventsd", "log"), nil, 0644))
+
+	members, err := readDirArchive(tempDir)
+	require.NoError(t, err)
+	var names []string
+	for _, member := range members {
+		names = append(names, member.name)
+	}
+	assert.Equal(t, []string{"pkg-1.0", "pkg-1.0/.fseventsd", "pkg-1.0/README"}, names)
+	assert.Equal(t, []byte("pkg\n"), members[2].contents)
+}
+
+func newTestTar(t *testing.T, headers []*tar.Header, contents [][]byte) []byte {
+	t.Helper()
+	b := &bytes.Buffer{}
+	tarWriter := tar.NewWriter(b)
+	for i, header := range headers {
+		require.NoError(t, tarWriter.WriteHeader(header))
+		if contents[i] != nil {
+			_, err := tarWriter.Write(contents[i])
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tarWriter.Close())
+	return b.Bytes()
+}
+
+func newTestTarGz(t *testing.T, headers []*tar.Header, contents [][]byte) []byte {
+	t.Helper()
+	b := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(b)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for i, header := range headers {
+		require.NoError(t, tarWriter.WriteHeader(header))
+		if contents[i] != nil {
+			_, err := tarWriter.Write(contents[i])
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	return b.Bytes()
+}
@@ -0,0 +1,104 @@
+package chezmoi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// ExternalLockName is the name of the file in the root of the source directory
+// that records what externals and network-derived template values resolved to,
+// so that applies are reproducible. It is not part of the source state.
+const ExternalLockName = ".chezmoiexternal.lock"
+
+// An ExternalLock records the resolved state of externals, keyed by target
+// name, and the values of template functions that fetch data from the
+// network, keyed by function name and argument.
+type ExternalLock struct {
+	Externals map[string]*ExternalLockEntry `json:"externals"`
+	Values    map[string]string             `json:"values,omitempty"`
+}
+
+// An ExternalLockEntry is the resolved state of a single external. Which fields
+// are set depends on the type of the external: file and archive externals
+// record the checksum of their URL's contents, git-repo externals record a
+// commit, and github-release externals record a release. Assets only contains
+// the assets of a release that have been used, which may differ between
+// machines.
+type ExternalLockEntry struct {
+	Type     string                        `json:"type"`
+	URL      string                        `json:"url,omitempty"`
+	Checksum string                        `json:"checksum,omitempty"`
+	Commit   string                        `json:"commit,omitempty"`
+	Repo     string                        `json:"repo,omitempty"`
+	Version  string                        `json:"version,omitempty"`
+	Assets   map[string]*ExternalLockAsset `json:"assets,omitempty"`
+}
+
+// An ExternalLockAsset is a single asset of a locked release.
+type ExternalLockAsset struct {
+	URL      string `json:"url"`
+	Checksum string `json:"checksum"`
+}
+
+// NewExternalLock returns a new empty ExternalLock.
+func NewExternalLock() *ExternalLock {
+	return &ExternalLock{
+		Externals: make(map[string]*ExternalLockEntry),
+		Values:    make(map[string]string),
+	}
+}
+
+// ReadExternalLock returns the external lock in sourceDir in fs. If there is no
+// lock file then it returns an empty lock.
+func ReadExternalLock(fs vfs.FS, sourceDir string) (*ExternalLock, error) {
+	lock := NewExternalLock()
+	data, err := fs.ReadFile(filepath.Join(sourceDir, ExternalLockName))
+	switch {
+	case os.IsNotExist(err):
+		return lock, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", ExternalLockName, err)
+	}
+	if lock.Externals == nil {
+		lock.Externals = make(map[string]*ExternalLockEntry)
+	}
+	if lock.Values == nil {
+		lock.Values = make(map[string]string)
+	}
+	return lock, nil
+}
+
+// Write writes l to sourceDir using mutator.
+func (l *ExternalLock) Write(mutator Mutator, sourceDir string, umask os.FileMode) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return mutator.WriteFile(filepath.Join(sourceDir, ExternalLockName), data, 0666&^umask, nil)
+}
+
+// matchAsset returns the name and asset of the first asset in e, in name order,
+// that matches pattern.
+func (e *ExternalLockEntry) matchAsset(pattern string) (string, *ExternalLockAsset) {
+	names := make([]string, 0, len(e.Assets))
+	for name := range e.Assets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ok, _ := path.Match(pattern, name); ok {
+			return name, e.Assets[name]
+		}
+	}
+	return "", nil
+}
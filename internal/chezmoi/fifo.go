@@ -0,0 +1,102 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A FIFO represents the target state of a named pipe. FIFOs are only applied
+// if ApplyOptions.FIFOs is set.
+type FIFO struct {
+	sourceName string
+	targetName string
+	Perm       os.FileMode
+}
+
+type fifoConcreteValue struct {
+	Type       string `json:"type" yaml:"type"`
+	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
+	TargetPath string `json:"targetPath" yaml:"targetPath"`
+	Perm       int    `json:"perm" yaml:"perm"`
+}
+
+// AppendAllEntries appends all f to allEntries.
+func (f *FIFO) AppendAllEntries(allEntries []Entry) []Entry {
+	return append(allEntries, f)
+}
+
+// Apply ensures that the state of f's target in fs matches f.
+func (f *FIFO) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
+	if applyOptions.Ignore(f.targetName) {
+		return nil
+	}
+	targetPath := TargetPath(applyOptions.DestDir, f.targetName)
+	if !applyOptions.FIFOs {
+		return fmt.Errorf("%s: FIFOs are not enabled, set fifos = true in your config file", targetPath)
+	}
+	info, err := fs.Lstat(targetPath)
+	switch {
+	case err == nil && info.Mode()&os.ModeType == os.ModeNamedPipe:
+		if info.Mode().Perm() != f.Perm&^applyOptions.Umask {
+			return mutator.Chmod(targetPath, f.Perm&^applyOptions.Umask)
+		}
+		return nil
+	case err == nil:
+		if err := mutator.RemoveAll(targetPath); err != nil {
+			return err
+		}
+	case os.IsNotExist(err):
+	default:
+		return err
+	}
+	return mutator.Mkfifo(targetPath, f.Perm&^applyOptions.Umask)
+}
+
+// ConcreteValue implements Entry.ConcreteValue.
+func (f *FIFO) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(f.targetName) {
+		return nil, nil
+	}
+	return &fifoConcreteValue{
+		Type:       "fifo",
+		SourcePath: filepath.Join(sourceDir, f.SourceName()),
+		TargetPath: f.TargetName(),
+		Perm:       int(f.Perm &^ umask),
+	}, nil
+}
+
+// Evaluate implements Entry.Evaluate.
+func (f *FIFO) Evaluate(ignore func(string) bool) error {
+	return nil
+}
+
+// Private returns true if f is private.
+func (f *FIFO) Private() bool {
+	return f.Perm&077 == 0
+}
+
+// SourceName implements Entry.SourceName.
+func (f *FIFO) SourceName() string {
+	return f.sourceName
+}
+
+// TargetName implements Entry.TargetName.
+func (f *FIFO) TargetName() string {
+	return f.targetName
+}
+
+// archive writes f to w.
+func (f *FIFO) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
+	if ignore(f.targetName) {
+		return nil
+	}
+	header := *headerTemplate
+	header.Name = f.targetName
+	header.Typeflag = tar.TypeFifo
+	header.Mode = int64(f.Perm &^ umask)
+	return w.WriteHeader(&header)
+}
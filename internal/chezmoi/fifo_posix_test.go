@@ -0,0 +1,85 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestFIFOApply(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		root    interface{}
+		fifos   bool
+		wantErr bool
+		tests   []vfst.Test
+	}{
+		{
+			name: "disabled",
+			root: map[string]interface{}{
+				"/home/user": &vfst.Dir{Perm: 0755},
+			},
+			wantErr: true,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/fifo",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name: "create",
+			root: map[string]interface{}{
+				"/home/user": &vfst.Dir{Perm: 0755},
+			},
+			fifos: true,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/fifo",
+					vfst.TestModeType(os.ModeNamedPipe),
+					vfst.TestModePerm(0600),
+				),
+			},
+		},
+		{
+			name: "replace_file",
+			root: map[string]interface{}{
+				"/home/user/fifo": "contents",
+			},
+			fifos: true,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/fifo",
+					vfst.TestModeType(os.ModeNamedPipe),
+					vfst.TestModePerm(0600),
+				),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			f := &FIFO{
+				sourceName: "fifo_private_fifo",
+				targetName: "fifo",
+				Perm:       0600,
+			}
+			applyOptions := &ApplyOptions{
+				DestDir: "/home/user",
+				FIFOs:   tc.fifos,
+				Ignore:  func(string) bool { return false },
+				Umask:   022,
+			}
+			err = f.Apply(fs, NewFSMutator(fs), false, applyOptions)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			vfst.RunTests(t, fs, "", tc.tests)
+		})
+	}
+}
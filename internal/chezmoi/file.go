@@ -3,27 +3,31 @@ package chezmoi
 import (
 	"archive/tar"
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	vfs "github.com/twpayne/go-vfs"
 )
 
 // A FileAttributes holds attributes parsed from a source file name.
 type FileAttributes struct {
-	Name      string
-	Mode      os.FileMode
-	Empty     bool
-	Encrypted bool
-	Template  bool
+	Name       string
+	Mode       os.FileMode
+	Compressed bool
+	Empty      bool
+	Encrypted  bool
+	Hardlink   bool
+	Template   bool
+	Recursive  bool
 }
 
 // A File represents the target state of a file.
 type File struct {
 	sourceName       string
 	targetName       string
+	Compressed       bool
 	Empty            bool
 	Encrypted        bool
 	Perm             os.FileMode
@@ -31,75 +35,86 @@ type File struct {
 	contents         []byte
 	contentsErr      error
 	evaluateContents func() ([]byte, error)
+	evaluating       bool
+	blobSHA256       *[sha256.Size]byte
+	ExtraAttributes
 }
 
 type fileConcreteValue struct {
-	Type       string `json:"type" yaml:"type"`
-	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
-	TargetPath string `json:"targetPath" yaml:"targetPath"`
-	Empty      bool   `json:"empty" yaml:"empty"`
-	Encrypted  bool   `json:"encrypted" yaml:"encrypted"`
-	Perm       int    `json:"perm" yaml:"perm"`
-	Template   bool   `json:"template" yaml:"template"`
-	Contents   string `json:"contents" yaml:"contents"`
+	Type            string `json:"type" yaml:"type"`
+	SourcePath      string `json:"sourcePath" yaml:"sourcePath"`
+	TargetPath      string `json:"targetPath" yaml:"targetPath"`
+	Compressed      bool   `json:"compressed" yaml:"compressed"`
+	Empty           bool   `json:"empty" yaml:"empty"`
+	Encrypted       bool   `json:"encrypted" yaml:"encrypted"`
+	Perm            int    `json:"perm" yaml:"perm"`
+	Template        bool   `json:"template" yaml:"template"`
+	Contents        string `json:"contents" yaml:"contents"`
+	ExtraAttributes `yaml:",inline"`
 }
 
 // ParseFileAttributes parses a source file name.
 func ParseFileAttributes(sourceName string) FileAttributes {
-	name := sourceName
 	mode := os.FileMode(0666)
-	empty := false
-	encrypted := false
-	template := false
-	if strings.HasPrefix(name, symlinkPrefix) {
-		name = strings.TrimPrefix(name, symlinkPrefix)
-		mode |= os.ModeSymlink
-	} else {
-		private := false
-		if strings.HasPrefix(name, encryptedPrefix) {
-			name = strings.TrimPrefix(name, encryptedPrefix)
-			encrypted = true
-		}
-		if strings.HasPrefix(name, privatePrefix) {
-			name = strings.TrimPrefix(name, privatePrefix)
-			private = true
+	if isSymlinkSourceName(sourceName) {
+		t := symlinkSourceNameGrammar.tokenize(sourceName)
+		return FileAttributes{
+			Name:     t.name,
+			Mode:     mode | os.ModeSymlink,
+			Template: t.template,
 		}
-		if strings.HasPrefix(name, emptyPrefix) {
-			name = strings.TrimPrefix(name, emptyPrefix)
-			empty = true
-		}
-		if strings.HasPrefix(name, executablePrefix) {
-			name = strings.TrimPrefix(name, executablePrefix)
-			mode |= 0111
+	}
+	if isHardlinkSourceName(sourceName) {
+		t := hardlinkSourceNameGrammar.tokenize(sourceName)
+		return FileAttributes{
+			Name:     t.name,
+			Mode:     mode,
+			Hardlink: true,
+			Template: t.template,
 		}
-		if private {
+	}
+	if isFIFOSourceName(sourceName) {
+		t := fifoSourceNameGrammar.tokenize(sourceName)
+		if t.prefixes[privatePrefix] {
 			mode &= 0700
 		}
+		return FileAttributes{
+			Name: t.name,
+			Mode: mode | os.ModeNamedPipe,
+		}
 	}
-	if strings.HasPrefix(name, dotPrefix) {
-		name = "." + strings.TrimPrefix(name, dotPrefix)
+	t := fileSourceNameGrammar.tokenize(sourceName)
+	if t.prefixes[executablePrefix] {
+		mode |= 0111
 	}
-	if strings.HasSuffix(name, TemplateSuffix) {
-		name = strings.TrimSuffix(name, TemplateSuffix)
-		template = true
+	if t.prefixes[privatePrefix] {
+		mode &= 0700
 	}
 	return FileAttributes{
-		Name:      name,
-		Mode:      mode,
-		Empty:     empty,
-		Encrypted: encrypted,
-		Template:  template,
+		Name:       t.name,
+		Mode:       mode,
+		Compressed: t.prefixes[compressedPrefix],
+		Empty:      t.prefixes[emptyPrefix],
+		Encrypted:  t.prefixes[encryptedPrefix],
+		Template:   t.template,
 	}
 }
 
 // SourceName returns fa's source name.
 func (fa FileAttributes) SourceName() string {
 	sourceName := ""
-	switch fa.Mode & os.ModeType {
-	case 0:
+	g := fileSourceNameGrammar
+	switch {
+	case fa.Hardlink:
+		sourceName = hardlinkPrefix
+		g = hardlinkSourceNameGrammar
+	case fa.Mode&os.ModeType == 0:
 		if fa.Encrypted {
 			sourceName += encryptedPrefix
 		}
+		if fa.Compressed {
+			sourceName += compressedPrefix
+		}
 		if fa.Mode.Perm()&os.FileMode(077) == os.FileMode(0) {
 			sourceName += privatePrefix
 		}
@@ -109,20 +124,19 @@ func (fa FileAttributes) SourceName() string {
 		if fa.Mode.Perm()&os.FileMode(0111) != os.FileMode(0) {
 			sourceName += executablePrefix
 		}
-	case os.ModeSymlink:
+	case fa.Mode&os.ModeType == os.ModeNamedPipe:
+		sourceName = fifoPrefix
+		if fa.Mode.Perm()&os.FileMode(077) == os.FileMode(0) {
+			sourceName += privatePrefix
+		}
+		g = fifoSourceNameGrammar
+	case fa.Mode&os.ModeType == os.ModeSymlink:
 		sourceName = symlinkPrefix
+		g = symlinkSourceNameGrammar
 	default:
 		panic(fmt.Sprintf("%+v: unsupported type", fa))
 	}
-	if strings.HasPrefix(fa.Name, ".") {
-		sourceName += dotPrefix + strings.TrimPrefix(fa.Name, ".")
-	} else {
-		sourceName += fa.Name
-	}
-	if fa.Template {
-		sourceName += TemplateSuffix
-	}
-	return sourceName
+	return g.sourceName(sourceName, fa.Name, fa.Template)
 }
 
 // AppendAllEntries appends all f to allEntries.
@@ -135,11 +149,14 @@ func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Appl
 	if applyOptions.Ignore(f.targetName) {
 		return nil
 	}
+	if err := f.ExtraAttributes.checkApply(f.targetName); err != nil {
+		return err
+	}
 	contents, err := f.Contents()
 	if err != nil {
 		return err
 	}
-	targetPath := filepath.Join(applyOptions.DestDir, f.targetName)
+	targetPath := TargetPath(applyOptions.DestDir, f.targetName)
 	var info os.FileInfo
 	if follow {
 		info, err = fs.Stat(targetPath)
@@ -157,6 +174,12 @@ func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Appl
 			return err
 		}
 		if !bytes.Equal(currData, contents) {
+			if applyOptions.ConfirmOverwrite != nil {
+				ok, err := applyOptions.ConfirmOverwrite(targetPath, info)
+				if err != nil || !ok {
+					return err
+				}
+			}
 			break
 		}
 		if info.Mode().Perm() != f.Perm&^applyOptions.Umask {
@@ -189,26 +212,54 @@ func (f *File) ConcreteValue(ignore func(string) bool, sourceDir string, umask o
 		return nil, err
 	}
 	return &fileConcreteValue{
-		Type:       "file",
-		SourcePath: filepath.Join(sourceDir, f.SourceName()),
-		TargetPath: f.TargetName(),
-		Empty:      f.Empty,
-		Encrypted:  f.Encrypted,
-		Perm:       int(f.Perm &^ umask),
-		Template:   f.Template,
-		Contents:   string(contents),
+		Type:            "file",
+		SourcePath:      filepath.Join(sourceDir, f.SourceName()),
+		TargetPath:      f.TargetName(),
+		Compressed:      f.Compressed,
+		Empty:           f.Empty,
+		Encrypted:       f.Encrypted,
+		Perm:            int(f.Perm &^ umask),
+		Template:        f.Template,
+		Contents:        string(contents),
+		ExtraAttributes: f.ExtraAttributes,
 	}, nil
 }
 
-// Contents returns f's contents.
+// Blob returns true if f's source contents are stored in the blob store. It is
+// only valid after f's contents have been evaluated.
+func (f *File) Blob() bool {
+	return f.blobSHA256 != nil
+}
+
+// Contents returns f's contents. It returns an error if evaluating f's contents
+// requires f's contents, for example if f's template reads f's own contents
+// with targetContents, directly or through other targets.
 func (f *File) Contents() ([]byte, error) {
 	if f.evaluateContents != nil {
+		if f.evaluating {
+			return nil, fmt.Errorf("%s: contents depend on themselves", f.targetName)
+		}
+		f.evaluating = true
 		f.contents, f.contentsErr = f.evaluateContents()
+		f.evaluating = false
 		f.evaluateContents = nil
 	}
 	return f.contents, f.contentsErr
 }
 
+// ContentsSHA256 returns the SHA256 of f's contents. If f's contents are a blob
+// in the blob store then the blob's SHA256 is used.
+func (f *File) ContentsSHA256() ([sha256.Size]byte, error) {
+	contents, err := f.Contents()
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	if f.blobSHA256 != nil && !f.Compressed && !f.Encrypted && !f.Template {
+		return *f.blobSHA256, nil
+	}
+	return sha256.Sum256(contents), nil
+}
+
 // Evaluate evaluates f's contents.
 func (f *File) Evaluate(ignore func(string) bool) error {
 	if ignore(f.targetName) {
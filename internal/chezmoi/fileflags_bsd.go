@@ -0,0 +1,34 @@
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package chezmoi
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// File flags that prevent a file from being replaced, see chflags(2).
+const (
+	ufImmutable = 0x2
+	ufAppend    = 0x4
+	sfImmutable = 0x20000
+	sfAppend    = 0x40000
+
+	unreplaceableFileFlags = ufImmutable | ufAppend | sfImmutable | sfAppend
+)
+
+// getFileFlags returns the file flags of info.
+func getFileFlags(info os.FileInfo) uint32 {
+	statT, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0
+	}
+	return statT.Flags
+}
+
+// setFileFlags sets the file flags of name.
+func setFileFlags(name string, flags uint32) error {
+	return unix.Chflags(name, int(flags))
+}
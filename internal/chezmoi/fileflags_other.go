@@ -0,0 +1,19 @@
+// +build !darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package chezmoi
+
+import (
+	"os"
+)
+
+const unreplaceableFileFlags = 0
+
+// getFileFlags returns zero as file flags are not supported.
+func getFileFlags(info os.FileInfo) uint32 {
+	return 0
+}
+
+// setFileFlags does nothing as file flags are not supported.
+func setFileFlags(name string, flags uint32) error {
+	return nil
+}
@@ -0,0 +1,112 @@
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A FixMutator wraps another Mutator and only makes changes that repair
+// permissions and the targets of existing symlinks, never contents. It records
+// the names of the files that it fixes and of the files that it cannot fix.
+type FixMutator struct {
+	m       Mutator
+	fs      vfs.FS
+	fixed   map[string]struct{}
+	unfixed map[string]struct{}
+}
+
+// NewFixMutator returns a new FixMutator.
+func NewFixMutator(m Mutator, fs vfs.FS) *FixMutator {
+	return &FixMutator{
+		m:       m,
+		fs:      fs,
+		fixed:   make(map[string]struct{}),
+		unfixed: make(map[string]struct{}),
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *FixMutator) Chmod(name string, mode os.FileMode) error {
+	if err := m.m.Chmod(name, mode); err != nil {
+		return err
+	}
+	m.fixed[name] = struct{}{}
+	return nil
+}
+
+// Fixed returns the names of the files that m has fixed.
+func (m *FixMutator) Fixed() map[string]struct{} {
+	return m.fixed
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *FixMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Link implements Mutator.Link.
+func (m *FixMutator) Link(oldname, newname string) error {
+	m.unfixed[newname] = struct{}{}
+	return nil
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *FixMutator) Mkdir(name string, perm os.FileMode) error {
+	m.unfixed[name] = struct{}{}
+	return nil
+}
+
+// Mkfifo implements Mutator.Mkfifo.
+func (m *FixMutator) Mkfifo(name string, perm os.FileMode) error {
+	m.unfixed[name] = struct{}{}
+	return nil
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *FixMutator) RemoveAll(name string) error {
+	m.unfixed[name] = struct{}{}
+	return nil
+}
+
+// Rename implements Mutator.Rename.
+func (m *FixMutator) Rename(oldpath, newpath string) error {
+	m.unfixed[newpath] = struct{}{}
+	return nil
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *FixMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *FixMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// Unfixed returns the names of the files that m could not fix.
+func (m *FixMutator) Unfixed() map[string]struct{} {
+	return m.unfixed
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *FixMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	m.unfixed[name] = struct{}{}
+	return nil
+}
+
+// WriteSymlink implements Mutator.WriteSymlink. Only existing symlinks are
+// changed.
+func (m *FixMutator) WriteSymlink(oldname, newname string) error {
+	if info, err := m.fs.Lstat(newname); err != nil || info.Mode()&os.ModeType != os.ModeSymlink {
+		m.unfixed[newname] = struct{}{}
+		return nil
+	}
+	if err := m.m.WriteSymlink(oldname, newname); err != nil {
+		return err
+	}
+	m.fixed[newname] = struct{}{}
+	return nil
+}
@@ -29,6 +29,22 @@ func (m *FSMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 	return cmd.Output()
 }
 
+// Link implements Mutator.Link.
+func (m *FSMutator) Link(oldname, newname string) error {
+	rawOldname, err := m.RawPath(oldname)
+	if err != nil {
+		return err
+	}
+	rawNewname, err := m.RawPath(newname)
+	if err != nil {
+		return err
+	}
+	if err := m.FS.RemoveAll(newname); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Link(rawOldname, rawNewname)
+}
+
 // RunCmd implements Mutator.RunCmd.
 func (m *FSMutator) RunCmd(cmd *exec.Cmd) error {
 	return cmd.Run()
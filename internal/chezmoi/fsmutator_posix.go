@@ -4,18 +4,45 @@ package chezmoi
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"syscall"
 
 	"github.com/google/renameio"
 	vfs "github.com/twpayne/go-vfs"
+	"golang.org/x/sys/unix"
 )
 
+// Mkfifo implements Mutator.Mkfifo.
+func (m *FSMutator) Mkfifo(name string, perm os.FileMode) error {
+	rawName, err := m.RawPath(name)
+	if err != nil {
+		return err
+	}
+	if err := m.FS.RemoveAll(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := unix.Mkfifo(rawName, uint32(perm.Perm())); err != nil {
+		return &os.PathError{Op: "mkfifo", Path: name, Err: err}
+	}
+	// The permissions passed to mkfifo(2) are modified by the umask.
+	return m.Chmod(name, perm)
+}
+
 // WriteFile implements Mutator.WriteFile.
 func (m *FSMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
 	// Special case: if writing to the real filesystem, use github.com/google/renameio
 	if m.FS == vfs.OSFS {
+		// Atomically replacing a file does not preserve its file flags, and
+		// fails if it is immutable or append-only.
+		var flags uint32
+		if info, err := m.Lstat(name); err == nil {
+			flags = getFileFlags(info)
+		}
+		if flags&unreplaceableFileFlags != 0 {
+			return fmt.Errorf("%s: file is immutable or append-only, see chflags(1)", name)
+		}
 		dir := filepath.Dir(name)
 		dev, ok := m.devCache[dir]
 		if !ok {
@@ -48,7 +75,13 @@ func (m *FSMutator) WriteFile(name string, data []byte, perm os.FileMode, currDa
 		if _, err := t.Write(data); err != nil {
 			return err
 		}
-		return t.CloseAtomicallyReplace()
+		if err := t.CloseAtomicallyReplace(); err != nil {
+			return err
+		}
+		if flags != 0 {
+			return setFileFlags(name, flags)
+		}
+		return nil
 	}
 	return m.FS.WriteFile(name, data, perm)
 }
@@ -3,9 +3,15 @@
 package chezmoi
 
 import (
+	"errors"
 	"os"
 )
 
+// Mkfifo implements Mutator.Mkfifo.
+func (m *FSMutator) Mkfifo(name string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkfifo", Path: name, Err: errors.New("FIFOs are not supported on Windows")}
+}
+
 // WriteFile implements Mutator.WriteFile.
 func (m *FSMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
 	return m.FS.WriteFile(name, data, perm)
@@ -64,6 +64,25 @@ func (m *GitDiffMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 	return m.m.IdempotentCmdOutput(cmd)
 }
 
+// Link implements Mutator.Link. The new link is shown as a copy of oldname.
+func (m *GitDiffMutator) Link(oldname, newname string) error {
+	fileMode, _, err := m.getFileMode(oldname)
+	if err != nil {
+		fileMode = filemode.Regular
+	}
+	return m.unifiedEncoder.Encode(&gitDiffPatch{
+		filePatches: []diff.FilePatch{
+			&gitDiffFilePatch{
+				to: &gitDiffFile{
+					fileMode: fileMode,
+					path:     m.trimPrefix(newname),
+					hash:     plumbing.ZeroHash,
+				},
+			},
+		},
+	})
+}
+
 // Mkdir implements Mutator.Mkdir.
 func (m *GitDiffMutator) Mkdir(name string, perm os.FileMode) error {
 	toFileMode, err := filemode.NewFromOSFileMode(os.ModeDir | perm)
@@ -83,6 +102,12 @@ func (m *GitDiffMutator) Mkdir(name string, perm os.FileMode) error {
 	})
 }
 
+// Mkfifo implements Mutator.Mkfifo.
+func (m *GitDiffMutator) Mkfifo(name string, perm os.FileMode) error {
+	// git cannot represent FIFOs, so they do not appear in diffs.
+	return nil
+}
+
 // RemoveAll implements Mutator.RemoveAll.
 func (m *GitDiffMutator) RemoveAll(name string) error {
 	fromFileMode, _, err := m.getFileMode(name)
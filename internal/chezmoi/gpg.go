@@ -1,10 +1,9 @@
 package chezmoi
 
 import (
-	"io/ioutil"
+	"bytes"
 	"os"
 	"os/exec"
-	"path/filepath"
 )
 
 // GPG interfaces with gpg.
@@ -14,57 +13,26 @@ type GPG struct {
 	Symmetric bool
 }
 
-// Decrypt decrypts ciphertext. filename is used as a hint for naming temporary
-// files.
-func (g *GPG) Decrypt(filename string, ciphertext []byte) ([]byte, error) {
-	tempDir, err := ioutil.TempDir("", "chezmoi-decrypt")
-	if err != nil {
-		return nil, err
-	}
-	defer os.RemoveAll(tempDir)
-
-	outputFilename := filepath.Join(tempDir, filepath.Base(filename))
-	inputFilename := outputFilename + ".gpg"
-	if err := ioutil.WriteFile(inputFilename, ciphertext, 0600); err != nil {
-		return nil, err
-	}
-
+// Decrypt decrypts ciphertext. The ciphertext and plaintext are passed to and
+// from gpg through pipes, so the plaintext is never written to disk.
+func (g *GPG) Decrypt(ciphertext []byte) ([]byte, error) {
 	//nolint:gosec
 	cmd := exec.Command(
 		g.Command,
-		"--output", outputFilename,
 		"--quiet",
-		"--decrypt", inputFilename,
+		"--decrypt",
 	)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return nil, err
-	}
-
-	return ioutil.ReadFile(outputFilename)
+	return g.run(cmd, ciphertext)
 }
 
-// Encrypt encrypts plaintext for ts's recipient. filename is used as a hint for
-// naming temporary files.
+// Encrypt encrypts plaintext for ts's recipient. The plaintext and ciphertext
+// are passed to and from gpg through pipes, so the plaintext is never written
+// to disk. filename is recorded as the name of the plaintext.
 func (g *GPG) Encrypt(filename string, plaintext []byte) ([]byte, error) {
-	tempDir, err := ioutil.TempDir("", "chezmoi-encrypt")
-	if err != nil {
-		return nil, err
-	}
-	defer os.RemoveAll(tempDir)
-
-	inputFilename := filepath.Join(tempDir, filepath.Base(filename))
-	if err := ioutil.WriteFile(inputFilename, plaintext, 0600); err != nil {
-		return nil, err
-	}
-	outputFilename := inputFilename + ".gpg"
-
 	args := []string{
 		"--armor",
-		"--output", outputFilename,
 		"--quiet",
+		"--set-filename", filename,
 	}
 	if g.Symmetric {
 		args = append(args, "--symmetric")
@@ -74,16 +42,22 @@ func (g *GPG) Encrypt(filename string, plaintext []byte) ([]byte, error) {
 		}
 		args = append(args, "--encrypt")
 	}
-	args = append(args, filename)
 
 	//nolint:gosec
 	cmd := exec.Command(g.Command, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
+	return g.run(cmd, plaintext)
+}
+
+// run runs cmd with input as its standard input and returns its standard
+// output. Any passphrase prompts are made by gpg-agent's pinentry on the
+// terminal.
+func (g *GPG) run(cmd *exec.Cmd, input []byte) ([]byte, error) {
+	cmd.Stdin = bytes.NewReader(input)
+	stdout := &bytes.Buffer{}
+	cmd.Stdout = stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return nil, err
 	}
-
-	return ioutil.ReadFile(outputFilename)
+	return stdout.Bytes(), nil
 }
@@ -0,0 +1,51 @@
+package chezmoi
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGPG(t *testing.T) {
+	command, err := exec.LookPath("gpg")
+	if err != nil {
+		t.Skip("gpg not found in $PATH")
+	}
+
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-gpg")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	oldGNUPGHome, ok := os.LookupEnv("GNUPGHOME")
+	require.NoError(t, os.Setenv("GNUPGHOME", tempDir))
+	defer func() {
+		if ok {
+			_ = os.Setenv("GNUPGHOME", oldGNUPGHome)
+		} else {
+			_ = os.Unsetenv("GNUPGHOME")
+		}
+	}()
+
+	recipient := "chezmoi-test@example.com"
+	//nolint:gosec
+	output, err := exec.Command(command, "--batch", "--passphrase", "", "--quick-generate-key", recipient).CombinedOutput()
+	require.NoError(t, err, string(output))
+	defer func() {
+		_ = exec.Command("gpgconf", "--kill", "gpg-agent").Run()
+	}()
+
+	g := &GPG{
+		Command:   command,
+		Recipient: recipient,
+	}
+	plaintext := []byte("secret\n")
+	ciphertext, err := g.Encrypt("secret", plaintext)
+	require.NoError(t, err)
+	assert.NotEqual(t, plaintext, ciphertext)
+	actualPlaintext, err := g.Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, actualPlaintext)
+}
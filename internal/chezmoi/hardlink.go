@@ -0,0 +1,159 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A Hardlink represents the target state of a hard link. Its link name is the
+// target name of the file that it shares an inode with.
+type Hardlink struct {
+	sourceName       string
+	targetName       string
+	Template         bool
+	linkname         string
+	linknameErr      error
+	evaluateLinkname func() (string, error)
+}
+
+type hardlinkConcreteValue struct {
+	Type       string `json:"type" yaml:"type"`
+	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
+	TargetPath string `json:"targetPath" yaml:"targetPath"`
+	Template   bool   `json:"template" yaml:"template"`
+	Linkname   string `json:"linkname" yaml:"linkname"`
+}
+
+// AppendAllEntries appends all h to allEntries.
+func (h *Hardlink) AppendAllEntries(allEntries []Entry) []Entry {
+	return append(allEntries, h)
+}
+
+// Apply ensures that the state of h's target in fs matches h.
+func (h *Hardlink) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
+	if applyOptions.Ignore(h.targetName) {
+		return nil
+	}
+	linkname, err := h.Linkname()
+	if err != nil {
+		return err
+	}
+	targetPath := TargetPath(applyOptions.DestDir, h.targetName)
+	if linkname == "" {
+		if _, err := fs.Lstat(targetPath); os.IsNotExist(err) {
+			return nil
+		}
+		return mutator.RemoveAll(targetPath)
+	}
+	// Hard links can only link to files in the destination directory.
+	linkPath := TargetPath(applyOptions.DestDir, linkname)
+	if relPath, err := filepath.Rel(applyOptions.DestDir, linkPath); err != nil || isOutside(relPath) {
+		return fmt.Errorf("%s: hard link target %s is outside %s", targetPath, linkPath, applyOptions.DestDir)
+	}
+	linkInfo, err := fs.Lstat(linkPath)
+	switch {
+	case os.IsNotExist(err):
+		// The file may not exist yet if this is a dry run.
+		if applyOptions.DryRun {
+			return mutator.Link(linkPath, targetPath)
+		}
+		return fmt.Errorf("%s: hard link target %s does not exist", targetPath, linkPath)
+	case err != nil:
+		return err
+	case !linkInfo.Mode().IsRegular():
+		return fmt.Errorf("%s: hard link target %s is not a regular file", targetPath, linkPath)
+	}
+	info, err := fs.Lstat(targetPath)
+	switch {
+	case err == nil && os.SameFile(info, linkInfo):
+		return nil
+	case err == nil:
+	case os.IsNotExist(err):
+	default:
+		return err
+	}
+	return mutator.Link(linkPath, targetPath)
+}
+
+// ConcreteValue implements Entry.ConcreteValue.
+func (h *Hardlink) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(h.targetName) {
+		return nil, nil
+	}
+	linkname, err := h.Linkname()
+	if err != nil {
+		return nil, err
+	}
+	return &hardlinkConcreteValue{
+		Type:       "hardlink",
+		SourcePath: filepath.Join(sourceDir, h.SourceName()),
+		TargetPath: h.TargetName(),
+		Template:   h.Template,
+		Linkname:   linkname,
+	}, nil
+}
+
+// Evaluate evaluates h's link name.
+func (h *Hardlink) Evaluate(ignore func(string) bool) error {
+	if ignore(h.targetName) {
+		return nil
+	}
+	_, err := h.Linkname()
+	return err
+}
+
+// Linkname returns h's link name.
+func (h *Hardlink) Linkname() (string, error) {
+	if h.evaluateLinkname != nil {
+		h.linkname, h.linknameErr = h.evaluateLinkname()
+		h.evaluateLinkname = nil
+	}
+	return strings.TrimSpace(h.linkname), h.linknameErr
+}
+
+// SourceName implements Entry.SourceName.
+func (h *Hardlink) SourceName() string {
+	return h.sourceName
+}
+
+// TargetName implements Entry.TargetName.
+func (h *Hardlink) TargetName() string {
+	return h.targetName
+}
+
+// archive writes h to w.
+func (h *Hardlink) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
+	if ignore(h.targetName) {
+		return nil
+	}
+	linkname, err := h.Linkname()
+	if err != nil {
+		return err
+	}
+	if linkname == "" {
+		return nil
+	}
+	header := *headerTemplate
+	header.Name = h.targetName
+	header.Typeflag = tar.TypeLink
+	header.Linkname = linkname
+	return w.WriteHeader(&header)
+}
+
+// findHardlinks returns all the Hardlinks in entries, at any depth.
+func findHardlinks(entries []Entry) []Entry {
+	var hardlinks []Entry
+	for _, entry := range entries {
+		for _, e := range entry.AppendAllEntries(nil) {
+			if _, ok := e.(*Hardlink); ok {
+				hardlinks = append(hardlinks, e)
+			}
+		}
+	}
+	return hardlinks
+}
@@ -0,0 +1,117 @@
+package chezmoi
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// An InterruptedError is returned when applying is interrupted.
+type InterruptedError struct {
+	// TargetPaths are the target paths of the entries that were not applied,
+	// in the order in which they would have been applied.
+	TargetPaths []string
+	Err         error
+}
+
+func (e *InterruptedError) Error() string {
+	return "interrupted"
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyEntries applies entries in order. If applyOptions.Context is done then
+// it stops before the next entry and returns an *InterruptedError. If
+// applyOptions.ConfirmApply is set then entries that it does not confirm are
+// skipped.
+func ApplyEntries(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions, entries []Entry) error {
+	for i, entry := range entries {
+		if err := applyOptions.interrupted(); err != nil {
+			return newInterruptedError(applyOptions.DestDir, entries[i:], err)
+		}
+		if applyOptions.ConfirmApply != nil && !applyOptions.Ignore(entry.TargetName()) {
+			ok, err := applyOptions.ConfirmApply(entry, applyOptions.newPreviewFunc(fs, follow, entry))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+		if err := entry.Apply(fs, applyOptions.MutatorFor(entry, mutator), follow, applyOptions); err != nil {
+			var interruptedErr *InterruptedError
+			if errors.As(err, &interruptedErr) {
+				interruptedErr.TargetPaths = append(interruptedErr.TargetPaths, newInterruptedError(applyOptions.DestDir, entries[i+1:], nil).TargetPaths...)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// newPreviewFunc returns a PreviewFunc for entry.
+func (o *ApplyOptions) newPreviewFunc(fs vfs.FS, follow bool, entry Entry) PreviewFunc {
+	return func(mutator Mutator, stdout io.Writer) error {
+		previewOptions := *o
+		previewOptions.ConfirmApply = nil
+		previewOptions.ConfirmOverwrite = nil
+		previewOptions.DryRun = true
+		previewOptions.OnRunScript = nil
+		previewOptions.PrivilegedMutator = nil
+		previewOptions.Stdout = stdout
+		previewOptions.Verbose = true
+		return entry.Apply(fs, mutator, follow, &previewOptions)
+	}
+}
+
+// interrupted returns the error of o.Context, if any.
+func (o *ApplyOptions) interrupted() error {
+	if o.Context == nil {
+		return nil
+	}
+	return o.Context.Err()
+}
+
+// runScript runs c, killing it if o.Context is done before it exits.
+func (o *ApplyOptions) runScript(c *exec.Cmd) error {
+	c.SysProcAttr = scriptSysProcAttr()
+	if err := c.Start(); err != nil {
+		return err
+	}
+	if o.Context == nil {
+		return c.Wait()
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-o.Context.Done():
+			_ = killScript(c)
+		case <-done:
+		}
+	}()
+	return c.Wait()
+}
+
+func newInterruptedError(destDir string, entries []Entry, err error) *InterruptedError {
+	targetPaths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		targetPaths = append(targetPaths, TargetPath(destDir, entry.TargetName()))
+	}
+	return &InterruptedError{
+		TargetPaths: targetPaths,
+		Err:         err,
+	}
+}
+
+func sortedEntries(entries map[string]Entry) []Entry {
+	sortedEntries := make([]Entry, 0, len(entries))
+	for _, entryName := range sortedEntryNames(entries) {
+		sortedEntries = append(sortedEntries, entries[entryName])
+	}
+	return sortedEntries
+}
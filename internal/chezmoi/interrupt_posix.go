@@ -0,0 +1,34 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// scriptSysProcAttr returns the attributes of script processes. Scripts are
+// run in their own process group so that they and any processes that they
+// start can be killed together. If stdin is a terminal then scripts remain in
+// the foreground process group so that they can read from it, and receive
+// signals generated by the terminal directly.
+func scriptSysProcAttr() *syscall.SysProcAttr {
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	return &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+}
+
+// killScript kills the script process c and, if it has its own process group,
+// all processes in its process group.
+func killScript(c *exec.Cmd) error {
+	if c.SysProcAttr != nil && c.SysProcAttr.Setpgid {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+	return c.Process.Kill()
+}
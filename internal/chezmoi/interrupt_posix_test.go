@@ -0,0 +1,58 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+func TestScriptInterrupted(t *testing.T) {
+	destDir, err := ioutil.TempDir("", "chezmoi-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(destDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, cancel)
+	applyOptions := &ApplyOptions{
+		Context: ctx,
+		DestDir: destDir,
+		Ignore:  func(string) bool { return false },
+		Stdout:  ioutil.Discard,
+		Umask:   022,
+	}
+	entries := []Entry{
+		&Script{
+			sourceName: "run_foo",
+			targetName: "foo",
+			contents:   []byte("#!/bin/sh\nsleep 10 &\nsleep 10\n"),
+		},
+		&File{
+			sourceName: "bar",
+			targetName: "bar",
+			Perm:       0644,
+			contents:   []byte("bar"),
+		},
+	}
+	start := time.Now()
+	err = ApplyEntries(vfs.OSFS, NewFSMutator(vfs.OSFS), false, applyOptions, entries)
+	assert.True(t, time.Since(start) < 5*time.Second)
+	var interruptedErr *InterruptedError
+	require.True(t, errors.As(err, &interruptedErr))
+	assert.Equal(t, []string{
+		filepath.Join(destDir, "foo"),
+		filepath.Join(destDir, "bar"),
+	}, interruptedErr.TargetPaths)
+	_, err = os.Stat(filepath.Join(destDir, "bar"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,111 @@
+package chezmoi
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+// A cancelingMutator cancels a context after writing a file.
+type cancelingMutator struct {
+	Mutator
+	cancel context.CancelFunc
+}
+
+func (m *cancelingMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	defer m.cancel()
+	return m.Mutator.WriteFile(name, data, perm, currData)
+}
+
+func TestApplyInterrupted(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"dot_dir/bar": "bar",
+			"dot_dir/foo": "foo",
+			"dot_qux":     "qux",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithUmask(022),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	applyOptions := &ApplyOptions{
+		Context:           ctx,
+		DestDir:           ts.DestDir,
+		Ignore:            ts.TargetIgnore.Match,
+		ScriptStateBucket: []byte("script"),
+		Stdout:            os.Stdout,
+		Umask:             022,
+	}
+	mutator := &cancelingMutator{
+		Mutator: NewFSMutator(fs),
+		cancel:  cancel,
+	}
+	err = ts.Apply(fs, mutator, false, applyOptions)
+	var interruptedErr *InterruptedError
+	require.True(t, errors.As(err, &interruptedErr))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, []string{
+		"/home/user/.dir/foo",
+		"/home/user/.qux",
+	}, interruptedErr.TargetPaths)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.dir/bar",
+			vfst.TestContentsString("bar"),
+		),
+		vfst.TestPath("/home/user/.dir/foo",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.qux",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
+
+func TestApplyEntriesCanceled(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	applyOptions := &ApplyOptions{
+		Context: ctx,
+		DestDir: "/home/user",
+		Ignore:  func(string) bool { return false },
+		Umask:   022,
+	}
+	entries := []Entry{
+		&File{
+			sourceName: "dot_foo",
+			targetName: ".foo",
+			Perm:       0644,
+			contents:   []byte("foo"),
+		},
+	}
+	err = ApplyEntries(fs, NewFSMutator(fs), false, applyOptions, entries)
+	var interruptedErr *InterruptedError
+	require.True(t, errors.As(err, &interruptedErr))
+	assert.Equal(t, []string{"/home/user/.foo"}, interruptedErr.TargetPaths)
+	vfst.RunTests(t, vfs.NewReadOnlyFS(fs), "",
+		vfst.TestPath("/home/user/.foo",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
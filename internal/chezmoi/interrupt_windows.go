@@ -0,0 +1,18 @@
+// +build windows
+
+package chezmoi
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// scriptSysProcAttr returns the attributes of script processes.
+func scriptSysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+// killScript kills the script process c.
+func killScript(c *exec.Cmd) error {
+	return c.Process.Kill()
+}
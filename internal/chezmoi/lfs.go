@@ -0,0 +1,107 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+const (
+	lfsPointerMaxSize = 1024
+	lfsPointerVersion = "version https://git-lfs.github.com/spec/v1\n"
+)
+
+// LFS interfaces with git-lfs.
+type LFS struct {
+	Command string
+}
+
+// An lfsPointer is a parsed git-lfs pointer file.
+type lfsPointer struct {
+	oid  string
+	size int64
+}
+
+// parseLFSPointer parses data as a git-lfs pointer file. It returns nil if
+// data is not a pointer file.
+func parseLFSPointer(data []byte) *lfsPointer {
+	if len(data) > lfsPointerMaxSize || !bytes.HasPrefix(data, []byte(lfsPointerVersion)) {
+		return nil
+	}
+	p := &lfsPointer{
+		size: -1,
+	}
+	s := bufio.NewScanner(bytes.NewReader(data[len(lfsPointerVersion):]))
+	for s.Scan() {
+		fields := strings.SplitN(s.Text(), " ", 2)
+		if len(fields) != 2 {
+			return nil
+		}
+		switch key, value := fields[0], fields[1]; key {
+		case "oid":
+			oid := strings.TrimPrefix(value, "sha256:")
+			if oid == value || len(oid) != 2*sha256.Size {
+				return nil
+			}
+			if _, err := hex.DecodeString(oid); err != nil {
+				return nil
+			}
+			p.oid = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return nil
+			}
+			p.size = size
+		}
+	}
+	if p.oid == "" || p.size < 0 {
+		return nil
+	}
+	return p
+}
+
+// resolveLFSPointer returns the contents of the object that data points to if
+// data is a git-lfs pointer file, or data otherwise. The object is read from
+// the local git-lfs object store in ts.SourceDir if it is there, otherwise it
+// is fetched with git-lfs. sourceName is the source name of the file
+// containing data.
+func (ts *TargetState) resolveLFSPointer(fs vfs.FS, sourceName string, data []byte) ([]byte, error) {
+	p := parseLFSPointer(data)
+	if p == nil {
+		return data, nil
+	}
+	objectPath := filepath.Join(ts.SourceDir, ".git", "lfs", "objects", p.oid[0:2], p.oid[2:4], p.oid)
+	switch contents, err := fs.ReadFile(objectPath); {
+	case err == nil:
+		if sum := sha256.Sum256(contents); int64(len(contents)) != p.size || hex.EncodeToString(sum[:]) != p.oid {
+			return nil, fmt.Errorf("%s: corrupt git-lfs object", objectPath)
+		}
+		return contents, nil
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	if ts.LFS == nil || ts.LFS.Command == "" {
+		return nil, fmt.Errorf("%s: git-lfs object %s not found", sourceName, p.oid)
+	}
+	//nolint:gosec
+	cmd := exec.Command(ts.LFS.Command, "lfs", "smudge", "--", filepath.ToSlash(sourceName))
+	cmd.Dir = ts.SourceDir
+	cmd.Stdin = bytes.NewReader(data)
+	stdout := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w", sourceName, err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,144 @@
+package chezmoi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func newTestLFSPointer(contents string) (string, string) {
+	sum := sha256.Sum256([]byte(contents))
+	oid := hex.EncodeToString(sum[:])
+	return oid, fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, len(contents))
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	oid, pointer := newTestLFSPointer("contents")
+	for _, tc := range []struct {
+		name string
+		data string
+		want *lfsPointer
+	}{
+		{
+			name: "pointer",
+			data: pointer,
+			want: &lfsPointer{
+				oid:  oid,
+				size: 8,
+			},
+		},
+		{
+			name: "extension",
+			data: "version https://git-lfs.github.com/spec/v1\next-0-foo sha256:" + oid + "\noid sha256:" + oid + "\nsize 8\n",
+			want: &lfsPointer{
+				oid:  oid,
+				size: 8,
+			},
+		},
+		{
+			name: "empty",
+			data: "",
+		},
+		{
+			name: "no_version",
+			data: "oid sha256:" + oid + "\nsize 8\n",
+		},
+		{
+			name: "no_oid",
+			data: "version https://git-lfs.github.com/spec/v1\nsize 8\n",
+		},
+		{
+			name: "no_size",
+			data: "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\n",
+		},
+		{
+			name: "short_oid",
+			data: "version https://git-lfs.github.com/spec/v1\noid sha256:0123\nsize 8\n",
+		},
+		{
+			name: "bad_size",
+			data: "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize -1\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, parseLFSPointer([]byte(tc.data)))
+		})
+	}
+}
+
+func TestTargetStatePopulateLFS(t *testing.T) {
+	oid, pointer := newTestLFSPointer("contents")
+	missingOID, missingPointer := newTestLFSPointer("missing")
+	corruptOID, corruptPointer := newTestLFSPointer("corrupt")
+	for _, tc := range []struct {
+		name         string
+		root         interface{}
+		wantContents string
+		wantErr      bool
+	}{
+		{
+			name: "object",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".git/lfs/objects/" + oid[0:2] + "/" + oid[2:4] + "/" + oid: "contents",
+					"dot_foo": pointer,
+				},
+			},
+			wantContents: "contents",
+		},
+		{
+			name: "not_a_pointer",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_foo": "contents",
+			},
+			wantContents: "contents",
+		},
+		{
+			name: "missing_object",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".git/lfs/objects/" + missingOID[0:2] + "/" + missingOID[2:4] + "/.keep": "",
+					"dot_foo": missingPointer,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "corrupt_object",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".git/lfs/objects/" + corruptOID[0:2] + "/" + corruptOID[2:4] + "/" + corruptOID: "contents",
+					"dot_foo": corruptPointer,
+				},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithLFS(&LFS{}),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+			)
+			require.NoError(t, ts.Populate(fs, nil))
+			file, ok := ts.Entries[".foo"].(*File)
+			require.True(t, ok)
+			contents, err := file.Contents()
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantContents, string(contents))
+		})
+	}
+}
@@ -9,7 +9,9 @@ import (
 type Mutator interface {
 	Chmod(name string, mode os.FileMode) error
 	IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error)
+	Link(oldname, newname string) error
 	Mkdir(name string, perm os.FileMode) error
+	Mkfifo(name string, perm os.FileMode) error
 	RemoveAll(name string) error
 	Rename(oldpath, newpath string) error
 	RunCmd(cmd *exec.Cmd) error
@@ -0,0 +1,11 @@
+package chezmoi
+
+import "golang.org/x/text/unicode/norm"
+
+// normalizeName returns name in Unicode Normalization Form C (NFC). macOS
+// filesystems may return names in Normalization Form D (NFD), so target names
+// are normalized before they are compared, otherwise the same name checked out
+// on different systems could refer to different targets.
+func normalizeName(name string) string {
+	return norm.NFC.String(name)
+}
@@ -0,0 +1,71 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestNormalizeTargetNames(t *testing.T) {
+	const (
+		nfc = "caf\u00e9"
+		nfd = "cafe\u0301"
+	)
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".local/share/chezmoi": map[string]interface{}{
+				".chezmoiignore": nfc + "/ignored\n",
+				"exact_" + nfd: map[string]interface{}{
+					"file":       "contents",
+					nfc + ".txt": "text",
+				},
+			},
+			nfc: map[string]interface{}{
+				"file":       "contents",
+				"ignored":    "ignored",
+				nfd + ".txt": "text",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Evaluate())
+
+	assert.Contains(t, ts.Entries, nfc)
+	assert.NotContains(t, ts.Entries, nfd)
+	for _, target := range []string{"/home/user/" + nfc + "/file", "/home/user/" + nfd + "/file"} {
+		entry, err := ts.Get(fs, target)
+		assert.NoError(t, err)
+		assert.NotNil(t, entry)
+	}
+	assert.True(t, ts.TargetIgnore.Match(nfd+"/ignored"))
+
+	applyOptions := &ApplyOptions{
+		DestDir: ts.DestDir,
+		Ignore:  ts.TargetIgnore.Match,
+		Umask:   022,
+	}
+	require.NoError(t, ts.Apply(fs, NewFSMutator(fs), false, applyOptions))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/"+nfc+"/file",
+			vfst.TestContentsString("contents"),
+		),
+		vfst.TestPath("/home/user/"+nfc+"/ignored",
+			vfst.TestContentsString("ignored"),
+		),
+		vfst.TestPath("/home/user/"+nfc+"/"+nfd+".txt",
+			vfst.TestContentsString("text"),
+		),
+		vfst.TestPath("/home/user/"+nfd,
+			vfst.TestDoesNotExist,
+		),
+	)
+}
@@ -0,0 +1,10 @@
+// +build !darwin
+
+package chezmoi
+
+// normalizeName returns name unchanged. Filesystems on this system distinguish
+// names that differ only in their Unicode normalization, so they are different
+// targets.
+func normalizeName(name string) string {
+	return name
+}
@@ -18,11 +18,21 @@ func (NullMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 	return cmd.Output()
 }
 
+// Link implements Mutator.Link.
+func (NullMutator) Link(string, string) error {
+	return nil
+}
+
 // Mkdir implements Mutator.Mkdir.
 func (NullMutator) Mkdir(string, os.FileMode) error {
 	return nil
 }
 
+// Mkfifo implements Mutator.Mkfifo.
+func (NullMutator) Mkfifo(string, os.FileMode) error {
+	return nil
+}
+
 // RemoveAll implements Mutator.RemoveAll.
 func (NullMutator) RemoveAll(string) error {
 	return nil
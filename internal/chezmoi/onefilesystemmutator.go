@@ -0,0 +1,139 @@
+package chezmoi
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A OneFileSystemMutator wraps another Mutator and refuses to remove anything
+// that is on, or contains, a different filesystem to its parent directory,
+// like rm --one-file-system. This stops removals, for example when pruning an
+// exact directory, from descending into mount points.
+type OneFileSystemMutator struct {
+	m      Mutator
+	fs     vfs.FS
+	device func(os.FileInfo) (uint64, bool)
+}
+
+// NewOneFileSystemMutator returns a new OneFileSystemMutator.
+func NewOneFileSystemMutator(m Mutator, fs vfs.FS) *OneFileSystemMutator {
+	return &OneFileSystemMutator{
+		m:      m,
+		fs:     fs,
+		device: getDevice,
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *OneFileSystemMutator) Chmod(name string, mode os.FileMode) error {
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *OneFileSystemMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Link implements Mutator.Link.
+func (m *OneFileSystemMutator) Link(oldname, newname string) error {
+	return m.m.Link(oldname, newname)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *OneFileSystemMutator) Mkdir(name string, perm os.FileMode) error {
+	return m.m.Mkdir(name, perm)
+}
+
+// Mkfifo implements Mutator.Mkfifo.
+func (m *OneFileSystemMutator) Mkfifo(name string, perm os.FileMode) error {
+	return m.m.Mkfifo(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *OneFileSystemMutator) RemoveAll(name string) error {
+	if err := m.checkOneFileSystem(name); err != nil {
+		return err
+	}
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *OneFileSystemMutator) Rename(oldpath, newpath string) error {
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *OneFileSystemMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *OneFileSystemMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *OneFileSystemMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	return m.m.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *OneFileSystemMutator) WriteSymlink(oldname, newname string) error {
+	return m.m.WriteSymlink(oldname, newname)
+}
+
+// checkOneFileSystem returns an error if name or anything in it is on a
+// different filesystem to name's parent directory.
+func (m *OneFileSystemMutator) checkOneFileSystem(name string) error {
+	parentInfo, err := m.fs.Stat(filepath.Dir(name))
+	if err != nil {
+		return nil
+	}
+	parentDevice, ok := m.device(parentInfo)
+	if !ok {
+		return nil
+	}
+	info, err := m.fs.Lstat(name)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	mountPoint, err := m.findMountPoint(name, info, parentDevice)
+	switch {
+	case err != nil:
+		return err
+	case mountPoint == name:
+		return fmt.Errorf("%s: is a mount point, set crossFileSystems = true in your config file to remove it", name)
+	case mountPoint != "":
+		return fmt.Errorf("%s: contains mount point %s, set crossFileSystems = true in your config file to remove it", name, mountPoint)
+	default:
+		return nil
+	}
+}
+
+// findMountPoint returns the first path in path, which has info, that is not
+// on device, or the empty string if there is none.
+func (m *OneFileSystemMutator) findMountPoint(path string, info os.FileInfo, device uint64) (string, error) {
+	if d, ok := m.device(info); ok && d != device {
+		return path, nil
+	}
+	if !info.IsDir() {
+		return "", nil
+	}
+	infos, err := m.fs.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	for _, info := range infos {
+		if mountPoint, err := m.findMountPoint(filepath.Join(path, info.Name()), info, device); err != nil || mountPoint != "" {
+			return mountPoint, err
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,54 @@
+package chezmoi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestOneFileSystemMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			"dir": map[string]interface{}{
+				"foo": "foo",
+			},
+			"media": map[string]interface{}{
+				"nas": map[string]interface{}{
+					"bar": "bar",
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	m := NewOneFileSystemMutator(NewFSMutator(fs), fs)
+	// Simulate a filesystem mounted on /home/user/media/nas.
+	m.device = func(info os.FileInfo) (uint64, bool) {
+		if info.Name() == "nas" || info.Name() == "bar" {
+			return 2, true
+		}
+		return 1, true
+	}
+
+	assert.NoError(t, m.RemoveAll("/home/user/dir"))
+	assert.NoError(t, m.RemoveAll("/home/user/missing"))
+	assert.Error(t, m.RemoveAll("/home/user/media"))
+	assert.Error(t, m.RemoveAll("/home/user/media/nas"))
+	assert.NoError(t, m.RemoveAll("/home/user/media/nas/bar"))
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/dir",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/media/nas",
+			vfst.TestIsDir,
+		),
+		vfst.TestPath("/home/user/media/nas/bar",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
@@ -0,0 +1,8 @@
+// +build !windows
+
+package chezmoi
+
+// NormalPath returns path unchanged on POSIX systems.
+func NormalPath(path string) string {
+	return path
+}
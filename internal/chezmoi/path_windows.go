@@ -0,0 +1,28 @@
+// +build windows
+
+package chezmoi
+
+import (
+	"strings"
+)
+
+const (
+	extendedLengthPrefix    = `\\?\`
+	uncExtendedLengthPrefix = `\\?\UNC\`
+)
+
+// NormalPath returns path without any extended-length prefix, so that paths
+// given in extended-length form, for example \\?\C:\Users\user or
+// \\?\UNC\server\share\user, can be compared with paths in normal form. The os
+// package converts long absolute paths back to extended-length form when
+// calling the Windows API.
+func NormalPath(path string) string {
+	switch {
+	case strings.HasPrefix(path, uncExtendedLengthPrefix):
+		return `\\` + strings.TrimPrefix(path, uncExtendedLengthPrefix)
+	case strings.HasPrefix(path, extendedLengthPrefix) && len(path) >= len(extendedLengthPrefix)+2 && path[len(extendedLengthPrefix)+1] == ':':
+		return strings.TrimPrefix(path, extendedLengthPrefix)
+	default:
+		return path
+	}
+}
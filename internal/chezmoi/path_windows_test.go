@@ -0,0 +1,41 @@
+// +build windows
+
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalPath(t *testing.T) {
+	for _, tc := range []struct {
+		path string
+		want string
+	}{
+		{
+			path: `C:\Users\user`,
+			want: `C:\Users\user`,
+		},
+		{
+			path: `\\?\C:\Users\user`,
+			want: `C:\Users\user`,
+		},
+		{
+			path: `\\server\share\user`,
+			want: `\\server\share\user`,
+		},
+		{
+			path: `\\?\UNC\server\share\user`,
+			want: `\\server\share\user`,
+		},
+		{
+			path: `\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\user`,
+			want: `\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\user`,
+		},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.want, NormalPath(tc.path))
+		})
+	}
+}
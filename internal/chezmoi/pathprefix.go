@@ -0,0 +1,118 @@
+package chezmoi
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pathPrefixRegexp matches a path prefix in a source directory name, for
+// example {xdg-config}.
+var pathPrefixRegexp = regexp.MustCompile(`\A\{[a-z][a-z0-9-]*\}\z`)
+
+// targetDirNames returns the target directory names for the source directory
+// names dns. Any path prefix in the first component is replaced with its value
+// from ts.PathPrefixes, and then the longest leading directory names that
+// match a key in ts.DestDirOverrides are replaced with its value. If a
+// replacement is an absolute path then the first target directory name is the
+// root directory.
+func (ts *TargetState) targetDirNames(dns []string) ([]string, error) {
+	if len(dns) == 0 {
+		return dns, nil
+	}
+	if pathPrefixRegexp.MatchString(dns[0]) {
+		prefix, ok := ts.PathPrefixes[dns[0]]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%s: unknown path prefix", dns[0])
+		case prefix == "":
+			return nil, fmt.Errorf("%s: path prefix not set", dns[0])
+		}
+		dns = append(splitTargetName(filepath.Clean(prefix)), dns[1:]...)
+	}
+	for i := len(dns); i > 0; i-- {
+		if override, ok := ts.DestDirOverrides[filepath.Join(dns[:i]...)]; ok {
+			return append(splitTargetName(filepath.Clean(override)), dns[i:]...), nil
+		}
+	}
+	return dns, nil
+}
+
+// TargetPath returns the path of the target with targetName in destDir.
+// Absolute target names are outside destDir.
+func TargetPath(destDir, targetName string) string {
+	if filepath.IsAbs(targetName) {
+		return targetName
+	}
+	return filepath.Join(destDir, targetName)
+}
+
+// splitTargetName splits targetName into its components. The first component
+// of an absolute target name is the root directory.
+func splitTargetName(targetName string) []string {
+	if !filepath.IsAbs(targetName) {
+		return splitPathList(targetName)
+	}
+	root := filepath.VolumeName(targetName) + string(filepath.Separator)
+	if targetName == root {
+		return []string{root}
+	}
+	return append([]string{root}, strings.Split(strings.TrimPrefix(targetName, root), string(filepath.Separator))...)
+}
+
+// findOrCreateEntries returns the entries of the directory with target names
+// dirNames, creating any missing directories implicitly.
+func (ts *TargetState) findOrCreateEntries(dirNames []string) (map[string]Entry, error) {
+	entries := ts.Entries
+	parentDirSourceName := ""
+	for i, dirName := range dirNames {
+		entry, ok := entries[dirName]
+		if !ok {
+			sourceName := DirAttributes{
+				Name: dirName,
+				Perm: 0777,
+			}.SourceName()
+			if parentDirSourceName != "" {
+				sourceName = filepath.Join(parentDirSourceName, sourceName)
+			}
+			dir := newDir(sourceName, filepath.Join(dirNames[:i+1]...), false, 0777)
+			dir.implicit = true
+			entries[dirName] = dir
+			entry = dir
+		}
+		dir, ok := entry.(*Dir)
+		if !ok {
+			return nil, fmt.Errorf("%s: not a directory", filepath.Join(dirNames[:i+1]...))
+		}
+		entries = dir.Entries
+		parentDirSourceName = dir.sourceName
+	}
+	return entries, nil
+}
+
+// addDirEntry adds dir to entries as name. If a directory with the same name
+// already exists, for example because two source directories refer to the same
+// target directory, then their entries are merged.
+func addDirEntry(entries map[string]Entry, name string, dir *Dir) error {
+	entry, ok := entries[name]
+	if !ok {
+		entries[name] = dir
+		return nil
+	}
+	existingDir, ok := entry.(*Dir)
+	if !ok {
+		return fmt.Errorf("%s: not a directory", dir.targetName)
+	}
+	for entryName, entry := range existingDir.Entries {
+		dir.Entries[entryName] = entry
+	}
+	if !existingDir.implicit && dir.implicit {
+		dir.sourceName = existingDir.sourceName
+		dir.Exact = existingDir.Exact
+		dir.Perm = existingDir.Perm
+		dir.implicit = false
+	}
+	entries[name] = dir
+	return nil
+}
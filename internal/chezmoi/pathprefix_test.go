@@ -0,0 +1,363 @@
+package chezmoi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestPathPrefixes(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		root             interface{}
+		pathPrefixes     map[string]string
+		destDirOverrides map[string]string
+		umask            os.FileMode
+		privileged       bool
+		wantErr          bool
+		tests            interface{}
+	}{
+		{
+			name: "simple",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/{xdg-config}/foo/bar": "baz",
+			},
+			pathPrefixes: map[string]string{
+				"{xdg-config}": ".config",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.config/foo/bar",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("baz"),
+				),
+			},
+		},
+		{
+			name: "nested",
+			root: map[string]interface{}{
+				"/home/user/Library": &vfst.Dir{Perm: 0700},
+				"/home/user/.local/share/chezmoi/{xdg-config}/foo": "bar",
+			},
+			pathPrefixes: map[string]string{
+				"{xdg-config}": filepath.Join("Library", "Application Support"),
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/Library",
+					vfst.TestIsDir,
+					vfst.TestModePerm(0700),
+				),
+				vfst.TestPath("/home/user/Library/Application Support",
+					vfst.TestIsDir,
+					vfst.TestModePerm(0755),
+				),
+				vfst.TestPath("/home/user/Library/Application Support/foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("bar"),
+				),
+			},
+		},
+		{
+			name: "existing_dir_perm_unchanged",
+			root: map[string]interface{}{
+				"/home/user/.config": &vfst.Dir{Perm: 0700},
+				"/home/user/.local/share/chezmoi/{xdg-config}/foo": "bar",
+			},
+			pathPrefixes: map[string]string{
+				"{xdg-config}": ".config",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.config",
+					vfst.TestIsDir,
+					vfst.TestModePerm(0700),
+				),
+			},
+		},
+		{
+			name: "merge",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"private_dot_config/foo": "foo",
+					"{xdg-data}/bar":         "bar",
+					"{xdg-config}/baz":       "baz",
+				},
+			},
+			pathPrefixes: map[string]string{
+				"{xdg-config}": ".config",
+				"{xdg-data}":   ".config",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.config",
+					vfst.TestIsDir,
+					vfst.TestModePerm(0700),
+				),
+				vfst.TestPath("/home/user/.config/foo",
+					vfst.TestContentsString("foo"),
+				),
+				vfst.TestPath("/home/user/.config/bar",
+					vfst.TestContentsString("bar"),
+				),
+				vfst.TestPath("/home/user/.config/baz",
+					vfst.TestContentsString("baz"),
+				),
+			},
+		},
+		{
+			name: "ignore",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/{xdg-config}": map[string]interface{}{
+					".chezmoiignore": "foo\n",
+					"foo":            "foo",
+					"bar":            "bar",
+				},
+			},
+			pathPrefixes: map[string]string{
+				"{xdg-config}": ".config",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.config/foo",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.config/bar",
+					vfst.TestContentsString("bar"),
+				),
+			},
+		},
+		{
+			name: "root",
+			root: map[string]interface{}{
+				"/etc": &vfst.Dir{Perm: 0755},
+				"/home/user/.local/share/chezmoi/{root}/etc": map[string]interface{}{
+					"foo":             "foo",
+					"symlink_bar":     "foo",
+					"private_dir/baz": "baz",
+				},
+			},
+			pathPrefixes: map[string]string{
+				"{root}": "/",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/etc/foo",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0644),
+					vfst.TestContentsString("foo"),
+				),
+				vfst.TestPath("/etc/bar",
+					vfst.TestModeType(os.ModeSymlink),
+					vfst.TestSymlinkTarget("foo"),
+				),
+				vfst.TestPath("/etc/dir",
+					vfst.TestIsDir,
+					vfst.TestModePerm(0700),
+				),
+				vfst.TestPath("/home/user/etc",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name: "root_umask",
+			root: map[string]interface{}{
+				"/etc": &vfst.Dir{Perm: 0755},
+				"/home/user/.local/share/chezmoi/{root}/etc": map[string]interface{}{
+					"foo":              "foo",
+					"exact_dir/bar":    "bar",
+					"private_dir2/baz": "baz",
+				},
+			},
+			pathPrefixes: map[string]string{
+				"{root}": "/",
+			},
+			umask: 077,
+			tests: []vfst.Test{
+				vfst.TestPath("/etc",
+					vfst.TestIsDir,
+					vfst.TestModePerm(0755),
+				),
+				vfst.TestPath("/etc/foo",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0600),
+				),
+				vfst.TestPath("/etc/dir",
+					vfst.TestIsDir,
+					vfst.TestModePerm(0700),
+				),
+				vfst.TestPath("/etc/dir2",
+					vfst.TestIsDir,
+					vfst.TestModePerm(0700),
+				),
+			},
+		},
+		{
+			name: "root_privileged",
+			root: map[string]interface{}{
+				"/etc": map[string]interface{}{
+					"bar": "old",
+				},
+				"/home/user/.local/share/chezmoi/{root}/etc": map[string]interface{}{
+					"foo":             "foo",
+					"bar":             "bar",
+					"symlink_baz":     "foo",
+					"symlink_quux":    "-foo",
+					"private_dir/qux": "qux",
+				},
+			},
+			pathPrefixes: map[string]string{
+				"{root}": "/",
+			},
+			privileged: true,
+			tests: []vfst.Test{
+				vfst.TestPath("/etc/foo",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0644),
+					vfst.TestContentsString("foo"),
+				),
+				vfst.TestPath("/etc/bar",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("bar"),
+				),
+				vfst.TestPath("/etc/baz",
+					vfst.TestModeType(os.ModeSymlink),
+					vfst.TestSymlinkTarget("foo"),
+				),
+				vfst.TestPath("/etc/quux",
+					vfst.TestModeType(os.ModeSymlink),
+					vfst.TestSymlinkTarget("-foo"),
+				),
+				vfst.TestPath("/etc/dir",
+					vfst.TestIsDir,
+					vfst.TestModePerm(0700),
+				),
+				vfst.TestPath("/etc/dir/qux",
+					vfst.TestContentsString("qux"),
+				),
+			},
+		},
+		{
+			name: "dest_dir_override",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_config": map[string]interface{}{
+					"Code/User/settings.json": "{}",
+					"git/config":              "[core]",
+				},
+			},
+			destDirOverrides: map[string]string{
+				filepath.Join(".config", "Code"): filepath.Join("Library", "Application Support", "Code"),
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/Library/Application Support/Code/User/settings.json",
+					vfst.TestContentsString("{}"),
+				),
+				vfst.TestPath("/home/user/.config/Code",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.config/git/config",
+					vfst.TestContentsString("[core]"),
+				),
+			},
+		},
+		{
+			name: "dest_dir_override_longest_match",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/{xdg-config}/foo": map[string]interface{}{
+					"bar/baz": "baz",
+					"qux":     "qux",
+				},
+			},
+			pathPrefixes: map[string]string{
+				"{xdg-config}": ".config",
+			},
+			destDirOverrides: map[string]string{
+				filepath.Join(".config", "foo"):        "foo",
+				filepath.Join(".config", "foo", "bar"): "bar",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/bar/baz",
+					vfst.TestContentsString("baz"),
+				),
+				vfst.TestPath("/home/user/foo/qux",
+					vfst.TestContentsString("qux"),
+				),
+			},
+		},
+		{
+			name: "dest_dir_override_absolute",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_wsl/wsl.conf": "[boot]",
+			},
+			destDirOverrides: map[string]string{
+				".wsl": "/etc",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/etc/wsl.conf",
+					vfst.TestContentsString("[boot]"),
+				),
+			},
+		},
+		{
+			name: "unknown",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/{xdg-unknown}/foo": "bar",
+			},
+			wantErr: true,
+		},
+		{
+			name: "not_set",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/{xdg-runtime}/foo": "bar",
+			},
+			pathPrefixes: map[string]string{
+				"{xdg-runtime}": "",
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			umask := os.FileMode(022)
+			if tc.umask != 0 {
+				umask = tc.umask
+			}
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithDestDirOverrides(tc.destDirOverrides),
+				WithPathPrefixes(tc.pathPrefixes),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+				WithUmask(umask),
+			)
+			err = ts.Populate(fs, nil)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			applyOptions := &ApplyOptions{
+				DestDir:           ts.DestDir,
+				Ignore:            ts.TargetIgnore.Match,
+				ScriptStateBucket: []byte("script"),
+				Stdout:            os.Stdout,
+				Umask:             umask,
+			}
+			if tc.privileged {
+				if runtime.GOOS == "windows" {
+					t.Skip("privilege escalation is not supported on Windows")
+				}
+				// Use env as a privilege escalation command that does not
+				// escalate privileges.
+				applyOptions.PrivilegedMutator = NewPrivilegedMutator(fs, "env", nil, func() (string, func(), error) {
+					tempDir, err := ioutil.TempDir("", "chezmoi-test")
+					return tempDir, func() { _ = os.RemoveAll(tempDir) }, err
+				})
+			}
+			require.NoError(t, ts.Apply(fs, NewFSMutator(fs), false, applyOptions))
+			vfst.RunTests(t, fs, "", tc.tests)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package chezmoi
 
 import (
+	"sort"
+
 	"github.com/bmatcuk/doublestar"
 )
 
@@ -23,6 +25,7 @@ func (ps *PatternSet) Add(pattern string, include bool) error {
 	if _, err := doublestar.PathMatch(pattern, ""); err != nil {
 		return nil
 	}
+	pattern = normalizeName(pattern)
 	if include {
 		ps.includes[pattern] = struct{}{}
 	} else {
@@ -33,6 +36,7 @@ func (ps *PatternSet) Add(pattern string, include bool) error {
 
 // Match returns if name matches any pattern in ps.
 func (ps *PatternSet) Match(name string) bool {
+	name = normalizeName(name)
 	for pattern := range ps.excludes {
 		if ok, _ := doublestar.PathMatch(pattern, name); ok {
 			return false
@@ -45,3 +49,22 @@ func (ps *PatternSet) Match(name string) bool {
 	}
 	return false
 }
+
+// MatchingPatterns returns the sorted patterns in ps that match name. Exclude
+// patterns are prefixed with an exclamation mark.
+func (ps *PatternSet) MatchingPatterns(name string) []string {
+	name = normalizeName(name)
+	var patterns []string
+	for pattern := range ps.includes {
+		if ok, _ := doublestar.PathMatch(pattern, name); ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+	for pattern := range ps.excludes {
+		if ok, _ := doublestar.PathMatch(pattern, name); ok {
+			patterns = append(patterns, "!"+pattern)
+		}
+	}
+	sort.Strings(patterns)
+	return patterns
+}
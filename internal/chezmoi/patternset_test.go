@@ -74,6 +74,17 @@ func TestPatternSet(t *testing.T) {
 	}
 }
 
+func TestPatternSetMatchingPatterns(t *testing.T) {
+	ps := mustNewPatternSet(t, map[string]bool{
+		"b*":  true,
+		"baz": false,
+		"foo": true,
+	})
+	assert.Equal(t, []string(nil), ps.MatchingPatterns("qux"))
+	assert.Equal(t, []string{"b*"}, ps.MatchingPatterns("bar"))
+	assert.Equal(t, []string{"!baz", "b*"}, ps.MatchingPatterns("baz"))
+}
+
 func mustNewPatternSet(t *testing.T, patterns map[string]bool) *PatternSet {
 	ps := NewPatternSet()
 	for pattern, exclude := range patterns {
@@ -0,0 +1,154 @@
+package chezmoi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A PrivilegedMutator makes changes to a vfs.FS by running commands through a
+// privilege escalation command, like sudo or doas.
+type PrivilegedMutator struct {
+	fs          vfs.FS
+	command     string
+	args        []string
+	makeTempDir func() (string, func(), error)
+}
+
+// NewPrivilegedMutator returns a new PrivilegedMutator that acts on fs by
+// running commands with command and args. makeTempDir is called to create a
+// private temporary directory, and returns its name and a function that
+// removes it, in which the contents of files are staged before they are
+// installed.
+func NewPrivilegedMutator(fs vfs.FS, command string, args []string, makeTempDir func() (string, func(), error)) *PrivilegedMutator {
+	return &PrivilegedMutator{
+		fs:          fs,
+		command:     command,
+		args:        args,
+		makeTempDir: makeTempDir,
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *PrivilegedMutator) Chmod(name string, mode os.FileMode) error {
+	rawName, err := m.fs.RawPath(name)
+	if err != nil {
+		return err
+	}
+	return m.run("chmod", fmt.Sprintf("%03o", mode.Perm()), "--", rawName)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *PrivilegedMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return cmd.Output()
+}
+
+// Link implements Mutator.Link.
+func (m *PrivilegedMutator) Link(oldname, newname string) error {
+	rawOldname, err := m.fs.RawPath(oldname)
+	if err != nil {
+		return err
+	}
+	rawNewname, err := m.fs.RawPath(newname)
+	if err != nil {
+		return err
+	}
+	return m.run("ln", "-f", "--", rawOldname, rawNewname)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *PrivilegedMutator) Mkdir(name string, perm os.FileMode) error {
+	rawName, err := m.fs.RawPath(name)
+	if err != nil {
+		return err
+	}
+	return m.run("mkdir", "-m", fmt.Sprintf("%03o", perm.Perm()), "--", rawName)
+}
+
+// Mkfifo implements Mutator.Mkfifo.
+func (m *PrivilegedMutator) Mkfifo(name string, perm os.FileMode) error {
+	rawName, err := m.fs.RawPath(name)
+	if err != nil {
+		return err
+	}
+	return m.run("mkfifo", "-m", fmt.Sprintf("%03o", perm.Perm()), "--", rawName)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *PrivilegedMutator) RemoveAll(name string) error {
+	rawName, err := m.fs.RawPath(name)
+	if err != nil {
+		return err
+	}
+	return m.run("rm", "-rf", "--", rawName)
+}
+
+// Rename implements Mutator.Rename.
+func (m *PrivilegedMutator) Rename(oldpath, newpath string) error {
+	rawOldpath, err := m.fs.RawPath(oldpath)
+	if err != nil {
+		return err
+	}
+	rawNewpath, err := m.fs.RawPath(newpath)
+	if err != nil {
+		return err
+	}
+	return m.run("mv", "-f", "--", rawOldpath, rawNewpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *PrivilegedMutator) RunCmd(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
+
+// Stat implements Mutator.Stat.
+func (m *PrivilegedMutator) Stat(name string) (os.FileInfo, error) {
+	return m.fs.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile. The data, which may be a decrypted
+// secret, is written to a file in a private temporary directory which is then
+// installed as filename.
+func (m *PrivilegedMutator) WriteFile(filename string, data []byte, perm os.FileMode, currData []byte) error {
+	rawFilename, err := m.fs.RawPath(filename)
+	if err != nil {
+		return err
+	}
+	tempDir, removeTempDir, err := m.makeTempDir()
+	if err != nil {
+		return err
+	}
+	defer removeTempDir()
+	tempFilename := filepath.Join(tempDir, "contents")
+	if err := ioutil.WriteFile(tempFilename, data, 0600); err != nil {
+		return err
+	}
+	return m.run("install", "-m", fmt.Sprintf("%03o", perm.Perm()), "--", tempFilename, rawFilename)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *PrivilegedMutator) WriteSymlink(oldname, newname string) error {
+	rawNewname, err := m.fs.RawPath(newname)
+	if err != nil {
+		return err
+	}
+	return m.run("ln", "-sfn", "--", oldname, rawNewname)
+}
+
+// run runs name with args using m's command and args.
+func (m *PrivilegedMutator) run(name string, args ...string) error {
+	argv := append([]string{}, m.args...)
+	argv = append(argv, name)
+	argv = append(argv, args...)
+	cmd := exec.Command(m.command, argv...)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	if output, err := cmd.Output(); err != nil {
+		return fmt.Errorf("%s %s: %w\n%s", m.command, ShellQuoteArgs(argv), err, output)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+)
+
+// A RecordingMutator wraps another Mutator and records the names of the files
+// that it successfully changes.
+type RecordingMutator struct {
+	m     Mutator
+	names map[string]struct{}
+}
+
+// NewRecordingMutator returns a new RecordingMutator.
+func NewRecordingMutator(m Mutator) *RecordingMutator {
+	return &RecordingMutator{
+		m:     m,
+		names: make(map[string]struct{}),
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *RecordingMutator) Chmod(name string, mode os.FileMode) error {
+	return m.record(name, m.m.Chmod(name, mode))
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *RecordingMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Link implements Mutator.Link.
+func (m *RecordingMutator) Link(oldname, newname string) error {
+	return m.record(newname, m.m.Link(oldname, newname))
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *RecordingMutator) Mkdir(name string, perm os.FileMode) error {
+	return m.record(name, m.m.Mkdir(name, perm))
+}
+
+// Mkfifo implements Mutator.Mkfifo.
+func (m *RecordingMutator) Mkfifo(name string, perm os.FileMode) error {
+	return m.record(name, m.m.Mkfifo(name, perm))
+}
+
+// Names returns the names of the files that m has changed.
+func (m *RecordingMutator) Names() map[string]struct{} {
+	return m.names
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *RecordingMutator) RemoveAll(name string) error {
+	return m.record(name, m.m.RemoveAll(name))
+}
+
+// Rename implements Mutator.Rename.
+func (m *RecordingMutator) Rename(oldpath, newpath string) error {
+	return m.record(newpath, m.m.Rename(oldpath, newpath))
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *RecordingMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *RecordingMutator) Stat(path string) (os.FileInfo, error) {
+	return m.m.Stat(path)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *RecordingMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	return m.record(name, m.m.WriteFile(name, data, perm, currData))
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *RecordingMutator) WriteSymlink(oldname, newname string) error {
+	return m.record(newname, m.m.WriteSymlink(oldname, newname))
+}
+
+func (m *RecordingMutator) record(name string, err error) error {
+	if err == nil {
+		m.names[name] = struct{}{}
+	}
+	return err
+}
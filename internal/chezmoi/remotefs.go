@@ -0,0 +1,371 @@
+package chezmoi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// remoteNotExistExitCode is the exit code of the remote commands run by a
+// RemoteFS when the file that they operate on does not exist.
+const remoteNotExistExitCode = 66
+
+var errRemoteUnsupported = errors.New("not supported on remote systems")
+
+// Remote shell scripts run by a RemoteFS. Each receives the path that it
+// operates on as $1. They are kept on a single line so that they can be run by
+// any login shell, and only use commands specified by POSIX, except for
+// readlink, which is available everywhere in practice.
+const (
+	remoteLstatScript    = `if [ -e "$1" ] || [ -L "$1" ]; then exec ls -ldn -- "$1"; else exit 66; fi`
+	remoteStatScript     = `if [ -e "$1" ]; then exec ls -ldnL -- "$1"; else exit 66; fi`
+	remoteReadFileScript = `if [ -e "$1" ]; then exec cat -- "$1"; else exit 66; fi`
+	remoteReadlinkScript = `if [ -L "$1" ]; then exec readlink -- "$1"; elif [ -e "$1" ]; then exit 1; else exit 66; fi`
+	remoteReadDirScript  = `if [ ! -e "$1" ]; then exit 66; fi; cd -- "$1" || exit 1; for f in * .[!.]* ..?*; do if [ -e "$f" ] || [ -L "$f" ]; then printf '%s\000' "$f"; ls -ldn -- "$f" | head -n 1; fi; done`
+)
+
+// A RemoteFS is a read-only vfs.FS on a remote system, for example a remote
+// host accessed with ssh or a container accessed with docker exec. Every
+// operation runs a command on the remote system, so the remote system only
+// needs a POSIX shell.
+type RemoteFS struct {
+	command string
+	args    []string
+}
+
+// A remoteFileInfo is an os.FileInfo parsed from the output of ls -ldn.
+type remoteFileInfo struct {
+	name string
+	size int64
+	mode os.FileMode
+}
+
+// NewRemoteFS returns a new RemoteFS that runs commands on the remote system
+// with command and args. The remote command is appended to args as a single
+// argument, which must be interpreted by a shell on the remote system, as ssh
+// does and as docker exec does if args end with sh -c.
+func NewRemoteFS(command string, args []string) *RemoteFS {
+	return &RemoteFS{
+		command: command,
+		args:    args,
+	}
+}
+
+// Command returns an *exec.Cmd that runs remoteCommand, which is interpreted
+// by a shell, on the remote system.
+func (fs *RemoteFS) Command(remoteCommand string) *exec.Cmd {
+	argv := append([]string{}, fs.args...)
+	argv = append(argv, remoteCommand)
+	//nolint:gosec
+	return exec.Command(fs.command, argv...)
+}
+
+// ScriptCommand returns the remote command that runs the POSIX shell script
+// script with args.
+func (fs *RemoteFS) ScriptCommand(script string, args ...string) string {
+	return remoteQuoteArgs(append([]string{"sh", "-c", script, "sh"}, args...))
+}
+
+// Output runs script with args on the remote system, with stdin as its
+// standard input, and returns its standard output.
+func (fs *RemoteFS) Output(stdin []byte, script string, args ...string) ([]byte, error) {
+	cmd := fs.Command(fs.ScriptCommand(script, args...))
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == remoteNotExistExitCode {
+			return nil, os.ErrNotExist
+		}
+		if stderr.Len() != 0 {
+			return nil, fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// Chmod implements vfs.FS.Chmod.
+func (fs *RemoteFS) Chmod(name string, mode os.FileMode) error {
+	return &os.PathError{Op: "chmod", Path: name, Err: os.ErrPermission}
+}
+
+// Chown implements vfs.FS.Chown.
+func (fs *RemoteFS) Chown(name string, uid, gid int) error {
+	return &os.PathError{Op: "chown", Path: name, Err: os.ErrPermission}
+}
+
+// Chtimes implements vfs.FS.Chtimes.
+func (fs *RemoteFS) Chtimes(name string, atime, mtime time.Time) error {
+	return &os.PathError{Op: "chtimes", Path: name, Err: os.ErrPermission}
+}
+
+// Create implements vfs.FS.Create.
+func (fs *RemoteFS) Create(name string) (*os.File, error) {
+	return nil, &os.PathError{Op: "create", Path: name, Err: os.ErrPermission}
+}
+
+// Glob implements vfs.FS.Glob.
+func (fs *RemoteFS) Glob(pattern string) ([]string, error) {
+	return nil, &os.PathError{Op: "glob", Path: pattern, Err: errRemoteUnsupported}
+}
+
+// Lchown implements vfs.FS.Lchown.
+func (fs *RemoteFS) Lchown(name string, uid, gid int) error {
+	return &os.PathError{Op: "lchown", Path: name, Err: os.ErrPermission}
+}
+
+// Lstat implements vfs.FS.Lstat.
+func (fs *RemoteFS) Lstat(name string) (os.FileInfo, error) {
+	return fs.stat("lstat", remoteLstatScript, name)
+}
+
+// Mkdir implements vfs.FS.Mkdir.
+func (fs *RemoteFS) Mkdir(name string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrPermission}
+}
+
+// Open implements vfs.FS.Open.
+func (fs *RemoteFS) Open(name string) (*os.File, error) {
+	return nil, &os.PathError{Op: "open", Path: name, Err: errRemoteUnsupported}
+}
+
+// OpenFile implements vfs.FS.OpenFile.
+func (fs *RemoteFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return nil, &os.PathError{Op: "open", Path: name, Err: errRemoteUnsupported}
+}
+
+// PathSeparator implements vfs.FS.PathSeparator.
+func (fs *RemoteFS) PathSeparator() rune {
+	return '/'
+}
+
+// RawPath implements vfs.FS.RawPath.
+func (fs *RemoteFS) RawPath(name string) (string, error) {
+	return name, nil
+}
+
+// ReadDir implements vfs.FS.ReadDir.
+func (fs *RemoteFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	output, err := fs.Output(nil, remoteReadDirScript, dirname)
+	if err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: dirname, Err: err}
+	}
+	infos, err := parseRemoteReadDirOutput(output)
+	if err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: dirname, Err: err}
+	}
+	return infos, nil
+}
+
+// ReadFile implements vfs.FS.ReadFile.
+func (fs *RemoteFS) ReadFile(filename string) ([]byte, error) {
+	output, err := fs.Output(nil, remoteReadFileScript, filename)
+	if err != nil {
+		return nil, &os.PathError{Op: "read", Path: filename, Err: err}
+	}
+	return output, nil
+}
+
+// Readlink implements vfs.FS.Readlink.
+func (fs *RemoteFS) Readlink(name string) (string, error) {
+	output, err := fs.Output(nil, remoteReadlinkScript, name)
+	if err != nil {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+// Remove implements vfs.FS.Remove.
+func (fs *RemoteFS) Remove(name string) error {
+	return &os.PathError{Op: "remove", Path: name, Err: os.ErrPermission}
+}
+
+// RemoveAll implements vfs.FS.RemoveAll.
+func (fs *RemoteFS) RemoveAll(name string) error {
+	return &os.PathError{Op: "removeall", Path: name, Err: os.ErrPermission}
+}
+
+// Rename implements vfs.FS.Rename.
+func (fs *RemoteFS) Rename(oldpath, newpath string) error {
+	return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrPermission}
+}
+
+// Stat implements vfs.FS.Stat.
+func (fs *RemoteFS) Stat(name string) (os.FileInfo, error) {
+	return fs.stat("stat", remoteStatScript, name)
+}
+
+// Symlink implements vfs.FS.Symlink.
+func (fs *RemoteFS) Symlink(oldname, newname string) error {
+	return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: os.ErrPermission}
+}
+
+// Truncate implements vfs.FS.Truncate.
+func (fs *RemoteFS) Truncate(name string, size int64) error {
+	return &os.PathError{Op: "truncate", Path: name, Err: os.ErrPermission}
+}
+
+// WriteFile implements vfs.FS.WriteFile.
+func (fs *RemoteFS) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	return &os.PathError{Op: "writefile", Path: filename, Err: os.ErrPermission}
+}
+
+func (fs *RemoteFS) stat(op, script, name string) (os.FileInfo, error) {
+	output, err := fs.Output(nil, script, name)
+	if err != nil {
+		return nil, &os.PathError{Op: op, Path: name, Err: err}
+	}
+	line := string(output)
+	if i := strings.IndexByte(line, '\n'); i != -1 {
+		line = line[:i]
+	}
+	info, err := parseRemoteFileInfo(name[strings.LastIndexByte(name, '/')+1:], line)
+	if err != nil {
+		return nil, &os.PathError{Op: op, Path: name, Err: err}
+	}
+	return info, nil
+}
+
+// Name implements os.FileInfo.Name.
+func (i *remoteFileInfo) Name() string { return i.name }
+
+// Size implements os.FileInfo.Size.
+func (i *remoteFileInfo) Size() int64 { return i.size }
+
+// Mode implements os.FileInfo.Mode.
+func (i *remoteFileInfo) Mode() os.FileMode { return i.mode }
+
+// ModTime implements os.FileInfo.ModTime. The modification time is not known.
+func (i *remoteFileInfo) ModTime() time.Time { return time.Time{} }
+
+// IsDir implements os.FileInfo.IsDir.
+func (i *remoteFileInfo) IsDir() bool { return i.mode.IsDir() }
+
+// Sys implements os.FileInfo.Sys.
+func (i *remoteFileInfo) Sys() interface{} { return nil }
+
+// parseRemoteReadDirOutput parses the output of remoteReadDirScript, which is a
+// sequence of names, each terminated by a NUL byte and followed by the first
+// line of the output of ls -ldn for that name.
+func parseRemoteReadDirOutput(output []byte) ([]os.FileInfo, error) {
+	var infos []os.FileInfo
+	for len(output) != 0 {
+		i := bytes.IndexByte(output, 0)
+		if i == -1 {
+			return nil, errors.New("invalid output")
+		}
+		name := string(output[:i])
+		output = output[i+1:]
+		j := bytes.IndexByte(output, '\n')
+		if j == -1 {
+			j = len(output)
+		}
+		info, err := parseRemoteFileInfo(name, string(output[:j]))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+		if j < len(output) {
+			j++
+		}
+		output = output[j:]
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+	return infos, nil
+}
+
+// parseRemoteFileInfo parses line, a line of the output of ls -ldn for name.
+func parseRemoteFileInfo(name, line string) (*remoteFileInfo, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("%q: invalid ls output", line)
+	}
+	mode, err := parseLSMode(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	info := &remoteFileInfo{
+		name: name,
+		mode: mode,
+	}
+	// The size field of device files contains their major and minor numbers,
+	// which are ignored.
+	if size, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+		info.size = size
+	}
+	return info, nil
+}
+
+// parseLSMode parses the file mode string printed by ls -l, for example
+// drwxr-xr-x. Any trailing alternate access method character, like + or @, is
+// ignored.
+func parseLSMode(s string) (os.FileMode, error) {
+	if len(s) < 10 {
+		return 0, fmt.Errorf("%q: invalid file mode", s)
+	}
+	var mode os.FileMode
+	switch s[0] {
+	case '-':
+	case 'b':
+		mode = os.ModeDevice
+	case 'c':
+		mode = os.ModeDevice | os.ModeCharDevice
+	case 'd':
+		mode = os.ModeDir
+	case 'l':
+		mode = os.ModeSymlink
+	case 'p':
+		mode = os.ModeNamedPipe
+	case 's':
+		mode = os.ModeSocket
+	default:
+		return 0, fmt.Errorf("%q: invalid file mode", s)
+	}
+	for i, special := range []os.FileMode{os.ModeSetuid, os.ModeSetgid, os.ModeSticky} {
+		r, w, x := s[1+3*i], s[2+3*i], s[3+3*i]
+		shift := uint(3 * (2 - i))
+		if r == 'r' {
+			mode |= 04 << shift
+		}
+		if w == 'w' {
+			mode |= 02 << shift
+		}
+		switch x {
+		case 'x':
+			mode |= 01 << shift
+		case 's', 't':
+			mode |= 01<<shift | special
+		case 'S', 'T':
+			mode |= special
+		}
+	}
+	return mode, nil
+}
+
+// remoteQuoteArgs returns args quoted for a POSIX shell. Unlike ShellQuoteArgs,
+// which quotes arguments for display, backslashes are never escaped as they
+// are literal inside single quotes.
+func remoteQuoteArgs(args []string) string {
+	quotedArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "" && !needShellQuoteRegexp.MatchString(arg) {
+			quotedArgs = append(quotedArgs, arg)
+		} else {
+			quotedArgs = append(quotedArgs, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+		}
+	}
+	return strings.Join(quotedArgs, " ")
+}
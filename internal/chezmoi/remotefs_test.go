@@ -0,0 +1,110 @@
+package chezmoi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLSMode(t *testing.T) {
+	for _, tc := range []struct {
+		s            string
+		expectedMode os.FileMode
+		expectedErr  bool
+	}{
+		{
+			s:            "-rw-r--r--",
+			expectedMode: 0644,
+		},
+		{
+			s:            "-rwxr-x---+",
+			expectedMode: 0750,
+		},
+		{
+			s:            "drwxr-xr-x@",
+			expectedMode: os.ModeDir | 0755,
+		},
+		{
+			s:            "lrwxrwxrwx",
+			expectedMode: os.ModeSymlink | 0777,
+		},
+		{
+			s:            "prw-------",
+			expectedMode: os.ModeNamedPipe | 0600,
+		},
+		{
+			s:            "crw-rw-rw-",
+			expectedMode: os.ModeDevice | os.ModeCharDevice | 0666,
+		},
+		{
+			s:            "-rwsr-sr-x",
+			expectedMode: os.ModeSetuid | os.ModeSetgid | 0755,
+		},
+		{
+			s:            "drwxrwxrwt",
+			expectedMode: os.ModeDir | os.ModeSticky | 0777,
+		},
+		{
+			s:            "-rwSr--r-T",
+			expectedMode: os.ModeSetuid | os.ModeSticky | 0644,
+		},
+		{
+			s:           "-rw-r--r",
+			expectedErr: true,
+		},
+		{
+			s:           "?rw-r--r--",
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.s, func(t *testing.T) {
+			actualMode, err := parseLSMode(tc.s)
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedMode, actualMode)
+			}
+		})
+	}
+}
+
+func TestParseRemoteReadDirOutput(t *testing.T) {
+	infos, err := parseRemoteReadDirOutput([]byte("" +
+		"file\000-rw-r--r--    1 1000     1000            5 Jan  1 00:00 file\n" +
+		".dir\000drwx------    2 1000     1000         4096 Jan  1 00:00 .dir\n" +
+		"a link\000lrwxrwxrwx    1 1000     1000            4 Jan  1 00:00 a link -> file\n" +
+		"null\000crw-rw-rw-    1 0        0          1,   3 Jan  1 00:00 null\n",
+	))
+	require.NoError(t, err)
+	type entry struct {
+		name string
+		mode os.FileMode
+		size int64
+	}
+	var actualEntries []entry
+	for _, info := range infos {
+		actualEntries = append(actualEntries, entry{
+			name: info.Name(),
+			mode: info.Mode(),
+			size: info.Size(),
+		})
+	}
+	assert.Equal(t, []entry{
+		{name: ".dir", mode: os.ModeDir | 0700, size: 4096},
+		{name: "a link", mode: os.ModeSymlink | 0777, size: 4},
+		{name: "file", mode: 0644, size: 5},
+		{name: "null", mode: os.ModeDevice | os.ModeCharDevice | 0666},
+	}, actualEntries)
+
+	_, err = parseRemoteReadDirOutput([]byte("file"))
+	assert.Error(t, err)
+	_, err = parseRemoteReadDirOutput([]byte("file\000total 0\n"))
+	assert.Error(t, err)
+}
+
+func TestRemoteQuoteArgs(t *testing.T) {
+	assert.Equal(t, "printf '%s\\n' '' 'it'\\''s' a/b", remoteQuoteArgs([]string{"printf", `%s\n`, "", "it's", "a/b"}))
+}
@@ -0,0 +1,135 @@
+package chezmoi
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// remoteWriteFileScript writes its standard input to a temporary file next to
+// $1, sets the temporary file's permissions to $2, and then renames it to $1.
+const remoteWriteFileScript = `t=$(mktemp "$1.XXXXXXXX") || exit 1; if cat > "$t" && chmod "$2" "$t" && mv -f "$t" "$1"; then exit 0; fi; rm -f "$t"; exit 1`
+
+// A RemoteMutator makes changes to a remote system by running commands on it.
+type RemoteMutator struct {
+	fs *RemoteFS
+}
+
+// NewRemoteMutator returns a new RemoteMutator that makes changes to the
+// remote system of fs.
+func NewRemoteMutator(fs *RemoteFS) *RemoteMutator {
+	return &RemoteMutator{
+		fs: fs,
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *RemoteMutator) Chmod(name string, mode os.FileMode) error {
+	return m.run("chmod", fmt.Sprintf("%03o", mode.Perm()), name)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput. cmd is run on the
+// local host, as idempotent commands are only used to populate template data.
+func (m *RemoteMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return cmd.Output()
+}
+
+// Link implements Mutator.Link.
+func (m *RemoteMutator) Link(oldname, newname string) error {
+	return m.run("ln", "-f", oldname, newname)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *RemoteMutator) Mkdir(name string, perm os.FileMode) error {
+	return m.run("mkdir", "-m", fmt.Sprintf("%03o", perm.Perm()), name)
+}
+
+// Mkfifo implements Mutator.Mkfifo.
+func (m *RemoteMutator) Mkfifo(name string, perm os.FileMode) error {
+	return m.run("mkfifo", "-m", fmt.Sprintf("%03o", perm.Perm()), name)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *RemoteMutator) RemoveAll(name string) error {
+	return m.run("rm", "-rf", name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *RemoteMutator) Rename(oldpath, newpath string) error {
+	return m.run("mv", "-f", oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd. cmd is run on the remote system, in
+// cmd.Dir if it is set, with the CHEZMOI_* variables from its environment.
+func (m *RemoteMutator) RunCmd(cmd *exec.Cmd) error {
+	remoteCmd := m.fs.Command(remoteCommandLine(cmd.Dir, cmd.Env, cmd.Args))
+	remoteCmd.Stdin = cmd.Stdin
+	remoteCmd.Stdout = cmd.Stdout
+	remoteCmd.Stderr = cmd.Stderr
+	return remoteCmd.Run()
+}
+
+// Stat implements Mutator.Stat.
+func (m *RemoteMutator) Stat(name string) (os.FileInfo, error) {
+	return m.fs.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile. The data is written to a temporary
+// file which is then renamed to filename, so filename is replaced atomically.
+func (m *RemoteMutator) WriteFile(filename string, data []byte, perm os.FileMode, currData []byte) error {
+	if _, err := m.fs.Output(data, remoteWriteFileScript, filename, fmt.Sprintf("%03o", perm.Perm())); err != nil {
+		return &os.PathError{Op: "writefile", Path: filename, Err: err}
+	}
+	return nil
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *RemoteMutator) WriteSymlink(oldname, newname string) error {
+	return m.run("ln", "-sfn", oldname, newname)
+}
+
+// NewScriptCmd returns a command that runs a script with contents on the
+// remote system in dir with the CHEZMOI_* variables from env, and a function to
+// remove the script from the remote system after it has run.
+func (m *RemoteMutator) NewScriptCmd(name string, contents []byte, dir string, env []string) (*exec.Cmd, func(), error) {
+	output, err := m.fs.Output(contents, `t=$(mktemp "${TMPDIR:-/tmp}/chezmoi-script.XXXXXXXX") && cat > "$t" && chmod 700 "$t" && printf '%s' "$t"`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", name, err)
+	}
+	remoteName := string(output)
+	cleanup := func() {
+		_ = m.run("rm", "-f", remoteName)
+	}
+	return m.fs.Command(remoteCommandLine(dir, env, []string{remoteName})), cleanup, nil
+}
+
+// run runs name with args on the remote system.
+func (m *RemoteMutator) run(name string, args ...string) error {
+	argv := append([]string{name}, args...)
+	cmd := m.fs.Command(remoteQuoteArgs(argv))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w\n%s", remoteQuoteArgs(argv), err, output)
+	}
+	return nil
+}
+
+// remoteCommandLine returns a shell command line that runs argv in dir, if
+// set, with the CHEZMOI_* variables in env.
+func remoteCommandLine(dir string, env, argv []string) string {
+	var sb strings.Builder
+	if dir != "" {
+		sb.WriteString("cd " + remoteQuoteArgs([]string{dir}) + " && ")
+	}
+	var envArgs []string
+	for _, keyValue := range env {
+		if strings.HasPrefix(keyValue, "CHEZMOI") {
+			envArgs = append(envArgs, keyValue)
+		}
+	}
+	if len(envArgs) != 0 {
+		sb.WriteString("env " + remoteQuoteArgs(envArgs) + " ")
+	}
+	sb.WriteString(remoteQuoteArgs(argv))
+	return sb.String()
+}
@@ -0,0 +1,34 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteCommandLine(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		dir      string
+		env      []string
+		argv     []string
+		expected string
+	}{
+		{
+			name:     "argv",
+			argv:     []string{"/tmp/script"},
+			expected: "/tmp/script",
+		},
+		{
+			name:     "dir_and_env",
+			dir:      "/home/user/my dir",
+			env:      []string{"HOME=/home/local", "CHEZMOI_SOURCE_FILE=run_install.sh", "PATH=/bin"},
+			argv:     []string{"/tmp/script"},
+			expected: "cd '/home/user/my dir' && env CHEZMOI_SOURCE_FILE=run_install.sh /tmp/script",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, remoteCommandLine(tc.dir, tc.env, tc.argv))
+		})
+	}
+}
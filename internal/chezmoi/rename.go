@@ -0,0 +1,86 @@
+package chezmoi
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sort"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A RenameFunc is called when a file being added has the same contents as an
+// existing file in the source state whose target no longer exists. It should
+// rename oldSourcePath to newSourcePath and return true, or return false if
+// the file should be added as a new file instead.
+type RenameFunc func(oldSourcePath, newSourcePath string) (bool, error)
+
+// addRenamedFile adds file, which is not already in the source state, by
+// renaming an existing file with the same contents whose target no longer
+// exists, if there is one and rename agrees. It returns true if file was
+// added.
+func (ts *TargetState) addRenamedFile(fs vfs.FS, file *File, entries map[string]Entry, rename RenameFunc) (bool, error) {
+	if len(file.contents) == 0 {
+		return false, nil
+	}
+	if ts.filesByContentsSHA256 == nil {
+		if err := ts.indexFilesByContentsSHA256(); err != nil {
+			return false, err
+		}
+	}
+	contentsSHA256 := sourceContentsSHA256(file.contents)
+	for _, oldFile := range ts.filesByContentsSHA256[contentsSHA256] {
+		if oldFile.targetName == file.targetName {
+			continue
+		}
+		switch _, err := fs.Lstat(TargetPath(ts.DestDir, oldFile.targetName)); {
+		case os.IsNotExist(err):
+		case err != nil:
+			return false, err
+		default:
+			continue
+		}
+		if renamed, err := rename(filepath.Join(ts.SourceDir, oldFile.sourceName), filepath.Join(ts.SourceDir, file.sourceName)); err != nil || !renamed {
+			return false, err
+		}
+		names := splitTargetName(oldFile.targetName)
+		oldEntries, err := ts.findEntries(names[:len(names)-1])
+		if err != nil {
+			return false, err
+		}
+		delete(oldEntries, names[len(names)-1])
+		entries[filepath.Base(file.targetName)] = file
+		ts.filesByContentsSHA256[contentsSHA256] = append(ts.filesByContentsSHA256[contentsSHA256], file)
+		return true, nil
+	}
+	return false, nil
+}
+
+// indexFilesByContentsSHA256 indexes all unencrypted, non-template,
+// non-empty files in ts by the SHA256 of their contents.
+func (ts *TargetState) indexFilesByContentsSHA256() error {
+	ts.filesByContentsSHA256 = make(map[[sha256.Size]byte][]*File)
+	allEntries := ts.AllEntries()
+	sort.Slice(allEntries, func(i, j int) bool {
+		return allEntries[i].TargetName() < allEntries[j].TargetName()
+	})
+	for _, entry := range allEntries {
+		file, ok := entry.(*File)
+		if !ok || file.Encrypted || file.Template {
+			continue
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		if len(contents) == 0 {
+			continue
+		}
+		contentsSHA256, err := file.ContentsSHA256()
+		if err != nil {
+			return err
+		}
+		ts.filesByContentsSHA256[contentsSHA256] = append(ts.filesByContentsSHA256[contentsSHA256], file)
+	}
+	return nil
+}
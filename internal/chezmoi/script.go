@@ -10,26 +10,31 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
+	"sort"
 	"time"
 
 	vfs "github.com/twpayne/go-vfs"
 )
 
 // FIXME allow encrypted scripts
-// FIXME add pre- and post- attributes
 
 // A ScriptAttributes holds attributes parsed from a source script name.
 type ScriptAttributes struct {
-	Name     string
-	Once     bool
-	Template bool
+	Name      string
+	Once      bool
+	OnChange  bool
+	Before    bool
+	After     bool
+	Template  bool
+	Recursive bool
 }
 
-// A ScriptState represents the state of a script.
+// A ScriptState represents the state of a script. ContentsSHA256 is only
+// recorded for scripts that run on change.
 type ScriptState struct {
-	Name       string    `json:"name"`
-	ExecutedAt time.Time `json:"executedAt"`
+	Name           string    `json:"name"`
+	ExecutedAt     time.Time `json:"executedAt"`
+	ContentsSHA256 string    `json:"contentsSHA256,omitempty"`
 }
 
 // A Script represents a script to run.
@@ -37,6 +42,9 @@ type Script struct {
 	sourceName       string
 	targetName       string
 	Once             bool
+	OnChange         bool
+	Before           bool
+	After            bool
 	Template         bool
 	contents         []byte
 	contentsErr      error
@@ -48,41 +56,42 @@ type scriptConcreteValue struct {
 	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
 	TargetPath string `json:"targetPath" yaml:"targetPath"`
 	Once       bool   `json:"once" yaml:"once"`
+	OnChange   bool   `json:"onchange" yaml:"onchange"`
+	Before     bool   `json:"before" yaml:"before"`
+	After      bool   `json:"after" yaml:"after"`
 	Template   bool   `json:"template" yaml:"template"`
 	Contents   string `json:"contents" yaml:"contents"`
 }
 
 // ParseScriptAttributes parses a source script file name.
 func ParseScriptAttributes(sourceName string) ScriptAttributes {
-	name := strings.TrimPrefix(sourceName, runPrefix)
-	once := false
-	template := false
-	if strings.HasPrefix(name, oncePrefix) {
-		once = true
-		name = strings.TrimPrefix(name, oncePrefix)
-	}
-	if strings.HasSuffix(name, TemplateSuffix) {
-		template = true
-		name = strings.TrimSuffix(name, TemplateSuffix)
-	}
+	t := scriptSourceNameGrammar.tokenize(sourceName)
 	return ScriptAttributes{
-		Name:     name,
-		Once:     once,
-		Template: template,
+		Name:     t.name,
+		Once:     t.prefixes[oncePrefix],
+		OnChange: t.prefixes[onchangePrefix],
+		Before:   t.prefixes[beforePrefix],
+		After:    t.prefixes[afterPrefix],
+		Template: t.template,
 	}
 }
 
 // SourceName returns sa's source name.
 func (sa ScriptAttributes) SourceName() string {
 	sourceName := runPrefix
-	if sa.Once {
+	switch {
+	case sa.Once:
 		sourceName += oncePrefix
+	case sa.OnChange:
+		sourceName += onchangePrefix
 	}
-	sourceName += sa.Name
-	if sa.Template {
-		sourceName += TemplateSuffix
+	switch {
+	case sa.Before:
+		sourceName += beforePrefix
+	case sa.After:
+		sourceName += afterPrefix
 	}
-	return sourceName
+	return scriptSourceNameGrammar.sourceName(sourceName, sa.Name, sa.Template)
 }
 
 // AppendAllEntries returns allEntries unchanged.
@@ -103,10 +112,16 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 		return nil
 	}
 
+	// Run once scripts are keyed by their name and contents, so they run
+	// once for each distinct contents. Run on change scripts are keyed by
+	// their name only, so they run whenever their contents differ from the
+	// last run.
+	contentsSHA256Arr := sha256.Sum256(contents)
+	contentsSHA256 := hex.EncodeToString(contentsSHA256Arr[:])
 	var key []byte
-	if s.Once {
-		contentsKeyArr := sha256.Sum256(contents)
-		key = []byte(s.targetName + ":" + hex.EncodeToString(contentsKeyArr[:]))
+	switch {
+	case s.Once:
+		key = []byte(s.targetName + ":" + contentsSHA256)
 		scriptStateData, err := applyOptions.PersistentState.Get(applyOptions.ScriptStateBucket, key)
 		if err != nil {
 			return err
@@ -114,6 +129,21 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 		if scriptStateData != nil {
 			return nil
 		}
+	case s.OnChange:
+		key = []byte(s.targetName)
+		scriptStateData, err := applyOptions.PersistentState.Get(applyOptions.ScriptStateBucket, key)
+		if err != nil {
+			return err
+		}
+		if scriptStateData != nil {
+			var scriptState ScriptState
+			if err := json.Unmarshal(scriptStateData, &scriptState); err != nil {
+				return err
+			}
+			if scriptState.ContentsSHA256 == contentsSHA256 {
+				return nil
+			}
+		}
 	}
 
 	if applyOptions.Verbose {
@@ -125,42 +155,49 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 		return nil
 	}
 
-	// Write the temporary script file. Put the randomness on the front of the
-	// filename to preserve any file extension for Windows scripts.
-	f, err := ioutil.TempFile("", "*."+filepath.Base(s.targetName))
+	newScriptCmd := newLocalScriptCmd
+	if applyOptions.NewScriptCmd != nil {
+		newScriptCmd = applyOptions.NewScriptCmd
+	}
+	dir := TargetPath(applyOptions.DestDir, filepath.Dir(s.targetName))
+	env := append(os.Environ(), "CHEZMOI_SOURCE_FILE="+s.sourceName)
+	c, cleanup, err := newScriptCmd(s.targetName, contents, dir, env)
 	if err != nil {
 		return err
 	}
-
-	defer func() {
-		_ = os.RemoveAll(f.Name())
-	}()
-	if err := os.Chmod(f.Name(), 0700); err != nil {
-		return err
+	defer cleanup()
+	// Scripts use chezmoi's standard input and output unless newScriptCmd
+	// redirected them.
+	if c.Stdout == nil {
+		c.Stdout = os.Stdout
 	}
-	if _, err := f.Write(contents); err != nil {
-		return err
+	if c.Stderr == nil {
+		c.Stderr = os.Stderr
 	}
-	if err := f.Close(); err != nil {
-		return err
+	if c.Stdin == nil {
+		c.Stdin = os.Stdin
 	}
-
-	// Run the temporary script file.
-	//nolint:gosec
-	c := exec.Command(f.Name())
-	c.Dir = filepath.Join(applyOptions.DestDir, filepath.Dir(s.targetName))
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	c.Stdin = os.Stdin
-	if err := c.Run(); err != nil {
+	if err := applyOptions.runScript(c); err != nil {
+		if interruptedErr := applyOptions.interrupted(); interruptedErr != nil {
+			return &InterruptedError{
+				TargetPaths: []string{TargetPath(applyOptions.DestDir, s.targetName)},
+				Err:         interruptedErr,
+			}
+		}
 		return err
 	}
+	if applyOptions.OnRunScript != nil {
+		applyOptions.OnRunScript(TargetPath(applyOptions.DestDir, s.targetName))
+	}
 
-	if s.Once {
+	if key != nil {
 		scriptState := &ScriptState{
 			Name:       s.sourceName,
 			ExecutedAt: time.Now(),
 		}
+		if s.OnChange {
+			scriptState.ContentsSHA256 = contentsSHA256
+		}
 		scriptStateData, err := json.Marshal(&scriptState)
 		if err != nil {
 			return err
@@ -187,6 +224,9 @@ func (s *Script) ConcreteValue(ignore func(string) bool, sourceDir string, umask
 		SourcePath: filepath.Join(sourceDir, s.SourceName()),
 		TargetPath: s.TargetName(),
 		Once:       s.Once,
+		OnChange:   s.OnChange,
+		Before:     s.Before,
+		After:      s.After,
 		Template:   s.Template,
 		Contents:   string(contents),
 	}, nil
@@ -220,6 +260,26 @@ func (s *Script) TargetName() string {
 	return s.targetName
 }
 
+// findScripts returns all the Scripts in entries, at any depth, for which f
+// returns true, sorted by target name.
+func findScripts(entries []Entry, f func(*Script) bool) []Entry {
+	var scripts []Entry
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case *Dir:
+			scripts = append(scripts, findScripts(sortedEntries(entry.Entries), f)...)
+		case *Script:
+			if f(entry) {
+				scripts = append(scripts, entry)
+			}
+		}
+	}
+	sort.Slice(scripts, func(i, j int) bool {
+		return scripts[i].TargetName() < scripts[j].TargetName()
+	})
+	return scripts
+}
+
 // archive writes s to w.
 func (s *Script) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
 	if ignore(s.targetName) {
@@ -240,3 +300,36 @@ func (s *Script) archive(w *tar.Writer, ignore func(string) bool, headerTemplate
 	_, err = w.Write(contents)
 	return err
 }
+
+// newLocalScriptCmd writes contents to a temporary file and returns a command
+// that runs it in dir with env, and a function to remove the temporary file.
+func newLocalScriptCmd(name string, contents []byte, dir string, env []string) (*exec.Cmd, func(), error) {
+	// Put the randomness on the front of the filename to preserve any file
+	// extension for Windows scripts.
+	f, err := ioutil.TempFile("", "*."+filepath.Base(name))
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(f.Name())
+	}
+	if err := os.Chmod(f.Name(), 0700); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := f.Write(contents); err != nil {
+		_ = f.Close()
+		cleanup()
+		return nil, nil, err
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	//nolint:gosec
+	c := exec.Command(f.Name())
+	c.Dir = dir
+	c.Env = env
+	return c, cleanup, nil
+}
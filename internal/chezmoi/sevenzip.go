@@ -0,0 +1,34 @@
+package chezmoi
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// SevenZip interfaces with 7z.
+type SevenZip struct {
+	Command string
+}
+
+// readArchive returns the members of the 7z archive in data.
+func (s *SevenZip) readArchive(data []byte) ([]archiveMember, error) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-7z")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+	archivePath := filepath.Join(tempDir, "archive.7z")
+	if err := ioutil.WriteFile(archivePath, data, 0600); err != nil {
+		return nil, err
+	}
+	outputDir := filepath.Join(tempDir, "output")
+	//nolint:gosec
+	cmd := exec.Command(s.Command, "x", "-y", "-o"+outputDir, archivePath)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return readDirArchive(outputDir)
+}
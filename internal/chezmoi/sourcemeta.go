@@ -0,0 +1,337 @@
+package chezmoi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	vfs "github.com/twpayne/go-vfs"
+	"gopkg.in/yaml.v2"
+)
+
+// sourceMetaName is the name of the file that holds the attributes of the
+// entries in a source directory, instead of their source names.
+const sourceMetaName = ".chezmoimeta.yaml"
+
+// sourceMetaGrammar is the grammar of source names in a directory with a
+// .chezmoimeta.yaml file.
+var sourceMetaGrammar = sourceNameGrammar{dot: true}
+
+// An ExtraAttributes holds attributes of a target that cannot be expressed in
+// its source name.
+type ExtraAttributes struct {
+	Owner  string            `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Group  string            `json:"group,omitempty" yaml:"group,omitempty"`
+	Xattrs map[string]string `json:"xattrs,omitempty" yaml:"xattrs,omitempty"`
+}
+
+// checkApply returns an error if a sets any attributes, as they cannot be
+// applied yet. Failing is better than silently leaving the target with the
+// wrong owner, group, or extended attributes.
+func (a *ExtraAttributes) checkApply(targetName string) error {
+	if a.Owner == "" && a.Group == "" && len(a.Xattrs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s: owner, group, and xattrs cannot be applied", targetName)
+}
+
+// A SourceMetaAttributes holds the attributes of a single entry in a
+// .chezmoimeta.yaml file.
+type SourceMetaAttributes struct {
+	Type            string `yaml:"type,omitempty"`
+	Compressed      bool   `yaml:"compressed,omitempty"`
+	Empty           bool   `yaml:"empty,omitempty"`
+	Encrypted       bool   `yaml:"encrypted,omitempty"`
+	Exact           bool   `yaml:"exact,omitempty"`
+	Executable      bool   `yaml:"executable,omitempty"`
+	Once            bool   `yaml:"once,omitempty"`
+	OnChange        bool   `yaml:"onchange,omitempty"`
+	Before          bool   `yaml:"before,omitempty"`
+	After           bool   `yaml:"after,omitempty"`
+	Private         bool   `yaml:"private,omitempty"`
+	Template        bool   `yaml:"template,omitempty"`
+	Recursive       bool   `yaml:"recursive,omitempty"`
+	ExtraAttributes `yaml:",inline"`
+}
+
+// A sourceMeta maps source names in a directory to their attributes.
+type sourceMeta map[string]SourceMetaAttributes
+
+// HasSourceMeta returns true if the attributes of the entries in the source
+// directory sourceDirName are stored in a .chezmoimeta.yaml file.
+func (ts *TargetState) HasSourceMeta(fs vfs.FS, sourceDirName string) (bool, error) {
+	meta, err := ts.getSourceMeta(fs, sourceDirName)
+	return meta != nil, err
+}
+
+// check returns an error if a sets any attributes that are not allowed for
+// entries of type typ.
+func (a *SourceMetaAttributes) check(typ string) error {
+	var allowed []string
+	switch typ {
+	case "dir":
+		allowed = []string{"exact", "private", "owner", "group", "xattrs"}
+	case "file":
+		allowed = []string{"compressed", "empty", "encrypted", "executable", "private", "template", "recursive", "owner", "group", "xattrs"}
+	case "script":
+		allowed = []string{"type", "once", "onchange", "before", "after", "template", "recursive"}
+	case "symlink":
+		allowed = []string{"type", "template", "recursive"}
+	}
+	if a.Recursive && !a.Template {
+		return fmt.Errorf("recursive: not allowed without template")
+	}
+	for name, set := range map[string]bool{
+		"type":       a.Type != "" && a.Type != "file",
+		"compressed": a.Compressed,
+		"empty":      a.Empty,
+		"encrypted":  a.Encrypted,
+		"exact":      a.Exact,
+		"executable": a.Executable,
+		"once":       a.Once,
+		"onchange":   a.OnChange,
+		"before":     a.Before,
+		"after":      a.After,
+		"private":    a.Private,
+		"template":   a.Template,
+		"recursive":  a.Recursive,
+		"owner":      a.Owner != "",
+		"group":      a.Group != "",
+		"xattrs":     len(a.Xattrs) != 0,
+	} {
+		if set && !stringsContain(allowed, name) {
+			return fmt.Errorf("%s: not allowed for %s", name, typ)
+		}
+	}
+	return nil
+}
+
+// dirAttributes returns the DirAttributes of the directory with source name
+// sourceName.
+func (a *SourceMetaAttributes) dirAttributes(sourceName string) (DirAttributes, error) {
+	if err := a.check("dir"); err != nil {
+		return DirAttributes{}, err
+	}
+	perm := os.FileMode(0777)
+	if a.Private {
+		perm &= 0700
+	}
+	return DirAttributes{
+		Name:  sourceMetaTargetName(sourceName),
+		Exact: a.Exact,
+		Perm:  perm,
+	}, nil
+}
+
+// parsedSourceFilePath returns the parsed source file path of the file with
+// source name sourceName in the directories das.
+func (a *SourceMetaAttributes) parsedSourceFilePath(das []DirAttributes, sourceName string) (parsedSourceFilePath, error) {
+	switch a.Type {
+	case "", "file":
+		if err := a.check("file"); err != nil {
+			return parsedSourceFilePath{}, err
+		}
+		mode := os.FileMode(0666)
+		if a.Executable {
+			mode |= 0111
+		}
+		if a.Private {
+			mode &= 0700
+		}
+		return parsedSourceFilePath{
+			dirAttributes: das,
+			fileAttributes: &FileAttributes{
+				Name:      sourceMetaTargetName(sourceName),
+				Mode:      mode,
+				Empty:     a.Empty,
+				Encrypted: a.Encrypted,
+				Template:  a.Template,
+				Recursive: a.Recursive,
+			},
+		}, nil
+	case "script":
+		if err := a.check("script"); err != nil {
+			return parsedSourceFilePath{}, err
+		}
+		switch {
+		case a.Once && a.OnChange:
+			return parsedSourceFilePath{}, fmt.Errorf("%s: once and onchange cannot both be set", sourceName)
+		case a.Before && a.After:
+			return parsedSourceFilePath{}, fmt.Errorf("%s: before and after cannot both be set", sourceName)
+		}
+		return parsedSourceFilePath{
+			dirAttributes: das,
+			scriptAttributes: &ScriptAttributes{
+				Name:      sourceName,
+				Once:      a.Once,
+				OnChange:  a.OnChange,
+				Before:    a.Before,
+				After:     a.After,
+				Template:  a.Template,
+				Recursive: a.Recursive,
+			},
+		}, nil
+	case "symlink":
+		if err := a.check("symlink"); err != nil {
+			return parsedSourceFilePath{}, err
+		}
+		return parsedSourceFilePath{
+			dirAttributes: das,
+			fileAttributes: &FileAttributes{
+				Name:      sourceMetaTargetName(sourceName),
+				Mode:      os.ModeSymlink | 0666,
+				Template:  a.Template,
+				Recursive: a.Recursive,
+			},
+		}, nil
+	default:
+		return parsedSourceFilePath{}, fmt.Errorf("%s: unknown type", a.Type)
+	}
+}
+
+// parseDirNameComponents parses the directory name components of a source
+// path, using the .chezmoimeta.yaml file in each parent directory if it has
+// one.
+func (ts *TargetState) parseDirNameComponents(fs vfs.FS, components []string) ([]DirAttributes, error) {
+	das := make([]DirAttributes, 0, len(components))
+	for i, component := range components {
+		a, err := ts.getSourceMetaAttributes(fs, filepath.Join(components[:i+1]...))
+		if err != nil {
+			return nil, err
+		}
+		if a == nil {
+			das = append(das, ParseDirAttributes(component))
+			continue
+		}
+		da, err := a.dirAttributes(component)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(components[:i+1]...), err)
+		}
+		das = append(das, da)
+	}
+	return das, nil
+}
+
+// parseSourceFilePath parses the source file path path, using the
+// .chezmoimeta.yaml files in its parent directories if they have them.
+func (ts *TargetState) parseSourceFilePath(fs vfs.FS, path string) (parsedSourceFilePath, error) {
+	components := splitPathList(path)
+	das, err := ts.parseDirNameComponents(fs, components[:len(components)-1])
+	if err != nil {
+		return parsedSourceFilePath{}, err
+	}
+	a, err := ts.getSourceMetaAttributes(fs, path)
+	switch {
+	case err != nil:
+		return parsedSourceFilePath{}, err
+	case a == nil:
+		psfp := parseSourceFilePath(path)
+		psfp.dirAttributes = das
+		return psfp, nil
+	}
+	psfp, err := a.parsedSourceFilePath(das, components[len(components)-1])
+	if err != nil {
+		return parsedSourceFilePath{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return psfp, nil
+}
+
+// getSourceMeta returns the contents of the .chezmoimeta.yaml file in the
+// source directory sourceDirName, or nil if there is no such file.
+func (ts *TargetState) getSourceMeta(fs vfs.FS, sourceDirName string) (sourceMeta, error) {
+	sourceDirName = filepath.Clean(sourceDirName)
+	if meta, ok := ts.sourceMetas[sourceDirName]; ok {
+		return meta, nil
+	}
+	path := filepath.Join(ts.SourceDir, sourceDirName, sourceMetaName)
+	data, err := fs.ReadFile(path)
+	var meta sourceMeta
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		var rawMeta sourceMeta
+		if err := yaml.UnmarshalStrict(data, &rawMeta); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		meta = make(sourceMeta, len(rawMeta))
+		for name, a := range rawMeta {
+			meta[normalizeName(name)] = a
+		}
+	}
+	if ts.sourceMetas == nil {
+		ts.sourceMetas = make(map[string]sourceMeta)
+	}
+	ts.sourceMetas[sourceDirName] = meta
+	return meta, nil
+}
+
+// getSourceMetaAttributes returns the attributes of the entry with source name
+// sourceName from its directory's .chezmoimeta.yaml file, or nil if its
+// directory does not have one.
+func (ts *TargetState) getSourceMetaAttributes(fs vfs.FS, sourceName string) (*SourceMetaAttributes, error) {
+	sourceDirName, name := filepath.Split(sourceName)
+	meta, err := ts.getSourceMeta(fs, sourceDirName)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	a := meta[normalizeName(name)]
+	return &a, nil
+}
+
+// newSourceName returns the source name for a new entry named name in the
+// source directory parentDirSourceName with attributes a. If the directory
+// has a .chezmoimeta.yaml file then a is written to it, preserving any extra
+// attributes and, if a is a template, the recursive attribute, and the source
+// name is the entry's name. Otherwise, the source name is returned by
+// sourceName.
+func (ts *TargetState) newSourceName(fs vfs.FS, parentDirSourceName, name string, a SourceMetaAttributes, sourceName func() string, mutator Mutator) (string, error) {
+	meta, err := ts.getSourceMeta(fs, parentDirSourceName)
+	if err != nil {
+		return "", err
+	}
+	if meta == nil {
+		return filepath.Join(parentDirSourceName, sourceName()), nil
+	}
+	base := sourceMetaGrammar.sourceName("", name, false)
+	a.ExtraAttributes = meta[base].ExtraAttributes
+	a.Recursive = a.Template && meta[base].Recursive
+	if !reflect.DeepEqual(meta[base], a) {
+		path := filepath.Join(ts.SourceDir, parentDirSourceName, sourceMetaName)
+		currData, err := fs.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if reflect.DeepEqual(a, SourceMetaAttributes{}) {
+			delete(meta, base)
+		} else {
+			meta[base] = a
+		}
+		data, err := yaml.Marshal(meta)
+		if err != nil {
+			return "", err
+		}
+		if err := mutator.WriteFile(path, data, 0666&^ts.Umask, currData); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(parentDirSourceName, base), nil
+}
+
+// sourceMetaTargetName returns the target name of the entry with source name
+// sourceName in a directory with a .chezmoimeta.yaml file. Only the dot_ and
+// literal_ prefixes are significant.
+func sourceMetaTargetName(sourceName string) string {
+	return sourceMetaGrammar.tokenize(sourceName).name
+}
+
+func stringsContain(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package chezmoi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestSourceMetaPopulate(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoimeta.yaml": "" +
+				"dot_bashrc:\n" +
+				"  private: true\n" +
+				"  template: true\n" +
+				"  owner: root\n" +
+				"  xattrs:\n" +
+				"    user.comment: shell\n" +
+				"dot_config:\n" +
+				"  exact: true\n" +
+				"  group: staff\n" +
+				"install.sh:\n" +
+				"  type: script\n" +
+				"  once: true\n" +
+				"link:\n" +
+				"  type: symlink\n" +
+				"private_literal:\n" +
+				"  executable: true\n",
+			"dot_bashrc":      "{{ \"bashrc\" }}",
+			"install.sh":      "#!/bin/sh\n",
+			"link":            "target",
+			"private_literal": "literal",
+			"dot_config": map[string]interface{}{
+				"private_foo": "foo",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Evaluate())
+
+	bashrc, ok := ts.Entries[".bashrc"].(*File)
+	require.True(t, ok)
+	assert.Equal(t, "dot_bashrc", bashrc.SourceName())
+	assert.Equal(t, os.FileMode(0600), bashrc.Perm)
+	assert.True(t, bashrc.Template)
+	assert.Equal(t, []byte("bashrc"), bashrc.contents)
+	assert.Equal(t, ExtraAttributes{
+		Owner: "root",
+		Xattrs: map[string]string{
+			"user.comment": "shell",
+		},
+	}, bashrc.ExtraAttributes)
+
+	config, ok := ts.Entries[".config"].(*Dir)
+	require.True(t, ok)
+	assert.True(t, config.Exact)
+	assert.Equal(t, "staff", config.Group)
+	foo, ok := config.Entries["foo"].(*File)
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(".config", "foo"), foo.TargetName())
+	assert.Equal(t, os.FileMode(0600), foo.Perm)
+
+	script, ok := ts.Entries["install.sh"].(*Script)
+	require.True(t, ok)
+	assert.True(t, script.Once)
+
+	link, ok := ts.Entries["link"].(*Symlink)
+	require.True(t, ok)
+	assert.Equal(t, "target", link.linkname)
+
+	literal, ok := ts.Entries["private_literal"].(*File)
+	require.True(t, ok)
+	assert.Equal(t, os.FileMode(0777), literal.Perm)
+}
+
+func TestSourceMetaPopulateErrors(t *testing.T) {
+	for name, meta := range map[string]string{
+		"dir_template":  "dir:\n  template: true\n",
+		"file_exact":    "file:\n  exact: true\n",
+		"script_owner":  "file:\n  type: script\n  owner: root\n",
+		"unknown_key":   "file:\n  hidden: true\n",
+		"unknown_type":  "file:\n  type: fifo\n",
+		"symlink_empty": "file:\n  type: symlink\n  empty: true\n",
+		"recursive":     "file:\n  recursive: true\n",
+		"dir_recursive": "dir:\n  recursive: true\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoimeta.yaml": meta,
+					"dir/file":          "contents",
+					"file":              "contents",
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+			)
+			assert.Error(t, ts.Populate(fs, nil))
+		})
+	}
+}
+
+func TestSourceMetaApplyExtraAttributes(t *testing.T) {
+	for name, meta := range map[string]string{
+		"dir_group":   "dir:\n  group: staff\n",
+		"file_owner":  "file:\n  owner: root\n",
+		"file_xattrs": "file:\n  xattrs:\n    user.comment: file\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoimeta.yaml": meta,
+					"dir":               &vfst.Dir{Perm: 0755},
+					"file":              "contents",
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+			)
+			require.NoError(t, ts.Populate(fs, nil))
+			assert.Error(t, ts.Apply(fs, NewFSMutator(fs), false, &ApplyOptions{
+				DestDir: ts.DestDir,
+				Ignore:  ts.TargetIgnore.Match,
+				Umask:   022,
+			}))
+		})
+	}
+}
+
+func TestSourceMetaPopulateRecursive(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contents     string
+		recursive    bool
+		expectedErr  bool
+		expectedData []byte
+	}{
+		"not_recursive": {
+			contents:     "{{ .greeting }}",
+			expectedData: []byte("hello {{ .name }}"),
+		},
+		"nested": {
+			contents:     "{{ .nested }}",
+			recursive:    true,
+			expectedData: []byte("hello world!"),
+		},
+		"recursive": {
+			contents:     "{{ .greeting }}",
+			recursive:    true,
+			expectedData: []byte("hello world"),
+		},
+		"loop": {
+			contents:    "{{ .loop }}",
+			recursive:   true,
+			expectedErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			meta := "file:\n  template: true\n"
+			if tc.recursive {
+				meta += "  recursive: true\n"
+			}
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoimeta.yaml": meta,
+					"file":              tc.contents,
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+				WithTemplateData(map[string]interface{}{
+					"greeting": "hello {{ .name }}",
+					"nested":   "{{ .greeting }}!",
+					"loop":     "{{ .loop }}x",
+					"name":     "world",
+				}),
+			)
+			require.NoError(t, ts.Populate(fs, nil))
+			file, ok := ts.Entries["file"].(*File)
+			require.True(t, ok)
+			data, err := file.Contents()
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedData, data)
+			}
+		})
+	}
+}
+
+func TestSourceMetaAdd(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": &vfst.File{Perm: 0700, Contents: []byte("bashrc")},
+			".vimrc":  &vfst.Symlink{Target: ".vim/vimrc"},
+			"dot_foo": "foo",
+			".local/share/chezmoi/.chezmoimeta.yaml": "" +
+				"dot_bashrc:\n" +
+				"  owner: root\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	mutator := NewFSMutator(fs)
+	for _, targetPath := range []string{"/home/user/.bashrc", "/home/user/.vimrc", "/home/user/dot_foo"} {
+		require.NoError(t, ts.Add(fs, AddOptions{}, targetPath, nil, false, mutator))
+	}
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+			vfst.TestContentsString("bashrc"),
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_vimrc",
+			vfst.TestContentsString(".vim/vimrc"),
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/literal_dot_foo",
+			vfst.TestContentsString("foo"),
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/.chezmoimeta.yaml",
+			vfst.TestContentsString(""+
+				"dot_bashrc:\n"+
+				"  executable: true\n"+
+				"  private: true\n"+
+				"  owner: root\n"+
+				"dot_vimrc:\n"+
+				"  type: symlink\n",
+			),
+		),
+	)
+}
@@ -0,0 +1,264 @@
+package chezmoi
+
+import "strings"
+
+// Source names are parsed according to the following grammar, where name is
+// any non-empty string:
+//
+//	dir      = [ "exact_" ] [ "private_" ] ( "literal_" | [ "dot_" ] ) name
+//	file     = [ "encrypted_" ] [ "compressed_" ] [ "private_" ] [ "empty_" ] [ "executable_" ] ( "literal_" | [ "dot_" ] ) name [ ".literal" ] [ ".tmpl" ]
+//	symlink  = "symlink_" ( "literal_" | [ "dot_" ] ) name [ ".literal" ] [ ".tmpl" ]
+//	hardlink = "hardlink_" ( "literal_" | [ "dot_" ] ) name [ ".literal" ] [ ".tmpl" ]
+//	fifo     = "fifo_" [ "private_" ] ( "literal_" | [ "dot_" ] ) name
+//	script   = "run_" [ "once_" | "onchange_" ] [ "before_" | "after_" ] [ "literal_" ] name [ ".literal" ] [ ".tmpl" ]
+//
+// Each attribute may appear at most once and only in the order given. A
+// prefix or suffix is only an attribute if it does not make the name empty,
+// ".", or "..". Anything else, including attributes that are out of order or
+// repeated, is part of the name. "literal_" may also appear before any
+// attribute prefix, and ends the parsing of prefixes, so everything after it
+// is part of the name. Similarly, ".literal" ends the parsing of suffixes.
+
+// A sourceNameGrammar describes the attributes of a kind of source name.
+type sourceNameGrammar struct {
+	typ       string
+	prefixes  []string
+	exclusive map[string]string
+	others    []string
+	dot       bool
+	template  bool
+}
+
+// sourceNameTokens are the tokens of a source name.
+type sourceNameTokens struct {
+	prefixes  map[string]bool
+	dot       bool
+	name      string
+	template  bool
+	misplaced []string
+}
+
+// A ParsedSourceName describes how a single source name is parsed.
+type ParsedSourceName struct {
+	Type       string
+	Attributes []string
+	Name       string
+	Misplaced  []string
+}
+
+var (
+	dirSourceNameGrammar = sourceNameGrammar{
+		typ:      "dir",
+		prefixes: []string{exactPrefix, privatePrefix},
+		dot:      true,
+	}
+	fileSourceNameGrammar = sourceNameGrammar{
+		typ:      "file",
+		prefixes: []string{encryptedPrefix, compressedPrefix, privatePrefix, emptyPrefix, executablePrefix},
+		others:   []string{fifoPrefix, hardlinkPrefix, symlinkPrefix},
+		dot:      true,
+		template: true,
+	}
+	fifoSourceNameGrammar = sourceNameGrammar{
+		typ:      "fifo",
+		prefixes: []string{fifoPrefix, privatePrefix},
+		others:   []string{encryptedPrefix, compressedPrefix, emptyPrefix, executablePrefix},
+		dot:      true,
+	}
+	hardlinkSourceNameGrammar = sourceNameGrammar{
+		typ:      "hardlink",
+		prefixes: []string{hardlinkPrefix},
+		others:   []string{encryptedPrefix, compressedPrefix, privatePrefix, emptyPrefix, executablePrefix},
+		dot:      true,
+		template: true,
+	}
+	scriptSourceNameGrammar = sourceNameGrammar{
+		typ:      "script",
+		prefixes: []string{runPrefix, oncePrefix, onchangePrefix, beforePrefix, afterPrefix},
+		exclusive: map[string]string{
+			onchangePrefix: oncePrefix,
+			afterPrefix:    beforePrefix,
+		},
+		template: true,
+	}
+	symlinkSourceNameGrammar = sourceNameGrammar{
+		typ:      "symlink",
+		prefixes: []string{symlinkPrefix},
+		others:   []string{encryptedPrefix, compressedPrefix, privatePrefix, emptyPrefix, executablePrefix},
+		dot:      true,
+		template: true,
+	}
+)
+
+// ParseSourceName parses sourceName as the source name of a directory if dir
+// is true, or of a fifo, file, hardlink, script, or symlink otherwise.
+func ParseSourceName(sourceName string, dir bool) ParsedSourceName {
+	g := getSourceNameGrammar(sourceName, dir)
+	t := g.tokenize(sourceName)
+	attributes := []string{}
+	for _, prefix := range g.prefixes {
+		if t.prefixes[prefix] && !isTypePrefix(prefix) {
+			attributes = append(attributes, strings.TrimSuffix(prefix, "_"))
+		}
+	}
+	if t.template {
+		attributes = append(attributes, "template")
+	}
+	return ParsedSourceName{
+		Type:       g.typ,
+		Attributes: attributes,
+		Name:       t.name,
+		Misplaced:  t.misplaced,
+	}
+}
+
+// getSourceNameGrammar returns the grammar for sourceName.
+func getSourceNameGrammar(sourceName string, dir bool) sourceNameGrammar {
+	switch {
+	case dir:
+		return dirSourceNameGrammar
+	case isScriptSourceName(sourceName):
+		return scriptSourceNameGrammar
+	case isSymlinkSourceName(sourceName):
+		return symlinkSourceNameGrammar
+	case isHardlinkSourceName(sourceName):
+		return hardlinkSourceNameGrammar
+	case isFIFOSourceName(sourceName):
+		return fifoSourceNameGrammar
+	default:
+		return fileSourceNameGrammar
+	}
+}
+
+// isFIFOSourceName returns true if sourceName is the source name of a FIFO.
+func isFIFOSourceName(sourceName string) bool {
+	return strings.HasPrefix(sourceName, fifoPrefix) && isValidTargetName(sourceName[len(fifoPrefix):])
+}
+
+// isHardlinkSourceName returns true if sourceName is the source name of a hard
+// link.
+func isHardlinkSourceName(sourceName string) bool {
+	return strings.HasPrefix(sourceName, hardlinkPrefix) && isValidTargetName(sourceName[len(hardlinkPrefix):])
+}
+
+// isScriptSourceName returns true if sourceName is the source name of a
+// script.
+func isScriptSourceName(sourceName string) bool {
+	return strings.HasPrefix(sourceName, runPrefix) && isValidTargetName(sourceName[len(runPrefix):])
+}
+
+// isSymlinkSourceName returns true if sourceName is the source name of a
+// symlink.
+func isSymlinkSourceName(sourceName string) bool {
+	return strings.HasPrefix(sourceName, symlinkPrefix) && isValidTargetName(sourceName[len(symlinkPrefix):])
+}
+
+// isTypePrefix returns true if prefix determines the type of an entry rather
+// than being one of its attributes.
+func isTypePrefix(prefix string) bool {
+	return prefix == fifoPrefix || prefix == hardlinkPrefix || prefix == runPrefix || prefix == symlinkPrefix
+}
+
+// isValidTargetName returns true if name can be the name of a target.
+func isValidTargetName(name string) bool {
+	return name != "" && name != "." && name != ".."
+}
+
+// tokenize splits sourceName into tokens according to g.
+func (g sourceNameGrammar) tokenize(sourceName string) sourceNameTokens {
+	t := sourceNameTokens{
+		prefixes: make(map[string]bool),
+	}
+	rest, literal := trimLiteralPrefix(sourceName)
+	for _, prefix := range g.prefixes {
+		if literal {
+			break
+		}
+		if t.prefixes[g.exclusive[prefix]] {
+			continue
+		}
+		if strings.HasPrefix(rest, prefix) && isValidTargetName(rest[len(prefix):]) {
+			t.prefixes[prefix] = true
+			rest, literal = trimLiteralPrefix(rest[len(prefix):])
+		}
+	}
+	if g.template && strings.HasSuffix(rest, TemplateSuffix) && isValidTargetName(rest[:len(rest)-len(TemplateSuffix)]) {
+		t.template = true
+		rest = rest[:len(rest)-len(TemplateSuffix)]
+	}
+	if g.template && strings.HasSuffix(rest, literalSuffix) && isValidTargetName(rest[:len(rest)-len(literalSuffix)]) {
+		rest = rest[:len(rest)-len(literalSuffix)]
+	}
+	if g.dot && !literal && strings.HasPrefix(rest, dotPrefix) {
+		if name := "." + rest[len(dotPrefix):]; isValidTargetName(name) {
+			t.dot = true
+			rest = name
+		}
+	}
+	t.name = normalizeName(rest)
+	if !literal {
+		t.misplaced = g.misplacedPrefixes(rest, t.dot)
+	}
+	return t
+}
+
+// sourceName returns the source name of an entry called name with the
+// attribute prefixes prefix and, if template is true, the template suffix.
+// The literal prefix and suffix are added if they are needed for name to be
+// parsed unchanged.
+func (g sourceNameGrammar) sourceName(prefix, name string, template bool) string {
+	suffix := ""
+	if g.template && (strings.HasSuffix(name, TemplateSuffix) || strings.HasSuffix(name, literalSuffix)) {
+		suffix = literalSuffix
+	}
+	if template {
+		suffix += TemplateSuffix
+	}
+	sourceName := prefix
+	if g.dot && strings.HasPrefix(name, ".") {
+		sourceName += dotPrefix + strings.TrimPrefix(name, ".")
+	} else {
+		sourceName += name
+	}
+	sourceName += suffix
+	if g.tokenize(sourceName).name != normalizeName(name) || g.typ != "" && getSourceNameGrammar(sourceName, g.typ == "dir").typ != g.typ {
+		sourceName = prefix + literalPrefix + name + suffix
+	}
+	return sourceName
+}
+
+// trimLiteralPrefix returns s without the literal prefix and true if s starts
+// with the literal prefix, or s and false otherwise.
+func trimLiteralPrefix(s string) (string, bool) {
+	if strings.HasPrefix(s, literalPrefix) && isValidTargetName(s[len(literalPrefix):]) {
+		return s[len(literalPrefix):], true
+	}
+	return s, false
+}
+
+// misplacedPrefixes returns the attribute prefixes at the start of name, which
+// are part of the name because they are out of order, repeated, or follow
+// "dot_".
+func (g sourceNameGrammar) misplacedPrefixes(name string, dot bool) []string {
+	if dot {
+		name = name[1:]
+	}
+	dotPrefixes := []string{}
+	if g.dot {
+		dotPrefixes = append(dotPrefixes, dotPrefix)
+	}
+	var misplaced []string
+FOR:
+	for {
+		for _, prefixes := range [][]string{g.prefixes, g.others, dotPrefixes} {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(name, prefix) {
+					misplaced = append(misplaced, prefix)
+					name = name[len(prefix):]
+					continue FOR
+				}
+			}
+		}
+		return misplaced
+	}
+}
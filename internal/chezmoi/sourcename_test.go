@@ -0,0 +1,492 @@
+package chezmoi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSourceName(t *testing.T) {
+	for _, tc := range []struct {
+		sourceName string
+		dir        bool
+		want       ParsedSourceName
+	}{
+		{
+			sourceName: "exact_private_dot_config",
+			dir:        true,
+			want: ParsedSourceName{
+				Type:       "dir",
+				Attributes: []string{"exact", "private"},
+				Name:       ".config",
+			},
+		},
+		{
+			sourceName: "private_exact_foo",
+			dir:        true,
+			want: ParsedSourceName{
+				Type:       "dir",
+				Attributes: []string{"private"},
+				Name:       "exact_foo",
+				Misplaced:  []string{"exact_"},
+			},
+		},
+		{
+			sourceName: "encrypted_private_dot_netrc.tmpl",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{"encrypted", "private", "template"},
+				Name:       ".netrc",
+			},
+		},
+		{
+			sourceName: "encrypted_compressed_private_dot_big",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{"encrypted", "compressed", "private"},
+				Name:       ".big",
+			},
+		},
+		{
+			sourceName: "private_encrypted_dot_netrc",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{"private"},
+				Name:       "encrypted_dot_netrc",
+				Misplaced:  []string{"encrypted_", "dot_"},
+			},
+		},
+		{
+			sourceName: "dot_private_foo",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       ".private_foo",
+				Misplaced:  []string{"private_"},
+			},
+		},
+		{
+			sourceName: "dot_dot_foo",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       ".dot_foo",
+				Misplaced:  []string{"dot_"},
+			},
+		},
+		{
+			sourceName: "private_symlink_foo",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{"private"},
+				Name:       "symlink_foo",
+				Misplaced:  []string{"symlink_"},
+			},
+		},
+		{
+			sourceName: "symlink_dot_foo.tmpl",
+			want: ParsedSourceName{
+				Type:       "symlink",
+				Attributes: []string{"template"},
+				Name:       ".foo",
+			},
+		},
+		{
+			sourceName: "symlink_executable_foo",
+			want: ParsedSourceName{
+				Type:       "symlink",
+				Attributes: []string{},
+				Name:       "executable_foo",
+				Misplaced:  []string{"executable_"},
+			},
+		},
+		{
+			sourceName: "hardlink_dot_foo.tmpl",
+			want: ParsedSourceName{
+				Type:       "hardlink",
+				Attributes: []string{"template"},
+				Name:       ".foo",
+			},
+		},
+		{
+			sourceName: "fifo_private_dot_foo",
+			want: ParsedSourceName{
+				Type:       "fifo",
+				Attributes: []string{"private"},
+				Name:       ".foo",
+			},
+		},
+		{
+			sourceName: "fifo_foo.tmpl",
+			want: ParsedSourceName{
+				Type:       "fifo",
+				Attributes: []string{},
+				Name:       "foo.tmpl",
+			},
+		},
+		{
+			sourceName: "private_hardlink_foo",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{"private"},
+				Name:       "hardlink_foo",
+				Misplaced:  []string{"hardlink_"},
+			},
+		},
+		{
+			sourceName: "run_once_install.sh.tmpl",
+			want: ParsedSourceName{
+				Type:       "script",
+				Attributes: []string{"once", "template"},
+				Name:       "install.sh",
+			},
+		},
+		{
+			sourceName: "run_onchange_install.sh",
+			want: ParsedSourceName{
+				Type:       "script",
+				Attributes: []string{"onchange"},
+				Name:       "install.sh",
+			},
+		},
+		{
+			sourceName: "run_onchange_after_install.sh.tmpl",
+			want: ParsedSourceName{
+				Type:       "script",
+				Attributes: []string{"onchange", "after", "template"},
+				Name:       "install.sh",
+			},
+		},
+		{
+			sourceName: "run_before_after_install.sh",
+			want: ParsedSourceName{
+				Type:       "script",
+				Attributes: []string{"before"},
+				Name:       "after_install.sh",
+				Misplaced:  []string{"after_"},
+			},
+		},
+		{
+			sourceName: "run_once_onchange_install.sh",
+			want: ParsedSourceName{
+				Type:       "script",
+				Attributes: []string{"once"},
+				Name:       "onchange_install.sh",
+				Misplaced:  []string{"onchange_"},
+			},
+		},
+		{
+			sourceName: "run_",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "run_",
+			},
+		},
+		{
+			sourceName: "symlink_",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "symlink_",
+				Misplaced:  []string{"symlink_"},
+			},
+		},
+		{
+			sourceName: "private_",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "private_",
+				Misplaced:  []string{"private_"},
+			},
+		},
+		{
+			sourceName: "dot_",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "dot_",
+				Misplaced:  []string{"dot_"},
+			},
+		},
+		{
+			sourceName: "dot_.",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "dot_.",
+				Misplaced:  []string{"dot_"},
+			},
+		},
+		{
+			sourceName: "dot_.tmpl",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{"template"},
+				Name:       "dot_",
+				Misplaced:  []string{"dot_"},
+			},
+		},
+		{
+			sourceName: "private_.tmpl",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{"private"},
+				Name:       ".tmpl",
+			},
+		},
+		{
+			sourceName: "symlink_..",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "symlink_..",
+				Misplaced:  []string{"symlink_"},
+			},
+		},
+		{
+			sourceName: "private_..tmpl",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{"private"},
+				Name:       "..tmpl",
+			},
+		},
+		{
+			sourceName: "dot_..",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "...",
+			},
+		},
+		{
+			sourceName: "literal_private_dot_foo",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "private_dot_foo",
+			},
+		},
+		{
+			sourceName: "private_literal_dot_foo.tmpl",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{"private", "template"},
+				Name:       "dot_foo",
+			},
+		},
+		{
+			sourceName: "literal_run_foo.sh",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "run_foo.sh",
+			},
+		},
+		{
+			sourceName: "run_once_literal_before_foo.sh",
+			want: ParsedSourceName{
+				Type:       "script",
+				Attributes: []string{"once"},
+				Name:       "before_foo.sh",
+			},
+		},
+		{
+			sourceName: "exact_literal_private_foo",
+			dir:        true,
+			want: ParsedSourceName{
+				Type:       "dir",
+				Attributes: []string{"exact"},
+				Name:       "private_foo",
+			},
+		},
+		{
+			sourceName: "foo.tmpl.literal",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "foo.tmpl",
+			},
+		},
+		{
+			sourceName: "dot_foo.tmpl.literal.tmpl",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{"template"},
+				Name:       ".foo.tmpl",
+			},
+		},
+		{
+			sourceName: "literal_",
+			want: ParsedSourceName{
+				Type:       "file",
+				Attributes: []string{},
+				Name:       "literal_",
+			},
+		},
+	} {
+		t.Run(tc.sourceName, func(t *testing.T) {
+			assert.Equal(t, tc.want, ParseSourceName(tc.sourceName, tc.dir))
+		})
+	}
+}
+
+func TestParseSourceNameExhaustive(t *testing.T) {
+	tokens := []string{
+		afterPrefix,
+		beforePrefix,
+		compressedPrefix,
+		dotPrefix,
+		emptyPrefix,
+		encryptedPrefix,
+		exactPrefix,
+		executablePrefix,
+		fifoPrefix,
+		hardlinkPrefix,
+		literalPrefix,
+		oncePrefix,
+		onchangePrefix,
+		privatePrefix,
+		runPrefix,
+		symlinkPrefix,
+		literalSuffix,
+		TemplateSuffix,
+		".",
+		"_",
+		"x",
+	}
+	var sourceNames []string
+	var generate func(string, int)
+	generate = func(prefix string, n int) {
+		sourceNames = append(sourceNames, prefix)
+		if n == 0 {
+			return
+		}
+		for _, token := range tokens {
+			generate(prefix+token, n-1)
+		}
+	}
+	generate("", 4)
+
+	for _, sourceName := range sourceNames {
+		if !isValidTargetName(sourceName) {
+			continue
+		}
+		for _, dir := range []bool{false, true} {
+			psn := ParseSourceName(sourceName, dir)
+			for _, name := range []string{"", ".", ".."} {
+				if psn.Name == name {
+					t.Errorf("ParseSourceName(%q, %t).Name == %q", sourceName, dir, name)
+				}
+			}
+			switch {
+			case dir:
+				assert.Equal(t, psn.Name, ParseDirAttributes(sourceName).Name)
+			case psn.Type == "script":
+				assert.Equal(t, psn.Name, ParseScriptAttributes(sourceName).Name)
+			default:
+				assert.Equal(t, psn.Name, ParseFileAttributes(sourceName).Name)
+			}
+		}
+	}
+}
+
+func TestSourceNameRoundTrip(t *testing.T) {
+	for _, name := range []string{
+		"foo",
+		".foo",
+		"foo.sh",
+		"dot_foo",
+		".dot_foo",
+		"exact_foo",
+		"literal_foo",
+		"once_foo",
+		"private_foo",
+		"run_foo",
+		"symlink_foo",
+		"foo.literal",
+		"foo.tmpl",
+		".tmpl",
+	} {
+		for _, exact := range []bool{false, true} {
+			for _, perm := range []os.FileMode{0700, 0777} {
+				da := DirAttributes{
+					Name:  name,
+					Exact: exact,
+					Perm:  perm,
+				}
+				assert.Equal(t, da, ParseDirAttributes(da.SourceName()))
+			}
+		}
+		for _, mode := range []os.FileMode{0600, 0666, 0700, 0777, os.ModeSymlink | 0666} {
+			for _, compressed := range []bool{false, true} {
+				for _, empty := range []bool{false, true} {
+					for _, encrypted := range []bool{false, true} {
+						for _, template := range []bool{false, true} {
+							if mode&os.ModeSymlink != 0 && (compressed || empty || encrypted) {
+								continue
+							}
+							fa := FileAttributes{
+								Name:       name,
+								Mode:       mode,
+								Compressed: compressed,
+								Empty:      empty,
+								Encrypted:  encrypted,
+								Template:   template,
+							}
+							assert.Equal(t, fa, ParseFileAttributes(fa.SourceName()))
+						}
+					}
+				}
+			}
+		}
+		for _, mode := range []os.FileMode{os.ModeNamedPipe | 0600, os.ModeNamedPipe | 0666} {
+			fa := FileAttributes{
+				Name: name,
+				Mode: mode,
+			}
+			assert.Equal(t, fa, ParseFileAttributes(fa.SourceName()))
+		}
+		for _, template := range []bool{false, true} {
+			fa := FileAttributes{
+				Name:     name,
+				Mode:     0666,
+				Hardlink: true,
+				Template: template,
+			}
+			assert.Equal(t, fa, ParseFileAttributes(fa.SourceName()))
+		}
+		for _, run := range []struct {
+			once     bool
+			onChange bool
+		}{
+			{},
+			{once: true},
+			{onChange: true},
+		} {
+			for _, phase := range []struct {
+				before bool
+				after  bool
+			}{
+				{},
+				{before: true},
+				{after: true},
+			} {
+				for _, template := range []bool{false, true} {
+					sa := ScriptAttributes{
+						Name:     name,
+						Once:     run.once,
+						OnChange: run.onChange,
+						Before:   phase.before,
+						After:    phase.after,
+						Template: template,
+					}
+					assert.Equal(t, sa, ParseScriptAttributes(sa.SourceName()))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+const (
+	sshDirName        = ".ssh"
+	sshConfigName     = "config"
+	sshConfigDirName  = ".chezmoisshconfig"
+	sshConfigDirPerm  = os.FileMode(0700)
+	sshConfigFilePerm = os.FileMode(0600)
+)
+
+// sshConfigLineRegexp matches a single ssh_config(5) keyword and its
+// arguments, which may be separated by whitespace or an optional equals sign.
+var sshConfigLineRegexp = regexp.MustCompile(`\A[A-Za-z][A-Za-z0-9]*(?:\s*=\s*|\s+)\S`)
+
+// addSSHConfig adds the target ~/.ssh/config, assembled from the fragments in
+// the directory at path, to ts.
+func (ts *TargetState) addSSHConfig(fs vfs.FS, path string, options *PopulateOptions) error {
+	infos, err := fs.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	var fragmentPaths []string
+	for _, info := range infos {
+		switch {
+		case strings.HasPrefix(info.Name(), "."):
+			continue
+		case info.Mode().IsRegular():
+			fragmentPaths = append(fragmentPaths, filepath.Join(path, info.Name()))
+		default:
+			return fmt.Errorf("unsupported file in %s: %s", sshConfigDirName, filepath.Join(path, info.Name()))
+		}
+	}
+	sort.Strings(fragmentPaths)
+
+	var sshDir *Dir
+	if entry, ok := ts.Entries[sshDirName]; ok {
+		if sshDir, ok = entry.(*Dir); !ok {
+			return fmt.Errorf("%s: not a directory", sshDirName)
+		}
+	} else {
+		sshDir = newDir(sshConfigDirName, sshDirName, false, sshConfigDirPerm)
+		ts.Entries[sshDirName] = sshDir
+	}
+	// ssh refuses to read its configuration if ~/.ssh is accessible by others.
+	sshDir.Perm &= sshConfigDirPerm
+	if entry, ok := sshDir.Entries[sshConfigName]; ok {
+		return fmt.Errorf("%s: conflicts with %s", sshConfigDirName, entry.SourceName())
+	}
+
+	executeTemplates := options == nil || options.ExecuteTemplates
+	sshDir.Entries[sshConfigName] = &File{
+		sourceName: sshConfigDirName,
+		targetName: filepath.Join(sshDirName, sshConfigName),
+		Perm:       sshConfigFilePerm,
+		Template:   true,
+		evaluateContents: func() ([]byte, error) {
+			return ts.assembleSSHConfig(fs, fragmentPaths, executeTemplates)
+		},
+	}
+	return nil
+}
+
+// assembleSSHConfig returns the concatenation of the fragments at
+// fragmentPaths, in order. Each fragment is executed as a template, if
+// executeTemplates is true, and the result is verified.
+func (ts *TargetState) assembleSSHConfig(fs vfs.FS, fragmentPaths []string, executeTemplates bool) ([]byte, error) {
+	b := &bytes.Buffer{}
+	for _, fragmentPath := range fragmentPaths {
+		var data []byte
+		var err error
+		if executeTemplates {
+			data, err = ts.executeTemplate(fs, fragmentPath)
+		} else {
+			data, err = fs.ReadFile(fragmentPath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isEmpty(data) {
+			continue
+		}
+		if executeTemplates {
+			if err := verifySSHConfig(data); err != nil {
+				return nil, fmt.Errorf("%s: %w", fragmentPath, err)
+			}
+		}
+		if b.Len() != 0 {
+			b.WriteByte('\n')
+		}
+		b.Write(data)
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			b.WriteByte('\n')
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// verifySSHConfig returns an error if data contains lines which are not valid
+// ssh_config(5) keyword-argument pairs, comments, or blank lines.
+func verifySSHConfig(data []byte) error {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; s.Scan(); lineNumber++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !sshConfigLineRegexp.MatchString(line) {
+			return fmt.Errorf("line %d: invalid ssh config: %q", lineNumber, line)
+		}
+	}
+	return s.Err()
+}
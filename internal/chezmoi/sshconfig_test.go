@@ -0,0 +1,114 @@
+package chezmoi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestSSHConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		root             interface{}
+		data             map[string]interface{}
+		wantPopulateErr  bool
+		wantEvaluateErr  bool
+		expectedContents string
+		expectedDirPerm  os.FileMode
+	}{
+		{
+			name: "ordered_fragments",
+			root: map[string]interface{}{
+				"/.chezmoisshconfig": map[string]interface{}{
+					"20-work":  "Host work\n  HostName work.example.com\n",
+					"10-home":  "Host home\n  HostName home.example.com",
+					"99-all":   "Host *\n  ServerAliveInterval=60\n",
+					".ignored": "not ssh config",
+				},
+			},
+			expectedContents: "" +
+				"Host home\n  HostName home.example.com\n" +
+				"\n" +
+				"Host work\n  HostName work.example.com\n" +
+				"\n" +
+				"Host *\n  ServerAliveInterval=60\n",
+			expectedDirPerm: 0700,
+		},
+		{
+			name: "conditional_fragment",
+			root: map[string]interface{}{
+				"/.chezmoisshconfig": map[string]interface{}{
+					"10-work": "{{ if .work }}Host work\n  User {{ .user }}\n{{ end }}",
+					"20-home": "Host home\n  User {{ .user }}\n",
+				},
+			},
+			data: map[string]interface{}{
+				"user": "alice",
+				"work": false,
+			},
+			expectedContents: "Host home\n  User alice\n",
+			expectedDirPerm:  0700,
+		},
+		{
+			name: "existing_ssh_dir",
+			root: map[string]interface{}{
+				"/.chezmoisshconfig/host": "Host example.com\n  Port 2222\n",
+				"/dot_ssh/known_hosts":    "example.com ssh-ed25519 AAAA\n",
+			},
+			expectedContents: "Host example.com\n  Port 2222\n",
+			expectedDirPerm:  0700,
+		},
+		{
+			name: "conflict",
+			root: map[string]interface{}{
+				"/.chezmoisshconfig/host": "Host example.com\n",
+				"/private_dot_ssh/config": "Host example.com\n",
+			},
+			wantPopulateErr: true,
+		},
+		{
+			name: "invalid",
+			root: map[string]interface{}{
+				"/.chezmoisshconfig/host": "Host example.com\n  Port\n",
+			},
+			wantEvaluateErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/"),
+				WithTemplateData(tc.data),
+			)
+			err = ts.Populate(fs, nil)
+			if tc.wantPopulateErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			err = ts.Evaluate()
+			if tc.wantEvaluateErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			sshDir, ok := ts.Entries[".ssh"].(*Dir)
+			require.True(t, ok)
+			assert.Equal(t, tc.expectedDirPerm, sshDir.Perm)
+			file, ok := sshDir.Entries["config"].(*File)
+			require.True(t, ok)
+			assert.Equal(t, filepath.Join(".ssh", "config"), file.TargetName())
+			assert.Equal(t, os.FileMode(0600), file.Perm)
+			contents, err := file.Contents()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedContents, string(contents))
+		})
+	}
+}
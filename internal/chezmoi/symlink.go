@@ -41,7 +41,7 @@ func (s *Symlink) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *A
 	if err != nil {
 		return err
 	}
-	targetPath := filepath.Join(applyOptions.DestDir, s.targetName)
+	targetPath := TargetPath(applyOptions.DestDir, s.targetName)
 	var info os.FileInfo
 	if follow {
 		info, err = fs.Stat(targetPath)
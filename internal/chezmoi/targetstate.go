@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bufio"
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -18,6 +19,10 @@ import (
 	vfs "github.com/twpayne/go-vfs"
 )
 
+// maxTemplatePasses is the maximum number of times that a recursive template
+// is executed.
+const maxTemplatePasses = 10
+
 // DefaultTemplateOptions are the default template options.
 var DefaultTemplateOptions = []string{"missingkey=error"}
 
@@ -28,20 +33,28 @@ const (
 	versionName      = ".chezmoiversion"
 )
 
+// SOPSDataName is the name of the SOPS-encrypted template data file in the root
+// of the source directory. It is not part of the source state.
+const SOPSDataName = "secrets.sops.yaml"
+
 // An AddOptions contains options for TargetState.Add.
 type AddOptions struct {
+	Blob         bool
+	Compress     bool
 	Empty        bool
 	Encrypt      bool
 	Exact        bool
 	Recursive    bool
 	Template     bool
 	AutoTemplate bool
+	Rename       RenameFunc
 }
 
 // An ImportTAROptions contains options for TargetState.ImportTAR.
 type ImportTAROptions struct {
 	DestinationDir  string
 	Exact           bool
+	Rename          RenameFunc
 	StripComponents int
 }
 
@@ -52,18 +65,32 @@ type PopulateOptions struct {
 
 // A TargetState represents the root target state.
 type TargetState struct {
-	DestDir         string
-	Entries         map[string]Entry
-	GPG             *GPG
-	MinVersion      *semver.Version
-	SourceDir       string
-	TargetIgnore    *PatternSet
-	TargetRemove    *PatternSet
-	TemplateData    map[string]interface{}
-	TemplateFuncs   template.FuncMap
-	TemplateOptions []string
-	Templates       map[string]*template.Template
-	Umask           os.FileMode
+	DestDir           string
+	DestDirOverrides  map[string]string
+	Encryption        Encryption
+	Entries           map[string]Entry
+	ExternalLock      *ExternalLock
+	FetchURL          FetchURLFunc
+	LFS               *LFS
+	MinVersion        *semver.Version
+	PathPrefixes      map[string]string
+	ResolveRelease    ResolveReleaseFunc
+	SevenZip          *SevenZip
+	SourceDir         string
+	TargetIgnore      *PatternSet
+	TargetRemove      *PatternSet
+	TemplateData      map[string]interface{}
+	TemplateFuncs     template.FuncMap
+	TemplateOptions   []string
+	Templates         map[string]*template.Template
+	Umask             os.FileMode
+	WriteExternalLock func() error
+	Xz                *Xz
+	Zstd              *Zstd
+
+	blobs                 map[[sha256.Size]byte][]byte
+	filesByContentsSHA256 map[[sha256.Size]byte][]*File
+	sourceMetas           map[string]sourceMeta
 }
 
 // A TargetStateOption sets an option on a TargeState.
@@ -76,6 +103,20 @@ func WithDestDir(destDir string) TargetStateOption {
 	}
 }
 
+// WithDestDirOverrides sets the destination directory overrides.
+func WithDestDirOverrides(destDirOverrides map[string]string) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.DestDirOverrides = destDirOverrides
+	}
+}
+
+// WithEncryption sets the encryption.
+func WithEncryption(encryption Encryption) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.Encryption = encryption
+	}
+}
+
 // WithEntries sets the entries.
 func WithEntries(entries map[string]Entry) TargetStateOption {
 	return func(ts *TargetState) {
@@ -83,10 +124,27 @@ func WithEntries(entries map[string]Entry) TargetStateOption {
 	}
 }
 
-// WithGPG sets the GPG options.
-func WithGPG(gpg *GPG) TargetStateOption {
+// WithExternalLock sets the external lock and the function used to write it.
+// If the external lock is not set then it is read from the source directory
+// when populating, and is not written.
+func WithExternalLock(externalLock *ExternalLock, writeExternalLock func() error) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.ExternalLock = externalLock
+		ts.WriteExternalLock = writeExternalLock
+	}
+}
+
+// WithFetchURL sets the function used to download externals.
+func WithFetchURL(fetchURL FetchURLFunc) TargetStateOption {
 	return func(ts *TargetState) {
-		ts.GPG = gpg
+		ts.FetchURL = fetchURL
+	}
+}
+
+// WithLFS sets the git-lfs options.
+func WithLFS(lfs *LFS) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.LFS = lfs
 	}
 }
 
@@ -97,6 +155,28 @@ func WithMinVersion(minVersion *semver.Version) TargetStateOption {
 	}
 }
 
+// WithPathPrefixes sets the path prefixes.
+func WithPathPrefixes(pathPrefixes map[string]string) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.PathPrefixes = pathPrefixes
+	}
+}
+
+// WithResolveRelease sets the function used to resolve the releases of
+// github-release externals.
+func WithResolveRelease(resolveRelease ResolveReleaseFunc) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.ResolveRelease = resolveRelease
+	}
+}
+
+// WithSevenZip sets the 7z options.
+func WithSevenZip(sevenZip *SevenZip) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.SevenZip = sevenZip
+	}
+}
+
 // WithSourceDir sets the source directory.
 func WithSourceDir(sourceDir string) TargetStateOption {
 	return func(ts *TargetState) {
@@ -153,6 +233,20 @@ func WithUmask(umask os.FileMode) TargetStateOption {
 	}
 }
 
+// WithXz sets the xz options.
+func WithXz(xz *Xz) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.Xz = xz
+	}
+}
+
+// WithZstd sets the zstd options.
+func WithZstd(zstd *Zstd) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.Zstd = zstd
+	}
+}
+
 // NewTargetState creates a new TargetState with the given options.
 func NewTargetState(options ...TargetStateOption) *TargetState {
 	ts := &TargetState{
@@ -176,7 +270,7 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 	if !contains {
 		return fmt.Errorf("%s: outside target directory", targetPath)
 	}
-	targetName, err := filepath.Rel(ts.DestDir, targetPath)
+	targetName, err := RelTargetName(fs, ts.DestDir, targetPath)
 	if err != nil {
 		return err
 	}
@@ -220,6 +314,7 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 		parentDirSourceName = parentDir.sourceName
 		entries = parentDir.Entries
 	}
+	targetName = normalizeName(targetName)
 
 	switch {
 	case info.IsDir():
@@ -239,7 +334,7 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 		// recursively, add a .keep file so the directory is managed by git.
 		// chezmoi will ignore the .keep file as it begins with a dot.
 		createKeepFile := len(infos) == 0 || !addOptions.Recursive
-		return ts.addDir(targetName, entries, parentDirSourceName, addOptions.Exact, perm, createKeepFile, mutator)
+		return ts.addDir(fs, targetName, entries, parentDirSourceName, addOptions.Exact, perm, createKeepFile, mutator)
 	case info.Mode().IsRegular():
 		if info.Size() == 0 && !addOptions.Empty {
 			entry, err := ts.Get(fs, targetPath)
@@ -259,9 +354,12 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 		if addOptions.Template && addOptions.AutoTemplate {
 			contents = autoTemplate(contents, ts.TemplateData)
 		}
-		if addOptions.Encrypt {
-			contents, err = ts.GPG.Encrypt(targetPath, contents)
-			if err != nil {
+		contents, err = ts.EncodeContents(targetPath, addOptions.Compress, addOptions.Encrypt, contents)
+		if err != nil {
+			return err
+		}
+		if addOptions.Blob {
+			if contents, err = ts.AddBlob(fs, contents, mutator); err != nil {
 				return err
 			}
 		}
@@ -273,13 +371,13 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 		if private {
 			perm &^= 077
 		}
-		return ts.addFile(targetName, entries, parentDirSourceName, info, perm, addOptions.Encrypt, addOptions.Template, contents, mutator)
+		return ts.addFile(fs, targetName, entries, parentDirSourceName, info, perm, addOptions.Compress, addOptions.Encrypt, addOptions.Template, contents, addOptions.Rename, mutator)
 	case info.Mode()&os.ModeType == os.ModeSymlink:
 		linkname, err := fs.Readlink(targetPath)
 		if err != nil {
 			return err
 		}
-		return ts.addSymlink(targetName, entries, parentDirSourceName, linkname, mutator)
+		return ts.addSymlink(fs, targetName, entries, parentDirSourceName, linkname, mutator)
 	default:
 		return fmt.Errorf("%s: not a regular file, directory, or symlink", targetName)
 	}
@@ -318,13 +416,15 @@ func (ts *TargetState) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptio
 				if !ts.TargetRemove.Match(relPath) {
 					continue
 				}
+				// Don't remove targets that are in the target state, as they
+				// would only be recreated.
+				if _, err := ts.findEntry(filepath.ToSlash(relPath)); err == nil {
+					continue
+				}
 				targetsToRemove[match] = struct{}{}
 			}
 		}
 
-		// FIXME check that the set of targets to remove does not intersect wth
-		// the list of all entries.
-
 		// Remove targets in reverse order so we remove children before their
 		// parents.
 		sortedTargetsToRemove := make([]string, 0, len(targetsToRemove))
@@ -332,15 +432,52 @@ func (ts *TargetState) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptio
 			sortedTargetsToRemove = append(sortedTargetsToRemove, target)
 		}
 		sort.Sort(sort.Reverse(sort.StringSlice(sortedTargetsToRemove)))
-		for _, target := range sortedTargetsToRemove {
+		for i, target := range sortedTargetsToRemove {
+			if err := applyOptions.interrupted(); err != nil {
+				interruptedErr := newInterruptedError(applyOptions.DestDir, sortedEntries(ts.Entries), err)
+				interruptedErr.TargetPaths = append(sortedTargetsToRemove[i:], interruptedErr.TargetPaths...)
+				return interruptedErr
+			}
 			if err := mutator.RemoveAll(target); err != nil {
 				return err
 			}
 		}
 	}
 
-	for _, entryName := range sortedEntryNames(ts.Entries) {
-		if err := ts.Entries[entryName].Apply(fs, mutator, follow, applyOptions); err != nil {
+	entries := sortedEntries(ts.Entries)
+	beforeScripts := findScripts(entries, func(s *Script) bool { return s.Before })
+	hardlinks := findHardlinks(entries)
+	afterScripts := findScripts(entries, func(s *Script) bool { return s.After })
+	if len(beforeScripts) == 0 && len(hardlinks) == 0 && len(afterScripts) == 0 {
+		return ApplyEntries(fs, mutator, follow, applyOptions, entries)
+	}
+
+	// Run before scripts before anything else and after scripts after
+	// everything else. Apply hard links after all other entries so that the
+	// files that they link to exist.
+	phasedTargetNames := make(map[string]struct{})
+	for _, phaseEntries := range [][]Entry{beforeScripts, hardlinks, afterScripts} {
+		for _, entry := range phaseEntries {
+			phasedTargetNames[entry.TargetName()] = struct{}{}
+		}
+	}
+	withoutPhased := *applyOptions
+	withoutPhased.Ignore = func(targetName string) bool {
+		if _, ok := phasedTargetNames[targetName]; ok {
+			return true
+		}
+		return applyOptions.Ignore(targetName)
+	}
+	for _, phase := range []struct {
+		applyOptions *ApplyOptions
+		entries      []Entry
+	}{
+		{applyOptions, beforeScripts},
+		{&withoutPhased, entries},
+		{applyOptions, hardlinks},
+		{applyOptions, afterScripts},
+	} {
+		if err := ApplyEntries(fs, mutator, follow, phase.applyOptions, phase.entries); err != nil {
 			return err
 		}
 	}
@@ -377,6 +514,42 @@ func (ts *TargetState) ConcreteValue(recursive bool) (interface{}, error) {
 	return entryConcreteValues, nil
 }
 
+// DecodeContents returns the contents of a target from data, the contents of
+// the source file name, by decrypting and then decompressing data as
+// required.
+func (ts *TargetState) DecodeContents(name string, compressed, encrypted bool, data []byte) ([]byte, error) {
+	var err error
+	if encrypted {
+		if data, err = ts.Encryption.Decrypt(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if compressed {
+		if data, err = ts.Zstd.Decompress(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return data, nil
+}
+
+// EncodeContents returns the contents of the source file for the target
+// targetPath with contents, by compressing and then encrypting contents as
+// required.
+func (ts *TargetState) EncodeContents(targetPath string, compressed, encrypted bool, contents []byte) ([]byte, error) {
+	var err error
+	if compressed {
+		if contents, err = ts.Zstd.Compress(contents); err != nil {
+			return nil, fmt.Errorf("%s: %w", targetPath, err)
+		}
+	}
+	if encrypted {
+		if contents, err = ts.Encryption.Encrypt(targetPath, contents); err != nil {
+			return nil, err
+		}
+	}
+	return contents, nil
+}
+
 // Evaluate evaluates all of the entries in ts.
 func (ts *TargetState) Evaluate() error {
 	for _, entryName := range sortedEntryNames(ts.Entries) {
@@ -387,11 +560,12 @@ func (ts *TargetState) Evaluate() error {
 	return nil
 }
 
-// ExecuteTemplateData returns the result of executing template data.
+// ExecuteTemplateData returns the result of executing template data. Errors
+// are returned as *TemplateErrors where possible.
 func (ts *TargetState) ExecuteTemplateData(name string, data []byte) ([]byte, error) {
-	tmpl, err := template.New(name).Option(ts.TemplateOptions...).Funcs(ts.TemplateFuncs).Parse(string(data))
+	tmpl, err := template.New(name).Option(ts.TemplateOptions...).Funcs(ts.TemplateFuncs).Funcs(ts.targetTemplateFuncs()).Parse(string(data))
 	if err != nil {
-		return nil, err
+		return nil, newTemplateError(name, data, ts.TemplateData, err)
 	}
 	for name, t := range ts.Templates {
 		tmpl, err = tmpl.AddParseTree(name, t.Tree)
@@ -401,21 +575,26 @@ func (ts *TargetState) ExecuteTemplateData(name string, data []byte) ([]byte, er
 	}
 	output := &bytes.Buffer{}
 	if err = tmpl.ExecuteTemplate(output, name, ts.TemplateData); err != nil {
-		return nil, err
+		return nil, newTemplateError(name, data, ts.TemplateData, err)
 	}
 	return output.Bytes(), nil
 }
 
 // Get returns the state of the given target, or nil if no such target is found.
-func (ts *TargetState) Get(fs vfs.Stater, target string) (Entry, error) {
+func (ts *TargetState) Get(fs vfs.FS, target string) (Entry, error) {
 	contains, err := vfs.Contains(fs, target, ts.DestDir)
 	if err != nil {
 		return nil, err
 	}
 	if !contains {
-		return nil, fmt.Errorf("%s: outside target directory", target)
+		// Targets outside the target directory have absolute target names.
+		entry, err := ts.findEntry(target)
+		if err != nil {
+			return nil, fmt.Errorf("%s: outside target directory", target)
+		}
+		return entry, nil
 	}
-	targetName, err := filepath.Rel(ts.DestDir, target)
+	targetName, err := RelTargetName(fs, ts.DestDir, target)
 	if err != nil {
 		return nil, err
 	}
@@ -423,7 +602,7 @@ func (ts *TargetState) Get(fs vfs.Stater, target string) (Entry, error) {
 }
 
 // ImportTAR imports a tar archive.
-func (ts *TargetState) ImportTAR(r *tar.Reader, importTAROptions ImportTAROptions, mutator Mutator) error {
+func (ts *TargetState) ImportTAR(fs vfs.FS, r *tar.Reader, importTAROptions ImportTAROptions, mutator Mutator) error {
 	for {
 		header, err := r.Next()
 		if err == io.EOF {
@@ -433,7 +612,7 @@ func (ts *TargetState) ImportTAR(r *tar.Reader, importTAROptions ImportTAROption
 		}
 		switch header.Typeflag {
 		case tar.TypeDir, tar.TypeReg, tar.TypeSymlink:
-			if err := ts.importHeader(r, importTAROptions, header, mutator); err != nil {
+			if err := ts.importHeader(fs, r, importTAROptions, header, mutator); err != nil {
 				return err
 			}
 		case tar.TypeXGlobalHeader:
@@ -446,23 +625,64 @@ func (ts *TargetState) ImportTAR(r *tar.Reader, importTAROptions ImportTAROption
 
 // Populate walks fs from ts.SourceDir to populate ts.
 func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
-	return vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, _ error) error {
+	// .chezmoimeta.yaml files are only cached while populating.
+	defer func() {
+		ts.sourceMetas = nil
+	}()
+	sshConfigDir := ""
+	var externals []deferredExternal
+	if err := vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, _ error) error {
 		relPath, err := filepath.Rel(ts.SourceDir, path)
 		if err != nil {
 			return err
 		}
-		if relPath == "." {
+		if relPath == "." || relPath == SOPSDataName {
 			return nil
 		}
 		// Treat all files and directories beginning with "." specially.
 		if _, name := filepath.Split(relPath); strings.HasPrefix(name, ".") {
 			switch {
 			case info.Name() == ignoreName:
-				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
+				das, err := ts.parseDirNameComponents(fs, splitPathList(relPath))
+				if err != nil {
+					return err
+				}
+				dns, err := ts.targetDirNames(dirNames(das))
+				if err != nil {
+					return err
+				}
 				return ts.addPatterns(fs, ts.TargetIgnore, path, filepath.Join(dns...))
+			case info.Name() == externalName:
+				components := splitPathList(relPath)
+				das, err := ts.parseDirNameComponents(fs, components[:len(components)-1])
+				if err != nil {
+					return err
+				}
+				dns, err := ts.targetDirNames(dirNames(das))
+				if err != nil {
+					return err
+				}
+				// Defer adding externals until all other entries are known.
+				externals = append(externals, deferredExternal{
+					path:       path,
+					sourceName: relPath,
+					dns:        dns,
+				})
+				return nil
 			case info.Name() == removeName:
-				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
+				das, err := ts.parseDirNameComponents(fs, splitPathList(relPath))
+				if err != nil {
+					return err
+				}
+				dns, err := ts.targetDirNames(dirNames(das))
+				if err != nil {
+					return err
+				}
 				return ts.addPatterns(fs, ts.TargetRemove, path, filepath.Join(dns...))
+			case relPath == sshConfigDirName && info.IsDir():
+				// Defer adding ~/.ssh/config until all other entries are known.
+				sshConfigDir = path
+				return filepath.SkipDir
 			case info.Name() == templatesDirName:
 				if err := ts.addTemplatesDir(fs, path); err != nil {
 					return err
@@ -490,68 +710,154 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 		}
 		switch {
 		case info.IsDir():
-			components := splitPathList(relPath)
-			das := parseDirNameComponents(components)
-			dns := dirNames(das)
+			das, err := ts.parseDirNameComponents(fs, splitPathList(relPath))
+			if err != nil {
+				return err
+			}
+			dns, err := ts.targetDirNames(dirNames(das))
+			if err != nil {
+				return err
+			}
 			targetName := filepath.Join(dns...)
-			entries, err := ts.findEntries(dns[:len(dns)-1])
+			entries, err := ts.findOrCreateEntries(dns[:len(dns)-1])
 			if err != nil {
 				return err
 			}
 			da := das[len(das)-1]
-			entries[da.Name] = newDir(relPath, targetName, da.Exact, da.Perm)
+			dir := newDir(relPath, targetName, da.Exact, da.Perm)
+			if a, err := ts.getSourceMetaAttributes(fs, relPath); err != nil {
+				return err
+			} else if a != nil {
+				dir.ExtraAttributes = a.ExtraAttributes
+			}
+			// A directory with a path prefix does not change the permissions
+			// of the directory that it refers to, unless it is private.
+			// Similarly, directories outside the destination directory, for
+			// example /etc, are only created, unless their source names set
+			// attributes.
+			switch {
+			case len(das) == 1 && pathPrefixRegexp.MatchString(da.Name):
+				dir.implicit = !dir.Private()
+			case filepath.IsAbs(targetName):
+				dir.implicit = !da.Exact && da.Perm == 0777
+			}
+			if err := addDirEntry(entries, dns[len(dns)-1], dir); err != nil {
+				return err
+			}
 		case info.Mode().IsRegular():
-			psfp := parseSourceFilePath(relPath)
-			dns := dirNames(psfp.dirAttributes)
-			entries, err := ts.findEntries(dns)
+			psfp, err := ts.parseSourceFilePath(fs, relPath)
+			if err != nil {
+				return err
+			}
+			dns, err := ts.targetDirNames(dirNames(psfp.dirAttributes))
+			if err != nil {
+				return err
+			}
+			entries, err := ts.findOrCreateEntries(dns)
 			if err != nil {
 				return err
 			}
 			switch {
+			case psfp.fileAttributes != nil && psfp.fileAttributes.Hardlink:
+				evaluateLinkname := func() (string, error) {
+					data, err := fs.ReadFile(path)
+					return string(data), err
+				}
+				if psfp.fileAttributes.Template {
+					evaluateLinkname = func() (string, error) {
+						data, err := ts.executeTemplate(fs, path)
+						return string(data), err
+					}
+				}
+				entries[psfp.fileAttributes.Name] = &Hardlink{
+					sourceName:       relPath,
+					targetName:       filepath.Join(append(dns, psfp.fileAttributes.Name)...),
+					Template:         psfp.fileAttributes.Template,
+					evaluateLinkname: evaluateLinkname,
+				}
+			case psfp.fileAttributes != nil && psfp.fileAttributes.Mode&os.ModeType == os.ModeNamedPipe:
+				entries[psfp.fileAttributes.Name] = &FIFO{
+					sourceName: relPath,
+					targetName: filepath.Join(append(dns, psfp.fileAttributes.Name)...),
+					Perm:       psfp.fileAttributes.Mode.Perm(),
+				}
 			case psfp.fileAttributes != nil && psfp.fileAttributes.Mode&os.ModeType == 0 || psfp.scriptAttributes != nil:
+				var file *File
 				readFile := func() ([]byte, error) {
-					return fs.ReadFile(path)
+					data, err := fs.ReadFile(path)
+					if err != nil {
+						return nil, err
+					}
+					data, blobSHA256, err := ts.resolveBlobPointer(fs, relPath, data)
+					if file != nil {
+						file.blobSHA256 = blobSHA256
+					}
+					return data, err
 				}
 				evaluateContents := readFile
-				if psfp.fileAttributes != nil && psfp.fileAttributes.Encrypted {
+				if ts.LFS != nil {
 					prevEvaluateContents := evaluateContents
 					evaluateContents = func() ([]byte, error) {
-						ciphertext, err := prevEvaluateContents()
+						data, err := prevEvaluateContents()
 						if err != nil {
 							return nil, err
 						}
-						return ts.GPG.Decrypt(path, ciphertext)
+						return ts.resolveLFSPointer(fs, relPath, data)
+					}
+				}
+				if psfp.fileAttributes != nil && (psfp.fileAttributes.Compressed || psfp.fileAttributes.Encrypted) {
+					prevEvaluateContents := evaluateContents
+					compressed, encrypted := psfp.fileAttributes.Compressed, psfp.fileAttributes.Encrypted
+					evaluateContents = func() ([]byte, error) {
+						data, err := prevEvaluateContents()
+						if err != nil {
+							return nil, err
+						}
+						return ts.DecodeContents(path, compressed, encrypted, data)
 					}
 				}
 				if psfp.fileAttributes != nil && psfp.fileAttributes.Template || psfp.scriptAttributes != nil && psfp.scriptAttributes.Template {
 					if options == nil || options.ExecuteTemplates {
+						recursive := psfp.fileAttributes != nil && psfp.fileAttributes.Recursive || psfp.scriptAttributes != nil && psfp.scriptAttributes.Recursive
 						prevEvaluateContents := evaluateContents
 						evaluateContents = func() ([]byte, error) {
 							data, err := prevEvaluateContents()
 							if err != nil {
 								return nil, err
 							}
+							if recursive {
+								return ts.executeTemplateDataRecursive(path, data)
+							}
 							return ts.ExecuteTemplateData(path, data)
 						}
 					}
 				}
 				switch {
 				case psfp.fileAttributes != nil:
-					entry := &File{
+					file = &File{
 						sourceName:       relPath,
 						targetName:       filepath.Join(append(dns, psfp.fileAttributes.Name)...),
+						Compressed:       psfp.fileAttributes.Compressed,
 						Empty:            psfp.fileAttributes.Empty,
 						Encrypted:        psfp.fileAttributes.Encrypted,
 						Perm:             psfp.fileAttributes.Mode.Perm(),
 						Template:         psfp.fileAttributes.Template,
 						evaluateContents: evaluateContents,
 					}
-					entries[psfp.fileAttributes.Name] = entry
+					if a, err := ts.getSourceMetaAttributes(fs, relPath); err != nil {
+						return err
+					} else if a != nil {
+						file.ExtraAttributes = a.ExtraAttributes
+					}
+					entries[psfp.fileAttributes.Name] = file
 				case psfp.scriptAttributes != nil:
 					entry := &Script{
 						sourceName:       relPath,
 						targetName:       filepath.Join(append(dns, psfp.scriptAttributes.Name)...),
 						Once:             psfp.scriptAttributes.Once,
+						OnChange:         psfp.scriptAttributes.OnChange,
+						Before:           psfp.scriptAttributes.Before,
+						After:            psfp.scriptAttributes.After,
 						Template:         psfp.scriptAttributes.Template,
 						evaluateContents: evaluateContents,
 					}
@@ -563,8 +869,17 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 					return string(data), err
 				}
 				if psfp.fileAttributes.Template {
+					recursive := psfp.fileAttributes.Recursive
 					evaluateLinkname = func() (string, error) {
-						data, err := ts.executeTemplate(fs, path)
+						data, err := fs.ReadFile(path)
+						if err != nil {
+							return "", err
+						}
+						if recursive {
+							data, err = ts.executeTemplateDataRecursive(path, data)
+						} else {
+							data, err = ts.ExecuteTemplateData(path, data)
+						}
 						return string(data), err
 					}
 				}
@@ -582,10 +897,48 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 			return fmt.Errorf("%s: unsupported file type", path)
 		}
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
+	if len(externals) != 0 && ts.ExternalLock == nil {
+		externalLock, err := ReadExternalLock(fs, ts.SourceDir)
+		if err != nil {
+			return err
+		}
+		ts.ExternalLock = externalLock
+	}
+	for _, external := range externals {
+		if err := ts.addExternals(fs, external.path, external.sourceName, external.dns); err != nil {
+			return err
+		}
+	}
+	if sshConfigDir != "" {
+		return ts.addSSHConfig(fs, sshConfigDir, options)
+	}
+	return nil
 }
 
-func (ts *TargetState) addDir(targetName string, entries map[string]Entry, parentDirSourceName string, exact bool, perm os.FileMode, createKeepFile bool, mutator Mutator) error {
+// ReadSourceFile returns the contents of the source file sourceName, reading
+// them from the blob store or git-lfs if it contains a pointer. It also returns
+// whether the contents were read from the blob store.
+func (ts *TargetState) ReadSourceFile(fs vfs.FS, sourceName string) ([]byte, bool, error) {
+	data, err := fs.ReadFile(filepath.Join(ts.SourceDir, sourceName))
+	if err != nil {
+		return nil, false, err
+	}
+	data, blobSHA256, err := ts.resolveBlobPointer(fs, sourceName, data)
+	if err != nil {
+		return nil, false, err
+	}
+	if ts.LFS != nil {
+		if data, err = ts.resolveLFSPointer(fs, sourceName, data); err != nil {
+			return nil, false, err
+		}
+	}
+	return data, blobSHA256 != nil, nil
+}
+
+func (ts *TargetState) addDir(fs vfs.FS, targetName string, entries map[string]Entry, parentDirSourceName string, exact bool, perm os.FileMode, createKeepFile bool, mutator Mutator) error {
 	name := filepath.Base(targetName)
 	if entry, ok := entries[name]; ok {
 		if _, ok = entry.(*Dir); !ok {
@@ -593,13 +946,17 @@ func (ts *TargetState) addDir(targetName string, entries map[string]Entry, paren
 		}
 		return nil
 	}
-	sourceName := DirAttributes{
+	da := DirAttributes{
 		Name:  name,
 		Exact: exact,
 		Perm:  perm,
-	}.SourceName()
-	if parentDirSourceName != "" {
-		sourceName = filepath.Join(parentDirSourceName, sourceName)
+	}
+	sourceName, err := ts.newSourceName(fs, parentDirSourceName, name, SourceMetaAttributes{
+		Exact:   exact,
+		Private: perm&077 == 0,
+	}, da.SourceName, mutator)
+	if err != nil {
+		return err
 	}
 	dir := newDir(sourceName, targetName, exact, perm)
 	if err := mutator.Mkdir(filepath.Join(ts.SourceDir, sourceName), 0777&^ts.Umask); err != nil {
@@ -614,7 +971,7 @@ func (ts *TargetState) addDir(targetName string, entries map[string]Entry, paren
 	return nil
 }
 
-func (ts *TargetState) addFile(targetName string, entries map[string]Entry, parentDirSourceName string, info os.FileInfo, perm os.FileMode, encrypted, template bool, contents []byte, mutator Mutator) error {
+func (ts *TargetState) addFile(fs vfs.FS, targetName string, entries map[string]Entry, parentDirSourceName string, info os.FileInfo, perm os.FileMode, compressed, encrypted, template bool, contents []byte, rename RenameFunc, mutator Mutator) error {
 	name := filepath.Base(targetName)
 	var existingFile *File
 	var existingContents []byte
@@ -631,25 +988,40 @@ func (ts *TargetState) addFile(targetName string, entries map[string]Entry, pare
 	}
 
 	empty := info.Size() == 0
-	sourceName := FileAttributes{
-		Name:      name,
-		Mode:      perm,
-		Empty:     empty,
-		Encrypted: encrypted,
-		Template:  template,
-	}.SourceName()
-	if parentDirSourceName != "" {
-		sourceName = filepath.Join(parentDirSourceName, sourceName)
+	fa := FileAttributes{
+		Name:       name,
+		Mode:       perm,
+		Compressed: compressed,
+		Empty:      empty,
+		Encrypted:  encrypted,
+		Template:   template,
+	}
+	sourceName, err := ts.newSourceName(fs, parentDirSourceName, name, SourceMetaAttributes{
+		Compressed: compressed,
+		Empty:      empty,
+		Encrypted:  encrypted,
+		Executable: perm&0111 != 0,
+		Private:    perm&077 == 0,
+		Template:   template,
+	}, fa.SourceName, mutator)
+	if err != nil {
+		return err
 	}
 	file := &File{
 		sourceName: sourceName,
 		targetName: targetName,
+		Compressed: compressed,
 		Empty:      empty,
 		Encrypted:  encrypted,
 		Perm:       perm,
 		Template:   template,
 		contents:   contents,
 	}
+	if existingFile == nil && rename != nil && !compressed && !encrypted && !template {
+		if renamed, err := ts.addRenamedFile(fs, file, entries, rename); err != nil || renamed {
+			return err
+		}
+	}
 	if existingFile != nil {
 		if bytes.Equal(existingFile.contents, file.contents) {
 			if existingFile.sourceName == file.sourceName {
@@ -697,7 +1069,7 @@ func (ts *TargetState) addPatterns(fs vfs.FS, ps *PatternSet, path, relPath stri
 	return nil
 }
 
-func (ts *TargetState) addSymlink(targetName string, entries map[string]Entry, parentDirSourceName, linkname string, mutator Mutator) error {
+func (ts *TargetState) addSymlink(fs vfs.FS, targetName string, entries map[string]Entry, parentDirSourceName, linkname string, mutator Mutator) error {
 	name := filepath.Base(targetName)
 	var existingSymlink *Symlink
 	var existingLinkname string
@@ -712,12 +1084,15 @@ func (ts *TargetState) addSymlink(targetName string, entries map[string]Entry, p
 			return err
 		}
 	}
-	sourceName := FileAttributes{
+	fa := FileAttributes{
 		Name: name,
 		Mode: os.ModeSymlink,
-	}.SourceName()
-	if parentDirSourceName != "" {
-		sourceName = filepath.Join(parentDirSourceName, sourceName)
+	}
+	sourceName, err := ts.newSourceName(fs, parentDirSourceName, name, SourceMetaAttributes{
+		Type: "symlink",
+	}, fa.SourceName, mutator)
+	if err != nil {
+		return err
 	}
 	symlink := &Symlink{
 		sourceName: sourceName,
@@ -752,7 +1127,7 @@ func (ts *TargetState) addTemplatesDir(fs vfs.FS, path string) error {
 				return err
 			}
 			name := strings.TrimPrefix(filepath.ToSlash(path), prefix)
-			tmpl, err := template.New(name).Parse(string(contents))
+			tmpl, err := template.New(name).Funcs(ts.TemplateFuncs).Parse(string(contents))
 			if err != nil {
 				return err
 			}
@@ -769,6 +1144,26 @@ func (ts *TargetState) addTemplatesDir(fs vfs.FS, path string) error {
 	})
 }
 
+// executeTemplateDataRecursive executes template data, and then executes its
+// output as a template until the output no longer changes. It returns an error
+// if the output is still changing after maxTemplatePasses passes.
+func (ts *TargetState) executeTemplateDataRecursive(name string, data []byte) ([]byte, error) {
+	for pass := 0; pass < maxTemplatePasses; pass++ {
+		output, err := ts.ExecuteTemplateData(name, data)
+		if err != nil {
+			if pass > 0 {
+				return nil, fmt.Errorf("pass %d: %w", pass+1, err)
+			}
+			return nil, err
+		}
+		if bytes.Equal(output, data) {
+			return output, nil
+		}
+		data = output
+	}
+	return nil, fmt.Errorf("%s: template output still changing after %d passes", name, maxTemplatePasses)
+}
+
 func (ts *TargetState) executeTemplate(fs vfs.FS, path string) ([]byte, error) {
 	data, err := fs.ReadFile(path)
 	if err != nil {
@@ -777,6 +1172,44 @@ func (ts *TargetState) executeTemplate(fs vfs.FS, path string) ([]byte, error) {
 	return ts.ExecuteTemplateData(path, data)
 }
 
+// targetContentsTemplateFunc returns the contents of target in ts. target is
+// either absolute, relative to the destination directory, or begins with ~/,
+// which is also relative to the destination directory. Ignored targets do not
+// exist.
+func (ts *TargetState) targetContentsTemplateFunc(target string) string {
+	targetName := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(target, "~/")))
+	if filepath.IsAbs(targetName) && ts.DestDir != "" {
+		if relPath, err := filepath.Rel(ts.DestDir, targetName); err == nil && !isOutside(relPath) {
+			targetName = relPath
+		}
+	}
+	if ts.TargetIgnore.Match(targetName) {
+		panic(fmt.Errorf("targetContents: %s: %w", target, os.ErrNotExist))
+	}
+	entry, err := ts.findEntry(targetName)
+	if err != nil {
+		panic(fmt.Errorf("targetContents: %s: %w", target, err))
+	}
+	file, ok := entry.(*File)
+	if !ok {
+		panic(fmt.Errorf("targetContents: %s: not a file", target))
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		panic(fmt.Errorf("targetContents: %w", err))
+	}
+	return string(contents)
+}
+
+// targetTemplateFuncs returns the template functions that depend on ts. They
+// are added to ts.TemplateFuncs, which may be shared with other target states,
+// when a template is executed.
+func (ts *TargetState) targetTemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"targetContents": ts.targetContentsTemplateFunc,
+	}
+}
+
 func (ts *TargetState) findEntries(dirNames []string) (map[string]Entry, error) {
 	entries := ts.Entries
 	for i, dirName := range dirNames {
@@ -792,7 +1225,7 @@ func (ts *TargetState) findEntries(dirNames []string) (map[string]Entry, error)
 }
 
 func (ts *TargetState) findEntry(name string) (Entry, error) {
-	names := splitPathList(name)
+	names := splitTargetName(normalizeName(name))
 	entries, err := ts.findEntries(names[:len(names)-1])
 	if err != nil {
 		return nil, err
@@ -804,7 +1237,7 @@ func (ts *TargetState) findEntry(name string) (Entry, error) {
 	return entry, nil
 }
 
-func (ts *TargetState) importHeader(r io.Reader, importTAROptions ImportTAROptions, header *tar.Header, mutator Mutator) error {
+func (ts *TargetState) importHeader(fs vfs.FS, r io.Reader, importTAROptions ImportTAROptions, header *tar.Header, mutator Mutator) error {
 	targetPath := header.Name
 	if importTAROptions.StripComponents > 0 {
 		targetPath = filepath.Join(strings.Split(targetPath, string(os.PathSeparator))[importTAROptions.StripComponents:]...)
@@ -836,17 +1269,17 @@ func (ts *TargetState) importHeader(r io.Reader, importTAROptions ImportTAROptio
 	case tar.TypeDir:
 		perm := os.FileMode(header.Mode).Perm()
 		createKeepFile := false // FIXME don't assume that we don't need a keep file
-		return ts.addDir(targetName, entries, parentDirSourceName, importTAROptions.Exact, perm, createKeepFile, mutator)
+		return ts.addDir(fs, targetName, entries, parentDirSourceName, importTAROptions.Exact, perm, createKeepFile, mutator)
 	case tar.TypeReg:
 		info := header.FileInfo()
 		contents, err := ioutil.ReadAll(r)
 		if err != nil {
 			return err
 		}
-		return ts.addFile(targetName, entries, parentDirSourceName, info, info.Mode().Perm(), false, false, contents, mutator)
+		return ts.addFile(fs, targetName, entries, parentDirSourceName, info, info.Mode().Perm(), false, false, false, contents, importTAROptions.Rename, mutator)
 	case tar.TypeSymlink:
 		linkname := header.Linkname
-		return ts.addSymlink(targetName, entries, parentDirSourceName, linkname, mutator)
+		return ts.addSymlink(fs, targetName, entries, parentDirSourceName, linkname, mutator)
 	default:
 		return fmt.Errorf("%s: unspported typeflag '%c'", header.Name, header.Typeflag)
 	}
@@ -9,6 +9,7 @@ import (
 	"github.com/coreos/go-semver/semver"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
 	"github.com/twpayne/go-vfs/vfst"
 )
 
@@ -94,6 +95,61 @@ func TestEndToEnd(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "symlinked_dir",
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".config": &vfst.Symlink{Target: "../../data/config"},
+				},
+				"/data/config": map[string]interface{}{
+					"foo": "old",
+				},
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_config/foo": "new",
+				},
+			},
+			sourceDir: "/home/user/.local/share/chezmoi",
+			destDir:   "/home/user",
+			umask:     022,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.config",
+					vfst.TestModeType(os.ModeSymlink),
+					vfst.TestSymlinkTarget("../../data/config"),
+				),
+				vfst.TestPath("/data/config/foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("new"),
+				),
+			},
+		},
+		{
+			name: "hardlink",
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".aliases": "old",
+				},
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_bashrc":           "bar",
+					"hardlink_dot_aliases": ".bashrc\n",
+				},
+			},
+			sourceDir: "/home/user/.local/share/chezmoi",
+			destDir:   "/home/user",
+			umask:     022,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.aliases",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("bar"),
+				),
+				func(t *testing.T, fs vfs.FS) {
+					info1, err := fs.Lstat("/home/user/.aliases")
+					require.NoError(t, err)
+					info2, err := fs.Lstat("/home/user/.bashrc")
+					require.NoError(t, err)
+					assert.True(t, os.SameFile(info1, info2))
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			fs, cleanup, err := vfst.NewTestFS(tc.root)
@@ -120,6 +176,36 @@ func TestEndToEnd(t *testing.T) {
 	}
 }
 
+func TestTargetStateApplyHardlinkOutsideDestDir(t *testing.T) {
+	for _, linkname := range []string{"../../etc/passwd", "/etc/passwd"} {
+		t.Run(linkname, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/etc/passwd": "root",
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"hardlink_dot_passwd": linkname + "\n",
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+			)
+			require.NoError(t, ts.Populate(fs, nil))
+			applyOptions := &ApplyOptions{
+				DestDir: ts.DestDir,
+				Ignore:  ts.TargetIgnore.Match,
+			}
+			assert.Error(t, ts.Apply(fs, NewFSMutator(fs), false, applyOptions))
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/.passwd",
+					vfst.TestDoesNotExist,
+				),
+			)
+		})
+	}
+}
+
 func TestTargetStatePopulate(t *testing.T) {
 	for _, tc := range []struct {
 		name          string
@@ -488,3 +574,126 @@ func TestTargetStatePopulate(t *testing.T) {
 		})
 	}
 }
+
+func TestTargetStateTargetContents(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		root        interface{}
+		targetName  string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name: "relative",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_gitconfig.tmpl": "email = {{ .email }}\n",
+					"summary.tmpl":       `{{ targetContents ".gitconfig" | printf "%q" }}`,
+				},
+			},
+			targetName: "summary",
+			expected:   `"email = user@example.com\n"`,
+		},
+		{
+			name: "tilde_and_absolute",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_config/app/settings": "settings",
+					"summary.tmpl":            `{{ targetContents "~/.config/app/settings" }} {{ targetContents "/home/user/.config/app/settings" }}`,
+				},
+			},
+			targetName: "summary",
+			expected:   "settings settings",
+		},
+		{
+			name: "chain",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"a.tmpl": `a{{ targetContents "b" }}`,
+					"b.tmpl": `b{{ targetContents "c" }}`,
+					"c":      "c",
+				},
+			},
+			targetName: "a",
+			expected:   "abc",
+		},
+		{
+			name: "cycle",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"a.tmpl": `{{ targetContents "b" }}`,
+					"b.tmpl": `{{ targetContents "a" }}`,
+				},
+			},
+			targetName:  "a",
+			expectedErr: "a: contents depend on themselves",
+		},
+		{
+			name: "self",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"a.tmpl": `{{ targetContents "a" }}`,
+				},
+			},
+			targetName:  "a",
+			expectedErr: "a: contents depend on themselves",
+		},
+		{
+			name: "not_exist",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"a.tmpl": `{{ targetContents "b" }}`,
+				},
+			},
+			targetName:  "a",
+			expectedErr: "targetContents: b: file does not exist",
+		},
+		{
+			name: "ignored",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiignore": "b\n",
+					"a.tmpl":         `{{ targetContents "b" }}`,
+					"b":              "b",
+				},
+			},
+			targetName:  "a",
+			expectedErr: "targetContents: b: file does not exist",
+		},
+		{
+			name: "dir",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"a.tmpl":  `{{ targetContents "dir" }}`,
+					"dir/foo": "foo",
+				},
+			},
+			targetName:  "a",
+			expectedErr: "targetContents: dir: not a file",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+				WithTemplateData(map[string]interface{}{
+					"email": "user@example.com",
+				}),
+			)
+			require.NoError(t, ts.Populate(fs, nil))
+			entry, err := ts.findEntry(tc.targetName)
+			require.NoError(t, err)
+			actual, err := entry.(*File).Contents()
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, string(actual))
+			}
+		})
+	}
+}
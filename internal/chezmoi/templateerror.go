@@ -0,0 +1,196 @@
+package chezmoi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	templateErrorLocationRegexp = regexp.MustCompile(`\A(\d+)(?::(\d+))?: `)
+	templateMissingKeyRegexp    = regexp.MustCompile(`at <\$?((?:\.[^.>]+)+)>: map has no entry for key "([^"]*)"`)
+)
+
+// A TemplateError is an error parsing or executing a template, with the
+// location of the error, the surrounding lines of the template, and
+// suggestions for misspelled data keys.
+type TemplateError struct {
+	Name        string
+	Line        int
+	Column      int
+	Message     string
+	Snippet     string
+	Suggestions []string
+	Keys        []string
+	Err         error
+}
+
+func (e *TemplateError) Error() string {
+	sb := &strings.Builder{}
+	sb.WriteString(e.Name)
+	sb.WriteString(":" + strconv.Itoa(e.Line))
+	if e.Column != 0 {
+		sb.WriteString(":" + strconv.Itoa(e.Column))
+	}
+	sb.WriteString(": " + e.Message)
+	if e.Snippet != "" {
+		sb.WriteString("\n" + e.Snippet)
+	}
+	switch {
+	case len(e.Suggestions) != 0:
+		sb.WriteString("\ndid you mean " + strings.Join(e.Suggestions, " or ") + "?")
+	case len(e.Keys) != 0:
+		sb.WriteString("\navailable keys: " + strings.Join(e.Keys, ", "))
+	}
+	return sb.String()
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// newTemplateError returns err, an error from parsing or executing the
+// template name with contents data and template data templateData, as a
+// *TemplateError. If the location of err cannot be determined, for example
+// because it is in another template, then err is returned unchanged.
+func newTemplateError(name string, data []byte, templateData map[string]interface{}, err error) error {
+	rest := strings.TrimPrefix(err.Error(), "template: "+name+":")
+	if rest == err.Error() {
+		return err
+	}
+	m := templateErrorLocationRegexp.FindStringSubmatch(rest)
+	if m == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(m[1])
+	column := 0
+	if m[2] != "" {
+		// text/template reports zero-based columns.
+		column, _ = strconv.Atoi(m[2])
+		column++
+	}
+	templateErr := &TemplateError{
+		Name:    name,
+		Line:    line,
+		Column:  column,
+		Message: strings.TrimPrefix(rest[len(m[0]):], "executing "+strconv.Quote(name)+" "),
+		Snippet: templateSnippet(data, line, column),
+		Err:     err,
+	}
+	if m := templateMissingKeyRegexp.FindStringSubmatch(templateErr.Message); m != nil {
+		fields := strings.Split(strings.TrimPrefix(m[1], "."), ".")
+		if keys, ok := templateDataKeys(templateData, fields[:len(fields)-1]); ok {
+			prefix := "." + strings.Join(append(fields[:len(fields)-1], ""), ".")
+			for _, key := range suggestKeys(m[2], keys) {
+				templateErr.Suggestions = append(templateErr.Suggestions, prefix+key)
+			}
+			const maxKeys = 10
+			if len(keys) > maxKeys {
+				keys = append(keys[:maxKeys], "...")
+			}
+			templateErr.Keys = keys
+		}
+	}
+	return templateErr
+}
+
+// suggestKeys returns the keys in keys that are close to key, closest first.
+func suggestKeys(key string, keys []string) []string {
+	maxDistance := 2
+	if len(key) < 4 {
+		maxDistance = 1
+	}
+	type suggestion struct {
+		key      string
+		distance int
+	}
+	var suggestions []suggestion
+	for _, k := range keys {
+		distance := levenshteinDistance(strings.ToLower(key), strings.ToLower(k))
+		if distance <= maxDistance {
+			suggestions = append(suggestions, suggestion{key: k, distance: distance})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+	result := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		result = append(result, s.key)
+	}
+	return result
+}
+
+// levenshteinDistance returns the edit distance between a and b.
+func levenshteinDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// templateDataKeys returns the sorted keys of the map found by following
+// fields from templateData.
+func templateDataKeys(templateData map[string]interface{}, fields []string) ([]string, bool) {
+	m := templateData
+	for _, field := range fields {
+		var ok bool
+		if m, ok = m[field].(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, true
+}
+
+// templateSnippet returns the lines of data around line, with a marker under
+// column if it is not zero.
+func templateSnippet(data []byte, line, column int) string {
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	first, last := line-1, line+1
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	width := len(strconv.Itoa(last))
+	sb := &strings.Builder{}
+	for i := first; i <= last; i++ {
+		fmt.Fprintf(sb, "%*d | %s\n", width, i, strings.TrimRight(lines[i-1], "\r"))
+		if i == line && column != 0 {
+			fmt.Fprintf(sb, "%*s | %s^\n", width, "", strings.Repeat(" ", column-1))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,98 @@
+package chezmoi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateError(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		data         string
+		templateData map[string]interface{}
+		expectedErr  string
+	}{
+		{
+			name: "misspelled_key",
+			data: "[user]\n    email = {{ .emial }}\n    name = {{ .name }}\n",
+			templateData: map[string]interface{}{
+				"email": "john.smith@company.com",
+				"name":  "John Smith",
+			},
+			expectedErr: "" +
+				"dot_gitconfig.tmpl:2:16: at <.emial>: map has no entry for key \"emial\"\n" +
+				"1 | [user]\n" +
+				"2 |     email = {{ .emial }}\n" +
+				"  |                ^\n" +
+				"3 |     name = {{ .name }}\n" +
+				"did you mean .email?",
+		},
+		{
+			name: "nested_missing_key",
+			data: "{{ .work.emial }}",
+			templateData: map[string]interface{}{
+				"work": map[string]interface{}{
+					"email": "john.smith@company.com",
+					"host":  "work",
+				},
+			},
+			expectedErr: "" +
+				"dot_gitconfig.tmpl:1:9: at <.work.emial>: map has no entry for key \"emial\"\n" +
+				"1 | {{ .work.emial }}\n" +
+				"  |         ^\n" +
+				"did you mean .work.email?",
+		},
+		{
+			name: "no_suggestion",
+			data: "{{ .hostname }}",
+			templateData: map[string]interface{}{
+				"email": "john.smith@company.com",
+				"name":  "John Smith",
+			},
+			expectedErr: "" +
+				"dot_gitconfig.tmpl:1:4: at <.hostname>: map has no entry for key \"hostname\"\n" +
+				"1 | {{ .hostname }}\n" +
+				"  |    ^\n" +
+				"available keys: email, name",
+		},
+		{
+			name: "parse_error",
+			data: "[user]\n{{ end }}\n",
+			expectedErr: "" +
+				"dot_gitconfig.tmpl:2: unexpected {{end}}\n" +
+				"1 | [user]\n" +
+				"2 | {{ end }}\n" +
+				"3 | ",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := NewTargetState(
+				WithTemplateData(tc.templateData),
+				WithTemplateOptions(DefaultTemplateOptions),
+			)
+			_, err := ts.ExecuteTemplateData("dot_gitconfig.tmpl", []byte(tc.data))
+			require.Error(t, err)
+			assert.Equal(t, tc.expectedErr, err.Error())
+			var templateErr *TemplateError
+			assert.True(t, errors.As(err, &templateErr))
+		})
+	}
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "", b: "", expected: 0},
+		{a: "email", b: "email", expected: 0},
+		{a: "emial", b: "email", expected: 2},
+		{a: "emai", b: "email", expected: 1},
+		{a: "kitten", b: "sitting", expected: 3},
+	} {
+		assert.Equal(t, tc.expected, levenshteinDistance(tc.a, tc.b))
+	}
+}
@@ -0,0 +1,168 @@
+package chezmoi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A Trash moves files to a trash directory instead of removing them.
+type Trash struct {
+	fs        vfs.FS
+	dir       string
+	trashInfo bool
+	now       func() time.Time
+}
+
+// NewXDGTrash returns a new Trash in dir that follows the freedesktop.org
+// trash specification, as used on Linux and the BSDs. dir is normally
+// $XDG_DATA_HOME/Trash.
+func NewXDGTrash(fs vfs.FS, dir string) *Trash {
+	return &Trash{
+		fs:        fs,
+		dir:       dir,
+		trashInfo: true,
+		now:       time.Now,
+	}
+}
+
+// NewDirTrash returns a new Trash that moves files directly into dir, like the
+// ~/.Trash directory on macOS.
+func NewDirTrash(fs vfs.FS, dir string) *Trash {
+	return &Trash{
+		fs:  fs,
+		dir: dir,
+		now: time.Now,
+	}
+}
+
+// Trash moves name to t. If a file with the same name is already in t then a
+// numeric suffix is added.
+func (t *Trash) Trash(name string) error {
+	if _, err := t.fs.Lstat(name); err != nil {
+		return err
+	}
+	filesDir := t.dir
+	infoDir := ""
+	if t.trashInfo {
+		filesDir = filepath.Join(t.dir, "files")
+		infoDir = filepath.Join(t.dir, "info")
+		if err := vfs.MkdirAll(t.fs, infoDir, 0700); err != nil {
+			return err
+		}
+	}
+	if err := vfs.MkdirAll(t.fs, filesDir, 0700); err != nil {
+		return err
+	}
+	base := filepath.Base(name)
+	for i := 1; ; i++ {
+		trashName := base
+		if i > 1 {
+			trashName = fmt.Sprintf("%s.%d", base, i)
+		}
+		trashPath := filepath.Join(filesDir, trashName)
+		if _, err := t.fs.Lstat(trashPath); err == nil {
+			continue
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		infoPath := ""
+		if t.trashInfo {
+			// Create the .trashinfo file exclusively to reserve trashName.
+			infoPath = filepath.Join(infoDir, trashName+".trashinfo")
+			reserved, err := t.writeTrashInfo(infoPath, name)
+			if err != nil {
+				return err
+			}
+			if !reserved {
+				continue
+			}
+		}
+		err := t.fs.Rename(name, trashPath)
+		// The trash may be on a different filesystem to name, in which case
+		// name is copied to the trash and then removed.
+		if errors.Is(err, syscall.EXDEV) {
+			err = t.copyAndRemove(name, trashPath)
+		}
+		if err != nil {
+			if infoPath != "" {
+				_ = t.fs.Remove(infoPath)
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// copyAndRemove copies name to trashPath and then removes name. If copying
+// fails then anything already copied to trashPath is removed.
+func (t *Trash) copyAndRemove(name, trashPath string) error {
+	if err := t.copyAll(name, trashPath); err != nil {
+		_ = t.fs.RemoveAll(trashPath)
+		return err
+	}
+	return t.fs.RemoveAll(name)
+}
+
+// copyAll copies the directory, regular file, or symlink src to dst,
+// recursively, preserving permissions.
+func (t *Trash) copyAll(src, dst string) error {
+	info, err := t.fs.Lstat(src)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.IsDir():
+		if err := t.fs.Mkdir(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		infos, err := t.fs.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			if err := t.copyAll(filepath.Join(src, info.Name()), filepath.Join(dst, info.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	case info.Mode().IsRegular():
+		data, err := t.fs.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		return t.fs.WriteFile(dst, data, info.Mode().Perm())
+	case info.Mode()&os.ModeType == os.ModeSymlink:
+		linkname, err := t.fs.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return t.fs.Symlink(linkname, dst)
+	default:
+		return fmt.Errorf("%s: cannot copy %s to trash", src, info.Mode()&os.ModeType)
+	}
+}
+
+// writeTrashInfo writes the .trashinfo file at infoPath for name. It returns
+// false if infoPath already exists.
+func (t *Trash) writeTrashInfo(infoPath, name string) (bool, error) {
+	f, err := t.fs.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	switch {
+	case os.IsExist(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	u := &url.URL{Path: filepath.ToSlash(name)}
+	if _, err := fmt.Fprintf(f, "[Trash Info]\nPath=%s\nDeletionDate=%s\n", u.EscapedPath(), t.now().Format("2006-01-02T15:04:05")); err != nil {
+		_ = f.Close()
+		return false, err
+	}
+	return true, f.Close()
+}
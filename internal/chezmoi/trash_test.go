@@ -0,0 +1,153 @@
+package chezmoi
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestTrash(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "bashrc",
+			"dir": map[string]interface{}{
+				".bashrc": "dir bashrc",
+			},
+			"my file": "my file",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	trash := NewXDGTrash(fs, "/home/user/.local/share/Trash")
+	trash.now = func() time.Time {
+		return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	assert.NoError(t, trash.Trash("/home/user/.bashrc"))
+	assert.NoError(t, trash.Trash("/home/user/dir/.bashrc"))
+	assert.NoError(t, trash.Trash("/home/user/my file"))
+	assert.True(t, os.IsNotExist(trash.Trash("/home/user/missing")))
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/dir/.bashrc",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.local/share/Trash/files/.bashrc",
+			vfst.TestContentsString("bashrc"),
+		),
+		vfst.TestPath("/home/user/.local/share/Trash/info/.bashrc.trashinfo",
+			vfst.TestModePerm(0600),
+			vfst.TestContentsString("[Trash Info]\nPath=/home/user/.bashrc\nDeletionDate=2020-01-02T03:04:05\n"),
+		),
+		vfst.TestPath("/home/user/.local/share/Trash/files/.bashrc.2",
+			vfst.TestContentsString("dir bashrc"),
+		),
+		vfst.TestPath("/home/user/.local/share/Trash/info/.bashrc.2.trashinfo",
+			vfst.TestContentsString("[Trash Info]\nPath=/home/user/dir/.bashrc\nDeletionDate=2020-01-02T03:04:05\n"),
+		),
+		vfst.TestPath("/home/user/.local/share/Trash/info/my file.trashinfo",
+			vfst.TestContentsString("[Trash Info]\nPath=/home/user/my%20file\nDeletionDate=2020-01-02T03:04:05\n"),
+		),
+	)
+}
+
+// An exdevFS is a vfs.FS whose Rename always fails as if across filesystems.
+type exdevFS struct {
+	vfs.FS
+}
+
+func (fs *exdevFS) Rename(oldpath, newpath string) error {
+	return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+}
+
+func TestTrashCrossDevice(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "bashrc",
+			".config": map[string]interface{}{
+				"app": &vfst.File{
+					Perm:     0600,
+					Contents: []byte("app"),
+				},
+				"link": &vfst.Symlink{Target: "app"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	trash := NewDirTrash(&exdevFS{FS: fs}, "/home/user/.Trash")
+	assert.NoError(t, trash.Trash("/home/user/.bashrc"))
+	assert.NoError(t, trash.Trash("/home/user/.config"))
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.config",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.Trash/.bashrc",
+			vfst.TestContentsString("bashrc"),
+		),
+		vfst.TestPath("/home/user/.Trash/.config/app",
+			vfst.TestModePerm(0600),
+			vfst.TestContentsString("app"),
+		),
+		vfst.TestPath("/home/user/.Trash/.config/link",
+			vfst.TestSymlinkTarget("app"),
+		),
+	)
+}
+
+func TestTrashMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			"foo":  "foo",
+			"bar":  "bar",
+			"baz":  "baz",
+			"link": "link",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	isTrashed := func(name string) bool {
+		return name == "/home/user/foo" || name == "/home/user/link"
+	}
+	m := NewTrashMutator(NewFSMutator(fs), isTrashed, NewDirTrash(fs, "/home/user/.Trash").Trash)
+	assert.NoError(t, m.RemoveAll("/home/user/foo"))
+	assert.NoError(t, m.RemoveAll("/home/user/bar"))
+	assert.NoError(t, m.RemoveAll("/home/user/missing"))
+	assert.NoError(t, m.Link("/home/user/baz", "/home/user/link"))
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/foo",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/bar",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.Trash/foo",
+			vfst.TestContentsString("foo"),
+		),
+		vfst.TestPath("/home/user/.Trash/bar",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/link",
+			vfst.TestContentsString("baz"),
+		),
+		vfst.TestPath("/home/user/.Trash/link",
+			vfst.TestContentsString("link"),
+		),
+	)
+}
@@ -0,0 +1,93 @@
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+)
+
+// A TrashMutator wraps another Mutator and moves files to the trash instead of
+// removing them, so that they can be recovered.
+type TrashMutator struct {
+	m         Mutator
+	isTrashed func(string) bool
+	trash     func(string) error
+}
+
+// NewTrashMutator returns a new TrashMutator that calls trash instead of
+// removing the paths for which isTrashed returns true.
+func NewTrashMutator(m Mutator, isTrashed func(string) bool, trash func(string) error) *TrashMutator {
+	return &TrashMutator{
+		m:         m,
+		isTrashed: isTrashed,
+		trash:     trash,
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *TrashMutator) Chmod(name string, mode os.FileMode) error {
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *TrashMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Link implements Mutator.Link. Any existing newname is removed first, so that
+// it is moved to the trash if needed.
+func (m *TrashMutator) Link(oldname, newname string) error {
+	if err := m.RemoveAll(newname); err != nil {
+		return err
+	}
+	return m.m.Link(oldname, newname)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *TrashMutator) Mkdir(name string, perm os.FileMode) error {
+	return m.m.Mkdir(name, perm)
+}
+
+// Mkfifo implements Mutator.Mkfifo. Any existing name is removed first, so
+// that it is moved to the trash if needed.
+func (m *TrashMutator) Mkfifo(name string, perm os.FileMode) error {
+	if err := m.RemoveAll(name); err != nil {
+		return err
+	}
+	return m.m.Mkfifo(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *TrashMutator) RemoveAll(name string) error {
+	if !m.isTrashed(name) {
+		return m.m.RemoveAll(name)
+	}
+	if err := m.trash(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Rename implements Mutator.Rename.
+func (m *TrashMutator) Rename(oldpath, newpath string) error {
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *TrashMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *TrashMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *TrashMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	return m.m.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *TrashMutator) WriteSymlink(oldname, newname string) error {
+	return m.m.WriteSymlink(oldname, newname)
+}
@@ -56,6 +56,18 @@ func (m *VerboseMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 	return output, err
 }
 
+// Link implements Mutator.Link.
+func (m *VerboseMutator) Link(oldname, newname string) error {
+	action := fmt.Sprintf("ln -f %s %s", MaybeShellQuote(oldname), MaybeShellQuote(newname))
+	err := m.m.Link(oldname, newname)
+	if err == nil {
+		_, _ = fmt.Fprintln(m.w, action)
+	} else {
+		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
+	}
+	return err
+}
+
 // Mkdir implements Mutator.Mkdir.
 func (m *VerboseMutator) Mkdir(name string, perm os.FileMode) error {
 	action := fmt.Sprintf("mkdir -m %o %s", perm, MaybeShellQuote(name))
@@ -68,6 +80,18 @@ func (m *VerboseMutator) Mkdir(name string, perm os.FileMode) error {
 	return err
 }
 
+// Mkfifo implements Mutator.Mkfifo.
+func (m *VerboseMutator) Mkfifo(name string, perm os.FileMode) error {
+	action := fmt.Sprintf("mkfifo -m %o %s", perm, MaybeShellQuote(name))
+	err := m.m.Mkfifo(name, perm)
+	if err == nil {
+		_, _ = fmt.Fprintln(m.w, action)
+	} else {
+		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
+	}
+	return err
+}
+
 // RemoveAll implements Mutator.RemoveAll.
 func (m *VerboseMutator) RemoveAll(name string) error {
 	action := fmt.Sprintf("rm -rf %s", MaybeShellQuote(name))
@@ -0,0 +1,11 @@
+package chezmoi
+
+// Xz interfaces with xz.
+type Xz struct {
+	Command string
+}
+
+// Decompress decompresses data.
+func (x *Xz) Decompress(data []byte) ([]byte, error) {
+	return runFilter(x.Command, data, "--decompress", "--quiet", "--stdout")
+}
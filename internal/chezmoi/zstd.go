@@ -0,0 +1,37 @@
+package chezmoi
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+)
+
+// Zstd interfaces with zstd.
+type Zstd struct {
+	Command string
+}
+
+// Compress compresses data.
+func (z *Zstd) Compress(data []byte) ([]byte, error) {
+	return runFilter(z.Command, data, "--quiet", "--stdout")
+}
+
+// Decompress decompresses data.
+func (z *Zstd) Decompress(data []byte) ([]byte, error) {
+	return runFilter(z.Command, data, "--decompress", "--quiet", "--stdout")
+}
+
+// runFilter runs command with args, passing input as its standard input, and
+// returns its standard output.
+func runFilter(command string, input []byte, args ...string) ([]byte, error) {
+	//nolint:gosec
+	cmd := exec.Command(command, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	stdout := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
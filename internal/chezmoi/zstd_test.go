@@ -0,0 +1,27 @@
+package chezmoi
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZstd(t *testing.T) {
+	command, err := exec.LookPath("zstd")
+	if err != nil {
+		t.Skip("zstd not found in $PATH")
+	}
+
+	z := &Zstd{
+		Command: command,
+	}
+	contents := []byte("contents\n")
+	compressed, err := z.Compress(contents)
+	require.NoError(t, err)
+	assert.NotEqual(t, contents, compressed)
+	actualContents, err := z.Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, contents, actualContents)
+}